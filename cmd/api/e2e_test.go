@@ -32,7 +32,8 @@ func TestMvpE2EFlow(t *testing.T) {
 		"start_date":   "2025-12-01",
 		"end_date":     "2025-12-01",
 		"run_analysis": true,
-	}, http.StatusOK)
+		"symbols":      []string{"BTCUSDT"},
+	}, http.StatusAccepted)
 
 	userToken := login(t, ts, "user@example.com", "password123")
 	getJSON(t, ts, "/api/analysis/summary", userToken, http.StatusOK)
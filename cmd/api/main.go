@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"ai-auto-trade/internal/infrastructure/config"
@@ -43,9 +46,37 @@ func main() {
 	}
 
 	apiServer := httpapi.NewServer(cfg, pool)
-	addr := cfg.HTTP.Addr
-	log.Printf("starting HTTP server on %s", addr)
-	if err := http.ListenAndServe(addr, apiServer.Handler()); err != nil {
-		log.Fatalf("server stopped: %v", err)
+	srv := &http.Server{Addr: cfg.HTTP.Addr, Handler: apiServer.Handler()}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("starting HTTP server on %s", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("server stopped: %v", err)
+		}
+	case <-sigCtx.Done():
+		log.Printf("shutdown signal received, draining (timeout %v)", cfg.HTTP.ShutdownTimeout)
+	}
+	stop()
+
+	// 先停止接受新請求並等待進行中的請求，再停止背景工作並等待下單寫完
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http shutdown: %v", err)
+	}
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("background shutdown: %v", err)
 	}
+	log.Printf("shutdown complete")
+	_ = os.Stdout.Sync()
+	_ = os.Stderr.Sync()
 }
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+	"ai-auto-trade/internal/infrastructure/config"
+	"ai-auto-trade/internal/infrastructure/db"
+	"ai-auto-trade/internal/infrastructure/persistence/postgres"
+)
+
+const usage = `用法:
+  candles import -timeframe 1h [-symbol BTCUSDT] [-tz Asia/Taipei] [-columns "time=Date,close=Adj Close"] file.csv
+  candles export -symbol BTCUSDT -timeframe 1h -from 2024-01-01 [-to 2024-02-01] [-dataset prices|analysis] [-out file.csv]`
+
+// priceRepo 讓 postgres.Repo 相容 dataingestion.PriceRepository：先確保交易對存在再寫入 K 線。
+type priceRepo struct {
+	repo *postgres.Repo
+}
+
+func (p priceRepo) UpsertDailyPrice(ctx context.Context, price dataDomain.DailyPrice, _ bool) error {
+	stockID, err := p.repo.UpsertTradingPair(ctx, price.Symbol, price.Symbol, price.Market, "Crypto")
+	if err != nil {
+		return err
+	}
+	return p.repo.InsertDailyPrice(ctx, stockID, price)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch os.Args[1] {
+	case "import":
+		runImport(ctx, os.Args[2:])
+	case "export":
+		runExport(ctx, os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func connect(ctx context.Context, cfgPath string) (config.Config, *postgres.Repo, *postgres.QualityStore) {
+	cfg, err := config.LoadFromFile(cfgPath)
+	if err != nil {
+		log.Fatalf("讀取組態失敗: %v", err)
+	}
+	conn, err := db.ConnectGORM(ctx, cfg.DB)
+	if err != nil {
+		log.Fatalf("連線資料庫失敗: %v", err)
+	}
+	if conn == nil {
+		log.Fatal("config.db.dsn 未設定，無法匯入或匯出")
+	}
+	return cfg, postgres.NewRepo(conn), postgres.NewQualityStore(conn)
+}
+
+func runImport(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	cfgPath := fs.String("config", "config.yaml", "path to config file")
+	format := fs.String("format", "", "csv 或 parquet，預設依副檔名判斷")
+	symbol := fs.String("symbol", "", "檔案沒有 symbol 欄位時使用的交易對")
+	market := fs.String("market", string(dataDomain.MarketCrypto), "市場別")
+	timeframe := fs.String("timeframe", "", "K 線週期，例如 1h、1d")
+	tz := fs.String("tz", "UTC", "沒有時區資訊的時間欄位所屬時區")
+	layout := fs.String("layout", "", "時間欄位的 Go layout，預設自動判斷")
+	columns := fs.String("columns", "", "欄位對應，例如 time=Date,close=Adj Close")
+	skipQuality := fs.Bool("skip-quality", false, "不做資料品質檢查")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("請指定一個匯入檔案")
+	}
+	path := fs.Arg(0)
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatalf("時區不正確: %v", err)
+	}
+	mapping, err := dataingestion.ParseColumnMapping(*columns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *format == "" {
+		*format = dataingestion.FormatFromName(path)
+	}
+	opts := dataingestion.ImportOptions{
+		Format:     strings.ToLower(*format),
+		Symbol:     strings.ToUpper(*symbol),
+		Market:     dataDomain.Market(strings.ToUpper(*market)),
+		Timeframe:  strings.ToLower(*timeframe),
+		Location:   loc,
+		TimeLayout: *layout,
+		Columns:    mapping,
+	}
+	if err := opts.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("開啟檔案失敗: %v", err)
+	}
+	defer f.Close()
+
+	cfg, repo, qualityStore := connect(ctx, *cfgPath)
+	uc := dataingestion.NewImportUseCase(priceRepo{repo: repo})
+	if !*skipQuality {
+		uc.SetQualityGate(dataingestion.NewQualityGate(repo, qualityStore, dataDomain.QualityRules{
+			OutlierSigma:     cfg.Ingestion.Quality.OutlierSigma,
+			VolatilityWindow: cfg.Ingestion.Quality.VolatilityWindow,
+		}))
+	}
+	res, err := uc.Import(ctx, f, opts)
+	for _, fail := range res.Failures {
+		log.Printf("第 %d 行: %s", fail.Line, fail.Reason)
+	}
+	if res.Failed > len(res.Failures) {
+		log.Printf("另有 %d 行失敗未列出", res.Failed-len(res.Failures))
+	}
+	if err != nil {
+		log.Fatalf("匯入中止（已寫入 %d 筆）: %v", res.Stored, err)
+	}
+	fmt.Printf("匯入完成: 共 %d 行，寫入 %d，失敗 %d，隔離 %d\n", res.Rows, res.Stored, res.Failed, res.Quarantined)
+}
+
+func runExport(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	cfgPath := fs.String("config", "config.yaml", "path to config file")
+	dataset := fs.String("dataset", dataingestion.DatasetPrices, "prices 或 analysis")
+	format := fs.String("format", dataingestion.FormatCSV, "輸出格式")
+	symbol := fs.String("symbol", "", "交易對")
+	timeframe := fs.String("timeframe", "1d", "K 線週期")
+	from := fs.String("from", "", "起始時間（含），RFC3339 或 YYYY-MM-DD")
+	to := fs.String("to", "", "結束時間（不含），預設現在")
+	tz := fs.String("tz", "UTC", "日期參數與輸出時間的時區")
+	out := fs.String("out", "", "輸出檔案，預設標準輸出")
+	_ = fs.Parse(args)
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatalf("時區不正確: %v", err)
+	}
+	req := dataingestion.ExportRequest{
+		Dataset:   *dataset,
+		Format:    strings.ToLower(*format),
+		Symbol:    strings.ToUpper(*symbol),
+		Timeframe: strings.ToLower(*timeframe),
+		To:        time.Now(),
+		Location:  loc,
+	}
+	if req.From, err = parseTime(*from, loc); err != nil {
+		log.Fatalf("from 不正確: %v", err)
+	}
+	if *to != "" {
+		if req.To, err = parseTime(*to, loc); err != nil {
+			log.Fatalf("to 不正確: %v", err)
+		}
+	}
+	if err := req.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("建立輸出檔失敗: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	_, repo, _ := connect(ctx, *cfgPath)
+	rows, err := dataingestion.NewExporter(repo).Export(ctx, bw, req)
+	if flushErr := bw.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log.Fatalf("匯出中止（已輸出 %d 筆）: %v", rows, err)
+	}
+	log.Printf("匯出完成: %d 筆", rows)
+}
+
+func parseTime(v string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, loc)
+}
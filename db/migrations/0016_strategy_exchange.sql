@@ -0,0 +1,4 @@
+-- Migration: Strategy Exchange Selection
+-- Description: Allow each strategy to select the exchange adapter (binance, bybit, ...) used for orders and prices.
+
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS exchange VARCHAR(32) NOT NULL DEFAULT 'binance';
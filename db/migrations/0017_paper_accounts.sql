@@ -0,0 +1,10 @@
+-- Migration: Paper Trading Accounts
+-- Description: Persist the virtual per-user balances used by the paper-trading broker.
+
+CREATE TABLE IF NOT EXISTS paper_balances (
+    account_id VARCHAR(64) NOT NULL,
+    asset      VARCHAR(16) NOT NULL,
+    amount     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, asset)
+);
@@ -0,0 +1,14 @@
+-- Migration: Kill Switch and Circuit Breakers
+-- Description: Persist the global kill switch and per-strategy circuit breaker state so halts survive restarts.
+
+CREATE TABLE IF NOT EXISTS trading_halts (
+    scope              VARCHAR(128) PRIMARY KEY, -- 'global' 或 'strategy:<id>:<env>'
+    halted             BOOLEAN NOT NULL DEFAULT FALSE,
+    reason             TEXT,
+    consecutive_losses INTEGER NOT NULL DEFAULT 0,
+    consecutive_errors INTEGER NOT NULL DEFAULT 0,
+    equity             DOUBLE PRECISION NOT NULL DEFAULT 0,
+    peak_equity        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    halted_at          TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
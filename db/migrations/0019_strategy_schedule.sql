@@ -0,0 +1,5 @@
+-- Migration: Strategy Schedules
+-- Description: Optional cron schedule and trading window per strategy (empty = evaluate on every bar close).
+
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS schedule VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS trading_window VARCHAR(64) NOT NULL DEFAULT '';
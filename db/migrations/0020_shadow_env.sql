@@ -0,0 +1,11 @@
+-- Migration: Shadow Environment
+-- Description: Allow the shadow environment, which records signals and hypothetical fills without trading.
+
+ALTER TABLE strategy_trades DROP CONSTRAINT IF EXISTS chk_strategy_trades_env;
+ALTER TABLE strategy_trades ADD CONSTRAINT chk_strategy_trades_env CHECK (env IN ('test', 'prod', 'real', 'paper', 'both', 'shadow'));
+
+ALTER TABLE strategy_positions DROP CONSTRAINT IF EXISTS chk_strategy_positions_env;
+ALTER TABLE strategy_positions ADD CONSTRAINT chk_strategy_positions_env CHECK (env IN ('test', 'prod', 'real', 'paper', 'both', 'shadow'));
+
+ALTER TABLE strategies DROP CONSTRAINT IF EXISTS chk_strategy_env;
+ALTER TABLE strategies ADD CONSTRAINT chk_strategy_env CHECK (env IN ('test', 'prod', 'real', 'paper', 'both', 'shadow'));
@@ -0,0 +1,21 @@
+-- Migration: DCA and Grid Strategy Kinds
+-- Description: Strategy kind with kind-specific JSON params, and the resting limit orders of grid strategies.
+
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'scoring';
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS kind_params JSONB;
+
+CREATE TABLE IF NOT EXISTS grid_orders (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    strategy_id UUID NOT NULL REFERENCES strategies(id) ON DELETE CASCADE,
+    env         VARCHAR(16) NOT NULL,
+    level       INTEGER NOT NULL,      -- 網格價位索引（0 = 下緣）
+    side        VARCHAR(8) NOT NULL,   -- buy / sell
+    price       DOUBLE PRECISION NOT NULL,
+    qty         DOUBLE PRECISION NOT NULL,
+    order_id    VARCHAR(64) NOT NULL,  -- 交易所（或 paper/shadow）訂單編號
+    status      VARCHAR(16) NOT NULL,  -- NEW / FILLED / CANCELED
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_grid_orders_open ON grid_orders (strategy_id, env) WHERE status = 'NEW';
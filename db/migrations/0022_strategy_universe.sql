@@ -0,0 +1,13 @@
+-- Migration: Strategy Symbol Universe
+-- Description: Let a strategy rotate across a universe of symbols (fixed list or top-N by volume) and hold up to max_positions of them.
+
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS universe JSONB;
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS max_positions INTEGER NOT NULL DEFAULT 1;
+
+-- 同一策略可同時持有多個交易對：未平倉唯一鍵改為 (strategy_id, env, symbol)
+DROP INDEX IF EXISTS idx_strategy_positions_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_strategy_positions_open_symbol
+ON strategy_positions(strategy_id, env, symbol)
+WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS idx_daily_prices_timeframe_date ON daily_prices (timeframe, trade_date);
@@ -0,0 +1,15 @@
+-- Migration: Bar Open Timestamps
+-- Description: Store the bar open time instead of a calendar date so intraday klines (1m/15m/1h/4h) of the same day no longer collide on the unique keys.
+
+-- migrate 每次都會執行全部檔案，僅在欄位仍為 DATE 時轉換；既有日 K 視為 UTC 00:00 開盤
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_name = 'daily_prices' AND column_name = 'trade_date') = 'date' THEN
+        ALTER TABLE daily_prices ALTER COLUMN trade_date TYPE TIMESTAMPTZ USING trade_date::timestamp AT TIME ZONE 'UTC';
+    END IF;
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_name = 'analysis_results' AND column_name = 'trade_date') = 'date' THEN
+        ALTER TABLE analysis_results ALTER COLUMN trade_date TYPE TIMESTAMPTZ USING trade_date::timestamp AT TIME ZONE 'UTC';
+    END IF;
+END $$;
@@ -0,0 +1,16 @@
+-- Migration: Job Tracking
+-- Description: Record every scheduled, manual and backfill run in ingestion_jobs/analysis_jobs with per-symbol items keyed by symbol and timeframe.
+
+-- 加密貨幣交易對以 symbol 記錄，不一定有 stocks 對應
+ALTER TABLE ingestion_job_items ADD COLUMN IF NOT EXISTS symbol VARCHAR(32);
+
+ALTER TABLE analysis_job_items ADD COLUMN IF NOT EXISTS symbol VARCHAR(32);
+ALTER TABLE analysis_job_items ADD COLUMN IF NOT EXISTS timeframe VARCHAR(16) NOT NULL DEFAULT '1d';
+ALTER TABLE analysis_job_items ADD COLUMN IF NOT EXISTS trade_date DATE;
+ALTER TABLE analysis_job_items ALTER COLUMN stock_id DROP NOT NULL;
+
+-- 一次執行以 ingestion_jobs 為主檔，分析階段關聯到同一筆
+ALTER TABLE analysis_jobs ADD COLUMN IF NOT EXISTS ingestion_job_id UUID REFERENCES ingestion_jobs(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_started ON ingestion_jobs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_ingestion_job ON analysis_jobs(ingestion_job_id);
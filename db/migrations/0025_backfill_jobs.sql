@@ -0,0 +1,22 @@
+-- Migration: Backfill Jobs
+-- Description: Persist asynchronous backfill jobs with a JSON checkpoint (per symbol/timeframe gaps and progress) so interrupted backfills resume after a restart.
+
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    status        VARCHAR(16) NOT NULL,
+    phase         VARCHAR(16) NOT NULL,
+    triggered_by  VARCHAR(64),
+    symbols       JSONB NOT NULL,
+    timeframes    JSONB NOT NULL,
+    start_time    TIMESTAMPTZ NOT NULL,
+    end_time      TIMESTAMPTZ NOT NULL,
+    run_analysis  BOOLEAN NOT NULL DEFAULT FALSE,
+    checkpoint    JSONB,
+    error         TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_status ON backfill_jobs(status, created_at);
@@ -0,0 +1,33 @@
+-- Migration: Data Quality
+-- Description: Quarantine candles that fail data-quality checks (gaps, duplicates, OHLC inconsistency, outlier returns, stale bars) for manual review, and track whether the latest bar of each symbol/timeframe passed so strategies can refuse to run on bad data.
+
+CREATE TABLE IF NOT EXISTS quarantined_bars (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol       VARCHAR(32) NOT NULL,
+    timeframe    VARCHAR(8) NOT NULL,
+    market_type  VARCHAR(16) NOT NULL DEFAULT 'CRYPTO',
+    bar_time     TIMESTAMPTZ NOT NULL,
+    open_price   NUMERIC(20,8) NOT NULL,
+    high_price   NUMERIC(20,8) NOT NULL,
+    low_price    NUMERIC(20,8) NOT NULL,
+    close_price  NUMERIC(20,8) NOT NULL,
+    volume       BIGINT NOT NULL DEFAULT 0,
+    issues       JSONB NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at  TIMESTAMPTZ,
+    resolved_by  VARCHAR(64),
+    UNIQUE (symbol, timeframe, bar_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantined_bars_status ON quarantined_bars(status, created_at);
+
+CREATE TABLE IF NOT EXISTS data_quality_status (
+    symbol         VARCHAR(32) NOT NULL,
+    timeframe      VARCHAR(8) NOT NULL,
+    last_bar_time  TIMESTAMPTZ NOT NULL,
+    passed         BOOLEAN NOT NULL,
+    issues         JSONB,
+    checked_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (symbol, timeframe)
+);
@@ -0,0 +1,26 @@
+-- Migration: Analysis Versions
+-- Description: Register analysis versions so history can be recomputed under a new formula side by side with the old one, mark the version that live reads and the pipeline use, and let strategies pin the version they evaluate.
+
+CREATE TABLE IF NOT EXISTS analysis_versions (
+    name         VARCHAR(64) PRIMARY KEY,
+    description  TEXT NOT NULL DEFAULT '',
+    is_active    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 同一時間只能有一個啟用中的版本
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analysis_versions_active ON analysis_versions(is_active) WHERE is_active;
+
+-- 既有結果皆為 v1-mvp；其他已寫入的版本一併註冊但不啟用
+INSERT INTO analysis_versions (name, description, is_active)
+VALUES ('v1-mvp', 'MVP 指標與線性分數', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO analysis_versions (name)
+SELECT DISTINCT analysis_version FROM analysis_results
+ON CONFLICT (name) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_analysis_results_version ON analysis_results(analysis_version, timeframe, trade_date);
+
+-- 空值代表跟隨啟用中的版本
+ALTER TABLE strategies ADD COLUMN IF NOT EXISTS analysis_version VARCHAR(64);
@@ -0,0 +1,5 @@
+-- Migration: Analysis Score Models
+-- Description: Let each analysis version name the JSON score model file it scores with, so an offline-trained model can be swapped in by registering a new version instead of changing code.
+
+-- 空字串代表內建的線性公式
+ALTER TABLE analysis_versions ADD COLUMN IF NOT EXISTS score_model VARCHAR(255) NOT NULL DEFAULT '';
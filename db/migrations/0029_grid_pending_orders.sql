@@ -0,0 +1,5 @@
+-- Migration: Pending Grid Orders
+-- Description: Grid counter-orders are stored as PENDING before they are sent, so a failed placement is retried on the next run; the open-order index covers them too.
+
+DROP INDEX IF EXISTS idx_grid_orders_open;
+CREATE INDEX IF NOT EXISTS idx_grid_orders_open ON grid_orders (strategy_id, env) WHERE status IN ('NEW', 'PENDING');
@@ -0,0 +1,5 @@
+-- Migration: Analysis Amplitude
+-- Description: Persist the bar amplitude and its 20-bar average with each analysis result, so score models and strategies see the same features when reading stored results as when the analysis ran.
+
+ALTER TABLE analysis_results ADD COLUMN IF NOT EXISTS amplitude NUMERIC(9,4);
+ALTER TABLE analysis_results ADD COLUMN IF NOT EXISTS avg_amplitude_20d NUMERIC(9,4);
@@ -0,0 +1,8 @@
+-- Migration: Position Owner
+-- Description: Record the owning user on each position so per-user limits count manual positions too; strategy positions are backfilled from their strategy.
+
+ALTER TABLE strategy_positions ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE strategy_positions p SET user_id = s.user_id FROM strategies s WHERE p.strategy_id = s.id AND p.user_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_strategy_positions_user_open ON strategy_positions(user_id) WHERE status = 'open';
@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -10,9 +11,12 @@ import (
 	"ai-auto-trade/internal/domain/dataingestion"
 )
 
-// PriceHistoryProvider 取得股票歷史日 K。
+// DefaultVersion 為目前指標算法的版本標記，寫入 analysis_results.analysis_version。
+const DefaultVersion = "v1-mvp"
+
+// PriceHistoryProvider 取得單一週期的歷史 K 線（依時間遞增，最後一根不晚於 endDate）。
 type PriceHistoryProvider interface {
-	GetHistory(ctx context.Context, symbol string, endDate time.Time, lookback int) ([]dataingestion.DailyPrice, error)
+	GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataingestion.DailyPrice, error)
 }
 
 // BasicInfoProvider 取得股票基本資料。
@@ -34,10 +38,12 @@ type BasicInfo struct {
 
 type AnalyzeInput struct {
 	TradeDate    time.Time
-	Symbols      []string // 若為空則由 BasicInfoProvider 回傳預設清單
-	LookbackDays int      // 不含當日，預設 120
-	Replace      bool     // 目前保留，未使用；預留重跑覆蓋策略
-	Version      string   // 分析版本，可追蹤算法
+	Timeframe    string    // K 線週期，預設 1d；日內週期會分析當日（含前一日）所有 K 線
+	Symbols      []string  // 若為空則由 BasicInfoProvider 回傳預設清單
+	LookbackDays int       // 不含當根的回看根數，預設 120
+	Until        time.Time // 非零時一併分析 TradeDate 之後直到 Until（不含）的 K 線，例如回補多日時只讀一次歷史
+	Replace      bool      // 目前保留，未使用；預留重跑覆蓋策略
+	Version      string    // 分析版本，可追蹤算法，預設為啟用中的版本
 }
 
 type Failure struct {
@@ -45,16 +51,39 @@ type Failure struct {
 	Reason string
 }
 
+// Item 為單一交易對的分析結果，供 job 明細記錄。
+type Item struct {
+	Symbol    string
+	Timeframe string
+	Success   int
+	Failed    int
+	Reason    string // 第一個失敗原因
+	Duration  time.Duration
+}
+
 type AnalyzeResult struct {
 	SuccessCount int
 	FailedCount  int
 	Failures     []Failure
+	Items        []Item
+}
+
+// fail 記錄一筆失敗並同步到交易對明細。
+func (r *AnalyzeResult) fail(item *Item, reason string) {
+	r.FailedCount++
+	r.Failures = append(r.Failures, Failure{Symbol: item.Symbol, Reason: reason})
+	item.Failed++
+	if item.Reason == "" {
+		item.Reason = reason
+	}
 }
 
 type AnalyzeUseCase struct {
 	basicProvider   BasicInfoProvider
 	historyProvider PriceHistoryProvider
 	repo            AnalysisRepository
+	versions        VersionStore
+	models          ScoreModelLoader
 }
 
 // NewAnalyzeUseCase 建立日批次分析用例，串接基本資料、歷史價格與儲存介面。
@@ -66,6 +95,41 @@ func NewAnalyzeUseCase(basicProvider BasicInfoProvider, historyProvider PriceHis
 	}
 }
 
+// SetVersions 讓未指定版本的分析寫入註冊表中啟用中的版本；未設定時使用 DefaultVersion。
+func (u *AnalyzeUseCase) SetVersions(store VersionStore) {
+	u.versions = store
+}
+
+// SetScoreModels 設定分數模型的載入來源，版本指定 ScoreModel 時由此載入；未指定的版本使用 RuleScoreModel。
+func (u *AnalyzeUseCase) SetScoreModels(loader ScoreModelLoader) {
+	u.models = loader
+}
+
+// scoreModel 回傳版本使用的分數模型；未註冊的版本（例如未設定註冊表時）使用 RuleScoreModel。
+func (u *AnalyzeUseCase) scoreModel(ctx context.Context, version string) (ScoreModel, error) {
+	if u.versions == nil {
+		return RuleScoreModel{}, nil
+	}
+	v, err := u.versions.GetVersion(ctx, version)
+	if errors.Is(err, ErrVersionNotFound) {
+		return RuleScoreModel{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.loadScoreModel(v.ScoreModel)
+}
+
+func (u *AnalyzeUseCase) loadScoreModel(name string) (ScoreModel, error) {
+	if name == "" {
+		return RuleScoreModel{}, nil
+	}
+	if u.models == nil {
+		return nil, fmt.Errorf("score model %q: no model loader configured", name)
+	}
+	return u.models.Load(name)
+}
+
 func (u *AnalyzeUseCase) Execute(ctx context.Context, input AnalyzeInput) (AnalyzeResult, error) {
 	var result AnalyzeResult
 
@@ -75,6 +139,31 @@ func (u *AnalyzeUseCase) Execute(ctx context.Context, input AnalyzeInput) (Analy
 	if input.LookbackDays <= 0 {
 		input.LookbackDays = 120
 	}
+	if input.Timeframe == "" {
+		input.Timeframe = "1d"
+	}
+	if input.Version == "" {
+		input.Version = DefaultVersion
+		if u.versions != nil {
+			active, err := ActiveVersion(ctx, u.versions)
+			if err != nil {
+				return result, fmt.Errorf("resolve analysis version: %w", err)
+			}
+			input.Version = active
+		}
+	}
+	model, err := u.scoreModel(ctx, input.Version)
+	if err != nil {
+		return result, fmt.Errorf("load score model for %s: %w", input.Version, err)
+	}
+	from, to, bars, err := analysisWindow(input.TradeDate, input.Timeframe)
+	if err != nil {
+		return result, err
+	}
+	if input.Until.After(to) {
+		bars += int(input.Until.Sub(to) / dataingestion.TimeframeDuration(input.Timeframe))
+		to = input.Until
+	}
 
 	basicList, err := u.basicProvider.ListBasicInfo(ctx, input.Symbols, input.TradeDate)
 	if err != nil {
@@ -82,95 +171,159 @@ func (u *AnalyzeUseCase) Execute(ctx context.Context, input AnalyzeInput) (Analy
 	}
 
 	for _, info := range basicList {
-		if info.Symbol == "" {
-			result.FailedCount++
-			result.Failures = append(result.Failures, Failure{Reason: "missing symbol"})
-			continue
+		if err := ctx.Err(); err != nil {
+			return result, err
 		}
+		started := time.Now()
+		item := Item{Symbol: info.Symbol, Timeframe: input.Timeframe}
+		u.analyzeSymbol(ctx, input, model, info, from, to, bars, &result, &item)
+		item.Duration = time.Since(started)
+		result.Items = append(result.Items, item)
+	}
 
-		history, err := u.historyProvider.GetHistory(ctx, info.Symbol, input.TradeDate, input.LookbackDays)
-		if err != nil {
-			result.FailedCount++
-			result.Failures = append(result.Failures, Failure{Symbol: info.Symbol, Reason: fmt.Sprintf("history error: %v", err)})
+	return result, nil
+}
+
+// analyzeSymbol 分析單一交易對在 [from, to) 內的所有 K 線，結果累計到 result 與 item；
+// 歷史只讀取一次（區間前 LookbackDays 根用於暖機）。
+func (u *AnalyzeUseCase) analyzeSymbol(ctx context.Context, input AnalyzeInput, model ScoreModel, info BasicInfo, from, to time.Time, bars int, result *AnalyzeResult, item *Item) {
+	if info.Symbol == "" {
+		result.fail(item, "missing symbol")
+		return
+	}
+
+	history, err := u.historyProvider.GetHistory(ctx, info.Symbol, input.Timeframe, to.Add(-time.Nanosecond), input.LookbackDays+bars)
+	if err != nil {
+		result.fail(item, fmt.Sprintf("history error: %v", err))
+		return
+	}
+
+	// 依序滾動計算，每根 K 線 O(1)；結果與 analyzeOne 對 LookbackDays 視窗重算相同
+	state := newIndicatorState(input.LookbackDays)
+	analyzed := 0
+	for _, bar := range history {
+		ind := state.push(bar)
+		if bar.TradeDate.Before(from) || !bar.TradeDate.Before(to) {
 			continue
 		}
-
-		analysisRes, err := analyzeOne(info, input.TradeDate, history, input.Version)
+		analysisRes, err := buildResult(info, bar, ind, input.Version, model)
+		analyzed++
 		if err != nil {
-			result.FailedCount++
-			result.Failures = append(result.Failures, Failure{Symbol: info.Symbol, Reason: err.Error()})
+			result.fail(item, err.Error())
 			continue
 		}
 
 		if err := u.repo.SaveDailyResult(ctx, analysisRes); err != nil {
-			result.FailedCount++
-			result.Failures = append(result.Failures, Failure{Symbol: info.Symbol, Reason: fmt.Sprintf("store failed: %v", err)})
+			result.fail(item, fmt.Sprintf("store failed: %v", err))
 			continue
 		}
-
 		result.SuccessCount++
+		item.Success++
+	}
+	if analyzed == 0 {
+		result.fail(item, "no bar for trade date")
 	}
-
-	return result, nil
 }
 
-func analyzeOne(info BasicInfo, tradeDate time.Time, history []dataingestion.DailyPrice, version string) (domain.DailyAnalysisResult, error) {
-	var res domain.DailyAnalysisResult
+// analysisWindow 回傳需分析的 K 線開盤時間區間 [from, to) 與區間內最多幾根。
+// 日 K 只分析當日；日內週期一併重算前一日，涵蓋上次排程後才收盤的 K 線。
+func analysisWindow(tradeDate time.Time, timeframe string) (time.Time, time.Time, int, error) {
+	step := dataingestion.TimeframeDuration(timeframe)
+	if step <= 0 {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("unsupported timeframe %q", timeframe)
+	}
+	from := tradeDate.UTC().Truncate(24 * time.Hour)
+	to := from.Add(max(step, 24*time.Hour))
+	if step < 24*time.Hour {
+		from = from.Add(-24 * time.Hour)
+	}
+	return from, to, int(to.Sub(from) / step), nil
+}
 
+// analyzeOne 以 history（最後一根為 tradeDate 的 K 線）整段重算指標，為 indicatorState 滾動計算的對照。
+func analyzeOne(info BasicInfo, tradeDate time.Time, history []dataingestion.DailyPrice, version string, model ScoreModel) (domain.DailyAnalysisResult, error) {
 	if len(history) == 0 {
-		return res, fmt.Errorf("no history data")
+		return domain.DailyAnalysisResult{}, fmt.Errorf("no history data")
 	}
 
 	latest := history[len(history)-1]
 	if !sameDate(latest.TradeDate, tradeDate) {
-		return res, fmt.Errorf("latest trade date mismatch")
+		return domain.DailyAnalysisResult{}, fmt.Errorf("latest trade date mismatch")
 	}
 
-	res = domain.DailyAnalysisResult{
+	var ind indicators
+	if len(history) >= 2 {
+		ind.prevClose = ptr(history[len(history)-2].Close)
+	}
+	ind.return5 = pctReturn(history, 5)
+	ind.return20 = pctReturn(history, 20)
+	ind.return60 = pctReturn(history, 60)
+	ind.ma5 = movingAverage(history, 5)
+	ind.ma10 = movingAverage(history, 10)
+	ind.ma20 = movingAverage(history, 20)
+	ind.ma60 = movingAverage(history, 60)
+	ind.high20, ind.low20, _ = highLowRange(history, 20, latest.Close)
+	ind.avgVolume5 = avgVolume(history, 5)
+	ind.avgVolume20 = avgVolume(history, 20)
+	ind.avgAmplitude20 = avgAmplitude(history, 20)
+	return buildResult(info, latest, ind, version, model)
+}
+
+// buildResult 由原始指標組出分析結果，並計算衍生欄位、標籤與 model 的分數。
+func buildResult(info BasicInfo, latest dataingestion.DailyPrice, ind indicators, version string, model ScoreModel) (domain.DailyAnalysisResult, error) {
+	timeframe := latest.Timeframe
+	if timeframe == "" {
+		timeframe = "1d"
+	}
+	res := domain.DailyAnalysisResult{
 		Symbol:    info.Symbol,
 		Market:    info.Market,
+		Timeframe: timeframe,
 		Industry:  info.Industry,
-		TradeDate: tradeDate,
+		TradeDate: latest.TradeDate,
 		Version:   version,
 		Close:     latest.Close,
 		Volume:    latest.Volume,
 		Success:   true,
 	}
 
-	if len(history) >= 2 {
-		prev := history[len(history)-2]
-		res.Change = latest.Close - prev.Close
-		if prev.Close > 0 {
-			res.ChangeRate = res.Change / prev.Close
+	if ind.prevClose != nil {
+		prev := *ind.prevClose
+		res.Change = latest.Close - prev
+		if prev > 0 {
+			res.ChangeRate = res.Change / prev
 		}
-		res.Amplitude = ptr(amplitude(latest, prev.Close))
+		res.Amplitude = ptr(amplitude(latest, prev))
 	}
 
-	res.Return5 = pctReturn(history, 5)
-	res.Return20 = pctReturn(history, 20)
-	res.Return60 = pctReturn(history, 60)
+	res.Return5 = ind.return5
+	res.Return20 = ind.return20
+	res.Return60 = ind.return60
 
-	res.MA5 = movingAverage(history, 5)
-	res.MA10 = movingAverage(history, 10)
-	res.MA20 = movingAverage(history, 20)
-	res.MA60 = movingAverage(history, 60)
+	res.MA5 = ind.ma5
+	res.MA10 = ind.ma10
+	res.MA20 = ind.ma20
+	res.MA60 = ind.ma60
 
 	if res.MA20 != nil && *res.MA20 > 0 {
 		res.Deviation20 = ptr((latest.Close - *res.MA20) / *res.MA20)
 	}
 
-	res.High20, res.Low20, res.RangePos20 = highLowRange(history, 20, latest.Close)
+	if ind.high20 != nil && ind.low20 != nil {
+		res.High20, res.Low20 = ind.high20, ind.low20
+		res.RangePos20 = ptr(rangePosition(latest.Close, *ind.high20, *ind.low20))
+	}
 
-	res.AvgVolume5 = avgVolume(history, 5)
-	res.AvgVolume20 = avgVolume(history, 20)
+	res.AvgVolume5 = ind.avgVolume5
+	res.AvgVolume20 = ind.avgVolume20
 	if res.AvgVolume20 != nil && *res.AvgVolume20 > 0 {
 		res.VolumeMultiple = ptr(float64(latest.Volume) / *res.AvgVolume20)
 	}
 
-	res.AvgAmplitude20 = avgAmplitude(history, 20)
+	res.AvgAmplitude20 = ind.avgAmplitude20
 
 	res.Tags = buildTags(res)
-	res.Score = buildScore(res)
+	res.Score = model.Score(res)
 
 	if err := res.Validate(); err != nil {
 		res.Success = false
@@ -185,11 +338,11 @@ func movingAverage(history []dataingestion.DailyPrice, window int) *float64 {
 	if len(history) < window {
 		return nil
 	}
-	sum := 0.0
+	var sum exactSum
 	for i := len(history) - window; i < len(history); i++ {
-		sum += history[i].Close
+		sum.Add(history[i].Close)
 	}
-	avg := sum / float64(window)
+	avg := sum.Value() / float64(window)
 	return &avg
 }
 
@@ -197,12 +350,14 @@ func pctReturn(history []dataingestion.DailyPrice, window int) *float64 {
 	if len(history) < window+1 {
 		return nil
 	}
-	current := history[len(history)-1]
-	base := history[len(history)-1-window]
-	if base.Close == 0 {
+	return returnBetween(history[len(history)-1-window].Close, history[len(history)-1].Close)
+}
+
+func returnBetween(base, current float64) *float64 {
+	if base == 0 {
 		return nil
 	}
-	r := (current.Close / base.Close) - 1
+	r := (current / base) - 1
 	return &r
 }
 
@@ -224,22 +379,27 @@ func highLowRange(history []dataingestion.DailyPrice, window int, close float64)
 	if h == -math.MaxFloat64 || l == math.MaxFloat64 {
 		return nil, nil, nil
 	}
-	rangePos := 0.0
-	if h != l {
-		rangePos = (close - l) / (h - l)
-	}
+	rangePos := rangePosition(close, h, l)
 	return &h, &l, &rangePos
 }
 
+// rangePosition 回傳收盤在區間高低點之間的位置（0..1）。
+func rangePosition(close, high, low float64) float64 {
+	if high == low {
+		return 0
+	}
+	return (close - low) / (high - low)
+}
+
 func avgVolume(history []dataingestion.DailyPrice, window int) *float64 {
 	if len(history) < window {
 		return nil
 	}
-	var sum float64
+	var sum int64
 	for i := len(history) - window; i < len(history); i++ {
-		sum += float64(history[i].Volume)
+		sum += history[i].Volume
 	}
-	avg := sum / float64(window)
+	avg := float64(sum) / float64(window)
 	return &avg
 }
 
@@ -254,12 +414,12 @@ func avgAmplitude(history []dataingestion.DailyPrice, window int) *float64 {
 	if len(history) < window+1 { // 需要前一天收盤價
 		return nil
 	}
-	var sum float64
+	var sum exactSum
 	for i := len(history) - window; i < len(history); i++ {
 		prevClose := history[i-1].Close
-		sum += amplitude(history[i], prevClose)
+		sum.Add(amplitude(history[i], prevClose))
 	}
-	avg := sum / float64(window)
+	avg := sum.Value() / float64(window)
 	return &avg
 }
 
@@ -291,25 +451,6 @@ func buildTags(res domain.DailyAnalysisResult) []domain.Tag {
 	return tags
 }
 
-func buildScore(res domain.DailyAnalysisResult) float64 {
-	score := 50.0
-
-	if res.Return5 != nil {
-		score += clamp(*res.Return5*100, -20, 20) * 0.5
-	}
-	if res.Return20 != nil {
-		score += clamp(*res.Return20*100, -40, 40) * 0.4
-	}
-	if res.VolumeMultiple != nil {
-		score += clamp((*res.VolumeMultiple-1)*10, -10, 15)
-	}
-	if res.RangePos20 != nil {
-		score += (*res.RangePos20 - 0.5) * 10
-	}
-
-	return score
-}
-
 func clamp(v, min, max float64) float64 {
 	if v < min {
 		return min
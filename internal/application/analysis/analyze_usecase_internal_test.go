@@ -25,7 +25,7 @@ func TestAnalyzeOne_ScoreCalculation(t *testing.T) {
 	}
 
 	// Case 1: Neutral
-	res, _ := analyzeOne(info, tradeDate, history, "v1")
+	res, _ := analyzeOne(info, tradeDate, history, "v1", RuleScoreModel{})
 	if res.Score != 50.0 {
 		t.Errorf("Expected neutral score 50.0, got %f", res.Score)
 	}
@@ -36,7 +36,7 @@ func TestAnalyzeOne_ScoreCalculation(t *testing.T) {
 	// Modify latest (Day 20) volume surge
 	history[20].Volume = 2000 // Multiple = 2.0 (relative to avg 1000)
 	
-	res2, _ := analyzeOne(info, tradeDate, history, "v1")
+	res2, _ := analyzeOne(info, tradeDate, history, "v1", RuleScoreModel{})
 	if res2.Score <= 50.0 {
 		t.Errorf("Expected positive score > 50, got %f", res2.Score)
 	}
@@ -27,7 +27,7 @@ type fakeHistoryProvider struct {
 	err     error
 }
 
-func (f fakeHistoryProvider) GetHistory(_ context.Context, symbol string, _ time.Time, _ int) ([]dataingestion.DailyPrice, error) {
+func (f fakeHistoryProvider) GetHistory(_ context.Context, symbol, _ string, _ time.Time, _ int) ([]dataingestion.DailyPrice, error) {
 	if f.err != nil {
 		return nil, f.err
 	}
@@ -122,6 +122,9 @@ func TestAnalyzeUseCase_FailureOnHistory(t *testing.T) {
 	if res.SuccessCount != 0 || res.FailedCount != 1 {
 		t.Fatalf("unexpected result counts: %+v", res)
 	}
+	if len(res.Items) != 1 || res.Items[0].Symbol != "2330" || res.Items[0].Failed != 1 || res.Items[0].Reason != "history error: source down" {
+		t.Fatalf("expected a failed item per symbol, got %+v", res.Items)
+	}
 }
 
 func TestAnalyzeUseCase_RepoError(t *testing.T) {
@@ -151,6 +154,61 @@ func TestAnalyzeUseCase_RepoError(t *testing.T) {
 	}
 }
 
+func TestAnalyzeUseCase_CryptoIntradayBars(t *testing.T) {
+	tradeDate := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+	// 前一日 00:00 起共 40 根 1h K 線，最後一根為 12/2 15:00
+	start := tradeDate.Add(-24 * time.Hour)
+	var history []dataingestion.DailyPrice
+	for i := 0; i < 40; i++ {
+		close := 96000 + float64(i*10)
+		history = append(history, dataingestion.DailyPrice{
+			Symbol:    "BTCUSDT",
+			Market:    dataingestion.MarketCrypto,
+			Timeframe: "1h",
+			TradeDate: start.Add(time.Duration(i) * time.Hour),
+			Open:      close - 5,
+			High:      close + 20,
+			Low:       close - 20,
+			Close:     close,
+			Volume:    100,
+		})
+	}
+	older := dataingestion.DailyPrice{Symbol: "BTCUSDT", Market: dataingestion.MarketCrypto, Timeframe: "1h", TradeDate: start.Add(-time.Hour), Open: 1, High: 1, Low: 1, Close: 1}
+	history = append([]dataingestion.DailyPrice{older}, history...)
+
+	basicProvider := fakeBasicProvider{list: []BasicInfo{{Symbol: "BTCUSDT", Market: dataingestion.MarketCrypto}}}
+	historyProvider := fakeHistoryProvider{history: map[string][]dataingestion.DailyPrice{"BTCUSDT": history}}
+	repo := &fakeAnalysisRepo{}
+
+	res, err := NewAnalyzeUseCase(basicProvider, historyProvider, repo).Execute(context.Background(), AnalyzeInput{
+		TradeDate: tradeDate,
+		Timeframe: "1h",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SuccessCount != 40 || res.FailedCount != 0 {
+		t.Fatalf("expected every bar of the day and the previous day analysed, got %+v", res)
+	}
+	if len(res.Items) != 1 || res.Items[0].Success != 40 || res.Items[0].Timeframe != "1h" {
+		t.Fatalf("expected one item summarising the symbol, got %+v", res.Items)
+	}
+	last := repo.results[len(repo.results)-1]
+	if last.Timeframe != "1h" || !last.TradeDate.Equal(start.Add(39*time.Hour)) || last.Version != DefaultVersion {
+		t.Fatalf("unexpected last result %+v", last)
+	}
+	if last.MA5 == nil || last.MA10 == nil || last.MA20 == nil {
+		t.Fatalf("expected moving averages on crypto bars, got %+v", last)
+	}
+
+	if _, err := NewAnalyzeUseCase(basicProvider, historyProvider, repo).Execute(context.Background(), AnalyzeInput{
+		TradeDate: tradeDate,
+		Timeframe: "3h",
+	}); err == nil {
+		t.Fatalf("expected unsupported timeframe error")
+	}
+}
+
 func buildHistory(tradeDate time.Time, closes []float64, volumes []int64) []dataingestion.DailyPrice {
 	if len(closes) != len(volumes) {
 		panic("closes and volumes length mismatch")
@@ -182,3 +240,50 @@ func hasTag(tags []analysisDomain.Tag, target analysisDomain.Tag) bool {
 	}
 	return false
 }
+
+// countingHistory 記錄 GetHistory 的呼叫次數與請求根數。
+type countingHistory struct {
+	fakeHistoryProvider
+	calls    int
+	lookback int
+}
+
+func (c *countingHistory) GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataingestion.DailyPrice, error) {
+	c.calls++
+	c.lookback = lookback
+	return c.fakeHistoryProvider.GetHistory(ctx, symbol, timeframe, endDate, lookback)
+}
+
+func TestAnalyzeUseCase_UntilAnalysesRangeInOnePass(t *testing.T) {
+	history := randomWalk(24*40, 3)
+	for i := range history {
+		history[i].Timeframe = "1d"
+		history[i].TradeDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i)
+	}
+	basic := fakeBasicProvider{list: []BasicInfo{{Symbol: "BTCUSDT", Market: dataingestion.MarketCrypto}}}
+	hp := &countingHistory{fakeHistoryProvider: fakeHistoryProvider{history: map[string][]dataingestion.DailyPrice{"BTCUSDT": history}}}
+	ranged := &fakeAnalysisRepo{}
+
+	from := history[200].TradeDate
+	res, err := NewAnalyzeUseCase(basic, hp, ranged).Execute(context.Background(), AnalyzeInput{
+		TradeDate: from,
+		Until:     from.AddDate(0, 0, 30),
+	})
+	if err != nil || res.SuccessCount != 30 {
+		t.Fatalf("expected 30 days analysed, got %+v err=%v", res, err)
+	}
+	if hp.calls != 1 || hp.lookback != 120+30 {
+		t.Fatalf("expected a single history read covering warm-up and range, got %d calls for %d bars", hp.calls, hp.lookback)
+	}
+
+	// 與逐日分析的結果逐位元相同
+	for i, got := range ranged.results {
+		daily := &fakeAnalysisRepo{}
+		if _, err := NewAnalyzeUseCase(basic, hp, daily).Execute(context.Background(), AnalyzeInput{TradeDate: got.TradeDate}); err != nil {
+			t.Fatal(err)
+		}
+		if diff := diffResults(got, daily.results[0]); diff != "" {
+			t.Fatalf("day %d: %s", i, diff)
+		}
+	}
+}
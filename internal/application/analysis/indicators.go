@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"math"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// exactSum 以 Shewchuk 演算法維護互不重疊的部分和，加入與移除都沒有捨入誤差；
+// Value 回傳正確捨入的總和，只取決於目前的數值集合，與加入、移除的順序無關。
+type exactSum struct {
+	partials []float64
+}
+
+func (s *exactSum) Add(x float64) {
+	i := 0
+	for _, y := range s.partials {
+		if math.Abs(x) < math.Abs(y) {
+			x, y = y, x
+		}
+		hi := x + y
+		lo := y - (hi - x)
+		if lo != 0 {
+			s.partials[i] = lo
+			i++
+		}
+		x = hi
+	}
+	s.partials = append(s.partials[:i], x)
+}
+
+func (s *exactSum) Value() float64 {
+	n := len(s.partials)
+	if n == 0 {
+		return 0
+	}
+	n--
+	hi := s.partials[n]
+	lo := 0.0
+	for n > 0 {
+		x := hi
+		n--
+		y := s.partials[n]
+		hi = x + y
+		lo = y - (hi - x)
+		if lo != 0 {
+			break
+		}
+	}
+	// 剩餘部分和與 lo 同號時，hi 可能落在捨入的中點，需要修正為偶數
+	if n > 0 && ((lo < 0 && s.partials[n-1] < 0) || (lo > 0 && s.partials[n-1] > 0)) {
+		y := lo * 2
+		x := hi + y
+		if y == x-hi {
+			hi = x
+		}
+	}
+	if hi == 0 {
+		return 0
+	}
+	return hi
+}
+
+// indicators 為單根 K 線的原始指標；由 analyzeOne 整段重算或 indicatorState 滾動產生，
+// 之後的衍生欄位、標籤與分數都由 buildResult 計算，兩種來源的結果逐位元相同。
+type indicators struct {
+	prevClose      *float64
+	return5        *float64
+	return20       *float64
+	return60       *float64
+	ma5            *float64
+	ma10           *float64
+	ma20           *float64
+	ma60           *float64
+	high20         *float64
+	low20          *float64
+	avgVolume5     *float64
+	avgVolume20    *float64
+	avgAmplitude20 *float64
+}
+
+// historyBars 為計算指標所需的最多根數（60 根報酬率需要第 61 根的收盤）。
+const historyBars = 61
+
+// indicatorState 以滾動狀態累計指標：固定大小的環狀緩衝、各視窗的精確加總與高低點的單調佇列，
+// 每加入一根 K 線為攤銷 O(1)。maxBars 對應 analyzeOne 收到的視窗長度（LookbackDays+1）。
+type indicatorState struct {
+	maxBars int
+	count   int
+	ring    [historyBars]dataingestion.DailyPrice
+	amps    [historyBars]float64 // 每根相對前一根收盤的振幅
+
+	closeSums  map[int]*exactSum
+	volumeSums map[int]int64
+	ampSum     exactSum
+	highs      []int // 視窗內的 K 線序號，High 遞減
+	lows       []int // 視窗內的 K 線序號，Low 遞增
+}
+
+func newIndicatorState(lookback int) *indicatorState {
+	return &indicatorState{
+		maxBars:    lookback + 1,
+		closeSums:  map[int]*exactSum{5: {}, 10: {}, 20: {}, 60: {}},
+		volumeSums: map[int]int64{5: 0, 20: 0},
+	}
+}
+
+// at 回傳第 i 根（自 0 起算）K 線；僅限最近 historyBars 根。
+func (s *indicatorState) at(i int) dataingestion.DailyPrice {
+	return s.ring[i%historyBars]
+}
+
+// push 加入下一根 K 線並回傳其指標。
+func (s *indicatorState) push(bar dataingestion.DailyPrice) indicators {
+	i := s.count
+	s.count++
+	s.ring[i%historyBars] = bar
+
+	// 與 analyzeOne 相同，只看最近 maxBars 根
+	n := min(s.count, s.maxBars)
+	amp := 0.0
+	if i > 0 {
+		amp = amplitude(bar, s.at(i-1).Close)
+	}
+	s.amps[i%historyBars] = amp
+
+	for w, sum := range s.closeSums {
+		sum.Add(bar.Close)
+		if i >= w {
+			sum.Add(-s.at(i - w).Close)
+		}
+	}
+	for w := range s.volumeSums {
+		s.volumeSums[w] += bar.Volume
+		if i >= w {
+			s.volumeSums[w] -= s.at(i - w).Volume
+		}
+	}
+	if i > 0 {
+		s.ampSum.Add(amp)
+		if i > 20 {
+			s.ampSum.Add(-s.amps[(i-20)%historyBars])
+		}
+	}
+
+	hlWindow := min(20, s.maxBars)
+	for len(s.highs) > 0 && s.at(s.highs[len(s.highs)-1]).High <= bar.High {
+		s.highs = s.highs[:len(s.highs)-1]
+	}
+	s.highs = append(s.highs, i)
+	for len(s.lows) > 0 && s.at(s.lows[len(s.lows)-1]).Low >= bar.Low {
+		s.lows = s.lows[:len(s.lows)-1]
+	}
+	s.lows = append(s.lows, i)
+	for s.highs[0] <= i-hlWindow {
+		s.highs = s.highs[1:]
+	}
+	for s.lows[0] <= i-hlWindow {
+		s.lows = s.lows[1:]
+	}
+
+	var ind indicators
+	if n >= 2 {
+		ind.prevClose = ptr(s.at(i - 1).Close)
+	}
+	ind.return5 = s.pctReturn(n, 5)
+	ind.return20 = s.pctReturn(n, 20)
+	ind.return60 = s.pctReturn(n, 60)
+	ind.ma5 = s.average(n, 5)
+	ind.ma10 = s.average(n, 10)
+	ind.ma20 = s.average(n, 20)
+	ind.ma60 = s.average(n, 60)
+	ind.high20 = ptr(s.at(s.highs[0]).High)
+	ind.low20 = ptr(s.at(s.lows[0]).Low)
+	if n >= 5 {
+		ind.avgVolume5 = ptr(float64(s.volumeSums[5]) / 5)
+	}
+	if n >= 20 {
+		ind.avgVolume20 = ptr(float64(s.volumeSums[20]) / 20)
+	}
+	if n >= 21 {
+		ind.avgAmplitude20 = ptr(s.ampSum.Value() / 20)
+	}
+	return ind
+}
+
+func (s *indicatorState) average(n, window int) *float64 {
+	if n < window {
+		return nil
+	}
+	return ptr(s.closeSums[window].Value() / float64(window))
+}
+
+func (s *indicatorState) pctReturn(n, window int) *float64 {
+	if n < window+1 {
+		return nil
+	}
+	i := s.count - 1
+	return returnBetween(s.at(i-window).Close, s.at(i).Close)
+}
@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	domain "ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+func TestExactSum_MatchesCorrectlyRoundedSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	var s exactSum
+	var window []float64
+	for i := 0; i < 2000; i++ {
+		x := rng.NormFloat64() * math.Pow(10, float64(rng.Intn(12)-4))
+		s.Add(x)
+		window = append(window, x)
+		if len(window) > 20 {
+			s.Add(-window[0])
+			window = window[1:]
+		}
+
+		exact := new(big.Float).SetPrec(2000)
+		for _, v := range window {
+			exact.Add(exact, new(big.Float).SetPrec(2000).SetFloat64(v))
+		}
+		want, _ := exact.Float64()
+		if got := s.Value(); math.Float64bits(got) != math.Float64bits(want) {
+			t.Fatalf("step %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// randomWalk 產生帶跳空、零量與劇烈波動的 K 線，讓滾動狀態經過各種邊界。
+func randomWalk(n int, seed int64) []dataingestion.DailyPrice {
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	close := 100.0
+	out := make([]dataingestion.DailyPrice, n)
+	for i := range out {
+		open := close * (1 + rng.NormFloat64()*0.01)
+		close = math.Max(0.01, open*(1+rng.NormFloat64()*0.03))
+		high := math.Max(open, close) * (1 + rng.Float64()*0.02)
+		low := math.Min(open, close) * (1 - rng.Float64()*0.02)
+		vol := int64(rng.Intn(5000))
+		if rng.Intn(10) == 0 {
+			vol = 0
+		}
+		out[i] = dataingestion.DailyPrice{
+			Symbol: "BTCUSDT", Market: dataingestion.MarketCrypto, Timeframe: "1h",
+			TradeDate: start.Add(time.Duration(i) * time.Hour),
+			Open:      open, High: high, Low: low, Close: close, Volume: vol,
+		}
+	}
+	return out
+}
+
+func TestIndicatorState_BitIdenticalToFullRecompute(t *testing.T) {
+	history := randomWalk(500, 42)
+	info := BasicInfo{Symbol: "BTCUSDT", Market: dataingestion.MarketCrypto}
+
+	for _, lookback := range []int{120, 59, 19, 3, 0} {
+		state := newIndicatorState(lookback)
+		for i, bar := range history {
+			got, gotErr := buildResult(info, bar, state.push(bar), "v", RuleScoreModel{})
+			want, wantErr := analyzeOne(info, bar.TradeDate, history[max(0, i-lookback):i+1], "v", RuleScoreModel{})
+			if (gotErr == nil) != (wantErr == nil) {
+				t.Fatalf("lookback %d bar %d: error mismatch %v vs %v", lookback, i, gotErr, wantErr)
+			}
+			if diff := diffResults(got, want); diff != "" {
+				t.Fatalf("lookback %d bar %d: %s", lookback, i, diff)
+			}
+		}
+	}
+}
+
+// diffResults 逐欄比較兩個結果，浮點數比較位元而非數值。
+func diffResults(a, b domain.DailyAnalysisResult) string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		name := va.Type().Field(i).Name
+		fa, fb := va.Field(i), vb.Field(i)
+		switch fa.Kind() {
+		case reflect.Float64:
+			if math.Float64bits(fa.Float()) != math.Float64bits(fb.Float()) {
+				return name + " differs"
+			}
+		case reflect.Pointer:
+			if fa.IsNil() != fb.IsNil() {
+				return name + " nil mismatch"
+			}
+			if !fa.IsNil() && math.Float64bits(fa.Elem().Float()) != math.Float64bits(fb.Elem().Float()) {
+				return name + " differs"
+			}
+		default:
+			if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+				return name + " differs"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,277 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	domain "ai-auto-trade/internal/domain/analysis"
+)
+
+// ScoreModel 由分析結果的指標算出 0–100 的 AI 分數。
+type ScoreModel interface {
+	Score(res domain.DailyAnalysisResult) float64
+}
+
+// ScoreModelLoader 依名稱載入分數模型，分析版本以名稱指定使用的模型。
+type ScoreModelLoader interface {
+	Load(name string) (ScoreModel, error)
+}
+
+// RuleScoreModel 為內建的手調線性公式，未指定模型的版本使用。
+type RuleScoreModel struct{}
+
+func (RuleScoreModel) Score(res domain.DailyAnalysisResult) float64 {
+	score := 50.0
+
+	if res.Return5 != nil {
+		score += clamp(*res.Return5*100, -20, 20) * 0.5
+	}
+	if res.Return20 != nil {
+		score += clamp(*res.Return20*100, -40, 40) * 0.4
+	}
+	if res.VolumeMultiple != nil {
+		score += clamp((*res.VolumeMultiple-1)*10, -10, 15)
+	}
+	if res.RangePos20 != nil {
+		score += (*res.RangePos20 - 0.5) * 10
+	}
+
+	return clamp(score, 0, 100)
+}
+
+// scoreFeatures 為模型可用的特徵，名稱與分析結果 CSV 匯出的欄位相同，
+// 離線訓練可直接使用匯出檔；指標不足時為 nil。
+var scoreFeatures = map[string]func(domain.DailyAnalysisResult) *float64{
+	"change_rate":      func(r domain.DailyAnalysisResult) *float64 { return &r.ChangeRate },
+	"return_5":         func(r domain.DailyAnalysisResult) *float64 { return r.Return5 },
+	"return_20":        func(r domain.DailyAnalysisResult) *float64 { return r.Return20 },
+	"return_60":        func(r domain.DailyAnalysisResult) *float64 { return r.Return60 },
+	"range_pos_20":     func(r domain.DailyAnalysisResult) *float64 { return r.RangePos20 },
+	"deviation_20":     func(r domain.DailyAnalysisResult) *float64 { return r.Deviation20 },
+	"volume_multiple":  func(r domain.DailyAnalysisResult) *float64 { return r.VolumeMultiple },
+	"amplitude":        func(r domain.DailyAnalysisResult) *float64 { return r.Amplitude },
+	"avg_amplitude_20": func(r domain.DailyAnalysisResult) *float64 { return r.AvgAmplitude20 },
+}
+
+// modelFile 為模型檔的 JSON 格式，type 決定其餘欄位：
+//   - linear：intercept + Σ weights·features，截斷於 0–100
+//   - logistic：100 × sigmoid(intercept + Σ weights·features)
+//   - gbdt：base_score + Σ 各樹葉值；objective 為 logistic 時取 100 × sigmoid，否則截斷於 0–100
+type modelFile struct {
+	Type      string    `json:"type"`
+	Features  []string  `json:"features"`
+	Weights   []float64 `json:"weights"`
+	Intercept float64   `json:"intercept"`
+	Fill      []float64 `json:"fill"` // 缺值時的替代值，預設 0
+
+	Trees     []treeSpec `json:"trees"`
+	BaseScore float64    `json:"base_score"`
+	Objective string     `json:"objective"`
+}
+
+// treeSpec 以陣列保存節點，第 0 個為根；子節點索引必須大於父節點，保證走訪會結束。
+type treeSpec struct {
+	Nodes []treeNode `json:"nodes"`
+}
+
+// treeNode 有 leaf 時為葉節點；否則 features[feature] < threshold 走 left，
+// 缺值依 default_left 決定方向（與 XGBoost 匯出的語意相同）。
+type treeNode struct {
+	Leaf        *float64 `json:"leaf"`
+	Feature     int      `json:"feature"`
+	Threshold   float64  `json:"threshold"`
+	Left        int      `json:"left"`
+	Right       int      `json:"right"`
+	DefaultLeft bool     `json:"default_left"`
+}
+
+// ParseScoreModel 解析並驗證 JSON 模型。
+func ParseScoreModel(data []byte) (ScoreModel, error) {
+	var spec modelFile
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("decode score model: %w", err)
+	}
+	if len(spec.Features) == 0 {
+		return nil, fmt.Errorf("score model has no features")
+	}
+	extract := make([]func(domain.DailyAnalysisResult) *float64, len(spec.Features))
+	for i, name := range spec.Features {
+		fn, ok := scoreFeatures[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q (available: %s)", name, strings.Join(scoreFeatureNames(), ", "))
+		}
+		extract[i] = fn
+	}
+	if len(spec.Fill) != 0 && len(spec.Fill) != len(spec.Features) {
+		return nil, fmt.Errorf("fill has %d values for %d features", len(spec.Fill), len(spec.Features))
+	}
+	fill := spec.Fill
+	if len(fill) == 0 {
+		fill = make([]float64, len(spec.Features))
+	}
+
+	switch spec.Type {
+	case "linear", "logistic":
+		if len(spec.Weights) != len(spec.Features) {
+			return nil, fmt.Errorf("weights has %d values for %d features", len(spec.Weights), len(spec.Features))
+		}
+		return &linearModel{extract: extract, fill: fill, weights: spec.Weights, intercept: spec.Intercept, logistic: spec.Type == "logistic"}, nil
+	case "gbdt":
+		if spec.Objective != "regression" && spec.Objective != "logistic" {
+			return nil, fmt.Errorf("gbdt objective must be regression or logistic, got %q", spec.Objective)
+		}
+		if len(spec.Trees) == 0 {
+			return nil, fmt.Errorf("gbdt model has no trees")
+		}
+		for i, tree := range spec.Trees {
+			if err := tree.validate(len(spec.Features)); err != nil {
+				return nil, fmt.Errorf("tree %d: %w", i, err)
+			}
+		}
+		return &treeModel{extract: extract, trees: spec.Trees, baseScore: spec.BaseScore, logistic: spec.Objective == "logistic"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported score model type %q", spec.Type)
+	}
+}
+
+func (t treeSpec) validate(features int) error {
+	if len(t.Nodes) == 0 {
+		return fmt.Errorf("no nodes")
+	}
+	for i, n := range t.Nodes {
+		if n.Leaf != nil {
+			continue
+		}
+		if n.Feature < 0 || n.Feature >= features {
+			return fmt.Errorf("node %d: feature index %d out of range", i, n.Feature)
+		}
+		for _, child := range []int{n.Left, n.Right} {
+			if child <= i || child >= len(t.Nodes) {
+				return fmt.Errorf("node %d: child %d must point forward within the tree", i, child)
+			}
+		}
+	}
+	return nil
+}
+
+type linearModel struct {
+	extract   []func(domain.DailyAnalysisResult) *float64
+	fill      []float64
+	weights   []float64
+	intercept float64
+	logistic  bool
+}
+
+func (m *linearModel) Score(res domain.DailyAnalysisResult) float64 {
+	z := m.intercept
+	for i, fn := range m.extract {
+		x := m.fill[i]
+		if v := fn(res); v != nil {
+			x = *v
+		}
+		z += m.weights[i] * x
+	}
+	if m.logistic {
+		return 100 * sigmoid(z)
+	}
+	return clamp(z, 0, 100)
+}
+
+type treeModel struct {
+	extract   []func(domain.DailyAnalysisResult) *float64
+	trees     []treeSpec
+	baseScore float64
+	logistic  bool
+}
+
+func (m *treeModel) Score(res domain.DailyAnalysisResult) float64 {
+	values := make([]*float64, len(m.extract))
+	for i, fn := range m.extract {
+		values[i] = fn(res)
+	}
+	raw := m.baseScore
+	for _, tree := range m.trees {
+		raw += tree.predict(values)
+	}
+	if m.logistic {
+		return 100 * sigmoid(raw)
+	}
+	return clamp(raw, 0, 100)
+}
+
+func (t treeSpec) predict(values []*float64) float64 {
+	i := 0
+	for {
+		n := t.Nodes[i]
+		if n.Leaf != nil {
+			return *n.Leaf
+		}
+		x := values[n.Feature]
+		switch {
+		case x == nil || math.IsNaN(*x):
+			i = n.Right
+			if n.DefaultLeft {
+				i = n.Left
+			}
+		case *x < n.Threshold:
+			i = n.Left
+		default:
+			i = n.Right
+		}
+	}
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// scoreFeatureNames 回傳模型可用的特徵名稱（排序後）。
+func scoreFeatureNames() []string {
+	names := make([]string, 0, len(scoreFeatures))
+	for name := range scoreFeatures {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// FileModelLoader 從目錄載入 JSON 模型檔；模型以檔名識別並快取，
+// 已被版本使用的檔案視為不可變，換模型請另存新檔並註冊新版本。
+type FileModelLoader struct {
+	dir string
+
+	mu    sync.Mutex
+	cache map[string]ScoreModel
+}
+
+func NewFileModelLoader(dir string) *FileModelLoader {
+	return &FileModelLoader{dir: dir, cache: make(map[string]ScoreModel)}
+}
+
+// Load 讀取 dir 下的模型檔；名稱不可包含路徑，避免讀取目錄外的檔案。
+func (l *FileModelLoader) Load(name string) (ScoreModel, error) {
+	if name == "" || filepath.Base(name) != name || !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("invalid score model name %q", name)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m, ok := l.cache[name]; ok {
+		return m, nil
+	}
+	data, err := os.ReadFile(filepath.Join(l.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("read score model: %w", err)
+	}
+	m, err := ParseScoreModel(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	l.cache[name] = m
+	return m, nil
+}
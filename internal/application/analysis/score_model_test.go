@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	domain "ai-auto-trade/internal/domain/analysis"
+)
+
+func TestParseScoreModel_Linear(t *testing.T) {
+	res := domain.DailyAnalysisResult{Return5: ptr(0.1), VolumeMultiple: nil}
+
+	linear, err := ParseScoreModel([]byte(`{"type":"linear","features":["return_5","volume_multiple"],"weights":[100,10],"intercept":40,"fill":[0,1]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40 + 100×0.1 + 10×1（缺值以 fill 代入）
+	if got := linear.Score(res); math.Abs(got-60) > 1e-9 {
+		t.Fatalf("linear score = %v, want 60", got)
+	}
+	if got := linear.Score(domain.DailyAnalysisResult{Return5: ptr(1.0)}); got != 100 {
+		t.Fatalf("linear score must be clamped to 100, got %v", got)
+	}
+
+	logistic, err := ParseScoreModel([]byte(`{"type":"logistic","features":["return_5"],"weights":[10],"intercept":-1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := logistic.Score(res); math.Abs(got-50) > 1e-9 {
+		t.Fatalf("logistic score = %v, want 50", got)
+	}
+}
+
+func TestParseScoreModel_GBDT(t *testing.T) {
+	model, err := ParseScoreModel([]byte(`{
+		"type": "gbdt", "objective": "regression", "base_score": 50,
+		"features": ["return_20", "range_pos_20"],
+		"trees": [
+			{"nodes": [{"feature": 0, "threshold": 0, "left": 1, "right": 2}, {"leaf": -10}, {"leaf": 10}]},
+			{"nodes": [{"feature": 1, "threshold": 0.5, "left": 1, "right": 2, "default_left": true}, {"leaf": -5}, {"leaf": 5}]}
+		]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		res  domain.DailyAnalysisResult
+		want float64
+	}{
+		{"both up", domain.DailyAnalysisResult{Return20: ptr(0.1), RangePos20: ptr(0.9)}, 65},
+		{"threshold goes right", domain.DailyAnalysisResult{Return20: ptr(0.0), RangePos20: ptr(0.1)}, 55},
+		{"missing follows default", domain.DailyAnalysisResult{}, 55}, // 第一棵樹預設走右、第二棵走左
+	}
+	for _, tt := range tests {
+		if got := model.Score(tt.res); got != tt.want {
+			t.Errorf("%s: score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseScoreModel_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":    `{"type":"svm","features":["return_5"]}`,
+		"unknown feature": `{"type":"linear","features":["ma_5"],"weights":[1]}`,
+		"weights length":  `{"type":"linear","features":["return_5","return_20"],"weights":[1]}`,
+		"fill length":     `{"type":"logistic","features":["return_5"],"weights":[1],"fill":[0,0]}`,
+		"no objective":    `{"type":"gbdt","features":["return_5"],"trees":[{"nodes":[{"leaf":1}]}]}`,
+		"backward child":  `{"type":"gbdt","objective":"regression","features":["return_5"],"trees":[{"nodes":[{"feature":0,"left":0,"right":1},{"leaf":1}]}]}`,
+		"feature index":   `{"type":"gbdt","objective":"regression","features":["return_5"],"trees":[{"nodes":[{"feature":1,"left":1,"right":2},{"leaf":1},{"leaf":2}]}]}`,
+	}
+	for name, spec := range tests {
+		if _, err := ParseScoreModel([]byte(spec)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFileModelLoader(t *testing.T) {
+	loader := NewFileModelLoader("../../../models")
+	for _, name := range []string{"example_logistic.json", "example_gbdt.json"} {
+		m, err := loader.Load(name)
+		if err != nil {
+			t.Fatalf("load %s: %v", name, err)
+		}
+		if score := m.Score(domain.DailyAnalysisResult{Return20: ptr(0.05)}); score <= 0 || score >= 100 {
+			t.Errorf("%s: score %v out of range", name, score)
+		}
+	}
+	if _, err := loader.Load("../config.example.yaml"); err == nil || !strings.Contains(err.Error(), "invalid score model name") {
+		t.Fatalf("expected path outside model dir to be rejected, got %v", err)
+	}
+}
+
+type constModel float64
+
+func (c constModel) Score(domain.DailyAnalysisResult) float64 { return float64(c) }
+
+type fakeModelLoader map[string]ScoreModel
+
+func (f fakeModelLoader) Load(name string) (ScoreModel, error) {
+	if m, ok := f[name]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("model %s not found", name)
+}
+
+func TestAnalyzeUseCase_ScoresWithVersionModel(t *testing.T) {
+	uc, repo, store, last := newVersionFixture(t)
+	ctx := context.Background()
+	uc.analyzer.SetScoreModels(fakeModelLoader{"ai.json": constModel(77)})
+
+	if _, err := uc.Create(ctx, Version{Name: "v3", ScoreModel: "missing.json"}); err == nil {
+		t.Fatal("expected unknown model to be rejected on create")
+	}
+	if _, err := uc.Create(ctx, Version{Name: "v3", ScoreModel: "ai.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := store.GetVersion(ctx, "v3"); v.ScoreModel != "ai.json" {
+		t.Fatalf("score model not stored, got %+v", v)
+	}
+
+	if _, err := uc.analyzer.Execute(ctx, AnalyzeInput{TradeDate: last}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.Recompute(ctx, RecomputeInput{Version: "v3", From: last, To: last.AddDate(0, 0, 1), Timeframes: []string{"1d"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.results["v3"][last].Score; got != 77 {
+		t.Fatalf("v3 must score with its model, got %v", got)
+	}
+	if got := repo.results["v1"][last].Score; got == 77 {
+		t.Fatalf("v1 must keep the rule formula")
+	}
+}
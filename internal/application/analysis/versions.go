@@ -0,0 +1,382 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	domain "ai-auto-trade/internal/domain/analysis"
+)
+
+var (
+	// ErrVersionNotFound 表示分析版本尚未註冊。
+	ErrVersionNotFound = errors.New("analysis version not found")
+	// ErrVersionExists 表示分析版本名稱已被使用。
+	ErrVersionExists = errors.New("analysis version already exists")
+	// ErrRecomputeRunning 表示同一版本已有重算在執行。
+	ErrRecomputeRunning = errors.New("recompute already running for this version")
+)
+
+// Version 為註冊的分析版本；啟用中的版本供排程寫入，以及未釘選版本的策略與查詢讀取。
+type Version struct {
+	Name        string
+	Description string
+	ScoreModel  string // 分數模型檔名，空值代表內建的 RuleScoreModel
+	Active      bool
+	CreatedAt   time.Time
+}
+
+// VersionStore 保存分析版本註冊表。
+type VersionStore interface {
+	ListVersions(ctx context.Context) ([]Version, error)
+	// GetVersion 查無時回傳 ErrVersionNotFound。
+	GetVersion(ctx context.Context, name string) (Version, error)
+	// CreateVersion 名稱重複時回傳 ErrVersionExists。
+	CreateVersion(ctx context.Context, v Version) error
+	// ActivateVersion 將指定版本設為唯一啟用中的版本。
+	ActivateVersion(ctx context.Context, name string) error
+}
+
+// HistoryReader 讀取啟用中版本的歷史分析結果。
+type HistoryReader interface {
+	FindHistory(ctx context.Context, symbol string, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]domain.DailyAnalysisResult, error)
+}
+
+// VersionedHistory 讀取指定版本的歷史分析結果。
+type VersionedHistory interface {
+	FindVersionHistory(ctx context.Context, version, symbol, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]domain.DailyAnalysisResult, error)
+}
+
+// ReadHistory 讀取指定版本的歷史；version 為空時讀取啟用中的版本，
+// 否則資料來源必須實作 VersionedHistory。
+func ReadHistory(ctx context.Context, repo HistoryReader, version, symbol, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]domain.DailyAnalysisResult, error) {
+	if version == "" {
+		return repo.FindHistory(ctx, symbol, timeframe, from, to, limit, onlySuccess)
+	}
+	versioned, ok := repo.(VersionedHistory)
+	if !ok {
+		return nil, fmt.Errorf("analysis version %q: data source cannot read pinned versions", version)
+	}
+	return versioned.FindVersionHistory(ctx, version, symbol, timeframe, from, to, limit, onlySuccess)
+}
+
+// ActiveVersion 回傳啟用中的版本名稱；尚未啟用任何版本時為 DefaultVersion。
+func ActiveVersion(ctx context.Context, store VersionStore) (string, error) {
+	versions, err := store.ListVersions(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range versions {
+		if v.Active {
+			return v.Name, nil
+		}
+	}
+	return DefaultVersion, nil
+}
+
+// ValidateVersionName 檢查版本名稱：1–64 個英數字、點、底線或連字號。
+func ValidateVersionName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("version name must be 1-64 characters")
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return fmt.Errorf("version name %q may only contain letters, digits, '.', '_' and '-'", name)
+		}
+	}
+	return nil
+}
+
+// recomputeSpan 為重算時每次分析的最長期間，限制單次讀取的歷史量。
+const recomputeSpan = 31 * 24 * time.Hour
+
+// diffPageSize 為比較版本時每次讀取的筆數，超過時往前分頁直到讀完整個期間。
+const diffPageSize = 5000
+
+// RecomputeInput 指定以哪個版本重新分析 [From, To) 內的交易日。
+type RecomputeInput struct {
+	Version    string
+	From       time.Time
+	To         time.Time
+	Timeframes []string
+	Symbols    []string // 空值代表 BasicInfoProvider 的預設清單
+}
+
+// VersionDiffInput 指定比較的兩個版本與範圍；From/To 皆包含。
+type VersionDiffInput struct {
+	Base      string
+	Target    string
+	Symbol    string
+	Timeframe string
+	From      time.Time
+	To        time.Time
+}
+
+// ScoreChange 為同一根 K 線在兩個版本的分數；只有一邊有結果時另一邊為 nil。
+type ScoreChange struct {
+	TradeDate   time.Time
+	BaseScore   *float64
+	TargetScore *float64
+}
+
+// Delta 回傳 TargetScore - BaseScore，任一邊缺少時為 nil。
+func (c ScoreChange) Delta() *float64 {
+	if c.BaseScore == nil || c.TargetScore == nil {
+		return nil
+	}
+	d := *c.TargetScore - *c.BaseScore
+	return &d
+}
+
+// VersionDiff 為兩個版本的分數比較；統計只計入兩邊都有結果的 K 線。
+type VersionDiff struct {
+	Changes       []ScoreChange
+	Compared      int
+	Changed       int
+	MeanAbsDelta  float64
+	MaxAbsDelta   float64
+	MissingBase   int // 只有 Target 有結果
+	MissingTarget int // 只有 Base 有結果
+}
+
+// VersionUseCase 管理分析版本：註冊、啟用、以新版本重算歷史，以及比較版本間的分數。
+type VersionUseCase struct {
+	store    VersionStore
+	analyzer *AnalyzeUseCase
+	history  VersionedHistory
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewVersionUseCase 建立分析版本用例。
+func NewVersionUseCase(store VersionStore, analyzer *AnalyzeUseCase, history VersionedHistory) *VersionUseCase {
+	return &VersionUseCase{store: store, analyzer: analyzer, history: history, running: make(map[string]bool)}
+}
+
+func (u *VersionUseCase) List(ctx context.Context) ([]Version, error) {
+	return u.store.ListVersions(ctx)
+}
+
+// Create 註冊新版本（不啟用）；指定 ScoreModel 時先確認模型可載入。
+func (u *VersionUseCase) Create(ctx context.Context, in Version) (Version, error) {
+	if err := ValidateVersionName(in.Name); err != nil {
+		return Version{}, err
+	}
+	if _, err := u.analyzer.loadScoreModel(in.ScoreModel); err != nil {
+		return Version{}, err
+	}
+	v := Version{Name: in.Name, Description: in.Description, ScoreModel: in.ScoreModel, CreatedAt: time.Now().UTC()}
+	if err := u.store.CreateVersion(ctx, v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Activate 切換啟用中的版本，之後的排程分析寫入此版本；重算中的版本須等重算結束才能啟用。
+func (u *VersionUseCase) Activate(ctx context.Context, name string) error {
+	if _, err := u.store.GetVersion(ctx, name); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.running[name] {
+		return ErrRecomputeRunning
+	}
+	return u.store.ActivateVersion(ctx, name)
+}
+
+// StartRecompute 驗證輸入後於背景重算，完成時呼叫 onFinish；同一版本同時只允許一個重算，
+// 重算期間本程序不允許啟用該版本。背景工作使用傳入的 ctx，呼叫端需自行確保其生命週期。
+func (u *VersionUseCase) StartRecompute(ctx context.Context, in RecomputeInput, onFinish func(RecomputeInput, AnalyzeResult, error)) error {
+	// 先佔用版本再驗證，避免驗證後、開始前被啟用
+	u.mu.Lock()
+	if u.running[in.Version] {
+		u.mu.Unlock()
+		return ErrRecomputeRunning
+	}
+	u.running[in.Version] = true
+	u.mu.Unlock()
+	done := func() {
+		u.mu.Lock()
+		delete(u.running, in.Version)
+		u.mu.Unlock()
+	}
+	if err := u.validateRecompute(ctx, in); err != nil {
+		done()
+		return err
+	}
+
+	go func() {
+		res, err := u.recompute(ctx, in)
+		done()
+		if onFinish != nil {
+			onFinish(in, res, err)
+		}
+	}()
+	return nil
+}
+
+// Recompute 以 in.Version 同步重算，只寫入該版本的結果，其他版本不受影響。
+func (u *VersionUseCase) Recompute(ctx context.Context, in RecomputeInput) (AnalyzeResult, error) {
+	if err := u.validateRecompute(ctx, in); err != nil {
+		return AnalyzeResult{}, err
+	}
+	return u.recompute(ctx, in)
+}
+
+// validateRecompute 只允許重算已註冊且未啟用的版本，避免覆蓋線上使用中的結果。
+func (u *VersionUseCase) validateRecompute(ctx context.Context, in RecomputeInput) error {
+	if !in.To.After(in.From) {
+		return fmt.Errorf("to must be after from")
+	}
+	if len(in.Timeframes) == 0 {
+		return fmt.Errorf("timeframes are required")
+	}
+	return u.checkInactive(ctx, in.Version)
+}
+
+// checkInactive 確認版本已註冊且未啟用。
+func (u *VersionUseCase) checkInactive(ctx context.Context, name string) error {
+	v, err := u.store.GetVersion(ctx, name)
+	if err != nil {
+		return err
+	}
+	if v.Active {
+		return fmt.Errorf("version %q is active; recompute into a new version and activate it afterwards", v.Name)
+	}
+	return nil
+}
+
+// recompute 依 recomputeSpan 分段，每段每個週期呼叫一次 AnalyzeUseCase；
+// 每段開始前重新確認版本未被啟用（其他實例可能已啟用），已啟用時中止。
+func (u *VersionUseCase) recompute(ctx context.Context, in RecomputeInput) (AnalyzeResult, error) {
+	var total AnalyzeResult
+	for start := in.From; start.Before(in.To); start = start.Add(recomputeSpan) {
+		if err := u.checkInactive(ctx, in.Version); err != nil {
+			return total, err
+		}
+		end := start.Add(recomputeSpan)
+		if end.After(in.To) {
+			end = in.To
+		}
+		for _, tf := range in.Timeframes {
+			res, err := u.analyzer.Execute(ctx, AnalyzeInput{
+				TradeDate: start,
+				Until:     end,
+				Timeframe: tf,
+				Symbols:   in.Symbols,
+				Version:   in.Version,
+			})
+			total.SuccessCount += res.SuccessCount
+			total.FailedCount += res.FailedCount
+			total.Failures = append(total.Failures, res.Failures...)
+			total.Items = append(total.Items, res.Items...)
+			if err != nil {
+				return total, fmt.Errorf("analyze %s from %s: %w", tf, start.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return total, nil
+}
+
+// Diff 比較兩個版本在同一交易對、週期與期間內的分數，依時間排序。
+func (u *VersionUseCase) Diff(ctx context.Context, in VersionDiffInput) (VersionDiff, error) {
+	var out VersionDiff
+	if in.Symbol == "" {
+		return out, fmt.Errorf("symbol is required")
+	}
+	if in.To.Before(in.From) {
+		return out, fmt.Errorf("to must not be before from")
+	}
+	for _, name := range []string{in.Base, in.Target} {
+		if _, err := u.store.GetVersion(ctx, name); err != nil {
+			return out, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	base, err := u.versionRange(ctx, in.Base, in)
+	if err != nil {
+		return out, err
+	}
+	target, err := u.versionRange(ctx, in.Target, in)
+	if err != nil {
+		return out, err
+	}
+
+	byTime := make(map[time.Time]*ScoreChange)
+	for _, r := range base {
+		score := r.Score
+		byTime[r.TradeDate.UTC()] = &ScoreChange{TradeDate: r.TradeDate.UTC(), BaseScore: &score}
+	}
+	for _, r := range target {
+		score := r.Score
+		c, ok := byTime[r.TradeDate.UTC()]
+		if !ok {
+			c = &ScoreChange{TradeDate: r.TradeDate.UTC()}
+			byTime[c.TradeDate] = c
+		}
+		c.TargetScore = &score
+	}
+
+	out.Changes = make([]ScoreChange, 0, len(byTime))
+	for _, c := range byTime {
+		out.Changes = append(out.Changes, *c)
+	}
+	slices.SortFunc(out.Changes, func(a, b ScoreChange) int { return a.TradeDate.Compare(b.TradeDate) })
+
+	sumAbs := 0.0
+	for _, c := range out.Changes {
+		d := c.Delta()
+		switch {
+		case c.BaseScore == nil:
+			out.MissingBase++
+		case c.TargetScore == nil:
+			out.MissingTarget++
+		default:
+			out.Compared++
+			abs := math.Abs(*d)
+			if abs > 1e-9 {
+				out.Changed++
+			}
+			sumAbs += abs
+			out.MaxAbsDelta = math.Max(out.MaxAbsDelta, abs)
+		}
+	}
+	if out.Compared > 0 {
+		out.MeanAbsDelta = sumAbs / float64(out.Compared)
+	}
+	return out, nil
+}
+
+// versionRange 讀取版本在 [From, To] 內的所有成功結果；資料來源每次回傳最新的 diffPageSize 筆，
+// 因此以已讀到的最早時間往前分頁。
+func (u *VersionUseCase) versionRange(ctx context.Context, version string, in VersionDiffInput) ([]domain.DailyAnalysisResult, error) {
+	var all []domain.DailyAnalysisResult
+	to := in.To
+	for {
+		page, err := u.history.FindVersionHistory(ctx, version, in.Symbol, in.Timeframe, &in.From, &to, diffPageSize, true)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < diffPageSize {
+			return all, nil
+		}
+		oldest := page[0].TradeDate
+		for _, r := range page[1:] {
+			if r.TradeDate.Before(oldest) {
+				oldest = r.TradeDate
+			}
+		}
+		to = oldest.Add(-time.Nanosecond)
+		if to.Before(in.From) {
+			return all, nil
+		}
+	}
+}
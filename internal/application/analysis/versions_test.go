@@ -0,0 +1,281 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+type fakeVersionStore struct {
+	versions []Version
+}
+
+func (f *fakeVersionStore) ListVersions(context.Context) ([]Version, error) {
+	return f.versions, nil
+}
+
+func (f *fakeVersionStore) GetVersion(_ context.Context, name string) (Version, error) {
+	for _, v := range f.versions {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return Version{}, ErrVersionNotFound
+}
+
+func (f *fakeVersionStore) CreateVersion(_ context.Context, v Version) error {
+	if _, err := f.GetVersion(context.Background(), v.Name); err == nil {
+		return ErrVersionExists
+	}
+	f.versions = append(f.versions, v)
+	return nil
+}
+
+func (f *fakeVersionStore) ActivateVersion(_ context.Context, name string) error {
+	for i := range f.versions {
+		f.versions[i].Active = f.versions[i].Name == name
+	}
+	return nil
+}
+
+// versionedRepo 依版本保存分析結果，同一版本同一根 K 線覆寫。
+type versionedRepo struct {
+	results map[string]map[time.Time]analysisDomain.DailyAnalysisResult
+}
+
+func newVersionedRepo() *versionedRepo {
+	return &versionedRepo{results: make(map[string]map[time.Time]analysisDomain.DailyAnalysisResult)}
+}
+
+func (r *versionedRepo) SaveDailyResult(_ context.Context, res analysisDomain.DailyAnalysisResult) error {
+	if r.results[res.Version] == nil {
+		r.results[res.Version] = make(map[time.Time]analysisDomain.DailyAnalysisResult)
+	}
+	r.results[res.Version][res.TradeDate] = res
+	return nil
+}
+
+// FindVersionHistory 與 postgres 相同，依時間遞減回傳最新的 limit 筆。
+func (r *versionedRepo) FindVersionHistory(_ context.Context, version, symbol, _ string, from, to *time.Time, limit int, _ bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	var out []analysisDomain.DailyAnalysisResult
+	for _, res := range r.results[version] {
+		if res.Symbol == symbol && !res.TradeDate.Before(*from) && !res.TradeDate.After(*to) {
+			out = append(out, res)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TradeDate.After(out[j].TradeDate) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func newVersionFixture(t *testing.T) (*VersionUseCase, *versionedRepo, *fakeVersionStore, time.Time) {
+	t.Helper()
+	last := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	closes := make([]float64, 40)
+	volumes := make([]int64, 40)
+	for i := range closes {
+		closes[i] = 100 + float64(i%7)
+		volumes[i] = 1000
+	}
+	store := &fakeVersionStore{versions: []Version{{Name: "v1", Active: true}, {Name: "v2"}}}
+	repo := newVersionedRepo()
+	analyzer := NewAnalyzeUseCase(
+		fakeBasicProvider{list: []BasicInfo{{Symbol: "2330", Market: dataingestion.MarketTWSE}}},
+		fakeHistoryProvider{history: map[string][]dataingestion.DailyPrice{"2330": buildHistory(last, closes, volumes)}},
+		repo,
+	)
+	analyzer.SetVersions(store)
+	return NewVersionUseCase(store, analyzer, repo), repo, store, last
+}
+
+func TestVersionUseCase_RecomputeKeepsOtherVersions(t *testing.T) {
+	uc, repo, store, last := newVersionFixture(t)
+	ctx := context.Background()
+	from := last.AddDate(0, 0, -4)
+
+	// 未指定版本時寫入啟用中的 v1
+	if _, err := uc.analyzer.Execute(ctx, AnalyzeInput{TradeDate: last}); err != nil {
+		t.Fatal(err)
+	}
+	original := repo.results["v1"][last]
+
+	res, err := uc.Recompute(ctx, RecomputeInput{Version: "v2", From: from, To: last.AddDate(0, 0, 1), Timeframes: []string{"1d"}})
+	if err != nil {
+		t.Fatalf("recompute: %v", err)
+	}
+	if res.SuccessCount != 5 || len(repo.results["v2"]) != 5 {
+		t.Fatalf("expected 5 bars under v2, got %+v / %d", res, len(repo.results["v2"]))
+	}
+	if len(repo.results["v1"]) != 1 || repo.results["v1"][last].Version != original.Version {
+		t.Fatalf("v1 results must be untouched, got %+v", repo.results["v1"])
+	}
+
+	if _, err := uc.Recompute(ctx, RecomputeInput{Version: "v1", From: from, To: last, Timeframes: []string{"1d"}}); err == nil {
+		t.Fatal("expected recomputing the active version to fail")
+	}
+	if _, err := uc.Recompute(ctx, RecomputeInput{Version: "v3", From: from, To: last, Timeframes: []string{"1d"}}); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+
+	// 啟用 v2 後排程分析改寫入 v2
+	if err := uc.Activate(ctx, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := ActiveVersion(ctx, store); active != "v2" {
+		t.Fatalf("expected v2 active, got %s", active)
+	}
+	next := last.AddDate(0, 0, -10)
+	if _, err := uc.analyzer.Execute(ctx, AnalyzeInput{TradeDate: next}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.results["v2"][next]; !ok {
+		t.Fatalf("expected scheduled analysis to write v2")
+	}
+}
+
+func TestVersionUseCase_StartRecomputeReportsCompletion(t *testing.T) {
+	uc, repo, _, last := newVersionFixture(t)
+	done := make(chan AnalyzeResult, 1)
+	err := uc.StartRecompute(context.Background(), RecomputeInput{Version: "v2", From: last, To: last.AddDate(0, 0, 1), Timeframes: []string{"1d"}},
+		func(_ RecomputeInput, res AnalyzeResult, err error) {
+			if err != nil {
+				t.Errorf("recompute: %v", err)
+			}
+			done <- res
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-done:
+		if res.SuccessCount != 1 || len(repo.results["v2"]) != 1 {
+			t.Fatalf("unexpected result %+v", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("recompute did not finish")
+	}
+}
+
+// activatingStore 在第 after 次查詢版本時由其他實例啟用 name。
+type activatingStore struct {
+	*fakeVersionStore
+	name  string
+	after int
+	calls int
+}
+
+func (s *activatingStore) GetVersion(ctx context.Context, name string) (Version, error) {
+	s.calls++
+	if s.calls == s.after {
+		_ = s.ActivateVersion(ctx, s.name)
+	}
+	return s.fakeVersionStore.GetVersion(ctx, name)
+}
+
+func TestVersionUseCase_RecomputeStopsWhenVersionActivated(t *testing.T) {
+	uc, repo, store, last := newVersionFixture(t)
+	ctx := context.Background()
+
+	// 本程序重算中的版本不能啟用
+	uc.running["v2"] = true
+	if err := uc.Activate(ctx, "v2"); !errors.Is(err, ErrRecomputeRunning) {
+		t.Fatalf("expected ErrRecomputeRunning, got %v", err)
+	}
+	delete(uc.running, "v2")
+
+	// 其他實例在第二段開始前啟用 v2：重算中止，不再覆寫線上結果
+	uc.store = &activatingStore{fakeVersionStore: store, name: "v2", after: 3}
+	from := last.AddDate(0, 0, -40)
+	_, err := uc.Recompute(ctx, RecomputeInput{Version: "v2", From: from, To: last.AddDate(0, 0, 1), Timeframes: []string{"1d"}})
+	if err == nil {
+		t.Fatal("expected recompute to stop once the version is active")
+	}
+	for ts := range repo.results["v2"] {
+		if !ts.Before(from.Add(recomputeSpan)) {
+			t.Fatalf("no bars may be written after activation, got %s", ts)
+		}
+	}
+}
+
+func TestVersionUseCase_Diff(t *testing.T) {
+	uc, repo, _, last := newVersionFixture(t)
+	ctx := context.Background()
+	save := func(version string, day time.Time, score float64) {
+		_ = repo.SaveDailyResult(ctx, analysisDomain.DailyAnalysisResult{Symbol: "2330", Version: version, TradeDate: day, Score: score, Success: true})
+	}
+	d0, d1, d2 := last.AddDate(0, 0, -2), last.AddDate(0, 0, -1), last
+	save("v1", d0, 50)
+	save("v2", d0, 50)
+	save("v1", d1, 40)
+	save("v2", d1, 46)
+	save("v2", d2, 70)
+
+	diff, err := uc.Diff(ctx, VersionDiffInput{Base: "v1", Target: "v2", Symbol: "2330", Timeframe: "1d", From: d0, To: d2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 3 || !diff.Changes[0].TradeDate.Equal(d0) || !diff.Changes[2].TradeDate.Equal(d2) {
+		t.Fatalf("changes must cover both versions in time order, got %+v", diff.Changes)
+	}
+	if d := diff.Changes[1].Delta(); d == nil || *d != 6 {
+		t.Fatalf("unexpected delta %v", d)
+	}
+	if diff.Changes[2].BaseScore != nil || diff.Changes[2].Delta() != nil {
+		t.Fatalf("bar only in target must have no base score")
+	}
+	if diff.Compared != 2 || diff.Changed != 1 || diff.MissingBase != 1 || diff.MissingTarget != 0 ||
+		math.Abs(diff.MeanAbsDelta-3) > 1e-12 || diff.MaxAbsDelta != 6 {
+		t.Fatalf("unexpected summary %+v", diff)
+	}
+
+	if _, err := uc.Diff(ctx, VersionDiffInput{Base: "v1", Target: "nope", Symbol: "2330", From: d0, To: d2}); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestVersionUseCase_DiffPagesThroughLongRanges(t *testing.T) {
+	uc, repo, _, last := newVersionFixture(t)
+	ctx := context.Background()
+	n := diffPageSize*2 + 10
+	first := last.Add(-time.Duration(n-1) * time.Minute)
+	for i := 0; i < n; i++ {
+		day := first.Add(time.Duration(i) * time.Minute)
+		_ = repo.SaveDailyResult(ctx, analysisDomain.DailyAnalysisResult{Symbol: "2330", Version: "v1", TradeDate: day, Score: 50, Success: true})
+		_ = repo.SaveDailyResult(ctx, analysisDomain.DailyAnalysisResult{Symbol: "2330", Version: "v2", TradeDate: day, Score: 51, Success: true})
+	}
+
+	diff, err := uc.Diff(ctx, VersionDiffInput{Base: "v1", Target: "v2", Symbol: "2330", Timeframe: "1m", From: first, To: last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Compared != n || diff.MissingBase != 0 || diff.MissingTarget != 0 || !diff.Changes[0].TradeDate.Equal(first) {
+		t.Fatalf("expected the whole range compared, got compared=%d missing=%d/%d", diff.Compared, diff.MissingBase, diff.MissingTarget)
+	}
+}
+
+type activeOnlyHistory struct{}
+
+func (activeOnlyHistory) FindHistory(context.Context, string, string, *time.Time, *time.Time, int, bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	return []analysisDomain.DailyAnalysisResult{{Symbol: "2330"}}, nil
+}
+
+func TestReadHistory_PinnedVersionNeedsVersionedSource(t *testing.T) {
+	ctx := context.Background()
+	if got, err := ReadHistory(ctx, activeOnlyHistory{}, "", "2330", "1d", nil, nil, 1, true); err != nil || len(got) != 1 {
+		t.Fatalf("unpinned read should use FindHistory, got %v, %v", got, err)
+	}
+	if _, err := ReadHistory(ctx, activeOnlyHistory{}, "v2", "2330", "1d", nil, nil, 1, true); err == nil {
+		t.Fatal("expected pinned read to fail without FindVersionHistory")
+	}
+	if err := ValidateVersionName("v2 beta"); err == nil {
+		t.Fatal("expected space in version name to be rejected")
+	}
+}
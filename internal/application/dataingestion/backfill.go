@@ -0,0 +1,599 @@
+package dataingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// BackfillStatus 為回補工作的狀態。
+type BackfillStatus string
+
+const (
+	BackfillQueued    BackfillStatus = "queued"
+	BackfillRunning   BackfillStatus = "running"
+	BackfillCompleted BackfillStatus = "completed"
+	BackfillFailed    BackfillStatus = "failed"
+	BackfillCanceled  BackfillStatus = "canceled"
+)
+
+// 回補階段：偵測缺口 → 批次抓取 → 分析新補的交易日。
+const (
+	PhaseDetect   = "detect"
+	PhaseFetch    = "fetch"
+	PhaseAnalysis = "analysis"
+	PhaseDone     = "done"
+)
+
+var (
+	// ErrBackfillNotFound 表示查無回補工作。
+	ErrBackfillNotFound = errors.New("backfill job not found")
+	// ErrBackfillNotActive 表示工作已結束，無法取消。
+	ErrBackfillNotActive = errors.New("backfill job is not active")
+)
+
+// TimeRange 為 K 線開盤時間區間 [From, To)。
+type TimeRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// BackfillTask 為單一交易對 × 週期的回補進度；Gaps 為尚未抓取的缺口，抓完一批即前移。
+type BackfillTask struct {
+	Symbol      string      `json:"symbol"`
+	Timeframe   string      `json:"timeframe"`
+	Detected    bool        `json:"detected"`
+	Missing     int         `json:"missing"`   // 偵測到的缺漏根數
+	Processed   int         `json:"processed"` // 已請求過的缺漏根數（交易所沒有的 K 線也算）
+	Stored      int         `json:"stored"`
+	Quarantined int         `json:"quarantined,omitempty"`
+	Gaps        []TimeRange `json:"gaps,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// BackfillJob 為一次回補工作與其檢查點；[Start, End) 為 K 線開盤時間範圍。
+type BackfillJob struct {
+	ID              string
+	TriggeredBy     string
+	Symbols         []string
+	Timeframes      []string
+	Start           time.Time
+	End             time.Time
+	RunAnalysis     bool
+	Status          BackfillStatus
+	Phase           string
+	Tasks           []BackfillTask
+	PendingAnalysis []time.Time // 尚待分析的交易日（UTC）
+	AnalyzedDays    int
+	AnalysisSuccess int
+	AnalysisFailure int
+	AnalysisErrors  []string // 最多保留 maxBackfillErrors 筆
+	Error           string
+	CreatedAt       time.Time
+	StartedAt       time.Time
+	FinishedAt      time.Time
+}
+
+const maxBackfillErrors = 20
+
+// Active 表示工作尚未結束。
+func (j BackfillJob) Active() bool {
+	return j.Status == BackfillQueued || j.Status == BackfillRunning
+}
+
+// Totals 回傳所有交易對的缺漏、已處理與寫入根數。
+func (j BackfillJob) Totals() (missing, processed, stored int) {
+	for _, t := range j.Tasks {
+		missing += t.Missing
+		processed += t.Processed
+		stored += t.Stored
+	}
+	return missing, processed, stored
+}
+
+// Percent 回傳整體進度（0..100）：偵測與抓取以缺漏根數計，分析以交易日計。
+func (j BackfillJob) Percent() float64 {
+	if j.Status == BackfillCompleted {
+		return 100
+	}
+	missing, processed, _ := j.Totals()
+	units, done := missing, processed
+	if j.RunAnalysis {
+		units += j.AnalyzedDays + len(j.PendingAnalysis)
+		done += j.AnalyzedDays
+	}
+	if units == 0 {
+		return 0
+	}
+	return float64(done) / float64(units) * 100
+}
+
+// BackfillStore 保存回補工作與檢查點。
+type BackfillStore interface {
+	CreateBackfill(ctx context.Context, job *BackfillJob) error
+	SaveBackfill(ctx context.Context, job *BackfillJob) error
+	GetBackfill(ctx context.Context, id string) (*BackfillJob, error)
+	// ListBackfills 由新到舊回傳最近的工作；statuses 非空時只回傳符合的狀態。
+	ListBackfills(ctx context.Context, statuses ...BackfillStatus) ([]BackfillJob, error)
+}
+
+// BarIndex 列出已儲存 K 線的開盤時間，供偵測缺口。
+type BarIndex interface {
+	BarTimes(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]time.Time, error)
+}
+
+// AnalyzeFunc 分析 [from, to) 內的交易日（UTC 00:00 對齊），回傳成功與失敗的結果筆數。
+type AnalyzeFunc func(ctx context.Context, from, to time.Time) (success, failure int, err error)
+
+// maxAnalysisDays 為一次分析的最多連續交易日；每段分析後保存檢查點。
+const maxAnalysisDays = 31
+
+// BackfillConfig 設定批次大小與分析、結束時的回呼。
+type BackfillConfig struct {
+	BatchBars int // 每次 K 線請求的最大根數，預設 1000（Binance 單頁上限）
+	Analyze   AnalyzeFunc
+	Quality   *QualityGate // 非 nil 時寫入前先做資料品質檢查
+	Base      string       // 非空時能由此週期組成的週期改抓此週期再重新取樣，同 KlinePriceSourceConfig.Base
+	OnFinish  func(ctx context.Context, job BackfillJob)
+}
+
+// BackfillRunner 在背景依序執行回補工作：先比對已儲存的 K 線找出缺口，再以大批次補抓，
+// 每批寫入後保存檢查點，中斷後可由 Resume 接續。同一時間只執行一個工作，避免超出交易所頻率限制。
+type BackfillRunner struct {
+	store  BackfillStore
+	klines KlineSource
+	bars   BarIndex
+	repo   PriceRepository
+	cfg    BackfillConfig
+	now    func() time.Time
+
+	base    context.Context // Submit 的工作在 Stop 前持續執行
+	stop    context.CancelFunc
+	runMu   sync.Mutex // 序列化工作執行
+	mu      sync.Mutex
+	active  map[string]context.CancelFunc
+	current string // 正在執行的工作
+	wg      sync.WaitGroup
+}
+
+// NewBackfillRunner 建立回補執行器。
+func NewBackfillRunner(store BackfillStore, klines KlineSource, bars BarIndex, repo PriceRepository, cfg BackfillConfig) *BackfillRunner {
+	if cfg.BatchBars <= 0 {
+		cfg.BatchBars = 1000
+	}
+	base, stop := context.WithCancel(context.Background())
+	return &BackfillRunner{
+		store:  store,
+		klines: klines,
+		bars:   bars,
+		repo:   repo,
+		cfg:    cfg,
+		now:    time.Now,
+		base:   base,
+		stop:   stop,
+		active: make(map[string]context.CancelFunc),
+	}
+}
+
+// Submit 建立回補工作並在背景執行；工作不受 ctx（通常是 HTTP 請求）影響，Stop 時暫停並保留檢查點。
+func (r *BackfillRunner) Submit(ctx context.Context, job BackfillJob) (*BackfillJob, error) {
+	if len(job.Symbols) == 0 {
+		return nil, fmt.Errorf("no symbols to backfill")
+	}
+	if len(job.Timeframes) == 0 {
+		job.Timeframes = []string{"1d"}
+	}
+	if !job.End.After(job.Start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	job.Tasks = nil
+	for _, sym := range job.Symbols {
+		sym = strings.ToUpper(strings.TrimSpace(sym))
+		if sym == "" {
+			continue
+		}
+		for _, tf := range job.Timeframes {
+			if _, ok := klineSteps[tf]; !ok && !r.resampled(tf) {
+				return nil, fmt.Errorf("unsupported timeframe %q", tf)
+			}
+			job.Tasks = append(job.Tasks, BackfillTask{Symbol: sym, Timeframe: tf})
+		}
+	}
+	job.Status = BackfillQueued
+	job.Phase = PhaseDetect
+	job.CreatedAt = r.now()
+	if err := r.store.CreateBackfill(ctx, &job); err != nil {
+		return nil, fmt.Errorf("create backfill job: %w", err)
+	}
+	r.start(r.base, job.ID)
+	return &job, nil
+}
+
+// Resume 接續尚未結束且不在本實例執行中的工作（例如程序重啟前中斷的回補）；ctx 結束時工作暫停。
+func (r *BackfillRunner) Resume(ctx context.Context) error {
+	jobs, err := r.store.ListBackfills(ctx, BackfillQueued, BackfillRunning)
+	if err != nil {
+		return err
+	}
+	// 依建立順序接續
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	for _, job := range jobs {
+		log.Printf("[Backfill] resuming job %s (%s)", job.ID, job.Phase)
+		r.start(ctx, job.ID)
+	}
+	return nil
+}
+
+// Get 回傳工作目前的進度。
+func (r *BackfillRunner) Get(ctx context.Context, id string) (*BackfillJob, error) {
+	return r.store.GetBackfill(ctx, id)
+}
+
+// List 由新到舊回傳最近的工作。
+func (r *BackfillRunner) List(ctx context.Context) ([]BackfillJob, error) {
+	return r.store.ListBackfills(ctx)
+}
+
+// HasActive 回傳是否有尚未結束的工作。
+func (r *BackfillRunner) HasActive(ctx context.Context) (bool, error) {
+	jobs, err := r.store.ListBackfills(ctx, BackfillQueued, BackfillRunning)
+	if err != nil {
+		return false, err
+	}
+	return len(jobs) > 0, nil
+}
+
+// Cancel 將工作標記為取消並中止本實例上的執行。
+func (r *BackfillRunner) Cancel(ctx context.Context, id string) (*BackfillJob, error) {
+	job, err := r.store.GetBackfill(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Active() {
+		return job, ErrBackfillNotActive
+	}
+	job.Status = BackfillCanceled
+	job.FinishedAt = r.now()
+	if err := r.store.SaveBackfill(ctx, job); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	cancel, local := r.active[id]
+	executing := r.current == id
+	r.mu.Unlock()
+	if local {
+		cancel()
+	}
+	// 執行中的工作由 run 收尾；尚未開始的工作在此結案
+	if !executing && r.cfg.OnFinish != nil {
+		r.cfg.OnFinish(ctx, *job)
+	}
+	return job, nil
+}
+
+// Stop 暫停所有工作（保留檢查點）並等待背景 goroutine 結束。
+func (r *BackfillRunner) Stop() {
+	r.stop()
+	r.wg.Wait()
+}
+
+// start 在背景執行工作；已在本實例執行中的工作會被略過。
+func (r *BackfillRunner) start(ctx context.Context, id string) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	if _, ok := r.active[id]; ok {
+		r.mu.Unlock()
+		cancel()
+		return
+	}
+	r.active[id] = cancel
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.active, id)
+			r.mu.Unlock()
+			cancel()
+		}()
+		r.runMu.Lock()
+		defer r.runMu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		r.mu.Lock()
+		r.current = id
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			r.current = ""
+			r.mu.Unlock()
+		}()
+		r.run(ctx, id)
+	}()
+}
+
+// errCanceled 表示工作在檢查點被發現已取消。
+var errCanceled = errors.New("backfill canceled")
+
+func (r *BackfillRunner) run(ctx context.Context, id string) {
+	job, err := r.store.GetBackfill(ctx, id)
+	if err != nil || !job.Active() {
+		return
+	}
+	job.Status = BackfillRunning
+	if job.StartedAt.IsZero() {
+		job.StartedAt = r.now()
+	}
+	if err := r.store.SaveBackfill(ctx, job); err != nil {
+		log.Printf("[Backfill] job %s: save failed: %v", id, err)
+		return
+	}
+
+	err = r.execute(ctx, job)
+	switch {
+	case err == nil:
+		job.Status = BackfillCompleted
+		job.Phase = PhaseDone
+	case errors.Is(err, errCanceled):
+		job.Status = BackfillCanceled
+	case ctx.Err() != nil:
+		// 使用者取消時狀態已寫入；其餘（例如關機、失去領導權）保留檢查點待 Resume
+		if stored, gerr := r.store.GetBackfill(context.WithoutCancel(ctx), id); gerr == nil && stored.Status == BackfillCanceled {
+			job.Status = BackfillCanceled
+			break
+		}
+		log.Printf("[Backfill] job %s paused at %s", id, job.Phase)
+		return
+	default:
+		job.Status = BackfillFailed
+		job.Error = err.Error()
+	}
+	if job.FinishedAt.IsZero() {
+		job.FinishedAt = r.now()
+	}
+	finishCtx := context.WithoutCancel(ctx)
+	if err := r.store.SaveBackfill(finishCtx, job); err != nil {
+		log.Printf("[Backfill] job %s: save failed: %v", id, err)
+	}
+	log.Printf("[Backfill] job %s %s", id, job.Status)
+	if r.cfg.OnFinish != nil {
+		r.cfg.OnFinish(finishCtx, *job)
+	}
+}
+
+// execute 從目前階段接續執行；每個檢查點都會確認工作是否已被取消。
+func (r *BackfillRunner) execute(ctx context.Context, job *BackfillJob) error {
+	if job.Phase == PhaseDetect {
+		for i := range job.Tasks {
+			t := &job.Tasks[i]
+			if t.Detected {
+				continue
+			}
+			gaps, missing, err := r.detectGaps(ctx, t.Symbol, t.Timeframe, job.Start, job.End)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("detect gaps for %s %s: %w", t.Symbol, t.Timeframe, err)
+			}
+			t.Gaps, t.Missing, t.Detected = gaps, missing, true
+			if err := r.checkpoint(ctx, job); err != nil {
+				return err
+			}
+		}
+		job.Phase = PhaseFetch
+		if err := r.checkpoint(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	if job.Phase == PhaseFetch {
+		for i := range job.Tasks {
+			if err := r.fetchTask(ctx, job, &job.Tasks[i]); err != nil {
+				return err
+			}
+		}
+		job.Phase = PhaseAnalysis
+		if !job.RunAnalysis {
+			job.PendingAnalysis = nil
+		}
+		if err := r.checkpoint(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	if job.Phase == PhaseAnalysis && r.cfg.Analyze != nil {
+		for len(job.PendingAnalysis) > 0 {
+			// 連續的交易日一次分析，每個交易對只讀取一次歷史
+			days := 1
+			for days < len(job.PendingAnalysis) && days < maxAnalysisDays &&
+				job.PendingAnalysis[days].Equal(job.PendingAnalysis[days-1].Add(24*time.Hour)) {
+				days++
+			}
+			from := job.PendingAnalysis[0]
+			to := job.PendingAnalysis[days-1].Add(24 * time.Hour)
+			succ, fail, err := r.cfg.Analyze(ctx, from, to)
+			if err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			job.AnalysisSuccess += succ
+			job.AnalysisFailure += fail
+			if err != nil && len(job.AnalysisErrors) < maxBackfillErrors {
+				job.AnalysisErrors = append(job.AnalysisErrors, fmt.Sprintf("%s: %v", from.Format("2006-01-02"), err))
+			}
+			job.AnalyzedDays += days
+			job.PendingAnalysis = job.PendingAnalysis[days:]
+			if err := r.checkpoint(ctx, job); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fetchTask 依序抓取缺口，每批最多 BatchBars 根；交易所錯誤記在 task 上並略過其餘缺口。
+func (r *BackfillRunner) fetchTask(ctx context.Context, job *BackfillJob, t *BackfillTask) error {
+	step := dataingestion.TimeframeDuration(t.Timeframe)
+	for len(t.Gaps) > 0 && t.Error == "" {
+		gap := &t.Gaps[0]
+		end := gap.From.Add(time.Duration(r.cfg.BatchBars) * step)
+		if r.resampled(t.Timeframe) {
+			// 批次大小以來源根數計，至少一根目標 K 線
+			srcStep := dataingestion.TimeframeDuration(r.cfg.Base)
+			end = gap.From.Add(max(step, time.Duration(r.cfg.BatchBars)*srcStep/step*step))
+		}
+		if end.After(gap.To) {
+			end = gap.To
+		}
+		prices, err := r.fetch(ctx, t.Symbol, t.Timeframe, gap.From, end)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			t.Error = err.Error()
+			break
+		}
+		if r.cfg.Quality != nil {
+			clean, quarantined, err := r.cfg.Quality.Screen(ctx, prices)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("quality checks for %s %s: %w", t.Symbol, t.Timeframe, err)
+			}
+			prices = clean
+			t.Quarantined += len(quarantined)
+		}
+		for _, p := range prices {
+			if err := p.Validate(); err != nil {
+				continue
+			}
+			if err := r.repo.UpsertDailyPrice(ctx, p, true); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("store %s %s: %w", t.Symbol, t.Timeframe, err)
+			}
+			t.Stored++
+			if job.RunAnalysis {
+				job.PendingAnalysis = addDay(job.PendingAnalysis, p.TradeDate)
+			}
+		}
+		t.Processed += int(end.Sub(gap.From) / step)
+		gap.From = end
+		if !gap.From.Before(gap.To) {
+			t.Gaps = t.Gaps[1:]
+		}
+		if err := r.checkpoint(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resampled 回傳 timeframe 是否改由 Base 週期重新取樣。
+func (r *BackfillRunner) resampled(timeframe string) bool {
+	return r.cfg.Base != "" && dataingestion.CanResample(r.cfg.Base, timeframe)
+}
+
+// fetch 抓取 [start, end) 內的 K 線；由 Base 產生的週期先抓 Base 再重新取樣。
+func (r *BackfillRunner) fetch(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataingestion.DailyPrice, error) {
+	source := timeframe
+	if r.resampled(timeframe) {
+		source = r.cfg.Base
+	}
+	prices, err := r.klines.FetchKlines(ctx, symbol, source, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prices {
+		if prices[i].Timeframe == "" {
+			prices[i].Timeframe = source
+		}
+	}
+	if source == timeframe {
+		return prices, nil
+	}
+	return dataingestion.Resample(prices, timeframe, dataingestion.ResampleOptions{From: start, To: end})
+}
+
+// checkpoint 保存進度；若工作已在其他請求中被取消則回傳 errCanceled。
+func (r *BackfillRunner) checkpoint(ctx context.Context, job *BackfillJob) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored, err := r.store.GetBackfill(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Status == BackfillCanceled {
+		job.FinishedAt = stored.FinishedAt
+		return errCanceled
+	}
+	return r.store.SaveBackfill(ctx, job)
+}
+
+// detectGaps 比對 [from, to) 內應有的已收盤 K 線與已儲存的 K 線，合併成連續缺口。
+func (r *BackfillRunner) detectGaps(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]TimeRange, int, error) {
+	step := dataingestion.TimeframeDuration(timeframe)
+	from = from.UTC().Truncate(step)
+	// 尚未收盤的 K 線不回補
+	if closed := r.now().UTC().Truncate(step); to.After(closed) {
+		to = closed
+	}
+
+	var (
+		gaps    []TimeRange
+		missing int
+	)
+	window := time.Duration(r.cfg.BatchBars*5) * step
+	for ws := from; ws.Before(to); ws = ws.Add(window) {
+		we := ws.Add(window)
+		if we.After(to) {
+			we = to
+		}
+		times, err := r.bars.BarTimes(ctx, symbol, timeframe, ws, we)
+		if err != nil {
+			return nil, 0, err
+		}
+		have := make(map[time.Time]bool, len(times))
+		for _, t := range times {
+			have[t.UTC().Truncate(step)] = true
+		}
+		for t := ws; t.Before(we); t = t.Add(step) {
+			if have[t] {
+				continue
+			}
+			missing++
+			if n := len(gaps); n > 0 && gaps[n-1].To.Equal(t) {
+				gaps[n-1].To = t.Add(step)
+			} else {
+				gaps = append(gaps, TimeRange{From: t, To: t.Add(step)})
+			}
+		}
+	}
+	return gaps, missing, nil
+}
+
+// addDay 將 K 線所屬交易日（UTC）加入遞增且不重複的清單。
+func addDay(days []time.Time, t time.Time) []time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	i := sort.Search(len(days), func(i int) bool { return !days[i].Before(day) })
+	if i < len(days) && days[i].Equal(day) {
+		return days
+	}
+	days = append(days, time.Time{})
+	copy(days[i+1:], days[i:])
+	days[i] = day
+	return days
+}
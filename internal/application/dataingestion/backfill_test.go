@@ -0,0 +1,291 @@
+package dataingestion
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+type memBackfillStore struct {
+	mu   sync.Mutex
+	jobs map[string]BackfillJob
+	seq  int
+}
+
+func newMemBackfillStore() *memBackfillStore {
+	return &memBackfillStore{jobs: make(map[string]BackfillJob)}
+}
+
+func (s *memBackfillStore) CreateBackfill(_ context.Context, job *BackfillJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	job.ID = string(rune('a' + s.seq))
+	s.jobs[job.ID] = cloneJob(*job)
+	return nil
+}
+
+func (s *memBackfillStore) SaveBackfill(_ context.Context, job *BackfillJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = cloneJob(*job)
+	return nil
+}
+
+func (s *memBackfillStore) GetBackfill(_ context.Context, id string) (*BackfillJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrBackfillNotFound
+	}
+	out := cloneJob(job)
+	return &out, nil
+}
+
+func (s *memBackfillStore) ListBackfills(_ context.Context, statuses ...BackfillStatus) ([]BackfillJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []BackfillJob
+	for _, job := range s.jobs {
+		for _, st := range statuses {
+			if job.Status == st {
+				out = append(out, cloneJob(job))
+			}
+		}
+	}
+	return out, nil
+}
+
+func cloneJob(j BackfillJob) BackfillJob {
+	j.Tasks = append([]BackfillTask(nil), j.Tasks...)
+	for i := range j.Tasks {
+		j.Tasks[i].Gaps = append([]TimeRange(nil), j.Tasks[i].Gaps...)
+	}
+	j.PendingAnalysis = append([]time.Time(nil), j.PendingAnalysis...)
+	return j
+}
+
+// fakeBars 同時扮演 BarIndex 與 PriceRepository：寫入的 K 線會出現在 BarTimes。
+type fakeBars struct {
+	mu    sync.Mutex
+	times map[time.Time]bool
+}
+
+func (f *fakeBars) BarTimes(_ context.Context, _, _ string, from, to time.Time) ([]time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []time.Time
+	for t := range f.times {
+		if !t.Before(from) && t.Before(to) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeBars) UpsertDailyPrice(_ context.Context, p dataingestion.DailyPrice, _ bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.times[p.TradeDate] = true
+	return nil
+}
+
+// rangeKlines 回傳請求區間內每一根 1h K 線並記錄請求。
+type rangeKlines struct {
+	mu    sync.Mutex
+	calls []TimeRange
+}
+
+func (k *rangeKlines) FetchKlines(_ context.Context, symbol, timeframe string, start, end time.Time) ([]dataingestion.DailyPrice, error) {
+	k.mu.Lock()
+	k.calls = append(k.calls, TimeRange{From: start, To: end})
+	k.mu.Unlock()
+	var out []dataingestion.DailyPrice
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		out = append(out, dataingestion.DailyPrice{
+			Symbol: symbol, Market: dataingestion.MarketCrypto, Timeframe: timeframe, TradeDate: t,
+			Open: 1, High: 1, Low: 1, Close: 1, Volume: 1,
+		})
+	}
+	return out, nil
+}
+
+func newTestRunner(store BackfillStore, klines KlineSource, bars *fakeBars, done chan BackfillJob, analyzed *[]TimeRange) *BackfillRunner {
+	r := NewBackfillRunner(store, klines, bars, bars, BackfillConfig{
+		BatchBars: 3,
+		Analyze: func(_ context.Context, from, to time.Time) (int, int, error) {
+			*analyzed = append(*analyzed, TimeRange{From: from, To: to})
+			return int(to.Sub(from) / (24 * time.Hour)), 0, nil
+		},
+		OnFinish: func(_ context.Context, job BackfillJob) { done <- job },
+	})
+	r.now = func() time.Time { return time.Date(2024, 3, 3, 0, 30, 0, 0, time.UTC) }
+	return r
+}
+
+func TestBackfillRunner_DetectsGapsAndFetchesInBatches(t *testing.T) {
+	start := time.Date(2024, 3, 2, 20, 0, 0, 0, time.UTC)
+	// 20:00 與 23:00 已存在；00:00 尚未收盤不補
+	bars := &fakeBars{times: map[time.Time]bool{start: true, start.Add(3 * time.Hour): true}}
+	klines := &rangeKlines{}
+	store := newMemBackfillStore()
+	done := make(chan BackfillJob, 1)
+	var analyzed []TimeRange
+	r := newTestRunner(store, klines, bars, done, &analyzed)
+
+	_, err := r.Submit(context.Background(), BackfillJob{
+		Symbols: []string{"btcusdt"}, Timeframes: []string{"1h"},
+		Start: start.Add(-4 * time.Hour), End: start.Add(6 * time.Hour), RunAnalysis: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := <-done
+	r.Stop()
+
+	if job.Status != BackfillCompleted || job.Percent() != 100 {
+		t.Fatalf("expected completed job, got %+v", job)
+	}
+	task := job.Tasks[0]
+	// 16:00–19:00（4 根）與 21:00–22:00（2 根）
+	if task.Symbol != "BTCUSDT" || task.Missing != 6 || task.Stored != 6 || len(task.Gaps) != 0 {
+		t.Fatalf("unexpected task %+v", task)
+	}
+	want := []TimeRange{
+		{From: start.Add(-4 * time.Hour), To: start.Add(-time.Hour)},
+		{From: start.Add(-time.Hour), To: start},
+		{From: start.Add(time.Hour), To: start.Add(3 * time.Hour)},
+	}
+	if len(klines.calls) != len(want) {
+		t.Fatalf("expected %d batched requests, got %+v", len(want), klines.calls)
+	}
+	for i, c := range klines.calls {
+		if !c.From.Equal(want[i].From) || !c.To.Equal(want[i].To) {
+			t.Errorf("call %d: got %v-%v, want %v-%v", i, c.From, c.To, want[i].From, want[i].To)
+		}
+	}
+	if len(analyzed) != 1 || !analyzed[0].From.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) || job.AnalysisSuccess != 1 {
+		t.Errorf("expected the backfilled day analysed once, got %v", analyzed)
+	}
+}
+
+func TestBackfillRunner_ResumesFromCheckpoint(t *testing.T) {
+	gapStart := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	store := newMemBackfillStore()
+	// 模擬中斷前已偵測缺口並抓完第一批
+	job := &BackfillJob{
+		Symbols: []string{"BTCUSDT"}, Timeframes: []string{"1h"},
+		Start: gapStart.Add(-3 * time.Hour), End: gapStart.Add(2 * time.Hour),
+		Status: BackfillRunning, Phase: PhaseFetch,
+		Tasks: []BackfillTask{{
+			Symbol: "BTCUSDT", Timeframe: "1h", Detected: true, Missing: 5, Processed: 3, Stored: 3,
+			Gaps: []TimeRange{{From: gapStart, To: gapStart.Add(2 * time.Hour)}},
+		}},
+	}
+	_ = store.CreateBackfill(context.Background(), job)
+
+	klines := &rangeKlines{}
+	done := make(chan BackfillJob, 1)
+	var analyzed []TimeRange
+	r := newTestRunner(store, klines, &fakeBars{times: map[time.Time]bool{}}, done, &analyzed)
+	if err := r.Resume(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	finished := <-done
+	r.Stop()
+
+	if len(klines.calls) != 1 || !klines.calls[0].From.Equal(gapStart) {
+		t.Fatalf("expected only the remaining gap fetched, got %+v", klines.calls)
+	}
+	if task := finished.Tasks[0]; task.Processed != 5 || task.Stored != 5 || finished.Status != BackfillCompleted {
+		t.Fatalf("unexpected resumed job %+v", finished)
+	}
+}
+
+func TestBackfillRunner_CancelQueuedJob(t *testing.T) {
+	store := newMemBackfillStore()
+	job := &BackfillJob{Symbols: []string{"BTCUSDT"}, Status: BackfillQueued, Phase: PhaseDetect}
+	_ = store.CreateBackfill(context.Background(), job)
+
+	done := make(chan BackfillJob, 1)
+	var analyzed []TimeRange
+	r := newTestRunner(store, &rangeKlines{}, &fakeBars{times: map[time.Time]bool{}}, done, &analyzed)
+
+	canceled, err := r.Cancel(context.Background(), job.ID)
+	if err != nil || canceled.Status != BackfillCanceled {
+		t.Fatalf("expected canceled job, got %+v, %v", canceled, err)
+	}
+	if finished := <-done; finished.Status != BackfillCanceled {
+		t.Errorf("expected OnFinish with canceled job, got %+v", finished)
+	}
+	if _, err := r.Cancel(context.Background(), job.ID); !errors.Is(err, ErrBackfillNotActive) {
+		t.Errorf("expected ErrBackfillNotActive, got %v", err)
+	}
+	if _, err := r.Cancel(context.Background(), "missing"); !errors.Is(err, ErrBackfillNotFound) {
+		t.Errorf("expected ErrBackfillNotFound, got %v", err)
+	}
+}
+
+func TestBackfillRunner_ResamplesFromBase(t *testing.T) {
+	bars := &fakeBars{times: map[time.Time]bool{}}
+	klines := &rangeKlines{}
+	done := make(chan BackfillJob, 1)
+	var analyzed []TimeRange
+	r := newTestRunner(newMemBackfillStore(), klines, bars, done, &analyzed)
+	r.cfg.Base = "1h"
+	r.cfg.BatchBars = 8 // 每批兩根 4h
+
+	day := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := r.Submit(context.Background(), BackfillJob{
+		Symbols: []string{"BTCUSDT"}, Timeframes: []string{"4h", "1w"}, Start: day, End: day.AddDate(0, 0, 1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	job := <-done
+	r.Stop()
+
+	if job.Status != BackfillCompleted || job.Tasks[0].Stored != 6 {
+		t.Fatalf("expected 6 resampled 4h bars, got %+v", job)
+	}
+	// 1w 的整根尚未收盤，不回補
+	if job.Tasks[1].Missing != 0 || len(klines.calls) != 3 {
+		t.Fatalf("unexpected weekly task %+v or calls %+v", job.Tasks[1], klines.calls)
+	}
+	if !bars.times[day.Add(20*time.Hour)] {
+		t.Fatalf("expected 20:00 4h bar to be stored, got %v", bars.times)
+	}
+}
+
+func TestBackfillRunner_AnalysesConsecutiveDaysTogether(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemBackfillStore()
+	job := &BackfillJob{
+		Symbols: []string{"BTCUSDT"}, Start: day, End: day.AddDate(0, 0, 5), RunAnalysis: true,
+		Status: BackfillRunning, Phase: PhaseAnalysis,
+		PendingAnalysis: []time.Time{day, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), day.AddDate(0, 0, 4)},
+	}
+	_ = store.CreateBackfill(context.Background(), job)
+
+	done := make(chan BackfillJob, 1)
+	var analyzed []TimeRange
+	r := newTestRunner(store, &rangeKlines{}, &fakeBars{times: map[time.Time]bool{}}, done, &analyzed)
+	if err := r.Resume(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	finished := <-done
+	r.Stop()
+
+	want := []TimeRange{{From: day, To: day.AddDate(0, 0, 3)}, {From: day.AddDate(0, 0, 4), To: day.AddDate(0, 0, 5)}}
+	if !reflect.DeepEqual(analyzed, want) {
+		t.Fatalf("expected consecutive days in one call, got %+v", analyzed)
+	}
+	if finished.AnalyzedDays != 4 || finished.AnalysisSuccess != 4 || len(finished.PendingAnalysis) != 0 {
+		t.Fatalf("unexpected progress %+v", finished)
+	}
+}
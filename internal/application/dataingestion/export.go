@@ -0,0 +1,189 @@
+package dataingestion
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// 可匯出的資料集。
+const (
+	DatasetPrices   = "prices"   // daily_prices
+	DatasetAnalysis = "analysis" // analysis_results
+)
+
+// ExportSource 依時間遞增分頁讀取 [from, to) 內的資料。
+type ExportSource interface {
+	PriceRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]dataingestion.DailyPrice, error)
+	AnalysisRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]analysisDomain.DailyAnalysisResult, error)
+}
+
+// ExportRequest 指定匯出的資料集、交易對、週期與時間範圍 [From, To)。
+type ExportRequest struct {
+	Dataset   string
+	Format    string
+	Symbol    string
+	Timeframe string
+	From      time.Time
+	To        time.Time
+	// Location 為輸出時間的時區，預設 UTC。
+	Location *time.Location
+}
+
+// Validate 檢查匯出條件。
+func (r ExportRequest) Validate() error {
+	switch r.Format {
+	case "", FormatCSV:
+	case FormatParquet:
+		return fmt.Errorf("%w: parquet export is not supported by this build, use csv", ErrUnsupportedFormat)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, r.Format)
+	}
+	if r.Dataset != DatasetPrices && r.Dataset != DatasetAnalysis {
+		return fmt.Errorf("invalid dataset %q", r.Dataset)
+	}
+	if r.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if dataingestion.TimeframeDuration(r.Timeframe) == 0 {
+		return fmt.Errorf("invalid timeframe %q", r.Timeframe)
+	}
+	if !r.To.After(r.From) {
+		return fmt.Errorf("to must be after from")
+	}
+	return nil
+}
+
+// exportPageSize 為每次向資料庫讀取的筆數，匯出時記憶體用量不隨範圍增加。
+const exportPageSize = 1000
+
+// Exporter 將儲存的 K 線或分析結果以 CSV 串流輸出。
+type Exporter struct {
+	src      ExportSource
+	pageSize int
+}
+
+// NewExporter 建立匯出流程。
+func NewExporter(src ExportSource) *Exporter {
+	return &Exporter{src: src, pageSize: exportPageSize}
+}
+
+// PriceColumns 為 K 線匯出的欄位，與匯入的預設欄名一致，可直接匯回。
+var PriceColumns = []string{"symbol", "market", "timeframe", "open_time", "open", "high", "low", "close", "volume"}
+
+// AnalysisColumns 為分析結果匯出的欄位。
+var AnalysisColumns = []string{
+	"symbol", "market", "timeframe", "trade_date", "version",
+	"close", "change", "change_rate", "return_5", "return_20", "return_60",
+	"high_20", "low_20", "range_pos_20", "ma_5", "ma_10", "ma_20", "ma_60", "deviation_20",
+	"volume", "avg_volume_5", "avg_volume_20", "volume_multiple", "amplitude", "avg_amplitude_20",
+	"score", "tags", "success", "error_reason",
+}
+
+// Export 寫出標題列後逐頁讀取並寫入 w，回傳資料筆數。
+func (e *Exporter) Export(ctx context.Context, w io.Writer, req ExportRequest) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+	loc := req.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	cw := csv.NewWriter(w)
+	header := PriceColumns
+	if req.Dataset == DatasetAnalysis {
+		header = AnalysisColumns
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	for from := req.From; ; {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		var (
+			records [][]string
+			last    time.Time
+			err     error
+		)
+		if req.Dataset == DatasetPrices {
+			records, last, err = e.pricePage(ctx, req, from, loc)
+		} else {
+			records, last, err = e.analysisPage(ctx, req, from, loc)
+		}
+		if err != nil {
+			return rows, err
+		}
+		if err := cw.WriteAll(records); err != nil {
+			return rows, err
+		}
+		rows += len(records)
+		if len(records) < e.pageSize {
+			return rows, nil
+		}
+		// 資料庫時間精度為微秒，下一頁從最後一筆之後開始
+		from = last.Add(time.Microsecond)
+	}
+}
+
+func (e *Exporter) pricePage(ctx context.Context, req ExportRequest, from time.Time, loc *time.Location) ([][]string, time.Time, error) {
+	prices, err := e.src.PriceRange(ctx, req.Symbol, req.Timeframe, from, req.To, e.pageSize)
+	if err != nil || len(prices) == 0 {
+		return nil, time.Time{}, err
+	}
+	out := make([][]string, len(prices))
+	for i, p := range prices {
+		out[i] = []string{
+			p.Symbol, string(p.Market), p.Timeframe, p.TradeDate.In(loc).Format(time.RFC3339),
+			formatFloat(p.Open), formatFloat(p.High), formatFloat(p.Low), formatFloat(p.Close),
+			strconv.FormatInt(p.Volume, 10),
+		}
+	}
+	return out, prices[len(prices)-1].TradeDate, nil
+}
+
+func (e *Exporter) analysisPage(ctx context.Context, req ExportRequest, from time.Time, loc *time.Location) ([][]string, time.Time, error) {
+	results, err := e.src.AnalysisRange(ctx, req.Symbol, req.Timeframe, from, req.To, e.pageSize)
+	if err != nil || len(results) == 0 {
+		return nil, time.Time{}, err
+	}
+	out := make([][]string, len(results))
+	for i, r := range results {
+		tags := make([]string, len(r.Tags))
+		for k, t := range r.Tags {
+			tags[k] = string(t)
+		}
+		out[i] = []string{
+			r.Symbol, string(r.Market), r.Timeframe, r.TradeDate.In(loc).Format(time.RFC3339), r.Version,
+			formatFloat(r.Close), formatFloat(r.Change), formatFloat(r.ChangeRate),
+			formatOptional(r.Return5), formatOptional(r.Return20), formatOptional(r.Return60),
+			formatOptional(r.High20), formatOptional(r.Low20), formatOptional(r.RangePos20),
+			formatOptional(r.MA5), formatOptional(r.MA10), formatOptional(r.MA20), formatOptional(r.MA60), formatOptional(r.Deviation20),
+			strconv.FormatInt(r.Volume, 10), formatOptional(r.AvgVolume5), formatOptional(r.AvgVolume20), formatOptional(r.VolumeMultiple),
+			formatOptional(r.Amplitude), formatOptional(r.AvgAmplitude20),
+			formatFloat(r.Score), strings.Join(tags, "|"), strconv.FormatBool(r.Success), r.ErrorReason,
+		}
+	}
+	return out, results[len(results)-1].TradeDate, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatOptional 將未計算的指標輸出為空欄位。
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
@@ -0,0 +1,467 @@
+package dataingestion
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// 匯入／匯出的檔案格式。
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// ErrUnsupportedFormat 表示檔案格式無法處理。
+var ErrUnsupportedFormat = errors.New("unsupported file format")
+
+// parquetMagic 為 Parquet 檔案開頭的魔術字。
+var parquetMagic = []byte("PAR1")
+
+// FormatFromName 依副檔名判斷格式，無法判斷時回傳 CSV。
+func FormatFromName(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".parquet") {
+		return FormatParquet
+	}
+	return FormatCSV
+}
+
+// ColumnMapping 指定 K 線欄位在檔案中的欄名；未指定者依常見欄名比對（不分大小寫）。
+type ColumnMapping struct {
+	Time   string
+	Open   string
+	High   string
+	Low    string
+	Close  string
+	Volume string
+	Symbol string
+}
+
+// ParseColumnMapping 解析 "time=Date,close=Adj Close" 形式的欄位對應。
+func ParseColumnMapping(s string) (ColumnMapping, error) {
+	var m ColumnMapping
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return m, fmt.Errorf("invalid column mapping %q", pair)
+		}
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "time":
+			m.Time = column
+		case "open":
+			m.Open = column
+		case "high":
+			m.High = column
+		case "low":
+			m.Low = column
+		case "close":
+			m.Close = column
+		case "volume":
+			m.Volume = column
+		case "symbol":
+			m.Symbol = column
+		default:
+			return m, fmt.Errorf("unknown column %q in mapping", field)
+		}
+	}
+	return m, nil
+}
+
+// defaultColumns 為各欄位未指定時依序嘗試的欄名。
+var defaultColumns = map[string][]string{
+	"time":   {"open_time", "time", "timestamp", "datetime", "date", "trade_date"},
+	"open":   {"open", "open_price"},
+	"high":   {"high", "high_price"},
+	"low":    {"low", "low_price"},
+	"close":  {"close", "close_price"},
+	"volume": {"volume", "vol"},
+	"symbol": {"symbol", "pair", "trading_pair"},
+}
+
+// ImportOptions 描述匯入檔案的格式與解讀方式。
+type ImportOptions struct {
+	Format    string
+	Symbol    string // 檔案沒有 symbol 欄位時套用
+	Market    dataingestion.Market
+	Timeframe string
+	// Location 用於解讀沒有時區資訊的時間，預設 UTC。
+	Location *time.Location
+	// TimeLayout 為時間欄位的 Go layout；空白時自動判斷 unix 秒／毫秒、RFC3339 與常見日期格式。
+	TimeLayout string
+	Columns    ColumnMapping
+}
+
+// Validate 檢查匯入選項。
+func (o ImportOptions) Validate() error {
+	switch o.Format {
+	case "", FormatCSV, FormatParquet:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, o.Format)
+	}
+	if dataingestion.TimeframeDuration(o.Timeframe) == 0 {
+		return fmt.Errorf("invalid timeframe %q", o.Timeframe)
+	}
+	return nil
+}
+
+// ImportFailure 為無法匯入的資料列與原因；Line 為檔案中的行號（含標題列），Parquet 為資料列序號加一。
+type ImportFailure struct {
+	Line   int
+	Reason string
+}
+
+// ImportResult 為一次匯入的統計；Failures 最多保留 maxImportFailures 筆。
+type ImportResult struct {
+	Rows        int
+	Stored      int
+	Failed      int
+	Quarantined int
+	Failures    []ImportFailure
+	From        time.Time
+	To          time.Time
+}
+
+const (
+	maxImportFailures = 100
+	importBatchSize   = 1000
+)
+
+func (r *ImportResult) fail(line int, reason string) {
+	r.Failed++
+	if len(r.Failures) < maxImportFailures {
+		r.Failures = append(r.Failures, ImportFailure{Line: line, Reason: reason})
+	}
+}
+
+// ImportUseCase 將外部來源的歷史 K 線檔案逐批驗證後寫入 PriceRepository。
+type ImportUseCase struct {
+	repo    PriceRepository
+	quality *QualityGate
+}
+
+// NewImportUseCase 建立歷史資料匯入流程。
+func NewImportUseCase(repo PriceRepository) *ImportUseCase {
+	return &ImportUseCase{repo: repo}
+}
+
+// SetQualityGate 設定寫入前的資料品質檢查；未設定時只做欄位驗證。
+func (u *ImportUseCase) SetQualityGate(g *QualityGate) {
+	u.quality = g
+}
+
+// Import 串流讀取檔案並分批寫入；單列錯誤計入失敗並繼續，讀取或寫入資料庫失敗則中止。
+func (u *ImportUseCase) Import(ctx context.Context, r io.Reader, opts ImportOptions) (ImportResult, error) {
+	var result ImportResult
+	if err := opts.Validate(); err != nil {
+		return result, err
+	}
+	if opts.Market == "" {
+		opts.Market = dataingestion.MarketCrypto
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	opts.Timeframe = strings.ToLower(opts.Timeframe)
+
+	// 副檔名或格式欄位與內容不符時以內容為準
+	var rows importRows
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(parquetMagic)); opts.Format == FormatParquet || bytes.Equal(head, parquetMagic) {
+		pq, err := openParquetRows(r, br)
+		if err != nil {
+			return result, err
+		}
+		defer pq.Close()
+		rows = pq
+	} else {
+		reader := csv.NewReader(br)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		reader.ReuseRecord = true
+		header, err := reader.Read()
+		if err != nil {
+			return result, fmt.Errorf("read header: %w", err)
+		}
+		// ReuseRecord 會覆寫標題列的切片，先複製
+		rows = csvRows{r: reader, header: slices.Clone(header)}
+	}
+
+	cols, err := resolveColumns(rows.Header(), opts)
+	if err != nil {
+		return result, err
+	}
+	if pq, ok := rows.(*parquetRows); ok && pq.isTime[cols.time] {
+		opts.TimeLayout = "" // 時間型別欄位已轉成帶格式的字串
+	}
+
+	batch := make([]dataingestion.DailyPrice, 0, importBatchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		record, line, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			result.Rows++
+			result.fail(rowErr.line, rowErr.reason)
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("read file: %w", err)
+		}
+		result.Rows++
+
+		price, err := cols.price(record, opts)
+		if err == nil {
+			err = price.Validate()
+		}
+		if err != nil {
+			result.fail(line, err.Error())
+			continue
+		}
+		batch = append(batch, price)
+		if len(batch) == importBatchSize {
+			if err := u.flush(ctx, batch, &result); err != nil {
+				return result, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := u.flush(ctx, batch, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// flush 經品質檢查後寫入一批 K 線。
+func (u *ImportUseCase) flush(ctx context.Context, batch []dataingestion.DailyPrice, result *ImportResult) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	clean := batch
+	if u.quality != nil {
+		var (
+			quarantined []QuarantinedBar
+			err         error
+		)
+		clean, quarantined, err = u.quality.Screen(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("quality checks: %w", err)
+		}
+		result.Quarantined += len(quarantined)
+	}
+	for _, p := range clean {
+		if err := u.repo.UpsertDailyPrice(ctx, p, true); err != nil {
+			return fmt.Errorf("store %s %s: %w", p.Symbol, p.TradeDate.Format(time.RFC3339), err)
+		}
+		result.Stored++
+		if result.From.IsZero() || p.TradeDate.Before(result.From) {
+			result.From = p.TradeDate
+		}
+		if p.TradeDate.After(result.To) {
+			result.To = p.TradeDate
+		}
+	}
+	return nil
+}
+
+// importRows 逐列讀取匯入檔案，各格式轉成字串欄位後共用欄位對應與驗證。
+type importRows interface {
+	Header() []string
+	// Next 回傳下一列與其行號；單列無法解讀時回傳 *importRowError，讀完時回傳 io.EOF。
+	Next() ([]string, int, error)
+}
+
+// importRowError 表示單列無法解讀，計入失敗後繼續讀取。
+type importRowError struct {
+	line   int
+	reason string
+}
+
+func (e *importRowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.reason)
+}
+
+// csvRows 為已讀過標題列的 CSV。
+type csvRows struct {
+	r      *csv.Reader
+	header []string
+}
+
+func (c csvRows) Header() []string {
+	return c.header
+}
+
+func (c csvRows) Next() ([]string, int, error) {
+	record, err := c.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, parseErr.Line, &importRowError{line: parseErr.Line, reason: parseErr.Err.Error()}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return record, line, nil
+}
+
+// importColumns 為各欄位在資料列中的索引；-1 表示檔案沒有該欄位。
+type importColumns struct {
+	time, open, high, low, close, volume, symbol int
+}
+
+func resolveColumns(header []string, opts ImportOptions) (importColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff") // UTF-8 BOM
+		}
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	find := func(field, explicit string, required bool) (int, error) {
+		if explicit != "" {
+			if i, ok := index[strings.ToLower(explicit)]; ok {
+				return i, nil
+			}
+			return -1, fmt.Errorf("column %q for %s not found in header", explicit, field)
+		}
+		for _, name := range defaultColumns[field] {
+			if i, ok := index[name]; ok {
+				return i, nil
+			}
+		}
+		if required {
+			return -1, fmt.Errorf("no %s column in header, map one with %s=<column>", field, field)
+		}
+		return -1, nil
+	}
+
+	var (
+		cols importColumns
+		err  error
+	)
+	m := opts.Columns
+	for _, c := range []struct {
+		dst      *int
+		field    string
+		explicit string
+		required bool
+	}{
+		{&cols.time, "time", m.Time, true},
+		{&cols.open, "open", m.Open, true},
+		{&cols.high, "high", m.High, true},
+		{&cols.low, "low", m.Low, true},
+		{&cols.close, "close", m.Close, true},
+		{&cols.volume, "volume", m.Volume, true},
+		{&cols.symbol, "symbol", m.Symbol, opts.Symbol == ""},
+	} {
+		if *c.dst, err = find(c.field, c.explicit, c.required); err != nil {
+			return cols, err
+		}
+	}
+	return cols, nil
+}
+
+func (c importColumns) price(record []string, opts ImportOptions) (dataingestion.DailyPrice, error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	p := dataingestion.DailyPrice{
+		Symbol:    strings.ToUpper(opts.Symbol),
+		Market:    opts.Market,
+		Timeframe: opts.Timeframe,
+	}
+	if c.symbol >= 0 {
+		if s := field(c.symbol); s != "" {
+			p.Symbol = strings.ToUpper(s)
+		}
+	}
+
+	var err error
+	if p.TradeDate, err = parseImportTime(field(c.time), opts.TimeLayout, opts.Location); err != nil {
+		return p, err
+	}
+	for _, f := range []struct {
+		dst  *float64
+		name string
+		idx  int
+	}{
+		{&p.Open, "open", c.open},
+		{&p.High, "high", c.high},
+		{&p.Low, "low", c.low},
+		{&p.Close, "close", c.close},
+	} {
+		if *f.dst, err = strconv.ParseFloat(field(f.idx), 64); err != nil {
+			return p, fmt.Errorf("invalid %s %q", f.name, field(f.idx))
+		}
+	}
+	volume, err := strconv.ParseFloat(field(c.volume), 64)
+	if err != nil {
+		return p, fmt.Errorf("invalid volume %q", field(c.volume))
+	}
+	p.Volume = int64(math.Round(volume))
+	return p, nil
+}
+
+// importTimeLayouts 為未指定 layout 時依序嘗試的格式。
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// parseImportTime 解析時間欄位；純數字視為 unix 時間，依位數判斷秒、毫秒或微秒。
+func parseImportTime(v, layout string, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, errors.New("missing time")
+	}
+	if layout != "" {
+		t, err := time.ParseInLocation(layout, v, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", v)
+		}
+		return t.UTC(), nil
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		switch {
+		case n >= 1e14:
+			return time.UnixMicro(int64(n)).UTC(), nil
+		case n >= 1e11:
+			return time.UnixMilli(int64(n)).UTC(), nil
+		default:
+			return time.Unix(int64(n), 0).UTC(), nil
+		}
+	}
+	for _, l := range importTimeLayouts {
+		if t, err := time.ParseInLocation(l, v, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
@@ -0,0 +1,230 @@
+package dataingestion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	domain "ai-auto-trade/internal/domain/dataingestion"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestImportUseCase_MapsColumnsAndTimezone(t *testing.T) {
+	file := "\ufeffDate,Open,High,Low,Adj Close,Vol\n" +
+		"2024-01-02 09:00:00,100,110,95,105,12.6\n" +
+		"2024-01-02 10:00:00,105,100,95,104,3\n" + // 高價低於開盤：驗證失敗
+		"bad-time,1,1,1,1,1\n" +
+		"2024-01-02 11:00:00,104,108,103,107,4\n"
+	repo := &fakeRepo{}
+	loc, _ := time.LoadLocation("Asia/Taipei")
+
+	res, err := NewImportUseCase(repo).Import(context.Background(), strings.NewReader(file), ImportOptions{
+		Symbol:    "btcusdt",
+		Timeframe: "1H",
+		Location:  loc,
+		Columns:   ColumnMapping{Time: "date", Close: "Adj Close"},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Rows != 4 || res.Stored != 2 || res.Failed != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.Failures[0].Line != 3 || res.Failures[1].Line != 4 {
+		t.Fatalf("expected failures on lines 3 and 4, got %+v", res.Failures)
+	}
+	first := repo.stored[0]
+	if first.Symbol != "BTCUSDT" || first.Timeframe != "1h" || first.Market != domain.MarketCrypto || first.Close != 105 || first.Volume != 13 {
+		t.Fatalf("unexpected bar %+v", first)
+	}
+	if want := time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC); !first.TradeDate.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, first.TradeDate)
+	}
+}
+
+func TestImportUseCase_UnixTimesAndSymbolColumn(t *testing.T) {
+	file := "symbol,open_time,open,high,low,close,volume\n" +
+		"ethusdt,1704067200000,1,2,1,2,5\n" +
+		"BTCUSDT,1704067200,1,2,1,2,5\n"
+	repo := &fakeRepo{}
+	res, err := NewImportUseCase(repo).Import(context.Background(), strings.NewReader(file), ImportOptions{Timeframe: "1d"})
+	if err != nil || res.Stored != 2 {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if repo.stored[0].Symbol != "ETHUSDT" || !repo.stored[0].TradeDate.Equal(want) || !repo.stored[1].TradeDate.Equal(want) {
+		t.Fatalf("unexpected bars %+v", repo.stored)
+	}
+}
+
+func TestImportUseCase_RejectsBadInput(t *testing.T) {
+	uc := NewImportUseCase(&fakeRepo{})
+	ctx := context.Background()
+
+	if _, err := uc.Import(ctx, strings.NewReader("x"), ImportOptions{Format: "xlsx", Timeframe: "1d"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected xlsx to be unsupported, got %v", err)
+	}
+	if _, err := uc.Import(ctx, strings.NewReader("PAR1...."), ImportOptions{Timeframe: "1d"}); err == nil || !strings.Contains(err.Error(), "parquet") {
+		t.Fatalf("expected parquet content to be read as parquet, got %v", err)
+	}
+	if _, err := uc.Import(ctx, strings.NewReader("time,open,high,low,close,volume\n"), ImportOptions{Timeframe: "1d"}); err == nil {
+		t.Fatalf("expected missing symbol column to fail")
+	}
+	if _, err := uc.Import(ctx, strings.NewReader("a,b\n"), ImportOptions{Timeframe: "2d", Symbol: "X"}); err == nil {
+		t.Fatalf("expected invalid timeframe to fail")
+	}
+	if _, err := ParseColumnMapping("time=Date,foo=Bar"); err == nil {
+		t.Fatalf("expected unknown mapping field to fail")
+	}
+}
+
+// parquetCandle 為測試用 Parquet 檔案的欄位，時間為 UTC 毫秒時間戳。
+type parquetCandle struct {
+	Symbol string    `parquet:"pair"`
+	Time   time.Time `parquet:"ts,timestamp(millisecond)"`
+	Open   float64   `parquet:"open"`
+	High   float64   `parquet:"high"`
+	Low    float64   `parquet:"low"`
+	Close  float64   `parquet:"adj_close"`
+	Volume int64     `parquet:"volume"`
+}
+
+func TestImportUseCase_ReadsParquetThroughColumnMapping(t *testing.T) {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[parquetCandle](&buf)
+	t0 := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	if _, err := w.Write([]parquetCandle{
+		{Symbol: "btcusdt", Time: t0, Open: 100, High: 110, Low: 95, Close: 105.123456789, Volume: 12},
+		{Symbol: "btcusdt", Time: t0.Add(time.Hour), Open: 105, High: 100, Low: 95, Close: 104, Volume: 3}, // 高價低於開盤
+	}); err != nil {
+		t.Fatalf("write parquet: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close parquet: %v", err)
+	}
+
+	repo := &fakeRepo{}
+	loc, _ := time.LoadLocation("Asia/Taipei")
+	// 檔名為 .csv 時仍依內容辨識為 Parquet；時間型別欄位忽略 TimeLayout
+	res, err := NewImportUseCase(repo).Import(context.Background(), bytes.NewReader(buf.Bytes()), ImportOptions{
+		Timeframe:  "1h",
+		Location:   loc,
+		TimeLayout: "2006/01/02",
+		Columns:    ColumnMapping{Time: "ts", Close: "adj_close", Symbol: "pair"},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Rows != 2 || res.Stored != 1 || res.Failed != 1 || res.Failures[0].Line != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	first := repo.stored[0]
+	if first.Symbol != "BTCUSDT" || first.Close != 105.123456789 || first.Volume != 12 {
+		t.Fatalf("unexpected bar %+v", first)
+	}
+	if !first.TradeDate.Equal(t0) {
+		t.Fatalf("UTC timestamps must ignore the import location, want %s got %s", t0, first.TradeDate)
+	}
+}
+
+func TestImportUseCase_QuarantinesThroughQualityGate(t *testing.T) {
+	file := "time,open,high,low,close,volume\n" +
+		"2024-01-01T00:00:00Z,1,2,1,2,5\n" +
+		"2024-01-01T01:00:00Z,2,2,2,2,0\n"
+	repo := &fakeRepo{}
+	uc := NewImportUseCase(repo)
+	uc.SetQualityGate(NewQualityGate(fakeHistory{}, newMemQualityStore(), domain.QualityRules{}))
+
+	res, err := uc.Import(context.Background(), strings.NewReader(file), ImportOptions{Symbol: "BTCUSDT", Timeframe: "1h"})
+	if err != nil || res.Stored != 1 || res.Quarantined != 1 || len(repo.stored) != 1 {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+}
+
+// rangeSource 以切片模擬資料庫分頁查詢。
+type rangeSource struct {
+	prices   []domain.DailyPrice
+	analysis []analysisDomain.DailyAnalysisResult
+	calls    int
+}
+
+func (s *rangeSource) PriceRange(_ context.Context, _, _ string, from, to time.Time, limit int) ([]domain.DailyPrice, error) {
+	s.calls++
+	var out []domain.DailyPrice
+	for _, p := range s.prices {
+		if !p.TradeDate.Before(from) && p.TradeDate.Before(to) && len(out) < limit {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *rangeSource) AnalysisRange(_ context.Context, _, _ string, from, to time.Time, limit int) ([]analysisDomain.DailyAnalysisResult, error) {
+	s.calls++
+	var out []analysisDomain.DailyAnalysisResult
+	for _, r := range s.analysis {
+		if !r.TradeDate.Before(from) && r.TradeDate.Before(to) && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func TestExporter_PagesAndRoundTrips(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &rangeSource{}
+	for i := 0; i < 5; i++ {
+		src.prices = append(src.prices, hourBar(start, i, 100+float64(i)))
+	}
+	exp := NewExporter(src)
+	exp.pageSize = 2
+
+	var buf bytes.Buffer
+	rows, err := exp.Export(context.Background(), &buf, ExportRequest{
+		Dataset: DatasetPrices, Symbol: "BTCUSDT", Timeframe: "1h", From: start, To: start.Add(4 * time.Hour),
+	})
+	if err != nil || rows != 4 {
+		t.Fatalf("expected 4 rows, got %d err=%v", rows, err)
+	}
+	if src.calls != 3 {
+		t.Fatalf("expected 3 page queries, got %d", src.calls)
+	}
+
+	// 匯出的檔案可直接匯回
+	repo := &fakeRepo{}
+	res, err := NewImportUseCase(repo).Import(context.Background(), &buf, ImportOptions{Timeframe: "1h"})
+	if err != nil || res.Stored != 4 || res.Failed != 0 {
+		t.Fatalf("round trip failed: %+v err=%v", res, err)
+	}
+	if repo.stored[3] != src.prices[3] {
+		t.Fatalf("round trip changed bar: %+v vs %+v", repo.stored[3], src.prices[3])
+	}
+}
+
+func TestExporter_AnalysisColumns(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ma := 101.5
+	src := &rangeSource{analysis: []analysisDomain.DailyAnalysisResult{{
+		Symbol: "BTCUSDT", Market: domain.MarketCrypto, Timeframe: "1d", TradeDate: day, Version: "v1",
+		Close: 100, MA20: &ma, Score: 72, Tags: []analysisDomain.Tag{analysisDomain.TagVolumeSurge}, Success: true,
+	}}}
+	var buf bytes.Buffer
+	rows, err := NewExporter(src).Export(context.Background(), &buf, ExportRequest{
+		Dataset: DatasetAnalysis, Symbol: "BTCUSDT", Timeframe: "1d", From: day, To: day.AddDate(0, 0, 1),
+	})
+	if err != nil || rows != 1 {
+		t.Fatalf("expected 1 row, got %d err=%v", rows, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "BTCUSDT,CRYPTO,1d,2024-01-01T00:00:00Z,v1,100,") || !strings.Contains(lines[1], ",101.5,") {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+	if _, err := NewExporter(src).Export(context.Background(), &buf, ExportRequest{Dataset: "trades", Symbol: "BTCUSDT", Timeframe: "1d", From: day, To: day}); err == nil {
+		t.Fatalf("expected invalid request to fail")
+	}
+}
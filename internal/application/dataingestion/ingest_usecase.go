@@ -2,6 +2,7 @@ package dataingestion
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,8 +21,9 @@ type PriceRepository interface {
 
 // IngestUseCase 提供每日例行/回補/重抓的共用流程。
 type IngestUseCase struct {
-	source PriceSource
-	repo   PriceRepository
+	source  PriceSource
+	repo    PriceRepository
+	quality *QualityGate
 }
 
 // NewIngestUseCase 建立資料擷取用例，串接外部來源與儲存端。
@@ -32,6 +34,11 @@ func NewIngestUseCase(source PriceSource, repo PriceRepository) *IngestUseCase {
 	}
 }
 
+// SetQualityGate 在寫入前加入資料品質檢查，可疑的 K 線改為隔離。
+func (u *IngestUseCase) SetQualityGate(g *QualityGate) {
+	u.quality = g
+}
+
 type IngestMode string
 
 const (
@@ -50,14 +57,50 @@ type IngestInput struct {
 }
 
 type Failure struct {
-	Symbol string
-	Reason string
+	Symbol    string
+	Timeframe string
+	Reason    string
+}
+
+// Item 彙總單一交易對 × 週期的寫入結果，供 job 明細記錄。
+type Item struct {
+	Symbol      string
+	Timeframe   string
+	Stored      int
+	Failed      int
+	Quarantined int
+	Reason      string // 第一個失敗原因
 }
 
 type IngestResult struct {
-	SuccessCount int
-	FailedCount  int
-	Failures     []Failure
+	SuccessCount     int
+	FailedCount      int
+	QuarantinedCount int
+	Failures         []Failure
+	Items            []Item
+}
+
+// track 依交易對與週期累計結果，維持第一次出現的順序。
+func (r *IngestResult) track(symbol, timeframe string, ok bool, reason string) {
+	it := r.item(symbol, timeframe)
+	if ok {
+		it.Stored++
+		return
+	}
+	it.Failed++
+	if it.Reason == "" {
+		it.Reason = reason
+	}
+}
+
+func (r *IngestResult) item(symbol, timeframe string) *Item {
+	for i := range r.Items {
+		if r.Items[i].Symbol == symbol && r.Items[i].Timeframe == timeframe {
+			return &r.Items[i]
+		}
+	}
+	r.Items = append(r.Items, Item{Symbol: symbol, Timeframe: timeframe})
+	return &r.Items[len(r.Items)-1]
 }
 
 // Execute 執行一次資料抓取與寫入。
@@ -73,30 +116,55 @@ func (u *IngestUseCase) Execute(ctx context.Context, input IngestInput) (IngestR
 	}
 
 	rawPrices, err := u.source.FetchDaily(ctx, input.Date, input.Symbols, input.MarketFilter)
-	if err != nil {
+	var partial *FetchError
+	if errors.As(err, &partial) {
+		result.FailedCount += len(partial.Failures)
+		result.Failures = append(result.Failures, partial.Failures...)
+		for _, f := range partial.Failures {
+			result.track(f.Symbol, f.Timeframe, false, f.Reason)
+		}
+	} else if err != nil {
 		return result, fmt.Errorf("fetch daily prices: %w", err)
 	}
 
+	if u.quality != nil {
+		clean, quarantined, err := u.quality.Screen(ctx, rawPrices)
+		if err != nil {
+			return result, fmt.Errorf("quality checks: %w", err)
+		}
+		for _, q := range quarantined {
+			result.QuarantinedCount++
+			result.item(q.Price.Symbol, q.Price.Timeframe).Quarantined++
+		}
+		rawPrices = clean
+	}
+
 	for _, p := range rawPrices {
 		if err := p.Validate(); err != nil {
 			result.FailedCount++
 			result.Failures = append(result.Failures, Failure{
-				Symbol: p.Symbol,
-				Reason: err.Error(),
+				Symbol:    p.Symbol,
+				Timeframe: p.Timeframe,
+				Reason:    err.Error(),
 			})
+			result.track(p.Symbol, p.Timeframe, false, err.Error())
 			continue
 		}
 
 		if err := u.repo.UpsertDailyPrice(ctx, p, input.Replace); err != nil {
+			reason := fmt.Sprintf("store failed: %v", err)
 			result.FailedCount++
 			result.Failures = append(result.Failures, Failure{
-				Symbol: p.Symbol,
-				Reason: fmt.Sprintf("store failed: %v", err),
+				Symbol:    p.Symbol,
+				Timeframe: p.Timeframe,
+				Reason:    reason,
 			})
+			result.track(p.Symbol, p.Timeframe, false, reason)
 			continue
 		}
 
 		result.SuccessCount++
+		result.track(p.Symbol, p.Timeframe, true, "")
 	}
 
 	return result, nil
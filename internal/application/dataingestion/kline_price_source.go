@@ -0,0 +1,220 @@
+package dataingestion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// klineSteps 為可擷取的 K 線週期與其長度。
+var klineSteps = map[string]time.Duration{
+	"1m":  time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// FetchError 表示部分交易對/週期抓取失敗；其餘成功的資料仍會一併回傳。
+type FetchError struct {
+	Failures []Failure
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetch failed for %d symbol/timeframe pairs", len(e.Failures))
+}
+
+// KlinePriceSourceConfig 設定觀察清單、擷取週期與每次往回重抓的根數。
+type KlinePriceSourceConfig struct {
+	Symbols    []string
+	Timeframes []string
+	Lookback   int // 指定日期之前再抓幾根 K 線（日內週期至少一日），補上前次未收盤的資料；預設 5
+	// Base 非空時只向交易所抓取此週期，Timeframes 中能由它組成的較粗週期改以重新取樣產生，
+	// 節省請求權重並讓各週期一致；其餘週期仍個別抓取。
+	Base string
+}
+
+// KlinePriceSource 以交易所 K 線（預設 Binance /api/v3/klines）實作 PriceSource：
+// 對觀察清單每個交易對、每個週期抓取指定日期當日與前 Lookback 根 K 線，長區間由 KlineSource 分頁。
+type KlinePriceSource struct {
+	klines KlineSource
+	cfg    KlinePriceSourceConfig
+	stored PriceRangeSource
+	now    func() time.Time
+}
+
+// NewKlinePriceSource 建立 K 線資料來源。
+func NewKlinePriceSource(klines KlineSource, cfg KlinePriceSourceConfig) *KlinePriceSource {
+	if cfg.Lookback <= 0 {
+		cfg.Lookback = 5
+	}
+	if len(cfg.Timeframes) == 0 {
+		cfg.Timeframes = []string{"1d"}
+	}
+	return &KlinePriceSource{klines: klines, cfg: cfg, now: time.Now}
+}
+
+// SetStoredBars 設定已儲存 K 線的來源：設定後 Base 只向交易所抓取自身的回補區間，
+// 較粗週期更早的部分改讀已儲存的 Base K 線，不再每日重抓整段。
+func (k *KlinePriceSource) SetStoredBars(stored PriceRangeSource) {
+	k.stored = stored
+}
+
+// FetchDaily 抓取 date 當日（UTC）與回補區間內各週期的 K 線；symbols 非空時取代觀察清單。
+// 個別交易對失敗不影響其他交易對，失敗明細以 *FetchError 回傳。
+func (k *KlinePriceSource) FetchDaily(ctx context.Context, date time.Time, symbols []string, market *dataingestion.Market) ([]dataingestion.DailyPrice, error) {
+	if market != nil && *market != dataingestion.MarketCrypto {
+		return nil, nil
+	}
+	if len(symbols) == 0 {
+		symbols = k.cfg.Symbols
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols to ingest")
+	}
+
+	day := date.UTC().Truncate(24 * time.Hour)
+	var (
+		out      []dataingestion.DailyPrice
+		failures []Failure
+	)
+	for _, sym := range symbols {
+		sym = strings.ToUpper(strings.TrimSpace(sym))
+		if sym == "" {
+			continue
+		}
+		direct := k.cfg.Timeframes
+		if k.cfg.Base != "" {
+			prices, rest, fail := k.fetchResampled(ctx, sym, day)
+			out = append(out, prices...)
+			failures = append(failures, fail...)
+			direct = rest
+		}
+		for _, tf := range direct {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if _, ok := klineSteps[tf]; !ok {
+				failures = append(failures, Failure{Symbol: sym, Timeframe: tf, Reason: fmt.Sprintf("unsupported timeframe %q", tf)})
+				continue
+			}
+			start, _ := k.window(tf, day)
+			prices, err := k.klines.FetchKlines(ctx, sym, tf, start, day.Add(24*time.Hour))
+			if err != nil {
+				failures = append(failures, Failure{Symbol: sym, Timeframe: tf, Reason: err.Error()})
+				continue
+			}
+			out = append(out, prices...)
+		}
+	}
+	if len(failures) > 0 {
+		return out, &FetchError{Failures: failures}
+	}
+	return out, nil
+}
+
+// window 回傳 timeframe 在 day 需要的 K 線開盤時間範圍 [start, end)：
+// 往回 Lookback 根（日內週期至少回補前一日，涵蓋上次排程後才收盤的 K 線），直到涵蓋當日的最後一根。
+func (k *KlinePriceSource) window(tf string, day time.Time) (time.Time, time.Time) {
+	step := dataingestion.TimeframeDuration(tf)
+	back := max(time.Duration(k.cfg.Lookback)*step, 24*time.Hour)
+	start := dataingestion.BucketStart(day.Add(-back), step, 0)
+	end := dataingestion.BucketStart(day.Add(24*time.Hour-time.Nanosecond), step, 0).Add(step)
+	return start, end
+}
+
+// fetchResampled 抓取一次 Base 週期的 K 線並重新取樣成能由其組成的週期，回傳仍需個別抓取的週期。
+// 有已儲存 K 線時只抓 Base 自身的區間，較粗週期其餘部分以已儲存的 Base K 線補齊。
+func (k *KlinePriceSource) fetchResampled(ctx context.Context, sym string, day time.Time) ([]dataingestion.DailyPrice, []string, []Failure) {
+	base := k.cfg.Base
+	var derived, direct []string
+	for _, tf := range k.cfg.Timeframes {
+		if tf == base || dataingestion.CanResample(base, tf) {
+			derived = append(derived, tf)
+		} else {
+			direct = append(direct, tf)
+		}
+	}
+	if len(derived) == 0 {
+		return nil, direct, nil
+	}
+	fail := func(reason string) []Failure {
+		out := make([]Failure, len(derived))
+		for i, tf := range derived {
+			out[i] = Failure{Symbol: sym, Timeframe: tf, Reason: reason}
+		}
+		return out
+	}
+	if _, ok := klineSteps[base]; !ok {
+		return nil, direct, fail(fmt.Sprintf("unsupported base timeframe %q", base))
+	}
+
+	from, to := k.window(base, day)
+	earliest := from
+	for _, tf := range derived {
+		start, end := k.window(tf, day)
+		earliest, to = minTime(earliest, start), maxTime(to, end)
+	}
+	if k.stored == nil {
+		from = earliest
+	}
+	bars, err := k.klines.FetchKlines(ctx, sym, base, from, to)
+	if err != nil {
+		return nil, direct, fail(err.Error())
+	}
+	for i := range bars {
+		if bars[i].Timeframe == "" {
+			bars[i].Timeframe = base
+		}
+	}
+	if earliest.Before(from) {
+		// 已儲存的部分只讀到本次抓取區間之前，與新抓的 K 線不重疊
+		srcStep := dataingestion.TimeframeDuration(base)
+		older, err := k.stored.PriceRange(ctx, sym, base, earliest, from, int(from.Sub(earliest)/srcStep)+1)
+		if err != nil {
+			return nil, direct, fail(fmt.Sprintf("load stored %s bars: %v", base, err))
+		}
+		bars = append(older, bars...)
+	}
+
+	var (
+		out      []dataingestion.DailyPrice
+		failures []Failure
+	)
+	for _, tf := range derived {
+		start, end := k.window(tf, day)
+		if tf == base {
+			for _, b := range bars {
+				if !b.TradeDate.Before(start) && b.TradeDate.Before(day.Add(24*time.Hour)) {
+					out = append(out, b)
+				}
+			}
+			continue
+		}
+		// 最後一根尚未收盤時與交易所相同，以目前已有的資料產生進行中的 K 線；缺資料的 K 線不產生
+		resampled, err := dataingestion.Resample(bars, tf, dataingestion.ResampleOptions{From: start, To: end, Now: k.now()})
+		if err != nil {
+			failures = append(failures, Failure{Symbol: sym, Timeframe: tf, Reason: err.Error()})
+			continue
+		}
+		out = append(out, resampled...)
+	}
+	return out, direct, failures
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,101 @@
+package dataingestion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+type klineCall struct {
+	symbol, timeframe string
+	start, end        time.Time
+}
+
+// fakeKlines 依呼叫參數回傳一根 K 線，ETHUSDT 模擬交易所錯誤。
+type fakeKlines struct {
+	calls []klineCall
+}
+
+func (f *fakeKlines) FetchKlines(_ context.Context, symbol, timeframe string, start, end time.Time) ([]domain.DailyPrice, error) {
+	f.calls = append(f.calls, klineCall{symbol, timeframe, start, end})
+	if symbol == "ETHUSDT" {
+		return nil, errors.New("rate limited")
+	}
+	return []domain.DailyPrice{{
+		Symbol:    symbol,
+		Market:    domain.MarketCrypto,
+		Timeframe: timeframe,
+		TradeDate: start,
+		Open:      10,
+		High:      12,
+		Low:       9,
+		Close:     11,
+	}}, nil
+}
+
+func TestKlinePriceSource_FetchesWatchlistPerTimeframe(t *testing.T) {
+	klines := &fakeKlines{}
+	src := NewKlinePriceSource(klines, KlinePriceSourceConfig{
+		Symbols:    []string{"btcusdt", "SOLUSDT"},
+		Timeframes: []string{"1d", "1h"},
+	})
+	date := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	prices, err := src.FetchDaily(context.Background(), date, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prices) != 4 || len(klines.calls) != 4 {
+		t.Fatalf("expected 2 symbols x 2 timeframes, got %d prices over %d calls", len(prices), len(klines.calls))
+	}
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	daily, hourly := klines.calls[0], klines.calls[1]
+	if daily.symbol != "BTCUSDT" || !daily.start.Equal(day.AddDate(0, 0, -5)) || !daily.end.Equal(day.AddDate(0, 0, 1)) {
+		t.Fatalf("daily window must cover 5 prior bars through the end of the day, got %+v", daily)
+	}
+	if !hourly.start.Equal(day.AddDate(0, 0, -1)) {
+		t.Fatalf("intraday window must reach back at least one day, got %v", hourly.start)
+	}
+
+	tw := domain.MarketTWSE
+	if prices, err := src.FetchDaily(context.Background(), date, nil, &tw); err != nil || len(prices) != 0 {
+		t.Fatalf("non-crypto market filter must yield nothing, got %d, %v", len(prices), err)
+	}
+}
+
+func TestIngestUseCase_KlineSourcePartialFailure(t *testing.T) {
+	src := NewKlinePriceSource(&fakeKlines{}, KlinePriceSourceConfig{
+		Symbols:    []string{"BTCUSDT", "ETHUSDT"},
+		Timeframes: []string{"4h", "2h"},
+	})
+	repo := &fakeRepo{}
+	res, err := NewIngestUseCase(src, repo).Execute(context.Background(), IngestInput{
+		Date: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("partial failures must not abort ingestion: %v", err)
+	}
+	if res.SuccessCount != 1 || res.FailedCount != 3 {
+		t.Fatalf("expected BTCUSDT 4h stored and 3 failures, got %+v", res)
+	}
+	if len(repo.stored) != 1 || repo.stored[0].Timeframe != "4h" {
+		t.Fatalf("unexpected stored prices %+v", repo.stored)
+	}
+	want := map[string]Item{
+		"BTCUSDT/4h": {Symbol: "BTCUSDT", Timeframe: "4h", Stored: 1},
+		"BTCUSDT/2h": {Symbol: "BTCUSDT", Timeframe: "2h", Failed: 1, Reason: `unsupported timeframe "2h"`},
+		"ETHUSDT/4h": {Symbol: "ETHUSDT", Timeframe: "4h", Failed: 1},
+		"ETHUSDT/2h": {Symbol: "ETHUSDT", Timeframe: "2h", Failed: 1},
+	}
+	if len(res.Items) != len(want) {
+		t.Fatalf("expected an item per symbol/timeframe, got %+v", res.Items)
+	}
+	for _, it := range res.Items {
+		w, ok := want[it.Symbol+"/"+it.Timeframe]
+		if !ok || it.Stored != w.Stored || it.Failed != w.Failed || (w.Reason != "" && it.Reason != w.Reason) {
+			t.Errorf("unexpected item %+v", it)
+		}
+	}
+}
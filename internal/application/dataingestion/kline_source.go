@@ -0,0 +1,13 @@
+package dataingestion
+
+import (
+	"context"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// KlineSource 抽象化交易所 K 線來源，回傳 [start, end) 區間內依時間排序的 K 線。
+type KlineSource interface {
+	FetchKlines(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataingestion.DailyPrice, error)
+}
@@ -0,0 +1,200 @@
+package dataingestion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// parquetBatchSize 為每次從列群組讀取的列數。
+const parquetBatchSize = 256
+
+// parquetRows 逐列讀取 Parquet 檔案並轉成字串欄位，與 CSV 共用欄位對應與驗證；
+// 巢狀欄位以 "a.b" 命名，行號為第幾列資料加上標題列。
+type parquetRows struct {
+	groups  []parquet.RowGroup
+	rows    parquet.Rows
+	buf     []parquet.Row
+	n, pos  int
+	line    int
+	format  []func(parquet.Value) string
+	isTime  []bool
+	record  []string
+	columns []string
+}
+
+// openParquetRows 開啟 Parquet 檔案；r 可隨機讀取時直接使用，否則先讀入記憶體。
+func openParquetRows(r io.Reader, buffered io.Reader) (*parquetRows, error) {
+	var (
+		at   io.ReaderAt
+		size int64
+	)
+	if f, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		end, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		at, size = f, end
+	} else {
+		data, err := io.ReadAll(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		at, size = bytes.NewReader(data), int64(len(data))
+	}
+	file, err := parquet.OpenFile(at, size)
+	if err != nil {
+		return nil, fmt.Errorf("open parquet: %w", err)
+	}
+
+	schema := file.Schema()
+	paths := schema.Columns()
+	p := &parquetRows{
+		groups:  file.RowGroups(),
+		buf:     make([]parquet.Row, parquetBatchSize),
+		line:    1,
+		format:  make([]func(parquet.Value) string, len(paths)),
+		isTime:  make([]bool, len(paths)),
+		record:  make([]string, len(paths)),
+		columns: make([]string, len(paths)),
+	}
+	for i, path := range paths {
+		p.columns[i] = strings.Join(path, ".")
+		leaf, _ := schema.Lookup(path...)
+		p.format[i], p.isTime[i] = parquetFormatter(leaf.Node.Type())
+	}
+	return p, nil
+}
+
+// Header 回傳各葉欄位的名稱。
+func (p *parquetRows) Header() []string {
+	return p.columns
+}
+
+// Next 回傳下一列資料，讀完所有列群組時回傳 io.EOF。
+func (p *parquetRows) Next() ([]string, int, error) {
+	for p.pos == p.n {
+		if err := p.fill(); err != nil {
+			return nil, 0, err
+		}
+	}
+	row := p.buf[p.pos]
+	p.pos++
+	p.line++
+	for i := range p.record {
+		p.record[i] = ""
+	}
+	for _, v := range row {
+		if c := v.Column(); c >= 0 && c < len(p.record) && !v.IsNull() {
+			p.record[c] = p.format[c](v)
+		}
+	}
+	return p.record, p.line, nil
+}
+
+// fill 從目前的列群組讀取下一批資料，讀完時換到下一個列群組。
+func (p *parquetRows) fill() error {
+	if p.rows == nil {
+		if len(p.groups) == 0 {
+			return io.EOF
+		}
+		p.rows = p.groups[0].Rows()
+		p.groups = p.groups[1:]
+	}
+	n, err := p.rows.ReadRows(p.buf)
+	p.n, p.pos = n, 0
+	if err == io.EOF {
+		err = p.rows.Close()
+		p.rows = nil
+	}
+	if err != nil {
+		return fmt.Errorf("read parquet: %w", err)
+	}
+	return nil
+}
+
+// Close 釋放讀到一半的列群組。
+func (p *parquetRows) Close() {
+	if p.rows != nil {
+		p.rows.Close()
+	}
+}
+
+// parquetFormatter 依欄位型別把值轉成 parseImportTime／ParseFloat 可解析的字串；
+// 第二個回傳值表示欄位本身為時間型別，不需時間 layout。
+func parquetFormatter(t parquet.Type) (func(parquet.Value) string, bool) {
+	logical := t.LogicalType()
+	converted := t.ConvertedType()
+	switch {
+	case logical != nil && logical.Timestamp != nil:
+		unit, utc := logical.Timestamp.Unit, logical.Timestamp.IsAdjustedToUTC
+		return func(v parquet.Value) string {
+			var ts time.Time
+			switch {
+			case unit.Millis != nil:
+				ts = time.UnixMilli(v.Int64())
+			case unit.Micros != nil:
+				ts = time.UnixMicro(v.Int64())
+			default:
+				ts = time.Unix(0, v.Int64())
+			}
+			return formatParquetTime(ts, utc)
+		}, true
+	case converted != nil && (*converted == deprecated.TimestampMillis || *converted == deprecated.TimestampMicros):
+		millis := *converted == deprecated.TimestampMillis
+		return func(v parquet.Value) string {
+			if millis {
+				return formatParquetTime(time.UnixMilli(v.Int64()), true)
+			}
+			return formatParquetTime(time.UnixMicro(v.Int64()), true)
+		}, true
+	case (logical != nil && logical.Date != nil) || (converted != nil && *converted == deprecated.Date):
+		return func(v parquet.Value) string {
+			return time.Unix(int64(v.Int32())*86400, 0).UTC().Format("2006-01-02")
+		}, true
+	case t.Kind() == parquet.Int96:
+		// Spark／Impala 舊式時間戳：前 8 bytes 為當日奈秒，後 4 bytes 為儒略日
+		return func(v parquet.Value) string {
+			i := v.Int96()
+			nanos := int64(uint64(i[1])<<32 | uint64(i[0]))
+			days := int64(i[2]) - 2440588
+			return formatParquetTime(time.Unix(days*86400, nanos), true)
+		}, true
+	case logical != nil && logical.Decimal != nil && (t.Kind() == parquet.Int32 || t.Kind() == parquet.Int64):
+		scale, is32 := math.Pow10(int(logical.Decimal.Scale)), t.Kind() == parquet.Int32
+		return func(v parquet.Value) string {
+			n := v.Int64()
+			if is32 {
+				n = int64(v.Int32())
+			}
+			return strconv.FormatFloat(float64(n)/scale, 'f', -1, 64)
+		}, false
+	}
+	switch t.Kind() {
+	case parquet.Double:
+		return func(v parquet.Value) string { return strconv.FormatFloat(v.Double(), 'f', -1, 64) }, false
+	case parquet.Float:
+		return func(v parquet.Value) string { return strconv.FormatFloat(float64(v.Float()), 'f', -1, 32) }, false
+	case parquet.Int32:
+		return func(v parquet.Value) string { return strconv.FormatInt(int64(v.Int32()), 10) }, false
+	}
+	return parquet.Value.String, false
+}
+
+// formatParquetTime 輸出時間字串；未標示 UTC 的時間戳不帶時區，改由 ImportOptions.Location 解讀。
+func formatParquetTime(t time.Time, utc bool) string {
+	if utc {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.999999999")
+}
@@ -0,0 +1,259 @@
+package dataingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// 隔離 K 線的審核狀態。
+const (
+	QuarantinePending  = "pending"
+	QuarantineReleased = "released" // 人工確認無誤並寫入
+	QuarantineRejected = "rejected" // 確認為壞資料，之後重抓也不寫入
+)
+
+var (
+	// ErrQuarantineNotFound 表示查無隔離的 K 線。
+	ErrQuarantineNotFound = errors.New("quarantined bar not found")
+	// ErrQuarantineResolved 表示隔離的 K 線已審核過。
+	ErrQuarantineResolved = errors.New("quarantined bar already resolved")
+)
+
+// QuarantinedBar 為未通過品質檢查、等待人工審核的 K 線。
+type QuarantinedBar struct {
+	ID         string
+	Price      dataingestion.DailyPrice
+	Issues     []dataingestion.QualityIssue
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt time.Time
+	ResolvedBy string
+}
+
+// QuarantineFilter 篩選隔離清單；空字串代表不限。
+type QuarantineFilter struct {
+	Symbol    string
+	Timeframe string
+	Status    string
+	Limit     int
+}
+
+// QualityStatus 為交易對 × 週期最新一根 K 線的品質狀態，供策略執行前檢查。
+type QualityStatus struct {
+	Symbol    string
+	Timeframe string
+	LastBar   time.Time
+	Passed    bool
+	Issues    []dataingestion.QualityIssue
+	CheckedAt time.Time
+}
+
+// QualityStore 保存隔離的 K 線與各交易對的品質狀態。
+type QualityStore interface {
+	// QuarantineBars 寫入可疑 K 線；同一根 K 線尚待審核時覆寫內容，已審核者不變。
+	QuarantineBars(ctx context.Context, bars []QuarantinedBar) error
+	ListQuarantine(ctx context.Context, filter QuarantineFilter) ([]QuarantinedBar, error)
+	GetQuarantine(ctx context.Context, id string) (*QuarantinedBar, error)
+	ResolveQuarantine(ctx context.Context, bar QuarantinedBar) error
+	// ResolvedBars 回傳區間內已審核 K 線的審核結果，以 UTC 開盤時間為鍵。
+	ResolvedBars(ctx context.Context, symbol, timeframe string, from, to time.Time) (map[time.Time]string, error)
+	SaveQualityStatus(ctx context.Context, st QualityStatus) error
+	// GetQualityStatus 尚未檢查過時回傳 nil。
+	GetQualityStatus(ctx context.Context, symbol, timeframe string) (*QualityStatus, error)
+	ListQualityStatus(ctx context.Context) ([]QualityStatus, error)
+}
+
+// PriceHistory 取得 endDate（含）之前最近 lookback 根 K 線，由舊到新。
+type PriceHistory interface {
+	GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataingestion.DailyPrice, error)
+}
+
+// QualityGate 位於抓取與寫入之間：依交易對 × 週期檢查缺漏、重複、OHLC、離群報酬與停滯 K 線，
+// 可疑的 K 線移入隔離區待審，並記錄每個交易對最新資料是否通過檢查。
+type QualityGate struct {
+	history PriceHistory
+	store   QualityStore
+	rules   dataingestion.QualityRules
+	now     func() time.Time
+}
+
+// NewQualityGate 建立資料品質檢查。
+func NewQualityGate(history PriceHistory, store QualityStore, rules dataingestion.QualityRules) *QualityGate {
+	return &QualityGate{history: history, store: store, rules: rules, now: time.Now}
+}
+
+// Screen 檢查一批 K 線（可含多個交易對與週期），回傳可寫入的 K 線與本次隔離的 K 線。
+// 已放行的 K 線視為正常；已駁回的 K 線直接略過。
+func (g *QualityGate) Screen(ctx context.Context, prices []dataingestion.DailyPrice) ([]dataingestion.DailyPrice, []QuarantinedBar, error) {
+	type seriesKey struct{ symbol, timeframe string }
+	var order []seriesKey
+	groups := make(map[seriesKey][]dataingestion.DailyPrice)
+	for _, p := range prices {
+		k := seriesKey{p.Symbol, p.Timeframe}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], p)
+	}
+
+	var (
+		clean       []dataingestion.DailyPrice
+		quarantined []QuarantinedBar
+	)
+	for _, k := range order {
+		batch := groups[k]
+		from, to := batch[0].TradeDate, batch[0].TradeDate
+		for _, p := range batch[1:] {
+			if p.TradeDate.Before(from) {
+				from = p.TradeDate
+			}
+			if p.TradeDate.After(to) {
+				to = p.TradeDate
+			}
+		}
+		history, err := g.history.GetHistory(ctx, k.symbol, k.timeframe, from.Add(-time.Nanosecond), g.rules.HistoryBars())
+		if err != nil {
+			return nil, nil, fmt.Errorf("load history for %s %s: %w", k.symbol, k.timeframe, err)
+		}
+		resolved, err := g.store.ResolvedBars(ctx, k.symbol, k.timeframe, from, to.Add(time.Nanosecond))
+		if err != nil {
+			return nil, nil, fmt.Errorf("load resolved bars for %s %s: %w", k.symbol, k.timeframe, err)
+		}
+		trusted := make(map[time.Time]bool)
+		kept := batch[:0:0]
+		for _, p := range batch {
+			switch resolved[p.TradeDate.UTC()] {
+			case QuarantineRejected:
+				continue
+			case QuarantineReleased:
+				trusted[p.TradeDate.UTC()] = true
+			}
+			kept = append(kept, p)
+		}
+		if len(kept) == 0 {
+			continue
+		}
+
+		report := g.rules.Inspect(history, kept, trusted)
+		clean = append(clean, report.Clean...)
+		now := g.now()
+		for _, s := range report.Suspect {
+			quarantined = append(quarantined, QuarantinedBar{Price: s.Price, Issues: s.Issues, Status: QuarantinePending, CreatedAt: now})
+		}
+		if err := g.updateStatus(ctx, k.symbol, k.timeframe, report); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(quarantined) > 0 {
+		if err := g.store.QuarantineBars(ctx, quarantined); err != nil {
+			return nil, nil, fmt.Errorf("quarantine bars: %w", err)
+		}
+	}
+	return clean, quarantined, nil
+}
+
+// updateStatus 只在本批含有最新的 K 線時更新狀態，回補舊資料不影響目前的判定。
+func (g *QualityGate) updateStatus(ctx context.Context, symbol, timeframe string, report dataingestion.QualityReport) error {
+	current, err := g.store.GetQualityStatus(ctx, symbol, timeframe)
+	if err != nil {
+		return fmt.Errorf("load quality status: %w", err)
+	}
+	if current != nil && current.LastBar.After(report.Latest) {
+		return nil
+	}
+	return g.store.SaveQualityStatus(ctx, QualityStatus{
+		Symbol:    symbol,
+		Timeframe: timeframe,
+		LastBar:   report.Latest,
+		Passed:    report.Passed(),
+		Issues:    report.Issues,
+		CheckedAt: g.now(),
+	})
+}
+
+// CheckDataQuality 在交易對最新資料未通過檢查時回傳錯誤；尚未檢查過的交易對視為通過。
+func (g *QualityGate) CheckDataQuality(ctx context.Context, symbol, timeframe string) error {
+	st, err := g.store.GetQualityStatus(ctx, symbol, timeframe)
+	if err != nil {
+		return err
+	}
+	if st == nil || st.Passed {
+		return nil
+	}
+	reasons := make([]string, len(st.Issues))
+	for i, is := range st.Issues {
+		reasons[i] = fmt.Sprintf("%s: %s", is.Check, is.Detail)
+	}
+	return fmt.Errorf("%s %s bar at %s failed quality checks (%s)", symbol, timeframe, st.LastBar.UTC().Format(time.RFC3339), strings.Join(reasons, "; "))
+}
+
+// Statuses 回傳所有交易對 × 週期的最新品質狀態。
+func (g *QualityGate) Statuses(ctx context.Context) ([]QualityStatus, error) {
+	return g.store.ListQualityStatus(ctx)
+}
+
+// Quarantined 查詢隔離的 K 線。
+func (g *QualityGate) Quarantined(ctx context.Context, filter QuarantineFilter) ([]QuarantinedBar, error) {
+	return g.store.ListQuarantine(ctx, filter)
+}
+
+// Release 人工放行隔離的 K 線：寫入資料庫，若為最新一根則一併解除品質狀態。
+func (g *QualityGate) Release(ctx context.Context, id, by string, repo PriceRepository) (*QuarantinedBar, error) {
+	bar, err := g.resolve(ctx, id, by, QuarantineReleased)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.UpsertDailyPrice(ctx, bar.Price, true); err != nil {
+		return nil, fmt.Errorf("store released bar: %w", err)
+	}
+	if err := g.store.ResolveQuarantine(ctx, *bar); err != nil {
+		return nil, err
+	}
+	st, err := g.store.GetQualityStatus(ctx, bar.Price.Symbol, bar.Price.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil && !st.Passed && st.LastBar.Equal(bar.Price.TradeDate) {
+		// 僅剩缺漏問題時仍維持未通過，待回補補齊
+		var rest []dataingestion.QualityIssue
+		for _, is := range st.Issues {
+			if is.Check == dataingestion.CheckGap {
+				rest = append(rest, is)
+			}
+		}
+		st.Issues, st.Passed, st.CheckedAt = rest, len(rest) == 0, g.now()
+		if err := g.store.SaveQualityStatus(ctx, *st); err != nil {
+			return nil, err
+		}
+	}
+	return bar, nil
+}
+
+// Reject 確認隔離的 K 線為壞資料；交易對維持未通過直到有新的 K 線通過檢查。
+func (g *QualityGate) Reject(ctx context.Context, id, by string) (*QuarantinedBar, error) {
+	bar, err := g.resolve(ctx, id, by, QuarantineRejected)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.ResolveQuarantine(ctx, *bar); err != nil {
+		return nil, err
+	}
+	return bar, nil
+}
+
+func (g *QualityGate) resolve(ctx context.Context, id, by, status string) (*QuarantinedBar, error) {
+	bar, err := g.store.GetQuarantine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bar.Status != QuarantinePending {
+		return bar, ErrQuarantineResolved
+	}
+	bar.Status, bar.ResolvedBy, bar.ResolvedAt = status, by, g.now()
+	return bar, nil
+}
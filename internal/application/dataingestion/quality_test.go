@@ -0,0 +1,213 @@
+package dataingestion
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	domain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+type fakeHistory struct {
+	bars []domain.DailyPrice
+}
+
+func (h fakeHistory) GetHistory(_ context.Context, _, _ string, endDate time.Time, _ int) ([]domain.DailyPrice, error) {
+	var out []domain.DailyPrice
+	for _, b := range h.bars {
+		if !b.TradeDate.After(endDate) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// memQualityStore 為測試用的 QualityStore。
+type memQualityStore struct {
+	bars   []QuarantinedBar
+	status map[string]QualityStatus
+}
+
+func newMemQualityStore() *memQualityStore {
+	return &memQualityStore{status: make(map[string]QualityStatus)}
+}
+
+func (s *memQualityStore) QuarantineBars(_ context.Context, bars []QuarantinedBar) error {
+	for _, b := range bars {
+		b.ID = strconv.Itoa(len(s.bars) + 1)
+		s.bars = append(s.bars, b)
+	}
+	return nil
+}
+
+func (s *memQualityStore) ListQuarantine(_ context.Context, _ QuarantineFilter) ([]QuarantinedBar, error) {
+	return s.bars, nil
+}
+
+func (s *memQualityStore) GetQuarantine(_ context.Context, id string) (*QuarantinedBar, error) {
+	for _, b := range s.bars {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, ErrQuarantineNotFound
+}
+
+func (s *memQualityStore) ResolveQuarantine(_ context.Context, bar QuarantinedBar) error {
+	for i := range s.bars {
+		if s.bars[i].ID == bar.ID {
+			s.bars[i] = bar
+		}
+	}
+	return nil
+}
+
+func (s *memQualityStore) ResolvedBars(_ context.Context, symbol, timeframe string, from, to time.Time) (map[time.Time]string, error) {
+	out := make(map[time.Time]string)
+	for _, b := range s.bars {
+		t := b.Price.TradeDate
+		if b.Status != QuarantinePending && b.Price.Symbol == symbol && b.Price.Timeframe == timeframe && !t.Before(from) && t.Before(to) {
+			out[t.UTC()] = b.Status
+		}
+	}
+	return out, nil
+}
+
+func (s *memQualityStore) SaveQualityStatus(_ context.Context, st QualityStatus) error {
+	s.status[st.Symbol+"|"+st.Timeframe] = st
+	return nil
+}
+
+func (s *memQualityStore) GetQualityStatus(_ context.Context, symbol, timeframe string) (*QualityStatus, error) {
+	st, ok := s.status[symbol+"|"+timeframe]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s *memQualityStore) ListQualityStatus(context.Context) ([]QualityStatus, error) {
+	out := make([]QualityStatus, 0, len(s.status))
+	for _, st := range s.status {
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func hourBar(start time.Time, i int, closePrice float64) domain.DailyPrice {
+	return domain.DailyPrice{
+		Symbol:    "BTCUSDT",
+		Market:    domain.MarketCrypto,
+		Timeframe: "1h",
+		TradeDate: start.Add(time.Duration(i) * time.Hour),
+		Open:      closePrice,
+		High:      closePrice + 1,
+		Low:       closePrice - 1,
+		Close:     closePrice,
+		Volume:    10,
+	}
+}
+
+func TestQualityGate_QuarantinesAndBlocksUntilReleased(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := fakeHistory{bars: []domain.DailyPrice{hourBar(start, 0, 100)}}
+	store := newMemQualityStore()
+	gate := NewQualityGate(history, store, domain.QualityRules{})
+
+	bad := hourBar(start, 2, 101)
+	bad.Volume = 0
+	clean, quarantined, err := gate.Screen(ctx, []domain.DailyPrice{hourBar(start, 1, 101), bad})
+	if err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+	if len(clean) != 1 || len(quarantined) != 1 || len(store.bars) != 1 {
+		t.Fatalf("expected one clean and one quarantined bar, got %d/%d", len(clean), len(quarantined))
+	}
+	if err := gate.CheckDataQuality(ctx, "BTCUSDT", "1h"); err == nil {
+		t.Fatalf("expected failing quality status after quarantine")
+	}
+
+	repo := &fakeRepo{}
+	released, err := gate.Release(ctx, store.bars[0].ID, "ops", repo)
+	if err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if released.Status != QuarantineReleased || len(repo.stored) != 1 {
+		t.Fatalf("expected released bar to be stored, got %+v", released)
+	}
+	if err := gate.CheckDataQuality(ctx, "BTCUSDT", "1h"); err != nil {
+		t.Fatalf("expected quality status to pass after release, got %v", err)
+	}
+	if _, err := gate.Reject(ctx, store.bars[0].ID, "ops"); !errors.Is(err, ErrQuarantineResolved) {
+		t.Fatalf("expected ErrQuarantineResolved, got %v", err)
+	}
+
+	// 重新抓到已放行的 K 線時不再隔離
+	clean, quarantined, err = gate.Screen(ctx, []domain.DailyPrice{bad})
+	if err != nil || len(clean) != 1 || len(quarantined) != 0 {
+		t.Fatalf("expected released bar to pass, got %d/%d err=%v", len(clean), len(quarantined), err)
+	}
+}
+
+func TestQualityGate_RejectedBarsAreDropped(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemQualityStore()
+	gate := NewQualityGate(fakeHistory{}, store, domain.QualityRules{})
+
+	bad := hourBar(start, 0, 100)
+	bad.High = 50
+	if _, _, err := gate.Screen(ctx, []domain.DailyPrice{bad}); err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+	if _, err := gate.Reject(ctx, store.bars[0].ID, "ops"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	clean, quarantined, err := gate.Screen(ctx, []domain.DailyPrice{bad})
+	if err != nil || len(clean) != 0 || len(quarantined) != 0 {
+		t.Fatalf("expected rejected bar to be skipped, got %d/%d err=%v", len(clean), len(quarantined), err)
+	}
+}
+
+func TestQualityGate_BackfillDoesNotOverrideLatestStatus(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemQualityStore()
+	gate := NewQualityGate(fakeHistory{}, store, domain.QualityRules{})
+
+	if _, _, err := gate.Screen(ctx, []domain.DailyPrice{hourBar(start, 10, 100)}); err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+	old := hourBar(start, 2, 100)
+	old.Volume = 0
+	if _, _, err := gate.Screen(ctx, []domain.DailyPrice{old}); err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+	if err := gate.CheckDataQuality(ctx, "BTCUSDT", "1h"); err != nil {
+		t.Fatalf("expected older bad bar not to fail latest status, got %v", err)
+	}
+}
+
+func TestIngestUseCase_QuarantinesSuspectBars(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bad := hourBar(start, 1, 100)
+	bad.Close = -1
+	source := fakeSource{prices: []domain.DailyPrice{hourBar(start, 0, 100), bad}}
+	repo := &fakeRepo{}
+
+	usecase := NewIngestUseCase(source, repo)
+	usecase.SetQualityGate(NewQualityGate(fakeHistory{}, newMemQualityStore(), domain.QualityRules{}))
+	res, err := usecase.Execute(context.Background(), IngestInput{Date: start, Mode: IngestModeDaily, Replace: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.SuccessCount != 1 || res.FailedCount != 0 || res.QuarantinedCount != 1 || len(repo.stored) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(res.Items) != 1 || res.Items[0].Stored != 1 || res.Items[0].Quarantined != 1 {
+		t.Fatalf("unexpected items: %+v", res.Items)
+	}
+}
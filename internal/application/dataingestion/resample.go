@@ -0,0 +1,81 @@
+package dataingestion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// PriceRangeSource 依時間遞增分頁讀取 [from, to) 內已儲存的 K 線。
+type PriceRangeSource interface {
+	PriceRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]dataingestion.DailyPrice, error)
+}
+
+// ResampleInput 指定由哪個已儲存週期產生哪個較粗週期；[From, To) 會向外對齊到完整的 K 線。
+type ResampleInput struct {
+	Symbol    string
+	Source    string
+	Timeframe string
+	From      time.Time
+	To        time.Time
+	Offset    time.Duration // 分桶邊界平移，見 dataingestion.ResampleOptions
+}
+
+// Resampler 讀取已儲存的細週期 K 線，重新取樣後寫回較粗的週期。
+type Resampler struct {
+	bars     PriceRangeSource
+	repo     PriceRepository
+	pageSize int
+}
+
+// NewResampler 建立重新取樣流程。
+func NewResampler(bars PriceRangeSource, repo PriceRepository) *Resampler {
+	return &Resampler{bars: bars, repo: repo, pageSize: exportPageSize}
+}
+
+// Run 逐段讀取來源 K 線並寫入重新取樣的結果，回傳寫入根數；每段包含整數根目標 K 線，
+// 記憶體用量不隨範圍增加。
+func (r *Resampler) Run(ctx context.Context, in ResampleInput) (int, error) {
+	if in.Symbol == "" {
+		return 0, fmt.Errorf("symbol is required")
+	}
+	if !dataingestion.CanResample(in.Source, in.Timeframe) {
+		return 0, fmt.Errorf("cannot resample %q bars into %q", in.Source, in.Timeframe)
+	}
+	if !in.To.After(in.From) {
+		return 0, fmt.Errorf("to must be after from")
+	}
+	srcStep := dataingestion.TimeframeDuration(in.Source)
+	step := dataingestion.TimeframeDuration(in.Timeframe)
+	from := dataingestion.BucketStart(in.From, step, in.Offset)
+	to := dataingestion.BucketStart(in.To.Add(-time.Nanosecond), step, in.Offset).Add(step)
+	span := max(step, time.Duration(r.pageSize)*srcStep/step*step)
+
+	stored := 0
+	for start := from; start.Before(to); start = start.Add(span) {
+		if err := ctx.Err(); err != nil {
+			return stored, err
+		}
+		end := minTime(start.Add(span), to)
+		bars, err := r.bars.PriceRange(ctx, in.Symbol, in.Source, start, end, int(end.Sub(start)/srcStep)+1)
+		if err != nil {
+			return stored, fmt.Errorf("load %s %s bars: %w", in.Symbol, in.Source, err)
+		}
+		resampled, err := dataingestion.Resample(bars, in.Timeframe, dataingestion.ResampleOptions{From: start, To: end, Offset: in.Offset})
+		if err != nil {
+			return stored, err
+		}
+		for _, p := range resampled {
+			if err := p.Validate(); err != nil {
+				continue
+			}
+			if err := r.repo.UpsertDailyPrice(ctx, p, true); err != nil {
+				return stored, fmt.Errorf("store %s %s %s: %w", p.Symbol, p.Timeframe, p.TradeDate.Format(time.RFC3339), err)
+			}
+			stored++
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,127 @@
+package dataingestion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+// hourlyKlines 回傳區間內每小時一根的 K 線，收盤價為小時序號。
+type hourlyKlines struct {
+	calls []klineCall
+}
+
+func (f *hourlyKlines) FetchKlines(_ context.Context, symbol, timeframe string, start, end time.Time) ([]domain.DailyPrice, error) {
+	f.calls = append(f.calls, klineCall{symbol, timeframe, start, end})
+	var out []domain.DailyPrice
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		bar := hourBar(t, 0, float64(t.Hour()+1))
+		bar.Timeframe = timeframe
+		out = append(out, bar)
+	}
+	return out, nil
+}
+
+func TestKlinePriceSource_ResamplesFromBase(t *testing.T) {
+	klines := &hourlyKlines{}
+	src := NewKlinePriceSource(klines, KlinePriceSourceConfig{
+		Symbols:    []string{"BTCUSDT"},
+		Timeframes: []string{"1h", "4h", "1d", "15m"},
+		Base:       "1h",
+	})
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	prices, err := src.FetchDaily(context.Background(), day.Add(15*time.Hour), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 1h、4h、1d 共用一次請求，15m 無法由 1h 組成仍個別抓取
+	if len(klines.calls) != 2 || klines.calls[0].timeframe != "1h" || klines.calls[1].timeframe != "15m" {
+		t.Fatalf("unexpected calls %+v", klines.calls)
+	}
+	if base := klines.calls[0]; !base.start.Equal(day.AddDate(0, 0, -5)) || !base.end.Equal(day.AddDate(0, 0, 1)) {
+		t.Fatalf("base window must cover the widest lookback, got %+v", base)
+	}
+
+	counts := map[string]int{}
+	var daily domain.DailyPrice
+	for _, p := range prices {
+		counts[p.Timeframe]++
+		if p.Timeframe == "1d" && p.TradeDate.Equal(day) {
+			daily = p
+		}
+	}
+	if counts["1h"] != 48 || counts["4h"] != 12 || counts["1d"] != 6 {
+		t.Fatalf("unexpected bar counts %v", counts)
+	}
+	if daily.Open != 1 || daily.Close != 24 || daily.High != 25 || daily.Low != 0 || daily.Volume != 240 {
+		t.Fatalf("unexpected daily bar %+v", daily)
+	}
+}
+
+func TestKlinePriceSource_ResamplesFromStoredBase(t *testing.T) {
+	klines := &hourlyKlines{}
+	src := NewKlinePriceSource(klines, KlinePriceSourceConfig{
+		Symbols:    []string{"BTCUSDT"},
+		Timeframes: []string{"1h", "1d"},
+		Base:       "1h",
+	})
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	stored := &rangeSource{}
+	for i := 0; i < 4*24; i++ {
+		if i == 30 {
+			continue // 3/6 缺一根，該日 K 不完整
+		}
+		stored.prices = append(stored.prices, hourBar(day.AddDate(0, 0, -5), i, 100))
+	}
+	src.SetStoredBars(stored)
+
+	prices, err := src.FetchDaily(context.Background(), day.Add(15*time.Hour), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 只向交易所抓取 1h 自身的回補區間，更早的日 K 由已儲存的 1h 產生
+	if len(klines.calls) != 1 || !klines.calls[0].start.Equal(day.AddDate(0, 0, -1)) || stored.calls != 1 {
+		t.Fatalf("expected only the base window to be fetched, got %+v (%d stored reads)", klines.calls, stored.calls)
+	}
+	var days []time.Time
+	for _, p := range prices {
+		if p.Timeframe == "1d" {
+			days = append(days, p.TradeDate)
+		}
+	}
+	if len(days) != 5 || !days[0].Equal(day.AddDate(0, 0, -5)) || !days[1].Equal(day.AddDate(0, 0, -3)) {
+		t.Fatalf("expected daily bars except the gappy day, got %v", days)
+	}
+}
+
+func TestResampler_WritesCoarserBarsInChunks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := &rangeSource{}
+	for i := 0; i < 72; i++ {
+		src.prices = append(src.prices, hourBar(start, i, 100+float64(i)))
+	}
+	repo := &fakeRepo{}
+	r := NewResampler(src, repo)
+	r.pageSize = 10 // 每段兩根 4h
+
+	stored, err := r.Run(context.Background(), ResampleInput{
+		Symbol: "BTCUSDT", Source: "1h", Timeframe: "4h",
+		From: start.Add(time.Hour), To: start.Add(71 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	if stored != 18 || src.calls != 9 {
+		t.Fatalf("expected 18 bars over 9 chunks, got %d bars over %d", stored, src.calls)
+	}
+	first := repo.stored[0]
+	if first.Timeframe != "4h" || !first.TradeDate.Equal(start) || first.Open != 100 || first.Close != 103 || first.Volume != 40 {
+		t.Fatalf("unexpected first bar %+v", first)
+	}
+
+	if _, err := r.Run(context.Background(), ResampleInput{Symbol: "BTCUSDT", Source: "4h", Timeframe: "1h", From: start, To: start.Add(time.Hour)}); err == nil {
+		t.Fatalf("expected finer target to fail")
+	}
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 執行狀態：成功、部分失敗、失敗。
+const (
+	StatusSuccess = "success"
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// ErrNotFound 表示查無指定 job。
+var ErrNotFound = errors.New("job not found")
+
+// Item 為單一交易對 × 週期 × 日期的執行明細；Symbol 為空代表整個日期失敗（例如交易所無法連線）。
+type Item struct {
+	Symbol      string
+	Timeframe   string
+	TradeDate   time.Time
+	Status      string
+	ErrorReason string
+	Duration    time.Duration
+}
+
+// Stage 為 ingestion 或 analysis 階段的彙總；Success/Failure 為寫入成功與失敗的 K 線（或分析結果）筆數。
+type Stage struct {
+	Error   string
+	Total   int
+	Success int
+	Failure int
+	Items   []Item
+}
+
+// Status 依筆數與錯誤判斷階段狀態：沒有任何成功且有錯誤即為失敗。
+func (s Stage) Status() string {
+	return StatusOf(s.Success, s.Failure, s.Error)
+}
+
+// Run 為一次排程、手動或回補執行，涵蓋 ingestion 與（選擇性的）analysis。
+type Run struct {
+	ID              string
+	Kind            string // auto / daily_manual / backfill / recompute
+	TriggeredBy     string
+	DataSource      string
+	TargetStart     time.Time
+	TargetEnd       time.Time
+	Start           time.Time
+	End             time.Time
+	Ingestion       Stage
+	AnalysisOn      bool
+	AnalysisVersion string
+	Analysis        Stage
+}
+
+// Status 回傳整體狀態：任一階段失敗即失敗，任一階段部分失敗即部分失敗。
+func (r Run) Status() string {
+	statuses := []string{r.Ingestion.Status()}
+	if r.AnalysisOn {
+		statuses = append(statuses, r.Analysis.Status())
+	}
+	out := StatusSuccess
+	for _, st := range statuses {
+		switch st {
+		case StatusFailed:
+			return StatusFailed
+		case StatusPartial:
+			out = StatusPartial
+		}
+	}
+	return out
+}
+
+// Filter 為查詢歷史的條件；From/To 比對開始時間，Limit 預設 20、上限 200。
+type Filter struct {
+	Kind   string
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// Normalize 補上分頁預設值並限制範圍。
+func (f Filter) Normalize() Filter {
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	if f.Limit > 200 {
+		f.Limit = 200
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return f
+}
+
+// Matches 判斷 run 是否符合條件（不含分頁），供記憶體實作使用。
+func (f Filter) Matches(r Run) bool {
+	if f.Kind != "" && r.Kind != f.Kind {
+		return false
+	}
+	if f.Status != "" && r.Status() != f.Status {
+		return false
+	}
+	if f.From != nil && r.Start.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && r.Start.After(*f.To) {
+		return false
+	}
+	return true
+}
+
+// Store 持久化 job 執行紀錄。
+type Store interface {
+	// SaveRun 寫入一次執行與其明細，並回填 ID。
+	SaveRun(ctx context.Context, run *Run) error
+	// ListRuns 依開始時間由新到舊回傳符合條件的執行（不含明細）與總筆數。
+	ListRuns(ctx context.Context, filter Filter) ([]Run, int, error)
+	// GetRun 回傳含明細的執行紀錄，查無時回傳 ErrNotFound。
+	GetRun(ctx context.Context, id string) (*Run, error)
+}
+
+// StatusOf 依成功/失敗筆數與錯誤訊息判斷狀態。
+func StatusOf(success, failure int, errMsg string) string {
+	switch {
+	case failure == 0 && errMsg == "":
+		return StatusSuccess
+	case success == 0:
+		return StatusFailed
+	default:
+		return StatusPartial
+	}
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunStatus(t *testing.T) {
+	cases := []struct {
+		name string
+		run  Run
+		want string
+	}{
+		{"ingestion only", Run{Ingestion: Stage{Success: 3}}, StatusSuccess},
+		{"ingestion partial", Run{Ingestion: Stage{Success: 3, Failure: 1}}, StatusPartial},
+		{"ingestion failed", Run{Ingestion: Stage{Error: "no kline data"}}, StatusFailed},
+		{"analysis ignored when off", Run{Ingestion: Stage{Success: 1}, Analysis: Stage{Error: "boom"}}, StatusSuccess},
+		{"analysis failed", Run{Ingestion: Stage{Success: 1}, AnalysisOn: true, Analysis: Stage{Error: "boom"}}, StatusFailed},
+		{"analysis partial", Run{Ingestion: Stage{Success: 1}, AnalysisOn: true, Analysis: Stage{Success: 2, Failure: 1}}, StatusPartial},
+	}
+	for _, tc := range cases {
+		if got := tc.run.Status(); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	start := time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)
+	run := Run{Kind: "backfill", Start: start, Ingestion: Stage{Success: 1, Failure: 1}}
+	from := start.Add(-time.Hour)
+	to := start.Add(-time.Minute)
+
+	if !(Filter{Kind: "backfill", Status: StatusPartial, From: &from}).Matches(run) {
+		t.Error("expected match")
+	}
+	if (Filter{Kind: "auto"}).Matches(run) {
+		t.Error("kind should not match")
+	}
+	if (Filter{Status: StatusSuccess}).Matches(run) {
+		t.Error("status should not match")
+	}
+	if (Filter{To: &to}).Matches(run) {
+		t.Error("run after To should not match")
+	}
+	if f := (Filter{Limit: 1000, Offset: -1}).Normalize(); f.Limit != 200 || f.Offset != 0 {
+		t.Errorf("unexpected normalized filter %+v", f)
+	}
+}
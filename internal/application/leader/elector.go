@@ -0,0 +1,169 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Lock 為分散式互斥鎖（例如 Postgres advisory lock）。
+type Lock interface {
+	// TryAcquire 嘗試取得鎖，不阻塞；已被其他實例持有時回傳 false。
+	TryAcquire(ctx context.Context) (bool, error)
+	// Check 確認目前仍持有鎖（例如連線仍存活），失敗代表已失去領導權。
+	Check(ctx context.Context) error
+	// Release 釋放鎖。
+	Release(ctx context.Context) error
+}
+
+// Status 為選舉狀態，供 /admin/jobs/status 顯示。
+type Status struct {
+	InstanceID string     `json:"instance_id"`
+	Backend    string     `json:"backend"`
+	IsLeader   bool       `json:"is_leader"`
+	Since      *time.Time `json:"since,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// Elector 週期性地競爭領導權：取得鎖後以 leader ctx 呼叫 onElected 啟動背景工作，
+// 失去鎖（或 Run 結束）時取消該 ctx，讓其他實例接手。
+type Elector struct {
+	lock      Lock
+	backend   string
+	id        string
+	interval  time.Duration
+	onElected func(ctx context.Context)
+
+	mu      sync.Mutex
+	leader  bool
+	since   time.Time
+	lastErr error
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewElector 建立選舉器；interval 為未當選時的重試間隔與當選後的存活檢查間隔，
+// 也是每次鎖操作的逾時上限，連線卡住時視同失去領導權。
+func NewElector(lock Lock, backend string, interval time.Duration, onElected func(ctx context.Context)) *Elector {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Elector{
+		lock:      lock,
+		backend:   backend,
+		id:        instanceID(),
+		interval:  interval,
+		onElected: onElected,
+	}
+}
+
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Run 參與選舉直到 ctx 結束，結束時釋放鎖並等待背景工作停止。
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.step(ctx)
+		select {
+		case <-ctx.Done():
+			e.resign(context.WithoutCancel(ctx), nil)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) step(ctx context.Context) {
+	opCtx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+	if e.IsLeader() {
+		if err := e.lock.Check(opCtx); err != nil && ctx.Err() == nil {
+			log.Printf("[Leader] %s lost leadership: %v", e.id, err)
+			e.resign(ctx, err)
+		}
+		return
+	}
+	ok, err := e.lock.TryAcquire(opCtx)
+	e.mu.Lock()
+	e.lastErr = err
+	e.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[Leader] %s acquire failed: %v", e.id, err)
+		}
+		return
+	}
+	if ok {
+		e.elect(ctx)
+	}
+}
+
+func (e *Elector) elect(ctx context.Context) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	e.mu.Lock()
+	e.leader, e.since, e.cancel, e.done = true, time.Now(), cancel, done
+	e.mu.Unlock()
+	log.Printf("[Leader] %s elected (%s)", e.id, e.backend)
+	go func() {
+		defer close(done)
+		if e.onElected != nil {
+			e.onElected(leaderCtx)
+		}
+	}()
+}
+
+// resign 停止背景工作並釋放鎖。
+func (e *Elector) resign(ctx context.Context, cause error) {
+	e.mu.Lock()
+	if !e.leader {
+		e.mu.Unlock()
+		return
+	}
+	cancel, done := e.cancel, e.done
+	e.leader, e.since, e.cancel, e.done = false, time.Time{}, nil, nil
+	if cause != nil {
+		e.lastErr = cause
+	}
+	e.mu.Unlock()
+
+	cancel()
+	<-done
+	releaseCtx, cancelRelease := context.WithTimeout(ctx, e.interval)
+	defer cancelRelease()
+	if err := e.lock.Release(releaseCtx); err != nil {
+		log.Printf("[Leader] %s release failed: %v", e.id, err)
+	}
+}
+
+// IsLeader 回傳本實例是否為 leader。
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Status 回傳選舉狀態。
+func (e *Elector) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := Status{InstanceID: e.id, Backend: e.backend, IsLeader: e.leader}
+	if e.leader {
+		since := e.since
+		st.Since = &since
+	}
+	if e.lastErr != nil {
+		st.LastError = e.lastErr.Error()
+	}
+	return st
+}
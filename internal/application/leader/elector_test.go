@@ -0,0 +1,138 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flakyLock struct {
+	*MemoryLock
+	fail atomic.Bool
+}
+
+func (l *flakyLock) Check(ctx context.Context) error {
+	if l.fail.Load() {
+		return errors.New("connection reset")
+	}
+	return l.MemoryLock.Check(ctx)
+}
+
+// hangingLock 模擬半開的 TCP 連線：Check 與 TryAcquire 一直阻塞到 ctx 結束。
+type hangingLock struct {
+	*MemoryLock
+	hang atomic.Bool
+}
+
+func (l *hangingLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.hang.Load() {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return l.MemoryLock.TryAcquire(ctx)
+}
+
+func (l *hangingLock) Check(ctx context.Context) error {
+	if l.hang.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return l.MemoryLock.Check(ctx)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElector_Failover(t *testing.T) {
+	shared := NewMemoryLock()
+	first := &flakyLock{MemoryLock: shared}
+	var running [2]atomic.Int32
+	job := func(i int) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			running[i].Add(1)
+			<-ctx.Done()
+			running[i].Add(-1)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := NewElector(first, "memory", 5*time.Millisecond, job(0))
+	go a.Run(ctx)
+	waitFor(t, a.IsLeader)
+
+	b := NewElector(shared.Handle(), "memory", 5*time.Millisecond, job(1))
+	go b.Run(ctx)
+	time.Sleep(30 * time.Millisecond)
+	if b.IsLeader() || running[1].Load() != 0 || running[0].Load() != 1 {
+		t.Fatal("only one instance may run leader jobs")
+	}
+
+	// a 失去連線：停止工作並釋放鎖，由 b 接手
+	first.fail.Store(true)
+	waitFor(t, b.IsLeader)
+	waitFor(t, func() bool { return running[0].Load() == 0 && running[1].Load() == 1 })
+	if st := a.Status(); st.IsLeader || st.LastError == "" {
+		t.Fatalf("unexpected status after losing leadership %+v", st)
+	}
+	if st := b.Status(); !st.IsLeader || st.Since == nil || st.Backend != "memory" {
+		t.Fatalf("unexpected leader status %+v", st)
+	}
+}
+
+func TestElector_ResignsOnShutdown(t *testing.T) {
+	lock := NewMemoryLock()
+	stopped := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	e := NewElector(lock, "memory", time.Hour, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, e.IsLeader)
+	cancel()
+	<-done
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("Run must wait for leader jobs to stop")
+	}
+	if ok, _ := lock.Handle().TryAcquire(context.Background()); !ok {
+		t.Fatal("lock must be released on shutdown")
+	}
+}
+
+func TestElector_ResignsWhenCheckHangs(t *testing.T) {
+	lock := &hangingLock{MemoryLock: NewMemoryLock()}
+	var running atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := NewElector(lock, "memory", 10*time.Millisecond, func(ctx context.Context) {
+		running.Add(1)
+		<-ctx.Done()
+		running.Add(-1)
+	})
+	go e.Run(ctx)
+	waitFor(t, func() bool { return running.Load() == 1 })
+
+	lock.hang.Store(true)
+	waitFor(t, func() bool { return !e.IsLeader() && running.Load() == 0 })
+	if st := e.Status(); !strings.Contains(st.LastError, context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected check timeout recorded, got %+v", st)
+	}
+}
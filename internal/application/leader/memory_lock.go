@@ -0,0 +1,48 @@
+package leader
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryLock 為單一程序內的鎖，供沒有資料庫時使用（單實例即為 leader）。
+// 同一個 MemoryLock 可由多個 Elector 共用，以模擬多實例競爭。
+type MemoryLock struct {
+	mu     *sync.Mutex
+	holder *bool
+	held   bool
+}
+
+// NewMemoryLock 建立記憶體鎖。
+func NewMemoryLock() *MemoryLock {
+	return &MemoryLock{mu: &sync.Mutex{}, holder: new(bool)}
+}
+
+// Handle 回傳競爭同一把鎖的另一個持有者視角。
+func (l *MemoryLock) Handle() *MemoryLock {
+	return &MemoryLock{mu: l.mu, holder: l.holder}
+}
+
+func (l *MemoryLock) TryAcquire(context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		return true, nil
+	}
+	if *l.holder {
+		return false, nil
+	}
+	*l.holder, l.held = true, true
+	return true, nil
+}
+
+func (l *MemoryLock) Check(context.Context) error { return nil }
+
+func (l *MemoryLock) Release(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		*l.holder, l.held = false, false
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 描述觸發時間，精度為分鐘。
+type Schedule interface {
+	// Matches 判斷 t（已截至分鐘）是否為觸發時間。
+	Matches(t time.Time) bool
+	// Next 回傳嚴格晚於 t 的下一次觸發時間。
+	Next(t time.Time) time.Time
+}
+
+// cronSchedule 為標準五欄位 cron（分 時 日 月 週）。
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	loc                           *time.Location
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: weekdayNames}
+)
+
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析五欄位 cron、@hourly 等描述字或 "@every 90m"；時間以 loc 解讀（nil 為 UTC）。
+func ParseCron(expr string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	expr = strings.TrimSpace(expr)
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("parse cron %q: %w", expr, err)
+		}
+		if d < time.Minute || d%time.Minute != 0 {
+			return nil, fmt.Errorf("parse cron %q: interval must be a whole number of minutes", expr)
+		}
+		return everySchedule{every: d}, nil
+	}
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("parse cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("parse cron %q minute: %w", expr, err)
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("parse cron %q hour: %w", expr, err)
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("parse cron %q day of month: %w", expr, err)
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("parse cron %q month: %w", expr, err)
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("parse cron %q day of week: %w", expr, err)
+	}
+	// 週日可寫成 0 或 7
+	if has(s.dow, 7) {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// parse 支援 *、a-b、a,b、*/n 與 a-b/n。
+func (f cronField) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		step := 1
+		if base, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			part, step = base, n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			a, b, _ := strings.Cut(part, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := f.value(part)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	if bits == 0 {
+		return 0, fmt.Errorf("empty field %q", spec)
+	}
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool { return bits&(1<<uint(v)) != 0 }
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := has(s.dom, t.Day())
+	dowOK := has(s.dow, int(t.Weekday()))
+	// 與標準 cron 相同：日與週皆有限制時任一符合即可
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+func (s *cronSchedule) Matches(t time.Time) bool {
+	t = t.In(s.loc)
+	return has(s.minute, t.Minute()) && has(s.hour, t.Hour()) && has(s.month, int(t.Month())) && s.dayMatches(t)
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case !has(s.hour, t.Hour()):
+			// 不用 Truncate：半小時時差的時區整點不落在 UTC 整點上
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// everySchedule 以 Unix epoch 對齊的固定間隔觸發。
+type everySchedule struct{ every time.Duration }
+
+func (e everySchedule) Matches(t time.Time) bool {
+	return t.Truncate(time.Minute).UnixNano()%int64(e.every) == 0
+}
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	d := int64(e.every)
+	return time.Unix(0, (t.UnixNano()/d+1)*d).In(t.Location())
+}
+
+// timeframeCron 將 K 線週期對應到收盤時間。
+var timeframeCron = map[string]string{
+	"1m":  "* * * * *",
+	"3m":  "*/3 * * * *",
+	"5m":  "*/5 * * * *",
+	"15m": "*/15 * * * *",
+	"30m": "*/30 * * * *",
+	"1h":  "0 * * * *",
+	"2h":  "0 */2 * * *",
+	"4h":  "0 */4 * * *",
+	"6h":  "0 */6 * * *",
+	"8h":  "0 */8 * * *",
+	"12h": "0 */12 * * *",
+	"1d":  "0 0 * * *",
+	"1w":  "0 0 * * 1",
+}
+
+// TimeframeSchedule 回傳在 timeframe 每根 K 線收盤時觸發的排程；空值視為 1d。
+// 交易所的 K 線以 UTC 對齊，因此一律以 UTC 解讀，不受排程時區影響。
+func TimeframeSchedule(timeframe string) (Schedule, error) {
+	if timeframe == "" {
+		timeframe = "1d"
+	}
+	expr, ok := timeframeCron[strings.ToLower(timeframe)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported timeframe %q", timeframe)
+	}
+	return ParseCron(expr, time.UTC)
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustCron(t *testing.T, expr string, loc *time.Location) Schedule {
+	t.Helper()
+	s, err := ParseCron(expr, loc)
+	if err != nil {
+		t.Fatalf("parse %q: %v", expr, err)
+	}
+	return s
+}
+
+func TestParseCron_Next(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 7, 30, 0, time.UTC) // 週三
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * mon-fri", time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 6-7", time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * */7", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 jun *", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		s := mustCron(t, c.expr, time.UTC)
+		got := s.Next(base)
+		if !got.Equal(c.want) {
+			t.Errorf("%s: next = %s, want %s", c.expr, got, c.want)
+		}
+		if !s.Matches(got) {
+			t.Errorf("%s: next %s does not match", c.expr, got)
+		}
+	}
+
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every 30s", "0 0 * * funday"} {
+		if _, err := ParseCron(bad, nil); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseCron_HalfHourOffsetZone(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	s := mustCron(t, "0 11 * * *", kolkata)
+	got := s.Next(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 5, 1, 11, 0, 0, 0, kolkata); !got.Equal(want) {
+		t.Fatalf("next = %s, want %s", got, want)
+	}
+}
+
+func TestParseCron_DomOrDow(t *testing.T) {
+	// 日與週皆有限制時為 OR：每月 13 日或每週五
+	s := mustCron(t, "0 0 13 * fri", time.UTC)
+	got := s.Next(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("next = %s, want %s", got, want)
+	}
+}
+
+func TestTimeframeSchedule_AlignsToBarClose(t *testing.T) {
+	s, err := TimeframeSchedule("4h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC)); !got.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("4h next = %s", got)
+	}
+
+	daily, err := TimeframeSchedule("1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// K 線以 UTC 收盤，與排程時區無關
+	if !daily.Matches(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || daily.Matches(time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)) {
+		t.Fatal("1d schedule must close at UTC midnight")
+	}
+	if _, err := TimeframeSchedule("7m"); err == nil {
+		t.Fatal("expected unsupported timeframe error")
+	}
+}
+
+func TestTradingWindow(t *testing.T) {
+	w, err := ParseTradingWindow("mon-fri 09:00-17:30", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{wed.Add(9 * time.Hour), true},
+		{wed.Add(17*time.Hour + 29*time.Minute), true},
+		{wed.Add(17*time.Hour + 30*time.Minute), false},
+		{wed.Add(8 * time.Hour), false},
+		{wed.AddDate(0, 0, 3).Add(10 * time.Hour), false}, // 週六
+	} {
+		if got := w.Contains(c.at); got != c.want {
+			t.Errorf("contains(%s) = %v, want %v", c.at, got, c.want)
+		}
+	}
+
+	overnight, err := ParseTradingWindow("fri 22:00-02:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fri := wed.AddDate(0, 0, 2)
+	if !overnight.Contains(fri.Add(23*time.Hour)) || !overnight.Contains(fri.Add(25*time.Hour)) || overnight.Contains(wed.Add(25*time.Hour)) {
+		t.Fatal("overnight window should belong to the day it starts")
+	}
+	if zero, _ := ParseTradingWindow("", nil); !zero.IsZero() || !zero.Contains(wed) {
+		t.Fatal("empty window must allow everything")
+	}
+	if _, err := ParseTradingWindow("09:00", nil); err == nil {
+		t.Fatal("expected error for missing end time")
+	}
+}
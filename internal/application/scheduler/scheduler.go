@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Task 為一個排程工作（資料管線或單一策略評估）。
+type Task struct {
+	Name     string
+	Schedule Schedule
+	Window   TradingWindow
+	Run      func(ctx context.Context, tick time.Time) error
+}
+
+// TaskSource 於每次觸發時提供目前的工作，讓新啟用或停用的策略立即生效。
+type TaskSource func(ctx context.Context) ([]Task, error)
+
+// Config 為排程器設定。
+type Config struct {
+	Location    *time.Location // 解讀 cron 與交易時段的時區，nil 為 UTC
+	SettleDelay time.Duration  // K 線收盤後等待交易所完成資料的時間
+	Pipeline    *Task          // ingestion → analysis，於任何策略評估前執行
+}
+
+// TaskStatus 為單一工作的排程狀態。
+type TaskStatus struct {
+	Name      string     `json:"name"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// Status 為排程器狀態，供 /admin/jobs/status 顯示。
+type Status struct {
+	Timezone string       `json:"timezone"`
+	LastTick *time.Time   `json:"last_tick,omitempty"`
+	Pipeline *TaskStatus  `json:"pipeline,omitempty"`
+	Tasks    []TaskStatus `json:"tasks"`
+}
+
+type runRecord struct {
+	at  time.Time
+	err error
+}
+
+// Scheduler 每分鐘檢查到期的工作：先跑資料管線，成功後再依序評估到期策略，
+// 保證 ingestion → analysis → evaluation 的順序。
+type Scheduler struct {
+	cfg    Config
+	source TaskSource
+
+	mu       sync.Mutex
+	lastTick time.Time
+	runs     map[string]runRecord
+	now      func() time.Time
+}
+
+// New 建立排程器。
+func New(cfg Config, source TaskSource) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	return &Scheduler{
+		cfg:    cfg,
+		source: source,
+		runs:   make(map[string]runRecord),
+		now:    time.Now,
+	}
+}
+
+// Location 回傳排程時區。
+func (s *Scheduler) Location() *time.Location {
+	return s.cfg.Location
+}
+
+// Run 在每個整分鐘（加上 SettleDelay）觸發 Tick，直到 ctx 結束。
+func (s *Scheduler) Run(ctx context.Context) error {
+	log.Printf("[Scheduler] started (tz=%s, settle=%v)", s.cfg.Location, s.cfg.SettleDelay)
+	for {
+		tick := s.now().Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(time.Until(tick.Add(s.cfg.SettleDelay)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		s.Tick(ctx, tick)
+	}
+}
+
+// Tick 執行 tick（整分鐘）到期的工作。資料管線失敗時略過本次策略評估，避免以舊資料下單。
+func (s *Scheduler) Tick(ctx context.Context, tick time.Time) {
+	tick = tick.Truncate(time.Minute)
+	s.mu.Lock()
+	s.lastTick = tick
+	s.mu.Unlock()
+
+	var tasks []Task
+	if s.source != nil {
+		var err error
+		if tasks, err = s.source(ctx); err != nil {
+			log.Printf("[Scheduler] list tasks failed: %v", err)
+			return
+		}
+	}
+	var due []Task
+	for _, t := range tasks {
+		if t.Schedule.Matches(tick) && t.Window.Contains(tick) {
+			due = append(due, t)
+		}
+	}
+
+	if p := s.cfg.Pipeline; p != nil && p.Run != nil {
+		if len(due) > 0 || (p.Schedule != nil && p.Schedule.Matches(tick)) {
+			if err := s.run(ctx, *p, tick); err != nil {
+				if len(due) > 0 {
+					log.Printf("[Scheduler] pipeline failed, skipping %d strategy evaluations at %s", len(due), tick.Format(time.RFC3339))
+				}
+				return
+			}
+		}
+	}
+	// 到期的策略並行執行，各自的並行上限與逾時由任務本身控制
+	var wg sync.WaitGroup
+	for _, t := range due {
+		wg.Add(1)
+		go func(t Task) {
+			defer wg.Done()
+			_ = s.run(ctx, t, tick)
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, t Task, tick time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		if err != nil {
+			log.Printf("[Scheduler] %s at %s failed: %v", t.Name, tick.Format(time.RFC3339), err)
+		}
+		s.mu.Lock()
+		s.runs[t.Name] = runRecord{at: tick, err: err}
+		s.mu.Unlock()
+	}()
+	return t.Run(ctx, tick)
+}
+
+func (s *Scheduler) taskStatus(t Task, now time.Time) TaskStatus {
+	st := TaskStatus{Name: t.Name}
+	if t.Schedule != nil {
+		// 略過不在交易時段內的觸發點，最多往後找一週的觸發次數
+		next := t.Schedule.Next(now)
+		for i := 0; i < 7*24*60 && !next.IsZero() && !t.Window.Contains(next); i++ {
+			next = t.Schedule.Next(next)
+		}
+		if !next.IsZero() && t.Window.Contains(next) {
+			next = next.In(s.cfg.Location)
+			st.NextRun = &next
+		}
+	}
+	if rec, ok := s.runs[t.Name]; ok {
+		at := rec.at.In(s.cfg.Location)
+		st.LastRun = &at
+		if rec.err != nil {
+			st.LastError = rec.err.Error()
+		}
+	}
+	return st
+}
+
+// Status 回傳資料管線與各工作的下次/上次執行時間。
+func (s *Scheduler) Status(ctx context.Context) Status {
+	var tasks []Task
+	if s.source != nil {
+		tasks, _ = s.source(ctx)
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := Status{Timezone: s.cfg.Location.String(), Tasks: make([]TaskStatus, 0, len(tasks))}
+	if !s.lastTick.IsZero() {
+		lt := s.lastTick.In(s.cfg.Location)
+		out.LastTick = &lt
+	}
+	if p := s.cfg.Pipeline; p != nil {
+		st := s.taskStatus(*p, now)
+		out.Pipeline = &st
+	}
+	for _, t := range tasks {
+		out.Tasks = append(out.Tasks, s.taskStatus(t, now))
+	}
+	sort.Slice(out.Tasks, func(i, j int) bool { return out.Tasks[i].Name < out.Tasks[j].Name })
+	return out
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_PipelineRunsBeforeDueTasks(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string, err error) func(context.Context, time.Time) error {
+		return func(context.Context, time.Time) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return err
+		}
+	}
+	hourly := mustCron(t, "0 * * * *", time.UTC)
+	fourHourly := mustCron(t, "0 */4 * * *", time.UTC)
+	window, _ := ParseTradingWindow("mon-fri 00:00-24:00", time.UTC)
+
+	pipelineErr := error(nil)
+	s := New(Config{Pipeline: &Task{Name: "pipeline", Schedule: mustCron(t, "30 * * * *", time.UTC), Run: func(ctx context.Context, tick time.Time) error {
+		return record("pipeline", pipelineErr)(ctx, tick)
+	}}}, func(context.Context) ([]Task, error) {
+		return []Task{
+			{Name: "1h", Schedule: hourly, Run: record("1h", nil)},
+			{Name: "4h", Schedule: fourHourly, Run: record("4h", nil)},
+			{Name: "weekday", Schedule: hourly, Window: window, Run: record("weekday", nil)},
+		}, nil
+	})
+	ctx := context.Background()
+	wed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	s.Tick(ctx, wed.Add(8*time.Hour))
+	// 到期任務並行執行，只保證 pipeline 先於所有任務
+	sort.Strings(order[1:])
+	if got := order; len(got) != 4 || got[0] != "pipeline" || got[1] != "1h" || got[2] != "4h" || got[3] != "weekday" {
+		t.Fatalf("unexpected order %v", got)
+	}
+
+	order = nil
+	s.Tick(ctx, wed.Add(9*time.Hour+10*time.Minute))
+	if len(order) != 0 {
+		t.Fatalf("nothing should run between bar closes, got %v", order)
+	}
+	s.Tick(ctx, wed.Add(9*time.Hour+30*time.Minute))
+	if len(order) != 1 || order[0] != "pipeline" {
+		t.Fatalf("pipeline should run on its own schedule, got %v", order)
+	}
+
+	order = nil
+	s.Tick(ctx, wed.AddDate(0, 0, 3).Add(time.Hour)) // 週六
+	if len(order) != 2 || order[1] != "1h" {
+		t.Fatalf("trading window should exclude weekend task, got %v", order)
+	}
+
+	order = nil
+	pipelineErr = errors.New("ingestion failed")
+	s.Tick(ctx, wed.Add(10*time.Hour))
+	if len(order) != 1 {
+		t.Fatalf("pipeline failure must skip evaluations, got %v", order)
+	}
+
+	s.now = func() time.Time { return wed.Add(10*time.Hour + time.Minute) }
+	st := s.Status(ctx)
+	if st.Pipeline == nil || st.Pipeline.LastError == "" || len(st.Tasks) != 3 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	if st.Tasks[1].Name != "4h" || !st.Tasks[1].NextRun.Equal(wed.Add(12*time.Hour)) {
+		t.Fatalf("unexpected 4h status %+v", st.Tasks[1])
+	}
+}
+
+func TestScheduler_RecoversPanics(t *testing.T) {
+	s := New(Config{}, func(context.Context) ([]Task, error) {
+		return []Task{{Name: "boom", Schedule: mustCron(t, "* * * * *", nil), Run: func(context.Context, time.Time) error {
+			panic("boom")
+		}}}, nil
+	})
+	s.Tick(context.Background(), time.Now())
+	if st := s.Status(context.Background()); st.Tasks[0].LastError == "" {
+		t.Fatalf("expected panic to be recorded, got %+v", st.Tasks[0])
+	}
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TradingWindow 限制排程只在特定星期與時段內執行；零值代表不限制。
+// 結束時間早於開始時間代表跨夜（例如 22:00-02:00）。
+type TradingWindow struct {
+	days       uint8 // 以 time.Weekday 為位元，0 代表每天
+	start, end int   // 當日分鐘數，[start, end)
+	loc        *time.Location
+	set        bool
+}
+
+// ParseTradingWindow 解析 "mon-fri 09:00-17:30"、"sat,sun 00:00-24:00" 或 "09:00-17:00"。
+func ParseTradingWindow(spec string, loc *time.Location) (TradingWindow, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return TradingWindow{}, nil
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	w := TradingWindow{loc: loc, set: true}
+	fields := strings.Fields(spec)
+	if len(fields) > 2 {
+		return TradingWindow{}, fmt.Errorf("parse trading window %q: too many fields", spec)
+	}
+	hours := fields[len(fields)-1]
+	if len(fields) == 2 {
+		bits, err := dowField.parse(strings.ReplaceAll(fields[0], "7", "0"))
+		if err != nil {
+			return TradingWindow{}, fmt.Errorf("parse trading window %q days: %w", spec, err)
+		}
+		w.days = uint8(bits)
+	}
+	from, to, ok := strings.Cut(hours, "-")
+	if !ok {
+		return TradingWindow{}, fmt.Errorf("parse trading window %q: expected HH:MM-HH:MM", spec)
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return TradingWindow{}, fmt.Errorf("parse trading window %q: %w", spec, err)
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return TradingWindow{}, fmt.Errorf("parse trading window %q: %w", spec, err)
+	}
+	if w.start == w.end {
+		return TradingWindow{}, fmt.Errorf("parse trading window %q: empty window", spec)
+	}
+	return w, nil
+}
+
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// IsZero 代表不限制時段。
+func (w TradingWindow) IsZero() bool { return !w.set }
+
+// Contains 判斷 t 是否落在時段內；跨夜時段以開始當天的星期判斷。
+func (w TradingWindow) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+	t = t.In(w.loc)
+	mins := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.dayAllowed(day) && mins >= w.start && mins < w.end
+	}
+	if mins >= w.start {
+		return w.dayAllowed(day)
+	}
+	return mins < w.end && w.dayAllowed((day+6)%7)
+}
+
+func (w TradingWindow) dayAllowed(d time.Weekday) bool {
+	return w.days == 0 || w.days&(1<<uint(d)) != 0
+}
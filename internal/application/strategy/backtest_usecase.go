@@ -7,6 +7,7 @@ import (
 	"sort"
 	"time"
 
+	"ai-auto-trade/internal/application/analysis"
 	analysisDomain "ai-auto-trade/internal/domain/analysis"
 	strategyDomain "ai-auto-trade/internal/domain/strategy"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
@@ -26,13 +27,17 @@ type BacktestResult struct {
 }
 
 type BacktestTrade struct {
-	EntryDate  string  `json:"entry_date"`
-	EntryPrice float64 `json:"entry_price"`
-	ExitDate   string  `json:"exit_date"`
-	ExitPrice  float64 `json:"exit_price"`
-	PnL        float64 `json:"pnl"`
-	PnLPct     float64 `json:"pnl_pct"`
-	Reason     string  `json:"reason"`
+	Symbol     string    `json:"symbol,omitempty"` // 多交易對策略才會填入
+	EntryDate  string    `json:"entry_date"`
+	EntryTime  time.Time `json:"entry_time"` // 訊號 K 線的開盤時間
+	EntryPrice float64   `json:"entry_price"`
+	ExitDate   string    `json:"exit_date"`
+	ExitTime   time.Time `json:"exit_time"`
+	ExitPrice  float64   `json:"exit_price"`
+	PnL        float64   `json:"pnl"`
+	PnLPct     float64   `json:"pnl_pct"`
+	Reason     string    `json:"reason"`
+	Open       bool      `json:"open,omitempty"` // 回測結束時仍持有，以最後收盤價估值
 }
 
 type SimulationSummary struct {
@@ -42,6 +47,7 @@ type SimulationSummary struct {
 }
 
 type BacktestEvent struct {
+	Symbol         string             `json:"symbol,omitempty"` // 多交易對策略才會填入
 	TradeDate      string             `json:"trade_date"`
 	ClosePrice     float64            `json:"close_price"`
 	ChangePercent  float64            `json:"change_percent"`
@@ -62,15 +68,45 @@ type DataProvider interface {
 	FindHistory(ctx context.Context, symbol string, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error)
 }
 
+// HistoryFiller 在策略週期沒有分析資料時，由較細週期的 K 線重新取樣並以 version（空值為啟用中的版本）分析後補上，
+// 回傳是否有補到資料。
+type HistoryFiller interface {
+	FillHistory(ctx context.Context, symbol, timeframe, version string, from, to time.Time) (bool, error)
+}
+
 type BacktestUseCase struct {
 	db       *gorm.DB
 	dataProv DataProvider
+	filler   HistoryFiller
 }
 
 func NewBacktestUseCase(db *gorm.DB, dataProv DataProvider) *BacktestUseCase {
 	return &BacktestUseCase{db: db, dataProv: dataProv}
 }
 
+// SetHistoryFiller 讓回測在缺少策略週期的資料時改由較細的週期產生。
+func (u *BacktestUseCase) SetHistoryFiller(f HistoryFiller) {
+	u.filler = f
+}
+
+// loadHistory 讀取回測區間內策略釘選版本（未釘選時為啟用中版本）的分析結果；
+// 沒有資料且設定了 HistoryFiller 時先補資料再讀一次。
+func (u *BacktestUseCase) loadHistory(ctx context.Context, s *strategyDomain.ScoringStrategy, symbol string, start, end time.Time) ([]analysisDomain.DailyAnalysisResult, error) {
+	timeframe, version := s.Timeframe, s.AnalysisVersion
+	history, err := analysis.ReadHistory(ctx, u.dataProv, version, symbol, timeframe, &start, &end, 5000, true)
+	if err != nil || len(history) > 0 || u.filler == nil {
+		return history, err
+	}
+	filled, err := u.filler.FillHistory(ctx, symbol, timeframe, version, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("derive %s history for %s: %w", timeframe, symbol, err)
+	}
+	if !filled {
+		return history, nil
+	}
+	return analysis.ReadHistory(ctx, u.dataProv, version, symbol, timeframe, &start, &end, 5000, true)
+}
+
 func (u *BacktestUseCase) Execute(ctx context.Context, slug string, symbol string, start, end time.Time, horizons []int) (*BacktestResult, error) {
 	if u.db == nil {
 		return nil, fmt.Errorf("database not available")
@@ -89,8 +125,11 @@ func (u *BacktestUseCase) Execute(ctx context.Context, slug string, symbol strin
 }
 
 func (u *BacktestUseCase) ExecuteWithStrategy(ctx context.Context, s *strategyDomain.ScoringStrategy, symbol string, start, end time.Time, horizons []int) (*BacktestResult, error) {
+	if s.IsMultiSymbol() && s.IsSignalDriven() {
+		return u.executeUniverse(ctx, s, start, end)
+	}
 	// 2. Load History
-	history, err := u.dataProv.FindHistory(ctx, symbol, s.Timeframe, &start, &end, 5000, true)
+	history, err := u.loadHistory(ctx, s, symbol, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("fetch history failed: %w", err)
 	}
@@ -98,6 +137,13 @@ func (u *BacktestUseCase) ExecuteWithStrategy(ctx context.Context, s *strategyDo
 		return history[i].TradeDate.Before(history[j].TradeDate)
 	})
 
+	switch s.Kind {
+	case strategyDomain.KindDCA:
+		return simulateDCA(s, symbol, start, end, history)
+	case strategyDomain.KindGrid:
+		return simulateGrid(s, symbol, start, end, history)
+	}
+
 	if len(horizons) == 0 {
 		horizons = []int{3, 5, 10}
 	}
@@ -145,6 +191,7 @@ func (u *BacktestUseCase) ExecuteWithStrategy(ctx context.Context, s *strategyDo
 			if triggered {
 				currentPosition = &BacktestTrade{
 					EntryDate:  res.TradeDate.Format("2006-01-02"),
+					EntryTime:  res.TradeDate,
 					EntryPrice: res.Close,
 				}
 			}
@@ -158,6 +205,7 @@ func (u *BacktestUseCase) ExecuteWithStrategy(ctx context.Context, s *strategyDo
 			if exitTriggered {
 				currentPosition.Reason = reason
 				currentPosition.ExitDate = res.TradeDate.Format("2006-01-02")
+				currentPosition.ExitTime = res.TradeDate
 				currentPosition.ExitPrice = res.Close
 				
 				// Apply 0.1% slippage/fee on exit
@@ -176,6 +224,8 @@ func (u *BacktestUseCase) ExecuteWithStrategy(ctx context.Context, s *strategyDo
 	if currentPosition != nil && len(history) > 0 {
 		last := history[len(history)-1]
 		currentPosition.ExitDate = last.TradeDate.Format("2006-01-02")
+		currentPosition.ExitTime = last.TradeDate
+		currentPosition.Open = true
 		currentPosition.ExitPrice = last.Close
 		currentPosition.PnL = currentPosition.ExitPrice - currentPosition.EntryPrice
 		currentPosition.PnLPct = (currentPosition.ExitPrice / currentPosition.EntryPrice) - 1.0
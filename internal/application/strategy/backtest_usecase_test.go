@@ -154,3 +154,44 @@ func TestBacktestUseCase_Execute(t *testing.T) {
 		t.Errorf("Expected BTCUSDT, got %s", res.Symbol)
 	}
 }
+
+// fillingProvider 在 filler 執行前沒有資料，模擬策略週期尚未產生的情況。
+type fillingProvider struct {
+	history []analysis.DailyAnalysisResult
+	filled  bool
+}
+
+func (p *fillingProvider) FindHistory(_ context.Context, _ string, _ string, _, _ *time.Time, _ int, _ bool) ([]analysis.DailyAnalysisResult, error) {
+	if !p.filled {
+		return nil, nil
+	}
+	return p.history, nil
+}
+
+func (p *fillingProvider) FillHistory(_ context.Context, _, timeframe, _ string, _, _ time.Time) (bool, error) {
+	p.filled = timeframe == "4h"
+	return p.filled, nil
+}
+
+func TestBacktestUseCase_FillsMissingTimeframe(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	prov := &fillingProvider{history: []analysis.DailyAnalysisResult{
+		{TradeDate: start, Timeframe: "4h", Close: 100, Score: 80},
+		{TradeDate: start.Add(4 * time.Hour), Timeframe: "4h", Close: 110, Score: 80},
+	}}
+	usecase := NewBacktestUseCase(nil, prov)
+	usecase.SetHistoryFiller(prov)
+	s := &strategy.ScoringStrategy{
+		Threshold:  70,
+		EntryRules: []strategy.StrategyRule{{Condition: strategy.Condition{Type: "BASE_SCORE"}, Weight: 1}},
+		Timeframe:  "4h",
+	}
+
+	res, err := usecase.ExecuteWithStrategy(context.Background(), s, "BTCUSDT", start, start.Add(8*time.Hour), []int{1})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !prov.filled || res.TotalEvents != 2 {
+		t.Fatalf("expected derived history to be used, got %d events", res.TotalEvents)
+	}
+}
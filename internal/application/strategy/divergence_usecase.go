@@ -0,0 +1,306 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// TradeHistory 提供實際成交紀錄（trading.Service 已實作）。
+type TradeHistory interface {
+	ListTrades(ctx context.Context, filter tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error)
+}
+
+// backtestExitFee 為回測出場時扣除的手續費/滑價（見 ExecuteWithStrategy）。
+const backtestExitFee = 0.001
+
+// DivergenceTrade 為一筆回測交易與實際交易的比對結果。
+type DivergenceTrade struct {
+	Status string `json:"status"` // matched / missed（回測有、實盤無）/ extra（實盤有、回測無）
+	Symbol string `json:"symbol"`
+
+	SignalTime     *time.Time `json:"signal_time,omitempty"` // 回測訊號 K 線收盤時間
+	SimEntryPrice  float64    `json:"sim_entry_price,omitempty"`
+	SimExitPrice   float64    `json:"sim_exit_price,omitempty"`
+	SimPnLPct      float64    `json:"sim_pnl_pct,omitempty"`
+	LiveEntryTime  *time.Time `json:"live_entry_time,omitempty"`
+	LiveEntryPrice float64    `json:"live_entry_price,omitempty"`
+	LiveExitTime   *time.Time `json:"live_exit_time,omitempty"`
+	LiveExitPrice  float64    `json:"live_exit_price,omitempty"`
+	LivePnLPct     float64    `json:"live_pnl_pct,omitempty"` // 扣除估計手續費後
+
+	EntrySlippagePct float64 `json:"entry_slippage_pct"` // 實際買價高於 K 線收盤價的比例，正值為不利
+	ExitSlippagePct  float64 `json:"exit_slippage_pct"`  // 實際賣價低於 K 線收盤價的比例，正值為不利
+	FeeDragPct       float64 `json:"fee_drag_pct"`       // 實際估計手續費超出回測假設的部分（佔進場名目）
+	EntryDelaySec    float64 `json:"entry_delay_sec"`    // 實際下單時間晚於訊號 K 線收盤的秒數
+	ExitDelaySec     float64 `json:"exit_delay_sec"`
+	ShortfallPct     float64 `json:"shortfall_pct"` // 回測報酬 - 實際報酬
+	Open             bool    `json:"open,omitempty"`
+}
+
+// DivergenceSummary 彙總實盤與回測的落差（implementation shortfall）。
+type DivergenceSummary struct {
+	SimTrades           int     `json:"sim_trades"`
+	LiveTrades          int     `json:"live_trades"`
+	Matched             int     `json:"matched"`
+	Missed              int     `json:"missed"`
+	Extra               int     `json:"extra"`
+	AvgEntrySlippagePct float64 `json:"avg_entry_slippage_pct"`
+	AvgExitSlippagePct  float64 `json:"avg_exit_slippage_pct"`
+	TotalFeeDragPct     float64 `json:"total_fee_drag_pct"`
+	AvgEntryDelaySec    float64 `json:"avg_entry_delay_sec"`
+	AvgExitDelaySec     float64 `json:"avg_exit_delay_sec"`
+	SimReturnPct        float64 `json:"sim_return_pct"`  // 已比對交易的回測報酬加總
+	LiveReturnPct       float64 `json:"live_return_pct"` // 已比對交易的實際報酬加總
+	ShortfallPct        float64 `json:"shortfall_pct"`
+}
+
+// DivergenceReport 為實盤 vs 回測落差報告；多交易對策略的 Symbol 為觸發時鐘，逐筆交易另有各自的交易對。
+type DivergenceReport struct {
+	StrategyID  string                    `json:"strategy_id"`
+	Symbol      string                    `json:"symbol"`
+	Timeframe   string                    `json:"timeframe"`
+	Env         tradingDomain.Environment `json:"env"`
+	PeriodStart time.Time                 `json:"period_start"`
+	PeriodEnd   time.Time                 `json:"period_end"`
+	FeeRate     float64                   `json:"fee_rate"`
+	Summary     DivergenceSummary         `json:"summary"`
+	Trades      []DivergenceTrade         `json:"trades"`
+}
+
+// DivergenceUseCase 在策略上線期間重跑回測，與實際成交逐筆比對。
+type DivergenceUseCase struct {
+	backtest *BacktestUseCase
+	trades   TradeHistory
+	feeRate  float64
+}
+
+// NewDivergenceUseCase 建立落差報告；feeRate 為實際成交估計手續費率（<=0 時使用 0.1%）。
+func NewDivergenceUseCase(backtest *BacktestUseCase, trades TradeHistory, feeRate float64) *DivergenceUseCase {
+	if feeRate <= 0 {
+		feeRate = 0.001
+	}
+	return &DivergenceUseCase{backtest: backtest, trades: trades, feeRate: feeRate}
+}
+
+// liveTrade 為一次實際進出場（買單與其後的賣單）。
+type liveTrade struct {
+	symbol string
+	buy    tradingDomain.TradeRecord
+	sell   *tradingDomain.TradeRecord
+}
+
+func (u *DivergenceUseCase) Execute(ctx context.Context, s *strategyDomain.ScoringStrategy, env tradingDomain.Environment, start, end time.Time) (*DivergenceReport, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	bar := strategyDomain.TimeframeDuration(s.Timeframe)
+	if bar <= 0 {
+		bar = 24 * time.Hour
+	}
+	sim, err := u.backtest.ExecuteWithStrategy(ctx, s, s.BaseSymbol, start, end, nil)
+	if err != nil {
+		return nil, fmt.Errorf("replay backtest: %w", err)
+	}
+	records, err := u.trades.ListTrades(ctx, tradingDomain.TradeFilter{StrategyID: s.ID, Env: env, StartDate: &start, EndDate: &end, All: true})
+	if err != nil {
+		return nil, fmt.Errorf("list live trades: %w", err)
+	}
+	live := pairLiveTrades(records, s.BaseSymbol)
+
+	rep := &DivergenceReport{
+		StrategyID:  s.ID,
+		Symbol:      s.BaseSymbol,
+		Timeframe:   s.Timeframe,
+		Env:         env,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		FeeRate:     u.feeRate,
+		Trades:      []DivergenceTrade{},
+	}
+
+	// 依時間順序貪婪比對同一交易對：實際買單須落在訊號 K 線開盤到收盤後一根 K 線之間
+	used := make([]bool, len(live))
+	for _, st := range sim.Trades {
+		signal := st.EntryTime.Add(bar)
+		symbol := tradeSymbol(st.Symbol, s.BaseSymbol)
+		idx := -1
+		for i, lt := range live {
+			if used[i] || lt.symbol != symbol || lt.buy.EntryDate.Before(st.EntryTime) {
+				continue
+			}
+			if lt.buy.EntryDate.Before(signal.Add(bar)) {
+				idx = i
+			}
+			break
+		}
+		if idx < 0 {
+			rep.Trades = append(rep.Trades, DivergenceTrade{
+				Status:        "missed",
+				Symbol:        symbol,
+				SignalTime:    &signal,
+				SimEntryPrice: st.EntryPrice,
+				SimExitPrice:  st.ExitPrice,
+				SimPnLPct:     st.PnLPct,
+				Open:          st.Open,
+			})
+			continue
+		}
+		used[idx] = true
+		rep.Trades = append(rep.Trades, u.compare(st, live[idx], signal, bar))
+	}
+	for i, lt := range live {
+		if used[i] {
+			continue
+		}
+		dt := DivergenceTrade{Status: "extra", Symbol: lt.symbol}
+		u.fillLive(&dt, lt)
+		rep.Trades = append(rep.Trades, dt)
+	}
+	sort.SliceStable(rep.Trades, func(i, j int) bool {
+		return tradeTime(rep.Trades[i]).Before(tradeTime(rep.Trades[j]))
+	})
+
+	rep.Summary = summarizeDivergence(rep.Trades, len(sim.Trades), len(live))
+	return rep, nil
+}
+
+func (u *DivergenceUseCase) compare(st BacktestTrade, lt liveTrade, signal time.Time, bar time.Duration) DivergenceTrade {
+	dt := DivergenceTrade{
+		Status:        "matched",
+		Symbol:        lt.symbol,
+		SignalTime:    &signal,
+		SimEntryPrice: st.EntryPrice,
+		SimExitPrice:  st.ExitPrice,
+		SimPnLPct:     st.PnLPct,
+		Open:          st.Open || lt.sell == nil,
+	}
+	u.fillLive(&dt, lt)
+	if st.EntryPrice > 0 {
+		dt.EntrySlippagePct = (lt.buy.EntryPrice - st.EntryPrice) / st.EntryPrice
+	}
+	dt.EntryDelaySec = lt.buy.EntryDate.Sub(signal).Seconds()
+	if dt.Open {
+		return dt
+	}
+	if st.ExitPrice > 0 {
+		dt.ExitSlippagePct = (st.ExitPrice - dt.LiveExitPrice) / st.ExitPrice
+	}
+	dt.ExitDelaySec = dt.LiveExitTime.Sub(st.ExitTime.Add(bar)).Seconds()
+	if st.EntryPrice > 0 {
+		dt.FeeDragPct = u.liveFeePct(lt) - backtestExitFee*st.ExitPrice/st.EntryPrice
+	}
+	dt.ShortfallPct = dt.SimPnLPct - dt.LivePnLPct
+	return dt
+}
+
+// fillLive 填入實際成交資訊，實際報酬扣除估計的進出場手續費。
+func (u *DivergenceUseCase) fillLive(dt *DivergenceTrade, lt liveTrade) {
+	entry := lt.buy.EntryDate
+	dt.LiveEntryTime = &entry
+	dt.LiveEntryPrice = lt.buy.EntryPrice
+	if lt.sell == nil || lt.sell.ExitDate == nil || lt.sell.ExitPrice == nil {
+		dt.Open = true
+		return
+	}
+	dt.LiveExitTime = lt.sell.ExitDate
+	dt.LiveExitPrice = *lt.sell.ExitPrice
+	if lt.buy.EntryPrice > 0 {
+		dt.LivePnLPct = dt.LiveExitPrice/lt.buy.EntryPrice - 1 - u.liveFeePct(lt)
+	}
+}
+
+func (u *DivergenceUseCase) liveFeePct(lt liveTrade) float64 {
+	if lt.sell == nil || lt.sell.ExitPrice == nil || lt.buy.EntryPrice <= 0 {
+		return u.feeRate
+	}
+	return u.feeRate * (1 + *lt.sell.ExitPrice/lt.buy.EntryPrice)
+}
+
+// pairLiveTrades 將買單與同一交易對其後第一筆賣單配對；同一策略同一環境每個交易對一次只會有一個持倉。
+// 紀錄沒有交易對時視為 baseSymbol。
+func pairLiveTrades(records []tradingDomain.TradeRecord, baseSymbol string) []liveTrade {
+	sorted := append([]tradingDomain.TradeRecord(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return recordTime(sorted[i]).Before(recordTime(sorted[j])) })
+	var out []liveTrade
+	open := make(map[string]int)
+	for i, r := range sorted {
+		symbol := tradeSymbol(r.Symbol, baseSymbol)
+		if strings.EqualFold(r.Side, "buy") {
+			open[symbol] = len(out)
+			out = append(out, liveTrade{symbol: symbol, buy: r})
+			continue
+		}
+		if j, ok := open[symbol]; ok {
+			out[j].sell = &sorted[i]
+			delete(open, symbol)
+		}
+	}
+	return out
+}
+
+func tradeSymbol(symbol, baseSymbol string) string {
+	if symbol == "" {
+		symbol = baseSymbol
+	}
+	return strings.ToUpper(symbol)
+}
+
+func recordTime(r tradingDomain.TradeRecord) time.Time {
+	if r.ExitDate != nil {
+		return *r.ExitDate
+	}
+	return r.EntryDate
+}
+
+func tradeTime(t DivergenceTrade) time.Time {
+	if t.SignalTime != nil {
+		return *t.SignalTime
+	}
+	if t.LiveEntryTime != nil {
+		return *t.LiveEntryTime
+	}
+	return time.Time{}
+}
+
+func summarizeDivergence(trades []DivergenceTrade, simCount, liveCount int) DivergenceSummary {
+	sum := DivergenceSummary{SimTrades: simCount, LiveTrades: liveCount}
+	var exits int
+	for _, t := range trades {
+		switch t.Status {
+		case "missed":
+			sum.Missed++
+			continue
+		case "extra":
+			sum.Extra++
+			continue
+		}
+		sum.Matched++
+		sum.AvgEntrySlippagePct += t.EntrySlippagePct
+		sum.AvgEntryDelaySec += t.EntryDelaySec
+		if t.Open {
+			continue
+		}
+		exits++
+		sum.AvgExitSlippagePct += t.ExitSlippagePct
+		sum.AvgExitDelaySec += t.ExitDelaySec
+		sum.TotalFeeDragPct += t.FeeDragPct
+		sum.SimReturnPct += t.SimPnLPct
+		sum.LiveReturnPct += t.LivePnLPct
+	}
+	if sum.Matched > 0 {
+		sum.AvgEntrySlippagePct /= float64(sum.Matched)
+		sum.AvgEntryDelaySec /= float64(sum.Matched)
+	}
+	if exits > 0 {
+		sum.AvgExitSlippagePct /= float64(exits)
+		sum.AvgExitDelaySec /= float64(exits)
+	}
+	sum.ShortfallPct = sum.SimReturnPct - sum.LiveReturnPct
+	return sum
+}
@@ -0,0 +1,129 @@
+package strategy
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type stubTradeHistory struct {
+	trades []tradingDomain.TradeRecord
+	filter *tradingDomain.TradeFilter
+}
+
+func (s stubTradeHistory) ListTrades(_ context.Context, f tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error) {
+	if s.filter != nil {
+		*s.filter = f
+	}
+	return s.trades, nil
+}
+
+func TestDivergenceUseCase_MatchesLiveTrades(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC) }
+	h := []analysis.DailyAnalysisResult{
+		{TradeDate: day(1), Close: 100, Score: 90}, // 進場
+		{TradeDate: day(2), Close: 105, Score: 90},
+		{TradeDate: day(3), Close: 95, Score: 20},  // 出場
+		{TradeDate: day(4), Close: 110, Score: 90}, // 再次進場（實盤漏單）
+		{TradeDate: day(5), Close: 120, Score: 90},
+	}
+	tp := 100.0
+	s := &strategy.ScoringStrategy{
+		ID:         "s-1",
+		BaseSymbol: "BTCUSDT",
+		Timeframe:  "1d",
+		Threshold:  70,
+		Risk:       tradingDomain.RiskSettings{TakeProfitPct: &tp},
+		EntryRules: []strategy.StrategyRule{{Condition: strategy.Condition{Type: "BASE_SCORE"}, Weight: 1}},
+	}
+
+	at := func(d time.Time, sec int) *time.Time { v := d.Add(time.Duration(sec) * time.Second); return &v }
+	price := func(v float64) *float64 { return &v }
+	live := []tradingDomain.TradeRecord{
+		// 回測沒有的多單
+		{Side: "buy", EntryDate: day(1).Add(-12 * time.Hour), EntryPrice: 99},
+		{Side: "sell", EntryDate: day(1).Add(-12 * time.Hour), ExitDate: at(day(1), -6*3600), ExitPrice: price(99.5)},
+		// 對應第一筆回測交易：訊號於 1/2 00:00 收盤，30 秒後買進、出場訊號 1/4 00:00 收盤後 60 秒賣出
+		{Side: "buy", EntryDate: *at(day(2), 30), EntryPrice: 100.5},
+		{Side: "sell", EntryDate: *at(day(2), 30), ExitDate: at(day(4), 60), ExitPrice: price(94.5)},
+	}
+
+	uc := NewDivergenceUseCase(NewBacktestUseCase(nil, &mockDataProvider{history: h}), stubTradeHistory{trades: live}, 0.001)
+	rep, err := uc.Execute(context.Background(), s, tradingDomain.EnvPaper, day(1), day(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := rep.Summary
+	if sum.SimTrades != 2 || sum.LiveTrades != 2 || sum.Matched != 1 || sum.Missed != 1 || sum.Extra != 1 {
+		t.Fatalf("unexpected counts %+v", sum)
+	}
+	var m *DivergenceTrade
+	for i := range rep.Trades {
+		if rep.Trades[i].Status == "matched" {
+			m = &rep.Trades[i]
+		}
+	}
+	if rep.Trades[0].Status != "extra" || m == nil {
+		t.Fatalf("unexpected trades %+v", rep.Trades)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(m.EntrySlippagePct, 0.005) || m.EntryDelaySec != 30 {
+		t.Errorf("entry slippage %.6f delay %.0f", m.EntrySlippagePct, m.EntryDelaySec)
+	}
+	if !near(m.ExitSlippagePct, 0.5/95) || m.ExitDelaySec != 60 {
+		t.Errorf("exit slippage %.6f delay %.0f", m.ExitSlippagePct, m.ExitDelaySec)
+	}
+	if want := 0.001*(1+94.5/100.5) - 0.001*95/100; !near(m.FeeDragPct, want) {
+		t.Errorf("fee drag %.8f, want %.8f", m.FeeDragPct, want)
+	}
+	if !near(sum.ShortfallPct, m.SimPnLPct-m.LivePnLPct) || sum.ShortfallPct <= 0 {
+		t.Errorf("unexpected shortfall %+v", sum)
+	}
+}
+
+func TestDivergenceUseCase_UniverseMatchesPerSymbol(t *testing.T) {
+	data := symbolHistoryProvider{
+		"AAAUSDT": scoredBars(10, 10, 10),
+		"BBBUSDT": scoredBars(90, 90, 90),
+		"CCCUSDT": scoredBars(80, 80, 80),
+	}
+	tp := 100.0
+	s := &strategy.ScoringStrategy{
+		ID:           "s-u",
+		BaseSymbol:   "AAAUSDT",
+		Timeframe:    "1d",
+		Threshold:    60,
+		Universe:     &strategy.Universe{Symbols: []string{"AAAUSDT", "BBBUSDT", "CCCUSDT"}},
+		MaxPositions: 2,
+		Risk:         tradingDomain.RiskSettings{TakeProfitPct: &tp},
+		EntryRules:   []strategy.StrategyRule{{Condition: strategy.Condition{Type: "BASE_SCORE"}, Weight: 1}},
+	}
+	signal := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	live := []tradingDomain.TradeRecord{
+		// 時間落在回測訊號的容許範圍內，但交易對不在回測進場名單
+		{Symbol: "AAAUSDT", Side: "buy", EntryDate: signal.Add(5 * time.Second), EntryPrice: 100},
+		{Symbol: "BBBUSDT", Side: "buy", EntryDate: signal.Add(10 * time.Second), EntryPrice: 100.2},
+	}
+	var filter tradingDomain.TradeFilter
+	uc := NewDivergenceUseCase(NewBacktestUseCase(nil, data), stubTradeHistory{trades: live, filter: &filter}, 0.001)
+	rep, err := uc.Execute(context.Background(), s, tradingDomain.EnvPaper, signal.AddDate(0, 0, -1), signal.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter.All {
+		t.Fatalf("live trades must be read without the list cap")
+	}
+	status := map[string]string{}
+	for _, tr := range rep.Trades {
+		status[tr.Symbol] = tr.Status
+	}
+	if status["BBBUSDT"] != "matched" || status["CCCUSDT"] != "missed" || status["AAAUSDT"] != "extra" {
+		t.Fatalf("expected per-symbol matching, got %+v", rep.Trades)
+	}
+}
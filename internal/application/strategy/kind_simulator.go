@@ -0,0 +1,243 @@
+package strategy
+
+import (
+	"fmt"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+)
+
+// exitFee 為回測出場時扣除的手續費/滑價（與評分策略一致）。
+const exitFee = 0.999
+
+// simulateDCA 依收盤價模擬定期定額：每隔 Interval 買入一次，達停利時整輪賣出。
+// TotalReturn 為已實現與未實現損益占總投入金額的比例。
+func simulateDCA(s *strategyDomain.ScoringStrategy, symbol string, start, end time.Time, history []analysisDomain.DailyAnalysisResult) (*BacktestResult, error) {
+	cfg := s.DCA
+	if cfg == nil {
+		return nil, fmt.Errorf("dca strategy %s has no dca params", s.Slug)
+	}
+	interval := strategyDomain.TimeframeDuration(cfg.Interval)
+	tp := cfg.TakeProfitPct
+	if tp > 1 {
+		tp /= 100
+	}
+
+	var (
+		events   []BacktestEvent
+		trades   []BacktestTrade
+		qty      float64
+		cost     float64
+		buys     int
+		first    time.Time
+		lastBuy  time.Time
+		invested float64
+		profit   float64
+	)
+	closeRound := func(res analysisDomain.DailyAnalysisResult, reason string, open bool) {
+		avg := cost / qty
+		exit := res.Close
+		if !open {
+			exit *= exitFee
+		}
+		trades = append(trades, BacktestTrade{
+			EntryDate:  first.Format("2006-01-02"),
+			EntryTime:  first,
+			EntryPrice: avg,
+			ExitDate:   res.TradeDate.Format("2006-01-02"),
+			ExitTime:   res.TradeDate,
+			ExitPrice:  res.Close,
+			PnL:        exit - avg,
+			PnLPct:     exit/avg - 1,
+			Reason:     reason,
+			Open:       open,
+		})
+		profit += (exit - avg) * qty
+		qty, cost, buys = 0, 0, 0
+	}
+
+	for _, res := range history {
+		if res.Close <= 0 {
+			continue
+		}
+		score := res.Score
+		if len(s.EntryRules) > 0 {
+			score, _ = s.CalculateScoreForRules(s.EntryRules, res)
+		}
+		bought := false
+
+		// 與實盤一致：止盈當根只賣出，下一根才開始新一輪
+		tookProfit := qty > 0 && tp > 0 && res.Close/(cost/qty)-1 >= tp
+		if tookProfit {
+			closeRound(res, fmt.Sprintf("DCA 止盈 (%.2f%%)", tp*100), false)
+			lastBuy = time.Time{}
+		}
+		due := lastBuy.IsZero() || res.TradeDate.Sub(lastBuy) >= interval
+		if !tookProfit && due && (cfg.MaxOrders == 0 || buys < cfg.MaxOrders) {
+			if amount := cfg.AmountFor(score); amount > 0 {
+				if qty == 0 {
+					first = res.TradeDate
+				}
+				qty += amount / res.Close
+				cost += amount
+				invested += amount
+				buys++
+				lastBuy = res.TradeDate
+				bought = true
+			}
+		}
+
+		events = append(events, BacktestEvent{
+			TradeDate:     res.TradeDate.Format("2006-01-02"),
+			ClosePrice:    res.Close,
+			ChangePercent: res.ChangeRate,
+			TotalScore:    score,
+			EntryScore:    score,
+			IsTriggered:   bought,
+			Return5d:      res.Return5,
+		})
+	}
+	if qty > 0 && len(history) > 0 {
+		closeRound(history[len(history)-1], "回測結束前尚未出場 (Simulation End)", true)
+	}
+
+	summary := kindSummary(trades)
+	if invested > 0 {
+		summary.TotalReturn = profit / invested * 100
+	}
+	return &BacktestResult{
+		Symbol:      symbol,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		TotalEvents: len(events),
+		Events:      events,
+		Stats:       map[string]BacktestStats{},
+		Trades:      trades,
+		Summary:     summary,
+	}, nil
+}
+
+// simulateGrid 依收盤價模擬現貨網格：收盤跌破買價視為買單成交並在上一格掛賣，
+// 收盤突破賣價視為賣單成交並在原價位補買。TotalReturn 為損益占網格最大資金（OrderSize × Grids）的比例。
+func simulateGrid(s *strategyDomain.ScoringStrategy, symbol string, start, end time.Time, history []analysisDomain.DailyAnalysisResult) (*BacktestResult, error) {
+	cfg := s.Grid
+	if cfg == nil {
+		return nil, fmt.Errorf("grid strategy %s has no grid params", s.Slug)
+	}
+	levels := cfg.Levels()
+
+	type lot struct {
+		qty   float64
+		since time.Time
+	}
+	var (
+		events  []BacktestEvent
+		trades  []BacktestTrade
+		profit  float64
+		buyAt   = make(map[int]bool) // 掛著買單的價位
+		holding = make(map[int]lot)  // 已買入、等待在上一格賣出的價位
+	)
+
+	for idx, res := range history {
+		if res.Close <= 0 {
+			continue
+		}
+		if idx == 0 {
+			for i := 0; i < len(levels)-1 && levels[i] < res.Close; i++ {
+				buyAt[i] = true
+			}
+		}
+		filled := false
+
+		for i := 0; i < len(levels)-1; i++ {
+			l, ok := holding[i]
+			if !ok || res.Close < levels[i+1] {
+				continue
+			}
+			exit := levels[i+1] * exitFee
+			trades = append(trades, BacktestTrade{
+				EntryDate:  l.since.Format("2006-01-02"),
+				EntryTime:  l.since,
+				EntryPrice: levels[i],
+				ExitDate:   res.TradeDate.Format("2006-01-02"),
+				ExitTime:   res.TradeDate,
+				ExitPrice:  levels[i+1],
+				PnL:        exit - levels[i],
+				PnLPct:     exit/levels[i] - 1,
+				Reason:     fmt.Sprintf("Grid sell L%d", i+1),
+			})
+			profit += (exit - levels[i]) * l.qty
+			delete(holding, i)
+			buyAt[i] = true
+			filled = true
+		}
+		for i := 0; i < len(levels)-1; i++ {
+			if !buyAt[i] || res.Close > levels[i] {
+				continue
+			}
+			holding[i] = lot{qty: cfg.OrderSize / levels[i], since: res.TradeDate}
+			delete(buyAt, i)
+			filled = true
+		}
+
+		events = append(events, BacktestEvent{
+			TradeDate:     res.TradeDate.Format("2006-01-02"),
+			ClosePrice:    res.Close,
+			ChangePercent: res.ChangeRate,
+			IsTriggered:   filled,
+			Return5d:      res.Return5,
+		})
+	}
+
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		for i := 0; i < len(levels)-1; i++ {
+			l, ok := holding[i]
+			if !ok {
+				continue
+			}
+			trades = append(trades, BacktestTrade{
+				EntryDate:  l.since.Format("2006-01-02"),
+				EntryTime:  l.since,
+				EntryPrice: levels[i],
+				ExitDate:   last.TradeDate.Format("2006-01-02"),
+				ExitTime:   last.TradeDate,
+				ExitPrice:  last.Close,
+				PnL:        last.Close - levels[i],
+				PnLPct:     last.Close/levels[i] - 1,
+				Reason:     "回測結束前尚未出場 (Simulation End)",
+				Open:       true,
+			})
+			profit += (last.Close - levels[i]) * l.qty
+		}
+	}
+
+	summary := kindSummary(trades)
+	summary.TotalReturn = profit / (cfg.OrderSize * float64(cfg.Grids)) * 100
+	return &BacktestResult{
+		Symbol:      symbol,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		TotalEvents: len(events),
+		Events:      events,
+		Stats:       map[string]BacktestStats{},
+		Trades:      trades,
+		Summary:     summary,
+	}, nil
+}
+
+func kindSummary(trades []BacktestTrade) SimulationSummary {
+	summary := SimulationSummary{TotalTrades: len(trades)}
+	if len(trades) == 0 {
+		return summary
+	}
+	wins := 0
+	for _, t := range trades {
+		if t.PnLPct > 0 {
+			wins++
+		}
+	}
+	summary.WinRate = float64(wins) / float64(len(trades)) * 100
+	return summary
+}
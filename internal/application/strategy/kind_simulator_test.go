@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/strategy"
+)
+
+func dailyCloses(closes ...float64) []analysis.DailyAnalysisResult {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]analysis.DailyAnalysisResult, len(closes))
+	for i, c := range closes {
+		out[i] = analysis.DailyAnalysisResult{TradeDate: start.AddDate(0, 0, i), Close: c, Score: 50}
+	}
+	return out
+}
+
+func TestBacktestUseCase_SimulatesDCA(t *testing.T) {
+	usecase := NewBacktestUseCase(nil, &mockDataProvider{history: dailyCloses(100, 80, 90, 120, 110)})
+	s := &strategy.ScoringStrategy{
+		Kind: strategy.KindDCA,
+		DCA:  &strategy.DCAConfig{Amount: 100, Interval: "1d", TakeProfitPct: 20},
+	}
+
+	res, err := usecase.ExecuteWithStrategy(context.Background(), s, "BTCUSDT", time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trades) != 2 {
+		t.Fatalf("expected one closed round and one open round, got %+v", res.Trades)
+	}
+	round := res.Trades[0]
+	avg := 300 / (100.0/100 + 100.0/80 + 100.0/90)
+	if math.Abs(round.EntryPrice-avg) > 1e-9 || round.ExitPrice != 120 || round.Open {
+		t.Fatalf("expected take profit at 120 on average cost %.4f, got %+v", avg, round)
+	}
+	if !res.Trades[1].Open || res.Trades[1].EntryPrice != 110 {
+		t.Fatalf("expected new round bought after take profit, got %+v", res.Trades[1])
+	}
+	if res.Summary.TotalReturn <= 0 {
+		t.Fatalf("expected positive return, got %.4f", res.Summary.TotalReturn)
+	}
+}
+
+func TestBacktestUseCase_SimulatesGrid(t *testing.T) {
+	usecase := NewBacktestUseCase(nil, &mockDataProvider{history: dailyCloses(160, 140, 180, 120, 130)})
+	s := &strategy.ScoringStrategy{
+		Kind: strategy.KindGrid,
+		Grid: &strategy.GridConfig{Lower: 100, Upper: 200, Grids: 4, OrderSize: 100},
+	}
+
+	res, err := usecase.ExecuteWithStrategy(context.Background(), s, "BTCUSDT", time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 140 成交 150 買單、180 在 175 賣出；120 再成交 125 與 150 買單，結束時未賣出。
+	var closed, open int
+	for _, tr := range res.Trades {
+		if tr.Open {
+			open++
+			continue
+		}
+		closed++
+		if tr.EntryPrice != 150 || tr.ExitPrice != 175 {
+			t.Fatalf("unexpected grid round trip %+v", tr)
+		}
+	}
+	if closed != 1 || open != 2 {
+		t.Fatalf("expected 1 closed and 2 open grid trades, got %+v", res.Trades)
+	}
+}
@@ -7,19 +7,31 @@ import (
 	"reflect"
 	"time"
 
+	"ai-auto-trade/internal/application/scheduler"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type SaveScoringStrategyInput struct {
-	UserID        string          `json:"user_id"`
-	Name          string          `json:"name"`
-	Slug          string          `json:"slug"`
-	BaseSymbol    string          `json:"base_symbol"`
-	Timeframe     string          `json:"timeframe"`
-	Threshold     float64         `json:"threshold"`
-	ExitThreshold float64         `json:"exit_threshold"`
-	Rules         []SaveRuleInput `json:"rules"`
+	UserID        string                     `json:"user_id"`
+	Name          string                     `json:"name"`
+	Slug          string                     `json:"slug"`
+	BaseSymbol    string                     `json:"base_symbol"`
+	Timeframe     string                     `json:"timeframe"`
+	Exchange      string                     `json:"exchange"`
+	Schedule      string                     `json:"schedule"`       // cron，空值代表每根 K 線收盤時評估
+	TradingWindow string                     `json:"trading_window"` // 例如 "mon-fri 09:00-17:00"
+	Threshold     float64                    `json:"threshold"`
+	ExitThreshold float64                    `json:"exit_threshold"`
+	Kind          string                     `json:"kind"` // scoring（預設）、dca、grid
+	DCA           *strategyDomain.DCAConfig  `json:"dca,omitempty"`
+	Grid          *strategyDomain.GridConfig `json:"grid,omitempty"`
+	Universe      *strategyDomain.Universe   `json:"universe,omitempty"` // 多交易對輪動，BaseSymbol 作為觸發時鐘
+	MaxPositions  int                        `json:"max_positions"`
+	AnalysisVersion string                   `json:"analysis_version"` // 釘選的分析版本，空值代表跟隨啟用中的版本
+	Rules         []SaveRuleInput            `json:"rules"`
 }
 
 type SaveRuleInput struct {
@@ -46,25 +58,85 @@ func (u *SaveScoringStrategyUseCase) Execute(ctx context.Context, input SaveScor
 		return fmt.Errorf("database storage not initialized")
 	}
 
-	hasEntry := false
-	hasExit := false
-	for _, r := range input.Rules {
-		if r.RuleType == "entry" || r.RuleType == "" || r.RuleType == "both" {
-			hasEntry = true
+	kind := input.Kind
+	if kind == "" {
+		kind = strategyDomain.KindScoring
+	}
+	kindParams, err := kindParamsJSON(kind, input)
+	if err != nil {
+		return err
+	}
+
+	var universe []byte
+	if input.Universe != nil {
+		if kind != strategyDomain.KindScoring {
+			return fmt.Errorf("只有評分策略支援多交易對 (universe)")
 		}
-		if r.RuleType == "exit" || r.RuleType == "both" {
-			hasExit = true
+		if err := input.Universe.Validate(); err != nil {
+			return err
+		}
+		if input.BaseSymbol == "" && len(input.Universe.Symbols) > 0 {
+			input.BaseSymbol = input.Universe.Symbols[0]
+		}
+		if input.BaseSymbol == "" {
+			return fmt.Errorf("動態交易對清單需要 base_symbol 作為觸發時鐘")
+		}
+		if universe, err = json.Marshal(input.Universe); err != nil {
+			return err
 		}
 	}
-	if !hasEntry {
-		return fmt.Errorf("策略必須包含至少一個進場規則 (entry)")
+	if input.MaxPositions < 0 {
+		return fmt.Errorf("max_positions 不可為負數")
 	}
-	if !hasExit {
-		return fmt.Errorf("策略必須包含至少一個出場規則 (exit)")
+	maxPositions := input.MaxPositions
+	if maxPositions == 0 {
+		maxPositions = 1
+	}
+
+	// DCA 與網格自行管理進出場，規則為選用（DCA 的進場規則用於計算投入金額倍數）
+	if kind == strategyDomain.KindScoring {
+		hasEntry := false
+		hasExit := false
+		for _, r := range input.Rules {
+			if r.RuleType == "entry" || r.RuleType == "" || r.RuleType == "both" {
+				hasEntry = true
+			}
+			if r.RuleType == "exit" || r.RuleType == "both" {
+				hasExit = true
+			}
+		}
+		if !hasEntry {
+			return fmt.Errorf("策略必須包含至少一個進場規則 (entry)")
+		}
+		if !hasExit {
+			return fmt.Errorf("策略必須包含至少一個出場規則 (exit)")
+		}
+	}
+
+	exchange := input.Exchange
+	if exchange == "" {
+		exchange = "binance"
+	}
+	if input.Schedule != "" {
+		if _, err := scheduler.ParseCron(input.Schedule, nil); err != nil {
+			return fmt.Errorf("排程格式錯誤: %w", err)
+		}
+	}
+	if _, err := scheduler.ParseTradingWindow(input.TradingWindow, nil); err != nil {
+		return fmt.Errorf("交易時段格式錯誤: %w", err)
 	}
 
 	var strategyID string
-	err := u.db.Transaction(func(tx *gorm.DB) error {
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		if input.AnalysisVersion != "" {
+			var registered int64
+			if err := tx.Table("analysis_versions").Where("name = ?", input.AnalysisVersion).Count(&registered).Error; err != nil {
+				return err
+			}
+			if registered == 0 {
+				return fmt.Errorf("分析版本 %s 尚未註冊", input.AnalysisVersion)
+			}
+		}
 		type Strategy struct {
 			ID            string `gorm:"primaryKey;default:gen_random_uuid()"`
 			UserID        string
@@ -75,6 +147,14 @@ func (u *SaveScoringStrategyUseCase) Execute(ctx context.Context, input SaveScor
 			BaseSymbol    string
 			Timeframe     string
 			Env           string
+			Exchange      string
+			Schedule      string
+			TradingWindow string
+			Kind          string
+			KindParams    []byte
+			Universe      []byte
+			MaxPositions  int
+			AnalysisVersion *string
 			IsActive      bool
 			UpdatedAt     time.Time
 		}
@@ -87,13 +167,21 @@ func (u *SaveScoringStrategyUseCase) Execute(ctx context.Context, input SaveScor
 			BaseSymbol:    input.BaseSymbol,
 			Timeframe:     input.Timeframe,
 			Env:           "both",
+			Exchange:      exchange,
+			Schedule:      input.Schedule,
+			TradingWindow: input.TradingWindow,
+			Kind:          kind,
+			KindParams:    kindParams,
+			Universe:      universe,
+			MaxPositions:  maxPositions,
+			AnalysisVersion: nullableVersion(input.AnalysisVersion),
 			IsActive:      true,
 			UpdatedAt:     time.Now(),
 		}
 
 		err := tx.Table("strategies").Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "slug"}},
-			DoUpdates: clause.AssignmentColumns([]string{"name", "threshold", "exit_threshold", "base_symbol", "timeframe", "updated_at"}),
+			DoUpdates: clause.AssignmentColumns([]string{"name", "threshold", "exit_threshold", "base_symbol", "timeframe", "exchange", "schedule", "trading_window", "kind", "kind_params", "universe", "max_positions", "analysis_version", "updated_at"}),
 		}).Create(&s).Error
 		if err != nil {
 			return err
@@ -154,3 +242,40 @@ func (u *SaveScoringStrategyUseCase) Execute(ctx context.Context, input SaveScor
 	fmt.Printf("[SaveStrategy] Successfully upserted strategy %s (ID: %s)\n", input.Slug, strategyID)
 	return nil
 }
+
+// kindParamsJSON 驗證策略種類參數並序列化為 kind_params 欄位內容，scoring 回傳 nil。
+func kindParamsJSON(kind string, input SaveScoringStrategyInput) ([]byte, error) {
+	var params interface{}
+	switch kind {
+	case strategyDomain.KindScoring:
+		return nil, nil
+	case strategyDomain.KindDCA:
+		if input.DCA == nil {
+			return nil, fmt.Errorf("dca 策略必須提供 dca 參數")
+		}
+		params = input.DCA
+	case strategyDomain.KindGrid:
+		if input.Grid == nil {
+			return nil, fmt.Errorf("grid 策略必須提供 grid 參數")
+		}
+		params = input.Grid
+	default:
+		return nil, fmt.Errorf("不支援的策略種類: %s", kind)
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := strategyDomain.ParseKindParams(kind, raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// nullableVersion 將未釘選的分析版本存為 NULL。
+func nullableVersion(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
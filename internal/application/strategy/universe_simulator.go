@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// executeUniverse 回測多交易對策略：每根 K 線先檢查持倉出場，再依分數由高到低進場，
+// 同時最多持有 MaxPositions 檔。動態清單以回測起日的成交額排名決定。
+// TotalReturn 假設資金平均分成 MaxPositions 份，每筆交易以其中一份複利計算。
+func (u *BacktestUseCase) executeUniverse(ctx context.Context, s *strategyDomain.ScoringStrategy, start, end time.Time) (*BacktestResult, error) {
+	ranker, _ := u.dataProv.(strategyDomain.VolumeRanker)
+	symbols, err := s.Universe.Resolve(ctx, ranker, start)
+	if err != nil {
+		return nil, fmt.Errorf("resolve universe: %w", err)
+	}
+
+	bars := make(map[time.Time]map[string]analysisDomain.DailyAnalysisResult)
+	lastBar := make(map[string]analysisDomain.DailyAnalysisResult)
+	for _, sym := range symbols {
+		history, err := u.loadHistory(ctx, s, sym, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("fetch history for %s failed: %w", sym, err)
+		}
+		for _, res := range history {
+			if bars[res.TradeDate] == nil {
+				bars[res.TradeDate] = make(map[string]analysisDomain.DailyAnalysisResult)
+			}
+			bars[res.TradeDate][sym] = res
+			if res.TradeDate.After(lastBar[sym].TradeDate) {
+				lastBar[sym] = res
+			}
+		}
+	}
+	dates := make([]time.Time, 0, len(bars))
+	for d := range bars {
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	limit := s.PositionLimit()
+	var (
+		events []BacktestEvent
+		trades []BacktestTrade
+		open   = make(map[string]*BacktestTrade)
+	)
+	totalReturn := 1.0
+	closeTrade := func(sym string, t *BacktestTrade) {
+		trades = append(trades, *t)
+		totalReturn *= 1.0 + t.PnLPct/float64(limit)
+		delete(open, sym)
+	}
+
+	for _, d := range dates {
+		day := bars[d]
+		for _, sym := range symbols {
+			pos, ok := open[sym]
+			res, has := day[sym]
+			if !ok || !has {
+				continue
+			}
+			exit, reason := s.ShouldExit(res, tradingDomain.Position{EntryPrice: pos.EntryPrice, EntryDate: start})
+			if !exit {
+				continue
+			}
+			exitWithFee := res.Close * 0.999
+			pos.Reason = reason
+			pos.ExitDate = res.TradeDate.Format("2006-01-02")
+			pos.ExitTime = res.TradeDate
+			pos.ExitPrice = res.Close
+			pos.PnL = exitWithFee - pos.EntryPrice
+			pos.PnLPct = exitWithFee/pos.EntryPrice - 1.0
+			closeTrade(sym, pos)
+		}
+
+		type candidate struct {
+			symbol string
+			score  float64
+			res    analysisDomain.DailyAnalysisResult
+		}
+		var cands []candidate
+		for _, sym := range symbols {
+			res, has := day[sym]
+			if !has {
+				continue
+			}
+			triggered, score, err := s.IsTriggered(res)
+			if err != nil {
+				continue
+			}
+			exitScore, _ := s.CalculateScoreForRules(s.ExitRules, res)
+			events = append(events, BacktestEvent{
+				Symbol:        sym,
+				TradeDate:     res.TradeDate.Format("2006-01-02"),
+				ClosePrice:    res.Close,
+				ChangePercent: res.ChangeRate,
+				TotalScore:    score,
+				EntryScore:    score,
+				ExitScore:     exitScore,
+				IsTriggered:   triggered,
+				Return5d:      res.Return5,
+			})
+			if _, held := open[sym]; triggered && !held {
+				cands = append(cands, candidate{symbol: sym, score: score, res: res})
+			}
+		}
+		sort.SliceStable(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+		for _, c := range cands {
+			if len(open) >= limit {
+				break
+			}
+			open[c.symbol] = &BacktestTrade{
+				Symbol:     c.symbol,
+				EntryDate:  c.res.TradeDate.Format("2006-01-02"),
+				EntryTime:  c.res.TradeDate,
+				EntryPrice: c.res.Close,
+			}
+		}
+	}
+
+	for _, sym := range symbols {
+		pos, ok := open[sym]
+		if !ok {
+			continue
+		}
+		last := lastBar[sym]
+		pos.ExitDate = last.TradeDate.Format("2006-01-02")
+		pos.ExitTime = last.TradeDate
+		pos.Open = true
+		pos.ExitPrice = last.Close
+		pos.PnL = pos.ExitPrice - pos.EntryPrice
+		pos.PnLPct = pos.ExitPrice/pos.EntryPrice - 1.0
+		pos.Reason = "回測結束前尚未出場 (Simulation End)"
+		closeTrade(sym, pos)
+	}
+
+	summary := kindSummary(trades)
+	summary.TotalReturn = (totalReturn - 1.0) * 100
+	return &BacktestResult{
+		Symbol:      strings.Join(symbols, ","),
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		TotalEvents: len(events),
+		Events:      events,
+		Stats:       map[string]BacktestStats{},
+		Trades:      trades,
+		Summary:     summary,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type symbolHistoryProvider map[string][]analysis.DailyAnalysisResult
+
+func (p symbolHistoryProvider) FindHistory(_ context.Context, symbol string, _ string, _, _ *time.Time, _ int, _ bool) ([]analysis.DailyAnalysisResult, error) {
+	return p[symbol], nil
+}
+
+func scoredBars(scores ...float64) []analysis.DailyAnalysisResult {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]analysis.DailyAnalysisResult, len(scores))
+	for i, sc := range scores {
+		out[i] = analysis.DailyAnalysisResult{TradeDate: start.AddDate(0, 0, i), Close: 100, Score: sc}
+	}
+	return out
+}
+
+func TestBacktestUseCase_UniverseRanksAndCapsPositions(t *testing.T) {
+	data := symbolHistoryProvider{
+		"AAAUSDT": scoredBars(70, 70, 10, 10),
+		"BBBUSDT": scoredBars(90, 90, 90, 90),
+		"CCCUSDT": scoredBars(80, 80, 80, 80),
+	}
+	tp := 100.0
+	s := &strategy.ScoringStrategy{
+		Threshold:    60,
+		Universe:     &strategy.Universe{Symbols: []string{"AAAUSDT", "BBBUSDT", "CCCUSDT"}},
+		MaxPositions: 2,
+		Risk:         tradingDomain.RiskSettings{TakeProfitPct: &tp},
+		EntryRules:   []strategy.StrategyRule{{Condition: strategy.Condition{Type: "BASE_SCORE"}, Weight: 1.0}},
+	}
+
+	res, err := NewBacktestUseCase(nil, data).ExecuteWithStrategy(context.Background(), s, "", time.Time{}, time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Symbol != "AAAUSDT,BBBUSDT,CCCUSDT" || res.TotalEvents != 12 {
+		t.Fatalf("unexpected result header %s / %d events", res.Symbol, res.TotalEvents)
+	}
+	held := map[string]bool{}
+	for _, tr := range res.Trades {
+		held[tr.Symbol] = true
+		if !tr.Open {
+			t.Fatalf("flat prices must not trigger exits, got %+v", tr)
+		}
+	}
+	if len(res.Trades) != 2 || !held["BBBUSDT"] || !held["CCCUSDT"] {
+		t.Fatalf("expected the two best-scored symbols only, got %+v", res.Trades)
+	}
+}
@@ -0,0 +1,286 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ErrTradingHalted 代表 kill switch 或策略熔斷中，不接受新進場單。
+var ErrTradingHalted = errors.New("trading halted")
+
+// GlobalHaltScope 為 kill switch 的狀態鍵。
+const GlobalHaltScope = "global"
+
+// haltRefreshInterval 為 Allow 重新讀取 store 的間隔，讓其他實例寫入的 kill switch 與熔斷狀態生效。
+const haltRefreshInterval = 5 * time.Second
+
+// HaltState 為 kill switch 或單一策略熔斷器的持久化狀態。
+type HaltState struct {
+	Scope             string     `json:"scope"`
+	StrategyID        string     `json:"strategy_id,omitempty"`
+	Env               string     `json:"env,omitempty"`
+	Halted            bool       `json:"halted"`
+	Reason            string     `json:"reason,omitempty"`
+	ConsecutiveLosses int        `json:"consecutive_losses"`
+	ConsecutiveErrors int        `json:"consecutive_errors"`
+	Equity            float64    `json:"equity"`
+	PeakEquity        float64    `json:"peak_equity"`
+	HaltedAt          *time.Time `json:"halted_at,omitempty"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// HaltStore 保存 kill switch 與熔斷狀態。
+type HaltStore interface {
+	LoadHaltStates(ctx context.Context) ([]HaltState, error)
+	SaveHaltState(ctx context.Context, st HaltState) error
+}
+
+// BreakerConfig 為策略熔斷門檻，0 代表停用該條件。
+type BreakerConfig struct {
+	MaxConsecutiveLosses int     // 連續虧損筆數
+	MaxDrawdownPct       float64 // 自權益高點回落比例（0.2 = 20%），權益以策略下單金額為起點
+	MaxConsecutiveErrors int     // 連續交易所錯誤次數
+}
+
+// BreakerStatus 為 /api/health 顯示的摘要。
+type BreakerStatus struct {
+	KillSwitch HaltState   `json:"kill_switch"`
+	Tripped    []HaltState `json:"tripped_strategies"`
+}
+
+func strategyScope(strategyID string, env tradingDomain.Environment) string {
+	return "strategy:" + strategyID + ":" + string(env)
+}
+
+// CircuitBreakers 管理全域 kill switch 與各策略熔斷器。
+// 兩者都只阻擋新進場單；出場（停損、平倉）仍可執行。
+type CircuitBreakers struct {
+	mu     sync.Mutex
+	store  HaltStore
+	cfg    BreakerConfig
+	states map[string]*HaltState
+	now    func() time.Time
+	// loadedAt 為上次成功從 store 載入的時間
+	loadedAt time.Time
+}
+
+// NewCircuitBreakers 建立熔斷管理器；store 為 nil 時狀態僅保存在記憶體。
+func NewCircuitBreakers(store HaltStore, cfg BreakerConfig) *CircuitBreakers {
+	return &CircuitBreakers{
+		store:  store,
+		cfg:    cfg,
+		states: make(map[string]*HaltState),
+		now:    time.Now,
+	}
+}
+
+// Load 從 store 載入狀態（啟動、取得領導權與定期重新整理時呼叫）；
+// 記憶體中較新的狀態（尚未被其他實例覆寫）保留不變。
+func (b *CircuitBreakers) Load(ctx context.Context) error {
+	if b.store == nil {
+		return nil
+	}
+	states, err := b.store.LoadHaltStates(ctx)
+	if err != nil {
+		return fmt.Errorf("load halt states: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range states {
+		st := states[i]
+		if rest, ok := strings.CutPrefix(st.Scope, "strategy:"); ok {
+			if i := strings.LastIndex(rest, ":"); i >= 0 {
+				st.StrategyID, st.Env = rest[:i], rest[i+1:]
+			}
+		}
+		if cur, ok := b.states[st.Scope]; ok && cur.UpdatedAt.After(st.UpdatedAt) {
+			continue
+		}
+		b.states[st.Scope] = &st
+	}
+	b.loadedAt = b.now()
+	return nil
+}
+
+// refresh 在距上次載入超過 haltRefreshInterval 時重新讀取 store，失敗時沿用記憶體狀態。
+func (b *CircuitBreakers) refresh(ctx context.Context) {
+	if b.store == nil {
+		return
+	}
+	b.mu.Lock()
+	stale := b.now().Sub(b.loadedAt) >= haltRefreshInterval
+	b.mu.Unlock()
+	if !stale {
+		return
+	}
+	if err := b.Load(ctx); err != nil {
+		log.Printf("[Breaker] refresh failed, using cached state: %v", err)
+	}
+}
+
+func (b *CircuitBreakers) state(scope string) *HaltState {
+	st, ok := b.states[scope]
+	if !ok {
+		st = &HaltState{Scope: scope}
+		b.states[scope] = st
+	}
+	return st
+}
+
+func (b *CircuitBreakers) strategyState(strategyID string, env tradingDomain.Environment) *HaltState {
+	st := b.state(strategyScope(strategyID, env))
+	st.StrategyID, st.Env = strategyID, string(env)
+	return st
+}
+
+// save 持久化狀態（需持有 b.mu）。
+func (b *CircuitBreakers) save(ctx context.Context, st *HaltState) error {
+	st.UpdatedAt = b.now()
+	if b.store == nil {
+		return nil
+	}
+	if err := b.store.SaveHaltState(ctx, *st); err != nil {
+		log.Printf("[Breaker] persist %s failed: %v", st.Scope, err)
+		return err
+	}
+	return nil
+}
+
+func (b *CircuitBreakers) halt(st *HaltState, reason string) {
+	now := b.now()
+	st.Halted = true
+	st.Reason = reason
+	st.HaltedAt = &now
+}
+
+// Engage 啟動 kill switch。
+func (b *CircuitBreakers) Engage(ctx context.Context, reason string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.state(GlobalHaltScope)
+	b.halt(st, reason)
+	return b.save(ctx, st)
+}
+
+// Release 解除 kill switch。
+func (b *CircuitBreakers) Release(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.state(GlobalHaltScope)
+	st.Halted, st.Reason, st.HaltedAt = false, "", nil
+	return b.save(ctx, st)
+}
+
+// Reset 解除策略熔斷並清除連續虧損/錯誤計數，權益高點重設為目前權益。
+func (b *CircuitBreakers) Reset(ctx context.Context, strategyID string, env tradingDomain.Environment) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.strategyState(strategyID, env)
+	st.Halted, st.Reason, st.HaltedAt = false, "", nil
+	st.ConsecutiveLosses, st.ConsecutiveErrors = 0, 0
+	st.PeakEquity = st.Equity
+	return b.save(ctx, st)
+}
+
+// Allow 判斷是否可送出新進場單，不可時回傳包裝 ErrTradingHalted 的錯誤；
+// 狀態會定期從 store 重新讀取，其他實例啟動的 kill switch 也會生效。
+func (b *CircuitBreakers) Allow(ctx context.Context, strategyID string, env tradingDomain.Environment) error {
+	b.refresh(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if st, ok := b.states[GlobalHaltScope]; ok && st.Halted {
+		return fmt.Errorf("%w: kill switch engaged (%s)", ErrTradingHalted, st.Reason)
+	}
+	if strategyID == "" || strategyID == "manual" {
+		return nil
+	}
+	if st, ok := b.states[strategyScope(strategyID, env)]; ok && st.Halted {
+		return fmt.Errorf("%w: strategy circuit breaker tripped (%s)", ErrTradingHalted, st.Reason)
+	}
+	return nil
+}
+
+// RecordTrade 記錄平倉損益；capital 為權益起點（策略下單金額）。回傳是否因此觸發熔斷與原因。
+func (b *CircuitBreakers) RecordTrade(ctx context.Context, strategyID string, env tradingDomain.Environment, pnl, capital float64) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.strategyState(strategyID, env)
+	if st.PeakEquity == 0 && st.Equity == 0 {
+		st.Equity, st.PeakEquity = capital, capital
+	}
+	st.Equity += pnl
+	if st.Equity > st.PeakEquity {
+		st.PeakEquity = st.Equity
+	}
+	if pnl < 0 {
+		st.ConsecutiveLosses++
+	} else {
+		st.ConsecutiveLosses = 0
+	}
+
+	var reason string
+	switch {
+	case st.Halted:
+	case b.cfg.MaxConsecutiveLosses > 0 && st.ConsecutiveLosses >= b.cfg.MaxConsecutiveLosses:
+		reason = fmt.Sprintf("%d consecutive losses", st.ConsecutiveLosses)
+	case b.cfg.MaxDrawdownPct > 0 && st.PeakEquity > 0 && (st.PeakEquity-st.Equity)/st.PeakEquity >= b.cfg.MaxDrawdownPct:
+		reason = fmt.Sprintf("drawdown %.2f%% from peak %.2f", (st.PeakEquity-st.Equity)/st.PeakEquity*100, st.PeakEquity)
+	}
+	if reason != "" {
+		b.halt(st, reason)
+	}
+	_ = b.save(ctx, st)
+	return reason != "", reason
+}
+
+// RecordError 記錄交易所錯誤，連續達門檻時觸發熔斷。
+func (b *CircuitBreakers) RecordError(ctx context.Context, strategyID string, env tradingDomain.Environment, cause error) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.strategyState(strategyID, env)
+	st.ConsecutiveErrors++
+	var reason string
+	if !st.Halted && b.cfg.MaxConsecutiveErrors > 0 && st.ConsecutiveErrors >= b.cfg.MaxConsecutiveErrors {
+		reason = fmt.Sprintf("%d consecutive exchange errors, last: %v", st.ConsecutiveErrors, cause)
+		b.halt(st, reason)
+	}
+	_ = b.save(ctx, st)
+	return reason != "", reason
+}
+
+// RecordSuccess 在下單成功後清除連續錯誤計數。
+func (b *CircuitBreakers) RecordSuccess(ctx context.Context, strategyID string, env tradingDomain.Environment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st, ok := b.states[strategyScope(strategyID, env)]
+	if !ok || st.ConsecutiveErrors == 0 {
+		return
+	}
+	st.ConsecutiveErrors = 0
+	_ = b.save(ctx, st)
+}
+
+// Status 回傳 kill switch 與所有熔斷中策略。
+func (b *CircuitBreakers) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := BreakerStatus{KillSwitch: HaltState{Scope: GlobalHaltScope}, Tripped: []HaltState{}}
+	if st, ok := b.states[GlobalHaltScope]; ok {
+		out.KillSwitch = *st
+	}
+	for scope, st := range b.states {
+		if scope != GlobalHaltScope && st.Halted {
+			out.Tripped = append(out.Tripped, *st)
+		}
+	}
+	sort.Slice(out.Tripped, func(i, j int) bool { return out.Tripped[i].Scope < out.Tripped[j].Scope })
+	return out
+}
@@ -0,0 +1,164 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type memHaltStore struct{ states map[string]HaltState }
+
+func (m *memHaltStore) LoadHaltStates(context.Context) ([]HaltState, error) {
+	out := make([]HaltState, 0, len(m.states))
+	for _, st := range m.states {
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (m *memHaltStore) SaveHaltState(_ context.Context, st HaltState) error {
+	m.states[st.Scope] = st
+	return nil
+}
+
+func TestCircuitBreakers_TripsAndPersists(t *testing.T) {
+	ctx := context.Background()
+	env := tradingDomain.EnvPaper
+	store := &memHaltStore{states: map[string]HaltState{}}
+	b := NewCircuitBreakers(store, BreakerConfig{MaxConsecutiveLosses: 3, MaxDrawdownPct: 0.2, MaxConsecutiveErrors: 2})
+
+	// 連續虧損：盈利會重置計數
+	b.RecordTrade(ctx, "s1", env, -1, 1000)
+	b.RecordTrade(ctx, "s1", env, -1, 1000)
+	b.RecordTrade(ctx, "s1", env, 5, 1000)
+	b.RecordTrade(ctx, "s1", env, -1, 1000)
+	if err := b.Allow(ctx, "s1", env); err != nil {
+		t.Fatalf("profit should reset consecutive losses: %v", err)
+	}
+	b.RecordTrade(ctx, "s1", env, -1, 1000)
+	if tripped, _ := b.RecordTrade(ctx, "s1", env, -1, 1000); !tripped {
+		t.Fatal("expected trip after 3 consecutive losses")
+	}
+	if err := b.Allow(ctx, "s1", env); !errors.Is(err, ErrTradingHalted) {
+		t.Fatalf("expected halted, got %v", err)
+	}
+	if err := b.Allow(ctx, "s1", tradingDomain.EnvProd); err != nil {
+		t.Fatalf("breaker is per environment: %v", err)
+	}
+
+	// 回撤：權益 1000 → 1200 高點 → 950（-20.8%）
+	b.RecordTrade(ctx, "s2", env, 200, 1000)
+	if tripped, reason := b.RecordTrade(ctx, "s2", env, -250, 1000); !tripped {
+		t.Fatalf("expected drawdown trip, reason=%q", reason)
+	}
+
+	// 交易所錯誤：成功會重置
+	b.RecordError(ctx, "s3", env, errors.New("timeout"))
+	b.RecordSuccess(ctx, "s3", env)
+	b.RecordError(ctx, "s3", env, errors.New("timeout"))
+	if err := b.Allow(ctx, "s3", env); err != nil {
+		t.Fatalf("success should reset error count: %v", err)
+	}
+	if tripped, _ := b.RecordError(ctx, "s3", env, errors.New("timeout")); !tripped {
+		t.Fatal("expected trip after 2 consecutive errors")
+	}
+
+	if err := b.Engage(ctx, "maintenance"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Allow(ctx, "manual", env); !errors.Is(err, ErrTradingHalted) {
+		t.Fatalf("kill switch must block manual orders: %v", err)
+	}
+
+	// 重新載入後狀態一致
+	restored := NewCircuitBreakers(store, BreakerConfig{})
+	if err := restored.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	st := restored.Status()
+	if !st.KillSwitch.Halted || st.KillSwitch.Reason != "maintenance" || len(st.Tripped) != 3 {
+		t.Fatalf("unexpected restored status %+v", st)
+	}
+	if st.Tripped[0].StrategyID != "s1" || st.Tripped[0].Env != string(env) {
+		t.Fatalf("scope not parsed back: %+v", st.Tripped[0])
+	}
+
+	if err := restored.Reset(ctx, "s1", env); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Allow(ctx, "s1", env); err != nil {
+		t.Fatalf("expected reset breaker to allow orders: %v", err)
+	}
+}
+
+func TestService_KillSwitchBlocksEntries(t *testing.T) {
+	ctx := context.Background()
+	repo := &riskRepo{}
+	noty := &recordingNotifier{}
+	svc := NewService(repo, stubDataProvider{history: []analysisDomain.DailyAnalysisResult{{TradeDate: time.Now().Add(-time.Hour), Close: 50000, Score: 75}}}, &mockExchange{}, noty)
+
+	if _, err := svc.EngageKillSwitch(ctx, "incident", false, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.ExecuteScoringAutoTrade(ctx, "alpha", tradingDomain.EnvPaper, "u1")
+	if !errors.Is(err, ErrTradingHalted) {
+		t.Fatalf("expected halted, got %v", err)
+	}
+	if len(repo.upserted) != 0 {
+		t.Fatal("halted strategy must not open a position")
+	}
+	if !svc.HaltStatus().KillSwitch.Halted || len(noty.msgs) == 0 {
+		t.Fatalf("expected kill switch to be reported and notified")
+	}
+
+	if err := svc.ReleaseKillSwitch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ExecuteScoringAutoTrade(ctx, "alpha", tradingDomain.EnvPaper, "u1"); err != nil {
+		t.Fatalf("expected buy after release: %v", err)
+	}
+	if len(repo.upserted) != 1 {
+		t.Fatalf("expected one position, got %d", len(repo.upserted))
+	}
+}
+
+func TestCircuitBreakers_SeesHaltsFromOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	store := &memHaltStore{states: map[string]HaltState{}}
+	now := time.Unix(1700000000, 0)
+	leader := NewCircuitBreakers(store, BreakerConfig{})
+	leader.now = func() time.Time { return now }
+	if err := leader.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	replica := NewCircuitBreakers(store, BreakerConfig{})
+	replica.now = func() time.Time { return now.Add(time.Second) }
+
+	// 非 leader 實例透過 API 啟動 kill switch
+	if err := replica.Engage(ctx, "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.Allow(ctx, "s1", tradingDomain.EnvProd); err != nil {
+		t.Fatalf("cached state is used within the refresh interval: %v", err)
+	}
+	now = now.Add(haltRefreshInterval)
+	if err := leader.Allow(ctx, "s1", tradingDomain.EnvProd); !errors.Is(err, ErrTradingHalted) {
+		t.Fatalf("expected kill switch from another instance to block orders, got %v", err)
+	}
+
+	replica.now = func() time.Time { return now.Add(time.Second) }
+	if err := replica.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(haltRefreshInterval)
+	if err := leader.Allow(ctx, "s1", tradingDomain.EnvProd); err != nil {
+		t.Fatalf("expected release from another instance to be picked up: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ErrBadData 表示交易對最新的 K 線未通過資料品質檢查，策略不在該交易對上執行。
+var ErrBadData = errors.New("latest data failed quality checks")
+
+// DataQualityGuard 回傳交易對在指定週期的最新資料是否可信；未通過時回傳原因。
+type DataQualityGuard interface {
+	CheckDataQuality(ctx context.Context, symbol, timeframe string) error
+}
+
+// SetDataQualityGuard 設定資料品質檢查；未設定時不檢查。
+func (s *Service) SetDataQualityGuard(g DataQualityGuard) {
+	s.dq = g
+}
+
+// checkDataQuality 在交易對資料未通過檢查時記錄執行日誌並回傳 ErrBadData。
+// 網格策略由掛單成交驅動、不讀 K 線，因此不經過此檢查。
+func (s *Service) checkDataQuality(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, symbol string) error {
+	if s.dq == nil {
+		return nil
+	}
+	tf := strat.Timeframe
+	if tf == "" {
+		tf = "1d"
+	}
+	err := s.dq.CheckDataQuality(ctx, symbol, tf)
+	if err == nil {
+		return nil
+	}
+	log.Printf("[DATA-QUALITY] %s skip %s: %v", strat.Slug, symbol, err)
+	_ = s.repo.SaveLog(ctx, tradingDomain.LogEntry{
+		StrategyID: strat.ID,
+		Env:        env,
+		Date:       s.now(),
+		Phase:      "data_quality",
+		Message:    err.Error(),
+		Payload: map[string]interface{}{
+			"symbol":    symbol,
+			"timeframe": tf,
+		},
+	})
+	return fmt.Errorf("%w: %v", ErrBadData, err)
+}
@@ -0,0 +1,60 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// badDataGuard 讓列出的交易對未通過資料品質檢查。
+type badDataGuard map[string]bool
+
+func (g badDataGuard) CheckDataQuality(_ context.Context, symbol, timeframe string) error {
+	if g[symbol] {
+		return fmt.Errorf("%s %s failed quality checks", symbol, timeframe)
+	}
+	return nil
+}
+
+func TestService_DataQualityBlocksSingleSymbolStrategy(t *testing.T) {
+	repo := &universeRepo{strat: universeStrategy(nil, 0)}
+	data := symbolDataProvider{latest: map[string]analysisDomain.DailyAnalysisResult{
+		"BTCUSDT": {TradeDate: time.Now().Add(-time.Hour), Close: 50000, Score: 90},
+	}}
+	svc := NewService(repo, data, &mockExchange{}, nil)
+	svc.SetDataQualityGuard(badDataGuard{"BTCUSDT": true})
+
+	err := svc.ExecuteScoringAutoTrade(context.Background(), "uni", tradingDomain.EnvShadow, "u1")
+	if !errors.Is(err, ErrBadData) {
+		t.Fatalf("expected ErrBadData, got %v", err)
+	}
+	if len(repo.held()) != 0 {
+		t.Fatalf("expected no position on bad data, got %v", repo.held())
+	}
+}
+
+func TestService_DataQualitySkipsUniverseCandidates(t *testing.T) {
+	bar := time.Now().Add(-time.Hour)
+	data := symbolDataProvider{latest: map[string]analysisDomain.DailyAnalysisResult{
+		"BTCUSDT": {TradeDate: bar, Close: 50000, Score: 65},
+		"ETHUSDT": {TradeDate: bar, Close: 3000, Score: 90},
+		"SOLUSDT": {TradeDate: bar, Close: 150, Score: 80},
+	}}
+	repo := &universeRepo{strat: universeStrategy(&strategyDomain.Universe{Symbols: []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}}, 2)}
+	svc := NewService(repo, data, &mockExchange{}, nil)
+	svc.SetDataQualityGuard(badDataGuard{"ETHUSDT": true})
+
+	if err := svc.ExecuteScoringAutoTrade(context.Background(), "uni", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repo.held(), ","); got != "SOLUSDT,BTCUSDT" {
+		t.Fatalf("expected ETH to be skipped on bad data, got %s", got)
+	}
+}
@@ -0,0 +1,132 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// executeDCA 定期定額：達到停利時全數賣出並開始新一輪，否則每隔 Interval 依分數調整金額買入一次。
+func (s *Service) executeDCA(ctx context.Context, strat *strategyDomain.ScoringStrategy, pos *tradingDomain.Position, latest analysisDomain.DailyAnalysisResult, env tradingDomain.Environment) error {
+	cfg := strat.DCA
+	if cfg == nil {
+		return fmt.Errorf("dca strategy %s has no dca params", strat.Slug)
+	}
+
+	if pos != nil && cfg.TakeProfitPct > 0 && pos.EntryPrice > 0 {
+		tp := cfg.TakeProfitPct
+		if tp > 1 {
+			tp /= 100
+		}
+		if gain := (latest.Close - pos.EntryPrice) / pos.EntryPrice; gain >= tp {
+			return s.exitScoringPosition(ctx, strat, pos, env, fmt.Sprintf("DCA 止盈 (%.2f%%)", gain*100))
+		}
+	}
+
+	buys, last, err := s.dcaRound(ctx, strat.ID, env, pos)
+	if err != nil {
+		return err
+	}
+	if cfg.MaxOrders > 0 && buys >= cfg.MaxOrders {
+		return nil
+	}
+	// 保留一分鐘彈性，避免排程誤差讓剛好到期的一輪被略過
+	if !last.IsZero() && s.now().Sub(last) < strategyDomain.TimeframeDuration(cfg.Interval)-time.Minute {
+		return nil
+	}
+
+	score := latest.Score
+	if len(strat.EntryRules) > 0 {
+		if score, err = strat.CalculateScoreForRules(strat.EntryRules, latest); err != nil {
+			return err
+		}
+	}
+	amount := cfg.AmountFor(score)
+	if amount <= 0 {
+		return nil
+	}
+	return s.dcaBuy(ctx, strat, env, amount, score, latest.Close)
+}
+
+// dcaRound 回傳本輪（目前持倉期間）已買入次數與最後一次買入時間。
+func (s *Service) dcaRound(ctx context.Context, strategyID string, env tradingDomain.Environment, pos *tradingDomain.Position) (int, time.Time, error) {
+	if pos == nil {
+		return 0, time.Time{}, nil
+	}
+	start := pos.EntryDate
+	trades, err := s.repo.ListTrades(ctx, tradingDomain.TradeFilter{StrategyID: strategyID, Env: env, StartDate: &start})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("list dca trades: %w", err)
+	}
+	var count int
+	var last time.Time
+	for _, t := range trades {
+		if !isBuy(t.Side) || t.EntryDate.Before(start) {
+			continue
+		}
+		count++
+		if t.EntryDate.After(last) {
+			last = t.EntryDate
+		}
+	}
+	if last.IsZero() {
+		last = pos.EntryDate
+	}
+	return count, last, nil
+}
+
+func (s *Service) dcaBuy(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, amount, score, refPrice float64) error {
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+	release, err := s.checkRisk(ctx, OrderIntent{
+		StrategyID: strat.ID,
+		UserID:     strategyOwner(strat),
+		Env:        env,
+		Symbol:     strat.BaseSymbol,
+		Side:       "buy",
+		Notional:   amount,
+		RefPrice:   refPrice,
+		Risk:       strat.Risk,
+	}, ex)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	price, qty, err := s.venue(ex, env, strategyOwner(strat)).PlaceMarketOrderQuote(ctx, strat.BaseSymbol, "buy", amount)
+	if err != nil {
+		s.recordOrderError(ctx, strat, env, err)
+		return fmt.Errorf("place %s dca buy: %w", venueName(strat.Exchange, env), err)
+	}
+	s.halts.RecordSuccess(ctx, strat.ID, env)
+
+	now := s.now()
+	reason := fmt.Sprintf("DCA buy %.2f USDT (score %.2f)", amount, score)
+	_ = s.repo.SaveTrade(ctx, tradingDomain.TradeRecord{
+		StrategyID:      strat.ID,
+		Symbol:          strat.BaseSymbol,
+		StrategyVersion: 1,
+		Env:             env,
+		Side:            "buy",
+		EntryDate:       now,
+		EntryPrice:      price,
+		Reason:          reason,
+		CreatedAt:       now,
+	})
+	s.addToPosition(ctx, strat.ID, strategyOwner(strat), strat.BaseSymbol, env, now, price, qty)
+
+	s.notify(fmt.Sprintf("🪙 %s [DCA] BUY %s\nPrice: %.2f\nAmount: %.2f USDT\nReason: %s",
+		s.envTag(env), strat.BaseSymbol, price, amount, reason))
+	return nil
+}
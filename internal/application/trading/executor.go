@@ -0,0 +1,263 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ErrRunInProgress 代表同一策略與環境已有執行中的評估，本次略過。
+var ErrRunInProgress = errors.New("strategy run already in progress")
+
+// 單次執行結果。
+const (
+	RunStatusOK      = "ok"
+	RunStatusError   = "error"
+	RunStatusTimeout = "timeout"
+	RunStatusPanic   = "panic"
+	RunStatusSkipped = "skipped"
+)
+
+const recentRunLimit = 100
+
+// ExecutorConfig 控制策略並行度與單次執行期限，零值使用預設。
+type ExecutorConfig struct {
+	Workers    int           // 同時執行的策略/環境數，預設 4
+	RunTimeout time.Duration // 單次評估期限，預設 2 分鐘；已送出的訂單不受影響
+}
+
+// RunMetric 為單次策略評估的紀錄。
+type RunMetric struct {
+	StrategyID string        `json:"strategy_id"`
+	Slug       string        `json:"slug"`
+	Env        string        `json:"env"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration_ns"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// StrategyRunStats 為單一策略/環境的累計指標。
+type StrategyRunStats struct {
+	Runs         int64         `json:"runs"`
+	Failures     int64         `json:"failures"`
+	Timeouts     int64         `json:"timeouts"`
+	Panics       int64         `json:"panics"`
+	Skipped      int64         `json:"skipped"`
+	LastStatus   string        `json:"last_status"`
+	LastRunAt    time.Time     `json:"last_run_at"`
+	LastDuration time.Duration `json:"last_duration_ns"`
+	MaxDuration  time.Duration `json:"max_duration_ns"`
+	TotalTime    time.Duration `json:"total_duration_ns"`
+}
+
+// ExecutorStats 為執行器整體狀態，供 /admin/jobs/status 顯示。
+type ExecutorStats struct {
+	Workers    int                         `json:"workers"`
+	RunTimeout time.Duration               `json:"run_timeout_ns"`
+	Running    int                         `json:"running"`
+	Strategies map[string]StrategyRunStats `json:"strategies"`
+	Recent     []RunMetric                 `json:"recent"`
+}
+
+// StrategyExecutor 以有限的 worker 數並行評估策略：每次執行有期限、panic 不會擴散，
+// 同一策略同一環境不會重疊執行，並記錄每次執行的指標。
+type StrategyExecutor struct {
+	run     func(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) error
+	cfg     ExecutorConfig
+	sem     chan struct{}
+	mu      sync.Mutex
+	running map[string]bool
+	stats   map[string]*StrategyRunStats
+	recent  []RunMetric
+	now     func() time.Time
+}
+
+// NewStrategyExecutor 建立執行器。
+func NewStrategyExecutor(svc *Service, cfg ExecutorConfig) *StrategyExecutor {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.RunTimeout <= 0 {
+		cfg.RunTimeout = 2 * time.Minute
+	}
+	return &StrategyExecutor{
+		run: func(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) error {
+			return svc.ExecuteScoringAutoTrade(ctx, strat.Slug, env, strategyOwner(strat))
+		},
+		cfg:     cfg,
+		sem:     make(chan struct{}, cfg.Workers),
+		running: make(map[string]bool),
+		stats:   make(map[string]*StrategyRunStats),
+		now:     time.Now,
+	}
+}
+
+func runKey(strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) string {
+	id := strat.ID
+	if id == "" {
+		id = strat.Slug
+	}
+	return id + "|" + string(env)
+}
+
+// RunAll 並行評估所有策略的所有環境並等待完成，回傳合併的錯誤（略過的執行不算錯誤）。
+func (x *StrategyExecutor) RunAll(ctx context.Context, strats []*strategyDomain.ScoringStrategy) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, strat := range strats {
+		for _, env := range StrategyEnvs(strat.Env) {
+			wg.Add(1)
+			go func(strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) {
+				defer wg.Done()
+				if err := x.Run(ctx, strat, env); err != nil && !errors.Is(err, ErrRunInProgress) {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s (%s): %w", strat.Slug, env, err))
+					mu.Unlock()
+				}
+			}(strat, env)
+		}
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Exclusive 在同一策略與環境沒有執行中的評估時執行 fn，期間該策略的評估會被略過；
+// 已有評估執行中時回傳 ErrRunInProgress，供停損停利等評估以外的下單共用互斥。
+func (x *StrategyExecutor) Exclusive(strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, fn func() error) error {
+	release, ok := x.claim(strat, env)
+	if !ok {
+		return ErrRunInProgress
+	}
+	defer release()
+	return fn()
+}
+
+// claim 佔用策略與環境的執行權，已被佔用時回傳 false。
+func (x *StrategyExecutor) claim(strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) (func(), bool) {
+	key := runKey(strat, env)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.running[key] {
+		return nil, false
+	}
+	x.running[key] = true
+	return func() {
+		x.mu.Lock()
+		delete(x.running, key)
+		x.mu.Unlock()
+	}, true
+}
+
+// Run 評估單一策略的單一環境。
+func (x *StrategyExecutor) Run(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) (err error) {
+	// 先取得 worker 再佔用執行權，排隊中的評估不算執行中，也不擋停損停利
+	select {
+	case x.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-x.sem }()
+
+	release, ok := x.claim(strat, env)
+	if !ok {
+		x.record(strat, env, x.now(), 0, RunStatusSkipped, ErrRunInProgress)
+		return ErrRunInProgress
+	}
+	defer release()
+
+	runCtx, cancel := context.WithTimeout(ctx, x.cfg.RunTimeout)
+	defer cancel()
+	start := x.now()
+	status := RunStatusOK
+	defer func() {
+		if r := recover(); r != nil {
+			status = RunStatusPanic
+			err = fmt.Errorf("panic: %v", r)
+			log.Printf("[Executor] strategy %s (%s) panicked: %v\n%s", strat.Slug, env, r, debug.Stack())
+		}
+		x.record(strat, env, start, x.now().Sub(start), status, err)
+	}()
+
+	err = x.run(runCtx, strat, env)
+	switch {
+	case err == nil:
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		status = RunStatusTimeout
+		err = fmt.Errorf("run exceeded %v: %w", x.cfg.RunTimeout, err)
+	default:
+		status = RunStatusError
+	}
+	return err
+}
+
+func (x *StrategyExecutor) record(strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, start time.Time, d time.Duration, status string, err error) {
+	m := RunMetric{StrategyID: strat.ID, Slug: strat.Slug, Env: string(env), StartedAt: start, Duration: d, Status: status}
+	if err != nil {
+		m.Error = err.Error()
+	}
+	if status != RunStatusOK {
+		log.Printf("[Executor] strategy %s (%s) %s in %v: %v", strat.Slug, env, status, d, err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := runKey(strat, env)
+	st, ok := x.stats[key]
+	if !ok {
+		st = &StrategyRunStats{}
+		x.stats[key] = st
+	}
+	switch status {
+	case RunStatusSkipped:
+		st.Skipped++
+	case RunStatusTimeout:
+		st.Timeouts++
+		st.Failures++
+	case RunStatusPanic:
+		st.Panics++
+		st.Failures++
+	case RunStatusError:
+		st.Failures++
+	}
+	if status != RunStatusSkipped {
+		st.Runs++
+		st.LastStatus, st.LastRunAt, st.LastDuration = status, start, d
+		st.TotalTime += d
+		if d > st.MaxDuration {
+			st.MaxDuration = d
+		}
+	}
+	x.recent = append(x.recent, m)
+	if len(x.recent) > recentRunLimit {
+		x.recent = x.recent[len(x.recent)-recentRunLimit:]
+	}
+}
+
+// Stats 回傳執行器指標快照。
+func (x *StrategyExecutor) Stats() ExecutorStats {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	out := ExecutorStats{
+		Workers:    x.cfg.Workers,
+		RunTimeout: x.cfg.RunTimeout,
+		Running:    len(x.running),
+		Strategies: make(map[string]StrategyRunStats, len(x.stats)),
+		Recent:     make([]RunMetric, len(x.recent)),
+	}
+	for k, v := range x.stats {
+		out.Strategies[k] = *v
+	}
+	copy(out.Recent, x.recent)
+	return out
+}
@@ -0,0 +1,137 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+func TestStrategyExecutor_BoundsParallelism(t *testing.T) {
+	x := NewStrategyExecutor(nil, ExecutorConfig{Workers: 2, RunTimeout: time.Second})
+	var cur, peak int32
+	x.run = func(ctx context.Context, _ *strategyDomain.ScoringStrategy, _ tradingDomain.Environment) error {
+		n := atomic.AddInt32(&cur, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&cur, -1)
+		return nil
+	}
+	var strats []*strategyDomain.ScoringStrategy
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		strats = append(strats, &strategyDomain.ScoringStrategy{ID: id, Slug: id, Env: "paper"})
+	}
+	if err := x.RunAll(context.Background(), strats); err != nil {
+		t.Fatal(err)
+	}
+	if peak != 2 {
+		t.Fatalf("expected 2 concurrent runs, got %d", peak)
+	}
+	if st := x.Stats(); len(st.Recent) != 5 || st.Strategies["a|paper"].Runs != 1 || st.Running != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestStrategyExecutor_TimeoutAndPanic(t *testing.T) {
+	x := NewStrategyExecutor(nil, ExecutorConfig{Workers: 2, RunTimeout: 20 * time.Millisecond})
+	x.run = func(ctx context.Context, strat *strategyDomain.ScoringStrategy, _ tradingDomain.Environment) error {
+		if strat.Slug == "boom" {
+			panic("bad rule")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	slow := &strategyDomain.ScoringStrategy{ID: "slow", Slug: "slow", Env: "paper"}
+	boom := &strategyDomain.ScoringStrategy{ID: "boom", Slug: "boom", Env: "paper"}
+	if err := x.RunAll(context.Background(), []*strategyDomain.ScoringStrategy{slow, boom}); err == nil {
+		t.Fatal("expected joined errors")
+	}
+	st := x.Stats()
+	if s := st.Strategies["slow|paper"]; s.Timeouts != 1 || s.LastStatus != RunStatusTimeout {
+		t.Fatalf("expected timeout, got %+v", s)
+	}
+	if s := st.Strategies["boom|paper"]; s.Panics != 1 || s.LastStatus != RunStatusPanic {
+		t.Fatalf("expected recovered panic, got %+v", s)
+	}
+}
+
+func TestStrategyExecutor_SkipsOverlappingRuns(t *testing.T) {
+	x := NewStrategyExecutor(nil, ExecutorConfig{Workers: 4, RunTimeout: time.Second})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	x.run = func(ctx context.Context, _ *strategyDomain.ScoringStrategy, _ tradingDomain.Environment) error {
+		close(started)
+		<-release
+		return errors.New("failed")
+	}
+	strat := &strategyDomain.ScoringStrategy{ID: "s", Slug: "s", Env: "paper"}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = x.Run(context.Background(), strat, tradingDomain.EnvPaper)
+	}()
+	<-started
+	if err := x.Run(context.Background(), strat, tradingDomain.EnvPaper); !errors.Is(err, ErrRunInProgress) {
+		t.Fatalf("expected ErrRunInProgress, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	s := x.Stats().Strategies["s|paper"]
+	if s.Runs != 1 || s.Skipped != 1 || s.Failures != 1 || s.LastStatus != RunStatusError {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestStrategyExecutor_QueuedRunDoesNotHoldStrategy(t *testing.T) {
+	x := NewStrategyExecutor(nil, ExecutorConfig{Workers: 1, RunTimeout: time.Second})
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	x.run = func(ctx context.Context, _ *strategyDomain.ScoringStrategy, _ tradingDomain.Environment) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	a := &strategyDomain.ScoringStrategy{ID: "a", Slug: "a", Env: "paper"}
+	b := &strategyDomain.ScoringStrategy{ID: "b", Slug: "b", Env: "paper"}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = x.Run(context.Background(), a, tradingDomain.EnvPaper)
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = x.Run(context.Background(), b, tradingDomain.EnvPaper)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// b 仍在等待 worker：不算執行中，停損停利可取得互斥
+	if n := x.Stats().Running; n != 1 {
+		t.Fatalf("expected only the active run counted, got %d", n)
+	}
+	called := false
+	if err := x.Exclusive(b, tradingDomain.EnvPaper, func() error { called = true; return nil }); err != nil || !called {
+		t.Fatalf("queued run must not block exclusive work, err=%v called=%v", err, called)
+	}
+	close(release)
+	wg.Wait()
+	if st := x.Stats(); st.Strategies["b|paper"].Runs != 1 || st.Running != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
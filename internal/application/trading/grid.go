@@ -0,0 +1,339 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// LimitOrderExchange 為支援掛限價單的交易所（網格策略需要）。
+type LimitOrderExchange interface {
+	PlaceLimitOrder(ctx context.Context, symbol, side string, qty, price float64) (OrderResponse, error)
+	CancelOrder(ctx context.Context, symbol, orderID string) error
+}
+
+// GridOrder 為網格策略在某一價位掛出的限價單。
+type GridOrder struct {
+	ID         string
+	StrategyID string
+	Env        tradingDomain.Environment
+	Level      int
+	Side       string
+	Price      float64
+	Qty        float64
+	OrderID    string
+	Status     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// GridOrderPending 為已決定但尚未成功送出的網格單（例如成交後的反向單），下次執行時重試。
+const GridOrderPending = "PENDING"
+
+// LimitOrderRestorer 由掛單簿只存在記憶體的模擬交易所實作：重啟後依保存的網格單以原訂單編號還原掛單。
+// 資金在下單時已扣住並寫入帳本，還原時不再重複扣住。
+type LimitOrderRestorer interface {
+	RestoreLimitOrder(ctx context.Context, o OrderResponse) error
+}
+
+// GridStore 保存網格掛單，重啟後可接續追蹤；LoadOpenGridOrders 回傳掛單中與待送出的網格單。
+type GridStore interface {
+	LoadOpenGridOrders(ctx context.Context, strategyID string, env tradingDomain.Environment) ([]GridOrder, error)
+	SaveGridOrder(ctx context.Context, o GridOrder) (string, error)
+}
+
+// executeGrid 追蹤網格掛單：買單成交後在上一格掛賣、賣單成交後在下一格補買；
+// 尚無掛單時以目前價格為界，在較低的每一格掛買單。
+func (s *Service) executeGrid(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) error {
+	if strat.Grid == nil {
+		return fmt.Errorf("grid strategy %s has no grid params", strat.Slug)
+	}
+	if s.grids == nil {
+		return fmt.Errorf("grid strategies require grid order storage")
+	}
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+	venue := s.venue(ex, env, strategyOwner(strat))
+	lim, ok := venue.(LimitOrderExchange)
+	if !ok {
+		return fmt.Errorf("%s does not support limit orders", venueName(strat.Exchange, env))
+	}
+
+	orders, err := s.grids.LoadOpenGridOrders(ctx, strat.ID, env)
+	if err != nil {
+		return fmt.Errorf("load grid orders: %w", err)
+	}
+	levels := strat.Grid.Levels()
+	if len(orders) == 0 {
+		return s.openGrid(ctx, strat, env, venue, lim, levels)
+	}
+
+	var (
+		errs    []error
+		pending []GridOrder
+	)
+	for _, o := range orders {
+		if o.Status == GridOrderPending {
+			pending = append(pending, o)
+		}
+	}
+	for _, o := range orders {
+		if o.Status == GridOrderPending {
+			continue
+		}
+		resp, err := venue.GetOrder(ctx, strat.BaseSymbol, o.OrderID)
+		if err != nil && env.IsSimulated() {
+			// paper/shadow 掛單簿只存在記憶體，重啟後以保存的網格單還原原掛單，不重新扣住資金
+			if r, ok := venue.(LimitOrderRestorer); ok {
+				restored := OrderResponse{OrderID: o.OrderID, Symbol: strat.BaseSymbol, Side: strings.ToUpper(o.Side), Price: o.Price, Qty: o.Qty, Status: OrderStatusNew}
+				if err = r.RestoreLimitOrder(ctx, restored); err == nil {
+					resp, err = venue.GetOrder(ctx, strat.BaseSymbol, o.OrderID)
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("grid order %s: %w", o.OrderID, err))
+			continue
+		}
+		switch resp.Status {
+		case OrderStatusFilled:
+			counter, err := s.onGridFill(ctx, strat, env, levels, o, resp, pending)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if counter != nil {
+				pending = append(pending, *counter)
+			}
+		case OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired:
+			o.Status = resp.Status
+			o.UpdatedAt = s.now()
+			_, _ = s.grids.SaveGridOrder(ctx, o)
+			log.Printf("[GRID] %s %s level %d order %s ended as %s", strat.Slug, env, o.Level, o.OrderID, resp.Status)
+		}
+	}
+	// 反向單先存為待送出再下單，送出失敗時留待下次執行重試
+	for _, o := range pending {
+		if err := s.placeGridOrder(ctx, strat, env, lim, o); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		s.recordOrderError(ctx, strat, env, errors.Join(errs...))
+	}
+	return errors.Join(errs...)
+}
+
+// openGrid 在目前價格以下的每一格掛買單。
+func (s *Service) openGrid(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, venue Exchange, lim LimitOrderExchange, levels []float64) error {
+	price, err := venue.GetPrice(ctx, strat.BaseSymbol)
+	if err != nil {
+		return fmt.Errorf("grid get price: %w", err)
+	}
+	var errs []error
+	placed := 0
+	for i := 0; i < len(levels)-1; i++ {
+		if levels[i] >= price {
+			break
+		}
+		o := GridOrder{StrategyID: strat.ID, Env: env, Level: i, Side: "buy", Price: levels[i], Qty: strat.Grid.OrderSize / levels[i]}
+		if err := s.placeGridOrder(ctx, strat, env, lim, o); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		placed++
+	}
+	if placed > 0 {
+		s.notify(fmt.Sprintf("🕸 %s [GRID] %s 已掛出 %d 張買單\n區間：%.2f - %.2f（%d 格）\n現價：%.2f",
+			s.envTag(env), strat.BaseSymbol, placed, strat.Grid.Lower, strat.Grid.Upper, strat.Grid.Grids, price))
+	}
+	return errors.Join(errs...)
+}
+
+// onGridFill 先保存待送出的反向單，再記錄成交與更新持倉；回傳新建的反向單，
+// 同一價位已有待送出的反向單（上次執行在兩次寫入之間中斷）時不重複建立並回傳 nil。
+func (s *Service) onGridFill(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, levels []float64, o GridOrder, resp OrderResponse, pending []GridOrder) (*GridOrder, error) {
+	price, qty := resp.Price, resp.Qty
+	if price <= 0 {
+		price = o.Price
+	}
+	if qty <= 0 {
+		qty = o.Qty
+	}
+	now := s.now()
+	counter := GridOrder{StrategyID: strat.ID, Env: env, Level: o.Level + 1, Side: "sell", Price: levels[o.Level+1], Qty: qty}
+	if !isBuy(o.Side) {
+		entry := levels[o.Level-1]
+		counter = GridOrder{StrategyID: strat.ID, Env: env, Level: o.Level - 1, Side: "buy", Price: entry, Qty: strat.Grid.OrderSize / entry}
+	}
+	var created *GridOrder
+	if !slices.ContainsFunc(pending, func(p GridOrder) bool { return p.Level == counter.Level && p.Side == counter.Side }) {
+		counter.Status = GridOrderPending
+		counter.CreatedAt, counter.UpdatedAt = now, now
+		id, err := s.grids.SaveGridOrder(ctx, counter)
+		if err != nil {
+			return nil, fmt.Errorf("save grid counter order: %w", err)
+		}
+		counter.ID = id
+		created = &counter
+	}
+
+	o.Status = OrderStatusFilled
+	o.UpdatedAt = now
+	if _, err := s.grids.SaveGridOrder(ctx, o); err != nil {
+		return created, fmt.Errorf("save grid order: %w", err)
+	}
+	s.halts.RecordSuccess(ctx, strat.ID, env)
+
+	if isBuy(o.Side) {
+		_ = s.repo.SaveTrade(ctx, tradingDomain.TradeRecord{
+			StrategyID:      strat.ID,
+			Symbol:          strat.BaseSymbol,
+			StrategyVersion: 1,
+			Env:             env,
+			Side:            "buy",
+			EntryDate:       now,
+			EntryPrice:      price,
+			Reason:          fmt.Sprintf("Grid buy L%d", o.Level),
+			CreatedAt:       now,
+		})
+		s.addToPosition(ctx, strat.ID, strategyOwner(strat), strat.BaseSymbol, env, now, price, qty)
+		return created, nil
+	}
+
+	entry := levels[o.Level-1]
+	pnl := (price - entry) * qty
+	pnlPct := pnl / (entry * qty)
+	exitDate := now
+	_ = s.repo.SaveTrade(ctx, tradingDomain.TradeRecord{
+		StrategyID:      strat.ID,
+		Symbol:          strat.BaseSymbol,
+		StrategyVersion: 1,
+		Env:             env,
+		Side:            "sell",
+		EntryDate:       exitDate,
+		EntryPrice:      entry,
+		ExitDate:        &exitDate,
+		ExitPrice:       &price,
+		PNL:             &pnl,
+		PNLPct:          &pnlPct,
+		Reason:          fmt.Sprintf("Grid sell L%d", o.Level),
+		CreatedAt:       now,
+	})
+	s.reducePosition(ctx, strat.ID, env, price, qty)
+	s.notify(fmt.Sprintf("🕸 %s [GRID] SELL %s L%d\nPrice: %.2f (Buy: %.2f)\nPNL: %.2f",
+		s.envTag(env), strat.BaseSymbol, o.Level, price, entry, pnl))
+	s.recordTradeResult(ctx, strat, env, pnl)
+	return created, nil
+}
+
+// placeGridOrder 送出網格單並保存為掛單中；o 帶 ID 時（待送出的網格單）更新原紀錄。
+func (s *Service) placeGridOrder(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, lim LimitOrderExchange, o GridOrder) error {
+	if isBuy(o.Side) {
+		// 掛單價與現價本來就有距離，不套用價格偏離檢查
+		release, err := s.checkRisk(ctx, OrderIntent{StrategyID: strat.ID, UserID: strategyOwner(strat), Env: env, Symbol: strat.BaseSymbol, Side: o.Side, Notional: o.Qty * o.Price, Risk: strat.Risk}, nil)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	resp, err := lim.PlaceLimitOrder(ctx, strat.BaseSymbol, o.Side, o.Qty, o.Price)
+	if err != nil {
+		return fmt.Errorf("place grid %s L%d at %.8f: %w", o.Side, o.Level, o.Price, err)
+	}
+	o.OrderID = resp.OrderID
+	o.Status = OrderStatusNew
+	o.UpdatedAt = s.now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = o.UpdatedAt
+	}
+	_, err = s.grids.SaveGridOrder(ctx, o)
+	return err
+}
+
+// stopGrid 取消策略在指定環境仍掛著的網格單（停用策略時呼叫）。
+func (s *Service) stopGrid(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment) error {
+	if s.grids == nil {
+		return nil
+	}
+	orders, err := s.grids.LoadOpenGridOrders(ctx, strat.ID, env)
+	if err != nil || len(orders) == 0 {
+		return err
+	}
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+	lim, ok := s.venue(ex, env, strategyOwner(strat)).(LimitOrderExchange)
+	if !ok {
+		return fmt.Errorf("%s does not support limit orders", venueName(strat.Exchange, env))
+	}
+	var errs []error
+	for _, o := range orders {
+		// 待送出的網格單沒有交易所訂單，只需作廢紀錄
+		if o.Status != GridOrderPending {
+			if err := lim.CancelOrder(ctx, strat.BaseSymbol, o.OrderID); err != nil {
+				errs = append(errs, fmt.Errorf("cancel grid order %s: %w", o.OrderID, err))
+				continue
+			}
+		}
+		o.Status = OrderStatusCanceled
+		o.UpdatedAt = s.now()
+		_, _ = s.grids.SaveGridOrder(ctx, o)
+	}
+	return errors.Join(errs...)
+}
+
+// addToPosition 將新買入的數量併入策略持倉並更新平均成本；owner 為新持倉的擁有者。
+func (s *Service) addToPosition(ctx context.Context, strategyID, owner, symbol string, env tradingDomain.Environment, at time.Time, price, qty float64) {
+	existing, _ := s.repo.GetOpenPosition(ctx, strategyID, env)
+	if existing != nil {
+		total := existing.Size + qty
+		existing.EntryPrice = (existing.EntryPrice*existing.Size + price*qty) / total
+		existing.Size = total
+		existing.UpdatedAt = at
+		_ = s.repo.UpsertPosition(ctx, *existing)
+		return
+	}
+	_ = s.repo.UpsertPosition(ctx, tradingDomain.Position{
+		StrategyID: strategyID,
+		UserID:     owner,
+		Symbol:     symbol,
+		Env:        env,
+		EntryDate:  at,
+		EntryPrice: price,
+		Size:       qty,
+		Status:     "open",
+		UpdatedAt:  at,
+	})
+}
+
+// reducePosition 自策略持倉扣除賣出數量，全部賣完時平倉。
+func (s *Service) reducePosition(ctx context.Context, strategyID string, env tradingDomain.Environment, price, qty float64) {
+	existing, _ := s.repo.GetOpenPosition(ctx, strategyID, env)
+	if existing == nil {
+		return
+	}
+	if existing.Size-qty <= 1e-12 {
+		_ = s.repo.ClosePosition(ctx, existing.ID, s.now(), price)
+		return
+	}
+	existing.Size -= qty
+	existing.UpdatedAt = s.now()
+	_ = s.repo.UpsertPosition(ctx, *existing)
+}
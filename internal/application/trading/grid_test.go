@@ -0,0 +1,356 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// kindRepo 保存單一 DCA/網格策略的持倉、交易與網格掛單。
+type kindRepo struct {
+	fakeRepo
+	strat  *strategyDomain.ScoringStrategy
+	pos    *tradingDomain.Position
+	saved  []tradingDomain.TradeRecord
+	orders []GridOrder
+}
+
+func (r *kindRepo) LoadScoringStrategyBySlug(context.Context, string) (*strategyDomain.ScoringStrategy, error) {
+	return r.strat, nil
+}
+
+func (r *kindRepo) LoadScoringStrategyByID(context.Context, string) (*strategyDomain.ScoringStrategy, error) {
+	return r.strat, nil
+}
+
+func (r *kindRepo) GetOpenPosition(context.Context, string, tradingDomain.Environment) (*tradingDomain.Position, error) {
+	if r.pos == nil {
+		return nil, nil
+	}
+	cp := *r.pos
+	return &cp, nil
+}
+
+func (r *kindRepo) UpsertPosition(_ context.Context, p tradingDomain.Position) error {
+	r.pos = &p
+	return nil
+}
+
+func (r *kindRepo) ClosePosition(context.Context, string, time.Time, float64) error {
+	r.pos = nil
+	return nil
+}
+
+func (r *kindRepo) SaveTrade(_ context.Context, t tradingDomain.TradeRecord) error {
+	r.saved = append(r.saved, t)
+	return nil
+}
+
+func (r *kindRepo) ListTrades(context.Context, tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error) {
+	return r.saved, nil
+}
+
+func (r *kindRepo) LoadOpenGridOrders(_ context.Context, strategyID string, env tradingDomain.Environment) ([]GridOrder, error) {
+	var out []GridOrder
+	for _, o := range r.orders {
+		if o.StrategyID == strategyID && o.Env == env && (o.Status == OrderStatusNew || o.Status == GridOrderPending) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (r *kindRepo) SaveGridOrder(_ context.Context, o GridOrder) (string, error) {
+	for i := range r.orders {
+		if r.orders[i].ID == o.ID {
+			r.orders[i] = o
+			return o.ID, nil
+		}
+	}
+	o.ID = time.Now().Format("150405.000000000")
+	r.orders = append(r.orders, o)
+	return o.ID, nil
+}
+
+func (r *kindRepo) open(side string) []GridOrder {
+	var out []GridOrder
+	for _, o := range r.orders {
+		if o.Status == OrderStatusNew && o.Side == side {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// movingPrice 回傳可由測試調整的最新價。
+type movingPrice struct {
+	mockExchange
+	price float64
+}
+
+func (m *movingPrice) GetPrice(context.Context, string) (float64, error) { return m.price, nil }
+
+func gridStrategy() *strategyDomain.ScoringStrategy {
+	return &strategyDomain.ScoringStrategy{
+		ID:         "grid-1",
+		Slug:       "grid",
+		BaseSymbol: "BTCUSDT",
+		Kind:       strategyDomain.KindGrid,
+		Grid:       &strategyDomain.GridConfig{Lower: 100, Upper: 200, Grids: 4, OrderSize: 100},
+	}
+}
+
+func TestPaperExchange_LimitOrdersReserveFillAndRefund(t *testing.T) {
+	prices := &movingPrice{price: 110}
+	paper := NewPaperExchange(prices, nil, PaperConfig{InitialBalance: 1000, FeeRate: 0.001})
+	acct := paper.For("u1", nil)
+	ctx := context.Background()
+
+	buy, err := acct.PlaceLimitOrder(ctx, "BTCUSDT", "buy", 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usdt, _ := acct.GetBalance(ctx, "USDT"); !approx(usdt, 1000-200.2) {
+		t.Fatalf("buy limit must reserve notional plus fee, got %v", usdt)
+	}
+	if o, _ := acct.GetOrder(ctx, "BTCUSDT", buy.OrderID); o.Status != OrderStatusNew {
+		t.Fatalf("order above market must rest, got %s", o.Status)
+	}
+	if _, err := acct.PlaceLimitOrder(ctx, "BTCUSDT", "buy", 10, 100); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	prices.price = 99
+	if o, _ := acct.GetOrder(ctx, "BTCUSDT", buy.OrderID); o.Status != OrderStatusFilled || o.Price != 100 {
+		t.Fatalf("expected fill at the limit price, got %+v", o)
+	}
+	if btc, _ := acct.GetBalance(ctx, "BTC"); btc != 2 {
+		t.Fatalf("expected 2 BTC after fill, got %v", btc)
+	}
+
+	sell, err := acct.PlaceLimitOrder(ctx, "BTCUSDT", "sell", 2, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if btc, _ := acct.GetBalance(ctx, "BTC"); btc != 0 {
+		t.Fatalf("sell limit must reserve base asset, got %v", btc)
+	}
+	if err := acct.CancelOrder(ctx, "BTCUSDT", sell.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	if btc, _ := acct.GetBalance(ctx, "BTC"); btc != 2 {
+		t.Fatalf("cancel must refund reserved BTC, got %v", btc)
+	}
+	if err := acct.CancelOrder(ctx, "BTCUSDT", sell.OrderID); err == nil {
+		t.Fatalf("cancelling twice must fail")
+	}
+}
+
+func TestService_GridPlacesAndCyclesOrders(t *testing.T) {
+	repo := &kindRepo{strat: gridStrategy()}
+	prices := &movingPrice{price: 160}
+	svc := NewService(repo, nil, prices, nil)
+	ctx := context.Background()
+	run := func() {
+		t.Helper()
+		if err := svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvShadow, "u1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+	if buys := repo.open("buy"); len(buys) != 3 || buys[2].Price != 150 {
+		t.Fatalf("expected buys at 100/125/150 below 160, got %+v", buys)
+	}
+
+	prices.price = 140
+	run()
+	sells := repo.open("sell")
+	if len(sells) != 1 || sells[0].Price != 175 || sells[0].Level != 3 {
+		t.Fatalf("expected a sell one level above the filled buy, got %+v", sells)
+	}
+	if repo.pos == nil || !approx(repo.pos.Size, 100.0/150) {
+		t.Fatalf("expected position from the filled buy, got %+v", repo.pos)
+	}
+
+	prices.price = 180
+	run()
+	if repo.pos != nil {
+		t.Fatalf("position must close once the grid inventory is sold")
+	}
+	last := repo.saved[len(repo.saved)-1]
+	if last.Side != "sell" || last.PNL == nil || !approx(*last.PNL, 25*100.0/150) {
+		t.Fatalf("expected grid profit of one step, got %+v", last)
+	}
+	if buys := repo.open("buy"); len(buys) != 3 {
+		t.Fatalf("sold level must be re-armed with a buy, got %d buys", len(buys))
+	}
+}
+
+func TestService_GridRestoresPaperOrdersAfterRestart(t *testing.T) {
+	repo := &kindRepo{strat: gridStrategy()}
+	prices := &movingPrice{price: 160}
+	ledger := &memLedger{}
+	ctx := context.Background()
+	start := func() *Service {
+		svc := NewService(repo, nil, prices, nil)
+		svc.SetPaperExchange(NewPaperExchange(prices, ledger, PaperConfig{InitialBalance: 1000}))
+		return svc
+	}
+	usdt := func(svc *Service) float64 {
+		v, _ := svc.PaperAccount(strategyOwner(repo.strat)).GetBalance(ctx, "USDT")
+		return v
+	}
+
+	svc := start()
+	if err := svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvPaper, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	before := repo.open("buy")
+	if len(before) != 3 || !approx(usdt(svc), 700) {
+		t.Fatalf("expected 3 buys reserving 300 USDT, got %+v balance %v", before, usdt(svc))
+	}
+
+	// 重啟後掛單簿是空的，網格單要以原編號還原，不可重新扣住資金
+	svc = start()
+	if err := svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvPaper, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	after := repo.open("buy")
+	if len(after) != 3 || after[2].OrderID != before[2].OrderID || !approx(usdt(svc), 700) {
+		t.Fatalf("expected the same orders without a second reservation, got %+v balance %v", after, usdt(svc))
+	}
+
+	prices.price = 140
+	if err := svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvPaper, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if sells := repo.open("sell"); len(sells) != 1 || sells[0].Level != 3 {
+		t.Fatalf("restored order must still fill, got sells %+v", sells)
+	}
+}
+
+func TestService_GridRetriesPendingCounterOrder(t *testing.T) {
+	repo := &kindRepo{strat: gridStrategy()}
+	prices := &movingPrice{price: 160}
+	svc := NewService(repo, nil, prices, nil)
+	ctx := context.Background()
+	run := func() error {
+		return svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvShadow, "u1")
+	}
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	prices.price = 140
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 賣單成交後的補買單被風控擋下，留下待送出的紀錄
+	svc.SetRiskLimits(RiskLimits{MaxOrderNotional: 1})
+	prices.price = 180
+	if err := run(); !errors.Is(err, ErrRiskRejected) {
+		t.Fatalf("expected counter-order to be rejected, got %v", err)
+	}
+	var pending []GridOrder
+	for _, o := range repo.orders {
+		if o.Status == GridOrderPending {
+			pending = append(pending, o)
+		}
+	}
+	if len(pending) != 1 || pending[0].Side != "buy" || pending[0].Level != 2 || repo.pos != nil {
+		t.Fatalf("expected a pending buy at level 2 after the sell filled, got %+v", pending)
+	}
+
+	svc.SetRiskLimits(RiskLimits{})
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if buys := repo.open("buy"); len(buys) != 3 {
+		t.Fatalf("pending counter-order must be placed on the next run, got %+v", buys)
+	}
+	for _, o := range repo.orders {
+		if o.Status == GridOrderPending {
+			t.Fatalf("no order may stay pending, got %+v", o)
+		}
+	}
+}
+
+func TestService_GridCancelsOrdersWhenStopped(t *testing.T) {
+	repo := &kindRepo{strat: gridStrategy()}
+	svc := NewService(repo, nil, &movingPrice{price: 160}, nil)
+	ctx := context.Background()
+
+	if err := svc.ExecuteScoringAutoTrade(ctx, "grid", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetStatus(ctx, "grid-1", tradingDomain.StatusDraft, tradingDomain.EnvShadow); err != nil {
+		t.Fatal(err)
+	}
+	if open := repo.open("buy"); len(open) != 0 {
+		t.Fatalf("expected all grid orders cancelled, got %+v", open)
+	}
+}
+
+func TestService_DCABuysOnIntervalAndTakesProfit(t *testing.T) {
+	repo := &kindRepo{strat: &strategyDomain.ScoringStrategy{
+		ID:         "dca-1",
+		Slug:       "dca",
+		BaseSymbol: "BTCUSDT",
+		Kind:       strategyDomain.KindDCA,
+		DCA: &strategyDomain.DCAConfig{
+			Amount:        100,
+			Interval:      "1d",
+			Multipliers:   []strategyDomain.DCAMultiplier{{MaxScore: 30, Multiplier: 2}},
+			TakeProfitPct: 10,
+		},
+	}}
+	history := []analysisDomain.DailyAnalysisResult{{TradeDate: time.Now().Add(-time.Hour), Close: 50000, Score: 20}}
+	data := &stubDataProvider{history: history}
+	prices := &movingPrice{price: 50000}
+	svc := NewService(repo, data, prices, nil)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+	run := func() {
+		t.Helper()
+		if err := svc.ExecuteScoringAutoTrade(ctx, "dca", tradingDomain.EnvShadow, "u1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+	if len(repo.saved) != 1 || repo.pos == nil {
+		t.Fatalf("expected first DCA buy, got %+v", repo.saved)
+	}
+	if notional := repo.pos.Size * repo.pos.EntryPrice; notional < 190 || notional > 200 {
+		t.Fatalf("low score must double the amount, got notional %.2f", notional)
+	}
+
+	now = now.Add(12 * time.Hour)
+	run()
+	if len(repo.saved) != 1 {
+		t.Fatalf("must not buy again before the interval elapses")
+	}
+
+	now = now.Add(12 * time.Hour)
+	run()
+	if len(repo.saved) != 2 || repo.pos == nil {
+		t.Fatalf("expected second DCA buy after one day, got %d trades", len(repo.saved))
+	}
+
+	data.history[0].Close = 60000
+	prices.price = 60000
+	run()
+	if repo.pos != nil {
+		t.Fatalf("take profit must sell the whole position")
+	}
+	if last := repo.saved[len(repo.saved)-1]; last.Side != "sell" || last.PNL == nil || *last.PNL <= 0 {
+		t.Fatalf("expected profitable DCA exit, got %+v", last)
+	}
+}
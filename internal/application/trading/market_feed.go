@@ -0,0 +1,229 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCandleHistory = 500
+	defaultTickThrottle  = time.Second
+)
+
+// Candle 為串流推送的 K 線，Closed 代表該根已收盤。
+type Candle struct {
+	Symbol    string
+	Timeframe string
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	Closed    bool
+}
+
+// PriceTick 為最佳買賣價更新。
+type PriceTick struct {
+	Symbol string
+	Bid    float64
+	Ask    float64
+	Time   time.Time
+}
+
+// Mid 回傳買賣中價。
+func (t PriceTick) Mid() float64 {
+	if t.Bid <= 0 {
+		return t.Ask
+	}
+	if t.Ask <= 0 {
+		return t.Bid
+	}
+	return (t.Bid + t.Ask) / 2
+}
+
+// StreamSubscription 描述需要訂閱的交易對與 K 線週期。
+type StreamSubscription struct {
+	Symbol    string
+	Timeframe string
+}
+
+// MarketDataHandler 接收行情串流事件。
+type MarketDataHandler interface {
+	OnCandle(ctx context.Context, c Candle) error
+	OnTick(ctx context.Context, t PriceTick) error
+}
+
+// MarketFeed 在記憶體中維護最新價格與 K 線，
+// 於 K 線收盤時觸發策略評估，並在價格跳動時檢查停損停利。
+type MarketFeed struct {
+	svc          *Service
+	mu           sync.RWMutex
+	prices       map[string]PriceTick
+	candles      map[string][]Candle
+	maxCandles   int
+	tickThrottle time.Duration
+	lastCheck    map[string]time.Time
+	busy         map[string]bool
+	wg           sync.WaitGroup
+}
+
+// NewMarketFeed 建立行情快取。
+func NewMarketFeed(svc *Service) *MarketFeed {
+	return &MarketFeed{
+		svc:          svc,
+		prices:       make(map[string]PriceTick),
+		candles:      make(map[string][]Candle),
+		maxCandles:   defaultCandleHistory,
+		tickThrottle: defaultTickThrottle,
+		lastCheck:    make(map[string]time.Time),
+		busy:         make(map[string]bool),
+	}
+}
+
+func candleKey(symbol, timeframe string) string {
+	return strings.ToUpper(symbol) + "|" + timeframe
+}
+
+// OnCandle 更新 K 線快取；收盤 K 線會非同步觸發策略評估。
+func (f *MarketFeed) OnCandle(ctx context.Context, c Candle) error {
+	c.Symbol = strings.ToUpper(c.Symbol)
+	key := candleKey(c.Symbol, c.Timeframe)
+
+	f.mu.Lock()
+	series := f.candles[key]
+	if n := len(series); n > 0 && series[n-1].OpenTime.Equal(c.OpenTime) {
+		series[n-1] = c
+	} else {
+		series = append(series, c)
+		if len(series) > f.maxCandles {
+			series = series[len(series)-f.maxCandles:]
+		}
+	}
+	f.candles[key] = series
+	if _, ok := f.prices[c.Symbol]; !ok {
+		f.prices[c.Symbol] = PriceTick{Symbol: c.Symbol, Bid: c.Close, Ask: c.Close, Time: c.CloseTime}
+	}
+	f.mu.Unlock()
+
+	if c.Closed && f.svc != nil {
+		f.runExclusive(ctx, "bar|"+key, func(ctx context.Context) {
+			if err := f.svc.EvaluateOnBarClose(ctx, c); err != nil {
+				log.Printf("[MarketFeed] bar close evaluation %s failed: %v", key, err)
+			}
+		})
+	}
+	return nil
+}
+
+// OnTick 更新最新價格，並節流觸發停損停利檢查。
+func (f *MarketFeed) OnTick(ctx context.Context, t PriceTick) error {
+	t.Symbol = strings.ToUpper(t.Symbol)
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+
+	f.mu.Lock()
+	f.prices[t.Symbol] = t
+	due := t.Time.Sub(f.lastCheck[t.Symbol]) >= f.tickThrottle
+	if due {
+		f.lastCheck[t.Symbol] = t.Time
+	}
+	f.mu.Unlock()
+
+	if due && f.svc != nil {
+		price := t.Mid()
+		f.runExclusive(ctx, "tick|"+t.Symbol, func(ctx context.Context) {
+			if err := f.svc.CheckProtectiveExits(ctx, t.Symbol, price); err != nil {
+				log.Printf("[MarketFeed] protective exit check %s failed: %v", t.Symbol, err)
+			}
+		})
+	}
+	return nil
+}
+
+// runExclusive 以 key 互斥地在背景執行 fn，避免同一標的重複下單。
+func (f *MarketFeed) runExclusive(ctx context.Context, key string, fn func(ctx context.Context)) {
+	f.mu.Lock()
+	if f.busy[key] {
+		f.mu.Unlock()
+		return
+	}
+	f.busy[key] = true
+	f.mu.Unlock()
+
+	// 串流重連會取消 ctx，但已開始的下單流程不應被中斷。
+	ctx = context.WithoutCancel(ctx)
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer func() {
+			f.mu.Lock()
+			delete(f.busy, key)
+			f.mu.Unlock()
+		}()
+		fn(ctx)
+	}()
+}
+
+// Wait 等待背景評估完成（供測試與關機流程使用）。
+func (f *MarketFeed) Wait() {
+	f.wg.Wait()
+}
+
+// LatestPrice 取得串流中的最新價格。
+func (f *MarketFeed) LatestPrice(symbol string) (PriceTick, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	t, ok := f.prices[strings.ToUpper(symbol)]
+	return t, ok
+}
+
+// Candles 取得最近的 K 線（由舊到新）。
+func (f *MarketFeed) Candles(symbol, timeframe string) []Candle {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	series := f.candles[candleKey(symbol, timeframe)]
+	out := make([]Candle, len(series))
+	copy(out, series)
+	return out
+}
+
+// Subscriptions 依啟用中的策略決定需要訂閱的交易對與週期。
+func (f *MarketFeed) Subscriptions(ctx context.Context) ([]StreamSubscription, error) {
+	strats, err := f.svc.repo.ListActiveScoringStrategies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list active strategies: %w", err)
+	}
+	seen := make(map[string]bool)
+	var subs []StreamSubscription
+	for _, st := range strats {
+		tf := st.Timeframe
+		if tf == "" {
+			tf = "1d"
+		}
+		// 固定清單的多交易對策略需訂閱每個候選，動態清單以 BaseSymbol 作為觸發時鐘
+		symbols := []string{st.BaseSymbol}
+		if st.Universe != nil {
+			symbols = append(symbols, st.Universe.Symbols...)
+		}
+		for _, sym := range symbols {
+			key := candleKey(sym, tf)
+			if sym == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			subs = append(subs, StreamSubscription{Symbol: strings.ToUpper(sym), Timeframe: tf})
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return candleKey(subs[i].Symbol, subs[i].Timeframe) < candleKey(subs[j].Symbol, subs[j].Timeframe)
+	})
+	return subs, nil
+}
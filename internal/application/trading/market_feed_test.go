@@ -0,0 +1,187 @@
+package trading
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type feedRepo struct {
+	positionRepo
+	strat *strategyDomain.ScoringStrategy
+}
+
+func (f *feedRepo) LoadScoringStrategyByID(context.Context, string) (*strategyDomain.ScoringStrategy, error) {
+	return f.strat, nil
+}
+
+func TestMarketFeed_BarCloseTriggersEvaluation(t *testing.T) {
+	repo := &fakeRepo{activeStrats: []*strategyDomain.ScoringStrategy{
+		{ID: "strat-1", Slug: "alpha", BaseSymbol: "BTCUSDT", Timeframe: "1h", Env: "paper"},
+		{ID: "strat-2", Slug: "beta", BaseSymbol: "ETHUSDT", Timeframe: "1h", Env: "paper"},
+	}}
+	history := []analysisDomain.DailyAnalysisResult{{TradeDate: time.Now(), Close: 50000, Score: 80}}
+	svc := NewService(repo, stubDataProvider{history: history}, &mockExchange{}, nil)
+	feed := NewMarketFeed(svc)
+	ctx := context.Background()
+	open := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// 未收盤 K 線只更新快取
+	_ = feed.OnCandle(ctx, Candle{Symbol: "btcusdt", Timeframe: "1h", OpenTime: open, Close: 100})
+	feed.Wait()
+	if repo.upsertPositionCalled != 0 {
+		t.Fatalf("open candle must not trigger evaluation")
+	}
+
+	_ = feed.OnCandle(ctx, Candle{Symbol: "BTCUSDT", Timeframe: "1h", OpenTime: open, Close: 101, Closed: true})
+	feed.Wait()
+	if repo.upsertPositionCalled != 1 {
+		t.Fatalf("expected one buy after bar close, got %d", repo.upsertPositionCalled)
+	}
+	candles := feed.Candles("BTCUSDT", "1h")
+	if len(candles) != 1 || candles[0].Close != 101 || !candles[0].Closed {
+		t.Fatalf("expected in-place candle update, got %+v", candles)
+	}
+
+	subs, err := feed.Subscriptions(ctx)
+	if err != nil || len(subs) != 2 || subs[0].Symbol != "BTCUSDT" {
+		t.Fatalf("unexpected subscriptions %+v err=%v", subs, err)
+	}
+}
+
+func TestMarketFeed_TickTriggersProtectiveExit(t *testing.T) {
+	sl := 2.0
+	repo := &feedRepo{
+		positionRepo: positionRepo{positions: []tradingDomain.Position{
+			{ID: "p1", StrategyID: "strat-1", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, EntryPrice: 100, Size: 1, Status: "open"},
+			{ID: "p2", StrategyID: "manual", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, EntryPrice: 100, Size: 1, Status: "open"},
+		}},
+		strat: &strategyDomain.ScoringStrategy{ID: "strat-1", BaseSymbol: "BTCUSDT", Risk: tradingDomain.RiskSettings{StopLossPct: &sl}},
+	}
+	svc := NewService(repo, dummyDataProvider{}, &mockExchange{}, nil)
+	feed := NewMarketFeed(svc)
+	ctx := context.Background()
+	now := time.Now()
+
+	_ = feed.OnTick(ctx, PriceTick{Symbol: "BTCUSDT", Bid: 99.5, Ask: 99.7, Time: now})
+	feed.Wait()
+	if repo.closePositionCalled != 0 {
+		t.Fatalf("price within range must not exit")
+	}
+
+	// 節流期間內的跳動不檢查
+	_ = feed.OnTick(ctx, PriceTick{Symbol: "BTCUSDT", Bid: 90, Ask: 90, Time: now.Add(100 * time.Millisecond)})
+	feed.Wait()
+	if repo.closePositionCalled != 0 {
+		t.Fatalf("throttled tick must not trigger check")
+	}
+
+	_ = feed.OnTick(ctx, PriceTick{Symbol: "BTCUSDT", Bid: 97, Ask: 97.2, Time: now.Add(2 * time.Second)})
+	feed.Wait()
+	if repo.closePositionCalled != 1 {
+		t.Fatalf("expected stop-loss exit for strategy position only, got %d", repo.closePositionCalled)
+	}
+	if p, ok := feed.LatestPrice("btcusdt"); !ok || p.Bid != 97 {
+		t.Fatalf("unexpected latest price %+v", p)
+	}
+}
+
+// barHistory 只回傳 from 之後的分析結果，模擬尚未分析收盤 K 線的情況。
+type barHistory struct {
+	results []analysisDomain.DailyAnalysisResult
+}
+
+func (b *barHistory) FindHistory(_ context.Context, _ string, _ string, from, _ *time.Time, _ int, _ bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	var out []analysisDomain.DailyAnalysisResult
+	for _, r := range b.results {
+		if from == nil || !r.TradeDate.Before(*from) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (b *barHistory) PricesByPair(context.Context, string, string) ([]dataDomain.DailyPrice, error) {
+	return nil, nil
+}
+
+type fakeBarRecorder struct {
+	history  *barHistory
+	versions []string
+	calls    int
+}
+
+func (f *fakeBarRecorder) RecordClosedBar(_ context.Context, c Candle, versions []string) error {
+	f.calls++
+	f.versions = versions
+	f.history.results = append(f.history.results, analysisDomain.DailyAnalysisResult{TradeDate: c.OpenTime, Close: c.Close, Score: 80})
+	return nil
+}
+
+func TestService_EvaluateOnBarCloseWaitsForBarAnalysis(t *testing.T) {
+	repo := &fakeRepo{activeStrats: []*strategyDomain.ScoringStrategy{
+		{ID: "strat-1", Slug: "alpha", BaseSymbol: "BTCUSDT", Timeframe: "1h", Env: "paper"},
+	}}
+	open := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	// 只有上一根 K 線的分析
+	history := &barHistory{results: []analysisDomain.DailyAnalysisResult{{TradeDate: open.Add(-time.Hour), Close: 100, Score: 80}}}
+	svc := NewService(repo, history, &mockExchange{}, nil)
+	ctx := context.Background()
+	bar := Candle{Symbol: "BTCUSDT", Timeframe: "1h", OpenTime: open, Close: 101, Closed: true}
+
+	if err := svc.EvaluateOnBarClose(ctx, bar); err != nil {
+		t.Fatal(err)
+	}
+	if repo.upsertPositionCalled != 0 {
+		t.Fatalf("stale analysis must not be evaluated")
+	}
+
+	rec := &fakeBarRecorder{history: history}
+	svc.SetBarRecorder(rec)
+	if err := svc.EvaluateOnBarClose(ctx, bar); err != nil {
+		t.Fatal(err)
+	}
+	if rec.calls != 1 || len(rec.versions) != 1 || rec.versions[0] != "" {
+		t.Fatalf("expected closed bar recorded for the active version, got %+v", rec)
+	}
+	if repo.upsertPositionCalled != 1 {
+		t.Fatalf("expected buy after the bar is analysed, got %d", repo.upsertPositionCalled)
+	}
+}
+
+func TestService_ProtectiveExitSharesStrategyLock(t *testing.T) {
+	sl := 2.0
+	strat := &strategyDomain.ScoringStrategy{ID: "strat-1", BaseSymbol: "BTCUSDT", Risk: tradingDomain.RiskSettings{StopLossPct: &sl}}
+	repo := &feedRepo{
+		positionRepo: positionRepo{positions: []tradingDomain.Position{
+			{ID: "p1", StrategyID: "strat-1", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, EntryPrice: 100, Size: 1, Status: "open"},
+		}},
+		strat: strat,
+	}
+	svc := NewService(repo, dummyDataProvider{}, &mockExchange{}, nil)
+	ctx := context.Background()
+
+	// 評估執行中時不重複賣出
+	err := svc.exec.Exclusive(strat, tradingDomain.EnvPaper, func() error {
+		return svc.CheckProtectiveExits(ctx, "BTCUSDT", 97)
+	})
+	if err != nil || repo.closePositionCalled != 0 {
+		t.Fatalf("exit must wait for the running evaluation, err=%v closed=%d", err, repo.closePositionCalled)
+	}
+
+	if err := svc.CheckProtectiveExits(ctx, "BTCUSDT", 97); err != nil {
+		t.Fatal(err)
+	}
+	// 清單仍列出 p1（例如讀取後才平倉），重新讀取持倉後不再賣出
+	if err := svc.CheckProtectiveExits(ctx, "BTCUSDT", 97); err != nil {
+		t.Fatal(err)
+	}
+	if repo.closePositionCalled != 1 {
+		t.Fatalf("expected a single exit, got %d", repo.closePositionCalled)
+	}
+}
@@ -0,0 +1,438 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// 交易所訂單狀態。
+const (
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCanceled        = "CANCELED"
+	OrderStatusRejected        = "REJECTED"
+	OrderStatusExpired         = "EXPIRED"
+)
+
+// OrderUpdate 代表交易所推送（或查詢）到的訂單狀態。
+type OrderUpdate struct {
+	OrderID         string
+	ClientOrderID   string
+	Symbol          string
+	Side            string
+	Type            string
+	Status          string
+	Price           float64
+	OrigQty         float64
+	LastFillPrice   float64
+	LastFillQty     float64
+	CumQty          float64
+	CumQuote        float64
+	Commission      float64
+	CommissionAsset string
+	EventTime       time.Time
+}
+
+// AvgPrice 回傳目前累積成交均價。
+func (u OrderUpdate) AvgPrice() float64 {
+	if u.CumQty <= 0 {
+		return 0
+	}
+	return u.CumQuote / u.CumQty
+}
+
+// BalanceUpdate 代表單一資產的最新餘額。
+type BalanceUpdate struct {
+	Asset  string
+	Free   float64
+	Locked float64
+}
+
+// Total 回傳可用加凍結的總量。
+func (b BalanceUpdate) Total() float64 {
+	return b.Free + b.Locked
+}
+
+// UserDataHandler 接收交易所使用者資料流事件。
+type UserDataHandler interface {
+	OnOrderUpdate(ctx context.Context, u OrderUpdate) error
+	OnBalanceUpdate(ctx context.Context, balances []BalanceUpdate) error
+	// Resync 於重新連線後以 REST 快照覆蓋目前狀態。
+	Resync(ctx context.Context, openOrders []OrderUpdate, balances []BalanceUpdate) error
+}
+
+// orderTransitions 定義合法的狀態轉移，終態不可再變動。
+var orderTransitions = map[string][]string{
+	"":                         {OrderStatusNew, OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired},
+	OrderStatusNew:             {OrderStatusNew, OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired},
+	OrderStatusPartiallyFilled: {OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCanceled, OrderStatusExpired},
+}
+
+func isTerminalOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return !ok
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientOrderPrefix 為本系統下單時的 client order id 前綴；此類訂單的持倉由下單流程自行更新，
+// OrderTracker 只套用其他來源（手動、交易所介面）的成交。
+const ClientOrderPrefix = "aat-"
+
+// OrderLookup 查詢單筆訂單的最新狀態，用於補齊串流中斷期間結束的訂單。
+type OrderLookup interface {
+	LookupOrder(ctx context.Context, symbol, orderID string) (OrderUpdate, error)
+}
+
+// quoteAssets 用於由交易對推回基礎資產。
+var quoteAssets = []string{"USDT", "USDC", "FDUSD", "BUSD", "BTC", "ETH", "BNB"}
+
+// BaseAsset 由交易對（如 BTCUSDT）取出基礎資產（BTC）。
+func BaseAsset(symbol string) string {
+	s := strings.ToUpper(symbol)
+	for _, q := range quoteAssets {
+		if strings.HasSuffix(s, q) && len(s) > len(q) {
+			return strings.TrimSuffix(s, q)
+		}
+	}
+	return s
+}
+
+// OrderTracker 維護訂單狀態機與帳戶餘額，並依餘額校正實盤持倉。
+type OrderTracker struct {
+	mu       sync.RWMutex
+	posMu    sync.Mutex // 序列化持倉的讀改寫
+	repo     Repository
+	lookup   OrderLookup
+	envs     []tradingDomain.Environment
+	orders   map[string]OrderUpdate
+	balances map[string]BalanceUpdate
+	now      func() time.Time
+}
+
+// NewOrderTracker 建立訂單追蹤器，envs 為此帳戶對應的持倉環境。
+func NewOrderTracker(repo Repository, envs ...tradingDomain.Environment) *OrderTracker {
+	return &OrderTracker{
+		repo:     repo,
+		envs:     envs,
+		orders:   make(map[string]OrderUpdate),
+		balances: make(map[string]BalanceUpdate),
+		now:      time.Now,
+	}
+}
+
+// SetOrderLookup 設定重新同步時查詢訂單最終狀態的來源；未設定時快照外的未完成訂單直接移除。
+func (t *OrderTracker) SetOrderLookup(lookup OrderLookup) {
+	t.lookup = lookup
+}
+
+// OnOrderUpdate 套用一筆訂單事件，忽略過期或非法的狀態轉移；
+// 非本系統下的訂單，新增的成交量會併入（買）或扣除（賣）該交易對的持倉。
+func (t *OrderTracker) OnOrderUpdate(ctx context.Context, u OrderUpdate) error {
+	if u.OrderID == "" {
+		return fmt.Errorf("order update missing order id")
+	}
+	t.mu.Lock()
+	prev, exists := t.orders[u.OrderID]
+	if exists {
+		if !u.EventTime.IsZero() && u.EventTime.Before(prev.EventTime) {
+			t.mu.Unlock()
+			return nil
+		}
+		if u.CumQty < prev.CumQty {
+			t.mu.Unlock()
+			return nil
+		}
+		if !canTransition(prev.Status, u.Status) {
+			t.mu.Unlock()
+			return fmt.Errorf("order %s: invalid transition %s -> %s", u.OrderID, prev.Status, u.Status)
+		}
+	}
+	t.orders[u.OrderID] = u
+	t.mu.Unlock()
+
+	fillQty, fillQuote := u.CumQty-prev.CumQty, u.CumQuote-prev.CumQuote
+	if fillQty > 0 && !strings.HasPrefix(u.ClientOrderID, ClientOrderPrefix) {
+		if err := t.applyFill(ctx, u, fillQty, fillQuote); err != nil {
+			return err
+		}
+	}
+
+	if u.Status == OrderStatusFilled && t.repo != nil {
+		for _, env := range t.envs {
+			_ = t.repo.SaveLog(ctx, tradingDomain.LogEntry{
+				Env:     env,
+				Date:    t.now(),
+				Phase:   "fill",
+				Message: fmt.Sprintf("Order %s %s %s filled qty=%.8f avg=%.8f", u.OrderID, u.Side, u.Symbol, u.CumQty, u.AvgPrice()),
+				Payload: u,
+			})
+		}
+	}
+	return nil
+}
+
+// OnBalanceUpdate 更新餘額快取並校正持倉數量。
+func (t *OrderTracker) OnBalanceUpdate(ctx context.Context, balances []BalanceUpdate) error {
+	t.mu.Lock()
+	for _, b := range balances {
+		t.balances[strings.ToUpper(b.Asset)] = b
+	}
+	t.mu.Unlock()
+	return t.reconcilePositions(ctx, balances)
+}
+
+// Resync 以 REST 快照覆蓋狀態：未出現在快照中的未完成訂單已在中斷期間結束，
+// 逐筆查詢最終狀態並經 OnOrderUpdate 套用，讓期間的成交仍會反映到持倉。
+func (t *OrderTracker) Resync(ctx context.Context, openOrders []OrderUpdate, balances []BalanceUpdate) error {
+	open := make(map[string]OrderUpdate, len(openOrders))
+	for _, o := range openOrders {
+		open[o.OrderID] = o
+	}
+
+	var finished []OrderUpdate
+	t.mu.RLock()
+	for id, o := range t.orders {
+		if _, ok := open[id]; !ok && !isTerminalOrderStatus(o.Status) {
+			finished = append(finished, o)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, o := range finished {
+		if t.lookup == nil {
+			log.Printf("[TRADING] Order %s left the open-order snapshot and cannot be looked up, dropping", o.OrderID)
+			t.mu.Lock()
+			delete(t.orders, o.OrderID)
+			t.mu.Unlock()
+			continue
+		}
+		final, err := t.lookup.LookupOrder(ctx, o.Symbol, o.OrderID)
+		if err != nil {
+			// 保留追蹤，下次重新同步再查
+			log.Printf("[TRADING] Lookup order %s after resync failed: %v", o.OrderID, err)
+			continue
+		}
+		if err := t.OnOrderUpdate(ctx, final); err != nil {
+			log.Printf("[TRADING] Apply final state of order %s failed: %v", o.OrderID, err)
+		}
+	}
+	for _, o := range open {
+		if err := t.OnOrderUpdate(ctx, o); err != nil {
+			log.Printf("[TRADING] Apply snapshot order %s failed: %v", o.OrderID, err)
+		}
+	}
+
+	t.mu.Lock()
+	t.balances = make(map[string]BalanceUpdate, len(balances))
+	for _, b := range balances {
+		t.balances[strings.ToUpper(b.Asset)] = b
+	}
+	t.mu.Unlock()
+
+	return t.reconcilePositions(ctx, balances)
+}
+
+// applyFill 將外部成交套用到第一個追蹤環境中該交易對的持倉：
+// 買入併入手動持倉（無則新建），賣出先扣手動持倉再扣策略持倉，扣完即平倉。
+func (t *OrderTracker) applyFill(ctx context.Context, u OrderUpdate, qty, quote float64) error {
+	if t.repo == nil || len(t.envs) == 0 {
+		return nil
+	}
+	env := t.envs[0]
+	at := u.EventTime
+	if at.IsZero() {
+		at = t.now()
+	}
+	price := u.LastFillPrice
+	if qty > 0 && quote > 0 {
+		price = quote / qty
+	}
+
+	t.posMu.Lock()
+	defer t.posMu.Unlock()
+	positions, err := t.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("list open positions: %w", err)
+	}
+	// 手動持倉排在策略持倉之前
+	var matched, strategyHeld []tradingDomain.Position
+	for _, p := range positions {
+		if p.Env != env || !strings.EqualFold(p.Symbol, u.Symbol) {
+			continue
+		}
+		if p.StrategyID == "" {
+			matched = append(matched, p)
+		} else {
+			strategyHeld = append(strategyHeld, p)
+		}
+	}
+	matched = append(matched, strategyHeld...)
+
+	switch strings.ToUpper(u.Side) {
+	case "BUY":
+		pos := tradingDomain.Position{Symbol: strings.ToUpper(u.Symbol), Env: env, EntryDate: at, Status: "open"}
+		if len(matched) > 0 && matched[0].StrategyID == "" {
+			pos = matched[0]
+		}
+		total := pos.Size + qty
+		pos.EntryPrice = (pos.EntryPrice*pos.Size + price*qty) / total
+		pos.Size = total
+		pos.UpdatedAt = at
+		if err := t.repo.UpsertPosition(ctx, pos); err != nil {
+			return fmt.Errorf("upsert position for order %s: %w", u.OrderID, err)
+		}
+	case "SELL":
+		remaining := qty
+		for _, p := range matched {
+			if remaining <= 1e-12 {
+				break
+			}
+			if p.Size-remaining <= 1e-12 {
+				remaining -= p.Size
+				if err := t.repo.ClosePosition(ctx, p.ID, at, price); err != nil {
+					return fmt.Errorf("close position %s: %w", p.ID, err)
+				}
+				continue
+			}
+			p.Size -= remaining
+			p.UpdatedAt = at
+			remaining = 0
+			if err := t.repo.UpsertPosition(ctx, p); err != nil {
+				return fmt.Errorf("upsert position %s: %w", p.ID, err)
+			}
+		}
+	default:
+		return nil
+	}
+	_ = t.repo.SaveLog(ctx, tradingDomain.LogEntry{
+		Env:     env,
+		Date:    t.now(),
+		Phase:   "fill",
+		Message: fmt.Sprintf("External order %s %s %s qty=%.8f @ %.8f applied to positions", u.OrderID, u.Side, u.Symbol, qty, price),
+		Payload: u,
+	})
+	return nil
+}
+
+// Order 取得追蹤中的訂單狀態。
+func (t *OrderTracker) Order(orderID string) (OrderUpdate, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	o, ok := t.orders[orderID]
+	return o, ok
+}
+
+// Balance 取得資產最新餘額。
+func (t *OrderTracker) Balance(asset string) (BalanceUpdate, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	b, ok := t.balances[strings.ToUpper(asset)]
+	return b, ok
+}
+
+// reconcilePositions 若交易所實際持有量少於同一資產所有持倉的合計（例如在交易所手動賣出），
+// 依比例下修各持倉數量；餘額歸零則全部平倉。
+func (t *OrderTracker) reconcilePositions(ctx context.Context, balances []BalanceUpdate) error {
+	if t.repo == nil || len(t.envs) == 0 || len(balances) == 0 {
+		return nil
+	}
+	byAsset := make(map[string]BalanceUpdate, len(balances))
+	for _, b := range balances {
+		byAsset[strings.ToUpper(b.Asset)] = b
+	}
+
+	t.posMu.Lock()
+	defer t.posMu.Unlock()
+	positions, err := t.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("list open positions: %w", err)
+	}
+	var assets []string
+	held := make(map[string][]tradingDomain.Position)
+	for _, p := range positions {
+		if !t.tracksEnv(p.Env) {
+			continue
+		}
+		asset := BaseAsset(p.Symbol)
+		if _, ok := byAsset[asset]; !ok {
+			continue
+		}
+		if _, ok := held[asset]; !ok {
+			assets = append(assets, asset)
+		}
+		held[asset] = append(held[asset], p)
+	}
+	for _, asset := range assets {
+		if err := t.reconcileAsset(ctx, asset, byAsset[asset].Total(), held[asset]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileAsset 比較單一資產的餘額與其所有持倉合計，不足時依比例分攤到各持倉。
+func (t *OrderTracker) reconcileAsset(ctx context.Context, asset string, balance float64, positions []tradingDomain.Position) error {
+	var total float64
+	for _, p := range positions {
+		total += p.Size
+	}
+	if total <= 0 || balance >= total*0.999 {
+		return nil
+	}
+	for _, p := range positions {
+		if balance <= 0 {
+			// 已在系統外全部賣出；無成交價可用，以進場價結束避免虛構損益
+			log.Printf("[TRADING] Position %s (%s) has no exchange balance left, closing", p.ID, p.Symbol)
+			_ = t.repo.SaveLog(ctx, tradingDomain.LogEntry{
+				StrategyID: p.StrategyID,
+				Env:        p.Env,
+				Date:       t.now(),
+				Phase:      "reconcile",
+				Message:    fmt.Sprintf("Position size %.8f closed: exchange balance is 0 (sold outside the system)", p.Size),
+			})
+			if err := t.repo.ClosePosition(ctx, p.ID, t.now(), p.EntryPrice); err != nil {
+				return fmt.Errorf("close position %s: %w", p.ID, err)
+			}
+			continue
+		}
+		size := p.Size * balance / total
+		log.Printf("[TRADING] Positions in %s total %.8f exceed exchange balance %.8f, adjusting %s to %.8f", asset, total, balance, p.ID, size)
+		_ = t.repo.SaveLog(ctx, tradingDomain.LogEntry{
+			StrategyID: p.StrategyID,
+			Env:        p.Env,
+			Date:       t.now(),
+			Phase:      "reconcile",
+			Message:    fmt.Sprintf("Position size %.8f adjusted to %.8f: open %s positions total %.8f but exchange balance is %.8f", p.Size, size, asset, total, balance),
+		})
+		p.Size = size
+		p.UpdatedAt = t.now()
+		if err := t.repo.UpsertPosition(ctx, p); err != nil {
+			return fmt.Errorf("upsert position %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+func (t *OrderTracker) tracksEnv(env tradingDomain.Environment) bool {
+	for _, e := range t.envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package trading
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type positionRepo struct {
+	fakeRepo
+	positions []tradingDomain.Position
+	upserted  []tradingDomain.Position
+	closed    []string
+}
+
+func (p *positionRepo) ListOpenPositions(context.Context) ([]tradingDomain.Position, error) {
+	return p.positions, nil
+}
+
+func (p *positionRepo) GetPosition(_ context.Context, id string) (*tradingDomain.Position, error) {
+	for _, pos := range p.positions {
+		if pos.ID == id {
+			if slices.Contains(p.closed, id) {
+				pos.Status = "closed"
+			}
+			return &pos, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *positionRepo) UpsertPosition(_ context.Context, pos tradingDomain.Position) error {
+	p.upserted = append(p.upserted, pos)
+	return nil
+}
+
+func (p *positionRepo) ClosePosition(ctx context.Context, id string, exitDate time.Time, exitPrice float64) error {
+	p.closed = append(p.closed, id)
+	return p.fakeRepo.ClosePosition(ctx, id, exitDate, exitPrice)
+}
+
+type fakeOrderLookup map[string]OrderUpdate
+
+func (f fakeOrderLookup) LookupOrder(_ context.Context, _ string, orderID string) (OrderUpdate, error) {
+	return f[orderID], nil
+}
+
+func TestOrderTracker_StateMachine(t *testing.T) {
+	tr := NewOrderTracker(nil)
+	ctx := context.Background()
+	t0 := time.Unix(1700000000, 0)
+
+	if err := tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "1", Status: OrderStatusNew, EventTime: t0}); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "1", Status: OrderStatusPartiallyFilled, CumQty: 0.5, CumQuote: 50, EventTime: t0.Add(time.Second)}); err != nil {
+		t.Fatalf("partial: %v", err)
+	}
+	// 過期事件應被忽略
+	if err := tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "1", Status: OrderStatusNew, EventTime: t0}); err != nil {
+		t.Fatalf("stale: %v", err)
+	}
+	if o, _ := tr.Order("1"); o.Status != OrderStatusPartiallyFilled {
+		t.Fatalf("stale event applied: %s", o.Status)
+	}
+	if err := tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "1", Status: OrderStatusFilled, CumQty: 1, CumQuote: 110, EventTime: t0.Add(2 * time.Second)}); err != nil {
+		t.Fatalf("filled: %v", err)
+	}
+	if err := tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "1", Status: OrderStatusCanceled, CumQty: 1, EventTime: t0.Add(3 * time.Second)}); err == nil {
+		t.Fatalf("expected invalid transition from FILLED")
+	}
+	o, ok := tr.Order("1")
+	if !ok || o.Status != OrderStatusFilled || o.AvgPrice() != 110 {
+		t.Fatalf("unexpected order state: %+v", o)
+	}
+}
+
+func TestOrderTracker_ResyncAndReconcile(t *testing.T) {
+	repo := &positionRepo{positions: []tradingDomain.Position{
+		{ID: "p1", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 1.0, Status: "open"},
+		{ID: "p2", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, Size: 5.0, Status: "open"},
+		{ID: "p3", Symbol: "ETHUSDT", Env: tradingDomain.EnvProd, Size: 2.0, Status: "open"},
+	}}
+	tr := NewOrderTracker(repo, tradingDomain.EnvProd)
+	t0 := time.Unix(1700000000, 0)
+	tr.SetOrderLookup(fakeOrderLookup{"old": {OrderID: "old", ClientOrderID: ClientOrderPrefix + "1", Symbol: "BTCUSDT", Status: OrderStatusCanceled, EventTime: t0.Add(time.Minute)}})
+	ctx := context.Background()
+
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "old", Symbol: "BTCUSDT", Status: OrderStatusNew, EventTime: t0})
+	err := tr.Resync(ctx,
+		[]OrderUpdate{{OrderID: "live", Status: OrderStatusNew}},
+		[]BalanceUpdate{{Asset: "BTC", Free: 0.4, Locked: 0.1}, {Asset: "ETH", Free: 2}},
+	)
+	if err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+	if o, ok := tr.Order("old"); !ok || o.Status != OrderStatusCanceled {
+		t.Fatalf("expected order that ended during the outage to get its final status, got %+v", o)
+	}
+	if _, ok := tr.Order("live"); !ok {
+		t.Fatalf("expected snapshot order tracked")
+	}
+	if b, _ := tr.Balance("btc"); b.Total() != 0.5 {
+		t.Fatalf("unexpected BTC balance: %+v", b)
+	}
+	if len(repo.upserted) != 1 || repo.upserted[0].ID != "p1" || repo.upserted[0].Size != 0.5 {
+		t.Fatalf("expected only prod BTC position shrunk, got %+v", repo.upserted)
+	}
+}
+
+func TestOrderTracker_AppliesExternalFills(t *testing.T) {
+	repo := &positionRepo{positions: []tradingDomain.Position{
+		{ID: "s1", StrategyID: "st-1", Symbol: "ETHUSDT", Env: tradingDomain.EnvProd, Size: 2, EntryPrice: 2000, Status: "open"},
+	}}
+	tr := NewOrderTracker(repo, tradingDomain.EnvProd)
+	ctx := context.Background()
+	t0 := time.Unix(1700000000, 0)
+
+	// 手動買入分兩次成交：部分成交即開倉
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "m1", Symbol: "BTCUSDT", Side: "BUY", Status: OrderStatusPartiallyFilled, CumQty: 0.1, CumQuote: 3000, EventTime: t0})
+	if len(repo.upserted) != 1 || repo.upserted[0].Size != 0.1 || repo.upserted[0].StrategyID != "" {
+		t.Fatalf("expected manual position opened on first fill, got %+v", repo.upserted)
+	}
+	repo.positions = append(repo.positions, tradingDomain.Position{ID: "m", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 0.1, EntryPrice: 30000, Status: "open"})
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "m1", Symbol: "BTCUSDT", Side: "BUY", Status: OrderStatusFilled, CumQty: 0.3, CumQuote: 10000, EventTime: t0.Add(time.Second)})
+	last := repo.upserted[len(repo.upserted)-1]
+	if last.ID != "m" || math.Abs(last.Size-0.3) > 1e-12 || math.Abs(last.EntryPrice-10000/0.3) > 1e-6 {
+		t.Fatalf("expected remaining fill added at average cost, got %+v", last)
+	}
+
+	// 本系統下的單由下單流程自行更新持倉
+	before := len(repo.upserted)
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "a1", ClientOrderID: ClientOrderPrefix + "x", Symbol: "BTCUSDT", Side: "BUY", Status: OrderStatusFilled, CumQty: 1, CumQuote: 30000})
+	if len(repo.upserted) != before {
+		t.Fatalf("own orders must not be applied twice")
+	}
+
+	// 在交易所賣出全部 ETH 使策略持倉平倉
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "m2", Symbol: "ETHUSDT", Side: "SELL", Status: OrderStatusFilled, CumQty: 2, CumQuote: 4200})
+	if len(repo.closed) != 1 || repo.closed[0] != "s1" {
+		t.Fatalf("expected ETH position closed, got %v", repo.closed)
+	}
+}
+
+func TestOrderTracker_ResyncAppliesOrdersFinishedDuringOutage(t *testing.T) {
+	repo := &positionRepo{}
+	tr := NewOrderTracker(repo, tradingDomain.EnvProd)
+	ctx := context.Background()
+	t0 := time.Unix(1700000000, 0)
+	tr.SetOrderLookup(fakeOrderLookup{
+		"m1": {OrderID: "m1", Symbol: "BTCUSDT", Side: "BUY", Status: OrderStatusFilled, CumQty: 0.2, CumQuote: 6000, EventTime: t0.Add(time.Minute)},
+	})
+
+	// 資料流斷線前只收到掛單事件，期間在交易所成交
+	_ = tr.OnOrderUpdate(ctx, OrderUpdate{OrderID: "m1", Symbol: "BTCUSDT", Side: "BUY", Status: OrderStatusNew, EventTime: t0})
+	if err := tr.Resync(ctx, nil, nil); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+	if o, _ := tr.Order("m1"); o.Status != OrderStatusFilled {
+		t.Fatalf("expected final status from lookup, got %+v", o)
+	}
+	if len(repo.upserted) != 1 || repo.upserted[0].Size != 0.2 {
+		t.Fatalf("expected fill during outage applied, got %+v", repo.upserted)
+	}
+}
+
+func TestOrderTracker_ReconcileClosesSoldOutPosition(t *testing.T) {
+	repo := &positionRepo{positions: []tradingDomain.Position{
+		{ID: "p1", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 1.0, EntryPrice: 30000, Status: "open"},
+	}}
+	tr := NewOrderTracker(repo, tradingDomain.EnvProd)
+	if err := tr.OnBalanceUpdate(context.Background(), []BalanceUpdate{{Asset: "BTC"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.closed) != 1 || repo.closed[0] != "p1" || len(repo.upserted) != 0 {
+		t.Fatalf("expected position closed when balance is 0, closed=%v upserted=%+v", repo.closed, repo.upserted)
+	}
+}
+
+func TestOrderTracker_ReconcileComparesSummedSizePerAsset(t *testing.T) {
+	repo := &positionRepo{positions: []tradingDomain.Position{
+		{ID: "a", StrategyID: "st-a", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 1.0, Status: "open"},
+		{ID: "b", StrategyID: "st-b", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 3.0, Status: "open"},
+	}}
+	tr := NewOrderTracker(repo, tradingDomain.EnvProd)
+	ctx := context.Background()
+
+	// 餘額仍大於任一持倉，但少於合計：需偵測並依比例分攤
+	if err := tr.OnBalanceUpdate(ctx, []BalanceUpdate{{Asset: "BTC", Free: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.upserted) != 2 || repo.upserted[0].Size != 0.5 || repo.upserted[1].Size != 1.5 {
+		t.Fatalf("expected shortfall shared across both positions, got %+v", repo.upserted)
+	}
+
+	repo.upserted = nil
+	if err := tr.OnBalanceUpdate(ctx, []BalanceUpdate{{Asset: "BTC", Free: 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.upserted) != 0 || len(repo.closed) != 0 {
+		t.Fatalf("balance covering the total must not adjust, upserted=%+v closed=%v", repo.upserted, repo.closed)
+	}
+}
+
+func TestBaseAsset(t *testing.T) {
+	cases := map[string]string{"BTCUSDT": "BTC", "ethbtc": "ETH", "SOLFDUSD": "SOL", "USDT": "USDT"}
+	for in, want := range cases {
+		if got := BaseAsset(in); got != want {
+			t.Errorf("BaseAsset(%s)=%s want %s", in, got, want)
+		}
+	}
+}
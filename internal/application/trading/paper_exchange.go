@@ -0,0 +1,528 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ErrInsufficientFunds 代表模擬帳戶餘額不足以完成訂單。
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// PaperLedger 保存模擬帳戶各資產餘額，為多個實例共用的唯一來源；帳戶不存在時回傳空 map。
+type PaperLedger interface {
+	LoadPaperBalances(ctx context.Context, accountID string) (map[string]float64, error)
+	// OpenPaperAccount 寫入新帳戶的初始餘額，已存在的資產不變。
+	OpenPaperAccount(ctx context.Context, accountID string, initial map[string]float64) error
+	// ApplyPaperDeltas 在同一筆交易中鎖定並加減多個資產餘額；
+	// 任一資產會變為負數時整筆不變並回傳包裝 ErrInsufficientFunds 的錯誤。
+	ApplyPaperDeltas(ctx context.Context, accountID string, deltas map[string]float64) error
+}
+
+// PaperPositionSource 提供開戶時補記基礎資產所需的既有持倉與策略擁有者。
+type PaperPositionSource interface {
+	ListOpenPositions(ctx context.Context) ([]tradingDomain.Position, error)
+	LoadScoringStrategyByID(ctx context.Context, id string) (*strategyDomain.ScoringStrategy, error)
+}
+
+// PaperConfig 控制模擬成交的初始資金、手續費與滑價。
+type PaperConfig struct {
+	QuoteAsset     string  // 計價資產，預設 USDT
+	InitialBalance float64 // 新帳戶的計價資產初始餘額
+	FeeRate        float64 // taker 手續費率，例如 0.001 = 0.1%
+	SlippageBps    float64 // 固定滑價（基點）
+	ImpactBps      float64 // 每 10,000 計價單位名目額外增加的滑價（基點）
+}
+
+// DefaultPaperConfig 回傳與 Binance 現貨一般帳戶接近的設定。
+func DefaultPaperConfig() PaperConfig {
+	return PaperConfig{
+		QuoteAsset:     "USDT",
+		InitialBalance: 10000,
+		FeeRate:        0.001,
+		SlippageBps:    5,
+		ImpactBps:      1,
+	}
+}
+
+// fillPrice 依最新價與成交量套用固定滑價與市場衝擊，買單往上、賣單往下。
+func (c PaperConfig) fillPrice(last, qty float64, buy bool) float64 {
+	bps := c.SlippageBps + c.ImpactBps*(qty*last/10000)
+	if buy {
+		return last * (1 + bps/10000)
+	}
+	return last * (1 - bps/10000)
+}
+
+// paperBook 為所有模擬帳戶共用的狀態；餘額一律讀寫 ledger，不在程序內快取。
+type paperBook struct {
+	ledger    PaperLedger
+	positions PaperPositionSource
+	cfg       PaperConfig
+	mu        sync.Mutex
+	orders    map[string]OrderResponse
+	limits    map[string]paperLimit
+	seq       atomic.Int64
+}
+
+// paperLimit 為掛單中的限價單與其預先扣住的資金。
+type paperLimit struct {
+	account  string
+	base     string
+	quote    string
+	reserved map[string]float64
+}
+
+func (b *paperBook) nextOrderID() string {
+	return "paper-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(b.seq.Add(1), 10)
+}
+
+// PaperExchange 以真實行情模擬成交的 trading.Exchange：
+// 依設定計算滑價與手續費、維護每個使用者的虛擬餘額，餘額不足時拒單。
+type PaperExchange struct {
+	book    *paperBook
+	prices  Exchange
+	account string
+}
+
+// NewPaperExchange 建立模擬交易所；prices 提供最新成交價，ledger 為 nil 時餘額僅保存在本程序記憶體。
+func NewPaperExchange(prices Exchange, ledger PaperLedger, cfg PaperConfig) *PaperExchange {
+	def := DefaultPaperConfig()
+	if cfg.QuoteAsset == "" {
+		cfg.QuoteAsset = def.QuoteAsset
+	}
+	if cfg.InitialBalance <= 0 {
+		cfg.InitialBalance = def.InitialBalance
+	}
+	if ledger == nil {
+		ledger = newMemoryPaperLedger()
+	}
+	return &PaperExchange{
+		book: &paperBook{
+			ledger: ledger,
+			cfg:    cfg,
+			orders: make(map[string]OrderResponse),
+			limits: make(map[string]paperLimit),
+		},
+		prices: prices,
+	}
+}
+
+// For 回傳指定帳戶、以 prices 報價的模擬交易所，與原實例共用帳本。
+func (p *PaperExchange) For(accountID string, prices Exchange) *PaperExchange {
+	if prices == nil {
+		prices = p.prices
+	}
+	return &PaperExchange{book: p.book, prices: prices, account: accountID}
+}
+
+// Config 回傳目前的模擬設定。
+func (p *PaperExchange) Config() PaperConfig {
+	return p.book.cfg
+}
+
+// SetPositionSource 設定開戶時補記基礎資產的持倉來源：帳本建立前開出的模擬持倉
+// 依擁有者併入其新帳戶，之後才能正常賣出。
+func (p *PaperExchange) SetPositionSource(src PaperPositionSource) {
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	p.book.positions = src
+}
+
+// balances 自帳本讀取帳戶餘額（需持有 book.mu）；新帳戶以初始資金與既有模擬持倉開戶。
+func (p *PaperExchange) balances(ctx context.Context) (map[string]float64, error) {
+	b := p.book
+	bal, err := b.ledger.LoadPaperBalances(ctx, p.account)
+	if err != nil {
+		return nil, fmt.Errorf("load paper balances: %w", err)
+	}
+	if len(bal) > 0 {
+		return bal, nil
+	}
+	initial, err := p.openingBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.ledger.OpenPaperAccount(ctx, p.account, initial); err != nil {
+		return nil, fmt.Errorf("open paper account: %w", err)
+	}
+	// 其他實例可能同時開戶，以帳本內容為準
+	if bal, err = b.ledger.LoadPaperBalances(ctx, p.account); err != nil {
+		return nil, fmt.Errorf("load paper balances: %w", err)
+	}
+	return bal, nil
+}
+
+// openingBalances 回傳新帳戶的初始餘額：計價資產為設定的初始資金，
+// 基礎資產為此帳戶擁有的未平倉 paper 持倉數量（未記錄擁有者的舊手動持倉歸屬 admin）。
+func (p *PaperExchange) openingBalances(ctx context.Context) (map[string]float64, error) {
+	b := p.book
+	initial := map[string]float64{b.cfg.QuoteAsset: b.cfg.InitialBalance}
+	if b.positions == nil {
+		return initial, nil
+	}
+	positions, err := b.positions.ListOpenPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list paper positions: %w", err)
+	}
+	owners := map[string]string{}
+	for _, pos := range positions {
+		if pos.Env != tradingDomain.EnvPaper || pos.Size <= 0 {
+			continue
+		}
+		owner := pos.UserID
+		if owner == "" && isManualPosition(pos) {
+			owner = adminUserID
+		} else if owner == "" {
+			var ok bool
+			if owner, ok = owners[pos.StrategyID]; !ok {
+				if strat, err := b.positions.LoadScoringStrategyByID(ctx, pos.StrategyID); err == nil && strat != nil {
+					owner = strategyOwner(strat)
+				}
+				owners[pos.StrategyID] = owner
+			}
+		}
+		if owner != p.account {
+			continue
+		}
+		base, _ := p.assets(pos.Symbol)
+		initial[base] += pos.Size
+	}
+	return initial, nil
+}
+
+// apply 將餘額變動寫入帳本；帳本在同一筆交易中檢查餘額，多個實例同時下單也不會透支。
+func (p *PaperExchange) apply(ctx context.Context, deltas map[string]float64) error {
+	if err := p.book.ledger.ApplyPaperDeltas(ctx, p.account, deltas); err != nil {
+		return fmt.Errorf("save paper balance: %w", err)
+	}
+	return nil
+}
+
+func (p *PaperExchange) GetBalance(ctx context.Context, asset string) (float64, error) {
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	bal, err := p.balances(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return bal[strings.ToUpper(asset)], nil
+}
+
+// Balances 回傳帳戶所有資產餘額的副本。
+func (p *PaperExchange) Balances(ctx context.Context) (map[string]float64, error) {
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	bal, err := p.balances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(bal))
+	for asset, amount := range bal {
+		out[asset] = amount
+	}
+	return out, nil
+}
+
+// GetOrder 回傳訂單狀態；掛單中的限價單若最新價已觸及限價，會在此時以限價成交。
+func (p *PaperExchange) GetOrder(ctx context.Context, _ string, orderID string) (OrderResponse, error) {
+	p.book.mu.Lock()
+	o, ok := p.book.orders[orderID]
+	_, resting := p.book.limits[orderID]
+	p.book.mu.Unlock()
+	if !ok {
+		return OrderResponse{}, fmt.Errorf("paper order %s not found", orderID)
+	}
+	if !resting {
+		return o, nil
+	}
+	last, err := p.GetPrice(ctx, o.Symbol)
+	if err != nil || !limitCrossed(o, last) {
+		return o, nil // 報價失敗時維持掛單
+	}
+	return p.fillLimit(ctx, orderID)
+}
+
+// PlaceLimitOrder 掛出限價單並預先扣住資金：買單扣計價資產（含手續費）、賣單扣基礎資產。
+func (p *PaperExchange) PlaceLimitOrder(ctx context.Context, symbol, side string, qty, price float64) (OrderResponse, error) {
+	if qty <= 0 || price <= 0 {
+		return OrderResponse{}, fmt.Errorf("limit order quantity and price must be positive")
+	}
+	base, quote := p.assets(symbol)
+	reserve := map[string]float64{base: qty}
+	if isBuy(side) {
+		reserve = map[string]float64{quote: qty * price * (1 + p.book.cfg.FeeRate)}
+	}
+
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	bal, err := p.balances(ctx)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	deltas := make(map[string]float64, len(reserve))
+	for asset, amount := range reserve {
+		if bal[asset]+1e-9 < amount {
+			return OrderResponse{}, fmt.Errorf("%w: need %.8f %s, available %.8f", ErrInsufficientFunds, amount, asset, bal[asset])
+		}
+		deltas[asset] = -amount
+	}
+	if err := p.apply(ctx, deltas); err != nil {
+		return OrderResponse{}, err
+	}
+	id := p.book.nextOrderID()
+	o := OrderResponse{OrderID: id, Symbol: symbol, Side: strings.ToUpper(side), Price: price, Qty: qty, Status: OrderStatusNew}
+	p.book.orders[id] = o
+	p.book.limits[id] = paperLimit{account: p.account, base: base, quote: quote, reserved: reserve}
+	return o, nil
+}
+
+// RestoreLimitOrder 以原訂單編號還原重啟前的掛單；資金在掛單時已自帳本扣住，此處只登記不再扣款。
+func (p *PaperExchange) RestoreLimitOrder(_ context.Context, o OrderResponse) error {
+	if o.Qty <= 0 || o.Price <= 0 {
+		return fmt.Errorf("limit order quantity and price must be positive")
+	}
+	base, quote := p.assets(o.Symbol)
+	reserve := map[string]float64{base: o.Qty}
+	if isBuy(o.Side) {
+		reserve = map[string]float64{quote: o.Qty * o.Price * (1 + p.book.cfg.FeeRate)}
+	}
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	if _, ok := p.book.orders[o.OrderID]; ok {
+		return nil
+	}
+	o.Side = strings.ToUpper(o.Side)
+	o.Status = OrderStatusNew
+	p.book.orders[o.OrderID] = o
+	p.book.limits[o.OrderID] = paperLimit{account: p.account, base: base, quote: quote, reserved: reserve}
+	return nil
+}
+
+// CancelOrder 取消掛單並退回預先扣住的資金。
+func (p *PaperExchange) CancelOrder(ctx context.Context, _ string, orderID string) error {
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	lim, ok := p.book.limits[orderID]
+	if !ok {
+		return fmt.Errorf("paper order %s is not open", orderID)
+	}
+	acct := &PaperExchange{book: p.book, account: lim.account}
+	if err := acct.apply(ctx, lim.reserved); err != nil {
+		return err
+	}
+	o := p.book.orders[orderID]
+	o.Status = OrderStatusCanceled
+	p.book.orders[orderID] = o
+	delete(p.book.limits, orderID)
+	return nil
+}
+
+// fillLimit 以限價成交掛單：買單入帳基礎資產、賣單入帳扣除手續費後的計價資產。
+func (p *PaperExchange) fillLimit(ctx context.Context, orderID string) (OrderResponse, error) {
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	o := p.book.orders[orderID]
+	lim, ok := p.book.limits[orderID]
+	if !ok {
+		return o, nil // 已被其他呼叫成交或取消
+	}
+	acct := &PaperExchange{book: p.book, account: lim.account}
+	deltas := map[string]float64{lim.base: o.Qty}
+	if !isBuy(o.Side) {
+		notional := o.Qty * o.Price
+		deltas = map[string]float64{lim.quote: notional - notional*p.book.cfg.FeeRate}
+	}
+	if err := acct.apply(ctx, deltas); err != nil {
+		return o, err
+	}
+	o.Status = OrderStatusFilled
+	p.book.orders[orderID] = o
+	delete(p.book.limits, orderID)
+	return o, nil
+}
+
+// limitCrossed 判斷最新價是否觸及限價：買單價格跌到限價以下、賣單漲到限價以上。
+func limitCrossed(o OrderResponse, last float64) bool {
+	if last <= 0 {
+		return false
+	}
+	if isBuy(o.Side) {
+		return last <= o.Price
+	}
+	return last >= o.Price
+}
+
+// assets 拆出交易對的基礎與計價資產。
+func (p *PaperExchange) assets(symbol string) (string, string) {
+	base := BaseAsset(symbol)
+	quote := strings.TrimPrefix(strings.ToUpper(symbol), base)
+	if quote == "" {
+		quote = p.book.cfg.QuoteAsset
+	}
+	return base, quote
+}
+
+func (p *PaperExchange) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	if p.prices == nil {
+		return 0, fmt.Errorf("paper exchange has no price source")
+	}
+	return p.prices.GetPrice(ctx, symbol)
+}
+
+func (p *PaperExchange) PlaceMarketOrder(ctx context.Context, symbol, side string, qty float64) (float64, float64, error) {
+	if qty <= 0 {
+		return 0, 0, fmt.Errorf("order quantity must be positive")
+	}
+	return p.fill(ctx, symbol, side, func(price float64) float64 { return qty })
+}
+
+func (p *PaperExchange) PlaceMarketOrderQuote(ctx context.Context, symbol, side string, quoteAmount float64) (float64, float64, error) {
+	if quoteAmount <= 0 {
+		return 0, 0, fmt.Errorf("quote amount must be positive")
+	}
+	return p.fill(ctx, symbol, side, func(price float64) float64 {
+		if isBuy(side) {
+			// 手續費含在花費金額內：名目 + 手續費 = quoteAmount。
+			return quoteAmount / (1 + p.book.cfg.FeeRate) / price
+		}
+		return quoteAmount / price
+	})
+}
+
+// fill 以最新價加上滑價模擬一次完整成交，qtyAt 依成交價決定成交量。
+func (p *PaperExchange) fill(ctx context.Context, symbol, side string, qtyAt func(price float64) float64) (float64, float64, error) {
+	last, err := p.GetPrice(ctx, symbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("paper get price: %w", err)
+	}
+	if last <= 0 {
+		return 0, 0, fmt.Errorf("invalid price %.8f for %s", last, symbol)
+	}
+	cfg := p.book.cfg
+	buy := isBuy(side)
+
+	price := cfg.fillPrice(last, qtyAt(last), buy)
+	qty := qtyAt(price)
+	notional := qty * price
+	fee := notional * cfg.FeeRate
+
+	base, quote := p.assets(symbol)
+
+	p.book.mu.Lock()
+	defer p.book.mu.Unlock()
+	bal, err := p.balances(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var deltas map[string]float64
+	if buy {
+		cost := notional + fee
+		if bal[quote]+1e-9 < cost {
+			return 0, 0, fmt.Errorf("%w: need %.8f %s, available %.8f", ErrInsufficientFunds, cost, quote, bal[quote])
+		}
+		deltas = map[string]float64{quote: -cost, base: qty}
+	} else {
+		if bal[base]+1e-12 < qty {
+			return 0, 0, fmt.Errorf("%w: need %.8f %s, available %.8f", ErrInsufficientFunds, qty, base, bal[base])
+		}
+		deltas = map[string]float64{base: -qty, quote: notional - fee}
+	}
+	if err := p.apply(ctx, deltas); err != nil {
+		return 0, 0, err
+	}
+
+	id := p.book.nextOrderID()
+	p.book.orders[id] = OrderResponse{
+		OrderID: id,
+		Symbol:  symbol,
+		Side:    strings.ToUpper(side),
+		Price:   price,
+		Qty:     qty,
+		Status:  OrderStatusFilled,
+	}
+	return price, qty, nil
+}
+
+// memoryPaperLedger 為未設定帳本時使用的程序內帳本。
+type memoryPaperLedger struct {
+	mu       sync.Mutex
+	accounts map[string]map[string]float64
+}
+
+func newMemoryPaperLedger() *memoryPaperLedger {
+	return &memoryPaperLedger{accounts: make(map[string]map[string]float64)}
+}
+
+func (l *memoryPaperLedger) LoadPaperBalances(_ context.Context, accountID string) (map[string]float64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]float64, len(l.accounts[accountID]))
+	for asset, amount := range l.accounts[accountID] {
+		out[asset] = amount
+	}
+	return out, nil
+}
+
+func (l *memoryPaperLedger) OpenPaperAccount(_ context.Context, accountID string, initial map[string]float64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bal := l.accounts[accountID]
+	if bal == nil {
+		bal = make(map[string]float64, len(initial))
+		l.accounts[accountID] = bal
+	}
+	for asset, amount := range initial {
+		if _, ok := bal[asset]; !ok {
+			bal[asset] = amount
+		}
+	}
+	return nil
+}
+
+func (l *memoryPaperLedger) ApplyPaperDeltas(_ context.Context, accountID string, deltas map[string]float64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bal := l.accounts[accountID]
+	if err := checkPaperDeltas(bal, deltas); err != nil {
+		return err
+	}
+	if bal == nil {
+		bal = make(map[string]float64, len(deltas))
+		l.accounts[accountID] = bal
+	}
+	for asset, d := range deltas {
+		bal[asset] = math.Max(bal[asset]+d, 0)
+	}
+	return nil
+}
+
+// checkPaperDeltas 確認套用 deltas 後沒有資產變為負數（容許浮點誤差）。
+func checkPaperDeltas(bal, deltas map[string]float64) error {
+	for asset, d := range deltas {
+		if bal[asset]+d < -1e-9 {
+			return fmt.Errorf("%w: need %.8f %s, available %.8f", ErrInsufficientFunds, -d, asset, bal[asset])
+		}
+	}
+	return nil
+}
+
+func isBuy(side string) bool {
+	return strings.EqualFold(side, "buy")
+}
+
+var (
+	_ Exchange           = (*PaperExchange)(nil)
+	_ LimitOrderExchange = (*PaperExchange)(nil)
+	_ LimitOrderRestorer = (*PaperExchange)(nil)
+)
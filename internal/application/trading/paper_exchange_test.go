@@ -0,0 +1,235 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type memLedger struct {
+	balances map[string]map[string]float64
+	saves    int
+	failSave error
+}
+
+func (l *memLedger) LoadPaperBalances(_ context.Context, accountID string) (map[string]float64, error) {
+	out := map[string]float64{}
+	for k, v := range l.balances[accountID] {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (l *memLedger) OpenPaperAccount(_ context.Context, accountID string, initial map[string]float64) error {
+	if l.balances == nil {
+		l.balances = map[string]map[string]float64{}
+	}
+	if l.balances[accountID] == nil {
+		l.balances[accountID] = map[string]float64{}
+	}
+	for asset, amount := range initial {
+		if _, ok := l.balances[accountID][asset]; !ok {
+			l.balances[accountID][asset] = amount
+		}
+	}
+	return nil
+}
+
+func (l *memLedger) ApplyPaperDeltas(_ context.Context, accountID string, deltas map[string]float64) error {
+	if l.failSave != nil {
+		return l.failSave
+	}
+	if err := checkPaperDeltas(l.balances[accountID], deltas); err != nil {
+		return err
+	}
+	if l.balances == nil {
+		l.balances = map[string]map[string]float64{}
+	}
+	if l.balances[accountID] == nil {
+		l.balances[accountID] = map[string]float64{}
+	}
+	for asset, d := range deltas {
+		l.balances[accountID][asset] += d
+	}
+	l.saves++
+	return nil
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestPaperExchange_BuyAppliesSlippageAndFees(t *testing.T) {
+	ledger := &memLedger{}
+	paper := NewPaperExchange(&mockExchange{}, ledger, PaperConfig{InitialBalance: 1000, FeeRate: 0.001, SlippageBps: 10})
+	acct := paper.For("u1", nil)
+	ctx := context.Background()
+
+	price, qty, err := acct.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 500)
+	if err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if !approx(price, 50050) {
+		t.Fatalf("expected price with 10bps slippage, got %v", price)
+	}
+	if !approx(qty*price*1.001, 500) {
+		t.Fatalf("notional plus fee must equal quote spent, qty=%v", qty)
+	}
+	if usdt, _ := acct.GetBalance(ctx, "usdt"); !approx(usdt, 500) {
+		t.Fatalf("expected 500 USDT left, got %v", usdt)
+	}
+	if ledger.balances["u1"]["BTC"] != qty {
+		t.Fatalf("ledger not persisted: %+v", ledger.balances)
+	}
+
+	sellPrice, sold, err := acct.PlaceMarketOrder(ctx, "BTCUSDT", "sell", qty)
+	if err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	if !approx(sellPrice, 49950) || sold != qty {
+		t.Fatalf("unexpected sell fill %v %v", sellPrice, sold)
+	}
+	usdt, _ := acct.GetBalance(ctx, "USDT")
+	if want := 500 + qty*49950*0.999; !approx(usdt, want) {
+		t.Fatalf("expected %v USDT after round trip, got %v", want, usdt)
+	}
+	if usdt >= 1000 {
+		t.Fatalf("round trip must lose fees and slippage, got %v", usdt)
+	}
+}
+
+func TestPaperExchange_RejectsInsufficientFunds(t *testing.T) {
+	paper := NewPaperExchange(&mockExchange{}, nil, PaperConfig{InitialBalance: 100})
+	acct := paper.For("u1", nil)
+	ctx := context.Background()
+
+	if _, _, err := acct.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 150); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if _, _, err := acct.PlaceMarketOrder(ctx, "BTCUSDT", "sell", 0.01); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient base asset, got %v", err)
+	}
+	if usdt, _ := acct.GetBalance(ctx, "USDT"); usdt != 100 {
+		t.Fatalf("rejected orders must not change balance, got %v", usdt)
+	}
+}
+
+func TestPaperExchange_AccountsAreIsolatedAndReloaded(t *testing.T) {
+	ledger := &memLedger{balances: map[string]map[string]float64{"u2": {"USDT": 42}}}
+	paper := NewPaperExchange(&mockExchange{}, ledger, DefaultPaperConfig())
+	ctx := context.Background()
+
+	if v, _ := paper.For("u2", nil).GetBalance(ctx, "USDT"); v != 42 {
+		t.Fatalf("expected persisted balance, got %v", v)
+	}
+	if v, _ := paper.For("u3", nil).GetBalance(ctx, "USDT"); v != DefaultPaperConfig().InitialBalance {
+		t.Fatalf("expected new account to be funded, got %v", v)
+	}
+	if ledger.balances["u3"]["USDT"] != DefaultPaperConfig().InitialBalance {
+		t.Fatalf("new account must be persisted")
+	}
+
+	_, qty, err := paper.For("u3", nil).PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := paper.GetOrder(ctx, "BTCUSDT", "missing")
+	if err == nil {
+		t.Fatalf("expected unknown order error, got %+v", order)
+	}
+	if v, _ := paper.For("u2", nil).GetBalance(ctx, "BTC"); v != 0 || qty <= 0 {
+		t.Fatalf("accounts must be isolated, u2 BTC=%v", v)
+	}
+}
+
+func TestPaperExchange_FillIsSavedInOneWrite(t *testing.T) {
+	ledger := &memLedger{balances: map[string]map[string]float64{"u1": {"USDT": 1000}}}
+	acct := NewPaperExchange(&mockExchange{}, ledger, DefaultPaperConfig()).For("u1", nil)
+	ctx := context.Background()
+
+	_, qty, err := acct.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ledger.saves != 1 || ledger.balances["u1"]["BTC"] != qty || !approx(ledger.balances["u1"]["USDT"], 900) {
+		t.Fatalf("expected both assets in a single save, saves=%d balances=%+v", ledger.saves, ledger.balances)
+	}
+
+	ledger.failSave = errors.New("db down")
+	if _, _, err := acct.PlaceMarketOrder(ctx, "BTCUSDT", "sell", qty); err == nil {
+		t.Fatal("expected save error")
+	}
+	if v, _ := acct.GetBalance(ctx, "BTC"); v != qty {
+		t.Fatalf("failed save must leave balances unchanged, BTC=%v", v)
+	}
+	if v, _ := acct.GetBalance(ctx, "USDT"); !approx(v, 900) {
+		t.Fatalf("failed save must leave balances unchanged, USDT=%v", v)
+	}
+}
+
+func TestPaperExchange_ReplicasShareTheLedger(t *testing.T) {
+	ledger := &memLedger{balances: map[string]map[string]float64{"u1": {"USDT": 1000}}}
+	a := NewPaperExchange(&mockExchange{}, ledger, DefaultPaperConfig()).For("u1", nil)
+	b := NewPaperExchange(&mockExchange{}, ledger, DefaultPaperConfig()).For("u1", nil)
+	ctx := context.Background()
+
+	_, qa, err := a.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, qb, err := b.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := a.GetBalance(ctx, "USDT"); !approx(v, 400) {
+		t.Fatalf("instances must not overwrite each other's spending, USDT=%v", v)
+	}
+	if v, _ := b.GetBalance(ctx, "BTC"); !approx(v, qa+qb) {
+		t.Fatalf("expected both fills in the ledger, BTC=%v want %v", v, qa+qb)
+	}
+	if _, _, err := a.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 500); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected balance spent by the other instance to be seen, got %v", err)
+	}
+}
+
+type paperPositions struct {
+	positions []tradingDomain.Position
+	owners    map[string]string
+}
+
+func (p paperPositions) ListOpenPositions(context.Context) ([]tradingDomain.Position, error) {
+	return p.positions, nil
+}
+
+func (p paperPositions) LoadScoringStrategyByID(_ context.Context, id string) (*strategyDomain.ScoringStrategy, error) {
+	return &strategyDomain.ScoringStrategy{ID: id, UserID: p.owners[id]}, nil
+}
+
+func TestPaperExchange_OpensAccountWithExistingPositions(t *testing.T) {
+	ledger := &memLedger{}
+	paper := NewPaperExchange(&mockExchange{}, ledger, PaperConfig{InitialBalance: 1000})
+	paper.SetPositionSource(paperPositions{
+		positions: []tradingDomain.Position{
+			{ID: "p1", StrategyID: "st-1", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, Size: 0.01, Status: "open"},
+			{ID: "p2", StrategyID: "manual", UserID: "u1", Symbol: "ETHUSDT", Env: tradingDomain.EnvPaper, Size: 2, Status: "open"},
+			{ID: "p3", StrategyID: "st-2", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, Size: 5, Status: "open"},
+			{ID: "p4", StrategyID: "st-1", Symbol: "BTCUSDT", Env: tradingDomain.EnvProd, Size: 7, Status: "open"},
+		},
+		owners: map[string]string{"st-1": "u1", "st-2": "u2"},
+	})
+	acct := paper.For("u1", nil)
+	ctx := context.Background()
+
+	bal, err := acct.Balances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bal["USDT"] != 1000 || bal["BTC"] != 0.01 || bal["ETH"] != 2 {
+		t.Fatalf("expected u1's paper positions seeded into the new account, got %+v", bal)
+	}
+	if _, _, err := acct.PlaceMarketOrder(ctx, "BTCUSDT", "sell", 0.01); err != nil {
+		t.Fatalf("position opened before the ledger must be sellable: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ErrRiskRejected 為所有風控拒單的共同錯誤，可用 errors.Is 判斷。
+var ErrRiskRejected = errors.New("order rejected by risk check")
+
+// 風控規則名稱。
+const (
+	RiskRuleMaxOrderNotional = "max_order_notional"
+	RiskRuleMaxAssetExposure = "max_asset_exposure"
+	RiskRuleMaxDailyLoss     = "max_daily_loss"
+	RiskRuleMaxPositions     = "max_positions"
+	RiskRulePriceDeviation   = "price_deviation"
+)
+
+// RiskLimits 為全域下單前風控上限，零值代表不限制。
+type RiskLimits struct {
+	MaxOrderNotional     float64 // 單筆最大名目（計價資產）
+	MaxAssetExposure     float64 // 單一資產所有持倉加上本單的最大名目
+	MaxDailyLoss         float64 // 同一環境當日已實現虧損上限（正數）
+	MaxOpenPositions     int     // 每位使用者同時持倉上限
+	MaxPriceDeviationPct float64 // 訊號價與最新成交價最大偏離（0.05 = 5%）
+}
+
+// OrderIntent 描述一筆待送出的訂單，供風控檢查。
+type OrderIntent struct {
+	StrategyID string // manual 或空值代表手動下單
+	UserID     string
+	Env        tradingDomain.Environment
+	Symbol     string
+	Side       string
+	Notional   float64                    // 預估名目（計價資產）
+	RefPrice   float64                    // 訊號參考價，0 代表不檢查偏離
+	Risk       tradingDomain.RiskSettings // 策略層級設定（MaxPositions、MaxDailyLossPct）
+}
+
+// RiskRejection 為單一規則的拒單原因。
+type RiskRejection struct {
+	Rule   string
+	Reason string
+}
+
+func (r *RiskRejection) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrRiskRejected.Error(), r.Reason, r.Rule)
+}
+
+func (r *RiskRejection) Unwrap() error { return ErrRiskRejected }
+
+// RiskEngine 在每筆訂單送出前檢查全域與策略層級限制。
+// 賣出（平倉）一律放行，避免風控阻擋停損。
+type RiskEngine struct {
+	repo   Repository
+	limits RiskLimits
+	now    func() time.Time
+
+	mu    sync.Mutex
+	envMu map[tradingDomain.Environment]chan struct{}
+}
+
+// NewRiskEngine 建立風控引擎。
+func NewRiskEngine(repo Repository, limits RiskLimits) *RiskEngine {
+	return &RiskEngine{repo: repo, limits: limits, now: time.Now, envMu: make(map[tradingDomain.Environment]chan struct{})}
+}
+
+// lockEnv 取得環境的買單鎖，回傳釋放函式；ctx 結束前仍未取得時回傳錯誤。
+func (e *RiskEngine) lockEnv(ctx context.Context, env tradingDomain.Environment) (func(), error) {
+	e.mu.Lock()
+	ch, ok := e.envMu[env]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		e.envMu[env] = ch
+	}
+	e.mu.Unlock()
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Limits 回傳目前的全域限制。
+func (e *RiskEngine) Limits() RiskLimits {
+	return e.limits
+}
+
+// Check 依序檢查所有規則，第一個違反的規則以 *RiskRejection 回傳。
+func (e *RiskEngine) Check(ctx context.Context, intent OrderIntent, ex Exchange) error {
+	if !isBuy(intent.Side) {
+		return nil
+	}
+	last, err := e.checkPriceDeviation(ctx, intent, ex)
+	if err != nil {
+		return err
+	}
+	if e.limits.MaxOrderNotional > 0 && intent.Notional > e.limits.MaxOrderNotional {
+		return &RiskRejection{Rule: RiskRuleMaxOrderNotional, Reason: fmt.Sprintf("notional %.2f exceeds limit %.2f", intent.Notional, e.limits.MaxOrderNotional)}
+	}
+
+	positions, err := e.openPositions(ctx, intent.Env)
+	if err != nil {
+		return err
+	}
+	if err := e.checkExposure(intent, positions, last); err != nil {
+		return err
+	}
+	if err := e.checkPositions(ctx, intent, positions); err != nil {
+		return err
+	}
+	return e.checkDailyLoss(ctx, intent)
+}
+
+// checkPriceDeviation 比對訊號價與最新成交價（fat-finger 保護），回傳最新價供後續使用。
+func (e *RiskEngine) checkPriceDeviation(ctx context.Context, intent OrderIntent, ex Exchange) (float64, error) {
+	if e.limits.MaxPriceDeviationPct <= 0 || intent.RefPrice <= 0 || ex == nil {
+		return 0, nil
+	}
+	last, err := ex.GetPrice(ctx, intent.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("risk check get price: %w", err)
+	}
+	if last <= 0 {
+		return 0, &RiskRejection{Rule: RiskRulePriceDeviation, Reason: fmt.Sprintf("invalid last price %.8f", last)}
+	}
+	dev := math.Abs(last-intent.RefPrice) / intent.RefPrice
+	if dev > e.limits.MaxPriceDeviationPct {
+		return 0, &RiskRejection{Rule: RiskRulePriceDeviation, Reason: fmt.Sprintf("last price %.2f deviates %.2f%% from signal %.2f", last, dev*100, intent.RefPrice)}
+	}
+	return last, nil
+}
+
+func (e *RiskEngine) openPositions(ctx context.Context, env tradingDomain.Environment) ([]tradingDomain.Position, error) {
+	all, err := e.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("risk check list positions: %w", err)
+	}
+	out := make([]tradingDomain.Position, 0, len(all))
+	for _, p := range all {
+		if p.Env == env && p.Status == "open" {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// checkExposure 以進場價估算同一基礎資產的既有持倉名目。
+func (e *RiskEngine) checkExposure(intent OrderIntent, positions []tradingDomain.Position, last float64) error {
+	if e.limits.MaxAssetExposure <= 0 {
+		return nil
+	}
+	base := BaseAsset(intent.Symbol)
+	exposure := intent.Notional
+	for _, p := range positions {
+		if BaseAsset(p.Symbol) != base {
+			continue
+		}
+		price := p.EntryPrice
+		if last > 0 && strings.EqualFold(p.Symbol, intent.Symbol) {
+			price = last
+		}
+		exposure += p.Size * price
+	}
+	if exposure > e.limits.MaxAssetExposure {
+		return &RiskRejection{Rule: RiskRuleMaxAssetExposure, Reason: fmt.Sprintf("%s exposure %.2f exceeds limit %.2f", base, exposure, e.limits.MaxAssetExposure)}
+	}
+	return nil
+}
+
+// checkPositions 檢查策略層級 MaxPositions 與使用者層級 MaxOpenPositions。
+func (e *RiskEngine) checkPositions(ctx context.Context, intent OrderIntent, positions []tradingDomain.Position) error {
+	manual := intent.StrategyID == "" || intent.StrategyID == "manual"
+	if !manual && intent.Risk.MaxPositions > 0 {
+		count := 0
+		for _, p := range positions {
+			if p.StrategyID == intent.StrategyID {
+				count++
+			}
+		}
+		if count >= intent.Risk.MaxPositions {
+			return &RiskRejection{Rule: RiskRuleMaxPositions, Reason: fmt.Sprintf("strategy has %d open positions (limit %d)", count, intent.Risk.MaxPositions)}
+		}
+	}
+	if e.limits.MaxOpenPositions <= 0 || intent.UserID == "" {
+		return nil
+	}
+	owners := map[string]string{}
+	count := 0
+	for _, p := range positions {
+		// 未記錄擁有者的舊策略持倉以策略擁有者計算；未記錄擁有者的舊手動持倉無從歸屬
+		owner := p.UserID
+		if owner == "" && !isManualPosition(p) {
+			var ok bool
+			if owner, ok = owners[p.StrategyID]; !ok {
+				if strat, err := e.repo.LoadScoringStrategyByID(ctx, p.StrategyID); err == nil && strat != nil {
+					owner = strategyOwner(strat)
+				}
+				owners[p.StrategyID] = owner
+			}
+		}
+		if owner == intent.UserID {
+			count++
+		}
+	}
+	if count >= e.limits.MaxOpenPositions {
+		return &RiskRejection{Rule: RiskRuleMaxPositions, Reason: fmt.Sprintf("user has %d open positions (limit %d)", count, e.limits.MaxOpenPositions)}
+	}
+	return nil
+}
+
+// isManualPosition 判斷持倉是否為手動下單（不屬於任何策略）。
+func isManualPosition(p tradingDomain.Position) bool {
+	return p.StrategyID == "" || p.StrategyID == "manual"
+}
+
+// checkDailyLoss 加總當日（UTC）平倉的已實現損益。
+// 策略層級的 MaxDailyLossPct 以策略單筆下單金額為基準。
+func (e *RiskEngine) checkDailyLoss(ctx context.Context, intent OrderIntent) error {
+	stratPct := 0.0
+	if intent.Risk.MaxDailyLossPct != nil {
+		stratPct = math.Abs(*intent.Risk.MaxDailyLossPct)
+		if stratPct > 1 {
+			stratPct /= 100
+		}
+	}
+	if e.limits.MaxDailyLoss <= 0 && stratPct <= 0 {
+		return nil
+	}
+	now := e.now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	pnl, err := e.repo.SumRealizedPnL(ctx, intent.Env, dayStart)
+	if err != nil {
+		return fmt.Errorf("risk check realised pnl: %w", err)
+	}
+	var total float64
+	for _, v := range pnl {
+		total += v
+	}
+	strategy := pnl[intent.StrategyID]
+	if e.limits.MaxDailyLoss > 0 && -total >= e.limits.MaxDailyLoss {
+		return &RiskRejection{Rule: RiskRuleMaxDailyLoss, Reason: fmt.Sprintf("daily realised loss %.2f reached limit %.2f", -total, e.limits.MaxDailyLoss)}
+	}
+	if stratPct > 0 && intent.Risk.OrderSizeValue > 0 {
+		limit := stratPct * intent.Risk.OrderSizeValue
+		if -strategy >= limit {
+			return &RiskRejection{Rule: RiskRuleMaxDailyLoss, Reason: fmt.Sprintf("strategy daily realised loss %.2f reached limit %.2f", -strategy, limit)}
+		}
+	}
+	return nil
+}
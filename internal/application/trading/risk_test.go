@@ -0,0 +1,181 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+type riskRepo struct {
+	feedRepo
+	logs []tradingDomain.LogEntry
+}
+
+func (r *riskRepo) SaveLog(_ context.Context, l tradingDomain.LogEntry) error {
+	r.logs = append(r.logs, l)
+	return nil
+}
+
+type recordingNotifier struct{ msgs []string }
+
+func (n *recordingNotifier) Notify(msg string) error {
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+func rejectionRule(t *testing.T, err error) string {
+	t.Helper()
+	var rej *RiskRejection
+	if !errors.As(err, &rej) || !errors.Is(err, ErrRiskRejected) {
+		t.Fatalf("expected risk rejection, got %v", err)
+	}
+	return rej.Rule
+}
+
+func TestRiskEngine_Rules(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	loss := -60.0
+	yesterday := now.Add(-24 * time.Hour)
+	repo := &feedRepo{
+		positionRepo: positionRepo{positions: []tradingDomain.Position{
+			{ID: "p1", StrategyID: "strat-1", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, EntryPrice: 50000, Size: 0.02, Status: "open"},
+			{ID: "p2", StrategyID: "strat-2", Symbol: "ETHUSDT", Env: tradingDomain.EnvProd, EntryPrice: 3000, Size: 1, Status: "open"},
+		}},
+		strat: &strategyDomain.ScoringStrategy{ID: "strat-1", UserID: "u1"},
+	}
+	repo.trades = []tradingDomain.TradeRecord{
+		{StrategyID: "strat-1", Env: tradingDomain.EnvPaper, PNL: &loss, ExitDate: &now},
+		{StrategyID: "strat-1", Env: tradingDomain.EnvPaper, PNL: &loss, ExitDate: &yesterday},
+	}
+	buy := OrderIntent{StrategyID: "strat-3", UserID: "u2", Env: tradingDomain.EnvPaper, Symbol: "BTCUSDT", Side: "buy", Notional: 100}
+
+	check := func(limits RiskLimits, intent OrderIntent) error {
+		e := NewRiskEngine(repo, limits)
+		e.now = func() time.Time { return now }
+		return e.Check(ctx, intent, &mockExchange{})
+	}
+
+	if err := check(RiskLimits{MaxOrderNotional: 50}, buy); rejectionRule(t, err) != RiskRuleMaxOrderNotional {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	// 既有 0.02 BTC 以最新價 50000 計 = 1000，加上本單 100
+	if err := check(RiskLimits{MaxAssetExposure: 1050}, buy); rejectionRule(t, err) != RiskRuleMaxAssetExposure {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	if err := check(RiskLimits{MaxAssetExposure: 1200}, buy); err != nil {
+		t.Fatalf("exposure within limit: %v", err)
+	}
+	if err := check(RiskLimits{MaxDailyLoss: 50}, buy); rejectionRule(t, err) != RiskRuleMaxDailyLoss {
+		t.Fatalf("only today's realised loss (60) should count: %v", err)
+	}
+	if err := check(RiskLimits{MaxDailyLoss: 100}, buy); err != nil {
+		t.Fatalf("yesterday's loss must not count: %v", err)
+	}
+	pct := 5.0 // 5% of 1000 order size
+	stratBuy := buy
+	stratBuy.StrategyID = "strat-1"
+	stratBuy.Risk = tradingDomain.RiskSettings{OrderSizeValue: 1000, MaxDailyLossPct: &pct}
+	if err := check(RiskLimits{}, stratBuy); rejectionRule(t, err) != RiskRuleMaxDailyLoss {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	stratBuy.Risk = tradingDomain.RiskSettings{MaxPositions: 1}
+	if err := check(RiskLimits{}, stratBuy); rejectionRule(t, err) != RiskRuleMaxPositions {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	userBuy := buy
+	userBuy.UserID = "u1"
+	if err := check(RiskLimits{MaxOpenPositions: 1}, userBuy); rejectionRule(t, err) != RiskRuleMaxPositions {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	if err := check(RiskLimits{MaxOpenPositions: 1}, buy); err != nil {
+		t.Fatalf("other user's positions must not count: %v", err)
+	}
+	// 手動持倉依擁有者計入使用者上限
+	repo.positions = append(repo.positions, tradingDomain.Position{ID: "p3", StrategyID: "manual", UserID: "u2", Symbol: "SOLUSDT", Env: tradingDomain.EnvPaper, EntryPrice: 100, Size: 1, Status: "open"})
+	manualBuy := buy
+	manualBuy.StrategyID = "manual"
+	if err := check(RiskLimits{MaxOpenPositions: 1}, manualBuy); rejectionRule(t, err) != RiskRuleMaxPositions {
+		t.Fatalf("manual positions must count toward the owner's limit: %v", err)
+	}
+	if err := check(RiskLimits{MaxOpenPositions: 2}, manualBuy); err != nil {
+		t.Fatalf("u1's strategy position must not count for u2: %v", err)
+	}
+	repo.positions = repo.positions[:2]
+	fat := buy
+	fat.RefPrice = 40000 // mockExchange 報價 50000，偏離 25%
+	if err := check(RiskLimits{MaxPriceDeviationPct: 0.05}, fat); rejectionRule(t, err) != RiskRulePriceDeviation {
+		t.Fatalf("unexpected rule for %v", err)
+	}
+	sell := fat
+	sell.Side = "sell"
+	if err := check(RiskLimits{MaxOrderNotional: 1, MaxPriceDeviationPct: 0.05}, sell); err != nil {
+		t.Fatalf("exits must never be blocked: %v", err)
+	}
+}
+
+func TestService_BuyRiskChecksSerialisedPerEnv(t *testing.T) {
+	svc := NewService(&fakeRepo{}, dummyDataProvider{}, &mockExchange{}, nil)
+	ctx := context.Background()
+	buy := OrderIntent{StrategyID: "s1", Env: tradingDomain.EnvPaper, Symbol: "BTCUSDT", Side: "buy", Notional: 10}
+
+	release, err := svc.checkRisk(ctx, buy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同環境的下一筆買單要等前一筆寫完持倉
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := svc.checkRisk(waitCtx, buy, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected second buy to wait, got %v", err)
+	}
+	other := buy
+	other.Env = tradingDomain.EnvProd
+	releaseOther, err := svc.checkRisk(ctx, other, nil)
+	if err != nil {
+		t.Fatalf("other env must not wait: %v", err)
+	}
+	releaseOther()
+	sell := buy
+	sell.Side = "sell"
+	if _, err := svc.checkRisk(ctx, sell, nil); err != nil {
+		t.Fatalf("exits must not wait for buys: %v", err)
+	}
+
+	release()
+	release, err = svc.checkRisk(ctx, buy, nil)
+	if err != nil {
+		t.Fatalf("buy after release: %v", err)
+	}
+	release()
+}
+
+func TestService_RiskRejectionIsLoggedAndNotified(t *testing.T) {
+	day1 := time.Now().Add(-time.Hour)
+	repo := &riskRepo{}
+	noty := &recordingNotifier{}
+	svc := NewService(repo, stubDataProvider{history: []analysisDomain.DailyAnalysisResult{{TradeDate: day1, Close: 50000, Score: 75}}}, &mockExchange{}, noty)
+	svc.SetRiskLimits(RiskLimits{MaxOrderNotional: 10})
+
+	err := svc.ExecuteScoringAutoTrade(context.Background(), "alpha", tradingDomain.EnvPaper, "u1")
+	if !errors.Is(err, ErrRiskRejected) {
+		t.Fatalf("expected risk rejection, got %v", err)
+	}
+	if repo.upsertPositionCalled != 0 {
+		t.Fatal("rejected order must not open a position")
+	}
+	var logged bool
+	for _, l := range repo.logs {
+		if l.Phase == "risk" {
+			logged = true
+		}
+	}
+	if !logged || len(noty.msgs) == 0 {
+		t.Fatalf("expected rejection to be logged and notified, logs=%+v msgs=%v", repo.logs, noty.msgs)
+	}
+}
@@ -0,0 +1,62 @@
+package trading
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai-auto-trade/internal/application/scheduler"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+)
+
+// StrategyTasks 將啟用中的策略轉為排程工作：預設在策略 Timeframe 的每根 K 線收盤時評估（UTC 對齊），
+// 策略設定 Schedule（cron）時改用自訂排程；策略未設定交易時段時套用 defaultWindow。
+// loc 只用於自訂 cron 與交易時段。
+// 排程或時段格式錯誤的策略會被略過並記錄。
+func StrategyTasks(svc *Service, loc *time.Location, defaultWindow scheduler.TradingWindow) scheduler.TaskSource {
+	return func(ctx context.Context) ([]scheduler.Task, error) {
+		strats, err := svc.repo.ListActiveScoringStrategies(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tasks := make([]scheduler.Task, 0, len(strats))
+		for _, strat := range strats {
+			task, err := strategyTask(svc, strat, loc, defaultWindow)
+			if err != nil {
+				log.Printf("[Scheduler] skip strategy %s: %v", strat.Slug, err)
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, nil
+	}
+}
+
+func strategyTask(svc *Service, strat *strategyDomain.ScoringStrategy, loc *time.Location, defaultWindow scheduler.TradingWindow) (scheduler.Task, error) {
+	var (
+		sched scheduler.Schedule
+		err   error
+	)
+	if strat.Schedule != "" {
+		sched, err = scheduler.ParseCron(strat.Schedule, loc)
+	} else {
+		sched, err = scheduler.TimeframeSchedule(strat.Timeframe)
+	}
+	if err != nil {
+		return scheduler.Task{}, err
+	}
+	window := defaultWindow
+	if strat.TradingWindow != "" {
+		if window, err = scheduler.ParseTradingWindow(strat.TradingWindow, loc); err != nil {
+			return scheduler.Task{}, err
+		}
+	}
+	return scheduler.Task{
+		Name:     "strategy:" + strat.Slug,
+		Schedule: sched,
+		Window:   window,
+		Run: func(ctx context.Context, _ time.Time) error {
+			return svc.ExecuteStrategy(ctx, strat)
+		},
+	}, nil
+}
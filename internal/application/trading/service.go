@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"log"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"ai-auto-trade/internal/application/analysis"
@@ -36,6 +38,8 @@ type Repository interface {
 
 	SaveTrade(ctx context.Context, trade tradingDomain.TradeRecord) error
 	ListTrades(ctx context.Context, filter tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error)
+	// SumRealizedPnL 加總 env 內出場時間不早於 since 的已實現損益（不限筆數），依策略 ID 分組；手動交易歸在 "manual"。
+	SumRealizedPnL(ctx context.Context, env tradingDomain.Environment, since time.Time) (map[string]float64, error)
 	GetOpenPosition(ctx context.Context, strategyID string, env tradingDomain.Environment) (*tradingDomain.Position, error)
 	GetPosition(ctx context.Context, id string) (*tradingDomain.Position, error)
 	ListOpenPositions(ctx context.Context) ([]tradingDomain.Position, error)
@@ -75,6 +79,11 @@ type Exchange interface {
 	GetBalance(ctx context.Context, asset string) (float64, error)
 }
 
+// ExchangeResolver 依名稱取得交易所轉接器（策略可指定 binance、bybit...）。
+type ExchangeResolver interface {
+	Exchange(name string) (Exchange, error)
+}
+
 // Notifier 傳送外部通知。
 type Notifier interface {
 	Notify(msg string) error
@@ -89,22 +98,59 @@ type StrategyFilter struct {
 
 // Service 聚合策略 CRUD、回測與執行。
 type Service struct {
-	repo Repository
-	data MarketDataProvider
-	ex   Exchange
-	noty Notifier
-	now  func() time.Time
+	repo   Repository
+	data   MarketDataProvider
+	ex     Exchange
+	exs    ExchangeResolver
+	paper  *PaperExchange
+	shadow *ShadowExchange
+	risk   *RiskEngine
+	halts  *CircuitBreakers
+	exec   *StrategyExecutor
+	grids  GridStore
+	dq     DataQualityGuard
+	bars   BarRecorder
+	noty   Notifier
+	now    func() time.Time
+
+	orderMu  sync.Mutex
+	orderWG  sync.WaitGroup
+	draining bool
 }
 
+// ErrShuttingDown 代表服務正在關閉，不再送出新訂單。
+var ErrShuttingDown = errors.New("trading service is shutting down")
+
 // NewService 建立服務。
 func NewService(repo Repository, data MarketDataProvider, ex Exchange, noty Notifier) *Service {
-	return &Service{
-		repo: repo,
-		data: data,
-		ex:   ex,
-		noty: noty,
-		now:  time.Now,
-	}
+	ledger, _ := repo.(PaperLedger)
+	store, _ := repo.(HaltStore)
+	grids, _ := repo.(GridStore)
+	s := &Service{
+		repo:   repo,
+		data:   data,
+		ex:     ex,
+		paper:  NewPaperExchange(ex, ledger, DefaultPaperConfig()),
+		shadow: NewShadowExchange(ex, DefaultPaperConfig()),
+		risk:   NewRiskEngine(repo, RiskLimits{}),
+		halts:  NewCircuitBreakers(store, BreakerConfig{}),
+		grids:  grids,
+		noty:   noty,
+		now:    time.Now,
+	}
+	s.paper.SetPositionSource(repo)
+	s.exec = NewStrategyExecutor(s, ExecutorConfig{})
+	return s
+}
+
+// SetExecutorConfig 設定策略並行度與單次執行期限。
+func (s *Service) SetExecutorConfig(cfg ExecutorConfig) {
+	s.exec = NewStrategyExecutor(s, cfg)
+}
+
+// ExecutorStats 回傳策略執行指標。
+func (s *Service) ExecutorStats() ExecutorStats {
+	return s.exec.Stats()
 }
 
 func (s *Service) notify(msg string) {
@@ -119,6 +165,8 @@ func (s *Service) envTag(env tradingDomain.Environment) string {
 		return "【模擬】"
 	case tradingDomain.EnvTest:
 		return "【測試】"
+	case tradingDomain.EnvShadow:
+		return "【影子】"
 	case tradingDomain.EnvProd, tradingDomain.EnvReal:
 		return "【實盤】"
 	default:
@@ -205,9 +253,245 @@ func (s *Service) UpdateStrategy(ctx context.Context, id string, input tradingDo
 	return current, nil
 }
 
+// SetExchangeResolver 設定多交易所解析器；未設定時所有策略使用預設交易所。
+func (s *Service) SetExchangeResolver(r ExchangeResolver) {
+	s.exs = r
+}
+
+// SetPaperExchange 替換模擬交易所（例如自訂手續費與滑價）；影子模式沿用相同的成交模型。
+func (s *Service) SetPaperExchange(p *PaperExchange) {
+	p.SetPositionSource(s.repo)
+	s.paper = p
+	s.shadow = NewShadowExchange(p.prices, p.Config())
+}
+
+// SetRiskLimits 設定全域下單前風控上限。
+func (s *Service) SetRiskLimits(limits RiskLimits) {
+	s.risk = NewRiskEngine(s.repo, limits)
+}
+
+// beginOrder 登記一筆進行中的下單並回傳不隨呼叫端取消的 ctx：
+// 訂單一旦送出，成交記錄與持倉都必須寫完，避免關機或串流重連時留下未知狀態。
+// 服務關閉中時回傳 ErrShuttingDown。
+func (s *Service) beginOrder(ctx context.Context) (context.Context, func(), error) {
+	s.orderMu.Lock()
+	defer s.orderMu.Unlock()
+	if s.draining {
+		return ctx, func() {}, ErrShuttingDown
+	}
+	if err := ctx.Err(); err != nil {
+		return ctx, func() {}, err
+	}
+	s.orderWG.Add(1)
+	return context.WithoutCancel(ctx), s.orderWG.Done, nil
+}
+
+// Drain 停止接受新訂單並等待進行中的下單完成；ctx 到期時回傳錯誤。
+func (s *Service) Drain(ctx context.Context) error {
+	s.orderMu.Lock()
+	s.draining = true
+	s.orderMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.orderWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for in-flight orders: %w", ctx.Err())
+	}
+}
+
+// SetCircuitBreakers 替換 kill switch 與熔斷管理器（例如載入持久化狀態後的實例）。
+func (s *Service) SetCircuitBreakers(b *CircuitBreakers) {
+	s.halts = b
+}
+
+// ReloadHaltStates 重新從 store 載入 kill switch 與熔斷狀態（例如取得領導權時）。
+func (s *Service) ReloadHaltStates(ctx context.Context) error {
+	return s.halts.Load(ctx)
+}
+
+// HaltStatus 回傳 kill switch 與熔斷中策略，供 /api/health 顯示。
+func (s *Service) HaltStatus() BreakerStatus {
+	return s.halts.Status()
+}
+
+// EngageKillSwitch 停止所有新進場單；flatten 時以 userID 身分平掉所有未平倉部位。
+// 平倉失敗不會中止流程，回傳已平倉筆數與第一個錯誤。
+func (s *Service) EngageKillSwitch(ctx context.Context, reason string, flatten bool, userID string) (int, error) {
+	if reason == "" {
+		reason = "manual"
+	}
+	if err := s.halts.Engage(ctx, reason); err != nil {
+		return 0, err
+	}
+	log.Printf("[KILL-SWITCH] engaged: %s (flatten=%v)", reason, flatten)
+	s.notify(fmt.Sprintf("⛔ [KILL-SWITCH] 已停止所有新進場單\n原因：%s", reason))
+	if !flatten {
+		return 0, nil
+	}
+	positions, err := s.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list open positions: %w", err)
+	}
+	closed := 0
+	var firstErr error
+	for _, pos := range positions {
+		if err := s.ClosePositionManually(ctx, pos.ID, userID); err != nil {
+			log.Printf("[KILL-SWITCH] flatten %s (%s) failed: %v", pos.ID, pos.Symbol, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("flatten position %s: %w", pos.ID, err)
+			}
+			continue
+		}
+		closed++
+	}
+	return closed, firstErr
+}
+
+// ReleaseKillSwitch 解除 kill switch。
+func (s *Service) ReleaseKillSwitch(ctx context.Context) error {
+	if err := s.halts.Release(ctx); err != nil {
+		return err
+	}
+	log.Printf("[KILL-SWITCH] released")
+	s.notify("✅ [KILL-SWITCH] 已解除，恢復接受新進場單")
+	return nil
+}
+
+// ResetCircuitBreaker 解除策略熔斷。
+func (s *Service) ResetCircuitBreaker(ctx context.Context, strategyID string, env tradingDomain.Environment) error {
+	return s.halts.Reset(ctx, strategyID, env)
+}
+
+// recordTradeResult 以平倉損益更新策略熔斷器，觸發時寫日誌並通知。
+func (s *Service) recordTradeResult(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, pnl float64) {
+	capital := strat.Risk.OrderSizeValue
+	if capital <= 0 {
+		capital = 100
+	}
+	if tripped, reason := s.halts.RecordTrade(ctx, strat.ID, env, pnl, capital); tripped {
+		s.onBreakerTripped(ctx, strat, env, reason)
+	}
+}
+
+// recordOrderError 累計交易所錯誤，連續達門檻時觸發熔斷。
+func (s *Service) recordOrderError(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, cause error) {
+	if tripped, reason := s.halts.RecordError(ctx, strat.ID, env, cause); tripped {
+		s.onBreakerTripped(ctx, strat, env, reason)
+	}
+}
+
+func (s *Service) onBreakerTripped(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, reason string) {
+	log.Printf("[BREAKER] strategy %s (%s) tripped: %s", strat.ID, env, reason)
+	_ = s.repo.SaveLog(ctx, tradingDomain.LogEntry{
+		StrategyID: strat.ID,
+		Env:        env,
+		Date:       s.now(),
+		Phase:      "breaker",
+		Message:    "circuit breaker tripped: " + reason,
+	})
+	s.notify(fmt.Sprintf("🧯 %s [BREAKER] %s 已暫停新進場\n原因：%s", s.envTag(env), strat.Name, reason))
+}
+
+// checkRisk 執行下單前風控；kill switch 或策略熔斷中時拒絕買單，
+// 其餘規則拒單時寫入 risk 日誌並通知。
+// 買單通過後回傳的 release 須在持倉寫入後呼叫：同一環境的買單從檢查到寫入持倉依序執行，
+// 執行器並行的買單才不會在彼此成交前一起通過持倉數、曝險與當日虧損上限；賣單不排隊。
+func (s *Service) checkRisk(ctx context.Context, intent OrderIntent, ex Exchange) (func(), error) {
+	release := func() {}
+	if isBuy(intent.Side) {
+		if err := s.halts.Allow(ctx, intent.StrategyID, intent.Env); err != nil {
+			log.Printf("[HALT] %s %s %s blocked: %v", intent.Env, intent.Side, intent.Symbol, err)
+			return release, err
+		}
+		// 關閉中不再排隊等鎖，直接拒絕新訂單
+		s.orderMu.Lock()
+		draining := s.draining
+		s.orderMu.Unlock()
+		if draining {
+			return release, ErrShuttingDown
+		}
+		unlock, err := s.risk.lockEnv(ctx, intent.Env)
+		if err != nil {
+			return release, err
+		}
+		release = unlock
+	}
+	err := s.risk.Check(ctx, intent, ex)
+	if err == nil {
+		return release, nil
+	}
+	release()
+	release = func() {}
+	var rej *RiskRejection
+	if !errors.As(err, &rej) {
+		return release, err
+	}
+	log.Printf("[RISK] %s %s %s rejected: %v", intent.Env, intent.Side, intent.Symbol, rej)
+	if intent.StrategyID != "" && intent.StrategyID != "manual" {
+		_ = s.repo.SaveLog(ctx, tradingDomain.LogEntry{
+			StrategyID: intent.StrategyID,
+			Env:        intent.Env,
+			Date:       s.now(),
+			Phase:      "risk",
+			Message:    rej.Error(),
+			Payload: map[string]interface{}{
+				"rule":     rej.Rule,
+				"symbol":   intent.Symbol,
+				"side":     intent.Side,
+				"notional": intent.Notional,
+			},
+		})
+	}
+	s.notify(fmt.Sprintf("🛑 %s [RISK] %s %s 已拒單\n規則：%s\n原因：%s",
+		s.envTag(intent.Env), strings.ToUpper(intent.Side), intent.Symbol, rej.Rule, rej.Reason))
+	return release, err
+}
+
+// PaperAccount 回傳使用者的模擬帳戶。
+func (s *Service) PaperAccount(userID string) *PaperExchange {
+	return s.paper.For(userID, s.ex)
+}
+
+// venue 回傳實際下單的對象：paper 環境改用以 ex 報價的使用者模擬帳戶，
+// shadow 環境只以 ex 報價計算假設成交，不動任何餘額。
+func (s *Service) venue(ex Exchange, env tradingDomain.Environment, account string) Exchange {
+	switch env {
+	case tradingDomain.EnvPaper:
+		return s.paper.For(account, ex)
+	case tradingDomain.EnvShadow:
+		return s.shadow.For(ex)
+	}
+	return ex
+}
+
+// exchangeFor 回傳策略指定的交易所，名稱為空或未設定解析器時回傳預設交易所。
+func (s *Service) exchangeFor(name string) (Exchange, error) {
+	if name == "" || s.exs == nil {
+		return s.ex, nil
+	}
+	return s.exs.Exchange(name)
+}
+
 // SetStatus 切換策略狀態。
 func (s *Service) SetStatus(ctx context.Context, id string, status tradingDomain.Status, env tradingDomain.Environment) error {
-	return s.repo.SetStatus(ctx, id, status, env)
+	if err := s.repo.SetStatus(ctx, id, status, env); err != nil {
+		return err
+	}
+	// 網格策略停用時撤下仍掛著的限價單
+	if status != tradingDomain.StatusActive && s.grids != nil {
+		if strat, err := s.repo.LoadScoringStrategyByID(ctx, id); err == nil && strat != nil && strat.Kind == strategyDomain.KindGrid {
+			if err := s.stopGrid(ctx, strat, env); err != nil {
+				log.Printf("[GRID] cancel open orders for %s %s: %v", strat.Slug, env, err)
+			}
+		}
+	}
+	return nil
 }
 
 func (s *Service) UpdateRiskSettings(ctx context.Context, id string, risk tradingDomain.RiskSettings) error {
@@ -391,9 +675,14 @@ func (s *Service) ExecuteScoringAutoTrade(ctx context.Context, slug string, env
 		return fmt.Errorf("load scoring strategy: %w", err)
 	}
 
-	// 1.5 如果是 Paper 模式，略過實體餘額檢查
-	if env != tradingDomain.EnvPaper && strat.Risk.AutoStopMinBalance > 0 {
-		balance, berr := s.ex.GetBalance(ctx, "USDT")
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+
+	// 1.5 如果是 Paper 或 Shadow 模式，略過實體餘額檢查
+	if !env.IsSimulated() && strat.Risk.AutoStopMinBalance > 0 {
+		balance, berr := ex.GetBalance(ctx, "USDT")
 		if berr == nil && balance < strat.Risk.AutoStopMinBalance {
 			_ = s.repo.SetStatus(ctx, strat.ID, tradingDomain.StatusDraft, env)
 			s.notify(fmt.Sprintf("⚠️ %s [AUTO-STOP] 策略 %s 已停止。\n原因：可用餘額 %.2f 低於限制 %.2f。",
@@ -402,9 +691,19 @@ func (s *Service) ExecuteScoringAutoTrade(ctx context.Context, slug string, env
 		}
 	}
 
+	// 網格以掛單成交驅動，不需要分析結果
+	if strat.Kind == strategyDomain.KindGrid {
+		return s.executeGrid(ctx, strat, env)
+	}
+	if strat.IsMultiSymbol() {
+		return s.executeUniverse(ctx, strat, env, userID)
+	}
+	if err := s.checkDataQuality(ctx, strat, env, strat.BaseSymbol); err != nil {
+		return err
+	}
 
-	// 2. 獲取最新行情分析 (取得最後 1 天的結果)
-	results, err := s.data.FindHistory(ctx, strat.BaseSymbol, strat.Timeframe, nil, nil, 1, true)
+	// 2. 獲取策略釘選版本的最新行情分析 (取得最後 1 天的結果)
+	results, err := analysis.ReadHistory(ctx, s.data, strat.AnalysisVersion, strat.BaseSymbol, strat.Timeframe, nil, nil, 1, true)
 	if err != nil || len(results) == 0 {
 		return fmt.Errorf("no analysis results found for %s", strat.BaseSymbol)
 	}
@@ -421,6 +720,10 @@ func (s *Service) ExecuteScoringAutoTrade(ctx context.Context, slug string, env
 		// 忽略錯誤或處理
 	}
 
+	if strat.Kind == strategyDomain.KindDCA {
+		return s.executeDCA(ctx, strat, pos, latest, env)
+	}
+
 	// 4. 評估是否觸發
 	triggered, score, err := strat.IsTriggered(latest)
 	if err != nil {
@@ -434,11 +737,18 @@ func (s *Service) ExecuteScoringAutoTrade(ctx context.Context, slug string, env
 		Date:       s.now(),
 		Phase:      "eval",
 		Message:    fmt.Sprintf("Score evaluated: %.2f (Threshold: %.2f, Triggered: %v)", score, strat.Threshold, triggered),
+		Payload: map[string]interface{}{
+			"score":      score,
+			"threshold":  strat.Threshold,
+			"triggered":  triggered,
+			"close":      latest.Close,
+			"trade_date": latest.TradeDate,
+		},
 	})
 
 	if triggered && pos == nil {
 		// 執行買入
-		return s.handleScoringBuy(ctx, strat, latest, env, userID)
+		return s.handleScoringBuy(ctx, strat, strat.BaseSymbol, latest, env, userID)
 	} else if pos != nil {
 		// 檢查賣出 (這裡目前缺乏 ScoringStrategy 的賣出邏輯，暫時使用固定停利停損或簡單邏輯)
 		// TODO: 未來可在 ScoringStrategy 增加賣出規則
@@ -448,38 +758,49 @@ func (s *Service) ExecuteScoringAutoTrade(ctx context.Context, slug string, env
 	return nil
 }
 
-func (s *Service) handleScoringBuy(ctx context.Context, strat *strategyDomain.ScoringStrategy, data analysisDomain.DailyAnalysisResult, env tradingDomain.Environment, userID string) error {
+func (s *Service) handleScoringBuy(ctx context.Context, strat *strategyDomain.ScoringStrategy, symbol string, data analysisDomain.DailyAnalysisResult, env tradingDomain.Environment, userID string) error {
 	// 決定金額 (假設固定 1000 USDT 或從策略讀取)
 	amount := strat.Risk.OrderSizeValue
 	if amount <= 0 {
 		amount = 100 // Default 100 USDT
 	}
-	var price float64
-	var executedQty float64
-	var err error
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+	release, err := s.checkRisk(ctx, OrderIntent{
+		StrategyID: strat.ID,
+		UserID:     strategyOwner(strat),
+		Env:        env,
+		Symbol:     symbol,
+		Side:       "buy",
+		Notional:   amount,
+		RefPrice:   data.Close,
+		Risk:       strat.Risk,
+	}, ex)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
 
-	if env == tradingDomain.EnvPaper {
-		// Paper trading: get real price but don't place real order
-		price, err = s.ex.GetPrice(ctx, strat.BaseSymbol)
-		if err != nil {
-			return fmt.Errorf("paper trade get price: %w", err)
-		}
-		executedQty = amount / price
-		log.Printf("[TRADING] Paper BUY %s at %.2f (Mocked)", strat.BaseSymbol, price)
-	} else {
-		// Real trading (test/prod)
-		price, executedQty, err = s.ex.PlaceMarketOrderQuote(ctx, strat.BaseSymbol, "buy", amount)
-		if err != nil {
-			return fmt.Errorf("place binance buy order: %w", err)
-		}
+	price, executedQty, err := s.venue(ex, env, strategyOwner(strat)).PlaceMarketOrderQuote(ctx, symbol, "buy", amount)
+	if err != nil {
+		s.recordOrderError(ctx, strat, env, err)
+		return fmt.Errorf("place %s buy order: %w", venueName(strat.Exchange, env), err)
 	}
+	s.halts.RecordSuccess(ctx, strat.ID, env)
 
 	qty := executedQty
 
 	// 記錄交易
 	tRec := tradingDomain.TradeRecord{
 		StrategyID:      strat.ID,
-		Symbol:          strat.BaseSymbol,
+		Symbol:          symbol,
 		StrategyVersion: 1,
 		Env:             env,
 		Side:            "buy",
@@ -493,7 +814,8 @@ func (s *Service) handleScoringBuy(ctx context.Context, strat *strategyDomain.Sc
 	// 建立持倉
 	newPos := tradingDomain.Position{
 		StrategyID: strat.ID,
-		Symbol:     strat.BaseSymbol,
+		UserID:     strategyOwner(strat),
+		Symbol:     symbol,
 		Env:        env,
 		EntryDate:  s.now(),
 		EntryPrice: price,
@@ -504,7 +826,7 @@ func (s *Service) handleScoringBuy(ctx context.Context, strat *strategyDomain.Sc
 	_ = s.repo.UpsertPosition(ctx, newPos)
 
 	s.notify(fmt.Sprintf("🚀 %s [AUTO-TRADE] BUY %s\nPrice: %.2f\nAmount: %.2f USDT\nReason: %s",
-		s.envTag(env), strat.BaseSymbol, price, amount, tRec.Reason))
+		s.envTag(env), symbol, price, amount, tRec.Reason))
 
 	return nil
 }
@@ -512,76 +834,196 @@ func (s *Service) handleScoringBuy(ctx context.Context, strat *strategyDomain.Sc
 func (s *Service) handleScoringSellCheck(ctx context.Context, strat *strategyDomain.ScoringStrategy, pos *tradingDomain.Position, data analysisDomain.DailyAnalysisResult, env tradingDomain.Environment) error {
 	shouldSell, reason := strat.ShouldExit(data, *pos)
 	if shouldSell {
-		var price float64
-		var executedQty float64
-		var err error
-
-		if env == tradingDomain.EnvPaper {
-			// Paper trading
-			price, err = s.ex.GetPrice(ctx, strat.BaseSymbol)
-			if err != nil {
-				return fmt.Errorf("paper trade get price: %w", err)
-			}
-			executedQty = pos.Size
-			log.Printf("[TRADING] Paper SELL %s at %.2f (Mocked)", strat.BaseSymbol, price)
-		} else {
-			// Real trading
-			price, executedQty, err = s.ex.PlaceMarketOrder(ctx, strat.BaseSymbol, "sell", pos.Size)
-			if err != nil {
-				return err
-			}
-		}
+		return s.exitScoringPosition(ctx, strat, pos, env, reason)
+	}
 
-		pnl := (price - pos.EntryPrice) * executedQty
-		pnlPct := pnl / (pos.EntryPrice * pos.Size)
+	return nil
+}
 
-		exitDate := s.now()
-		_ = s.repo.SaveTrade(ctx, tradingDomain.TradeRecord{
-			StrategyID:      strat.ID,
-			Symbol:          strat.BaseSymbol,
-			StrategyVersion: 1,
-			Env:             env,
-			Side:            "sell",
-			EntryDate:       pos.EntryDate,
-			EntryPrice:      pos.EntryPrice,
-			ExitDate:        &exitDate,
-			ExitPrice:       &price,
-			PNL:             &pnl,
-			PNLPct:          &pnlPct,
-			Reason:          reason,
-			CreatedAt:       s.now(),
-		})
+// venueName 回傳顯示用的下單對象名稱。
+func venueName(name string, env tradingDomain.Environment) string {
+	if env.IsSimulated() {
+		return string(env)
+	}
+	if name == "" {
+		return "binance"
+	}
+	return name
+}
 
-		_ = s.repo.ClosePosition(ctx, pos.ID, exitDate, price)
+// exitScoringPosition 賣出整個持倉並記錄交易、平倉與通知。
+func (s *Service) exitScoringPosition(ctx context.Context, strat *strategyDomain.ScoringStrategy, pos *tradingDomain.Position, env tradingDomain.Environment, reason string) error {
+	symbol := pos.Symbol
+	if symbol == "" {
+		symbol = strat.BaseSymbol
+	}
+	ex, err := s.exchangeFor(strat.Exchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange: %w", err)
+	}
+	if _, err := s.checkRisk(ctx, OrderIntent{StrategyID: strat.ID, UserID: strategyOwner(strat), Env: env, Symbol: symbol, Side: "sell", Notional: pos.Size * pos.EntryPrice, Risk: strat.Risk}, ex); err != nil {
+		return err
+	}
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
 
-		s.notify(fmt.Sprintf("💰 %s [AUTO-TRADE] SELL %s\nPrice: %.2f (Entry: %.2f)\nPNL: %.2f (%.2f%%)\nReason: %s",
-			s.envTag(env), strat.BaseSymbol, price, pos.EntryPrice, pnl, pnlPct*100, reason))
+	price, executedQty, err := s.venue(ex, env, strategyOwner(strat)).PlaceMarketOrder(ctx, symbol, "sell", pos.Size)
+	if err != nil {
+		s.recordOrderError(ctx, strat, env, err)
+		return err
 	}
+	s.halts.RecordSuccess(ctx, strat.ID, env)
 
+	pnl := (price - pos.EntryPrice) * executedQty
+	pnlPct := pnl / (pos.EntryPrice * pos.Size)
+
+	exitDate := s.now()
+	_ = s.repo.SaveTrade(ctx, tradingDomain.TradeRecord{
+		StrategyID:      strat.ID,
+		Symbol:          symbol,
+		StrategyVersion: 1,
+		Env:             env,
+		Side:            "sell",
+		EntryDate:       pos.EntryDate,
+		EntryPrice:      pos.EntryPrice,
+		ExitDate:        &exitDate,
+		ExitPrice:       &price,
+		PNL:             &pnl,
+		PNLPct:          &pnlPct,
+		Reason:          reason,
+		CreatedAt:       s.now(),
+	})
+
+	_ = s.repo.ClosePosition(ctx, pos.ID, exitDate, price)
+
+	s.notify(fmt.Sprintf("💰 %s [AUTO-TRADE] SELL %s\nPrice: %.2f (Entry: %.2f)\nPNL: %.2f (%.2f%%)\nReason: %s",
+		s.envTag(env), symbol, price, pos.EntryPrice, pnl, pnlPct*100, reason))
+	s.recordTradeResult(ctx, strat, env, pnl)
 	return nil
 }
 
+// BarRecorder 在收盤評估前寫入收盤 K 線並分析，讓策略讀到的是這根 K 線的結果而非上一根。
+type BarRecorder interface {
+	// RecordClosedBar 寫入 c 並以 versions（空字串代表啟用中的版本）分析該交易對與週期。
+	RecordClosedBar(ctx context.Context, c Candle, versions []string) error
+}
+
+// SetBarRecorder 設定收盤 K 線的寫入與分析；未設定時只評估已有該 K 線分析結果的策略。
+func (s *Service) SetBarRecorder(r BarRecorder) {
+	s.bars = r
+}
+
+// EvaluateOnBarClose 在 K 線收盤後評估使用該交易對與週期的啟用策略；
+// 先寫入並分析收盤 K 線，尚無該 K 線分析結果的策略略過，等排程補上後再評估。
+func (s *Service) EvaluateOnBarClose(ctx context.Context, c Candle) error {
+	strats, err := s.repo.ListActiveScoringStrategies(ctx)
+	if err != nil {
+		return fmt.Errorf("list active strategies: %w", err)
+	}
+	var (
+		matched  []*strategyDomain.ScoringStrategy
+		versions []string
+	)
+	for _, st := range strats {
+		tf := st.Timeframe
+		if tf == "" {
+			tf = "1d"
+		}
+		if !st.Covers(c.Symbol) || tf != c.Timeframe {
+			continue
+		}
+		matched = append(matched, st)
+		if !slices.Contains(versions, st.AnalysisVersion) {
+			versions = append(versions, st.AnalysisVersion)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	if s.bars != nil {
+		if err := s.bars.RecordClosedBar(ctx, c, versions); err != nil {
+			return fmt.Errorf("record closed bar %s %s: %w", c.Symbol, c.Timeframe, err)
+		}
+	}
+
+	ready := matched[:0]
+	for _, st := range matched {
+		results, err := analysis.ReadHistory(ctx, s.data, st.AnalysisVersion, c.Symbol, c.Timeframe, &c.OpenTime, &c.OpenTime, 1, true)
+		if err != nil || len(results) == 0 {
+			log.Printf("[TRADING] %s skip bar close %s %s %s: analysis not ready", st.Slug, c.Symbol, c.Timeframe, c.OpenTime.Format(time.RFC3339))
+			continue
+		}
+		ready = append(ready, st)
+	}
+	return s.exec.RunAll(ctx, ready)
+}
+
+// CheckProtectiveExits 以即時價格檢查該交易對所有策略持倉的停損停利，觸發時立即出場。
+// 出場與該策略的評估共用執行器的互斥，評估執行中時本次略過，下一個跳動再檢查。
+func (s *Service) CheckProtectiveExits(ctx context.Context, symbol string, price float64) error {
+	if price <= 0 {
+		return nil
+	}
+	positions, err := s.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("list open positions: %w", err)
+	}
+	var errs []error
+	for i := range positions {
+		pos := positions[i]
+		if pos.StrategyID == "" || pos.StrategyID == "manual" || !strings.EqualFold(pos.Symbol, symbol) {
+			continue
+		}
+		strat, err := s.repo.LoadScoringStrategyByID(ctx, pos.StrategyID)
+		if err != nil || strat == nil || !strat.IsSignalDriven() {
+			continue
+		}
+		hit, reason := strat.HitsProtectiveLevel(pos.EntryPrice, price)
+		if !hit {
+			continue
+		}
+		err = s.exec.Exclusive(strat, pos.Env, func() error {
+			// 取得互斥前持倉可能已被評估賣出
+			current, err := s.repo.GetPosition(ctx, pos.ID)
+			if err != nil || current == nil || current.Status != "open" {
+				return err
+			}
+			log.Printf("[TRADING] Protective exit %s %s at %.2f: %s", pos.Env, symbol, price, reason)
+			return s.exitScoringPosition(ctx, strat, current, pos.Env, reason)
+		})
+		switch {
+		case errors.Is(err, ErrRunInProgress):
+			// 評估執行中由評估本身處理，下一筆行情會再檢查
+			log.Printf("[TRADING] Protective exit %s %s deferred: strategy run in progress", pos.Env, symbol)
+		case err != nil:
+			errs = append(errs, fmt.Errorf("exit position %s: %w", pos.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (s *Service) GetExchangePrice(ctx context.Context, symbol string) (float64, error) {
 	return s.ex.GetPrice(ctx, symbol)
 }
 
 func (s *Service) ExecuteManualBuy(ctx context.Context, symbol string, amount float64, env tradingDomain.Environment, userID string) error {
-	var price float64
-	var executedQty float64
-	var err error
+	release, err := s.checkRisk(ctx, OrderIntent{StrategyID: "manual", UserID: userID, Env: env, Symbol: symbol, Side: "buy", Notional: amount}, s.ex)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
 
-	if env == tradingDomain.EnvPaper {
-		price, err = s.ex.GetPrice(ctx, symbol)
-		if err != nil {
-			return fmt.Errorf("manual paper buy get price: %w", err)
-		}
-		executedQty = amount / price
-		log.Printf("[MANUAL] Paper BUY %s at %.2f (Mocked)", symbol, price)
-	} else {
-		price, executedQty, err = s.ex.PlaceMarketOrderQuote(ctx, symbol, "buy", amount)
-		if err != nil {
-			return fmt.Errorf("manual real buy order: %w", err)
-		}
+	price, executedQty, err := s.venue(s.ex, env, userID).PlaceMarketOrderQuote(ctx, symbol, "buy", amount)
+	if err != nil {
+		return fmt.Errorf("manual %s buy order: %w", venueName("", env), err)
 	}
 
 	// 紀錄交易
@@ -598,9 +1040,8 @@ func (s *Service) ExecuteManualBuy(ctx context.Context, symbol string, amount fl
 	}
 	_ = s.repo.SaveTrade(ctx, tRec)
 
-	// 建立持倉 (或是併入現有持倉，這裡先採簡單邏輯：每個手動買入都是獨立倉位或是更新現有)
-	// 為了簡化，手動買入的 strategy_id 固定為 "manual"
-	existing, _ := s.repo.GetOpenPosition(ctx, "manual", env)
+	// 建立持倉，或併入同一使用者同一交易對的手動持倉；手動買入的 strategy_id 固定為 "manual"
+	existing := s.manualPosition(ctx, env, symbol, userID)
 	if existing != nil {
 		// 平均價格與累積數量
 		newTotalQty := existing.Size + executedQty
@@ -612,6 +1053,7 @@ func (s *Service) ExecuteManualBuy(ctx context.Context, symbol string, amount fl
 	} else {
 		newPos := tradingDomain.Position{
 			StrategyID: "manual",
+			UserID:     userID,
 			Symbol:     symbol,
 			Env:        env,
 			EntryDate:  s.now(),
@@ -629,13 +1071,32 @@ func (s *Service) ExecuteManualBuy(ctx context.Context, symbol string, amount fl
 	return nil
 }
 
+// manualPosition 回傳使用者在指定環境與交易對的手動持倉，沒有時回傳 nil。
+func (s *Service) manualPosition(ctx context.Context, env tradingDomain.Environment, symbol, userID string) *tradingDomain.Position {
+	positions, err := s.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, p := range positions {
+		if p.Env == env && isManualPosition(p) && p.UserID == userID && strings.EqualFold(p.Symbol, symbol) {
+			return &p
+		}
+	}
+	return nil
+}
+
 func (s *Service) ExecuteManualBacktestBuy(ctx context.Context, symbol string, amount float64) (float64, float64, error) {
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer done()
 	price, executedQty, err := s.ex.PlaceMarketOrderQuote(ctx, symbol, "buy", amount)
 	return price, executedQty, err
 }
 
-// ClosePositionManually 手動平倉。
-func (s *Service) ClosePositionManually(ctx context.Context, positionID string) error {
+// ClosePositionManually 手動平倉；paper 持倉自持倉擁有者的模擬帳戶賣出。
+func (s *Service) ClosePositionManually(ctx context.Context, positionID, userID string) error {
 	pos, err := s.repo.GetPosition(ctx, positionID)
 	if err != nil {
 		return err
@@ -647,31 +1108,44 @@ func (s *Service) ClosePositionManually(ctx context.Context, positionID string)
 		return fmt.Errorf("position already closed")
 	}
 
+	// 策略持倉沿用策略的交易所與擁有者帳戶；手動持倉使用擁有者帳戶，
+	// 未記錄擁有者的舊手動 paper 持倉開戶時歸屬 admin，自 admin 帳戶賣出。
 	symbol := pos.Symbol
-	if symbol == "" {
-		strat, err := s.repo.LoadScoringStrategyByID(ctx, pos.StrategyID)
-		if err == nil {
-			symbol = strat.BaseSymbol
-		} else {
-			symbol = "BTCUSDT" // Fallback
+	ex, account := s.ex, userID
+	switch {
+	case pos.UserID != "":
+		account = pos.UserID
+	case pos.Env == tradingDomain.EnvPaper && isManualPosition(*pos):
+		account = adminUserID
+	}
+	var strat *strategyDomain.ScoringStrategy
+	if pos.StrategyID != "" && pos.StrategyID != "manual" {
+		if strat, err = s.repo.LoadScoringStrategyByID(ctx, pos.StrategyID); err == nil && strat != nil {
+			if symbol == "" {
+				symbol = strat.BaseSymbol
+			}
+			account = strategyOwner(strat)
+			if ex, err = s.exchangeFor(strat.Exchange); err != nil {
+				return fmt.Errorf("resolve exchange: %w", err)
+			}
 		}
 	}
+	if symbol == "" {
+		symbol = "BTCUSDT" // Fallback
+	}
 
-	var price float64
-	var executedQty float64
+	if _, err := s.checkRisk(ctx, OrderIntent{StrategyID: pos.StrategyID, UserID: account, Env: pos.Env, Symbol: symbol, Side: "sell", Notional: pos.Size * pos.EntryPrice}, ex); err != nil {
+		return err
+	}
+	ctx, done, err := s.beginOrder(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
 
-	if pos.Env == tradingDomain.EnvPaper {
-		price, err = s.ex.GetPrice(ctx, symbol)
-		if err != nil {
-			return fmt.Errorf("paper trade get price: %w", err)
-		}
-		executedQty = pos.Size
-		log.Printf("[TRADING] Paper Manual SELL %s at %.2f (Mocked)", symbol, price)
-	} else {
-		price, executedQty, err = s.ex.PlaceMarketOrder(ctx, symbol, "sell", pos.Size)
-		if err != nil {
-			return fmt.Errorf("place market order: %w", err)
-		}
+	price, executedQty, err := s.venue(ex, pos.Env, account).PlaceMarketOrder(ctx, symbol, "sell", pos.Size)
+	if err != nil {
+		return fmt.Errorf("place market order: %w", err)
 	}
 
 	pnl := (price - pos.EntryPrice) * executedQty
@@ -698,6 +1172,9 @@ func (s *Service) ClosePositionManually(ctx context.Context, positionID string)
 	if err == nil {
 		s.notify(fmt.Sprintf("✋ %s [MANUAL] SELL %s\nPrice: %.2f (Entry: %.2f)\nPNL: %.2f (%.2f%%)\nReason: Manual Close",
 			s.envTag(pos.Env), symbol, price, pos.EntryPrice, pnl, pnlPct*100))
+		if strat != nil {
+			s.recordTradeResult(ctx, strat, pos.Env, pnl)
+		}
 	}
 	return err
 }
@@ -1157,6 +1634,29 @@ func (s *Service) GenerateReport(ctx context.Context, strategyID string, env tra
 		return nil, err
 	}
 
+	summary := summarizeTrades(trades)
+
+	report := tradingDomain.Report{
+		StrategyID:      strat.ID,
+		StrategyVersion: strat.Version,
+		Env:             env,
+		PeriodStart:     start,
+		PeriodEnd:       end,
+		Summary:         summary,
+		TradesRef:       len(trades), // 紀錄交易數量作為引用參考
+		CreatedAt:       s.now(),
+	}
+
+	id, err := s.repo.SaveReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	report.ID = id
+	return &report, nil
+}
+
+// summarizeTrades 彙總交易紀錄的勝率、損益與平均持有天數。
+func summarizeTrades(trades []tradingDomain.TradeRecord) tradingDomain.ReportSummary {
 	summary := tradingDomain.ReportSummary{
 		TotalTrades: len(trades),
 	}
@@ -1194,22 +1694,5 @@ func (s *Service) GenerateReport(ctx context.Context, strategyID string, env tra
 	if closedCount > 0 {
 		summary.AvgHoldDays = totalHoldDays / float64(closedCount)
 	}
-
-	report := tradingDomain.Report{
-		StrategyID:      strat.ID,
-		StrategyVersion: strat.Version,
-		Env:             env,
-		PeriodStart:     start,
-		PeriodEnd:       end,
-		Summary:         summary,
-		TradesRef:       len(trades), // 紀錄交易數量作為引用參考
-		CreatedAt:       s.now(),
-	}
-
-	id, err := s.repo.SaveReport(ctx, report)
-	if err != nil {
-		return nil, err
-	}
-	report.ID = id
-	return &report, nil
+	return summary
 }
@@ -2,6 +2,7 @@ package trading
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -606,12 +607,18 @@ func TestExecuteScoringAutoTrade_NoTrigger(t *testing.T) {
 }
 
 func TestClosePositionManually(t *testing.T) {
-	repo := &fakeRepo{}
+	repo := &positionRepo{positions: []tradingDomain.Position{
+		{ID: "p1", StrategyID: "manual", UserID: "u2", Status: "open", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, Size: 0.1},
+	}}
 	svc := NewService(repo, nil, &mockExchange{}, nil)
-	err := svc.ClosePositionManually(context.Background(), "p1")
+	err := svc.ClosePositionManually(context.Background(), "p1", "u1")
 	if err != nil {
 		t.Fatalf("ClosePositionManually failed: %v", err)
 	}
+	// 由 u1 操作仍自擁有者 u2 的模擬帳戶賣出
+	if v, _ := svc.PaperAccount("u2").GetBalance(context.Background(), "BTC"); v != 0 {
+		t.Fatalf("expected owner's seeded BTC sold, got %v", v)
+	}
 }
 
 func TestExecuteScoringAutoTrade_TriggerSell(t *testing.T) {
@@ -625,6 +632,7 @@ func TestExecuteScoringAutoTrade_TriggerSell(t *testing.T) {
 		openPos: &tradingDomain.Position{
 			ID:         "p1",
 			StrategyID: "strat-1",
+			UserID:     adminUserID,
 			Env:        tradingDomain.EnvPaper,
 			Symbol:     "BTCUSDT",
 			EntryPrice: 50000,
@@ -801,6 +809,15 @@ func (f *fakeRepo) SaveTrade(context.Context, tradingDomain.TradeRecord) error {
 func (f *fakeRepo) ListTrades(context.Context, tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error) {
 	return f.trades, nil
 }
+func (f *fakeRepo) SumRealizedPnL(_ context.Context, env tradingDomain.Environment, since time.Time) (map[string]float64, error) {
+	out := make(map[string]float64)
+	for _, t := range f.trades {
+		if t.Env == env && t.PNL != nil && t.ExitDate != nil && !t.ExitDate.Before(since) {
+			out[t.StrategyID] += *t.PNL
+		}
+	}
+	return out, nil
+}
 func (f *fakeRepo) GetOpenPosition(context.Context, string, tradingDomain.Environment) (*tradingDomain.Position, error) {
 	return f.openPos, nil
 }
@@ -808,6 +825,9 @@ func (f *fakeRepo) GetPosition(ctx context.Context, id string) (*tradingDomain.P
 	return &tradingDomain.Position{ID: "p1", Status: "open", Symbol: "BTCUSDT", Env: tradingDomain.EnvPaper, Size: 0.1}, nil
 }
 func (f *fakeRepo) ListOpenPositions(context.Context) ([]tradingDomain.Position, error) {
+	if f.openPos != nil {
+		return []tradingDomain.Position{*f.openPos}, nil
+	}
 	return nil, nil
 }
 func (f *fakeRepo) UpsertPosition(context.Context, tradingDomain.Position) error {
@@ -874,3 +894,46 @@ func (m *mockExchange) PlaceMarketOrderQuote(ctx context.Context, symbol, side s
 type mockNotifier struct{}
 
 func (m *mockNotifier) Notify(msg string) error { return nil }
+
+type blockingExchange struct {
+	mockExchange
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingExchange) PlaceMarketOrderQuote(ctx context.Context, symbol, side string, quoteAmount float64) (float64, float64, error) {
+	close(b.started)
+	<-b.release
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 50000, quoteAmount / 50000, nil
+}
+
+func TestService_DrainWaitsForInFlightOrders(t *testing.T) {
+	ex := &blockingExchange{started: make(chan struct{}), release: make(chan struct{})}
+	svc := NewService(&fakeRepo{}, nil, ex, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- svc.ExecuteManualBuy(ctx, "BTCUSDT", 100, tradingDomain.EnvProd, "u1") }()
+	<-ex.started
+	cancel() // 呼叫端取消不可中斷已送出的訂單
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if err := svc.Drain(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected drain to wait for the in-flight order, got %v", err)
+	}
+	if err := svc.ExecuteManualBuy(context.Background(), "BTCUSDT", 100, tradingDomain.EnvProd, "u1"); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected new orders to be refused while draining, got %v", err)
+	}
+
+	close(ex.release)
+	if err := <-errCh; err != nil {
+		t.Fatalf("in-flight order should complete: %v", err)
+	}
+	if err := svc.Drain(context.Background()); err != nil {
+		t.Fatalf("drain after completion: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// ShadowSide 為比較中單一策略/環境在期間內的表現。
+type ShadowSide struct {
+	StrategyID   string                      `json:"strategy_id"`
+	Name         string                      `json:"name"`
+	Version      int                         `json:"version"`
+	Env          tradingDomain.Environment   `json:"env"`
+	Summary      tradingDomain.ReportSummary `json:"summary"`
+	Entries      int                         `json:"entries"`
+	Exits        int                         `json:"exits"`
+	OpenPosition *tradingDomain.Position     `json:"open_position,omitempty"`
+	Trades       []tradingDomain.TradeRecord `json:"trades"`
+}
+
+// ShadowComparison 並排比較影子模式的候選策略與目前部署中的策略。
+type ShadowComparison struct {
+	PeriodStart  time.Time  `json:"period_start"`
+	PeriodEnd    time.Time  `json:"period_end"`
+	Candidate    ShadowSide `json:"candidate"`
+	Baseline     ShadowSide `json:"baseline"`
+	PNLDelta     float64    `json:"pnl_delta"`      // candidate - baseline
+	WinRateDelta float64    `json:"win_rate_delta"` // candidate - baseline
+}
+
+// CompareShadow 比較 candidateID 在 shadow 環境與 baselineID 在 baselineEnv 的交易表現，不寫入報告。
+func (s *Service) CompareShadow(ctx context.Context, candidateID, baselineID string, baselineEnv tradingDomain.Environment, start, end time.Time) (*ShadowComparison, error) {
+	if baselineEnv == tradingDomain.EnvShadow && candidateID == baselineID {
+		return nil, fmt.Errorf("baseline must differ from the shadow candidate")
+	}
+	candidate, err := s.shadowSide(ctx, candidateID, tradingDomain.EnvShadow, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("candidate: %w", err)
+	}
+	baseline, err := s.shadowSide(ctx, baselineID, baselineEnv, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	return &ShadowComparison{
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Candidate:    candidate,
+		Baseline:     baseline,
+		PNLDelta:     candidate.Summary.TotalPNL - baseline.Summary.TotalPNL,
+		WinRateDelta: candidate.Summary.WinRate - baseline.Summary.WinRate,
+	}, nil
+}
+
+func (s *Service) shadowSide(ctx context.Context, strategyID string, env tradingDomain.Environment, start, end time.Time) (ShadowSide, error) {
+	strat, err := s.repo.GetStrategy(ctx, strategyID)
+	if err != nil {
+		return ShadowSide{}, err
+	}
+	trades, err := s.repo.ListTrades(ctx, tradingDomain.TradeFilter{
+		StrategyID: strategyID,
+		Env:        env,
+		StartDate:  &start,
+		EndDate:    &end,
+		All:        true,
+	})
+	if err != nil {
+		return ShadowSide{}, err
+	}
+	side := ShadowSide{
+		StrategyID: strat.ID,
+		Name:       strat.Name,
+		Version:    strat.Version,
+		Env:        env,
+		Summary:    summarizeTrades(trades),
+		Trades:     trades,
+	}
+	for _, t := range trades {
+		if isBuy(t.Side) {
+			side.Entries++
+		} else {
+			side.Exits++
+		}
+	}
+	if pos, err := s.repo.GetOpenPosition(ctx, strategyID, env); err == nil && pos != nil {
+		side.OpenPosition = pos
+	}
+	return side, nil
+}
@@ -0,0 +1,165 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShadowNoBalance 代表影子模式沒有任何帳戶餘額可查詢。
+var ErrShadowNoBalance = errors.New("shadow exchange has no balance")
+
+// ShadowExchange 以真實行情計算假設成交價的 trading.Exchange：
+// 滑價模型與 paper 相同，但不碰任何交易所或模擬帳戶餘額，也不會因餘額不足拒單。
+type ShadowExchange struct {
+	prices Exchange
+	cfg    PaperConfig
+	mu     *sync.Mutex
+	orders map[string]OrderResponse
+	seq    *atomic.Int64
+}
+
+// NewShadowExchange 建立影子交易所；prices 提供最新成交價。
+func NewShadowExchange(prices Exchange, cfg PaperConfig) *ShadowExchange {
+	return &ShadowExchange{
+		prices: prices,
+		cfg:    cfg,
+		mu:     &sync.Mutex{},
+		orders: make(map[string]OrderResponse),
+		seq:    &atomic.Int64{},
+	}
+}
+
+// For 回傳以 prices 報價、共用訂單紀錄的影子交易所。
+func (x *ShadowExchange) For(prices Exchange) *ShadowExchange {
+	if prices == nil {
+		prices = x.prices
+	}
+	cp := *x
+	cp.prices = prices
+	return &cp
+}
+
+// GetOrder 回傳訂單狀態；掛單中的限價單若最新價已觸及限價，視為以限價成交。
+func (x *ShadowExchange) GetOrder(ctx context.Context, _ string, orderID string) (OrderResponse, error) {
+	x.mu.Lock()
+	o, ok := x.orders[orderID]
+	x.mu.Unlock()
+	if !ok {
+		return OrderResponse{}, fmt.Errorf("shadow order %s not found", orderID)
+	}
+	if o.Status != OrderStatusNew {
+		return o, nil
+	}
+	if last, err := x.GetPrice(ctx, o.Symbol); err != nil || !limitCrossed(o, last) {
+		return o, nil
+	}
+	return x.setStatus(orderID, OrderStatusFilled)
+}
+
+// PlaceLimitOrder 記錄一筆假設掛單，不檢查也不扣住任何餘額。
+func (x *ShadowExchange) PlaceLimitOrder(_ context.Context, symbol, side string, qty, price float64) (OrderResponse, error) {
+	if qty <= 0 || price <= 0 {
+		return OrderResponse{}, fmt.Errorf("limit order quantity and price must be positive")
+	}
+	o := OrderResponse{OrderID: x.nextOrderID(), Symbol: symbol, Side: strings.ToUpper(side), Price: price, Qty: qty, Status: OrderStatusNew}
+	x.mu.Lock()
+	x.orders[o.OrderID] = o
+	x.mu.Unlock()
+	return o, nil
+}
+
+// RestoreLimitOrder 以原訂單編號還原重啟前的假設掛單。
+func (x *ShadowExchange) RestoreLimitOrder(_ context.Context, o OrderResponse) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.orders[o.OrderID]; !ok {
+		o.Side = strings.ToUpper(o.Side)
+		o.Status = OrderStatusNew
+		x.orders[o.OrderID] = o
+	}
+	return nil
+}
+
+func (x *ShadowExchange) CancelOrder(_ context.Context, _ string, orderID string) error {
+	o, err := x.setStatus(orderID, OrderStatusCanceled)
+	if err == nil && o.Status != OrderStatusCanceled {
+		return fmt.Errorf("shadow order %s is not open", orderID)
+	}
+	return err
+}
+
+// setStatus 將掛單轉為終態，已是終態的訂單保持不變。
+func (x *ShadowExchange) setStatus(orderID, status string) (OrderResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.orders[orderID]
+	if !ok {
+		return OrderResponse{}, fmt.Errorf("shadow order %s not found", orderID)
+	}
+	if o.Status == OrderStatusNew {
+		o.Status = status
+		x.orders[orderID] = o
+	}
+	return o, nil
+}
+
+func (x *ShadowExchange) nextOrderID() string {
+	return "shadow-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(x.seq.Add(1), 10)
+}
+
+func (x *ShadowExchange) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	if x.prices == nil {
+		return 0, fmt.Errorf("shadow exchange has no price source")
+	}
+	return x.prices.GetPrice(ctx, symbol)
+}
+
+func (x *ShadowExchange) GetBalance(context.Context, string) (float64, error) {
+	return 0, ErrShadowNoBalance
+}
+
+func (x *ShadowExchange) PlaceMarketOrder(ctx context.Context, symbol, side string, qty float64) (float64, float64, error) {
+	if qty <= 0 {
+		return 0, 0, fmt.Errorf("order quantity must be positive")
+	}
+	return x.fill(ctx, symbol, side, func(float64) float64 { return qty })
+}
+
+func (x *ShadowExchange) PlaceMarketOrderQuote(ctx context.Context, symbol, side string, quoteAmount float64) (float64, float64, error) {
+	if quoteAmount <= 0 {
+		return 0, 0, fmt.Errorf("quote amount must be positive")
+	}
+	return x.fill(ctx, symbol, side, func(price float64) float64 {
+		if isBuy(side) {
+			return quoteAmount / (1 + x.cfg.FeeRate) / price
+		}
+		return quoteAmount / price
+	})
+}
+
+// fill 計算假設成交：成交價與數量的算法與 PaperExchange 一致。市價單沒有訂單編號可查詢，
+// 因此不記錄，orders 只保存限價單。
+func (x *ShadowExchange) fill(ctx context.Context, symbol, side string, qtyAt func(price float64) float64) (float64, float64, error) {
+	last, err := x.GetPrice(ctx, symbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("shadow get price: %w", err)
+	}
+	if last <= 0 {
+		return 0, 0, fmt.Errorf("invalid price %.8f for %s", last, symbol)
+	}
+	buy := isBuy(side)
+	price := x.cfg.fillPrice(last, qtyAt(last), buy)
+	return price, qtyAt(price), nil
+}
+
+var (
+	_ Exchange           = (*ShadowExchange)(nil)
+	_ LimitOrderExchange = (*ShadowExchange)(nil)
+	_ LimitOrderRestorer = (*ShadowExchange)(nil)
+)
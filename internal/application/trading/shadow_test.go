@@ -0,0 +1,118 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// orderCountingExchange 記錄是否有訂單真的送到交易所。
+type orderCountingExchange struct {
+	mockExchange
+	orders int
+}
+
+func (e *orderCountingExchange) PlaceMarketOrder(context.Context, string, string, float64) (float64, float64, error) {
+	e.orders++
+	return 0, 0, errors.New("unexpected order")
+}
+
+func (e *orderCountingExchange) PlaceMarketOrderQuote(context.Context, string, string, float64) (float64, float64, error) {
+	e.orders++
+	return 0, 0, errors.New("unexpected order")
+}
+
+func TestShadowExchange_FillsWithoutBalance(t *testing.T) {
+	cfg := DefaultPaperConfig()
+	x := NewShadowExchange(&mockExchange{}, cfg)
+	ctx := context.Background()
+
+	price, qty, err := x.PlaceMarketOrderQuote(ctx, "BTCUSDT", "buy", 1e9)
+	if err != nil {
+		t.Fatalf("shadow fills must not depend on balance: %v", err)
+	}
+	if want := cfg.fillPrice(50000, 1e9/(1+cfg.FeeRate)/50000, true); !approx(price, want) || qty <= 0 {
+		t.Fatalf("unexpected fill %.4f x %.8f (want price %.4f)", price, qty, want)
+	}
+	if _, err := x.GetBalance(ctx, "USDT"); !errors.Is(err, ErrShadowNoBalance) {
+		t.Fatalf("expected ErrShadowNoBalance, got %v", err)
+	}
+	if n := len(x.orders); n != 0 {
+		t.Fatalf("market fills must not be retained, got %d orders", n)
+	}
+}
+
+func TestService_ShadowRecordsVirtualPositionWithoutTrading(t *testing.T) {
+	repo := &positionRepo{}
+	ex := &orderCountingExchange{}
+	history := []analysisDomain.DailyAnalysisResult{{TradeDate: time.Now().Add(-time.Hour), Close: 50000, Score: 75}}
+	svc := NewService(repo, stubDataProvider{history: history}, ex, nil)
+	ctx := context.Background()
+
+	if err := svc.ExecuteScoringAutoTrade(ctx, "alpha", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatalf("shadow run failed: %v", err)
+	}
+	if ex.orders != 0 {
+		t.Fatalf("shadow mode must not place exchange orders, got %d", ex.orders)
+	}
+	if len(repo.upserted) != 1 || repo.upserted[0].Env != tradingDomain.EnvShadow || repo.upserted[0].EntryPrice <= 50000 {
+		t.Fatalf("expected a virtual shadow position with a slipped fill, got %+v", repo.upserted)
+	}
+	bal, err := svc.PaperAccount("00000000-0000-0000-0000-000000000001").GetBalance(ctx, "USDT")
+	if err != nil || bal != DefaultPaperConfig().InitialBalance {
+		t.Fatalf("paper balance must be untouched, got %.2f (%v)", bal, err)
+	}
+}
+
+type envTradesRepo struct {
+	fakeRepo
+}
+
+func (r *envTradesRepo) ListTrades(_ context.Context, f tradingDomain.TradeFilter) ([]tradingDomain.TradeRecord, error) {
+	var out []tradingDomain.TradeRecord
+	for _, t := range r.trades {
+		if t.Env == f.Env && t.StrategyID == f.StrategyID {
+			out = append(out, t)
+		}
+	}
+	if !f.All && len(out) > 200 {
+		out = out[:200] // 與資料庫實作相同的列表上限
+	}
+	return out, nil
+}
+
+func TestService_CompareShadow(t *testing.T) {
+	win, loss := 120.0, -40.0
+	repo := &envTradesRepo{fakeRepo{trades: []tradingDomain.TradeRecord{
+		{StrategyID: "cand", Env: tradingDomain.EnvShadow, Side: "buy"},
+		{StrategyID: "cand", Env: tradingDomain.EnvShadow, Side: "sell", PNL: &win},
+		{StrategyID: "live", Env: tradingDomain.EnvPaper, Side: "sell", PNL: &loss},
+	}}}
+	// 超過列表上限的交易也要計入
+	zero := 0.0
+	for i := 0; i < 150; i++ {
+		repo.trades = append(repo.trades,
+			tradingDomain.TradeRecord{StrategyID: "cand", Env: tradingDomain.EnvShadow, Side: "buy"},
+			tradingDomain.TradeRecord{StrategyID: "cand", Env: tradingDomain.EnvShadow, Side: "sell", PNL: &zero})
+	}
+	svc := NewService(repo, nil, &mockExchange{}, nil)
+	now := time.Now()
+
+	cmp, err := svc.CompareShadow(context.Background(), "cand", "live", tradingDomain.EnvPaper, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Candidate.Env != tradingDomain.EnvShadow || cmp.Candidate.Entries != 151 || cmp.Candidate.Exits != 151 {
+		t.Fatalf("unexpected candidate %+v", cmp.Candidate)
+	}
+	if cmp.Baseline.Summary.TotalPNL != loss || cmp.PNLDelta != win-loss {
+		t.Fatalf("unexpected comparison %+v", cmp)
+	}
+	if _, err := svc.CompareShadow(context.Background(), "cand", "cand", tradingDomain.EnvShadow, now, now); err == nil {
+		t.Fatal("comparing a shadow strategy with itself must fail")
+	}
+}
@@ -0,0 +1,143 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/application/analysis"
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// universeCandidate 為本根 K 線觸發進場的候選交易對。
+type universeCandidate struct {
+	symbol string
+	score  float64
+	data   analysisDomain.DailyAnalysisResult
+}
+
+// UniverseSymbols 回傳策略目前的候選交易對；動態清單由資料來源依成交額排名。
+func (s *Service) UniverseSymbols(ctx context.Context, strat *strategyDomain.ScoringStrategy) ([]string, error) {
+	if !strat.IsMultiSymbol() {
+		return []string{strat.BaseSymbol}, nil
+	}
+	ranker, _ := s.data.(strategyDomain.VolumeRanker)
+	return strat.Universe.Resolve(ctx, ranker, s.now())
+}
+
+// executeUniverse 先檢查已持有交易對的出場條件，再依分數由高到低買進觸發的候選，
+// 直到持有數達 MaxPositions。
+func (s *Service) executeUniverse(ctx context.Context, strat *strategyDomain.ScoringStrategy, env tradingDomain.Environment, userID string) error {
+	symbols, err := s.UniverseSymbols(ctx, strat)
+	if err != nil {
+		return fmt.Errorf("resolve universe: %w", err)
+	}
+	held, err := s.strategyPositions(ctx, strat.ID, env)
+	if err != nil {
+		return fmt.Errorf("list positions: %w", err)
+	}
+
+	var errs []error
+	// 已持有的交易對即使被移出動態清單，仍照常管理出場
+	for sym, pos := range held {
+		if s.checkDataQuality(ctx, strat, env, sym) != nil {
+			continue
+		}
+		latest, err := s.latestAnalysis(ctx, sym, strat.Timeframe, strat.AnalysisVersion)
+		if err != nil {
+			log.Printf("[UNIVERSE] %s skip exit check for %s: %v", strat.Slug, sym, err)
+			continue
+		}
+		if exit, reason := strat.ShouldExit(latest, pos); exit {
+			if err := s.exitScoringPosition(ctx, strat, &pos, env, reason); err != nil {
+				errs = append(errs, fmt.Errorf("exit %s: %w", sym, err))
+				continue
+			}
+			delete(held, sym)
+		}
+	}
+
+	var cands []universeCandidate
+	for _, sym := range symbols {
+		if _, ok := held[sym]; ok {
+			continue
+		}
+		if s.checkDataQuality(ctx, strat, env, sym) != nil {
+			continue
+		}
+		latest, err := s.latestAnalysis(ctx, sym, strat.Timeframe, strat.AnalysisVersion)
+		if err != nil {
+			continue
+		}
+		triggered, score, err := strat.IsTriggered(latest)
+		if err != nil || !triggered {
+			continue
+		}
+		cands = append(cands, universeCandidate{symbol: sym, score: score, data: latest})
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+
+	slots := strat.PositionLimit() - len(held)
+	ranked := make([]string, 0, len(cands))
+	for _, c := range cands {
+		ranked = append(ranked, fmt.Sprintf("%s:%.2f", c.symbol, c.score))
+	}
+	_ = s.repo.SaveLog(ctx, tradingDomain.LogEntry{
+		StrategyID: strat.ID,
+		Env:        env,
+		Date:       s.now(),
+		Phase:      "eval",
+		Message:    fmt.Sprintf("Universe evaluated: %d symbols, %d triggered, %d held, %d slots", len(symbols), len(cands), len(held), max(slots, 0)),
+		Payload: map[string]interface{}{
+			"universe":  symbols,
+			"ranked":    ranked,
+			"held":      len(held),
+			"threshold": strat.Threshold,
+		},
+	})
+
+	for _, c := range cands {
+		if slots <= 0 {
+			break
+		}
+		if err := s.handleScoringBuy(ctx, strat, c.symbol, c.data, env, userID); err != nil {
+			errs = append(errs, fmt.Errorf("buy %s: %w", c.symbol, err))
+			continue
+		}
+		slots--
+	}
+	return errors.Join(errs...)
+}
+
+// strategyPositions 回傳策略在指定環境的未平倉，以交易對為鍵。
+func (s *Service) strategyPositions(ctx context.Context, strategyID string, env tradingDomain.Environment) (map[string]tradingDomain.Position, error) {
+	all, err := s.repo.ListOpenPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]tradingDomain.Position)
+	for _, p := range all {
+		if p.StrategyID == strategyID && p.Env == env {
+			out[strings.ToUpper(p.Symbol)] = p
+		}
+	}
+	return out, nil
+}
+
+// latestAnalysis 取得交易對在指定分析版本（空值為啟用中版本）的最新一筆結果，過舊時視為無資料。
+func (s *Service) latestAnalysis(ctx context.Context, symbol, timeframe, version string) (analysisDomain.DailyAnalysisResult, error) {
+	results, err := analysis.ReadHistory(ctx, s.data, version, symbol, timeframe, nil, nil, 1, true)
+	if err != nil || len(results) == 0 {
+		return analysisDomain.DailyAnalysisResult{}, fmt.Errorf("no analysis results found for %s", symbol)
+	}
+	if time.Since(results[0].TradeDate) > 48*time.Hour {
+		return analysisDomain.DailyAnalysisResult{}, fmt.Errorf("analysis result too old: %v", results[0].TradeDate)
+	}
+	return results[0], nil
+}
@@ -0,0 +1,152 @@
+package trading
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+)
+
+// universeRepo 保存多交易對策略的多筆未平倉。
+type universeRepo struct {
+	fakeRepo
+	strat     *strategyDomain.ScoringStrategy
+	positions []tradingDomain.Position
+}
+
+func (r *universeRepo) LoadScoringStrategyBySlug(context.Context, string) (*strategyDomain.ScoringStrategy, error) {
+	return r.strat, nil
+}
+
+func (r *universeRepo) ListOpenPositions(context.Context) ([]tradingDomain.Position, error) {
+	var out []tradingDomain.Position
+	for _, p := range r.positions {
+		if p.Status == "open" {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (r *universeRepo) UpsertPosition(_ context.Context, p tradingDomain.Position) error {
+	if p.ID == "" {
+		p.ID = p.Symbol
+	}
+	r.positions = append(r.positions, p)
+	return nil
+}
+
+func (r *universeRepo) ClosePosition(_ context.Context, id string, _ time.Time, _ float64) error {
+	for i := range r.positions {
+		if r.positions[i].ID == id {
+			r.positions[i].Status = "closed"
+		}
+	}
+	return nil
+}
+
+func (r *universeRepo) held() []string {
+	var out []string
+	for _, p := range r.positions {
+		if p.Status == "open" {
+			out = append(out, p.Symbol)
+		}
+	}
+	return out
+}
+
+// symbolDataProvider 依交易對回傳最新分析結果，並提供成交額排名。
+type symbolDataProvider struct {
+	latest map[string]analysisDomain.DailyAnalysisResult
+	ranked []string
+}
+
+func (p symbolDataProvider) FindHistory(_ context.Context, symbol string, _ string, _, _ *time.Time, _ int, _ bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	if res, ok := p.latest[symbol]; ok {
+		return []analysisDomain.DailyAnalysisResult{res}, nil
+	}
+	return nil, nil
+}
+
+func (p symbolDataProvider) PricesByPair(context.Context, string, string) ([]dataDomain.DailyPrice, error) {
+	return nil, nil
+}
+
+func (p symbolDataProvider) TopSymbolsByVolume(_ context.Context, _ time.Time, n int) ([]string, error) {
+	if n < len(p.ranked) {
+		return p.ranked[:n], nil
+	}
+	return p.ranked, nil
+}
+
+func universeStrategy(u *strategyDomain.Universe, maxPositions int) *strategyDomain.ScoringStrategy {
+	return &strategyDomain.ScoringStrategy{
+		ID:           "uni-1",
+		Slug:         "uni",
+		BaseSymbol:   "BTCUSDT",
+		Threshold:    60,
+		Universe:     u,
+		MaxPositions: maxPositions,
+		EntryRules:   []strategyDomain.StrategyRule{{Weight: 1, RuleType: "entry", Condition: strategyDomain.Condition{Type: "BASE_SCORE"}}},
+	}
+}
+
+func TestService_UniverseBuysTopScoredUpToMaxPositions(t *testing.T) {
+	bar := time.Now().Add(-time.Hour)
+	data := symbolDataProvider{latest: map[string]analysisDomain.DailyAnalysisResult{
+		"BTCUSDT": {TradeDate: bar, Close: 50000, Score: 65},
+		"ETHUSDT": {TradeDate: bar, Close: 3000, Score: 90},
+		"SOLUSDT": {TradeDate: bar, Close: 150, Score: 80},
+		"XRPUSDT": {TradeDate: bar, Close: 0.5, Score: 40},
+	}}
+	repo := &universeRepo{strat: universeStrategy(&strategyDomain.Universe{Symbols: []string{"btcusdt", "ETHUSDT", "SOLUSDT", "XRPUSDT"}}, 2)}
+	svc := NewService(repo, data, &mockExchange{}, nil)
+	ctx := context.Background()
+
+	if err := svc.ExecuteScoringAutoTrade(ctx, "uni", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repo.held(), ","); got != "ETHUSDT,SOLUSDT" {
+		t.Fatalf("expected the two highest scores to be bought, got %s", got)
+	}
+
+	// 再執行一次：持倉已滿，不得加碼或買進第三檔
+	if err := svc.ExecuteScoringAutoTrade(ctx, "uni", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.held()) != 2 {
+		t.Fatalf("max positions exceeded: %v", repo.held())
+	}
+
+	// ETH 訊號轉弱出場後，空出的名額由 BTC 遞補
+	eth := data.latest["ETHUSDT"]
+	eth.Score = 10
+	data.latest["ETHUSDT"] = eth
+	if err := svc.ExecuteScoringAutoTrade(ctx, "uni", tradingDomain.EnvShadow, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repo.held(), ","); got != "SOLUSDT,BTCUSDT" {
+		t.Fatalf("expected ETH to rotate out for BTC, got %s", got)
+	}
+}
+
+func TestService_UniverseResolvesTopNByVolume(t *testing.T) {
+	data := symbolDataProvider{ranked: []string{"ETHUSDT", "SOLUSDT", "BTCUSDT"}}
+	svc := NewService(&universeRepo{}, data, &mockExchange{}, nil)
+
+	got, err := svc.UniverseSymbols(context.Background(), universeStrategy(&strategyDomain.Universe{TopN: 2}, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "ETHUSDT,SOLUSDT" {
+		t.Fatalf("unexpected universe %v", got)
+	}
+	if _, err := NewService(&universeRepo{}, stubDataProvider{}, nil, nil).UniverseSymbols(context.Background(), universeStrategy(&strategyDomain.Universe{TopN: 2}, 1)); err == nil {
+		t.Fatalf("expected error when the data source cannot rank by volume")
+	}
+}
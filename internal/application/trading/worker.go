@@ -3,8 +3,10 @@ package trading
 import (
 	"context"
 	"log"
+	"sync/atomic"
 	"time"
 
+	strategyDomain "ai-auto-trade/internal/domain/strategy"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
 )
 
@@ -12,7 +14,10 @@ import (
 type BackgroundWorker struct {
 	svc      *Service
 	interval time.Duration
-	stopChan chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  atomic.Bool
+	done     chan struct{}
 }
 
 // NewBackgroundWorker 建立背景工作者。
@@ -20,18 +25,26 @@ func NewBackgroundWorker(svc *Service, interval time.Duration) *BackgroundWorker
 	if interval <= 0 {
 		interval = 1 * time.Hour
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &BackgroundWorker{
 		svc:      svc,
 		interval: interval,
-		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
 	}
 }
 
 // Start 啟動迴圈。
 func (w *BackgroundWorker) Start() {
+	if !w.started.CompareAndSwap(false, true) {
+		return
+	}
 	log.Printf("[Worker] Starting trading background worker with interval: %v", w.interval)
 	ticker := time.NewTicker(w.interval)
 	go func() {
+		defer close(w.done)
+		defer ticker.Stop()
 		// 啟動後立即執行一次
 		w.runOnce()
 
@@ -39,21 +52,23 @@ func (w *BackgroundWorker) Start() {
 			select {
 			case <-ticker.C:
 				w.runOnce()
-			case <-w.stopChan:
-				ticker.Stop()
+			case <-w.ctx.Done():
 				return
 			}
 		}
 	}()
 }
 
-// Stop 停止迴圈。
+// Stop 取消進行中的評估並等待迴圈結束（已送出的訂單由 Service.Drain 等待）。
 func (w *BackgroundWorker) Stop() {
-	close(w.stopChan)
+	w.cancel()
+	if w.started.Load() {
+		<-w.done
+	}
 }
 
 func (w *BackgroundWorker) runOnce() {
-	ctx := context.Background()
+	ctx := w.ctx
 	log.Printf("[Worker] Checking active strategies for auto-trade...")
 
 	strats, err := w.svc.repo.ListActiveScoringStrategies(ctx)
@@ -67,39 +82,42 @@ func (w *BackgroundWorker) runOnce() {
 		return
 	}
 
-	for _, s := range strats {
-		log.Printf("[Worker] Executing strategy: %s (Slug: %s, Env: %s)", s.Name, s.Slug, s.Env)
-		
-		envs := []tradingDomain.Environment{}
-		switch s.Env {
-		case "prod":
-			envs = append(envs, tradingDomain.EnvProd)
-		case "real":
-			envs = append(envs, tradingDomain.EnvReal)
-		case "paper":
-			envs = append(envs, tradingDomain.EnvPaper)
-		case "test":
-			envs = append(envs, tradingDomain.EnvTest)
-		case "both":
-			envs = append(envs, tradingDomain.EnvPaper, tradingDomain.EnvTest)
-		default:
-			envs = append(envs, tradingDomain.EnvTest)
-		}
+	if err := w.svc.exec.RunAll(ctx, strats); err != nil {
+		log.Printf("[Worker] Strategy run errors: %v", err)
+	}
+}
 
-		// userID 使用策略擁有者的 ID
-		userID := s.UserID
-		if userID == "" {
-			userID = "00000000-0000-0000-0000-000000000001" // Fallback to admin
-		}
-		
-		for _, env := range envs {
-			err := w.svc.ExecuteScoringAutoTrade(ctx, s.Slug, env, userID)
-			if err != nil {
-				log.Printf("[Worker] Strategy %s (%s) execution failed: %v", s.Slug, env, err)
-			} else {
-				log.Printf("[Worker] Strategy %s (%s) execution completed.", s.Slug, env)
-			}
-		}
+// ExecuteStrategy 以策略擁有者身分，在策略設定的每個環境（並行）執行一次自動交易。
+func (s *Service) ExecuteStrategy(ctx context.Context, strat *strategyDomain.ScoringStrategy) error {
+	return s.exec.RunAll(ctx, []*strategyDomain.ScoringStrategy{strat})
+}
+
+// StrategyEnvs 將策略設定的 env 字串展開為實際執行的環境。
+func StrategyEnvs(env string) []tradingDomain.Environment {
+	switch env {
+	case "prod":
+		return []tradingDomain.Environment{tradingDomain.EnvProd}
+	case "real":
+		return []tradingDomain.Environment{tradingDomain.EnvReal}
+	case "paper":
+		return []tradingDomain.Environment{tradingDomain.EnvPaper}
+	case "test":
+		return []tradingDomain.Environment{tradingDomain.EnvTest}
+	case "shadow":
+		return []tradingDomain.Environment{tradingDomain.EnvShadow}
+	case "both":
+		return []tradingDomain.Environment{tradingDomain.EnvPaper, tradingDomain.EnvTest}
+	default:
+		return []tradingDomain.Environment{tradingDomain.EnvTest}
 	}
 }
 
+// adminUserID 為未指定擁有者的策略與未記錄擁有者的舊手動持倉所歸屬的使用者。
+const adminUserID = "00000000-0000-0000-0000-000000000001"
+
+func strategyOwner(s *strategyDomain.ScoringStrategy) string {
+	if s.UserID == "" {
+		return adminUserID // Fallback to admin
+	}
+	return s.UserID
+}
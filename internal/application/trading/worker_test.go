@@ -1,9 +1,11 @@
 package trading
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"ai-auto-trade/internal/application/scheduler"
 	analysisDomain "ai-auto-trade/internal/domain/analysis"
 	strategyDomain "ai-auto-trade/internal/domain/strategy"
 )
@@ -46,3 +48,28 @@ func TestBackgroundWorker_RunOnce(t *testing.T) {
 		t.Errorf("expected worker to trigger trade and upsert position")
 	}
 }
+
+func TestStrategyTasks(t *testing.T) {
+	repo := &fakeRepo{activeStrats: []*strategyDomain.ScoringStrategy{
+		{Slug: "bar-close", Timeframe: "4h"},
+		{Slug: "custom", Timeframe: "1h", Schedule: "15 9 * * mon-fri", TradingWindow: "mon-fri 09:00-10:00"},
+		{Slug: "broken", Schedule: "not a cron"},
+	}}
+	svc := NewService(repo, stubDataProvider{}, &mockExchange{}, nil)
+
+	tasks, err := StrategyTasks(svc, time.UTC, scheduler.TradingWindow{})(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "strategy:bar-close" || tasks[1].Name != "strategy:custom" {
+		t.Fatalf("unexpected tasks %+v", tasks)
+	}
+	wed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if !tasks[0].Schedule.Matches(wed.Add(4*time.Hour)) || tasks[0].Schedule.Matches(wed.Add(5*time.Hour)) {
+		t.Fatal("default schedule must follow the strategy timeframe")
+	}
+	at := wed.Add(9*time.Hour + 15*time.Minute)
+	if !tasks[1].Schedule.Matches(at) || !tasks[1].Window.Contains(at) || tasks[1].Window.Contains(wed.AddDate(0, 0, 3).Add(9*time.Hour)) {
+		t.Fatal("custom cron and trading window must be applied")
+	}
+}
@@ -75,6 +75,10 @@ func (r DailyAnalysisResult) Validate() error {
 	}
 	switch r.Market {
 	case dataingestion.MarketTWSE, dataingestion.MarketTPEx:
+	case dataingestion.MarketCrypto:
+		if r.Timeframe == "" {
+			return fmt.Errorf("timeframe is required for crypto")
+		}
 	default:
 		return fmt.Errorf("market is required or unsupported")
 	}
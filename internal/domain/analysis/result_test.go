@@ -38,6 +38,25 @@ func TestDailyAnalysisResult_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Valid Crypto",
+			res: DailyAnalysisResult{
+				Symbol:    "BTCUSDT",
+				TradeDate: time.Now(),
+				Market:    dataingestion.MarketCrypto,
+				Timeframe: "1h",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Crypto Without Timeframe",
+			res: DailyAnalysisResult{
+				Symbol:    "BTCUSDT",
+				TradeDate: time.Now(),
+				Market:    dataingestion.MarketCrypto,
+			},
+			wantErr: true,
+		},
 		{
 			name: "Invalid Market",
 			res: DailyAnalysisResult{
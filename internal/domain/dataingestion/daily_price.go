@@ -56,6 +56,11 @@ func (p DailyPrice) Validate() error {
 	switch p.Market {
 	case MarketTWSE, MarketTPEx:
 		// ok
+	case MarketCrypto:
+		// 加密貨幣同一交易對有多個週期，需標明 timeframe
+		if p.Timeframe == "" {
+			reasons = append(reasons, "timeframe is required for crypto")
+		}
 	default:
 		reasons = append(reasons, "unsupported market")
 	}
@@ -23,6 +23,28 @@ func TestDailyPriceValidateSuccess(t *testing.T) {
 	}
 }
 
+func TestDailyPriceValidateCrypto(t *testing.T) {
+	p := DailyPrice{
+		Symbol:    "BTCUSDT",
+		Market:    MarketCrypto,
+		Timeframe: "4h",
+		TradeDate: time.Date(2024, 12, 2, 4, 0, 0, 0, time.UTC),
+		Open:      96000,
+		High:      96500,
+		Low:       95800,
+		Close:     96200,
+		Volume:    1200,
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("expected valid crypto bar, got error: %v", err)
+	}
+
+	p.Timeframe = ""
+	if err := p.Validate(); !IsValidationError(err) {
+		t.Fatalf("expected crypto bar without timeframe to fail, got %v", err)
+	}
+}
+
 func TestDailyPriceValidateErrors(t *testing.T) {
 	p := DailyPrice{
 		Symbol:    "",
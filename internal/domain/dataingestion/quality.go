@@ -0,0 +1,193 @@
+package dataingestion
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// QualityCheck 為資料品質檢查項目。
+type QualityCheck string
+
+const (
+	CheckGap       QualityCheck = "gap"            // 相鄰 K 線之間缺漏
+	CheckDuplicate QualityCheck = "duplicate"      // 同一開盤時間出現不同內容
+	CheckOHLC      QualityCheck = "ohlc"           // 價格非正或高低價不一致
+	CheckOutlier   QualityCheck = "outlier_return" // 報酬率遠超過近期波動
+	CheckStale     QualityCheck = "stale"          // 零成交量或價格完全未變動
+)
+
+// QualityIssue 描述一個檢查未通過的原因。
+type QualityIssue struct {
+	Check  QualityCheck `json:"check"`
+	Detail string       `json:"detail"`
+}
+
+// QualityRules 設定離群報酬率的判斷門檻。
+type QualityRules struct {
+	VolatilityWindow int     // 計算波動的報酬率樣本數，預設 30
+	MinSamples       int     // 樣本少於此數時不判斷離群，預設 10
+	OutlierSigma     float64 // 對數報酬率超過幾倍標準差視為離群，預設 8
+}
+
+func (r QualityRules) withDefaults() QualityRules {
+	if r.VolatilityWindow <= 0 {
+		r.VolatilityWindow = 30
+	}
+	if r.MinSamples <= 0 {
+		r.MinSamples = 10
+	}
+	if r.OutlierSigma <= 0 {
+		r.OutlierSigma = 8
+	}
+	return r
+}
+
+// HistoryBars 回傳判斷離群所需的前期 K 線根數。
+func (r QualityRules) HistoryBars() int {
+	return r.withDefaults().VolatilityWindow + 1
+}
+
+// SuspectBar 為未通過檢查、需隔離待審的 K 線。
+type SuspectBar struct {
+	Price  DailyPrice
+	Issues []QualityIssue
+}
+
+// Gap 為相鄰 K 線之間缺漏的區間 [From, To)。
+type Gap struct {
+	From    time.Time
+	To      time.Time
+	Missing int
+}
+
+// QualityReport 為單一交易對 × 週期一批 K 線的檢查結果。
+type QualityReport struct {
+	Clean   []DailyPrice
+	Suspect []SuspectBar
+	Gaps    []Gap
+	Latest  time.Time      // 本批最新一根 K 線的開盤時間
+	Issues  []QualityIssue // 最新一根 K 線的問題（含緊接在前的缺漏）
+}
+
+// Passed 表示本批最新的 K 線通過檢查。
+func (r QualityReport) Passed() bool {
+	return len(r.Issues) == 0
+}
+
+// Inspect 檢查同一交易對 × 週期的一批 K 線。history 為本批之前已儲存的 K 線（遞增），
+// 用於銜接缺漏與估計波動；trusted 以 UTC 開盤時間為鍵，其中的 K 線已人工放行，不再判定為可疑。
+// 完全相同的重複 K 線直接略過，內容不同的重複則隔離。
+func (r QualityRules) Inspect(history, batch []DailyPrice, trusted map[time.Time]bool) QualityReport {
+	r = r.withDefaults()
+	bars := append([]DailyPrice(nil), batch...)
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].TradeDate.Before(bars[j].TradeDate) })
+
+	var (
+		report  QualityReport
+		step    time.Duration
+		prev    *DailyPrice // 前一根存在的 K 線（含可疑者），用於缺漏與重複
+		lastOK  *DailyPrice // 前一根通過檢查的 K 線，用於報酬率
+		returns []float64
+		seen    = make(map[time.Time]DailyPrice, len(bars)) // 以 UTC 開盤時間為鍵
+	)
+	if len(bars) > 0 {
+		step = TimeframeDuration(bars[0].Timeframe)
+	}
+	for i := range history {
+		h := history[i]
+		if lastOK != nil && lastOK.Close > 0 && h.Close > 0 {
+			returns = append(returns, math.Log(h.Close/lastOK.Close))
+		}
+		prev, lastOK = &h, &h
+	}
+
+	for _, b := range bars {
+		key := b.TradeDate.UTC()
+		if first, ok := seen[key]; ok {
+			if first != b {
+				report.Suspect = append(report.Suspect, SuspectBar{Price: b, Issues: []QualityIssue{{
+					Check: CheckDuplicate, Detail: fmt.Sprintf("conflicting bar at %s", b.TradeDate.UTC().Format(time.RFC3339)),
+				}}})
+			}
+			continue
+		}
+		seen[key] = b
+
+		report.Issues = nil
+		if step > 0 && prev != nil {
+			if d := b.TradeDate.Sub(prev.TradeDate); d > step {
+				gap := Gap{From: prev.TradeDate.Add(step), To: b.TradeDate, Missing: int(d/step) - 1}
+				report.Gaps = append(report.Gaps, gap)
+				report.Issues = []QualityIssue{{Check: CheckGap, Detail: fmt.Sprintf("%d bars missing before %s", gap.Missing, b.TradeDate.UTC().Format(time.RFC3339))}}
+			}
+		}
+		issues := checkOHLC(b)
+		issues = append(issues, checkStale(b, prev)...)
+		if len(issues) == 0 && lastOK != nil && lastOK.Close > 0 {
+			ret := math.Log(b.Close / lastOK.Close)
+			if window := tail(returns, r.VolatilityWindow); len(window) >= r.MinSamples {
+				if sd := stddev(window); sd > 0 && math.Abs(ret) > r.OutlierSigma*sd {
+					issues = append(issues, QualityIssue{Check: CheckOutlier, Detail: fmt.Sprintf("return %.2f%% is %.1f sigma", ret*100, math.Abs(ret)/sd)})
+				}
+			}
+		}
+
+		p := b
+		prev = &p
+		report.Latest = b.TradeDate
+		if len(issues) > 0 && !trusted[key] {
+			report.Suspect = append(report.Suspect, SuspectBar{Price: b, Issues: issues})
+			report.Issues = append(report.Issues, issues...)
+			continue
+		}
+		if lastOK != nil && lastOK.Close > 0 && b.Close > 0 {
+			returns = append(returns, math.Log(b.Close/lastOK.Close))
+		}
+		lastOK = &p
+		report.Clean = append(report.Clean, b)
+	}
+	return report
+}
+
+func checkOHLC(p DailyPrice) []QualityIssue {
+	switch {
+	case p.Open <= 0 || p.High <= 0 || p.Low <= 0 || p.Close <= 0:
+		return []QualityIssue{{Check: CheckOHLC, Detail: "non-positive price"}}
+	case p.High < maxFloat64(p.Open, p.Close, p.Low) || p.Low > minFloat64(p.Open, p.Close, p.High):
+		return []QualityIssue{{Check: CheckOHLC, Detail: fmt.Sprintf("inconsistent ohlc o=%g h=%g l=%g c=%g", p.Open, p.High, p.Low, p.Close)}}
+	}
+	return nil
+}
+
+// checkStale 偵測零成交量，以及開高低收皆等於前一根收盤的停滯 K 線。
+func checkStale(p DailyPrice, prev *DailyPrice) []QualityIssue {
+	if p.Volume == 0 {
+		return []QualityIssue{{Check: CheckStale, Detail: "zero volume"}}
+	}
+	if prev != nil && p.Open == prev.Close && p.High == p.Open && p.Low == p.Open && p.Close == p.Open {
+		return []QualityIssue{{Check: CheckStale, Detail: "price unchanged from previous close"}}
+	}
+	return nil
+}
+
+func tail(values []float64, n int) []float64 {
+	if len(values) > n {
+		return values[len(values)-n:]
+	}
+	return values
+}
+
+func stddev(values []float64) float64 {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
@@ -0,0 +1,103 @@
+package dataingestion
+
+import (
+	"testing"
+	"time"
+)
+
+// qualityBars 產生每小時一根、收盤依 closes 的 K 線。
+func qualityBars(start time.Time, closes ...float64) []DailyPrice {
+	out := make([]DailyPrice, len(closes))
+	prev := closes[0]
+	for i, c := range closes {
+		out[i] = DailyPrice{
+			Symbol:    "BTCUSDT",
+			Market:    MarketCrypto,
+			Timeframe: "1h",
+			TradeDate: start.Add(time.Duration(i) * time.Hour),
+			Open:      prev,
+			High:      maxFloat64(prev, c) + 1,
+			Low:       minFloat64(prev, c) - 1,
+			Close:     c,
+			Volume:    10,
+		}
+		prev = c
+	}
+	return out
+}
+
+func hasCheck(issues []QualityIssue, check QualityCheck) bool {
+	for _, is := range issues {
+		if is.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+func TestQualityRulesInspect_FlagsBadBars(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	closes := make([]float64, 0, 20)
+	for i := 0; i < 20; i++ {
+		closes = append(closes, 100+float64(i%2))
+	}
+	history := qualityBars(start, closes...)
+	next := start.Add(20 * time.Hour)
+
+	batch := qualityBars(next, 101, 100, 300, 100)
+	batch[1].High = 90   // 高價低於開收
+	dup := batch[0]      // 完全相同的重複：略過
+	conflict := batch[0] // 內容不同的重複：隔離
+	conflict.Close = 102
+	conflict.High = 103
+	batch = append(batch, dup, conflict)
+
+	report := QualityRules{}.Inspect(history, batch, nil)
+	if len(report.Clean) != 2 {
+		t.Fatalf("expected 2 clean bars, got %+v", report.Clean)
+	}
+	if len(report.Suspect) != 3 {
+		t.Fatalf("expected 3 suspect bars, got %+v", report.Suspect)
+	}
+	checks := map[QualityCheck]bool{}
+	for _, s := range report.Suspect {
+		for _, is := range s.Issues {
+			checks[is.Check] = true
+		}
+	}
+	for _, c := range []QualityCheck{CheckOHLC, CheckOutlier, CheckDuplicate} {
+		if !checks[c] {
+			t.Fatalf("expected %s issue, got %+v", c, report.Suspect)
+		}
+	}
+	// 最新一根與離群前的收盤相比回到正常範圍
+	if !report.Passed() || !report.Latest.Equal(next.Add(3*time.Hour)) {
+		t.Fatalf("expected latest bar to pass, got %+v at %s", report.Issues, report.Latest)
+	}
+}
+
+func TestQualityRulesInspect_GapAndStaleOnLatest(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := qualityBars(start, 100, 101)
+	batch := qualityBars(start.Add(5*time.Hour), 101)
+	batch[0].Volume = 0
+
+	report := QualityRules{}.Inspect(history, batch, nil)
+	if len(report.Gaps) != 1 || report.Gaps[0].Missing != 3 || !report.Gaps[0].From.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("unexpected gaps: %+v", report.Gaps)
+	}
+	if report.Passed() || !hasCheck(report.Issues, CheckGap) || !hasCheck(report.Issues, CheckStale) {
+		t.Fatalf("expected gap and stale issues, got %+v", report.Issues)
+	}
+}
+
+func TestQualityRulesInspect_TrustedBarsAreClean(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := qualityBars(start, 100)
+	batch[0].Volume = 0
+
+	report := QualityRules{}.Inspect(nil, batch, map[time.Time]bool{start: true})
+	if len(report.Clean) != 1 || len(report.Suspect) != 0 || !report.Passed() {
+		t.Fatalf("expected released bar to be clean, got %+v", report)
+	}
+}
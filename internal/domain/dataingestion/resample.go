@@ -0,0 +1,118 @@
+package dataingestion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bucketEpoch 為 K 線分桶的基準點（1970-01-05，週一 00:00 UTC），
+// 日內與日 K 對齊 UTC 整點，週 K 與交易所一致從週一開始。
+var bucketEpoch = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// BucketStart 回傳 t 所屬 K 線的開盤時間；offset 將分桶邊界平移，
+// 例如 16h 讓日 K 從台北時間 00:00 開始。
+func BucketStart(t time.Time, step, offset time.Duration) time.Time {
+	base := bucketEpoch.Add(offset)
+	d := t.Sub(base)
+	n := d / step
+	if d%step < 0 {
+		n--
+	}
+	return base.Add(n * step).In(time.UTC)
+}
+
+// CanResample 回傳 target 週期能否由 source 週期的 K 線組成。
+func CanResample(source, target string) bool {
+	src, dst := TimeframeDuration(source), TimeframeDuration(target)
+	return src > 0 && dst > src && dst%src == 0
+}
+
+// ResampleOptions 設定重新取樣的來源範圍與分桶對齊。
+type ResampleOptions struct {
+	// From、To 為來源 K 線涵蓋的開盤時間範圍 [From, To)，超出範圍的分桶視為不完整而捨棄；
+	// 零值分別取第一根的開盤時間與最後一根的收盤時間。
+	From time.Time
+	To   time.Time
+	// Offset 平移分桶邊界（交易時段對齊），須為來源週期的整數倍。
+	Offset time.Duration
+	// Now 非零時，收盤時間晚於 Now 的最後一個分桶為進行中的 K 線，自開盤起連續即保留；
+	// 其餘分桶缺少任何一根來源 K 線都視為不完整而捨棄。
+	Now time.Time
+}
+
+// Resample 將同一交易對、較細週期的 K 線彙總為 timeframe 週期：開盤取第一根、收盤取最後一根、
+// 高低取極值、成交量與成交額加總。每個分桶須有 timeframe/來源週期 根 K 線，缺漏的分桶不輸出
+// （進行中的最後一根見 ResampleOptions.Now），來源需為同一週期。
+func Resample(bars []DailyPrice, timeframe string, opts ResampleOptions) ([]DailyPrice, error) {
+	if len(bars) == 0 {
+		return nil, nil
+	}
+	source := strings.ToLower(bars[0].Timeframe)
+	target := strings.ToLower(timeframe)
+	if !CanResample(source, target) {
+		return nil, fmt.Errorf("cannot resample %q bars into %q", source, timeframe)
+	}
+	srcStep, step := TimeframeDuration(source), TimeframeDuration(target)
+	if opts.Offset%srcStep != 0 {
+		return nil, fmt.Errorf("offset %s is not a multiple of %s", opts.Offset, source)
+	}
+
+	sorted := make([]DailyPrice, len(bars))
+	copy(sorted, bars)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TradeDate.Before(sorted[j].TradeDate) })
+	for _, b := range sorted {
+		if b.Symbol != sorted[0].Symbol || strings.ToLower(b.Timeframe) != source {
+			return nil, fmt.Errorf("resample needs a single %s series, got %s %s", source, b.Symbol, b.Timeframe)
+		}
+	}
+
+	from, to := opts.From, opts.To
+	if from.IsZero() {
+		from = sorted[0].TradeDate
+	}
+	if to.IsZero() {
+		to = sorted[len(sorted)-1].TradeDate.Add(srcStep)
+	}
+
+	want := int(step / srcStep)
+	var out []DailyPrice
+	for i := 0; i < len(sorted); {
+		start := BucketStart(sorted[i].TradeDate, step, opts.Offset)
+		end := start.Add(step)
+		bar := sorted[i]
+		bar.Timeframe = target
+		bar.TradeDate = start
+		bar.Change, bar.ChangeRate = 0, 0
+		count, last := 1, sorted[i].TradeDate
+		j := i + 1
+		for ; j < len(sorted) && sorted[j].TradeDate.Before(end); j++ {
+			b := sorted[j]
+			if b.TradeDate.Equal(sorted[j-1].TradeDate) {
+				continue // 重複的開盤時間只取第一根
+			}
+			bar.High = max(bar.High, b.High)
+			bar.Low = min(bar.Low, b.Low)
+			bar.Close = b.Close
+			bar.Volume += b.Volume
+			bar.Turnover += b.Turnover
+			bar.IsExDividend = bar.IsExDividend || b.IsExDividend
+			count++
+			last = b.TradeDate
+		}
+		i = j
+		if start.Before(from) || end.After(to) {
+			continue
+		}
+		if count < want {
+			inProgress := i == len(sorted) && !opts.Now.IsZero() && end.After(opts.Now) &&
+				count == int(last.Sub(start)/srcStep)+1
+			if !inProgress {
+				continue
+			}
+		}
+		out = append(out, bar)
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package dataingestion
+
+import (
+	"testing"
+	"time"
+)
+
+func minuteBars(start time.Time, n int) []DailyPrice {
+	out := make([]DailyPrice, n)
+	for i := range out {
+		p := 100 + float64(i)
+		out[i] = DailyPrice{
+			Symbol: "BTCUSDT", Market: MarketCrypto, Timeframe: "1m",
+			TradeDate: start.Add(time.Duration(i) * time.Minute),
+			Open:      p, High: p + 2, Low: p - 1, Close: p + 1, Volume: int64(i + 1),
+		}
+	}
+	return out
+}
+
+func TestResample_AggregatesOHLCV(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 3, 0, 0, time.UTC) // 第一個 5m 分桶不完整
+	bars := minuteBars(start, 12)                        // 00:03 ~ 00:14
+
+	out, err := Resample(bars, "5m", ResampleOptions{})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 complete buckets, got %+v", out)
+	}
+	first := out[0]
+	want := DailyPrice{
+		Symbol: "BTCUSDT", Market: MarketCrypto, Timeframe: "5m",
+		TradeDate: time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+		Open:      102, High: 108, Low: 101, Close: 107, Volume: 3 + 4 + 5 + 6 + 7,
+	}
+	if first != want {
+		t.Fatalf("unexpected bucket\n got %+v\nwant %+v", first, want)
+	}
+	if !out[1].TradeDate.Equal(time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)) || out[1].Close != 112 {
+		t.Fatalf("unexpected second bucket %+v", out[1])
+	}
+}
+
+func TestResample_DropsIncompleteBucketsAndRespectsRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := minuteBars(start, 120)
+	gappy := append(append([]DailyPrice{}, bars[:30]...), bars[31:]...) // 00:30 缺一根
+
+	out, err := Resample(gappy, "1h", ResampleOptions{From: start, To: start.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	// 缺一根的 00:00 不完整而捨棄，只剩 01:00
+	if len(out) != 1 || !out[0].TradeDate.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected the gappy bucket to be dropped, got %+v", out)
+	}
+
+	out, _ = Resample(bars, "1h", ResampleOptions{From: start, To: start.Add(90 * time.Minute)})
+	if len(out) != 1 {
+		t.Fatalf("expected bucket crossing To to be dropped, got %+v", out)
+	}
+
+	// 進行中的最後一根：自開盤起連續即保留，中間有缺漏或已收盤仍捨棄
+	now := start.Add(90 * time.Minute)
+	out, _ = Resample(bars[:90], "1h", ResampleOptions{From: start, To: start.Add(2 * time.Hour), Now: now})
+	if len(out) != 2 || out[1].Close != 190 {
+		t.Fatalf("expected in-progress bucket up to 01:29, got %+v", out)
+	}
+	partial := append(append([]DailyPrice{}, bars[:70]...), bars[71:90]...)
+	out, _ = Resample(partial, "1h", ResampleOptions{From: start, To: start.Add(2 * time.Hour), Now: now})
+	if len(out) != 1 {
+		t.Fatalf("in-progress bucket with a gap must be dropped, got %+v", out)
+	}
+	out, _ = Resample(bars[:90], "1h", ResampleOptions{From: start, To: start.Add(2 * time.Hour), Now: start.Add(3 * time.Hour)})
+	if len(out) != 1 {
+		t.Fatalf("closed bucket missing bars must be dropped, got %+v", out)
+	}
+}
+
+func TestResample_SessionAndWeekAlignment(t *testing.T) {
+	// 2024-01-01 為週一；四小時 K 從前一週五 16:00 起
+	start := time.Date(2023, 12, 29, 16, 0, 0, 0, time.UTC)
+	var bars []DailyPrice
+	for i := 0; i < 6*30; i++ {
+		bars = append(bars, DailyPrice{
+			Symbol: "ETHUSDT", Market: MarketCrypto, Timeframe: "4h",
+			TradeDate: start.Add(time.Duration(i) * 4 * time.Hour),
+			Open:      1, High: 2, Low: 1, Close: 2, Volume: 1,
+		})
+	}
+
+	daily, err := Resample(bars, "1d", ResampleOptions{Offset: 16 * time.Hour})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	// 台北時間 00:00 對齊：第一根日 K 從 2023-12-29 16:00 UTC 開始
+	if !daily[0].TradeDate.Equal(start) || daily[0].Volume != 6 {
+		t.Fatalf("unexpected session bucket %+v", daily[0])
+	}
+
+	weekly, err := Resample(bars, "1w", ResampleOptions{})
+	if err != nil {
+		t.Fatalf("resample: %v", err)
+	}
+	if len(weekly) == 0 || weekly[0].TradeDate.Weekday() != time.Monday || !weekly[0].TradeDate.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected weeks to start on Monday, got %+v", weekly)
+	}
+	if weekly[0].Volume != 42 {
+		t.Fatalf("expected 42 four-hour bars in a week, got %d", weekly[0].Volume)
+	}
+}
+
+func TestResample_RejectsInvalidInput(t *testing.T) {
+	bars := minuteBars(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 10)
+	if _, err := Resample(bars, "1m", ResampleOptions{}); err == nil {
+		t.Fatalf("expected same timeframe to fail")
+	}
+	if _, err := Resample(bars, "5m", ResampleOptions{Offset: 30 * time.Second}); err == nil {
+		t.Fatalf("expected misaligned offset to fail")
+	}
+	bars[3].Symbol = "ETHUSDT"
+	if _, err := Resample(bars, "5m", ResampleOptions{}); err == nil {
+		t.Fatalf("expected mixed symbols to fail")
+	}
+	if !CanResample("1h", "4h") || CanResample("4h", "1h") || CanResample("1d", "1h") || !CanResample("15m", "1w") {
+		t.Fatalf("unexpected CanResample results")
+	}
+}
@@ -0,0 +1,30 @@
+package dataingestion
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeframeDuration 回傳 K 線週期長度，無法辨識時回傳 0。
+func TimeframeDuration(tf string) time.Duration {
+	switch strings.ToLower(tf) {
+	case "1m":
+		return time.Minute
+	case "5m":
+		return 5 * time.Minute
+	case "15m":
+		return 15 * time.Minute
+	case "30m":
+		return 30 * time.Minute
+	case "1h":
+		return time.Hour
+	case "4h":
+		return 4 * time.Hour
+	case "1d":
+		return 24 * time.Hour
+	case "1w":
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
@@ -186,9 +186,9 @@ func (s *ScoringStrategy) IsExitTriggered(data analysis.DailyAnalysisResult) (bo
 	return score < s.ExitThreshold, score, nil
 }
 
-// ShouldExit evaluates all exit conditions including TP/SL, signal decay, and custom rules.
-func (s *ScoringStrategy) ShouldExit(data analysis.DailyAnalysisResult, pos tradingDomain.Position) (bool, string) {
-	// 1. Fixed Take Profit and Stop Loss
+// ExitLevels returns the stop-loss (negative) and take-profit thresholds as decimal returns.
+// Defaults are SL -2% and TP 5%; values above 1 are treated as percentages (2.0 => 2%).
+func (s *ScoringStrategy) ExitLevels() (float64, float64) {
 	sl := -0.02
 	if s.Risk.StopLossPct != nil {
 		sl = -(*s.Risk.StopLossPct)
@@ -201,29 +201,40 @@ func (s *ScoringStrategy) ShouldExit(data analysis.DailyAnalysisResult, pos trad
 		tp = *s.Risk.TakeProfitPct
 	}
 
-	if pos.EntryPrice > 0 {
-		change := (data.Close - pos.EntryPrice) / pos.EntryPrice
-		
-		// 處理百分比與小數的相容性 (如果設為 2.0 代表 2%，需除以 100 變為 0.02)
-		slVal := sl
-		if slVal < -1.0 || slVal > 1.0 {
-			slVal = slVal / 100.0
-		}
-		if slVal > 0 {
-			slVal = -slVal
-		}
+	// 處理百分比與小數的相容性 (如果設為 2.0 代表 2%，需除以 100 變為 0.02)
+	if sl < -1.0 || sl > 1.0 {
+		sl = sl / 100.0
+	}
+	if sl > 0 {
+		sl = -sl
+	}
+	if tp > 1.0 {
+		tp = tp / 100.0
+	}
+	return sl, tp
+}
 
-		tpVal := tp
-		if tpVal > 1.0 {
-			tpVal = tpVal / 100.0
-		}
+// HitsProtectiveLevel checks whether price has crossed the fixed stop-loss or take-profit of a position.
+func (s *ScoringStrategy) HitsProtectiveLevel(entryPrice, price float64) (bool, string) {
+	if entryPrice <= 0 || price <= 0 {
+		return false, ""
+	}
+	sl, tp := s.ExitLevels()
+	change := (price - entryPrice) / entryPrice
+	if change <= sl {
+		return true, fmt.Sprintf("止損 (%.2f%%)", sl*100)
+	}
+	if change >= tp {
+		return true, fmt.Sprintf("止盈 (%.2f%%)", tp*100)
+	}
+	return false, ""
+}
 
-		if change <= slVal {
-			return true, fmt.Sprintf("止損 (%.2f%%)", slVal*100)
-		}
-		if change >= tpVal {
-			return true, fmt.Sprintf("止盈 (%.2f%%)", tpVal*100)
-		}
+// ShouldExit evaluates all exit conditions including TP/SL, signal decay, and custom rules.
+func (s *ScoringStrategy) ShouldExit(data analysis.DailyAnalysisResult, pos tradingDomain.Position) (bool, string) {
+	// 1. Fixed Take Profit and Stop Loss
+	if hit, reason := s.HitsProtectiveLevel(pos.EntryPrice, data.Close); hit {
+		return true, reason
 	}
 
 	// 2. AI Signal Decay (Entry score drops below 50% of entry threshold)
@@ -0,0 +1,124 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ai-auto-trade/internal/domain/dataingestion"
+)
+
+// 策略種類：評分訊號、定期定額、現貨網格。
+const (
+	KindScoring = "scoring"
+	KindDCA     = "dca"
+	KindGrid    = "grid"
+)
+
+// DCAMultiplier 依分數調整單次投入金額：分數 <= MaxScore 時套用 Multiplier（取第一個符合的級距）。
+type DCAMultiplier struct {
+	MaxScore   float64 `json:"max_score"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// DCAConfig 為定期定額設定。
+type DCAConfig struct {
+	Amount        float64         `json:"amount"`                    // 每次投入的計價金額（USDT）
+	Interval      string          `json:"interval"`                  // 兩次買入的最短間隔，例如 1d、1w
+	Multipliers   []DCAMultiplier `json:"multipliers,omitempty"`     // 選用：依分數放大/縮小投入金額
+	TakeProfitPct float64         `json:"take_profit_pct,omitempty"` // 選用：平均成本獲利達此比例時全部賣出並重新開始
+	MaxOrders     int             `json:"max_orders,omitempty"`      // 選用：每輪最多買入次數
+}
+
+// GridConfig 為現貨網格設定：在 [Lower, Upper] 等差切出 Grids 格，每格掛 OrderSize 計價金額的限價單。
+type GridConfig struct {
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	Grids     int     `json:"grids"`
+	OrderSize float64 `json:"order_size"`
+}
+
+// IsSignalDriven 表示策略依評分訊號進出場（套用停損停利與評分門檻）；DCA 與網格自行管理出場。
+func (s *ScoringStrategy) IsSignalDriven() bool {
+	return s.Kind == "" || s.Kind == KindScoring
+}
+
+// Validate 檢查定期定額設定。
+func (c DCAConfig) Validate() error {
+	if c.Amount <= 0 {
+		return fmt.Errorf("dca amount must be positive")
+	}
+	if TimeframeDuration(c.Interval) <= 0 {
+		return fmt.Errorf("unsupported dca interval %q", c.Interval)
+	}
+	for _, m := range c.Multipliers {
+		if m.Multiplier < 0 {
+			return fmt.Errorf("dca multiplier must not be negative")
+		}
+	}
+	if c.TakeProfitPct < 0 || c.MaxOrders < 0 {
+		return fmt.Errorf("dca take_profit_pct and max_orders must not be negative")
+	}
+	return nil
+}
+
+// AmountFor 回傳在指定分數下的投入金額。
+func (c DCAConfig) AmountFor(score float64) float64 {
+	for _, m := range c.Multipliers {
+		if score <= m.MaxScore {
+			return c.Amount * m.Multiplier
+		}
+	}
+	return c.Amount
+}
+
+// Validate 檢查網格設定。
+func (c GridConfig) Validate() error {
+	if c.Lower <= 0 || c.Upper <= c.Lower {
+		return fmt.Errorf("grid range must satisfy 0 < lower < upper")
+	}
+	if c.Grids < 2 || c.Grids > 200 {
+		return fmt.Errorf("grid count must be between 2 and 200")
+	}
+	if c.OrderSize <= 0 {
+		return fmt.Errorf("grid order_size must be positive")
+	}
+	return nil
+}
+
+// Levels 回傳由低到高的 Grids+1 個價格。
+func (c GridConfig) Levels() []float64 {
+	out := make([]float64, c.Grids+1)
+	step := (c.Upper - c.Lower) / float64(c.Grids)
+	for i := range out {
+		out[i] = c.Lower + float64(i)*step
+	}
+	return out
+}
+
+// ParseKindParams 依策略種類解析並驗證 JSON 參數，scoring 不需要參數。
+func ParseKindParams(kind string, raw []byte) (*DCAConfig, *GridConfig, error) {
+	switch kind {
+	case "", KindScoring:
+		return nil, nil, nil
+	case KindDCA:
+		var c DCAConfig
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, nil, fmt.Errorf("invalid dca params: %w", err)
+		}
+		return &c, nil, c.Validate()
+	case KindGrid:
+		var c GridConfig
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, nil, fmt.Errorf("invalid grid params: %w", err)
+		}
+		return nil, &c, c.Validate()
+	default:
+		return nil, nil, fmt.Errorf("unsupported strategy kind %q", kind)
+	}
+}
+
+// TimeframeDuration 回傳 K 線週期長度，無法辨識時回傳 0。
+func TimeframeDuration(tf string) time.Duration {
+	return dataingestion.TimeframeDuration(tf)
+}
@@ -0,0 +1,40 @@
+package strategy
+
+import "testing"
+
+func TestParseKindParams(t *testing.T) {
+	dca, grid, err := ParseKindParams(KindDCA, []byte(`{"amount":50,"interval":"1w","multipliers":[{"max_score":30,"multiplier":2}]}`))
+	if err != nil || dca == nil || grid != nil {
+		t.Fatalf("expected dca config, got %+v %+v %v", dca, grid, err)
+	}
+	if dca.AmountFor(20) != 100 || dca.AmountFor(80) != 50 {
+		t.Fatalf("unexpected multiplier amounts %v %v", dca.AmountFor(20), dca.AmountFor(80))
+	}
+
+	_, grid, err = ParseKindParams(KindGrid, []byte(`{"lower":100,"upper":200,"grids":4,"order_size":10}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lv := grid.Levels(); len(lv) != 5 || lv[0] != 100 || lv[2] != 150 || lv[4] != 200 {
+		t.Fatalf("unexpected grid levels %v", lv)
+	}
+
+	bad := []struct {
+		kind string
+		raw  string
+	}{
+		{KindDCA, `{"amount":0,"interval":"1d"}`},
+		{KindDCA, `{"amount":10,"interval":"3d"}`},
+		{KindGrid, `{"lower":200,"upper":100,"grids":4,"order_size":10}`},
+		{KindGrid, `{"lower":100,"upper":200,"grids":1,"order_size":10}`},
+		{"martingale", `{}`},
+	}
+	for _, tc := range bad {
+		if _, _, err := ParseKindParams(tc.kind, []byte(tc.raw)); err == nil {
+			t.Errorf("expected error for %s %s", tc.kind, tc.raw)
+		}
+	}
+	if d, g, err := ParseKindParams(KindScoring, nil); d != nil || g != nil || err != nil {
+		t.Fatalf("scoring needs no params")
+	}
+}
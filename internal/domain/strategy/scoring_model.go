@@ -38,6 +38,14 @@ func loadScoringStrategyGORM(ctx context.Context, db *gorm.DB, field, value stri
 		ExitThreshold float64
 		IsActive      bool
 		Env           string
+		Exchange      string
+		Schedule      string
+		TradingWindow string
+		Kind          string
+		KindParams    []byte
+		Universe      []byte
+		MaxPositions  int
+		AnalysisVersion string
 		RiskSettings  []byte
 		CreatedAt     time.Time
 		UpdatedAt     time.Time
@@ -63,6 +71,25 @@ func loadScoringStrategyGORM(ctx context.Context, db *gorm.DB, field, value stri
 	s.ExitThreshold = res.ExitThreshold
 	s.IsActive = res.IsActive
 	s.Env = res.Env
+	s.Exchange = res.Exchange
+	s.Schedule = res.Schedule
+	s.TradingWindow = res.TradingWindow
+	s.Kind = res.Kind
+	if s.Kind == "" {
+		s.Kind = KindScoring
+	}
+	if s.DCA, s.Grid, err = ParseKindParams(s.Kind, res.KindParams); err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", res.Slug, err)
+	}
+	s.MaxPositions = res.MaxPositions
+	s.AnalysisVersion = res.AnalysisVersion
+	if len(res.Universe) > 0 && string(res.Universe) != "null" {
+		var u Universe
+		if err := json.Unmarshal(res.Universe, &u); err != nil {
+			return nil, fmt.Errorf("strategy %s: invalid universe: %w", res.Slug, err)
+		}
+		s.Universe = &u
+	}
 	s.CreatedAt = res.CreatedAt
 	s.UpdatedAt = res.UpdatedAt
 
@@ -135,6 +162,15 @@ type ScoringStrategy struct {
 	ExitThreshold float64        `json:"exit_threshold" db:"exit_threshold"`
 	IsActive      bool           `json:"is_active" db:"is_active"`
 	Env           string         `json:"env" gorm:"column:env"`
+	Exchange      string         `json:"exchange" gorm:"column:exchange"` // 空值代表預設交易所
+	Schedule      string         `json:"schedule" gorm:"column:schedule"` // cron 表示式，空值代表每根 K 線收盤時評估
+	TradingWindow string         `json:"trading_window" gorm:"column:trading_window"` // 例如 "mon-fri 09:00-17:00"，空值代表不限制
+	Kind          string         `json:"kind" gorm:"column:kind"`                     // scoring（預設）、dca、grid
+	DCA           *DCAConfig     `json:"dca,omitempty" gorm:"-"`
+	Grid          *GridConfig    `json:"grid,omitempty" gorm:"-"`
+	Universe      *Universe      `json:"universe,omitempty" gorm:"-"`                 // 設定時於候選清單中依分數輪動，BaseSymbol 僅作為觸發時鐘
+	MaxPositions  int            `json:"max_positions" gorm:"column:max_positions"`   // 同時持有的交易對上限，0 視為 1
+	AnalysisVersion string       `json:"analysis_version,omitempty" gorm:"column:analysis_version"` // 釘選的分析版本，空值代表啟用中的版本
 	Risk          tradingDomain.RiskSettings `json:"risk_settings" gorm:"-"`
 	Rules         []StrategyRule `json:"rules" gorm:"-"` 
 	EntryRules    []StrategyRule `json:"entry_rules" gorm:"-"`
@@ -0,0 +1,97 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Universe 定義策略的候選交易對：固定清單，或依儲存的日 K 取近 VolumeDays 日成交額前 TopN 名。
+type Universe struct {
+	Symbols    []string `json:"symbols,omitempty"`
+	TopN       int      `json:"top_n,omitempty"`
+	VolumeDays int      `json:"volume_days,omitempty"` // 動態選股的成交額回看天數，預設 30
+}
+
+// VolumeRanker 依近期成交額（收盤價 × 成交量）由高到低回傳交易對。
+type VolumeRanker interface {
+	TopSymbolsByVolume(ctx context.Context, since time.Time, n int) ([]string, error)
+}
+
+// Validate 檢查候選清單設定：固定清單與 TopN 擇一。
+func (u Universe) Validate() error {
+	if len(u.Symbols) == 0 && u.TopN <= 0 {
+		return fmt.Errorf("universe requires symbols or top_n")
+	}
+	if len(u.Symbols) > 0 && u.TopN > 0 {
+		return fmt.Errorf("universe accepts either symbols or top_n, not both")
+	}
+	if u.TopN > 100 || u.VolumeDays < 0 {
+		return fmt.Errorf("universe top_n must be at most 100 and volume_days must not be negative")
+	}
+	return nil
+}
+
+// IsDynamic 表示候選清單需依成交額動態選出。
+func (u Universe) IsDynamic() bool {
+	return len(u.Symbols) == 0 && u.TopN > 0
+}
+
+// Resolve 回傳截至 asOf 的候選交易對（大寫、去重）。動態清單需要 ranker。
+func (u Universe) Resolve(ctx context.Context, ranker VolumeRanker, asOf time.Time) ([]string, error) {
+	symbols := u.Symbols
+	if u.IsDynamic() {
+		if ranker == nil {
+			return nil, fmt.Errorf("data source does not support volume ranking")
+		}
+		days := u.VolumeDays
+		if days <= 0 {
+			days = 30
+		}
+		var err error
+		if symbols, err = ranker.TopSymbolsByVolume(ctx, asOf.AddDate(0, 0, -days), u.TopN); err != nil {
+			return nil, fmt.Errorf("rank symbols by volume: %w", err)
+		}
+	}
+	seen := make(map[string]bool, len(symbols))
+	out := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		sym = strings.ToUpper(strings.TrimSpace(sym))
+		if sym == "" || seen[sym] {
+			continue
+		}
+		seen[sym] = true
+		out = append(out, sym)
+	}
+	return out, nil
+}
+
+// IsMultiSymbol 表示策略以候選清單輪動，而非只交易 BaseSymbol。
+func (s *ScoringStrategy) IsMultiSymbol() bool {
+	return s.Universe != nil
+}
+
+// PositionLimit 回傳同時持有的交易對上限，未設定時為 1。
+func (s *ScoringStrategy) PositionLimit() int {
+	if s.MaxPositions <= 0 {
+		return 1
+	}
+	return s.MaxPositions
+}
+
+// Covers 判斷交易對是否可能屬於此策略（動態清單只能以 BaseSymbol 判斷）。
+func (s *ScoringStrategy) Covers(symbol string) bool {
+	if strings.EqualFold(s.BaseSymbol, symbol) {
+		return true
+	}
+	if s.Universe == nil {
+		return false
+	}
+	for _, sym := range s.Universe.Symbols {
+		if strings.EqualFold(sym, symbol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fixedRanker struct {
+	since time.Time
+	out   []string
+}
+
+func (r *fixedRanker) TopSymbolsByVolume(_ context.Context, since time.Time, n int) ([]string, error) {
+	r.since = since
+	return r.out[:n], nil
+}
+
+func TestUniverse_Resolve(t *testing.T) {
+	ctx := context.Background()
+	got, err := Universe{Symbols: []string{" btcusdt", "ETHUSDT", "BTCUSDT"}}.Resolve(ctx, nil, time.Now())
+	if err != nil || len(got) != 2 || got[0] != "BTCUSDT" || got[1] != "ETHUSDT" {
+		t.Fatalf("expected normalised, de-duplicated list, got %v (%v)", got, err)
+	}
+
+	asOf := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	ranker := &fixedRanker{out: []string{"SOLUSDT", "BTCUSDT", "ETHUSDT"}}
+	got, err = Universe{TopN: 2}.Resolve(ctx, ranker, asOf)
+	if err != nil || len(got) != 2 || got[0] != "SOLUSDT" {
+		t.Fatalf("unexpected dynamic universe %v (%v)", got, err)
+	}
+	if want := asOf.AddDate(0, 0, -30); !ranker.since.Equal(want) {
+		t.Fatalf("expected 30-day default lookback, got %v", ranker.since)
+	}
+	if _, err := (Universe{TopN: 2}).Resolve(ctx, nil, asOf); err == nil {
+		t.Fatalf("dynamic universe without a ranker must fail")
+	}
+
+	for _, u := range []Universe{{}, {Symbols: []string{"BTCUSDT"}, TopN: 3}, {TopN: 500}} {
+		if err := u.Validate(); err == nil {
+			t.Errorf("expected validation error for %+v", u)
+		}
+	}
+}
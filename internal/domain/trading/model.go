@@ -11,13 +11,19 @@ import (
 type Environment string
 
 const (
-	EnvTest  Environment = "test"
-	EnvProd  Environment = "prod"
-	EnvReal  Environment = "real"
-	EnvPaper Environment = "paper"
-	EnvBoth  Environment = "both"
+	EnvTest   Environment = "test"
+	EnvProd   Environment = "prod"
+	EnvReal   Environment = "real"
+	EnvPaper  Environment = "paper"
+	EnvBoth   Environment = "both"
+	EnvShadow Environment = "shadow" // 依正式排程評估並記錄訊號、假設成交與虛擬持倉，但不下單也不動模擬餘額
 )
 
+// IsSimulated 代表該環境不會送出真實訂單。
+func (e Environment) IsSimulated() bool {
+	return e == EnvPaper || e == EnvShadow
+}
+
 // Status 表示策略狀態。
 type Status string
 
@@ -113,7 +119,7 @@ func (s Strategy) Validate() error {
 		return fmt.Errorf("base_symbol is required")
 	}
 	switch s.Env {
-	case EnvTest, EnvProd, EnvReal, EnvPaper, EnvBoth, "":
+	case EnvTest, EnvProd, EnvReal, EnvPaper, EnvBoth, EnvShadow, "":
 	default:
 		return fmt.Errorf("unsupported env")
 	}
@@ -217,12 +223,15 @@ type TradeFilter struct {
 	Env        Environment
 	StartDate  *time.Time
 	EndDate    *time.Time
+	// All 不套用列表的筆數上限，報表與比對需要區間內的完整紀錄。
+	All bool
 }
 
 // Position 表示當前持倉。
 type Position struct {
 	ID         string      `json:"id"`
 	StrategyID string      `json:"strategy_id,omitempty"`
+	UserID     string      `json:"user_id,omitempty"` // 持倉擁有者；手動單為下單者，策略單為策略擁有者
 	Symbol     string      `json:"symbol"`
 	Env        Environment `json:"env"`
 	EntryDate  time.Time   `json:"entry_date"`
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+)
+
+// CreateBackfill 實作 dataingestion.BackfillStore。
+func (s *Store) CreateBackfill(ctx context.Context, job *dataingestion.BackfillJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = s.nextID()
+	s.backfills[job.ID] = cloneBackfill(*job)
+	return nil
+}
+
+func (s *Store) SaveBackfill(ctx context.Context, job *dataingestion.BackfillJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.backfills[job.ID]; !ok {
+		return dataingestion.ErrBackfillNotFound
+	}
+	s.backfills[job.ID] = cloneBackfill(*job)
+	return nil
+}
+
+func (s *Store) GetBackfill(ctx context.Context, id string) (*dataingestion.BackfillJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.backfills[id]
+	if !ok {
+		return nil, dataingestion.ErrBackfillNotFound
+	}
+	out := cloneBackfill(job)
+	return &out, nil
+}
+
+// ListBackfills 由新到舊回傳最近 50 筆工作。
+func (s *Store) ListBackfills(ctx context.Context, statuses ...dataingestion.BackfillStatus) ([]dataingestion.BackfillJob, error) {
+	s.mu.RLock()
+	out := make([]dataingestion.BackfillJob, 0)
+	for _, job := range s.backfills {
+		if len(statuses) > 0 && !containsStatus(statuses, job.Status) {
+			continue
+		}
+		out = append(out, cloneBackfill(job))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > 50 {
+		out = out[:50]
+	}
+	return out, nil
+}
+
+func containsStatus(statuses []dataingestion.BackfillStatus, st dataingestion.BackfillStatus) bool {
+	for _, v := range statuses {
+		if v == st {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneBackfill 複製檢查點內的切片，避免執行中的工作與查詢共用底層陣列。
+func cloneBackfill(j dataingestion.BackfillJob) dataingestion.BackfillJob {
+	j.Tasks = append([]dataingestion.BackfillTask(nil), j.Tasks...)
+	for i := range j.Tasks {
+		j.Tasks[i].Gaps = append([]dataingestion.TimeRange(nil), j.Tasks[i].Gaps...)
+	}
+	j.PendingAnalysis = append([]time.Time(nil), j.PendingAnalysis...)
+	j.AnalysisErrors = append([]string(nil), j.AnalysisErrors...)
+	return j
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+)
+
+func TestStore_Backfills(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	first := &dataingestion.BackfillJob{Status: dataingestion.BackfillRunning, CreatedAt: base,
+		Tasks: []dataingestion.BackfillTask{{Symbol: "BTCUSDT", Timeframe: "1h", Gaps: []dataingestion.TimeRange{{From: base, To: base.Add(time.Hour)}}}}}
+	second := &dataingestion.BackfillJob{Status: dataingestion.BackfillCompleted, CreatedAt: base.Add(time.Hour)}
+	for _, job := range []*dataingestion.BackfillJob{first, second} {
+		if err := s.CreateBackfill(ctx, job); err != nil || job.ID == "" {
+			t.Fatalf("CreateBackfill: id=%q err=%v", job.ID, err)
+		}
+	}
+
+	// 呼叫端後續修改不應影響已保存的檢查點
+	first.Tasks[0].Gaps[0].From = base.Add(30 * time.Minute)
+	got, err := s.GetBackfill(ctx, first.ID)
+	if err != nil || !got.Tasks[0].Gaps[0].From.Equal(base) {
+		t.Fatalf("expected stored checkpoint to be isolated, got %+v, %v", got, err)
+	}
+
+	active, _ := s.ListBackfills(ctx, dataingestion.BackfillQueued, dataingestion.BackfillRunning)
+	if len(active) != 1 || active[0].ID != first.ID {
+		t.Errorf("expected only the running job, got %+v", active)
+	}
+	all, _ := s.ListBackfills(ctx)
+	if len(all) != 2 || all[0].ID != second.ID {
+		t.Errorf("expected newest first, got %+v", all)
+	}
+
+	if err := s.SaveBackfill(ctx, &dataingestion.BackfillJob{ID: "missing"}); !errors.Is(err, dataingestion.ErrBackfillNotFound) {
+		t.Errorf("expected ErrBackfillNotFound, got %v", err)
+	}
+}
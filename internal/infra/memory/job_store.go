@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"ai-auto-trade/internal/application/jobs"
+)
+
+// maxJobRuns 為記憶體保留的執行紀錄上限。
+const maxJobRuns = 500
+
+// SaveRun 實作 jobs.Store，保存執行紀錄（含明細）的副本。
+func (s *Store) SaveRun(ctx context.Context, run *jobs.Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = s.nextID()
+	s.jobRuns = append(s.jobRuns, cloneRun(*run))
+	if len(s.jobRuns) > maxJobRuns {
+		s.jobRuns = s.jobRuns[len(s.jobRuns)-maxJobRuns:]
+	}
+	return nil
+}
+
+// ListRuns 依開始時間由新到舊分頁回傳，不含明細。
+func (s *Store) ListRuns(ctx context.Context, filter jobs.Filter) ([]jobs.Run, int, error) {
+	filter = filter.Normalize()
+	s.mu.RLock()
+	matched := make([]jobs.Run, 0)
+	for _, r := range s.jobRuns {
+		if filter.Matches(r) {
+			r.Ingestion.Items, r.Analysis.Items = nil, nil
+			matched = append(matched, r)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Start.After(matched[j].Start) })
+	total := len(matched)
+	if filter.Offset >= total {
+		return []jobs.Run{}, total, nil
+	}
+	end := min(filter.Offset+filter.Limit, total)
+	return matched[filter.Offset:end], total, nil
+}
+
+// GetRun 回傳含明細的執行紀錄。
+func (s *Store) GetRun(ctx context.Context, id string) (*jobs.Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.jobRuns {
+		if r.ID == id {
+			out := cloneRun(r)
+			return &out, nil
+		}
+	}
+	return nil, jobs.ErrNotFound
+}
+
+func cloneRun(r jobs.Run) jobs.Run {
+	r.Ingestion.Items = append([]jobs.Item(nil), r.Ingestion.Items...)
+	r.Analysis.Items = append([]jobs.Item(nil), r.Analysis.Items...)
+	return r
+}
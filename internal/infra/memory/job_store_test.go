@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/jobs"
+)
+
+func TestStore_JobRuns(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, kind := range []string{"auto", "backfill", "auto"} {
+		run := &jobs.Run{
+			Kind:      kind,
+			Start:     base.Add(time.Duration(i) * time.Hour),
+			Ingestion: jobs.Stage{Success: 1, Items: []jobs.Item{{Symbol: "BTCUSDT", Timeframe: "1d", Status: jobs.StatusSuccess}}},
+		}
+		if i == 1 {
+			run.Ingestion = jobs.Stage{Error: "no kline data", Items: []jobs.Item{{Symbol: "ETHUSDT", Status: jobs.StatusFailed, ErrorReason: "timeout"}}}
+		}
+		if err := s.SaveRun(ctx, run); err != nil || run.ID == "" {
+			t.Fatalf("SaveRun: id=%q err=%v", run.ID, err)
+		}
+	}
+
+	runs, total, err := s.ListRuns(ctx, jobs.Filter{Kind: "auto", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(runs) != 1 || !runs[0].Start.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("expected newest auto run of 2, got total=%d runs=%+v", total, runs)
+	}
+	if len(runs[0].Ingestion.Items) != 0 {
+		t.Error("list should not include items")
+	}
+
+	failed, total, _ := s.ListRuns(ctx, jobs.Filter{Status: jobs.StatusFailed})
+	if total != 1 || failed[0].Kind != "backfill" {
+		t.Fatalf("expected the failed backfill, got %+v", failed)
+	}
+	detail, err := s.GetRun(ctx, failed[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detail.Ingestion.Items) != 1 || detail.Ingestion.Items[0].ErrorReason != "timeout" {
+		t.Errorf("expected item detail, got %+v", detail.Ingestion.Items)
+	}
+	if _, err := s.GetRun(ctx, "missing"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
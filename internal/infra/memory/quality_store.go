@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+// QuarantineBars 實作 dataingestion.QualityStore；同一根 K 線尚待審核時覆寫內容。
+func (s *Store) QuarantineBars(ctx context.Context, bars []dataingestion.QuarantinedBar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range bars {
+		if i := s.findQuarantine(b.Price.Symbol, b.Price.Timeframe, b.Price.TradeDate); i >= 0 {
+			if s.quarantine[i].Status == dataingestion.QuarantinePending {
+				s.quarantine[i].Price = b.Price
+				s.quarantine[i].Issues = append([]dataDomain.QualityIssue(nil), b.Issues...)
+			}
+			continue
+		}
+		b.ID = s.nextID()
+		b.Status = dataingestion.QuarantinePending
+		b.Issues = append([]dataDomain.QualityIssue(nil), b.Issues...)
+		s.quarantine = append(s.quarantine, b)
+	}
+	return nil
+}
+
+func (s *Store) findQuarantine(symbol, timeframe string, barTime time.Time) int {
+	for i, q := range s.quarantine {
+		if q.Price.Symbol == symbol && q.Price.Timeframe == timeframe && q.Price.TradeDate.Equal(barTime) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ListQuarantine 依建立時間由新到舊回傳，預設 100 筆。
+func (s *Store) ListQuarantine(ctx context.Context, filter dataingestion.QuarantineFilter) ([]dataingestion.QuarantinedBar, error) {
+	s.mu.RLock()
+	out := make([]dataingestion.QuarantinedBar, 0)
+	for _, q := range s.quarantine {
+		if (filter.Symbol != "" && q.Price.Symbol != filter.Symbol) ||
+			(filter.Timeframe != "" && q.Price.Timeframe != filter.Timeframe) ||
+			(filter.Status != "" && q.Status != filter.Status) {
+			continue
+		}
+		out = append(out, q)
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *Store) GetQuarantine(ctx context.Context, id string) (*dataingestion.QuarantinedBar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, q := range s.quarantine {
+		if q.ID == id {
+			return &q, nil
+		}
+	}
+	return nil, dataingestion.ErrQuarantineNotFound
+}
+
+func (s *Store) ResolveQuarantine(ctx context.Context, bar dataingestion.QuarantinedBar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.quarantine {
+		if s.quarantine[i].ID == bar.ID {
+			s.quarantine[i].Status = bar.Status
+			s.quarantine[i].ResolvedAt = bar.ResolvedAt
+			s.quarantine[i].ResolvedBy = bar.ResolvedBy
+			return nil
+		}
+	}
+	return dataingestion.ErrQuarantineNotFound
+}
+
+func (s *Store) ResolvedBars(ctx context.Context, symbol, timeframe string, from, to time.Time) (map[time.Time]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[time.Time]string)
+	for _, q := range s.quarantine {
+		t := q.Price.TradeDate
+		if q.Status == dataingestion.QuarantinePending || q.Price.Symbol != symbol || q.Price.Timeframe != timeframe || t.Before(from) || !t.Before(to) {
+			continue
+		}
+		out[t.UTC()] = q.Status
+	}
+	return out, nil
+}
+
+func (s *Store) SaveQualityStatus(ctx context.Context, st dataingestion.QualityStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quality[st.Symbol+"|"+st.Timeframe] = st
+	return nil
+}
+
+func (s *Store) GetQualityStatus(ctx context.Context, symbol, timeframe string) (*dataingestion.QualityStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.quality[symbol+"|"+timeframe]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s *Store) ListQualityStatus(ctx context.Context) ([]dataingestion.QualityStatus, error) {
+	s.mu.RLock()
+	out := make([]dataingestion.QualityStatus, 0, len(s.quality))
+	for _, st := range s.quality {
+		out = append(out, st)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Symbol != out[j].Symbol {
+			return out[i].Symbol < out[j].Symbol
+		}
+		return out[i].Timeframe < out[j].Timeframe
+	})
+	return out, nil
+}
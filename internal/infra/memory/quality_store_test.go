@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+func TestStore_Quarantine(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	bar := time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC)
+	price := dataDomain.DailyPrice{Symbol: "BTCUSDT", Timeframe: "1h", TradeDate: bar, Open: 1, High: 1, Low: 1, Close: 1}
+	issues := []dataDomain.QualityIssue{{Check: dataDomain.CheckStale, Detail: "zero volume"}}
+
+	if err := s.QuarantineBars(ctx, []dataingestion.QuarantinedBar{{Price: price, Issues: issues, CreatedAt: bar}}); err != nil {
+		t.Fatal(err)
+	}
+	// 同一根 K 線再次被隔離時覆寫內容而非新增
+	price.Close = 2
+	_ = s.QuarantineBars(ctx, []dataingestion.QuarantinedBar{{Price: price, Issues: issues, CreatedAt: bar}})
+	pending, _ := s.ListQuarantine(ctx, dataingestion.QuarantineFilter{Status: dataingestion.QuarantinePending})
+	if len(pending) != 1 || pending[0].Price.Close != 2 || pending[0].ID == "" {
+		t.Fatalf("expected one pending bar, got %+v", pending)
+	}
+
+	got := pending[0]
+	got.Status, got.ResolvedBy = dataingestion.QuarantineRejected, "ops"
+	if err := s.ResolveQuarantine(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	resolved, _ := s.ResolvedBars(ctx, "BTCUSDT", "1h", bar, bar.Add(time.Hour))
+	if resolved[bar] != dataingestion.QuarantineRejected {
+		t.Fatalf("expected rejected bar, got %+v", resolved)
+	}
+	if _, err := s.GetQuarantine(ctx, "missing"); !errors.Is(err, dataingestion.ErrQuarantineNotFound) {
+		t.Fatalf("expected ErrQuarantineNotFound, got %v", err)
+	}
+
+	if st, _ := s.GetQualityStatus(ctx, "BTCUSDT", "1h"); st != nil {
+		t.Fatalf("expected no status before first check, got %+v", st)
+	}
+	_ = s.SaveQualityStatus(ctx, dataingestion.QualityStatus{Symbol: "BTCUSDT", Timeframe: "1h", LastBar: bar, Issues: issues})
+	list, _ := s.ListQualityStatus(ctx)
+	if len(list) != 1 || list[0].Passed {
+		t.Fatalf("unexpected statuses %+v", list)
+	}
+}
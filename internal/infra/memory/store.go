@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"ai-auto-trade/internal/application/analysis"
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/jobs"
 	analysisDomain "ai-auto-trade/internal/domain/analysis"
 	authDomain "ai-auto-trade/internal/domain/auth"
 	dataDomain "ai-auto-trade/internal/domain/dataingestion"
@@ -22,11 +24,17 @@ type Store struct {
 	passwords       map[string]string
 	tokens          map[string]tokenRecord
 	sessions        map[string]sessionRecord
-	tradingPairs    map[string]pairRecord                                    // id -> record
-	pairByCode      map[string]string                                        // pair+market -> id
-	dailyPrices     map[string]map[string]dataDomain.DailyPrice              // date -> stockID -> price
-	analysisResults map[string]map[string]analysisDomain.DailyAnalysisResult // date -> stockID -> result
+	tradingPairs    map[string]pairRecord                                               // id -> record
+	pairByCode      map[string]string                                                   // pair+market -> id
+	dailyPrices     map[string]map[string]dataDomain.DailyPrice                         // date -> stockID -> price
+	bars            map[string]map[int64]dataDomain.DailyPrice                          // symbol|timeframe -> open time (unix nano) -> 非日 K
+	analysisResults map[string]map[string]map[string]analysisDomain.DailyAnalysisResult // version -> date -> stockID -> result
+	versions        []analysis.Version
 	backtestPreset  map[string][]backtestPresetRecord
+	jobRuns         []jobs.Run // 依寫入順序，最多保留 maxJobRuns 筆
+	backfills       map[string]dataingestion.BackfillJob
+	quarantine      []dataingestion.QuarantinedBar
+	quality         map[string]dataingestion.QualityStatus // key: symbol|timeframe
 	idSeq           int64
 }
 
@@ -77,8 +85,12 @@ func NewStore() *Store {
 		tradingPairs:    make(map[string]pairRecord),
 		pairByCode:      make(map[string]string),
 		dailyPrices:     make(map[string]map[string]dataDomain.DailyPrice),
-		analysisResults: make(map[string]map[string]analysisDomain.DailyAnalysisResult),
+		bars:            make(map[string]map[int64]dataDomain.DailyPrice),
+		analysisResults: make(map[string]map[string]map[string]analysisDomain.DailyAnalysisResult),
+		versions:        []analysis.Version{{Name: analysis.DefaultVersion, Active: true, CreatedAt: time.Now()}},
 		backtestPreset:  make(map[string][]backtestPresetRecord),
+		backfills:       make(map[string]dataingestion.BackfillJob),
+		quality:         make(map[string]dataingestion.QualityStatus),
 	}
 }
 
@@ -370,7 +382,7 @@ func (s *Store) FindByDate(ctx context.Context, date time.Time, filter analysis.
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	dateKey := date.Format("2006-01-02")
-	results := s.analysisResults[dateKey]
+	results := s.activeResults()[dateKey]
 	var list []analysisDomain.DailyAnalysisResult
 	for _, r := range results {
 		if filter.OnlySuccess && !r.Success {
@@ -389,12 +401,23 @@ func (s *Store) FindByDate(ctx context.Context, date time.Time, filter analysis.
 	return list[pagination.Offset:end], total, nil
 }
 
-// FindHistory 依股票代碼與日期區間查詢歷史分析結果。
+// FindHistory 依股票代碼與日期區間查詢啟用中版本的歷史分析結果。
 func (s *Store) FindHistory(ctx context.Context, symbol string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.findHistory(s.activeVersion(), symbol, from, to, limit, onlySuccess), nil
+}
+
+// FindVersionHistory 同 FindHistory，讀取指定版本。
+func (s *Store) FindVersionHistory(ctx context.Context, version, symbol string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.findHistory(version, symbol, from, to, limit, onlySuccess), nil
+}
+
+func (s *Store) findHistory(version, symbol string, from, to *time.Time, limit int, onlySuccess bool) []analysisDomain.DailyAnalysisResult {
 	var all []analysisDomain.DailyAnalysisResult
-	for _, day := range s.analysisResults {
+	for _, day := range s.analysisResults[version] {
 		for _, r := range day {
 			if r.Symbol != symbol {
 				continue
@@ -418,7 +441,7 @@ func (s *Store) FindHistory(ctx context.Context, symbol string, from, to *time.T
 	if len(all) > limit {
 		all = all[len(all)-limit:]
 	}
-	return all, nil
+	return all
 }
 
 // Get 取得指定日期、指定股票的分析結果。
@@ -426,7 +449,7 @@ func (s *Store) Get(ctx context.Context, symbol string, date time.Time) (analysi
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	dateKey := date.Format("2006-01-02")
-	day := s.analysisResults[dateKey]
+	day := s.activeResults()[dateKey]
 	for _, r := range day {
 		if r.Symbol == symbol {
 			return r, nil
@@ -450,10 +473,18 @@ func (s *Store) UpsertTradingPair(pair, name string, market dataDomain.Market, i
 	return id
 }
 
-// InsertDailyPrice 寫入或覆蓋某日單檔的日 K。
+// InsertDailyPrice 寫入或覆蓋單檔 K 線；日 K 以日期為鍵，其他週期以交易對、週期與開盤時間為鍵。
 func (s *Store) InsertDailyPrice(price dataDomain.DailyPrice) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if price.Timeframe != "" && price.Timeframe != "1d" {
+		key := price.Symbol + "|" + price.Timeframe
+		if _, ok := s.bars[key]; !ok {
+			s.bars[key] = make(map[int64]dataDomain.DailyPrice)
+		}
+		s.bars[key][price.TradeDate.UnixNano()] = price
+		return
+	}
 	dateKey := price.TradeDate.Format("2006-01-02")
 	if _, ok := s.dailyPrices[dateKey]; !ok {
 		s.dailyPrices[dateKey] = make(map[string]dataDomain.DailyPrice)
@@ -461,15 +492,29 @@ func (s *Store) InsertDailyPrice(price dataDomain.DailyPrice) {
 	s.dailyPrices[dateKey][price.Symbol] = price
 }
 
-// InsertAnalysisResult 寫入或覆蓋分析結果。
+// InsertAnalysisResult 寫入或覆蓋分析結果；未指定版本時寫入 DefaultVersion。
 func (s *Store) InsertAnalysisResult(res analysisDomain.DailyAnalysisResult) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	version := res.Version
+	if version == "" {
+		version = analysis.DefaultVersion
+	}
+	byDate, ok := s.analysisResults[version]
+	if !ok {
+		byDate = make(map[string]map[string]analysisDomain.DailyAnalysisResult)
+		s.analysisResults[version] = byDate
+	}
 	dateKey := res.TradeDate.Format("2006-01-02")
-	if _, ok := s.analysisResults[dateKey]; !ok {
-		s.analysisResults[dateKey] = make(map[string]analysisDomain.DailyAnalysisResult)
+	if _, ok := byDate[dateKey]; !ok {
+		byDate[dateKey] = make(map[string]analysisDomain.DailyAnalysisResult)
 	}
-	s.analysisResults[dateKey][res.Symbol] = res
+	byDate[dateKey][res.Symbol] = res
+}
+
+// activeResults 回傳啟用中版本的結果；呼叫端需持有鎖。
+func (s *Store) activeResults() map[string]map[string]analysisDomain.DailyAnalysisResult {
+	return s.analysisResults[s.activeVersion()]
 }
 
 // Accessors for prices
@@ -489,6 +534,23 @@ func (s *Store) PricesByPair(pair string) []dataDomain.DailyPrice {
 	return out
 }
 
+// BarsByPair 取得單一交易對指定週期的全部 K 線並依時間排序；空週期視為日 K。
+func (s *Store) BarsByPair(pair, timeframe string) []dataDomain.DailyPrice {
+	if timeframe == "" || timeframe == "1d" {
+		return s.PricesByPair(pair)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]dataDomain.DailyPrice, 0, len(s.bars[pair+"|"+timeframe]))
+	for _, p := range s.bars[pair+"|"+timeframe] {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].TradeDate.Before(out[j].TradeDate)
+	})
+	return out
+}
+
 // PricesByDate 取得指定日期的全市場日 K。
 func (s *Store) PricesByDate(date time.Time) []dataDomain.DailyPrice {
 	s.mu.RLock()
@@ -501,11 +563,40 @@ func (s *Store) PricesByDate(date time.Time) []dataDomain.DailyPrice {
 	return out
 }
 
+// TopSymbolsByVolume 依 since 之後日 K 的成交額加總排序，回傳前 n 個交易對。
+func (s *Store) TopSymbolsByVolume(since time.Time, n int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sums := make(map[string]float64)
+	for _, day := range s.dailyPrices {
+		for sym, p := range day {
+			if p.TradeDate.Before(since) || (p.Timeframe != "" && p.Timeframe != "1d") {
+				continue
+			}
+			sums[sym] += p.Close * float64(p.Volume)
+		}
+	}
+	out := make([]string, 0, len(sums))
+	for sym := range sums {
+		out = append(out, sym)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if sums[out[i]] != sums[out[j]] {
+			return sums[out[i]] > sums[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
 // HasAnalysisForDate 回傳指定交易日是否已有分析結果。
 func (s *Store) HasAnalysisForDate(date time.Time) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.analysisResults[date.Format("2006-01-02")]) > 0
+	return len(s.activeResults()[date.Format("2006-01-02")]) > 0
 }
 
 // LatestAnalysisDate 回傳最新的分析日期（成功與否皆考慮）。
@@ -513,7 +604,7 @@ func (s *Store) LatestAnalysisDate() (time.Time, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	var latest time.Time
-	for dateKey := range s.analysisResults {
+	for dateKey := range s.activeResults() {
 		d, err := time.Parse("2006-01-02", dateKey)
 		if err != nil {
 			continue
@@ -72,6 +72,25 @@ func TestStore_DataIngestion(t *testing.T) {
 			t.Error("PricesByPair failed")
 		}
 	})
+
+	t.Run("InsertIntradayBars", func(t *testing.T) {
+		open := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			s.InsertDailyPrice(dataingestion.DailyPrice{Symbol: "ETHUSDT", Timeframe: "1h", TradeDate: open.Add(time.Duration(2-i) * time.Hour), Close: float64(i)})
+		}
+		s.InsertDailyPrice(dataingestion.DailyPrice{Symbol: "ETHUSDT", Timeframe: "4h", TradeDate: open, Close: 9})
+
+		bars := s.BarsByPair("ETHUSDT", "1h")
+		if len(bars) != 3 || !bars[0].TradeDate.Equal(open) || bars[2].Close != 0 {
+			t.Fatalf("unexpected 1h bars: %+v", bars)
+		}
+		if got := s.BarsByPair("ETHUSDT", "4h"); len(got) != 1 || got[0].Close != 9 {
+			t.Fatalf("unexpected 4h bars: %+v", got)
+		}
+		if got := s.PricesByPair("ETHUSDT"); len(got) != 0 {
+			t.Fatalf("intraday bars leaked into daily prices: %+v", got)
+		}
+	})
 }
 
 func TestStore_Sessions(t *testing.T) {
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +22,9 @@ type TradingRepo struct {
 	positions  map[string]tradingDomain.Position
 	logs       []tradingDomain.LogEntry
 	reports    map[string][]tradingDomain.Report
+	paper      map[string]map[string]float64
+	halts      map[string]trading.HaltState
+	grids      []trading.GridOrder
 }
 
 // NewTradingRepo 建立記憶體實例。
@@ -30,6 +34,8 @@ func NewTradingRepo() *TradingRepo {
 		backtests:  make(map[string][]tradingDomain.BacktestRecord),
 		positions:  make(map[string]tradingDomain.Position),
 		reports:    make(map[string][]tradingDomain.Report),
+		paper:      make(map[string]map[string]float64),
+		halts:      make(map[string]trading.HaltState),
 	}
 }
 
@@ -232,15 +238,32 @@ func (r *TradingRepo) ListTrades(_ context.Context, filter tradingDomain.TradeFi
 	return out, nil
 }
 
+func (r *TradingRepo) SumRealizedPnL(_ context.Context, env tradingDomain.Environment, since time.Time) (map[string]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]float64)
+	for _, t := range r.trades {
+		if t.Env != env || t.PNL == nil || t.ExitDate == nil || t.ExitDate.Before(since) {
+			continue
+		}
+		id := t.StrategyID
+		if id == "" {
+			id = "manual"
+		}
+		out[id] += *t.PNL
+	}
+	return out, nil
+}
+
 func (r *TradingRepo) GetOpenPosition(_ context.Context, strategyID string, env tradingDomain.Environment) (*tradingDomain.Position, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	key := fmt.Sprintf("%s|%s", strategyID, env)
-	p, ok := r.positions[key]
-	if !ok || p.Status != "open" {
-		return nil, nil
+	for _, p := range r.positions {
+		if p.StrategyID == strategyID && p.Env == env && p.Status == "open" {
+			return &p, nil
+		}
 	}
-	return &p, nil
+	return nil, nil
 }
 
 func (r *TradingRepo) GetPosition(_ context.Context, id string) (*tradingDomain.Position, error) {
@@ -269,7 +292,8 @@ func (r *TradingRepo) ListOpenPositions(_ context.Context) ([]tradingDomain.Posi
 func (r *TradingRepo) UpsertPosition(_ context.Context, p tradingDomain.Position) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	key := fmt.Sprintf("%s|%s", p.StrategyID, p.Env)
+	// 多交易對策略可同時持有多個部位，以交易對區分
+	key := fmt.Sprintf("%s|%s|%s", p.StrategyID, p.Env, strings.ToUpper(p.Symbol))
 	if p.ID == "" {
 		p.ID = r.nextID("pos")
 	}
@@ -366,3 +390,105 @@ func (r *TradingRepo) LoadScoringStrategyByID(_ context.Context, _ string) (*str
 func (r *TradingRepo) ListActiveScoringStrategies(ctx context.Context) ([]*strategyDomain.ScoringStrategy, error) {
 	return nil, nil
 }
+
+// LoadPaperBalances 回傳模擬帳戶餘額副本。
+func (r *TradingRepo) LoadPaperBalances(_ context.Context, accountID string) (map[string]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]float64, len(r.paper[accountID]))
+	for asset, amount := range r.paper[accountID] {
+		out[asset] = amount
+	}
+	return out, nil
+}
+
+// OpenPaperAccount 寫入模擬帳戶初始餘額，已存在的資產不變。
+func (r *TradingRepo) OpenPaperAccount(_ context.Context, accountID string, initial map[string]float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paper[accountID] == nil {
+		r.paper[accountID] = make(map[string]float64)
+	}
+	for asset, amount := range initial {
+		if _, ok := r.paper[accountID][asset]; !ok {
+			r.paper[accountID][asset] = amount
+		}
+	}
+	return nil
+}
+
+// ApplyPaperDeltas 一次加減模擬帳戶多個資產餘額，任一資產不足時整筆不變。
+func (r *TradingRepo) ApplyPaperDeltas(_ context.Context, accountID string, deltas map[string]float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bal := r.paper[accountID]
+	for asset, d := range deltas {
+		if bal[asset]+d < -1e-9 {
+			return fmt.Errorf("%w: need %.8f %s, available %.8f", trading.ErrInsufficientFunds, -d, asset, bal[asset])
+		}
+	}
+	if bal == nil {
+		bal = make(map[string]float64)
+		r.paper[accountID] = bal
+	}
+	for asset, d := range deltas {
+		bal[asset] = math.Max(bal[asset]+d, 0)
+	}
+	return nil
+}
+
+// LoadHaltStates 回傳所有 kill switch 與熔斷狀態。
+func (r *TradingRepo) LoadHaltStates(_ context.Context) ([]trading.HaltState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]trading.HaltState, 0, len(r.halts))
+	for _, st := range r.halts {
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// SaveHaltState 新增或更新單一狀態。
+func (r *TradingRepo) SaveHaltState(_ context.Context, st trading.HaltState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.halts[st.Scope] = st
+	return nil
+}
+
+// LoadOpenGridOrders 回傳策略在指定環境仍掛著與待送出的網格單。
+func (r *TradingRepo) LoadOpenGridOrders(_ context.Context, strategyID string, env tradingDomain.Environment) ([]trading.GridOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []trading.GridOrder
+	for _, o := range r.grids {
+		if o.StrategyID == strategyID && o.Env == env && (o.Status == trading.OrderStatusNew || o.Status == trading.GridOrderPending) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// SaveGridOrder 新增或依 ID 更新網格單。
+func (r *TradingRepo) SaveGridOrder(_ context.Context, o trading.GridOrder) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if o.ID != "" {
+		for i := range r.grids {
+			if r.grids[i].ID == o.ID {
+				r.grids[i] = o
+				return o.ID, nil
+			}
+		}
+	} else {
+		o.ID = r.nextID("grid")
+	}
+	r.grids = append(r.grids, o)
+	return o.ID, nil
+}
+
+var (
+	_ trading.PaperLedger = (*TradingRepo)(nil)
+	_ trading.HaltStore   = (*TradingRepo)(nil)
+	_ trading.GridStore   = (*TradingRepo)(nil)
+)
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+
+	"ai-auto-trade/internal/application/analysis"
+)
+
+// ListVersions 實作 analysis.VersionStore，依註冊順序回傳。
+func (s *Store) ListVersions(ctx context.Context) ([]analysis.Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]analysis.Version(nil), s.versions...), nil
+}
+
+func (s *Store) GetVersion(ctx context.Context, name string) (analysis.Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, v := range s.versions {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return analysis.Version{}, analysis.ErrVersionNotFound
+}
+
+func (s *Store) CreateVersion(ctx context.Context, v analysis.Version) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.versions {
+		if existing.Name == v.Name {
+			return analysis.ErrVersionExists
+		}
+	}
+	v.Active = false
+	s.versions = append(s.versions, v)
+	return nil
+}
+
+// ActivateVersion 將指定版本設為唯一啟用中的版本。
+func (s *Store) ActivateVersion(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for _, v := range s.versions {
+		found = found || v.Name == name
+	}
+	if !found {
+		return analysis.ErrVersionNotFound
+	}
+	for i := range s.versions {
+		s.versions[i].Active = s.versions[i].Name == name
+	}
+	return nil
+}
+
+// activeVersion 回傳啟用中的版本名稱；呼叫端需持有鎖。
+func (s *Store) activeVersion() string {
+	for _, v := range s.versions {
+		if v.Active {
+			return v.Name
+		}
+	}
+	return analysis.DefaultVersion
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/analysis"
+	analysisDomain "ai-auto-trade/internal/domain/analysis"
+)
+
+func TestStore_AnalysisVersions(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.InsertAnalysisResult(analysisDomain.DailyAnalysisResult{Symbol: "BTCUSDT", TradeDate: day, Version: analysis.DefaultVersion, Score: 40, Success: true})
+	s.InsertAnalysisResult(analysisDomain.DailyAnalysisResult{Symbol: "BTCUSDT", TradeDate: day, Version: "v2", Score: 55, Success: true})
+
+	if err := s.CreateVersion(ctx, analysis.Version{Name: "v2", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateVersion(ctx, analysis.Version{Name: "v2"}); !errors.Is(err, analysis.ErrVersionExists) {
+		t.Fatalf("expected ErrVersionExists, got %v", err)
+	}
+	if v, _ := s.GetVersion(ctx, "v2"); v.Active {
+		t.Fatal("new versions must not start active")
+	}
+
+	// 兩個版本的結果並存，預設讀取啟用中的版本
+	got, _ := s.FindHistory(ctx, "BTCUSDT", nil, nil, 10, true)
+	if len(got) != 1 || got[0].Score != 40 {
+		t.Fatalf("expected default version result, got %+v", got)
+	}
+	pinned, _ := s.FindVersionHistory(ctx, "v2", "BTCUSDT", nil, nil, 10, true)
+	if len(pinned) != 1 || pinned[0].Score != 55 {
+		t.Fatalf("expected v2 result, got %+v", pinned)
+	}
+
+	if err := s.ActivateVersion(ctx, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Get(ctx, "BTCUSDT", day); err != nil || res.Score != 55 {
+		t.Fatalf("expected v2 after activation, got %+v, %v", res, err)
+	}
+	if err := s.ActivateVersion(ctx, "missing"); !errors.Is(err, analysis.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+}
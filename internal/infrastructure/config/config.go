@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -18,11 +19,19 @@ type Config struct {
 	Ingestion IngestionConfig `yaml:"ingestion"`
 	Notifier  NotifierConfig  `yaml:"notifier"`
 	Binance   BinanceConfig   `yaml:"binance"`
+	Bybit     BybitConfig     `yaml:"bybit"`
 	AutoTrade AutoTradeConfig `yaml:"auto_trade"`
+	Paper     PaperConfig     `yaml:"paper"`
+	Risk      RiskConfig      `yaml:"risk"`
+	Breaker   BreakerConfig   `yaml:"breaker"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Leader    LeaderConfig    `yaml:"leader"`
+	Analysis  AnalysisConfig  `yaml:"analysis"`
 }
 
 type HTTPConfig struct {
-	Addr string `yaml:"addr"`
+	Addr            string        `yaml:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // 收到 SIGTERM 後等待請求與下單完成的上限
 }
 
 type DBConfig struct {
@@ -42,6 +51,18 @@ type IngestionConfig struct {
 	UseSynthetic      bool          `yaml:"use_synthetic"`
 	AutoInterval      time.Duration `yaml:"auto_interval"`
 	BackfillStartDate string        `yaml:"backfill_start_date"`
+	Exchange          string        `yaml:"exchange"` // K 線來源交易所（binance、bybit）
+	Symbols           []string      `yaml:"symbols"`    // 觀察清單，預設 BTCUSDT
+	Timeframes        []string      `yaml:"timeframes"` // 擷取週期（1m、15m、1h、4h、1d），預設 1d
+	LookbackBars      int           `yaml:"lookback_bars"`
+	ResampleFrom      string        `yaml:"resample_from"` // 非空時只抓此週期，較粗的週期以重新取樣產生
+	Quality           QualityConfig `yaml:"quality"`
+}
+
+// QualityConfig 設定寫入前的資料品質檢查；零值使用預設門檻。
+type QualityConfig struct {
+	OutlierSigma     float64 `yaml:"outlier_sigma"`     // 報酬率超過幾倍近期波動視為離群，預設 8
+	VolatilityWindow int     `yaml:"volatility_window"` // 估計波動的報酬率根數，預設 30
 }
 
 type NotifierConfig struct {
@@ -63,11 +84,65 @@ type BinanceConfig struct {
 	APIKey     string `yaml:"api_key"`
 	APISecret  string `yaml:"api_secret"`
 	UseTestnet bool   `yaml:"use_testnet"`
+	UserStream   bool `yaml:"user_stream"`   // 啟用使用者資料流（即時成交與餘額）
+	MarketStream bool `yaml:"market_stream"` // 啟用 K 線與最佳報價串流（收盤評估、即時停損停利）
+}
+
+type BybitConfig struct {
+	APIKey     string `yaml:"api_key"`
+	APISecret  string `yaml:"api_secret"`
+	UseTestnet bool   `yaml:"use_testnet"`
 }
 
 
 type AutoTradeConfig struct {
-	Interval time.Duration `yaml:"interval"`
+	Interval   time.Duration `yaml:"interval"`
+	Workers    int           `yaml:"workers"`     // 同時評估的策略/環境數上限
+	RunTimeout time.Duration `yaml:"run_timeout"` // 單次策略評估期限
+}
+
+// PaperConfig 控制 paper 環境的模擬帳戶與成交模型。
+type PaperConfig struct {
+	InitialBalance float64 `yaml:"initial_balance"` // 新帳戶初始 USDT
+	FeeRate        float64 `yaml:"fee_rate"`        // taker 手續費率
+	SlippageBps    float64 `yaml:"slippage_bps"`    // 固定滑價（基點）
+	ImpactBps      float64 `yaml:"impact_bps"`      // 每 10,000 USDT 名目額外滑價（基點）
+}
+
+// RiskConfig 為所有訂單共用的下單前風控上限，0 代表不限制。
+type RiskConfig struct {
+	MaxOrderNotional     float64 `yaml:"max_order_notional"`      // 單筆最大名目（USDT）
+	MaxAssetExposure     float64 `yaml:"max_asset_exposure"`      // 單一資產最大持倉名目（USDT）
+	MaxDailyLoss         float64 `yaml:"max_daily_loss"`          // 當日已實現虧損上限（USDT）
+	MaxOpenPositions     int     `yaml:"max_open_positions"`      // 每位使用者同時持倉上限
+	MaxPriceDeviationPct float64 `yaml:"max_price_deviation_pct"` // 訊號價與最新成交價最大偏離（0.05 = 5%）
+}
+
+// BreakerConfig 為策略熔斷門檻，0 代表停用該條件。
+type BreakerConfig struct {
+	MaxConsecutiveLosses int     `yaml:"max_consecutive_losses"` // 連續虧損筆數
+	MaxDrawdownPct       float64 `yaml:"max_drawdown_pct"`       // 自權益高點回落比例（0.2 = 20%）
+	MaxConsecutiveErrors int     `yaml:"max_consecutive_errors"` // 連續交易所錯誤次數
+}
+
+// SchedulerConfig 控制依 K 線收盤對齊的排程器；啟用時取代 auto_trade.interval 與 ingestion.auto_interval 的固定週期。
+type SchedulerConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Timezone      string        `yaml:"timezone"`       // cron 與交易時段使用的時區（IANA 名稱）
+	SettleDelay   time.Duration `yaml:"settle_delay"`   // K 線收盤後等待交易所完成資料的時間
+	PipelineCron  string        `yaml:"pipeline_cron"`  // 無策略到期時資料管線的獨立排程
+	TradingWindow string        `yaml:"trading_window"` // 策略未設定時套用的預設交易時段
+}
+
+// LeaderConfig 控制多實例部署的 leader 選舉，只有 leader 會執行排程與交易 worker。
+type LeaderConfig struct {
+	LockKey  int64         `yaml:"lock_key"` // Postgres advisory lock 鍵值，同一套部署須一致
+	Interval time.Duration `yaml:"interval"` // 競選重試與存活檢查間隔
+}
+
+// AnalysisConfig 控制分析用例；分析版本指定的分數模型從 ModelDir 載入。
+type AnalysisConfig struct {
+	ModelDir string `yaml:"model_dir"` // JSON 分數模型檔所在目錄，預設 models
 }
 
 // LoadFromFile 從 YAML 組態檔載入設定。
@@ -75,7 +150,8 @@ func LoadFromFile(path string) (Config, error) {
 	// 嘗試載入 .env 檔案（如果存在）
 	_ = godotenv.Load()
 
-	var cfg Config
+	// 手續費與滑價可明確設為 0，因此預設值在解析前填入，只有 YAML 未設定時才保留
+	cfg := Config{Paper: PaperConfig{FeeRate: 0.001, SlippageBps: 5}}
 	data, err := os.ReadFile(path)
 	if err == nil {
 		if err := yaml.Unmarshal(data, &cfg); err != nil {
@@ -115,6 +191,48 @@ func applyDefaults(cfg Config) Config {
 	if cfg.Ingestion.AutoInterval == 0 {
 		cfg.Ingestion.AutoInterval = time.Hour
 	}
+	if cfg.Ingestion.Exchange == "" {
+		cfg.Ingestion.Exchange = "binance"
+	}
+	if len(cfg.Ingestion.Symbols) == 0 {
+		cfg.Ingestion.Symbols = []string{"BTCUSDT"}
+	}
+	if len(cfg.Ingestion.Timeframes) == 0 {
+		cfg.Ingestion.Timeframes = []string{"1d"}
+	}
+	if cfg.Ingestion.LookbackBars == 0 {
+		cfg.Ingestion.LookbackBars = 5
+	}
+	if cfg.Scheduler.Timezone == "" {
+		cfg.Scheduler.Timezone = "UTC"
+	}
+	if cfg.Scheduler.SettleDelay == 0 {
+		cfg.Scheduler.SettleDelay = 5 * time.Second
+	}
+	if cfg.Scheduler.PipelineCron == "" {
+		cfg.Scheduler.PipelineCron = "@hourly"
+	}
+	if cfg.HTTP.ShutdownTimeout == 0 {
+		cfg.HTTP.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Leader.LockKey == 0 {
+		cfg.Leader.LockKey = 727001
+	}
+	if cfg.Leader.Interval == 0 {
+		cfg.Leader.Interval = 10 * time.Second
+	}
+	if cfg.AutoTrade.Workers == 0 {
+		cfg.AutoTrade.Workers = 4
+	}
+	if cfg.AutoTrade.RunTimeout == 0 {
+		cfg.AutoTrade.RunTimeout = 2 * time.Minute
+	}
+	if cfg.Paper.InitialBalance == 0 {
+		cfg.Paper.InitialBalance = 10000
+	}
+	if cfg.Analysis.ModelDir == "" {
+		cfg.Analysis.ModelDir = "models"
+	}
 	if cfg.Notifier.Telegram.Interval == 0 {
 		cfg.Notifier.Telegram.Interval = time.Hour
 	}
@@ -166,6 +284,21 @@ func applyEnv(cfg Config) Config {
 	if val := os.Getenv("BINANCE_USE_TESTNET"); val != "" {
 		cfg.Binance.UseTestnet = (val == "true")
 	}
+	if val := os.Getenv("BINANCE_USER_STREAM"); val != "" {
+		cfg.Binance.UserStream = (val == "true")
+	}
+	if val := os.Getenv("BINANCE_MARKET_STREAM"); val != "" {
+		cfg.Binance.MarketStream = (val == "true")
+	}
+	if val := os.Getenv("BYBIT_API_KEY"); val != "" {
+		cfg.Bybit.APIKey = val
+	}
+	if val := os.Getenv("BYBIT_API_SECRET"); val != "" {
+		cfg.Bybit.APISecret = val
+	}
+	if val := os.Getenv("BYBIT_USE_TESTNET"); val != "" {
+		cfg.Bybit.UseTestnet = (val == "true")
+	}
 
 
 	if val := os.Getenv("USE_SYNTHETIC"); val != "" {
@@ -174,15 +307,133 @@ func applyEnv(cfg Config) Config {
 	if val := os.Getenv("BACKFILL_START_DATE"); val != "" {
 		cfg.Ingestion.BackfillStartDate = val
 	}
+	if val := os.Getenv("INGESTION_EXCHANGE"); val != "" {
+		cfg.Ingestion.Exchange = val
+	}
+	if val := os.Getenv("INGESTION_SYMBOLS"); val != "" {
+		cfg.Ingestion.Symbols = splitList(val)
+	}
+	if val := os.Getenv("INGESTION_TIMEFRAMES"); val != "" {
+		cfg.Ingestion.Timeframes = splitList(val)
+	}
+	if val := os.Getenv("INGESTION_RESAMPLE_FROM"); val != "" {
+		cfg.Ingestion.ResampleFrom = val
+	}
+	if val := os.Getenv("ANALYSIS_MODEL_DIR"); val != "" {
+		cfg.Analysis.ModelDir = val
+	}
 	if val := os.Getenv("AUTO_INTERVAL"); val != "" {
 		if d, err := time.ParseDuration(val); err == nil {
 			cfg.Ingestion.AutoInterval = d
 		}
 	}
+	if val := os.Getenv("PAPER_INITIAL_BALANCE"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Paper.InitialBalance = f
+		}
+	}
+	if val := os.Getenv("PAPER_FEE_RATE"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Paper.FeeRate = f
+		}
+	}
+	if val := os.Getenv("PAPER_SLIPPAGE_BPS"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Paper.SlippageBps = f
+		}
+	}
+	if val := os.Getenv("RISK_MAX_ORDER_NOTIONAL"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Risk.MaxOrderNotional = f
+		}
+	}
+	if val := os.Getenv("RISK_MAX_ASSET_EXPOSURE"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Risk.MaxAssetExposure = f
+		}
+	}
+	if val := os.Getenv("RISK_MAX_DAILY_LOSS"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Risk.MaxDailyLoss = f
+		}
+	}
+	if val := os.Getenv("RISK_MAX_OPEN_POSITIONS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			cfg.Risk.MaxOpenPositions = n
+		}
+	}
+	if val := os.Getenv("RISK_MAX_PRICE_DEVIATION_PCT"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Risk.MaxPriceDeviationPct = f
+		}
+	}
+	if val := os.Getenv("BREAKER_MAX_CONSECUTIVE_LOSSES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			cfg.Breaker.MaxConsecutiveLosses = n
+		}
+	}
+	if val := os.Getenv("BREAKER_MAX_DRAWDOWN_PCT"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			cfg.Breaker.MaxDrawdownPct = f
+		}
+	}
+	if val := os.Getenv("BREAKER_MAX_CONSECUTIVE_ERRORS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			cfg.Breaker.MaxConsecutiveErrors = n
+		}
+	}
+	if val := os.Getenv("SCHEDULER_ENABLED"); val != "" {
+		cfg.Scheduler.Enabled = (val == "true")
+	}
+	if val := os.Getenv("SCHEDULER_TIMEZONE"); val != "" {
+		cfg.Scheduler.Timezone = val
+	}
+	if val := os.Getenv("SCHEDULER_PIPELINE_CRON"); val != "" {
+		cfg.Scheduler.PipelineCron = val
+	}
+	if val := os.Getenv("SCHEDULER_TRADING_WINDOW"); val != "" {
+		cfg.Scheduler.TradingWindow = val
+	}
+	if val := os.Getenv("HTTP_SHUTDOWN_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			cfg.HTTP.ShutdownTimeout = d
+		}
+	}
+	if val := os.Getenv("LEADER_LOCK_KEY"); val != "" {
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			cfg.Leader.LockKey = n
+		}
+	}
+	if val := os.Getenv("LEADER_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			cfg.Leader.Interval = d
+		}
+	}
 	if val := os.Getenv("AUTO_TRADE_INTERVAL"); val != "" {
 		if d, err := time.ParseDuration(val); err == nil {
 			cfg.AutoTrade.Interval = d
 		}
 	}
+	if val := os.Getenv("AUTO_TRADE_WORKERS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			cfg.AutoTrade.Workers = n
+		}
+	}
+	if val := os.Getenv("AUTO_TRADE_RUN_TIMEOUT"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			cfg.AutoTrade.RunTimeout = d
+		}
+	}
 	return cfg
 }
+
+// splitList 解析以逗號分隔的環境變數，忽略空白項目。
+func splitList(val string) []string {
+	var out []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
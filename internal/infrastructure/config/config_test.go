@@ -35,6 +35,7 @@ func TestConfig_ApplyEnvFull(t *testing.T) {
 	os.Setenv("TELEGRAM_ENABLED", "true")
 	os.Setenv("BINANCE_USE_TESTNET", "true")
 	os.Setenv("AUTO_INTERVAL", "5m")
+	os.Setenv("INGESTION_SYMBOLS", "btcusdt, ETHUSDT,")
 	
 	defer func() {
 		os.Unsetenv("PORT")
@@ -43,6 +44,7 @@ func TestConfig_ApplyEnvFull(t *testing.T) {
 		os.Unsetenv("TELEGRAM_ENABLED")
 		os.Unsetenv("BINANCE_USE_TESTNET")
 		os.Unsetenv("AUTO_INTERVAL")
+		os.Unsetenv("INGESTION_SYMBOLS")
 	}()
 
 	cfg := Config{}
@@ -66,6 +68,9 @@ func TestConfig_ApplyEnvFull(t *testing.T) {
 	if cfg.Ingestion.AutoInterval.Minutes() != 5 {
 		t.Error("Interval mismatch")
 	}
+	if len(cfg.Ingestion.Symbols) != 2 || cfg.Ingestion.Symbols[1] != "ETHUSDT" {
+		t.Errorf("symbols mismatch: %v", cfg.Ingestion.Symbols)
+	}
 }
 
 func TestLoadFromFile(t *testing.T) {
@@ -91,3 +96,25 @@ func TestLoadFromFile(t *testing.T) {
 		t.Errorf("got %s", cfg.HTTP.Addr)
 	}
 }
+
+func TestLoadFromFile_KeepsExplicitZeroPaperCosts(t *testing.T) {
+	cfg, err := LoadFromFile("no-such-file.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Paper.FeeRate != 0.001 || cfg.Paper.SlippageBps != 5 {
+		t.Errorf("expected default paper costs, got %+v", cfg.Paper)
+	}
+
+	tmp := "test_paper_config.yaml"
+	os.WriteFile(tmp, []byte("paper:\n  fee_rate: 0\n  slippage_bps: 0\n"), 0644)
+	defer os.Remove(tmp)
+
+	cfg, err = LoadFromFile(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Paper.FeeRate != 0 || cfg.Paper.SlippageBps != 0 {
+		t.Errorf("explicit zero must be kept, got %+v", cfg.Paper)
+	}
+}
@@ -19,7 +19,7 @@ func NewExchangeAdapter(client *Client) *ExchangeAdapter {
 }
 
 func (a *ExchangeAdapter) GetBalance(ctx context.Context, asset string) (float64, error) {
-	info, err := a.client.GetAccountInfo()
+	info, err := a.client.GetAccountInfo(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -34,7 +34,7 @@ func (a *ExchangeAdapter) GetBalance(ctx context.Context, asset string) (float64
 
 func (a *ExchangeAdapter) GetOrder(ctx context.Context, symbol, orderID string) (trading.OrderResponse, error) {
 	id, _ := strconv.ParseInt(orderID, 10, 64)
-	res, err := a.client.GetOrder(symbol, id)
+	res, err := a.client.GetOrder(ctx, symbol, id)
 	if err != nil {
 		return trading.OrderResponse{}, err
 	}
@@ -50,13 +50,26 @@ func (a *ExchangeAdapter) GetOrder(ctx context.Context, symbol, orderID string)
 	}, nil
 }
 
+// LookupOrder 實作 trading.OrderLookup，回傳含累計成交量的訂單狀態。
+func (a *ExchangeAdapter) LookupOrder(ctx context.Context, symbol, orderID string) (trading.OrderUpdate, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return trading.OrderUpdate{}, fmt.Errorf("invalid order id %q", orderID)
+	}
+	res, err := a.client.GetOrder(ctx, symbol, id)
+	if err != nil {
+		return trading.OrderUpdate{}, err
+	}
+	return orderUpdateFromResponse(*res), nil
+}
+
 func (a *ExchangeAdapter) GetPrice(ctx context.Context, symbol string) (float64, error) {
-	return a.client.GetPrice(symbol)
+	return a.client.GetPrice(ctx, symbol)
 }
 
 func (a *ExchangeAdapter) PlaceMarketOrder(ctx context.Context, symbol, side string, qty float64) (float64, float64, error) {
 	fmtQty := a.formatQuantity(symbol, qty)
-	price, executed, err := a.placeOrder(symbol, side, fmtQty, "")
+	price, executed, err := a.placeOrder(ctx, symbol, side, fmtQty, "")
 	if err != nil {
 		return 0, 0, fmt.Errorf("symbol %s qty %s err: %w", symbol, fmtQty, err)
 	}
@@ -90,11 +103,11 @@ func (a *ExchangeAdapter) formatQuantity(symbol string, qty float64) string {
 }
 
 func (a *ExchangeAdapter) PlaceMarketOrderQuote(ctx context.Context, symbol, side string, quoteAmount float64) (float64, float64, error) {
-	return a.placeOrder(symbol, side, "", fmt.Sprintf("%.2f", quoteAmount))
+	return a.placeOrder(ctx, symbol, side, "", fmt.Sprintf("%.2f", quoteAmount))
 }
 
-func (a *ExchangeAdapter) placeOrder(symbol, side, qty, quoteQty string) (float64, float64, error) {
-	res, err := a.client.CreateOrder(symbol, strings.ToUpper(side), "MARKET", qty, "", quoteQty)
+func (a *ExchangeAdapter) placeOrder(ctx context.Context, symbol, side, qty, quoteQty string) (float64, float64, error) {
+	res, err := a.client.CreateOrder(ctx, symbol, strings.ToUpper(side), "MARKET", qty, "", quoteQty)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -120,3 +133,44 @@ func (a *ExchangeAdapter) placeOrder(symbol, side, qty, quoteQty string) (float6
 
 	return 0, 0, fmt.Errorf("could not determine execution price")
 }
+
+// PlaceLimitOrder 掛出 GTC 限價單，回傳交易所訂單編號（網格策略使用）。
+func (a *ExchangeAdapter) PlaceLimitOrder(ctx context.Context, symbol, side string, qty, price float64) (trading.OrderResponse, error) {
+	fmtQty := a.formatQuantity(symbol, qty)
+	fmtPrice := formatPrice(price)
+	res, err := a.client.CreateOrder(ctx, symbol, strings.ToUpper(side), "LIMIT", fmtQty, fmtPrice, "")
+	if err != nil {
+		return trading.OrderResponse{}, fmt.Errorf("symbol %s qty %s price %s err: %w", symbol, fmtQty, fmtPrice, err)
+	}
+	q, _ := strconv.ParseFloat(res.OrigQty, 64)
+	return trading.OrderResponse{
+		OrderID: strconv.FormatInt(res.OrderID, 10),
+		Symbol:  res.Symbol,
+		Side:    res.Side,
+		Price:   price,
+		Qty:     q,
+		Status:  res.Status,
+	}, nil
+}
+
+// CancelOrder 取消尚未成交的訂單。
+func (a *ExchangeAdapter) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid order id %q", orderID)
+	}
+	_, err = a.client.CancelOrder(ctx, symbol, id)
+	return err
+}
+
+// formatPrice 依價格大小決定小數位數，避免超出交易對的 tick size。
+func formatPrice(price float64) string {
+	switch {
+	case price >= 1000:
+		return strconv.FormatFloat(price, 'f', 2, 64)
+	case price >= 1:
+		return strconv.FormatFloat(price, 'f', 4, 64)
+	default:
+		return strconv.FormatFloat(price, 'f', 8, 64)
+	}
+}
@@ -1,45 +1,57 @@
 package binance
 
 import (
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
+
+	"ai-auto-trade/internal/application/trading"
 )
 
 type Client struct {
 	apiKey     string
 	apiSecret  string
 	baseURL    string
+	streamURL  string
 	httpClient *http.Client
+	limiter    *weightLimiter
+	retry      retryPolicy
+	timeOffset atomic.Int64 // 伺服器時間 - 本機時間（毫秒）
 }
 
 func NewClient(apiKey, apiSecret string, useTestnet bool) *Client {
-	baseURL := "https://api.binance.com"
-	if useTestnet {
-		baseURL = "https://testnet.binance.vision"
-	}
-	return &Client{
+	c := &Client{
 		apiKey:     apiKey,
 		apiSecret:  apiSecret,
-		baseURL:    baseURL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		limiter:    newWeightLimiter(defaultWeightPerMinute),
+		retry:      defaultRetryPolicy,
 	}
+	c.SetBaseURL(useTestnet)
+	return c
 }
 
-
-
 func (c *Client) SetBaseURL(useTestnet bool) {
 	if useTestnet {
 		c.baseURL = "https://testnet.binance.vision"
+		c.streamURL = "wss://stream.testnet.binance.vision/ws"
 	} else {
 		c.baseURL = "https://api.binance.com"
+		c.streamURL = "wss://stream.binance.com:9443/ws"
 	}
+	c.timeOffset.Store(0)
 }
 
 func (c *Client) sign(query string) string {
@@ -48,24 +60,111 @@ func (c *Client) sign(query string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (c *Client) call(method, path string, params url.Values, signed bool) ([]byte, error) {
+// endpointWeights 為各端點的請求權重，未列出者視為 1。
+var endpointWeights = map[string]int{
+	"GET /api/v3/account":         20,
+	"GET /api/v3/order":           4,
+	"DELETE /api/v3/order":        1,
+	"GET /api/v3/openOrders":      80,
+	"GET /api/v3/ticker/price":    2,
+	"GET /api/v3/klines":          2,
+	"POST /api/v3/userDataStream": 2,
+	"PUT /api/v3/userDataStream":  2,
+}
+
+func requestWeight(method, path string, params url.Values) int {
+	if method == http.MethodGet && path == "/api/v3/openOrders" && params.Get("symbol") != "" {
+		return 6
+	}
+	if w, ok := endpointWeights[method+" "+path]; ok {
+		return w
+	}
+	return 1
+}
+
+// isIdempotent 僅查詢類請求可安全重送；下單、撤單不重試以免重複執行。
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut
+}
+
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.IsServerError()
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// call 為所有 REST 請求的共用入口：權重限流、時間同步、錯誤解析與冪等重試。
+func (c *Client) call(ctx context.Context, method, path string, params url.Values, signed bool) ([]byte, error) {
+	weight := requestWeight(method, path, params)
+	resynced := false
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, weight); err != nil {
+			return nil, err
+		}
+		body, err := c.send(ctx, method, path, params, signed)
+		if err == nil {
+			return body, nil
+		}
+
+		// -1021 代表請求因時間戳被拒、未被執行，任何方法都可在校時後重送一次。
+		if signed && !resynced && IsAPIError(err, ErrCodeTimestamp) {
+			resynced = true
+			if serr := c.SyncTime(ctx); serr == nil {
+				continue
+			}
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.IsRateLimited() {
+			retryAfter := apiErr.RetryAfter
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+			c.limiter.pause(time.Now().Add(retryAfter))
+		}
+		if !isIdempotent(method) || !isRetryable(err) || attempt+1 >= c.retry.maxAttempts {
+			return nil, err
+		}
+		if apiErr != nil && apiErr.StatusCode == http.StatusTeapot {
+			return nil, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		log.Printf("[Binance] %s %s failed (attempt %d): %v, retrying in %v", method, path, attempt+1, err, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, params url.Values, signed bool) ([]byte, error) {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
 	if signed {
-		params.Set("timestamp", fmt.Sprintf("%d", time.Now().UnixMilli()))
-		signature := c.sign(params.Encode())
-		params.Set("signature", signature)
+		query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()+c.timeOffset.Load(), 10))
+		query.Set("signature", c.sign(query.Encode()))
 	}
 
-	fullURL := fmt.Sprintf("%s%s", c.baseURL, path)
-	if len(params) > 0 {
-		fullURL = fmt.Sprintf("%s?%s", fullURL, params.Encode())
+	fullURL := c.baseURL + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequest(method, fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	req.Header.Set("X-MBX-APIKEY", c.apiKey)
+	if c.apiKey != "" {
+		req.Header.Set("X-MBX-APIKEY", c.apiKey)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -73,18 +172,41 @@ func (c *Client) call(method, path string, params url.Values, signed bool) ([]by
 	}
 	defer resp.Body.Close()
 
+	if used, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		c.limiter.observe(used)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("binance api error (status %d): %s", resp.StatusCode, string(body))
+		return nil, parseAPIError(resp, body)
 	}
 
 	return body, nil
 }
 
+// SyncTime 以 /api/v3/time 校正本機與伺服器的時間差，避免 -1021。
+func (c *Client) SyncTime(ctx context.Context) error {
+	sent := time.Now()
+	body, err := c.send(ctx, http.MethodGet, "/api/v3/time", nil, false)
+	if err != nil {
+		return err
+	}
+	received := time.Now()
+	var res struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+	local := sent.UnixMilli() + received.Sub(sent).Milliseconds()/2
+	c.timeOffset.Store(res.ServerTime - local)
+	return nil
+}
+
 type AccountInfo struct {
 	MakerCommission  int `json:"makerCommission"`
 	TakerCommission  int `json:"takerCommission"`
@@ -102,8 +224,8 @@ type AccountInfo struct {
 	} `json:"balances"`
 }
 
-func (c *Client) GetAccountInfo() (*AccountInfo, error) {
-	body, err := c.call("GET", "/api/v3/account", url.Values{}, true)
+func (c *Client) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	body, err := c.call(ctx, "GET", "/api/v3/account", url.Values{}, true)
 	if err != nil {
 		return nil, err
 	}
@@ -118,10 +240,13 @@ type OrderResponse struct {
 	Symbol              string `json:"symbol"`
 	OrderID             int64  `json:"orderId"`
 	ClientOrderID       string `json:"clientOrderId"`
-	TransactTime        int64  `json:"transactTime"`
+	TransactTime        int64  `json:"transactTime"` // 僅下單回應提供
+	Time                int64  `json:"time"`         // 查詢訂單：建立時間
+	UpdateTime          int64  `json:"updateTime"`   // 查詢訂單：最後更新時間
 	Price               string `json:"price"`
 	OrigQty             string `json:"origQty"`
 	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
 	Status              string `json:"status"`
 	Type                string `json:"type"`
 	Side                string `json:"side"`
@@ -133,11 +258,19 @@ type OrderResponse struct {
 	} `json:"fills"`
 }
 
-func (c *Client) CreateOrder(symbol, side, orderType, quantity string, price string, quoteQty string) (*OrderResponse, error) {
+// newClientOrderID 產生帶有 trading.ClientOrderPrefix 的訂單編號，讓使用者資料流能辨識本系統下的單。
+func newClientOrderID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return trading.ClientOrderPrefix + hex.EncodeToString(b[:])
+}
+
+func (c *Client) CreateOrder(ctx context.Context, symbol, side, orderType, quantity string, price string, quoteQty string) (*OrderResponse, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("side", side)
 	params.Set("type", orderType)
+	params.Set("newClientOrderId", newClientOrderID())
 	if quantity != "" {
 		params.Set("quantity", quantity)
 	}
@@ -149,7 +282,7 @@ func (c *Client) CreateOrder(symbol, side, orderType, quantity string, price str
 		params.Set("timeInForce", "GTC")
 	}
 
-	body, err := c.call("POST", "/api/v3/order", params, true)
+	body, err := c.call(ctx, "POST", "/api/v3/order", params, true)
 	if err != nil {
 		return nil, err
 	}
@@ -160,12 +293,12 @@ func (c *Client) CreateOrder(symbol, side, orderType, quantity string, price str
 	return &res, nil
 }
 
-func (c *Client) GetOrder(symbol string, orderID int64) (*OrderResponse, error) {
+func (c *Client) GetOrder(ctx context.Context, symbol string, orderID int64) (*OrderResponse, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("orderId", fmt.Sprintf("%d", orderID))
 
-	body, err := c.call("GET", "/api/v3/order", params, true)
+	body, err := c.call(ctx, "GET", "/api/v3/order", params, true)
 	if err != nil {
 		return nil, err
 	}
@@ -176,12 +309,12 @@ func (c *Client) GetOrder(symbol string, orderID int64) (*OrderResponse, error)
 	return &res, nil
 }
 
-func (c *Client) CancelOrder(symbol string, orderID int64) (*OrderResponse, error) {
+func (c *Client) CancelOrder(ctx context.Context, symbol string, orderID int64) (*OrderResponse, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("orderId", fmt.Sprintf("%d", orderID))
 
-	body, err := c.call("DELETE", "/api/v3/order", params, true)
+	body, err := c.call(ctx, "DELETE", "/api/v3/order", params, true)
 	if err != nil {
 		return nil, err
 	}
@@ -197,10 +330,10 @@ type PriceTicker struct {
 	Price  string `json:"price"`
 }
 
-func (c *Client) GetPrice(symbol string) (float64, error) {
+func (c *Client) GetPrice(ctx context.Context, symbol string) (float64, error) {
 	params := url.Values{}
 	params.Set("symbol", symbol)
-	body, err := c.call("GET", "/api/v3/ticker/price", params, false)
+	body, err := c.call(ctx, "GET", "/api/v3/ticker/price", params, false)
 	if err != nil {
 		return 0, err
 	}
@@ -212,3 +345,89 @@ func (c *Client) GetPrice(symbol string) (float64, error) {
 	fmt.Sscanf(ticker.Price, "%f", &p)
 	return p, nil
 }
+
+// GetOpenOrders 查詢所有未完成訂單（symbol 為空代表全部交易對）。
+func (c *Client) GetOpenOrders(ctx context.Context, symbol string) ([]OrderResponse, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+	body, err := c.call(ctx, "GET", "/api/v3/openOrders", params, true)
+	if err != nil {
+		return nil, err
+	}
+	var res []OrderResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Kline 為單根 K 線。
+type Kline struct {
+	OpenTime    time.Time
+	CloseTime   time.Time
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	Volume      float64
+	QuoteVolume float64
+}
+
+// maxKlineLimit 為 /api/v3/klines 單次最多回傳筆數。
+const maxKlineLimit = 1000
+
+// GetKlines 取得 [start, end] 區間的 K 線（單次請求，最多 limit 筆）。
+func (c *Client) GetKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("interval", interval)
+	if !start.IsZero() {
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+	if limit <= 0 || limit > maxKlineLimit {
+		limit = maxKlineLimit
+	}
+	params.Set("limit", strconv.Itoa(limit))
+
+	body, err := c.call(ctx, "GET", "/api/v3/klines", params, false)
+	if err != nil {
+		return nil, err
+	}
+	var raw [][]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	out := make([]Kline, 0, len(raw))
+	for _, row := range raw {
+		if len(row) < 8 {
+			continue
+		}
+		var openTime, closeTime int64
+		var o, h, l, cl, v, qv string
+		if json.Unmarshal(row[0], &openTime) != nil || json.Unmarshal(row[6], &closeTime) != nil {
+			continue
+		}
+		_ = json.Unmarshal(row[1], &o)
+		_ = json.Unmarshal(row[2], &h)
+		_ = json.Unmarshal(row[3], &l)
+		_ = json.Unmarshal(row[4], &cl)
+		_ = json.Unmarshal(row[5], &v)
+		_ = json.Unmarshal(row[7], &qv)
+		out = append(out, Kline{
+			OpenTime:    time.UnixMilli(openTime).UTC(),
+			CloseTime:   time.UnixMilli(closeTime).UTC(),
+			Open:        parseFloat(o),
+			High:        parseFloat(h),
+			Low:         parseFloat(l),
+			Close:       parseFloat(cl),
+			Volume:      parseFloat(v),
+			QuoteVolume: parseFloat(qv),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,136 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	c := NewClient("key", "secret", false)
+	c.baseURL = ts.URL
+	c.retry = retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: 5 * time.Millisecond}
+	return c
+}
+
+func TestClient_RetriesIdempotentOn429(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code":-1003,"msg":"Too many requests"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","price":"50000.10"}`))
+	})
+
+	p, err := c.GetPrice(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("expected retry to succeed: %v", err)
+	}
+	if p != 50000.10 || calls.Load() != 2 {
+		t.Fatalf("unexpected price %v after %d calls", p, calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryOrders(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"code":-1001,"msg":"Internal error; unable to process your request."}`))
+	})
+
+	_, err := c.CreateOrder(context.Background(), "BTCUSDT", "BUY", "MARKET", "", "", "100")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != ErrCodeDisconnected || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected typed api error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("POST must not be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestClient_ResyncsTimeOnTimestampError(t *testing.T) {
+	var orders atomic.Int32
+	serverTime := time.Now().Add(3 * time.Second).UnixMilli()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/time":
+			_, _ = w.Write([]byte(`{"serverTime":` + strconv.FormatInt(serverTime, 10) + `}`))
+		case "/api/v3/order":
+			ts, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+			orders.Add(1)
+			if ts < serverTime-1000 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","orderId":1,"status":"FILLED","executedQty":"0.002"}`))
+		}
+	})
+
+	res, err := c.CreateOrder(context.Background(), "BTCUSDT", "BUY", "MARKET", "", "", "100")
+	if err != nil {
+		t.Fatalf("expected success after time sync: %v", err)
+	}
+	if res.OrderID != 1 || orders.Load() != 2 {
+		t.Fatalf("unexpected result %+v after %d order calls", res, orders.Load())
+	}
+	if c.timeOffset.Load() < 2000 {
+		t.Fatalf("expected clock offset to be synced, got %d", c.timeOffset.Load())
+	}
+}
+
+func TestClient_ObservesUsedWeight(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "4990")
+		_, _ = w.Write([]byte(`[[1700000000000,"1.0","2.0","0.5","1.5","10.0",1700086399999,"15.0",3,"5","7","0"]]`))
+	})
+
+	klines, err := c.GetKlines(context.Background(), "BTCUSDT", "1d", time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("get klines: %v", err)
+	}
+	if len(klines) != 1 || klines[0].Close != 1.5 || klines[0].QuoteVolume != 15 || !klines[0].OpenTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("unexpected klines %+v", klines)
+	}
+	c.limiter.mu.Lock()
+	tokens := c.limiter.tokens
+	c.limiter.mu.Unlock()
+	if tokens > 11 {
+		t.Fatalf("expected limiter to honour server weight, tokens=%v", tokens)
+	}
+}
+
+func TestWeightLimiter_BlocksWhenExhausted(t *testing.T) {
+	l := newWeightLimiter(60) // 每秒補 1
+	if err := l.wait(context.Background(), 60); err != nil {
+		t.Fatalf("initial budget: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to block until deadline, got %v", err)
+	}
+
+	l.pause(time.Now().Add(time.Hour))
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+	l.tokens = 60
+	if err := l.wait(ctx2, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected pause to block, got %v", err)
+	}
+}
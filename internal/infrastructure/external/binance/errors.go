@@ -0,0 +1,72 @@
+package binance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Binance 錯誤代碼（部分）。
+const (
+	ErrCodeUnknown          = -1000
+	ErrCodeDisconnected     = -1001
+	ErrCodeTooManyRequests  = -1003
+	ErrCodeTimestamp        = -1021
+	ErrCodeInvalidSignature = -1022
+	ErrCodeNewOrderRejected = -2010
+	ErrCodeNoSuchOrder      = -2013
+)
+
+// APIError 為 Binance 回傳的錯誤，包含 HTTP 狀態與 code/msg。
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("binance api error (status %d, code %d): %s", e.StatusCode, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("binance api error (status %d): %s", e.StatusCode, e.Msg)
+}
+
+// IsRateLimited 代表請求因頻率限制（429）或 IP 封鎖（418）被拒。
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusTeapot
+}
+
+// IsServerError 代表交易所端錯誤，狀態未知但可重試查詢類請求。
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// IsAPIError 判斷 err 是否為指定 code 的 Binance 錯誤。
+func IsAPIError(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func parseAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var payload struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && (payload.Code != 0 || payload.Msg != "") {
+		apiErr.Code = payload.Code
+		apiErr.Msg = payload.Msg
+	} else {
+		apiErr.Msg = string(body)
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return apiErr
+}
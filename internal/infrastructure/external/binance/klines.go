@@ -0,0 +1,64 @@
+package binance
+
+import (
+	"context"
+	"time"
+
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+// KlineSource 以 /api/v3/klines 實作 dataingestion.KlineSource，超過單次上限時自動分頁。
+type KlineSource struct {
+	client   *Client
+	pageSize int
+}
+
+// NewKlineSource 建立 Binance K 線來源。
+func NewKlineSource(client *Client) *KlineSource {
+	return &KlineSource{client: client, pageSize: maxKlineLimit}
+}
+
+// FetchKlines 取得 [start, end) 區間的 K 線；start 為零值時僅取最近一頁。
+func (k *KlineSource) FetchKlines(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataDomain.DailyPrice, error) {
+	var out []dataDomain.DailyPrice
+	cursor := start
+	for {
+		var queryEnd time.Time
+		if !end.IsZero() {
+			queryEnd = end.Add(-time.Millisecond)
+		}
+		page, err := k.client.GetKlines(ctx, symbol, timeframe, cursor, queryEnd, k.pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, kl := range page {
+			if !end.IsZero() && !kl.OpenTime.Before(end) {
+				continue
+			}
+			out = append(out, klineToPrice(symbol, timeframe, kl))
+		}
+		if start.IsZero() || len(page) < k.pageSize {
+			return out, nil
+		}
+		next := page[len(page)-1].OpenTime.Add(time.Millisecond)
+		if !next.After(cursor) || (!end.IsZero() && !next.Before(end)) {
+			return out, nil
+		}
+		cursor = next
+	}
+}
+
+func klineToPrice(symbol, timeframe string, k Kline) dataDomain.DailyPrice {
+	return dataDomain.DailyPrice{
+		Symbol:    symbol,
+		Market:    dataDomain.MarketCrypto,
+		Timeframe: timeframe,
+		TradeDate: k.OpenTime,
+		Open:      k.Open,
+		High:      k.High,
+		Low:       k.Low,
+		Close:     k.Close,
+		Volume:    int64(k.Volume),
+		Turnover:  int64(k.QuoteVolume),
+	}
+}
@@ -0,0 +1,52 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+func TestKlineSource_Paginates(t *testing.T) {
+	page1, err := os.ReadFile("testdata/klines_page1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page2, err := os.ReadFile("testdata/klines_page2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var starts []int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		starts = append(starts, start)
+		if start <= 1704067200000 {
+			_, _ = w.Write(page1)
+			return
+		}
+		_, _ = w.Write(page2)
+	})
+	src := NewKlineSource(c)
+	src.pageSize = 2
+
+	start := time.UnixMilli(1704067200000).UTC()
+	end := start.AddDate(0, 0, 3)
+	prices, err := src.FetchKlines(context.Background(), "BTCUSDT", "1d", start, end)
+	if err != nil {
+		t.Fatalf("fetch klines: %v", err)
+	}
+	if len(prices) != 3 || len(starts) != 2 {
+		t.Fatalf("expected 3 klines over 2 pages, got %d over %d", len(prices), len(starts))
+	}
+	if starts[1] != 1704153600001 {
+		t.Fatalf("second page should start after last open time, got %d", starts[1])
+	}
+	last := prices[2]
+	if last.Market != dataDomain.MarketCrypto || last.Timeframe != "1d" || last.Close != 42845.23 || !last.TradeDate.Equal(time.UnixMilli(1704240000000)) {
+		t.Fatalf("unexpected kline %+v", last)
+	}
+}
@@ -0,0 +1,227 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+
+	"golang.org/x/net/websocket"
+)
+
+const defaultSubscriptionRefresh = 5 * time.Minute
+
+// SubscriptionProvider 回傳目前需要訂閱的交易對與週期。
+type SubscriptionProvider func(ctx context.Context) ([]trading.StreamSubscription, error)
+
+// combinedEvent 為 /stream?streams=... 的外層包裝。
+type combinedEvent struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type klineEvent struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Kline     struct {
+		OpenTime    int64  `json:"t"`
+		CloseTime   int64  `json:"T"`
+		Symbol      string `json:"s"`
+		Interval    string `json:"i"`
+		FirstID     int64  `json:"f"`
+		LastID      int64  `json:"L"`
+		Open        string `json:"o"`
+		Close       string `json:"c"`
+		High        string `json:"h"`
+		Low         string `json:"l"`
+		Volume      string `json:"v"`
+		TakerVolume string `json:"V"`
+		QuoteVolume string `json:"q"`
+		TakerQuote  string `json:"Q"`
+		Closed      bool   `json:"x"`
+	} `json:"k"`
+}
+
+type bookTickerEvent struct {
+	UpdateID int64  `json:"u"`
+	Symbol   string `json:"s"`
+	Bid      string `json:"b"`
+	BidQty   string `json:"B"`
+	Ask      string `json:"a"`
+	AskQty   string `json:"A"`
+}
+
+// MarketStream 訂閱 Binance kline 與 bookTicker 合併串流，並派送給 handler。
+// 訂閱清單會定期重新計算，有變動時自動重新連線。
+type MarketStream struct {
+	client          *Client
+	handler         trading.MarketDataHandler
+	subscriptions   SubscriptionProvider
+	refreshInterval time.Duration
+	minBackoff      time.Duration
+	maxBackoff      time.Duration
+}
+
+// NewMarketStream 建立行情串流。
+func NewMarketStream(client *Client, handler trading.MarketDataHandler, subs SubscriptionProvider) *MarketStream {
+	return &MarketStream{
+		client:          client,
+		handler:         handler,
+		subscriptions:   subs,
+		refreshInterval: defaultSubscriptionRefresh,
+		minBackoff:      defaultMinBackoff,
+		maxBackoff:      defaultMaxBackoff,
+	}
+}
+
+// streamNames 將訂閱轉為 Binance 串流名稱，每個交易對另外訂閱 bookTicker。
+func streamNames(subs []trading.StreamSubscription) []string {
+	var names []string
+	tickers := make(map[string]bool)
+	for _, sub := range subs {
+		sym := strings.ToLower(sub.Symbol)
+		names = append(names, fmt.Sprintf("%s@kline_%s", sym, sub.Timeframe))
+		if !tickers[sym] {
+			tickers[sym] = true
+			names = append(names, sym+"@bookTicker")
+		}
+	}
+	return names
+}
+
+// Run 持續連線直到 ctx 結束。
+func (m *MarketStream) Run(ctx context.Context) error {
+	backoff := m.minBackoff
+	for {
+		connected, err := m.runSession(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			backoff = m.minBackoff
+		}
+		if err != nil {
+			log.Printf("[MarketStream] disconnected: %v, reconnecting in %v", err, backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > m.maxBackoff {
+			backoff = m.maxBackoff
+		}
+	}
+}
+
+func (m *MarketStream) runSession(ctx context.Context) (bool, error) {
+	subs, err := m.subscriptions(ctx)
+	if err != nil {
+		return false, fmt.Errorf("load subscriptions: %w", err)
+	}
+	names := streamNames(subs)
+	if len(names) == 0 {
+		// 沒有需要訂閱的標的，等待下一次刷新
+		select {
+		case <-ctx.Done():
+		case <-time.After(m.refreshInterval):
+		}
+		return true, nil
+	}
+
+	sessCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	base := strings.TrimSuffix(strings.TrimRight(m.client.streamURL, "/"), "/ws")
+	conn, err := dialStream(sessCtx, base+"/stream?streams="+strings.Join(names, "/"))
+	if err != nil {
+		return false, fmt.Errorf("dial market stream: %w", err)
+	}
+	go func() {
+		<-sessCtx.Done()
+		_ = conn.Close()
+	}()
+	go m.watchSubscriptions(sessCtx, cancel, names)
+	log.Printf("[MarketStream] subscribed: %s", strings.Join(names, ","))
+
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			if sessCtx.Err() != nil && ctx.Err() == nil {
+				return true, nil
+			}
+			return true, err
+		}
+		if err := m.dispatch(sessCtx, raw); err != nil {
+			log.Printf("[MarketStream] handle event failed: %v", err)
+		}
+	}
+}
+
+// watchSubscriptions 訂閱清單改變時取消 session 以重新連線。
+func (m *MarketStream) watchSubscriptions(ctx context.Context, cancel context.CancelFunc, current []string) {
+	ticker := time.NewTicker(m.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			subs, err := m.subscriptions(ctx)
+			if err != nil {
+				continue
+			}
+			if strings.Join(streamNames(subs), "/") != strings.Join(current, "/") {
+				log.Printf("[MarketStream] subscriptions changed, reconnecting")
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (m *MarketStream) dispatch(ctx context.Context, raw []byte) error {
+	var env combinedEvent
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("decode event: %w", err)
+	}
+	switch {
+	case strings.Contains(env.Stream, "@kline_"):
+		var ev klineEvent
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return fmt.Errorf("decode kline: %w", err)
+		}
+		k := ev.Kline
+		return m.handler.OnCandle(ctx, trading.Candle{
+			Symbol:    k.Symbol,
+			Timeframe: k.Interval,
+			OpenTime:  time.UnixMilli(k.OpenTime).UTC(),
+			CloseTime: time.UnixMilli(k.CloseTime).UTC(),
+			Open:      parseFloat(k.Open),
+			High:      parseFloat(k.High),
+			Low:       parseFloat(k.Low),
+			Close:     parseFloat(k.Close),
+			Volume:    parseFloat(k.Volume),
+			Closed:    k.Closed,
+		})
+	case strings.HasSuffix(env.Stream, "@bookTicker"):
+		var ev bookTickerEvent
+		if err := json.Unmarshal(env.Data, &ev); err != nil {
+			return fmt.Errorf("decode bookTicker: %w", err)
+		}
+		return m.handler.OnTick(ctx, trading.PriceTick{
+			Symbol: ev.Symbol,
+			Bid:    parseFloat(ev.Bid),
+			Ask:    parseFloat(ev.Ask),
+			Time:   time.Now(),
+		})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+
+	"golang.org/x/net/websocket"
+)
+
+type recordingMarketHandler struct {
+	mu      sync.Mutex
+	candles []trading.Candle
+	ticks   []trading.PriceTick
+	done    chan struct{}
+}
+
+func (h *recordingMarketHandler) OnCandle(_ context.Context, c trading.Candle) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.candles = append(h.candles, c)
+	h.check()
+	return nil
+}
+
+func (h *recordingMarketHandler) OnTick(_ context.Context, t trading.PriceTick) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ticks = append(h.ticks, t)
+	h.check()
+	return nil
+}
+
+func (h *recordingMarketHandler) check() {
+	if len(h.candles) >= 1 && len(h.ticks) >= 1 {
+		select {
+		case <-h.done:
+		default:
+			close(h.done)
+		}
+	}
+}
+
+func TestMarketStream_Dispatch(t *testing.T) {
+	var mu sync.Mutex
+	var gotStreams string
+
+	mux := http.NewServeMux()
+	mux.Handle("/stream", websocket.Handler(func(ws *websocket.Conn) {
+		mu.Lock()
+		gotStreams = ws.Request().URL.Query().Get("streams")
+		mu.Unlock()
+		_ = websocket.Message.Send(ws, `{"stream":"btcusdt@kline_1h","data":{"e":"kline","E":1700003600001,"s":"BTCUSDT","k":{"t":1700000000000,"T":1700003599999,"s":"BTCUSDT","i":"1h","f":1,"L":2,"o":"100.0","c":"101.5","h":"102.0","l":"99.0","v":"12.5","V":"6","q":"1250","Q":"600","x":true}}}`)
+		_ = websocket.Message.Send(ws, `{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"101.40","B":"3","a":"101.60","A":"2"}}`)
+		var discard string
+		_ = websocket.Message.Receive(ws, &discard)
+	}))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := NewClient("", "", false)
+	client.streamURL = "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	h := &recordingMarketHandler{done: make(chan struct{})}
+	subs := func(context.Context) ([]trading.StreamSubscription, error) {
+		return []trading.StreamSubscription{{Symbol: "BTCUSDT", Timeframe: "1h"}, {Symbol: "BTCUSDT", Timeframe: "1d"}}, nil
+	}
+	stream := NewMarketStream(client, h, subs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- stream.Run(ctx) }()
+
+	select {
+	case <-h.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for market events")
+	}
+	cancel()
+	<-errCh
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotStreams != "btcusdt@kline_1h/btcusdt@bookTicker/btcusdt@kline_1d" {
+		t.Fatalf("unexpected streams %q", gotStreams)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := h.candles[0]
+	if c.Symbol != "BTCUSDT" || c.Timeframe != "1h" || !c.Closed || c.Close != 101.5 || c.High != 102 || c.Volume != 12.5 {
+		t.Fatalf("unexpected candle %+v", c)
+	}
+	if !c.OpenTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("unexpected open time %v", c.OpenTime)
+	}
+	if tk := h.ticks[0]; tk.Bid != 101.4 || tk.Ask != 101.6 {
+		t.Fatalf("unexpected tick %+v", tk)
+	}
+}
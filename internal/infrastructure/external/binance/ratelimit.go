@@ -0,0 +1,110 @@
+package binance
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// defaultWeightPerMinute 為 Binance 現貨 REQUEST_WEIGHT 每分鐘上限，保留部分餘裕。
+const defaultWeightPerMinute = 5000
+
+// weightLimiter 以 token bucket 控制每分鐘請求權重，
+// 並依回應的 X-MBX-USED-WEIGHT-1M 與 Retry-After 校正。
+type weightLimiter struct {
+	mu          sync.Mutex
+	capacity    float64
+	tokens      float64
+	rate        float64 // 每秒補充量
+	last        time.Time
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+func newWeightLimiter(perMinute int) *weightLimiter {
+	return &weightLimiter{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		now:      time.Now,
+	}
+}
+
+func (l *weightLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+	}
+	l.last = now
+}
+
+// wait 阻塞直到有足夠權重可用或 ctx 結束。
+func (l *weightLimiter) wait(ctx context.Context, weight int) error {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.refill(now)
+		var delay time.Duration
+		switch {
+		case now.Before(l.pausedUntil):
+			delay = l.pausedUntil.Sub(now)
+		case l.tokens >= float64(weight):
+			l.tokens -= float64(weight)
+			l.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((float64(weight) - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// observe 以伺服器回報的已用權重校正剩餘額度。
+func (l *weightLimiter) observe(used int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if remaining := l.capacity - float64(used); remaining < l.tokens {
+		l.tokens = remaining
+	}
+}
+
+// pause 在收到 429/418 後暫停所有請求至指定時間。
+func (l *weightLimiter) pause(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// retryPolicy 定義冪等請求的重試次數與指數退避（含 full jitter）。
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 3,
+	baseDelay:   500 * time.Millisecond,
+	maxDelay:    10 * time.Second,
+}
+
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.baseDelay << attempt
+	if d <= 0 || d > p.maxDelay {
+		d = p.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
@@ -0,0 +1,319 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultKeepAliveInterval = 30 * time.Minute
+	defaultMinBackoff        = time.Second
+	defaultMaxBackoff        = time.Minute
+)
+
+var errListenKeyExpired = errors.New("listen key expired")
+
+// CreateListenKey 建立使用者資料流的 listenKey。
+func (c *Client) CreateListenKey(ctx context.Context) (string, error) {
+	body, err := c.call(ctx, "POST", "/api/v3/userDataStream", url.Values{}, false)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", err
+	}
+	if res.ListenKey == "" {
+		return "", fmt.Errorf("empty listen key")
+	}
+	return res.ListenKey, nil
+}
+
+// KeepAliveListenKey 延長 listenKey 有效期（Binance 60 分鐘未續期即失效）。
+func (c *Client) KeepAliveListenKey(ctx context.Context, listenKey string) error {
+	params := url.Values{}
+	params.Set("listenKey", listenKey)
+	_, err := c.call(ctx, "PUT", "/api/v3/userDataStream", params, false)
+	return err
+}
+
+// CloseListenKey 關閉 listenKey。
+func (c *Client) CloseListenKey(ctx context.Context, listenKey string) error {
+	params := url.Values{}
+	params.Set("listenKey", listenKey)
+	_, err := c.call(ctx, "DELETE", "/api/v3/userDataStream", params, false)
+	return err
+}
+
+// dialStream 連線至指定的 websocket 串流，ctx 結束時自動關閉連線。
+func dialStream(ctx context.Context, streamURL string) (*websocket.Conn, error) {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, err
+	}
+	origin := "http://" + u.Host
+	cfg, err := websocket.NewConfig(streamURL, origin)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.DialContext(ctx)
+}
+
+// userEvent 對應 executionReport 與 outboundAccountPosition。
+// Binance 的欄位名稱大小寫敏感（e/E、s/S...），encoding/json 不分大小寫比對，
+// 因此同名異大小寫的欄位都必須宣告才能避免互相覆蓋。
+type userEvent struct {
+	EventType       string `json:"e"`
+	EventTime       int64  `json:"E"`
+	Symbol          string `json:"s"`
+	Side            string `json:"S"`
+	ClientOrderID   string `json:"c"`
+	OrigClientID    string `json:"C"`
+	OrderType       string `json:"o"`
+	CreateTime      int64  `json:"O"`
+	OrigQty         string `json:"q"`
+	QuoteOrderQty   string `json:"Q"`
+	Price           string `json:"p"`
+	StopPrice       string `json:"P"`
+	ExecutionType   string `json:"x"`
+	OrderStatus     string `json:"X"`
+	OrderID         int64  `json:"i"`
+	Ignore          int64  `json:"I"`
+	LastQty         string `json:"l"`
+	LastPrice       string `json:"L"`
+	Commission      string `json:"n"`
+	CommissionAsset string `json:"N"`
+	TradeID         int64  `json:"t"`
+	TransactTime    int64  `json:"T"`
+	CumQty          string `json:"z"`
+	CumQuote        string `json:"Z"`
+	LastUpdate      int64  `json:"u"`
+	UpdateID        int64  `json:"U"`
+	Balances        []struct {
+		Asset  string `json:"a"`
+		Free   string `json:"f"`
+		Locked string `json:"l"`
+	} `json:"B"`
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func (e userEvent) orderUpdate() trading.OrderUpdate {
+	return trading.OrderUpdate{
+		OrderID:         strconv.FormatInt(e.OrderID, 10),
+		ClientOrderID:   e.ClientOrderID,
+		Symbol:          e.Symbol,
+		Side:            e.Side,
+		Type:            e.OrderType,
+		Status:          e.OrderStatus,
+		Price:           parseFloat(e.Price),
+		OrigQty:         parseFloat(e.OrigQty),
+		LastFillPrice:   parseFloat(e.LastPrice),
+		LastFillQty:     parseFloat(e.LastQty),
+		CumQty:          parseFloat(e.CumQty),
+		CumQuote:        parseFloat(e.CumQuote),
+		Commission:      parseFloat(e.Commission),
+		CommissionAsset: e.CommissionAsset,
+		EventTime:       time.UnixMilli(e.EventTime),
+	}
+}
+
+func (e userEvent) balanceUpdates() []trading.BalanceUpdate {
+	out := make([]trading.BalanceUpdate, 0, len(e.Balances))
+	for _, b := range e.Balances {
+		out = append(out, trading.BalanceUpdate{
+			Asset:  b.Asset,
+			Free:   parseFloat(b.Free),
+			Locked: parseFloat(b.Locked),
+		})
+	}
+	return out
+}
+
+func orderUpdateFromResponse(o OrderResponse) trading.OrderUpdate {
+	return trading.OrderUpdate{
+		OrderID:       strconv.FormatInt(o.OrderID, 10),
+		ClientOrderID: o.ClientOrderID,
+		Symbol:        o.Symbol,
+		Side:          o.Side,
+		Type:          o.Type,
+		Status:        o.Status,
+		Price:         parseFloat(o.Price),
+		OrigQty:       parseFloat(o.OrigQty),
+		CumQty:        parseFloat(o.ExecutedQty),
+		CumQuote:      parseFloat(o.CummulativeQuoteQty),
+		EventTime:     orderResponseTime(o),
+	}
+}
+
+// orderResponseTime 取訂單最後變動時間：查詢回應用 updateTime，下單回應用 transactTime；
+// 皆無時回傳零值，讓 OrderTracker 不視為過期事件。
+func orderResponseTime(o OrderResponse) time.Time {
+	for _, ms := range []int64{o.UpdateTime, o.TransactTime, o.Time} {
+		if ms > 0 {
+			return time.UnixMilli(ms)
+		}
+	}
+	return time.Time{}
+}
+
+// UserStream 維護 Binance 使用者資料流，將成交與餘額事件派送給 handler。
+// 斷線時會以指數退避重新建立 listenKey 與連線，並在每次連線後以 REST 快照重新同步。
+type UserStream struct {
+	client            *Client
+	handler           trading.UserDataHandler
+	keepAliveInterval time.Duration
+	minBackoff        time.Duration
+	maxBackoff        time.Duration
+}
+
+// NewUserStream 建立使用者資料流。
+func NewUserStream(client *Client, handler trading.UserDataHandler) *UserStream {
+	return &UserStream{
+		client:            client,
+		handler:           handler,
+		keepAliveInterval: defaultKeepAliveInterval,
+		minBackoff:        defaultMinBackoff,
+		maxBackoff:        defaultMaxBackoff,
+	}
+}
+
+// Run 持續連線直到 ctx 結束。
+func (s *UserStream) Run(ctx context.Context) error {
+	backoff := s.minBackoff
+	for {
+		connected, err := s.runSession(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			backoff = s.minBackoff
+		}
+		log.Printf("[UserStream] disconnected: %v, reconnecting in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// runSession 建立一次完整連線：取得 listenKey、連線、重新同步、讀取事件直到斷線。
+func (s *UserStream) runSession(ctx context.Context) (bool, error) {
+	listenKey, err := s.client.CreateListenKey(ctx)
+	if err != nil {
+		return false, fmt.Errorf("create listen key: %w", err)
+	}
+
+	sessCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	conn, err := dialStream(sessCtx, strings.TrimRight(s.client.streamURL, "/")+"/"+listenKey)
+	if err != nil {
+		return false, fmt.Errorf("dial user stream: %w", err)
+	}
+	go func() {
+		<-sessCtx.Done()
+		_ = conn.Close()
+	}()
+	go s.keepAlive(sessCtx, cancel, listenKey)
+
+	// 先連線再同步，確保快照之後的事件都會在串流中收到。
+	if err := s.resync(sessCtx); err != nil {
+		return true, fmt.Errorf("resync: %w", err)
+	}
+	log.Printf("[UserStream] connected")
+
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			return true, err
+		}
+		if err := s.dispatch(sessCtx, raw); err != nil {
+			if errors.Is(err, errListenKeyExpired) {
+				return true, err
+			}
+			log.Printf("[UserStream] handle event failed: %v", err)
+		}
+	}
+}
+
+func (s *UserStream) keepAlive(ctx context.Context, cancel context.CancelFunc, listenKey string) {
+	ticker := time.NewTicker(s.keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = s.client.CloseListenKey(context.WithoutCancel(ctx), listenKey)
+			return
+		case <-ticker.C:
+			if err := s.client.KeepAliveListenKey(ctx, listenKey); err != nil {
+				log.Printf("[UserStream] keepalive failed: %v", err)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *UserStream) resync(ctx context.Context) error {
+	info, err := s.client.GetAccountInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("account info: %w", err)
+	}
+	orders, err := s.client.GetOpenOrders(ctx, "")
+	if err != nil {
+		return fmt.Errorf("open orders: %w", err)
+	}
+
+	balances := make([]trading.BalanceUpdate, 0, len(info.Balances))
+	for _, b := range info.Balances {
+		balances = append(balances, trading.BalanceUpdate{
+			Asset:  b.Asset,
+			Free:   parseFloat(b.Free),
+			Locked: parseFloat(b.Locked),
+		})
+	}
+	open := make([]trading.OrderUpdate, 0, len(orders))
+	for _, o := range orders {
+		open = append(open, orderUpdateFromResponse(o))
+	}
+	return s.handler.Resync(ctx, open, balances)
+}
+
+func (s *UserStream) dispatch(ctx context.Context, raw []byte) error {
+	var ev userEvent
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return fmt.Errorf("decode event: %w", err)
+	}
+	switch ev.EventType {
+	case "executionReport":
+		return s.handler.OnOrderUpdate(ctx, ev.orderUpdate())
+	case "outboundAccountPosition":
+		return s.handler.OnBalanceUpdate(ctx, ev.balanceUpdates())
+	case "listenKeyExpired":
+		return errListenKeyExpired
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+
+	"golang.org/x/net/websocket"
+)
+
+type recordingHandler struct {
+	mu       sync.Mutex
+	orders   []trading.OrderUpdate
+	balances []trading.BalanceUpdate
+	resyncs  int
+	openSeen int
+	done     chan struct{}
+	want     int
+}
+
+func (h *recordingHandler) OnOrderUpdate(_ context.Context, u trading.OrderUpdate) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.orders = append(h.orders, u)
+	h.check()
+	return nil
+}
+
+func (h *recordingHandler) OnBalanceUpdate(_ context.Context, b []trading.BalanceUpdate) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.balances = append(h.balances, b...)
+	h.check()
+	return nil
+}
+
+func (h *recordingHandler) Resync(_ context.Context, open []trading.OrderUpdate, _ []trading.BalanceUpdate) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resyncs++
+	h.openSeen += len(open)
+	h.check()
+	return nil
+}
+
+func (h *recordingHandler) check() {
+	if h.resyncs >= 2 && len(h.orders)+len(h.balances) >= h.want {
+		select {
+		case <-h.done:
+		default:
+			close(h.done)
+		}
+	}
+}
+
+const (
+	execNew    = `{"e":"executionReport","E":1700000000000,"s":"BTCUSDT","c":"cid-1","S":"BUY","o":"MARKET","q":"0.01000000","p":"0.00000000","x":"NEW","X":"NEW","i":42,"l":"0","z":"0","L":"0","n":"0","N":null,"T":1700000000000,"Z":"0"}`
+	execFilled = `{"e":"executionReport","E":1700000000100,"s":"BTCUSDT","c":"cid-1","S":"BUY","o":"MARKET","q":"0.01000000","p":"0.00000000","x":"TRADE","X":"FILLED","i":42,"l":"0.01000000","z":"0.01000000","L":"50000.00","n":"0.00001","N":"BTC","T":1700000000100,"Z":"500.00"}`
+	balanceEv  = `{"e":"outboundAccountPosition","E":1700000000200,"u":1700000000200,"B":[{"a":"BTC","f":"0.00999000","l":"0.00000000"},{"a":"USDT","f":"500.00","l":"0.00"}]}`
+)
+
+func newUserStreamServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	var mu sync.Mutex
+	conns := 0
+	keys := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/userDataStream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			mu.Lock()
+			keys++
+			n := keys
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"listenKey":"lk` + string(rune('0'+n)) + `"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/api/v3/account", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"balances":[{"asset":"BTC","free":"0.5","locked":"0"}]}`))
+	})
+	mux.HandleFunc("/api/v3/openOrders", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"symbol":"ETHUSDT","orderId":7,"status":"NEW","side":"SELL","type":"LIMIT","price":"3000","origQty":"1","executedQty":"0","cummulativeQuoteQty":"0"}]`))
+	})
+	mux.Handle("/ws/", websocket.Handler(func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+		if !strings.HasPrefix(ws.Request().URL.Path, "/ws/lk") {
+			return
+		}
+		if n == 1 {
+			// 第一次連線送出部分事件後斷線，驗證重新連線與重新同步。
+			_ = websocket.Message.Send(ws, execNew)
+			return
+		}
+		_ = websocket.Message.Send(ws, execFilled)
+		_ = websocket.Message.Send(ws, balanceEv)
+		_ = websocket.Message.Send(ws, `{"e":"somethingElse"}`)
+		var discard string
+		_ = websocket.Message.Receive(ws, &discard)
+	}))
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, &conns
+}
+
+func TestUserStream_DispatchAndReconnect(t *testing.T) {
+	ts, conns := newUserStreamServer(t)
+
+	client := NewClient("key", "secret", false)
+	client.baseURL = ts.URL
+	client.streamURL = "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	h := &recordingHandler{done: make(chan struct{}), want: 3}
+	stream := NewUserStream(client, h)
+	stream.minBackoff = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- stream.Run(ctx) }()
+
+	select {
+	case <-h.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if *conns < 2 {
+		t.Fatalf("expected reconnect, got %d connections", *conns)
+	}
+	if h.openSeen < 2 {
+		t.Fatalf("expected open orders resynced on each connect, got %d", h.openSeen)
+	}
+	if len(h.orders) != 2 {
+		t.Fatalf("expected 2 order updates, got %d", len(h.orders))
+	}
+	filled := h.orders[1]
+	if filled.OrderID != "42" || filled.Status != trading.OrderStatusFilled || filled.Side != "BUY" || filled.Symbol != "BTCUSDT" {
+		t.Fatalf("unexpected filled update: %+v", filled)
+	}
+	if filled.CumQty != 0.01 || filled.AvgPrice() != 50000 || filled.CommissionAsset != "BTC" {
+		t.Fatalf("unexpected fill amounts: %+v", filled)
+	}
+	if len(h.balances) != 2 || h.balances[0].Asset != "BTC" || h.balances[0].Free != 0.00999 {
+		t.Fatalf("unexpected balances: %+v", h.balances)
+	}
+}
+
+func TestUserEvent_ListenKeyExpired(t *testing.T) {
+	s := NewUserStream(NewClient("", "", false), &recordingHandler{done: make(chan struct{})})
+	err := s.dispatch(context.Background(), []byte(`{"e":"listenKeyExpired","E":1}`))
+	if err != errListenKeyExpired {
+		t.Fatalf("expected errListenKeyExpired, got %v", err)
+	}
+}
+
+func TestOrderUpdateFromResponse_UsesQueryTimestamps(t *testing.T) {
+	var o OrderResponse
+	raw := `{"symbol":"BTCUSDT","orderId":42,"status":"FILLED","side":"BUY","type":"LIMIT","price":"50000","origQty":"0.01","executedQty":"0.01","cummulativeQuoteQty":"500","time":1700000000000,"updateTime":1700000060000}`
+	if err := json.Unmarshal([]byte(raw), &o); err != nil {
+		t.Fatal(err)
+	}
+	if got := orderUpdateFromResponse(o).EventTime; !got.Equal(time.UnixMilli(1700000060000)) {
+		t.Fatalf("expected updateTime as event time, got %v", got)
+	}
+	if got := orderUpdateFromResponse(OrderResponse{OrderID: 1}).EventTime; !got.IsZero() {
+		t.Fatalf("expected zero event time without timestamps, got %v", got)
+	}
+}
@@ -0,0 +1,210 @@
+package bybit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+)
+
+const (
+	defaultFillTimeout  = 5 * time.Second
+	defaultPollInterval = 200 * time.Millisecond
+)
+
+// statusMap 將 Bybit 訂單狀態轉為系統統一（Binance 風格）的狀態。
+var statusMap = map[string]string{
+	"New":                     trading.OrderStatusNew,
+	"Created":                 trading.OrderStatusNew,
+	"Untriggered":             trading.OrderStatusNew,
+	"PartiallyFilled":         trading.OrderStatusPartiallyFilled,
+	"Filled":                  trading.OrderStatusFilled,
+	"Cancelled":               trading.OrderStatusCanceled,
+	"PartiallyFilledCanceled": trading.OrderStatusCanceled,
+	"Rejected":                trading.OrderStatusRejected,
+	"Deactivated":             trading.OrderStatusExpired,
+}
+
+func normalizeStatus(status string) string {
+	if s, ok := statusMap[status]; ok {
+		return s
+	}
+	return strings.ToUpper(status)
+}
+
+// ExchangeAdapter implements the trading.Exchange interface for Bybit spot.
+// Bybit 市價單回應只有 orderId，成交均價與數量需輪詢 /v5/order/realtime 取得。
+type ExchangeAdapter struct {
+	client       *Client
+	fillTimeout  time.Duration
+	pollInterval time.Duration
+
+	mu          sync.Mutex
+	instruments map[string]Instrument
+}
+
+// NewExchangeAdapter 建立 Bybit 交易轉接器。
+func NewExchangeAdapter(client *Client) *ExchangeAdapter {
+	return &ExchangeAdapter{
+		client:       client,
+		fillTimeout:  defaultFillTimeout,
+		pollInterval: defaultPollInterval,
+		instruments:  make(map[string]Instrument),
+	}
+}
+
+func (a *ExchangeAdapter) GetBalance(ctx context.Context, asset string) (float64, error) {
+	balances, err := a.client.GetWalletBalance(ctx, strings.ToUpper(asset))
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range balances {
+		if strings.EqualFold(b.Coin, asset) {
+			return b.Free(), nil
+		}
+	}
+	return 0, nil
+}
+
+func (a *ExchangeAdapter) GetOrder(ctx context.Context, symbol, orderID string) (trading.OrderResponse, error) {
+	o, err := a.client.GetOrder(ctx, symbol, orderID)
+	if err != nil {
+		return trading.OrderResponse{}, err
+	}
+	price := parseFloat(o.AvgPrice)
+	if price == 0 {
+		price = parseFloat(o.Price)
+	}
+	return trading.OrderResponse{
+		OrderID: o.OrderID,
+		Symbol:  o.Symbol,
+		Side:    strings.ToUpper(o.Side),
+		Price:   price,
+		Qty:     parseFloat(o.Qty),
+		Status:  normalizeStatus(o.OrderStatus),
+	}, nil
+}
+
+func (a *ExchangeAdapter) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	return a.client.GetPrice(ctx, symbol)
+}
+
+func (a *ExchangeAdapter) PlaceMarketOrder(ctx context.Context, symbol, side string, qty float64) (float64, float64, error) {
+	inst, err := a.instrument(ctx, symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	fmtQty := truncateToStep(qty, inst.BasePrecision)
+	price, executed, err := a.placeOrder(ctx, symbol, side, fmtQty, "baseCoin")
+	if err != nil {
+		return 0, 0, fmt.Errorf("symbol %s qty %s err: %w", symbol, fmtQty, err)
+	}
+	return price, executed, nil
+}
+
+func (a *ExchangeAdapter) PlaceMarketOrderQuote(ctx context.Context, symbol, side string, quoteAmount float64) (float64, float64, error) {
+	inst, err := a.instrument(ctx, symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a.placeOrder(ctx, symbol, side, truncateToStep(quoteAmount, inst.QuotePrecision), "quoteCoin")
+}
+
+func (a *ExchangeAdapter) placeOrder(ctx context.Context, symbol, side, qty, unit string) (float64, float64, error) {
+	orderID, err := a.client.CreateOrder(ctx, OrderRequest{
+		Symbol:     symbol,
+		Side:       bybitSide(side),
+		OrderType:  "Market",
+		Qty:        qty,
+		MarketUnit: unit,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return a.waitFill(ctx, symbol, orderID)
+}
+
+// waitFill 輪詢訂單直到終態，回傳成交均價與成交量。
+func (a *ExchangeAdapter) waitFill(ctx context.Context, symbol, orderID string) (float64, float64, error) {
+	deadline := time.Now().Add(a.fillTimeout)
+	for {
+		o, err := a.client.GetOrder(ctx, symbol, orderID)
+		switch {
+		case err == nil:
+			status := normalizeStatus(o.OrderStatus)
+			executed := parseFloat(o.CumExecQty)
+			if status == trading.OrderStatusFilled || status == trading.OrderStatusCanceled || status == trading.OrderStatusRejected || status == trading.OrderStatusExpired {
+				if executed <= 0 {
+					return 0, 0, fmt.Errorf("order %s %s with zero quantity", orderID, o.OrderStatus)
+				}
+				price := parseFloat(o.AvgPrice)
+				if price <= 0 {
+					if value := parseFloat(o.CumExecValue); value > 0 {
+						price = value / executed
+					}
+				}
+				if price <= 0 {
+					return 0, 0, fmt.Errorf("could not determine execution price")
+				}
+				return price, executed, nil
+			}
+		case !IsAPIError(err, ErrCodeOrderNotFound) && !isRetryable(err):
+			return 0, 0, err
+		}
+		if time.Now().After(deadline) {
+			return 0, 0, fmt.Errorf("order %s not filled within %v", orderID, a.fillTimeout)
+		}
+		timer := time.NewTimer(a.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (a *ExchangeAdapter) instrument(ctx context.Context, symbol string) (Instrument, error) {
+	a.mu.Lock()
+	inst, ok := a.instruments[symbol]
+	a.mu.Unlock()
+	if ok {
+		return inst, nil
+	}
+	inst, err := a.client.GetInstrument(ctx, symbol)
+	if err != nil {
+		return Instrument{}, fmt.Errorf("load instrument %s: %w", symbol, err)
+	}
+	a.mu.Lock()
+	a.instruments[symbol] = inst
+	a.mu.Unlock()
+	return inst, nil
+}
+
+func bybitSide(side string) string {
+	if strings.EqualFold(side, "sell") {
+		return "Sell"
+	}
+	return "Buy"
+}
+
+// truncateToStep 依精度字串（如 "0.000001"）無條件捨去並格式化。
+func truncateToStep(v float64, step string) string {
+	decimals := 8
+	if i := strings.IndexByte(step, '.'); i >= 0 {
+		decimals = len(strings.TrimRight(step[i+1:], "0"))
+	} else if step != "" {
+		decimals = 0
+	}
+	shift := math.Pow10(decimals)
+	// 加上 epsilon 避免 118.111 變成 118.110999999
+	truncated := math.Floor(v*shift+1e-7) / shift
+	return strconv.FormatFloat(truncated, 'f', decimals, 64)
+}
+
+var _ trading.Exchange = (*ExchangeAdapter)(nil)
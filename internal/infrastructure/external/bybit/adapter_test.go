@@ -0,0 +1,75 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+)
+
+func TestExchangeAdapter_PlaceMarketOrderQuoteWaitsForFill(t *testing.T) {
+	polls := 0
+	var created OrderRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v5/market/instruments-info":
+			_, _ = w.Write(fixture(t, "instruments_info.json"))
+		case "/v5/order/create":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write(fixture(t, "order_create.json"))
+		case "/v5/order/realtime":
+			polls++
+			if polls == 1 {
+				_, _ = w.Write(fixture(t, "order_realtime_new.json"))
+				return
+			}
+			_, _ = w.Write(fixture(t, "order_realtime_filled.json"))
+		}
+	})
+	a := NewExchangeAdapter(c)
+	a.pollInterval = time.Millisecond
+
+	price, qty, err := a.PlaceMarketOrderQuote(context.Background(), "BTCUSDT", "buy", 100.123456789)
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	if price != 64212.8 || qty != 0.001557 || polls != 2 {
+		t.Fatalf("unexpected fill price=%v qty=%v polls=%d", price, qty, polls)
+	}
+	if created.Side != "Buy" || created.MarketUnit != "quoteCoin" || created.Qty != "100.12345678" || created.Category != "spot" {
+		t.Fatalf("unexpected order request %+v", created)
+	}
+}
+
+func TestExchangeAdapter_GetOrderNormalizesStatus(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(fixture(t, "order_realtime_filled.json"))
+	})
+	o, err := NewExchangeAdapter(c).GetOrder(context.Background(), "BTCUSDT", "1733581291214569472")
+	if err != nil {
+		t.Fatalf("get order: %v", err)
+	}
+	if o.Status != trading.OrderStatusFilled || o.Side != "BUY" || o.Price != 64212.8 {
+		t.Fatalf("unexpected order %+v", o)
+	}
+}
+
+func TestTruncateToStep(t *testing.T) {
+	cases := []struct {
+		v    float64
+		step string
+		want string
+	}{
+		{0.0015579, "0.000001", "0.001557"},
+		{118.111, "0.001", "118.111"},
+		{5, "1", "5"},
+	}
+	for _, tc := range cases {
+		if got := truncateToStep(tc.v, tc.step); got != tc.want {
+			t.Fatalf("truncateToStep(%v, %q) = %s, want %s", tc.v, tc.step, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package bybit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRecvWindow  = "5000"
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 500 * time.Millisecond
+	// maxKlineLimit 為 /v5/market/kline 單次最多回傳筆數。
+	maxKlineLimit = 1000
+)
+
+// Client 為 Bybit v5 REST 客戶端（僅現貨 category=spot）。
+type Client struct {
+	apiKey      string
+	apiSecret   string
+	baseURL     string
+	recvWindow  string
+	httpClient  *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// NewClient 建立 Bybit 客戶端。
+func NewClient(apiKey, apiSecret string, useTestnet bool) *Client {
+	c := &Client{
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		recvWindow:  defaultRecvWindow,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+	}
+	c.SetBaseURL(useTestnet)
+	return c
+}
+
+func (c *Client) SetBaseURL(useTestnet bool) {
+	if useTestnet {
+		c.baseURL = "https://api-testnet.bybit.com"
+	} else {
+		c.baseURL = "https://api.bybit.com"
+	}
+}
+
+// sign 依 v5 規則簽章：HMAC_SHA256(timestamp + apiKey + recvWindow + payload)。
+func (c *Client) sign(timestamp, payload string) string {
+	h := hmac.New(sha256.New, []byte(c.apiSecret))
+	h.Write([]byte(timestamp + c.apiKey + c.recvWindow + payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// envelope 為所有 v5 回應的共用外層。
+type envelope struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+	Time    int64           `json:"time"`
+}
+
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsRateLimited() || apiErr.IsServerError()
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// call 送出請求並回傳 result；僅 GET 會在限流或伺服器錯誤時重試，下單不重送。
+func (c *Client) call(ctx context.Context, method, path string, params url.Values, body any, signed bool) (json.RawMessage, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, method, path, params, body, signed)
+		if err == nil {
+			return res, nil
+		}
+		if method != http.MethodGet || !isRetryable(err) || attempt+1 >= c.maxAttempts {
+			return nil, err
+		}
+		delay := time.Duration(rand.Int63n(int64(c.baseDelay<<attempt) + 1))
+		log.Printf("[Bybit] %s %s failed (attempt %d): %v, retrying in %v", method, path, attempt+1, err, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, params url.Values, body any, signed bool) (json.RawMessage, error) {
+	fullURL := c.baseURL + path
+	query := encodeSorted(params)
+	if query != "" {
+		fullURL += "?" + query
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if signed {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		signPayload := query
+		if method != http.MethodGet {
+			signPayload = string(payload)
+		}
+		req.Header.Set("X-BAPI-API-KEY", c.apiKey)
+		req.Header.Set("X-BAPI-TIMESTAMP", ts)
+		req.Header.Set("X-BAPI-RECV-WINDOW", c.recvWindow)
+		req.Header.Set("X-BAPI-SIGN", c.sign(ts, signPayload))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{StatusCode: resp.StatusCode, Msg: string(raw)}
+		}
+		return nil, fmt.Errorf("decode bybit response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || env.RetCode != 0 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Code: env.RetCode, Msg: env.RetMsg}
+	}
+	return env.Result, nil
+}
+
+// encodeSorted 以固定順序編碼 query，確保簽章內容與實際送出一致。
+func encodeSorted(params url.Values) string {
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range params[k] {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+// GetPrice 取得現貨最新成交價。
+func (c *Client) GetPrice(ctx context.Context, symbol string) (float64, error) {
+	params := url.Values{}
+	params.Set("category", "spot")
+	params.Set("symbol", symbol)
+	res, err := c.call(ctx, http.MethodGet, "/v5/market/tickers", params, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	var out struct {
+		List []struct {
+			Symbol    string `json:"symbol"`
+			LastPrice string `json:"lastPrice"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return 0, err
+	}
+	if len(out.List) == 0 {
+		return 0, fmt.Errorf("bybit ticker not found: %s", symbol)
+	}
+	return strconv.ParseFloat(out.List[0].LastPrice, 64)
+}
+
+// Instrument 為現貨交易對的下單精度。
+type Instrument struct {
+	Symbol         string
+	BasePrecision  string
+	QuotePrecision string
+	MinOrderAmt    float64
+}
+
+// GetInstrument 取得交易對的數量/金額精度。
+func (c *Client) GetInstrument(ctx context.Context, symbol string) (Instrument, error) {
+	params := url.Values{}
+	params.Set("category", "spot")
+	params.Set("symbol", symbol)
+	res, err := c.call(ctx, http.MethodGet, "/v5/market/instruments-info", params, nil, false)
+	if err != nil {
+		return Instrument{}, err
+	}
+	var out struct {
+		List []struct {
+			Symbol        string `json:"symbol"`
+			LotSizeFilter struct {
+				BasePrecision  string `json:"basePrecision"`
+				QuotePrecision string `json:"quotePrecision"`
+				MinOrderAmt    string `json:"minOrderAmt"`
+			} `json:"lotSizeFilter"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return Instrument{}, err
+	}
+	if len(out.List) == 0 {
+		return Instrument{}, fmt.Errorf("bybit instrument not found: %s", symbol)
+	}
+	f := out.List[0].LotSizeFilter
+	return Instrument{
+		Symbol:         out.List[0].Symbol,
+		BasePrecision:  f.BasePrecision,
+		QuotePrecision: f.QuotePrecision,
+		MinOrderAmt:    parseFloat(f.MinOrderAmt),
+	}, nil
+}
+
+// Kline 為單根 K 線。
+type Kline struct {
+	OpenTime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+	Turnover float64
+}
+
+// GetKlines 取得 [start, end] 區間的 K 線（單次請求）；回傳結果已轉為由舊到新。
+func (c *Client) GetKlines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	params := url.Values{}
+	params.Set("category", "spot")
+	params.Set("symbol", symbol)
+	params.Set("interval", interval)
+	if !start.IsZero() {
+		params.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		params.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+	if limit <= 0 || limit > maxKlineLimit {
+		limit = maxKlineLimit
+	}
+	params.Set("limit", strconv.Itoa(limit))
+
+	res, err := c.call(ctx, http.MethodGet, "/v5/market/kline", params, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		List [][]string `json:"list"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return nil, err
+	}
+	klines := make([]Kline, 0, len(out.List))
+	// Bybit 由新到舊排序，反向走訪以輸出由舊到新。
+	for i := len(out.List) - 1; i >= 0; i-- {
+		row := out.List[i]
+		if len(row) < 7 {
+			continue
+		}
+		openMs, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		klines = append(klines, Kline{
+			OpenTime: time.UnixMilli(openMs).UTC(),
+			Open:     parseFloat(row[1]),
+			High:     parseFloat(row[2]),
+			Low:      parseFloat(row[3]),
+			Close:    parseFloat(row[4]),
+			Volume:   parseFloat(row[5]),
+			Turnover: parseFloat(row[6]),
+		})
+	}
+	return klines, nil
+}
+
+// OrderRequest 為 /v5/order/create 的請求內容。
+type OrderRequest struct {
+	Category    string `json:"category"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	OrderType   string `json:"orderType"`
+	Qty         string `json:"qty"`
+	MarketUnit  string `json:"marketUnit,omitempty"`
+	OrderLinkID string `json:"orderLinkId,omitempty"`
+}
+
+// CreateOrder 下單並回傳 orderId；成交結果需另以 GetOrder 查詢。
+func (c *Client) CreateOrder(ctx context.Context, req OrderRequest) (string, error) {
+	if req.Category == "" {
+		req.Category = "spot"
+	}
+	res, err := c.call(ctx, http.MethodPost, "/v5/order/create", nil, req, true)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		OrderID     string `json:"orderId"`
+		OrderLinkID string `json:"orderLinkId"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return "", err
+	}
+	if out.OrderID == "" {
+		return "", fmt.Errorf("bybit create order returned empty order id")
+	}
+	return out.OrderID, nil
+}
+
+// Order 為 /v5/order/realtime 的單筆訂單。
+type Order struct {
+	OrderID      string `json:"orderId"`
+	OrderLinkID  string `json:"orderLinkId"`
+	Symbol       string `json:"symbol"`
+	Side         string `json:"side"`
+	OrderType    string `json:"orderType"`
+	OrderStatus  string `json:"orderStatus"`
+	Price        string `json:"price"`
+	Qty          string `json:"qty"`
+	AvgPrice     string `json:"avgPrice"`
+	CumExecQty   string `json:"cumExecQty"`
+	CumExecValue string `json:"cumExecValue"`
+	CumExecFee   string `json:"cumExecFee"`
+	UpdatedTime  string `json:"updatedTime"`
+}
+
+// GetOrder 查詢單筆訂單（含近期已完成訂單）。
+func (c *Client) GetOrder(ctx context.Context, symbol, orderID string) (*Order, error) {
+	params := url.Values{}
+	params.Set("category", "spot")
+	params.Set("symbol", symbol)
+	params.Set("orderId", orderID)
+	res, err := c.call(ctx, http.MethodGet, "/v5/order/realtime", params, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		List []Order `json:"list"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return nil, err
+	}
+	if len(out.List) == 0 {
+		return nil, &APIError{StatusCode: http.StatusOK, Code: ErrCodeOrderNotFound, Msg: "order not found"}
+	}
+	return &out.List[0], nil
+}
+
+// CoinBalance 為統一帳戶中單一幣種的餘額。
+type CoinBalance struct {
+	Coin          string
+	WalletBalance float64
+	Locked        float64
+}
+
+// Free 回傳可用餘額。
+func (b CoinBalance) Free() float64 {
+	return b.WalletBalance - b.Locked
+}
+
+// GetWalletBalance 取得統一帳戶（UNIFIED）指定幣種餘額，coin 為空時回傳全部。
+func (c *Client) GetWalletBalance(ctx context.Context, coin string) ([]CoinBalance, error) {
+	params := url.Values{}
+	params.Set("accountType", "UNIFIED")
+	if coin != "" {
+		params.Set("coin", coin)
+	}
+	res, err := c.call(ctx, http.MethodGet, "/v5/account/wallet-balance", params, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		List []struct {
+			Coin []struct {
+				Coin          string `json:"coin"`
+				WalletBalance string `json:"walletBalance"`
+				Locked        string `json:"locked"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(res, &out); err != nil {
+		return nil, err
+	}
+	var balances []CoinBalance
+	for _, acct := range out.List {
+		for _, c := range acct.Coin {
+			balances = append(balances, CoinBalance{
+				Coin:          c.Coin,
+				WalletBalance: parseFloat(c.WalletBalance),
+				Locked:        parseFloat(c.Locked),
+			})
+		}
+	}
+	return balances, nil
+}
@@ -0,0 +1,120 @@
+package bybit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// fixture 讀取 testdata 中錄製的 Bybit 回應。
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	c := NewClient("key", "secret", false)
+	c.baseURL = ts.URL
+	c.baseDelay = time.Millisecond
+	return c
+}
+
+func expectedSign(ts, payload string) string {
+	h := hmac.New(sha256.New, []byte("secret"))
+	h.Write([]byte(ts + "key" + defaultRecvWindow + payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestClient_GetPrice(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5/market/tickers" || r.URL.Query().Get("category") != "spot" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write(fixture(t, "tickers.json"))
+	})
+	p, err := c.GetPrice(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("get price: %v", err)
+	}
+	if p != 64210.55 {
+		t.Fatalf("unexpected price %v", p)
+	}
+}
+
+func TestClient_SignsRequests(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ts := r.Header.Get("X-BAPI-TIMESTAMP")
+		payload := r.URL.RawQuery
+		if r.Method == http.MethodPost {
+			b, _ := io.ReadAll(r.Body)
+			payload = string(b)
+		}
+		if r.Header.Get("X-BAPI-API-KEY") != "key" || r.Header.Get("X-BAPI-SIGN") != expectedSign(ts, payload) {
+			t.Errorf("bad signature for %s %s", r.Method, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/v5/account/wallet-balance":
+			_, _ = w.Write(fixture(t, "wallet_balance.json"))
+		case "/v5/order/create":
+			_, _ = w.Write(fixture(t, "order_create.json"))
+		}
+	})
+
+	balances, err := c.GetWalletBalance(context.Background(), "USDT")
+	if err != nil {
+		t.Fatalf("wallet balance: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Free() != 1153.52 {
+		t.Fatalf("unexpected balances %+v", balances)
+	}
+	id, err := c.CreateOrder(context.Background(), OrderRequest{Symbol: "BTCUSDT", Side: "Buy", OrderType: "Market", Qty: "100", MarketUnit: "quoteCoin"})
+	if err != nil || id != "1733581291214569472" {
+		t.Fatalf("create order: %v %s", err, id)
+	}
+}
+
+func TestClient_RetCodeIsTypedAndOrdersNotRetried(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write(fixture(t, "insufficient_balance.json"))
+	})
+	_, err := c.CreateOrder(context.Background(), OrderRequest{Symbol: "BTCUSDT", Side: "Buy", OrderType: "Market", Qty: "100"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != ErrCodeInsufficientBalance {
+		t.Fatalf("expected typed insufficient balance error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("orders must not be retried, got %d calls", calls)
+	}
+}
+
+func TestClient_RetriesRateLimitedGet(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			_, _ = w.Write([]byte(`{"retCode":10006,"retMsg":"Too many visits!","result":{},"time":1719734400000}`))
+			return
+		}
+		_, _ = w.Write(fixture(t, "tickers.json"))
+	})
+	if _, err := c.GetPrice(context.Background(), "BTCUSDT"); err != nil || calls != 2 {
+		t.Fatalf("expected retry to succeed, err=%v calls=%d", err, calls)
+	}
+}
@@ -0,0 +1,44 @@
+package bybit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Bybit v5 retCode（部分）。
+const (
+	ErrCodeInvalidParam        = 10001
+	ErrCodeTimestamp           = 10002
+	ErrCodeInvalidSignature    = 10004
+	ErrCodeTooManyRequests     = 10006
+	ErrCodeOrderNotFound       = 110001
+	ErrCodeInsufficientBalance = 170131
+)
+
+// APIError 為 Bybit 回傳的錯誤；HTTP 200 但 retCode 非 0 時 StatusCode 仍為 200。
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("bybit api error (status %d, code %d): %s", e.StatusCode, e.Code, e.Msg)
+}
+
+// IsRateLimited 代表請求因頻率限制被拒。
+func (e *APIError) IsRateLimited() bool {
+	return e.Code == ErrCodeTooManyRequests || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusForbidden
+}
+
+// IsServerError 代表交易所端錯誤，狀態未知但可重試查詢類請求。
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// IsAPIError 判斷 err 是否為指定 retCode 的 Bybit 錯誤。
+func IsAPIError(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
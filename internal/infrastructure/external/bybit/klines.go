@@ -0,0 +1,90 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+// errUnsupportedTimeframe 代表 Bybit 不支援該 K 線週期。
+var errUnsupportedTimeframe = errors.New("unsupported timeframe")
+
+// intervals 將系統 timeframe 對應到 Bybit interval 參數。
+var intervals = map[string]string{
+	"1m":  "1",
+	"5m":  "5",
+	"15m": "15",
+	"30m": "30",
+	"1h":  "60",
+	"4h":  "240",
+	"1d":  "D",
+	"1w":  "W",
+}
+
+// KlineSource 以 /v5/market/kline 實作 dataingestion.KlineSource。
+// Bybit 在區間內回傳最新的 limit 筆，因此由 end 往回分頁。
+type KlineSource struct {
+	client   *Client
+	pageSize int
+}
+
+// NewKlineSource 建立 Bybit K 線來源。
+func NewKlineSource(client *Client) *KlineSource {
+	return &KlineSource{client: client, pageSize: maxKlineLimit}
+}
+
+// FetchKlines 取得 [start, end) 區間的 K 線；start 為零值時僅取最近一頁。
+func (k *KlineSource) FetchKlines(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataDomain.DailyPrice, error) {
+	interval, ok := intervals[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("bybit %w: %s", errUnsupportedTimeframe, timeframe)
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	var pages [][]Kline
+	cursor := end.Add(-time.Millisecond)
+	for {
+		page, err := k.client.GetKlines(ctx, symbol, interval, start, cursor, k.pageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) > 0 {
+			pages = append(pages, page)
+		}
+		if start.IsZero() || len(page) < k.pageSize {
+			break
+		}
+		next := page[0].OpenTime.Add(-time.Millisecond)
+		if !next.Before(cursor) || next.Before(start) {
+			break
+		}
+		cursor = next
+	}
+
+	var out []dataDomain.DailyPrice
+	for i := len(pages) - 1; i >= 0; i-- {
+		for _, kl := range pages[i] {
+			if !kl.OpenTime.Before(end) || (!start.IsZero() && kl.OpenTime.Before(start)) {
+				continue
+			}
+			out = append(out, dataDomain.DailyPrice{
+				Symbol:    symbol,
+				Market:    dataDomain.MarketCrypto,
+				Timeframe: timeframe,
+				TradeDate: kl.OpenTime,
+				Open:      kl.Open,
+				High:      kl.High,
+				Low:       kl.Low,
+				Close:     kl.Close,
+				Volume:    int64(kl.Volume),
+				Turnover:  int64(kl.Turnover),
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package bybit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestKlineSource_PaginatesBackwardAndSorts(t *testing.T) {
+	var ends []int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") != "D" {
+			t.Errorf("unexpected interval %s", r.URL.Query().Get("interval"))
+		}
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		ends = append(ends, end)
+		if end >= 1704153600000 {
+			_, _ = w.Write(fixture(t, "kline_page1.json"))
+			return
+		}
+		_, _ = w.Write(fixture(t, "kline_page2.json"))
+	})
+	src := NewKlineSource(c)
+	src.pageSize = 2
+
+	start := time.UnixMilli(1704067200000).UTC()
+	prices, err := src.FetchKlines(context.Background(), "BTCUSDT", "1d", start, start.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("fetch klines: %v", err)
+	}
+	if len(prices) != 3 || len(ends) != 2 {
+		t.Fatalf("expected 3 klines over 2 pages, got %d over %d", len(prices), len(ends))
+	}
+	if ends[1] != 1704153599999 {
+		t.Fatalf("second page should end before oldest open time, got %d", ends[1])
+	}
+	for i := 1; i < len(prices); i++ {
+		if !prices[i].TradeDate.After(prices[i-1].TradeDate) {
+			t.Fatalf("klines not ascending: %v", prices)
+		}
+	}
+	if prices[0].Close != 44179.55 || prices[2].Close != 42845.23 || prices[2].Timeframe != "1d" {
+		t.Fatalf("unexpected klines %+v", prices)
+	}
+}
+
+func TestKlineSource_RejectsUnknownTimeframe(t *testing.T) {
+	src := NewKlineSource(NewClient("", "", false))
+	if _, err := src.FetchKlines(context.Background(), "BTCUSDT", "2h", time.Time{}, time.Time{}); err == nil {
+		t.Fatal("expected unsupported timeframe error")
+	}
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/trading"
+)
+
+// ErrUnknownExchange 代表名稱未註冊於 Registry。
+var ErrUnknownExchange = errors.New("unknown exchange")
+
+type entry struct {
+	exchange trading.Exchange
+	klines   dataingestion.KlineSource
+}
+
+// Registry 依名稱管理各交易所的下單轉接器與 K 線來源；名稱為空時使用預設交易所。
+type Registry struct {
+	mu          sync.RWMutex
+	defaultName string
+	entries     map[string]entry
+}
+
+// NewRegistry 建立交易所註冊表。
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{
+		defaultName: strings.ToLower(defaultName),
+		entries:     make(map[string]entry),
+	}
+}
+
+// Register 註冊交易所；klines 可為 nil 代表不提供行情。
+func (r *Registry) Register(name string, ex trading.Exchange, klines dataingestion.KlineSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[strings.ToLower(name)] = entry{exchange: ex, klines: klines}
+}
+
+func (r *Registry) lookup(name string) (entry, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		key = r.defaultName
+	}
+	r.mu.RLock()
+	e, ok := r.entries[key]
+	r.mu.RUnlock()
+	if !ok {
+		return entry{}, fmt.Errorf("%w: %s", ErrUnknownExchange, key)
+	}
+	return e, nil
+}
+
+// Exchange 實作 trading.ExchangeResolver。
+func (r *Registry) Exchange(name string) (trading.Exchange, error) {
+	e, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.exchange == nil {
+		return nil, fmt.Errorf("exchange %s does not support trading", name)
+	}
+	return e.exchange, nil
+}
+
+// KlineSource 回傳指定交易所的 K 線來源。
+func (r *Registry) KlineSource(name string) (dataingestion.KlineSource, error) {
+	e, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.klines == nil {
+		return nil, fmt.Errorf("exchange %s does not provide klines", name)
+	}
+	return e.klines, nil
+}
+
+// Names 回傳已註冊的交易所名稱（排序後）。
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for n := range r.entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ trading.ExchangeResolver = (*Registry)(nil)
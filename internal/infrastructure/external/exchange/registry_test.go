@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/trading"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+type stubExchange struct {
+	trading.Exchange
+	name string
+}
+
+type stubKlines struct{}
+
+func (stubKlines) FetchKlines(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataDomain.DailyPrice, error) {
+	return nil, nil
+}
+
+func TestRegistry_ResolvesByNameAndDefault(t *testing.T) {
+	r := NewRegistry("binance")
+	r.Register("binance", stubExchange{name: "binance"}, stubKlines{})
+	r.Register("Bybit", stubExchange{name: "bybit"}, nil)
+
+	ex, err := r.Exchange("")
+	if err != nil || ex.(stubExchange).name != "binance" {
+		t.Fatalf("expected default exchange, got %v %v", ex, err)
+	}
+	ex, err = r.Exchange("BYBIT")
+	if err != nil || ex.(stubExchange).name != "bybit" {
+		t.Fatalf("expected case-insensitive lookup, got %v %v", ex, err)
+	}
+	if _, err := r.Exchange("okx"); !errors.Is(err, ErrUnknownExchange) {
+		t.Fatalf("expected unknown exchange, got %v", err)
+	}
+	if _, err := r.KlineSource("bybit"); err == nil {
+		t.Fatal("expected error for exchange without klines")
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "binance" || names[1] != "bybit" {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// AdvisoryLock 以 session 層級的 pg_try_advisory_lock 實作 leader.Lock。
+// 鎖綁定在專用連線上，連線中斷時 Postgres 會自動釋放，讓其他實例接手。
+type AdvisoryLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewAdvisoryLock 建立 advisory lock。
+func NewAdvisoryLock(db *sql.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{db: db, key: key}
+}
+
+// TryAcquire 嘗試取得鎖，未取得時歸還連線。
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		return true, nil
+	}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("advisory lock conn: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil {
+		// 無法確定是否已取得鎖，丟棄連線讓 session 結束
+		discardConn(conn)
+		return false, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	if !ok {
+		_ = conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Check 確認持鎖連線仍存活。
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return errors.New("advisory lock not held")
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		discardConn(l.conn)
+		l.conn = nil
+		return fmt.Errorf("advisory lock connection lost: %w", err)
+	}
+	return nil
+}
+
+// Release 釋放鎖並歸還連線。
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	var unlocked bool
+	err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&unlocked)
+	if err == nil && !unlocked {
+		err = errors.New("pg_advisory_unlock: lock was not held by this session")
+	}
+	if err != nil {
+		// Close 只會把連線放回連線池，session 與鎖仍在；丟棄連線才會結束 session 並釋放鎖
+		discardConn(l.conn)
+	} else {
+		_ = l.conn.Close()
+	}
+	l.conn = nil
+	return err
+}
+
+// discardConn 讓連線池關閉底層連線而非重複使用，結束其 session。
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"ai-auto-trade/internal/application/analysis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VersionStore 以 analysis_versions 保存分析版本註冊表。
+type VersionStore struct {
+	db *gorm.DB
+}
+
+func NewVersionStore(db *gorm.DB) *VersionStore {
+	return &VersionStore{db: db}
+}
+
+// ListVersions 依建立時間排序。
+func (s *VersionStore) ListVersions(ctx context.Context) ([]analysis.Version, error) {
+	var models []AnalysisVersionModel
+	if err := s.db.WithContext(ctx).Order("created_at, name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]analysis.Version, len(models))
+	for i, m := range models {
+		out[i] = fromAnalysisVersionModel(m)
+	}
+	return out, nil
+}
+
+func (s *VersionStore) GetVersion(ctx context.Context, name string) (analysis.Version, error) {
+	var m AnalysisVersionModel
+	if err := s.db.WithContext(ctx).Where("name = ?", name).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return analysis.Version{}, analysis.ErrVersionNotFound
+		}
+		return analysis.Version{}, err
+	}
+	return fromAnalysisVersionModel(m), nil
+}
+
+// CreateVersion 新增未啟用的版本，名稱已存在時回傳 ErrVersionExists。
+func (s *VersionStore) CreateVersion(ctx context.Context, v analysis.Version) error {
+	m := AnalysisVersionModel{Name: v.Name, Description: v.Description, ScoreModel: v.ScoreModel, CreatedAt: v.CreatedAt}
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&m)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return analysis.ErrVersionExists
+	}
+	return nil
+}
+
+// ActivateVersion 在同一交易中停用原本的版本並啟用指定版本。
+func (s *VersionStore) ActivateVersion(ctx context.Context, name string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&AnalysisVersionModel{}).Where("is_active AND name <> ?", name).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&AnalysisVersionModel{}).Where("name = ?", name).Update("is_active", true)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return analysis.ErrVersionNotFound
+		}
+		return nil
+	})
+}
+
+func fromAnalysisVersionModel(m AnalysisVersionModel) analysis.Version {
+	return analysis.Version{
+		Name:        m.Name,
+		Description: m.Description,
+		ScoreModel:  m.ScoreModel,
+		Active:      m.IsActive,
+		CreatedAt:   m.CreatedAt,
+	}
+}
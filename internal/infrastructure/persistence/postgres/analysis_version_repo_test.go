@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/analysis"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestVersionStore_CreateAndActivate(t *testing.T) {
+	gormDB, mock, db := setupRepoMock(t)
+	defer db.Close()
+	store := NewVersionStore(gormDB)
+	ctx := context.Background()
+	v := analysis.Version{Name: "v2", Description: "new score", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "analysis_versions" .* ON CONFLICT DO NOTHING`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := store.CreateVersion(ctx, v); err != nil {
+		t.Fatalf("CreateVersion: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "analysis_versions" .* ON CONFLICT DO NOTHING`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	if err := store.CreateVersion(ctx, v); !errors.Is(err, analysis.ErrVersionExists) {
+		t.Fatalf("expected ErrVersionExists, got %v", err)
+	}
+
+	// 先停用原版本再啟用新版本，兩者在同一交易
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "analysis_versions" SET "is_active"=\$1 WHERE is_active AND name <> \$2`).WithArgs(false, "v2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "analysis_versions" SET "is_active"=\$1 WHERE name = \$2`).WithArgs(true, "v2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := store.ActivateVersion(ctx, "v2"); err != nil {
+		t.Fatalf("ActivateVersion: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "analysis_versions" SET "is_active"=\$1 WHERE is_active AND name <> \$2`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "analysis_versions" SET "is_active"=\$1 WHERE name = \$2`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := store.ActivateVersion(ctx, "missing"); !errors.Is(err, analysis.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRepo_FindVersionHistory(t *testing.T) {
+	gormDB, mock, db := setupRepoMock(t)
+	defer db.Close()
+	repo := NewRepo(gormDB)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"trading_pair", "timeframe", "trade_date", "analysis_version", "score", "status"}).
+		AddRow("BTCUSDT", "1d", day, "v2", 55.0, "success")
+	mock.ExpectQuery(`SELECT .* FROM analysis_results ar JOIN stocks s .* WHERE s.trading_pair = \$1 AND ar.analysis_version = \$2 AND ar.timeframe = \$3`).
+		WithArgs("BTCUSDT", "v2", "1d", "success", 10).
+		WillReturnRows(rows)
+
+	results, err := repo.FindVersionHistory(context.Background(), "v2", "BTCUSDT", "1d", nil, nil, 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Version != "v2" || results[0].Score != 55 {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+
+	"gorm.io/gorm"
+)
+
+// BackfillStore 以 backfill_jobs 保存回補工作與檢查點。
+type BackfillStore struct {
+	db *gorm.DB
+}
+
+func NewBackfillStore(db *gorm.DB) *BackfillStore {
+	return &BackfillStore{db: db}
+}
+
+// backfillCheckpoint 為 checkpoint 欄位的內容。
+type backfillCheckpoint struct {
+	Tasks           []dataingestion.BackfillTask `json:"tasks"`
+	PendingAnalysis []time.Time                  `json:"pending_analysis,omitempty"`
+	AnalyzedDays    int                          `json:"analyzed_days"`
+	AnalysisSuccess int                          `json:"analysis_success"`
+	AnalysisFailure int                          `json:"analysis_failure"`
+	AnalysisErrors  []string                     `json:"analysis_errors,omitempty"`
+}
+
+func (s *BackfillStore) CreateBackfill(ctx context.Context, job *dataingestion.BackfillJob) error {
+	m, err := toBackfillModel(job)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Create(&m).Error; err != nil {
+		return err
+	}
+	job.ID = m.ID
+	return nil
+}
+
+// SaveBackfill 覆寫狀態與檢查點。
+func (s *BackfillStore) SaveBackfill(ctx context.Context, job *dataingestion.BackfillJob) error {
+	m, err := toBackfillModel(job)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&BackfillJobModel{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":      m.Status,
+		"phase":       m.Phase,
+		"checkpoint":  m.Checkpoint,
+		"error":       m.Error,
+		"started_at":  m.StartedAt,
+		"finished_at": m.FinishedAt,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+func (s *BackfillStore) GetBackfill(ctx context.Context, id string) (*dataingestion.BackfillJob, error) {
+	var m BackfillJobModel
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dataingestion.ErrBackfillNotFound
+		}
+		return nil, err
+	}
+	job := fromBackfillModel(m)
+	return &job, nil
+}
+
+// ListBackfills 回傳最近 50 筆工作。
+func (s *BackfillStore) ListBackfills(ctx context.Context, statuses ...dataingestion.BackfillStatus) ([]dataingestion.BackfillJob, error) {
+	q := s.db.WithContext(ctx).Order("created_at DESC").Limit(50)
+	if len(statuses) > 0 {
+		values := make([]string, len(statuses))
+		for i, st := range statuses {
+			values[i] = string(st)
+		}
+		q = q.Where("status IN ?", values)
+	}
+	var models []BackfillJobModel
+	if err := q.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]dataingestion.BackfillJob, len(models))
+	for i, m := range models {
+		out[i] = fromBackfillModel(m)
+	}
+	return out, nil
+}
+
+func toBackfillModel(job *dataingestion.BackfillJob) (BackfillJobModel, error) {
+	symbols, err := json.Marshal(job.Symbols)
+	if err != nil {
+		return BackfillJobModel{}, err
+	}
+	timeframes, err := json.Marshal(job.Timeframes)
+	if err != nil {
+		return BackfillJobModel{}, err
+	}
+	checkpoint, err := json.Marshal(backfillCheckpoint{
+		Tasks:           job.Tasks,
+		PendingAnalysis: job.PendingAnalysis,
+		AnalyzedDays:    job.AnalyzedDays,
+		AnalysisSuccess: job.AnalysisSuccess,
+		AnalysisFailure: job.AnalysisFailure,
+		AnalysisErrors:  job.AnalysisErrors,
+	})
+	if err != nil {
+		return BackfillJobModel{}, err
+	}
+	return BackfillJobModel{
+		ID:          job.ID,
+		Status:      string(job.Status),
+		Phase:       job.Phase,
+		TriggeredBy: nullableString(job.TriggeredBy),
+		Symbols:     symbols,
+		Timeframes:  timeframes,
+		StartTime:   job.Start,
+		EndTime:     job.End,
+		RunAnalysis: job.RunAnalysis,
+		Checkpoint:  checkpoint,
+		Error:       nullableString(job.Error),
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   optionalTime(job.StartedAt),
+		FinishedAt:  optionalTime(job.FinishedAt),
+	}, nil
+}
+
+func fromBackfillModel(m BackfillJobModel) dataingestion.BackfillJob {
+	job := dataingestion.BackfillJob{
+		ID:          m.ID,
+		TriggeredBy: derefString(m.TriggeredBy),
+		Start:       m.StartTime,
+		End:         m.EndTime,
+		RunAnalysis: m.RunAnalysis,
+		Status:      dataingestion.BackfillStatus(m.Status),
+		Phase:       m.Phase,
+		Error:       derefString(m.Error),
+		CreatedAt:   m.CreatedAt,
+		StartedAt:   derefTime(m.StartedAt),
+		FinishedAt:  derefTime(m.FinishedAt),
+	}
+	_ = json.Unmarshal(m.Symbols, &job.Symbols)
+	_ = json.Unmarshal(m.Timeframes, &job.Timeframes)
+	var cp backfillCheckpoint
+	if len(m.Checkpoint) > 0 && json.Unmarshal(m.Checkpoint, &cp) == nil {
+		job.Tasks = cp.Tasks
+		job.PendingAnalysis = cp.PendingAnalysis
+		job.AnalyzedDays = cp.AnalyzedDays
+		job.AnalysisSuccess = cp.AnalysisSuccess
+		job.AnalysisFailure = cp.AnalysisFailure
+		job.AnalysisErrors = cp.AnalysisErrors
+	}
+	return job
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
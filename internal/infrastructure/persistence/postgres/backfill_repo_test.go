@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBackfillStore_CheckpointRoundTrip(t *testing.T) {
+	gormDB, mock, db := setupPresetMock(t)
+	defer db.Close()
+	store := NewBackfillStore(gormDB)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	job := &dataingestion.BackfillJob{
+		Symbols: []string{"BTCUSDT"}, Timeframes: []string{"1h"},
+		Start: start, End: start.Add(24 * time.Hour),
+		Status: dataingestion.BackfillQueued, Phase: dataingestion.PhaseDetect,
+		CreatedAt: start,
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "backfill_jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("bf-1"))
+	mock.ExpectCommit()
+	if err := store.CreateBackfill(context.Background(), job); err != nil || job.ID != "bf-1" {
+		t.Fatalf("CreateBackfill: id=%q err=%v", job.ID, err)
+	}
+
+	checkpoint, _ := json.Marshal(backfillCheckpoint{Tasks: []dataingestion.BackfillTask{{
+		Symbol: "BTCUSDT", Timeframe: "1h", Detected: true, Missing: 24, Processed: 10,
+		Gaps: []dataingestion.TimeRange{{From: start.Add(10 * time.Hour), To: start.Add(24 * time.Hour)}},
+	}}})
+	rows := sqlmock.NewRows([]string{"id", "status", "phase", "symbols", "timeframes", "start_time", "end_time", "checkpoint", "created_at"}).
+		AddRow("bf-1", "running", "fetch", []byte(`["BTCUSDT"]`), []byte(`["1h"]`), start, start.Add(24*time.Hour), checkpoint, start)
+	mock.ExpectQuery(`SELECT \* FROM "backfill_jobs" WHERE id = \$1`).WithArgs("bf-1", 1).WillReturnRows(rows)
+
+	got, err := store.GetBackfill(context.Background(), "bf-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != dataingestion.BackfillRunning || len(got.Tasks) != 1 || got.Tasks[0].Processed != 10 || len(got.Tasks[0].Gaps) != 1 || got.Symbols[0] != "BTCUSDT" {
+		t.Errorf("unexpected job %+v", got)
+	}
+}
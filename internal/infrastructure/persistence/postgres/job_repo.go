@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-auto-trade/internal/application/jobs"
+
+	"gorm.io/gorm"
+)
+
+// JobStore 以 ingestion_jobs/analysis_jobs 與其明細表記錄每次執行。
+// ingestion_jobs 為主檔（status 為整體狀態），分析階段以 ingestion_job_id 關聯。
+type JobStore struct {
+	db *gorm.DB
+}
+
+func NewJobStore(db *gorm.DB) *JobStore {
+	return &JobStore{db: db}
+}
+
+// jobOptions 存放在 ingestion_jobs.options 的執行資訊。
+type jobOptions struct {
+	TriggeredBy string `json:"triggered_by,omitempty"`
+	DataSource  string `json:"data_source,omitempty"`
+	AnalysisOn  bool   `json:"analysis_on"`
+}
+
+// SaveRun 在同一交易內寫入主檔、分析檔與所有明細。
+func (s *JobStore) SaveRun(ctx context.Context, run *jobs.Run) error {
+	opts, err := json.Marshal(jobOptions{TriggeredBy: run.TriggeredBy, DataSource: run.DataSource, AnalysisOn: run.AnalysisOn})
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		job := IngestionJobModel{
+			JobType:         run.Kind,
+			TargetStartDate: optionalDate(run.TargetStart),
+			TargetEndDate:   optionalDate(run.TargetEnd),
+			Options:         opts,
+			Status:          run.Status(),
+			StartedAt:       run.Start,
+			FinishedAt:      run.End,
+			SuccessCount:    run.Ingestion.Success,
+			FailureCount:    run.Ingestion.Failure,
+			ErrorSummary:    nullableString(run.Ingestion.Error),
+		}
+		if err := tx.Create(&job).Error; err != nil {
+			return fmt.Errorf("insert ingestion job: %w", err)
+		}
+		if items := ingestionItemModels(job.ID, run.Ingestion.Items); len(items) > 0 {
+			if err := tx.CreateInBatches(items, 500).Error; err != nil {
+				return fmt.Errorf("insert ingestion job items: %w", err)
+			}
+		}
+		if run.AnalysisOn {
+			target := run.TargetEnd
+			if target.IsZero() {
+				target = run.Start
+			}
+			aj := AnalysisJobModel{
+				IngestionJobID:  &job.ID,
+				JobType:         run.Kind,
+				TargetDate:      target.UTC().Truncate(24 * time.Hour),
+				AnalysisVersion: run.AnalysisVersion,
+				Status:          run.Analysis.Status(),
+				StartedAt:       run.Start,
+				FinishedAt:      run.End,
+				TotalStocks:     run.Analysis.Total,
+				SuccessCount:    run.Analysis.Success,
+				FailureCount:    run.Analysis.Failure,
+				ErrorSummary:    nullableString(run.Analysis.Error),
+			}
+			if err := tx.Create(&aj).Error; err != nil {
+				return fmt.Errorf("insert analysis job: %w", err)
+			}
+			if items := analysisItemModels(aj.ID, run.Analysis.Items); len(items) > 0 {
+				if err := tx.CreateInBatches(items, 500).Error; err != nil {
+					return fmt.Errorf("insert analysis job items: %w", err)
+				}
+			}
+		}
+		run.ID = job.ID
+		return nil
+	})
+}
+
+// ListRuns 依開始時間由新到舊分頁查詢，不載入明細。
+func (s *JobStore) ListRuns(ctx context.Context, filter jobs.Filter) ([]jobs.Run, int, error) {
+	filter = filter.Normalize()
+	q := s.db.WithContext(ctx).Model(&IngestionJobModel{})
+	if filter.Kind != "" {
+		q = q.Where("job_type = ?", filter.Kind)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		q = q.Where("started_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("started_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var models []IngestionJobModel
+	if err := q.Order("started_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(models) == 0 {
+		return []jobs.Run{}, int(total), nil
+	}
+
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	var analysisModels []AnalysisJobModel
+	if err := s.db.WithContext(ctx).Where("ingestion_job_id IN ?", ids).Find(&analysisModels).Error; err != nil {
+		return nil, 0, err
+	}
+	byJob := make(map[string]AnalysisJobModel, len(analysisModels))
+	for _, a := range analysisModels {
+		if a.IngestionJobID != nil {
+			byJob[*a.IngestionJobID] = a
+		}
+	}
+
+	out := make([]jobs.Run, len(models))
+	for i, m := range models {
+		out[i] = toJobRun(m)
+		if a, ok := byJob[m.ID]; ok {
+			applyAnalysisJob(&out[i], a)
+		}
+	}
+	return out, int(total), nil
+}
+
+// GetRun 回傳含明細的執行紀錄。
+func (s *JobStore) GetRun(ctx context.Context, id string) (*jobs.Run, error) {
+	var m IngestionJobModel
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, jobs.ErrNotFound
+		}
+		return nil, err
+	}
+	run := toJobRun(m)
+
+	var items []IngestionJobItemModel
+	if err := s.db.WithContext(ctx).Where("ingestion_job_id = ?", id).Order("trade_date, symbol, timeframe").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		run.Ingestion.Items = append(run.Ingestion.Items, jobs.Item{
+			Symbol:      derefString(it.Symbol),
+			Timeframe:   it.Timeframe,
+			TradeDate:   derefTime(it.TradeDate),
+			Status:      it.Status,
+			ErrorReason: derefString(it.ErrorReason),
+		})
+	}
+
+	var aj AnalysisJobModel
+	err := s.db.WithContext(ctx).Where("ingestion_job_id = ?", id).First(&aj).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &run, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	applyAnalysisJob(&run, aj)
+	var aItems []AnalysisJobItemModel
+	if err := s.db.WithContext(ctx).Where("analysis_job_id = ?", aj.ID).Order("trade_date, symbol, timeframe").Find(&aItems).Error; err != nil {
+		return nil, err
+	}
+	for _, it := range aItems {
+		run.Analysis.Items = append(run.Analysis.Items, jobs.Item{
+			Symbol:      derefString(it.Symbol),
+			Timeframe:   it.Timeframe,
+			TradeDate:   derefTime(it.TradeDate),
+			Status:      it.Status,
+			ErrorReason: derefString(it.ErrorReason),
+			Duration:    time.Duration(it.DurationMs) * time.Millisecond,
+		})
+	}
+	return &run, nil
+}
+
+func toJobRun(m IngestionJobModel) jobs.Run {
+	var opts jobOptions
+	_ = json.Unmarshal(m.Options, &opts)
+	return jobs.Run{
+		ID:          m.ID,
+		Kind:        m.JobType,
+		TriggeredBy: opts.TriggeredBy,
+		DataSource:  opts.DataSource,
+		TargetStart: derefTime(m.TargetStartDate),
+		TargetEnd:   derefTime(m.TargetEndDate),
+		Start:       m.StartedAt,
+		End:         m.FinishedAt,
+		AnalysisOn:  opts.AnalysisOn,
+		Ingestion: jobs.Stage{
+			Error:   derefString(m.ErrorSummary),
+			Success: m.SuccessCount,
+			Failure: m.FailureCount,
+		},
+	}
+}
+
+func applyAnalysisJob(run *jobs.Run, a AnalysisJobModel) {
+	run.AnalysisOn = true
+	run.AnalysisVersion = a.AnalysisVersion
+	run.Analysis = jobs.Stage{
+		Error:   derefString(a.ErrorSummary),
+		Total:   a.TotalStocks,
+		Success: a.SuccessCount,
+		Failure: a.FailureCount,
+	}
+}
+
+func ingestionItemModels(jobID string, items []jobs.Item) []IngestionJobItemModel {
+	out := make([]IngestionJobItemModel, 0, len(items))
+	for _, it := range items {
+		out = append(out, IngestionJobItemModel{
+			IngestionJobID: jobID,
+			Symbol:         nullableString(it.Symbol),
+			Timeframe:      defaultTimeframe(it.Timeframe),
+			TradeDate:      optionalDate(it.TradeDate),
+			Status:         it.Status,
+			ErrorReason:    nullableString(it.ErrorReason),
+		})
+	}
+	return out
+}
+
+func analysisItemModels(jobID string, items []jobs.Item) []AnalysisJobItemModel {
+	out := make([]AnalysisJobItemModel, 0, len(items))
+	for _, it := range items {
+		out = append(out, AnalysisJobItemModel{
+			AnalysisJobID: jobID,
+			Symbol:        nullableString(it.Symbol),
+			Timeframe:     defaultTimeframe(it.Timeframe),
+			TradeDate:     optionalDate(it.TradeDate),
+			Status:        it.Status,
+			ErrorReason:   nullableString(it.ErrorReason),
+			DurationMs:    int(it.Duration.Milliseconds()),
+		})
+	}
+	return out
+}
+
+// optionalDate 取 UTC 日期；零值存為 NULL。
+func optionalDate(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	d := t.UTC().Truncate(24 * time.Hour)
+	return &d
+}
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func defaultTimeframe(tf string) string {
+	if tf == "" {
+		return "1d"
+	}
+	return tf
+}
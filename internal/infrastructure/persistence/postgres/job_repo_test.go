@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/jobs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestJobStore_SaveRun(t *testing.T) {
+	gormDB, mock, db := setupPresetMock(t)
+	defer db.Close()
+	store := NewJobStore(gormDB)
+
+	now := time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)
+	run := &jobs.Run{
+		Kind:            "auto",
+		TriggeredBy:     "system",
+		TargetStart:     now,
+		TargetEnd:       now,
+		Start:           now,
+		End:             now.Add(time.Minute),
+		AnalysisOn:      true,
+		AnalysisVersion: "v1-mvp",
+		Ingestion: jobs.Stage{Success: 6, Items: []jobs.Item{
+			{Symbol: "BTCUSDT", Timeframe: "1d", TradeDate: now, Status: jobs.StatusSuccess},
+		}},
+		Analysis: jobs.Stage{Total: 1, Success: 1, Items: []jobs.Item{
+			{Symbol: "BTCUSDT", Timeframe: "1d", TradeDate: now, Status: jobs.StatusSuccess, Duration: 15 * time.Millisecond},
+		}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "ingestion_jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+	mock.ExpectQuery(`INSERT INTO "ingestion_job_items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	mock.ExpectQuery(`INSERT INTO "analysis_jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("aj-1"))
+	mock.ExpectQuery(`INSERT INTO "analysis_job_items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("aitem-1"))
+	mock.ExpectCommit()
+
+	if err := store.SaveRun(context.Background(), run); err != nil {
+		t.Fatalf("SaveRun failed: %v", err)
+	}
+	if run.ID != "job-1" {
+		t.Errorf("expected run id job-1, got %q", run.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJobStore_GetRunNotFound(t *testing.T) {
+	gormDB, mock, db := setupPresetMock(t)
+	defer db.Close()
+	store := NewJobStore(gormDB)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "ingestion_jobs"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, err := store.GetRun(context.Background(), "missing"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -142,6 +142,7 @@ type StrategyTrade struct {
 type StrategyPosition struct {
 	ID         string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	StrategyID *string `gorm:"index"` // NULL for manual
+	UserID     *string `gorm:"type:uuid"`
 	Env        string
 	Symbol     string
 	EntryDate  time.Time
@@ -159,6 +160,54 @@ func (StrategyPosition) TableName() string {
 	return "strategy_positions"
 }
 
+// PaperBalance 映射到 paper_balances 表
+type PaperBalance struct {
+	AccountID string `gorm:"primaryKey"`
+	Asset     string `gorm:"primaryKey"`
+	Amount    float64
+	UpdatedAt time.Time
+}
+
+func (PaperBalance) TableName() string {
+	return "paper_balances"
+}
+
+// TradingHalt 映射到 trading_halts 表
+type TradingHalt struct {
+	Scope             string `gorm:"primaryKey"`
+	Halted            bool
+	Reason            string
+	ConsecutiveLosses int
+	ConsecutiveErrors int
+	Equity            float64
+	PeakEquity        float64
+	HaltedAt          *time.Time
+	UpdatedAt         time.Time
+}
+
+func (TradingHalt) TableName() string {
+	return "trading_halts"
+}
+
+// GridOrder 映射到 grid_orders 表
+type GridOrder struct {
+	ID         string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	StrategyID string `gorm:"index"`
+	Env        string
+	Level      int
+	Side       string
+	Price      float64
+	Qty        float64
+	OrderID    string
+	Status     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (GridOrder) TableName() string {
+	return "grid_orders"
+}
+
 // StrategyLog 映射到 strategy_logs 表
 type StrategyLog struct {
 	ID              string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
@@ -251,10 +300,18 @@ type AnalysisResultModel struct {
 	Volume           int64
 	VolumeRatio      *float64
 	Score            float64
+	Ma5              *float64
+	Ma10             *float64
 	Ma20             *float64
+	Ma60             *float64
+	VolumeAvg5d      *float64 `gorm:"column:volume_avg_5d"`
+	VolumeAvg20d     *float64 `gorm:"column:volume_avg_20d"`
 	PricePosition20d *float64
 	High20d          *float64
 	Low20d           *float64
+	Amplitude        *float64
+	AvgAmplitude20d  *float64 `gorm:"column:avg_amplitude_20d"`
+	Tags             json.RawMessage `gorm:"type:jsonb"`
 	Status           string
 	ErrorReason      *string
 	CreatedAt        time.Time
@@ -280,3 +337,154 @@ type StockModel struct {
 func (StockModel) TableName() string {
 	return "stocks"
 }
+
+// IngestionJobModel 映射到 ingestion_jobs 表，一次執行的主檔
+type IngestionJobModel struct {
+	ID              string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	JobType         string
+	TargetStartDate *time.Time
+	TargetEndDate   *time.Time
+	Options         json.RawMessage `gorm:"type:jsonb"`
+	Status          string
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	SuccessCount    int
+	FailureCount    int
+	ErrorSummary    *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (IngestionJobModel) TableName() string {
+	return "ingestion_jobs"
+}
+
+// IngestionJobItemModel 映射到 ingestion_job_items 表
+type IngestionJobItemModel struct {
+	ID             string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	IngestionJobID string
+	Symbol         *string
+	Timeframe      string
+	TradeDate      *time.Time
+	Status         string
+	ErrorReason    *string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (IngestionJobItemModel) TableName() string {
+	return "ingestion_job_items"
+}
+
+// AnalysisJobModel 映射到 analysis_jobs 表
+type AnalysisJobModel struct {
+	ID              string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	IngestionJobID  *string
+	JobType         string
+	TargetDate      time.Time
+	AnalysisVersion string
+	Status          string
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	TotalStocks     int
+	SuccessCount    int
+	FailureCount    int
+	ErrorSummary    *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (AnalysisJobModel) TableName() string {
+	return "analysis_jobs"
+}
+
+// AnalysisJobItemModel 映射到 analysis_job_items 表
+type AnalysisJobItemModel struct {
+	ID            string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	AnalysisJobID string
+	Symbol        *string
+	Timeframe     string
+	TradeDate     *time.Time
+	Status        string
+	ErrorReason   *string
+	DurationMs    int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (AnalysisJobItemModel) TableName() string {
+	return "analysis_job_items"
+}
+
+// BackfillJobModel 映射到 backfill_jobs 表，checkpoint 保存各交易對的缺口與進度
+type BackfillJobModel struct {
+	ID          string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Status      string
+	Phase       string
+	TriggeredBy *string
+	Symbols     json.RawMessage `gorm:"type:jsonb"`
+	Timeframes  json.RawMessage `gorm:"type:jsonb"`
+	StartTime   time.Time
+	EndTime     time.Time
+	RunAnalysis bool
+	Checkpoint  json.RawMessage `gorm:"type:jsonb"`
+	Error       *string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	UpdatedAt   time.Time
+}
+
+func (BackfillJobModel) TableName() string {
+	return "backfill_jobs"
+}
+
+// QuarantinedBarModel 映射到 quarantined_bars 表，保存未通過資料品質檢查的 K 線
+type QuarantinedBarModel struct {
+	ID         string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Symbol     string
+	Timeframe  string
+	MarketType string
+	BarTime    time.Time
+	OpenPrice  float64
+	HighPrice  float64
+	LowPrice   float64
+	ClosePrice float64
+	Volume     int64
+	Issues     json.RawMessage `gorm:"type:jsonb"`
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+	ResolvedBy *string
+}
+
+func (QuarantinedBarModel) TableName() string {
+	return "quarantined_bars"
+}
+
+// DataQualityStatusModel 映射到 data_quality_status 表，記錄各交易對最新 K 線的檢查結果
+type DataQualityStatusModel struct {
+	Symbol      string `gorm:"primaryKey"`
+	Timeframe   string `gorm:"primaryKey"`
+	LastBarTime time.Time
+	Passed      bool
+	Issues      json.RawMessage `gorm:"type:jsonb"`
+	CheckedAt   time.Time
+}
+
+func (DataQualityStatusModel) TableName() string {
+	return "data_quality_status"
+}
+
+// AnalysisVersionModel 映射到 analysis_versions 表，記錄註冊的分析版本與啟用中的版本
+type AnalysisVersionModel struct {
+	Name        string `gorm:"primaryKey"`
+	Description string
+	ScoreModel  string
+	IsActive    bool
+	CreatedAt   time.Time
+}
+
+func (AnalysisVersionModel) TableName() string {
+	return "analysis_versions"
+}
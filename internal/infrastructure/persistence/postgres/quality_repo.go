@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QualityStore 以 quarantined_bars 與 data_quality_status 保存資料品質檢查結果。
+type QualityStore struct {
+	db *gorm.DB
+}
+
+func NewQualityStore(db *gorm.DB) *QualityStore {
+	return &QualityStore{db: db}
+}
+
+// QuarantineBars 以 (symbol, timeframe, bar_time) upsert；已審核的 K 線不覆寫。
+func (s *QualityStore) QuarantineBars(ctx context.Context, bars []dataingestion.QuarantinedBar) error {
+	models := make([]QuarantinedBarModel, 0, len(bars))
+	for _, b := range bars {
+		issues, err := json.Marshal(b.Issues)
+		if err != nil {
+			return err
+		}
+		models = append(models, QuarantinedBarModel{
+			Symbol:     b.Price.Symbol,
+			Timeframe:  b.Price.Timeframe,
+			MarketType: string(b.Price.Market),
+			BarTime:    b.Price.TradeDate,
+			OpenPrice:  b.Price.Open,
+			HighPrice:  b.Price.High,
+			LowPrice:   b.Price.Low,
+			ClosePrice: b.Price.Close,
+			Volume:     b.Price.Volume,
+			Issues:     issues,
+			Status:     dataingestion.QuarantinePending,
+			CreatedAt:  b.CreatedAt,
+		})
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "timeframe"}, {Name: "bar_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"open_price", "high_price", "low_price", "close_price", "volume", "issues"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Table: "quarantined_bars", Name: "status"}, Value: dataingestion.QuarantinePending}}},
+	}).Create(&models).Error
+}
+
+// ListQuarantine 依建立時間由新到舊查詢，預設 100 筆。
+func (s *QualityStore) ListQuarantine(ctx context.Context, filter dataingestion.QuarantineFilter) ([]dataingestion.QuarantinedBar, error) {
+	q := s.db.WithContext(ctx).Order("created_at DESC")
+	if filter.Symbol != "" {
+		q = q.Where("symbol = ?", filter.Symbol)
+	}
+	if filter.Timeframe != "" {
+		q = q.Where("timeframe = ?", filter.Timeframe)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	var models []QuarantinedBarModel
+	if err := q.Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]dataingestion.QuarantinedBar, len(models))
+	for i, m := range models {
+		out[i] = fromQuarantinedBarModel(m)
+	}
+	return out, nil
+}
+
+func (s *QualityStore) GetQuarantine(ctx context.Context, id string) (*dataingestion.QuarantinedBar, error) {
+	var m QuarantinedBarModel
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dataingestion.ErrQuarantineNotFound
+		}
+		return nil, err
+	}
+	bar := fromQuarantinedBarModel(m)
+	return &bar, nil
+}
+
+// ResolveQuarantine 寫入審核結果。
+func (s *QualityStore) ResolveQuarantine(ctx context.Context, bar dataingestion.QuarantinedBar) error {
+	return s.db.WithContext(ctx).Model(&QuarantinedBarModel{}).Where("id = ?", bar.ID).Updates(map[string]interface{}{
+		"status":      bar.Status,
+		"resolved_at": optionalTime(bar.ResolvedAt),
+		"resolved_by": nullableString(bar.ResolvedBy),
+	}).Error
+}
+
+func (s *QualityStore) ResolvedBars(ctx context.Context, symbol, timeframe string, from, to time.Time) (map[time.Time]string, error) {
+	var rows []struct {
+		BarTime time.Time
+		Status  string
+	}
+	err := s.db.WithContext(ctx).Model(&QuarantinedBarModel{}).
+		Select("bar_time, status").
+		Where("symbol = ? AND timeframe = ? AND bar_time >= ? AND bar_time < ? AND status <> ?", symbol, timeframe, from, to, dataingestion.QuarantinePending).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[time.Time]string, len(rows))
+	for _, r := range rows {
+		out[r.BarTime.UTC()] = r.Status
+	}
+	return out, nil
+}
+
+func (s *QualityStore) SaveQualityStatus(ctx context.Context, st dataingestion.QualityStatus) error {
+	issues, err := json.Marshal(st.Issues)
+	if err != nil {
+		return err
+	}
+	m := DataQualityStatusModel{
+		Symbol:      st.Symbol,
+		Timeframe:   st.Timeframe,
+		LastBarTime: st.LastBar,
+		Passed:      st.Passed,
+		Issues:      issues,
+		CheckedAt:   st.CheckedAt,
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "timeframe"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_bar_time", "passed", "issues", "checked_at"}),
+	}).Create(&m).Error
+}
+
+func (s *QualityStore) GetQualityStatus(ctx context.Context, symbol, timeframe string) (*dataingestion.QualityStatus, error) {
+	var m DataQualityStatusModel
+	err := s.db.WithContext(ctx).Where("symbol = ? AND timeframe = ?", symbol, timeframe).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := fromQualityStatusModel(m)
+	return &st, nil
+}
+
+func (s *QualityStore) ListQualityStatus(ctx context.Context) ([]dataingestion.QualityStatus, error) {
+	var models []DataQualityStatusModel
+	if err := s.db.WithContext(ctx).Order("symbol, timeframe").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]dataingestion.QualityStatus, len(models))
+	for i, m := range models {
+		out[i] = fromQualityStatusModel(m)
+	}
+	return out, nil
+}
+
+func fromQuarantinedBarModel(m QuarantinedBarModel) dataingestion.QuarantinedBar {
+	bar := dataingestion.QuarantinedBar{
+		ID: m.ID,
+		Price: dataDomain.DailyPrice{
+			Symbol:    m.Symbol,
+			Market:    dataDomain.Market(m.MarketType),
+			Timeframe: m.Timeframe,
+			TradeDate: m.BarTime,
+			Open:      m.OpenPrice,
+			High:      m.HighPrice,
+			Low:       m.LowPrice,
+			Close:     m.ClosePrice,
+			Volume:    m.Volume,
+		},
+		Status:     m.Status,
+		CreatedAt:  m.CreatedAt,
+		ResolvedAt: derefTime(m.ResolvedAt),
+		ResolvedBy: derefString(m.ResolvedBy),
+	}
+	_ = json.Unmarshal(m.Issues, &bar.Issues)
+	return bar
+}
+
+func fromQualityStatusModel(m DataQualityStatusModel) dataingestion.QualityStatus {
+	st := dataingestion.QualityStatus{
+		Symbol:    m.Symbol,
+		Timeframe: m.Timeframe,
+		LastBar:   m.LastBarTime,
+		Passed:    m.Passed,
+		CheckedAt: m.CheckedAt,
+	}
+	_ = json.Unmarshal(m.Issues, &st.Issues)
+	return st
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQualityStore_QuarantineRoundTrip(t *testing.T) {
+	gormDB, mock, db := setupPresetMock(t)
+	defer db.Close()
+	store := NewQualityStore(gormDB)
+	ctx := context.Background()
+	bar := time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "quarantined_bars" .* ON CONFLICT \("symbol","timeframe","bar_time"\) DO UPDATE SET .* WHERE "quarantined_bars"."status" = \$\d+`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("q-1"))
+	mock.ExpectCommit()
+	err := store.QuarantineBars(ctx, []dataingestion.QuarantinedBar{{
+		Price:     dataDomain.DailyPrice{Symbol: "BTCUSDT", Market: dataDomain.MarketCrypto, Timeframe: "1h", TradeDate: bar, Open: 1, High: 1, Low: 1, Close: 1},
+		Issues:    []dataDomain.QualityIssue{{Check: dataDomain.CheckStale, Detail: "zero volume"}},
+		CreatedAt: bar,
+	}})
+	if err != nil {
+		t.Fatalf("QuarantineBars: %v", err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "symbol", "timeframe", "market_type", "bar_time", "close_price", "issues", "status", "created_at"}).
+		AddRow("q-1", "BTCUSDT", "1h", "CRYPTO", bar, 1.0, []byte(`[{"check":"stale","detail":"zero volume"}]`), "pending", bar)
+	mock.ExpectQuery(`SELECT \* FROM "quarantined_bars" WHERE id = \$1`).WithArgs("q-1", 1).WillReturnRows(rows)
+	got, err := store.GetQuarantine(ctx, "q-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Price.TradeDate.Equal(bar) || len(got.Issues) != 1 || got.Issues[0].Check != dataDomain.CheckStale || got.Status != dataingestion.QuarantinePending {
+		t.Errorf("unexpected bar %+v", got)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "quarantined_bars" WHERE id = \$1`).WithArgs("missing", 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := store.GetQuarantine(ctx, "missing"); !errors.Is(err, dataingestion.ErrQuarantineNotFound) {
+		t.Errorf("expected ErrQuarantineNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestQualityStore_StatusNotFoundIsNil(t *testing.T) {
+	gormDB, mock, db := setupPresetMock(t)
+	defer db.Close()
+	store := NewQualityStore(gormDB)
+
+	mock.ExpectQuery(`SELECT \* FROM "data_quality_status" WHERE symbol = \$1 AND timeframe = \$2`).
+		WithArgs("BTCUSDT", "1h", 1).WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+	st, err := store.GetQualityStatus(context.Background(), "BTCUSDT", "1h")
+	if err != nil || st != nil {
+		t.Fatalf("expected nil status, got %+v, %v", st, err)
+	}
+}
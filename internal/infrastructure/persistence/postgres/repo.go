@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -153,6 +154,23 @@ func (r *Repo) PricesByPair(ctx context.Context, pair string, timeframe string)
 	return out, nil
 }
 
+// TopSymbolsByVolume 依 since 之後日 K 的成交額（收盤價 × 成交量）加總排序，回傳前 n 個交易對。
+func (r *Repo) TopSymbolsByVolume(ctx context.Context, since time.Time, n int) ([]string, error) {
+	var pairs []string
+	err := r.db.WithContext(ctx).Table("daily_prices").
+		Select("stocks.trading_pair").
+		Joins("JOIN stocks ON daily_prices.stock_id = stocks.id").
+		Where("daily_prices.timeframe = ? AND daily_prices.trade_date >= ?", "1d", since).
+		Group("stocks.trading_pair").
+		Order("SUM(daily_prices.close_price * daily_prices.volume) DESC").
+		Limit(n).
+		Pluck("stocks.trading_pair", &pairs).Error
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
 // InsertAnalysisResult 寫入或更新分析結果。
 func (r *Repo) InsertAnalysisResult(ctx context.Context, stockID string, res analysisDomain.DailyAnalysisResult) error {
 	m := AnalysisResultModel{
@@ -169,17 +187,25 @@ func (r *Repo) InsertAnalysisResult(ctx context.Context, stockID string, res ana
 		Volume:           res.Volume,
 		VolumeRatio:      res.VolumeMultiple,
 		Score:            res.Score,
+		Ma5:              res.MA5,
+		Ma10:             res.MA10,
 		Ma20:             res.MA20,
+		Ma60:             res.MA60,
+		VolumeAvg5d:      res.AvgVolume5,
+		VolumeAvg20d:     res.AvgVolume20,
 		PricePosition20d: res.RangePos20,
 		High20d:          res.High20,
 		Low20d:           res.Low20,
+		Amplitude:        res.Amplitude,
+		AvgAmplitude20d:  res.AvgAmplitude20,
+		Tags:             tagsJSON(res.Tags),
 		Status:           statusValue(res.Success),
 		ErrorReason:      nullableString(res.ErrorReason),
 	}
 
 	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "stock_id"}, {Name: "timeframe"}, {Name: "trade_date"}, {Name: "analysis_version"}},
-		DoUpdates: clause.AssignmentColumns([]string{"close_price", "change", "change_percent", "return_5d", "return_20d", "return_60d", "volume", "volume_ratio", "score", "ma_20", "price_position_20d", "high_20d", "low_20d", "status", "error_reason", "updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"close_price", "change", "change_percent", "return_5d", "return_20d", "return_60d", "volume", "volume_ratio", "score", "ma_5", "ma_10", "ma_20", "ma_60", "volume_avg_5d", "volume_avg_20d", "price_position_20d", "high_20d", "low_20d", "amplitude", "avg_amplitude_20d", "tags", "status", "error_reason", "updated_at"}),
 	}).Create(&m).Error
 }
 
@@ -203,8 +229,61 @@ func (r *Repo) LatestAnalysisDate(ctx context.Context) (time.Time, error) {
 	return d, nil
 }
 
-// GetHistory 取單檔交易對歷史日 K（供 AnalyzeUseCase 使用）。
-func (r *Repo) GetHistory(ctx context.Context, symbol string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error) {
+// GetHistory 取單檔交易對指定週期的歷史 K 線（供 AnalyzeUseCase 使用）。
+// BarTimes 回傳交易對在 [from, to) 內已儲存的 K 線開盤時間，供回補偵測缺口。
+func (r *Repo) BarTimes(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]time.Time, error) {
+	var out []time.Time
+	err := r.db.WithContext(ctx).Table("daily_prices").
+		Joins("JOIN stocks ON daily_prices.stock_id = stocks.id").
+		Where("stocks.trading_pair = ? AND daily_prices.timeframe = ? AND daily_prices.trade_date >= ? AND daily_prices.trade_date < ?", symbol, timeframe, from, to).
+		Order("daily_prices.trade_date").
+		Pluck("daily_prices.trade_date", &out).Error
+	return out, err
+}
+
+// PriceRange 依時間遞增取交易對在 [from, to) 內的 K 線（供匯出分頁）。
+func (r *Repo) PriceRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]dataDomain.DailyPrice, error) {
+	type result struct {
+		TradingPair string
+		MarketType  string
+		Timeframe   string
+		TradeDate   time.Time
+		OpenPrice   float64
+		HighPrice   float64
+		LowPrice    float64
+		ClosePrice  float64
+		Volume      int64
+	}
+	var rawResults []result
+	err := r.db.WithContext(ctx).Table("daily_prices").
+		Select("stocks.trading_pair, stocks.market_type, daily_prices.timeframe, daily_prices.trade_date, daily_prices.open_price, daily_prices.high_price, daily_prices.low_price, daily_prices.close_price, daily_prices.volume").
+		Joins("JOIN stocks ON daily_prices.stock_id = stocks.id").
+		Where("stocks.trading_pair = ? AND daily_prices.timeframe = ? AND daily_prices.trade_date >= ? AND daily_prices.trade_date < ?", symbol, timeframe, from, to).
+		Order("daily_prices.trade_date").
+		Limit(limit).
+		Scan(&rawResults).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]dataDomain.DailyPrice, len(rawResults))
+	for i, r := range rawResults {
+		out[i] = dataDomain.DailyPrice{
+			Symbol:    r.TradingPair,
+			Market:    dataDomain.Market(r.MarketType),
+			Timeframe: r.Timeframe,
+			TradeDate: r.TradeDate,
+			Open:      r.OpenPrice,
+			High:      r.HighPrice,
+			Low:       r.LowPrice,
+			Close:     r.ClosePrice,
+			Volume:    r.Volume,
+		}
+	}
+	return out, nil
+}
+
+func (r *Repo) GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error) {
 	type result struct {
 		TradingPair string
 		MarketType  string
@@ -220,7 +299,7 @@ func (r *Repo) GetHistory(ctx context.Context, symbol string, endDate time.Time,
 	err := r.db.WithContext(ctx).Table("daily_prices").
 		Select("stocks.trading_pair, stocks.market_type, daily_prices.timeframe, daily_prices.trade_date, daily_prices.open_price, daily_prices.high_price, daily_prices.low_price, daily_prices.close_price, daily_prices.volume").
 		Joins("JOIN stocks ON daily_prices.stock_id = stocks.id").
-		Where("stocks.trading_pair = ? AND daily_prices.trade_date <= ?", symbol, endDate).
+		Where("stocks.trading_pair = ? AND daily_prices.timeframe = ? AND daily_prices.trade_date <= ?", symbol, timeframe, endDate).
 		Order("daily_prices.trade_date DESC").
 		Limit(lookback).
 		Scan(&rawResults).Error
@@ -301,19 +380,28 @@ func (r *Repo) FindByDate(ctx context.Context, date time.Time, filter analysis.Q
 		Volume      int64
 		VolumeRatio *float64
 		Score       float64
+		Ma5         *float64
+		Ma10        *float64
 		Ma20        *float64
+		Ma60        *float64
+		VolumeAvg5d  *float64 `gorm:"column:volume_avg_5d"`
+		VolumeAvg20d *float64 `gorm:"column:volume_avg_20d"`
+		Tags        *string
 		PricePosition20d *float64
 		High20d     *float64
 		Low20d      *float64
+		Amplitude   *float64
+		AvgAmplitude20d *float64 `gorm:"column:avg_amplitude_20d"`
 		Status      string
 		ErrorReason *string
 	}
 
 	var rawResults []result
 	query := r.db.WithContext(ctx).Table("analysis_results ar").
-		Select("s.trading_pair, s.market_type, s.industry, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_20, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.status, ar.error_reason").
+		Select("s.trading_pair, s.market_type, s.industry, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_5, ar.ma_10, ar.ma_20, ar.ma_60, ar.volume_avg_5d, ar.volume_avg_20d, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.amplitude, ar.avg_amplitude_20d, ar.tags, ar.status, ar.error_reason").
 		Joins("JOIN stocks s ON ar.stock_id = s.id").
-		Where("ar.trade_date = ?", date)
+		Where("ar.trade_date = ?", date).
+		Where(activeVersionClause, analysis.DefaultVersion)
 
 	if filter.OnlySuccess {
 		query = query.Where("ar.status = ?", "success")
@@ -351,10 +439,18 @@ func (r *Repo) FindByDate(ctx context.Context, date time.Time, filter analysis.Q
 			Volume:         r.Volume,
 			VolumeMultiple: r.VolumeRatio,
 			Score:          r.Score,
+			MA5:            r.Ma5,
+			MA10:           r.Ma10,
 			MA20:           r.Ma20,
+			MA60:           r.Ma60,
+			AvgVolume5:     r.VolumeAvg5d,
+			AvgVolume20:    r.VolumeAvg20d,
+			Tags:           parseTags(r.Tags),
 			RangePos20:     r.PricePosition20d,
 			High20:         r.High20d,
 			Low20:          r.Low20d,
+			Amplitude:      r.Amplitude,
+			AvgAmplitude20: r.AvgAmplitude20d,
 			Success:        r.Status == "success",
 		}
 		if r.ErrorReason != nil {
@@ -370,35 +466,17 @@ func (r *Repo) FindByDate(ctx context.Context, date time.Time, filter analysis.Q
 	return results, int(total), nil
 }
 
-// FindHistory 供 QueryUseCase 使用，MVP 版。
+// FindHistory 供 QueryUseCase 使用，讀取啟用中的分析版本。
 func (r *Repo) FindHistory(ctx context.Context, symbol string, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
-	type result struct {
-		TradingPair string
-		MarketType  string
-		Timeframe   string
-		TradeDate   time.Time
-		Version     string `gorm:"column:analysis_version"`
-		ClosePrice  float64
-		Change      float64
-		ChangePercent float64
-		Return5d    *float64
-		Return20d   *float64
-		Return60d   *float64
-		Volume      int64
-		VolumeRatio *float64
-		Score       float64
-		Ma20        *float64
-		PricePosition20d *float64
-		High20d     *float64
-		Low20d      *float64
-		Status      string
-		ErrorReason *string
-	}
+	return r.findHistory(r.analysisQuery(ctx, symbol), timeframe, from, to, limit, onlySuccess)
+}
 
-	query := r.db.WithContext(ctx).Table("analysis_results ar").
-		Select("s.trading_pair, s.market_type, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_20, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.status, ar.error_reason").
-		Joins("JOIN stocks s ON ar.stock_id = s.id").
-		Where("s.trading_pair = ?", symbol)
+// FindVersionHistory 同 FindHistory，讀取指定的分析版本（例如策略釘選的版本或版本比較）。
+func (r *Repo) FindVersionHistory(ctx context.Context, version, symbol, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	return r.findHistory(r.versionQuery(ctx, symbol).Where("ar.analysis_version = ?", version), timeframe, from, to, limit, onlySuccess)
+}
+
+func (r *Repo) findHistory(query *gorm.DB, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
 
 	if timeframe != "" {
 		query = query.Where("ar.timeframe = ?", timeframe)
@@ -418,7 +496,69 @@ func (r *Repo) FindHistory(ctx context.Context, symbol string, timeframe string,
 		query = query.Limit(limit)
 	}
 
-	var rawResults []result
+	return scanAnalysis(query)
+}
+
+// AnalysisRange 依時間遞增取交易對在 [from, to) 內的分析結果（供匯出分頁）。
+func (r *Repo) AnalysisRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]analysisDomain.DailyAnalysisResult, error) {
+	query := r.analysisQuery(ctx, symbol).
+		Where("ar.timeframe = ? AND ar.trade_date >= ? AND ar.trade_date < ?", timeframe, from, to).
+		Order("ar.trade_date").
+		Limit(limit)
+	return scanAnalysis(query)
+}
+
+// activeVersionClause 限定為啟用中的分析版本；註冊表沒有啟用版本時視為 DefaultVersion。
+const activeVersionClause = "ar.analysis_version = COALESCE((SELECT name FROM analysis_versions WHERE is_active), ?)"
+
+// analysisQuery 建立單一交易對在啟用中版本的分析結果查詢。
+func (r *Repo) analysisQuery(ctx context.Context, symbol string) *gorm.DB {
+	return r.versionQuery(ctx, symbol).Where(activeVersionClause, analysis.DefaultVersion)
+}
+
+// versionQuery 建立單一交易對分析結果的查詢，不限版本。
+func (r *Repo) versionQuery(ctx context.Context, symbol string) *gorm.DB {
+	return r.db.WithContext(ctx).Table("analysis_results ar").
+		Select("s.trading_pair, s.market_type, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_5, ar.ma_10, ar.ma_20, ar.ma_60, ar.volume_avg_5d, ar.volume_avg_20d, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.amplitude, ar.avg_amplitude_20d, ar.tags, ar.status, ar.error_reason").
+		Joins("JOIN stocks s ON ar.stock_id = s.id").
+		Where("s.trading_pair = ?", symbol)
+}
+
+// analysisRow 為 analysis_results 查詢結果列。
+type analysisRow struct {
+	TradingPair      string
+	MarketType       string
+	Timeframe        string
+	TradeDate        time.Time
+	Version          string    `gorm:"column:analysis_version"`
+	ClosePrice       float64
+	Change           float64
+	ChangePercent    float64
+	Return5d         *float64
+	Return20d        *float64
+	Return60d        *float64
+	Volume           int64
+	VolumeRatio      *float64
+	Score            float64
+	Ma5              *float64
+	Ma10             *float64
+	Ma20             *float64
+	Ma60             *float64
+	VolumeAvg5d      *float64  `gorm:"column:volume_avg_5d"`
+	VolumeAvg20d     *float64  `gorm:"column:volume_avg_20d"`
+	Tags             *string
+	PricePosition20d *float64
+	High20d          *float64
+	Low20d           *float64
+	Amplitude        *float64
+	AvgAmplitude20d  *float64 `gorm:"column:avg_amplitude_20d"`
+	Status           string
+	ErrorReason      *string
+}
+
+// scanAnalysis 執行查詢並轉為領域物件。
+func scanAnalysis(query *gorm.DB) ([]analysisDomain.DailyAnalysisResult, error) {
+	var rawResults []analysisRow
 	if err := query.Scan(&rawResults).Error; err != nil {
 		return nil, err
 	}
@@ -440,10 +580,18 @@ func (r *Repo) FindHistory(ctx context.Context, symbol string, timeframe string,
 			Volume:         r.Volume,
 			VolumeMultiple: r.VolumeRatio,
 			Score:          r.Score,
+			MA5:            r.Ma5,
+			MA10:           r.Ma10,
 			MA20:           r.Ma20,
+			MA60:           r.Ma60,
+			AvgVolume5:     r.VolumeAvg5d,
+			AvgVolume20:    r.VolumeAvg20d,
+			Tags:           parseTags(r.Tags),
 			RangePos20:     r.PricePosition20d,
 			High20:         r.High20d,
 			Low20:          r.Low20d,
+			Amplitude:      r.Amplitude,
+			AvgAmplitude20: r.AvgAmplitude20d,
 			Success:        r.Status == "success",
 		}
 		if r.ErrorReason != nil {
@@ -476,19 +624,28 @@ func (r *Repo) Get(ctx context.Context, symbol string, date time.Time, timeframe
 		Volume      int64
 		VolumeRatio *float64
 		Score       float64
+		Ma5         *float64
+		Ma10        *float64
 		Ma20        *float64
+		Ma60        *float64
+		VolumeAvg5d  *float64 `gorm:"column:volume_avg_5d"`
+		VolumeAvg20d *float64 `gorm:"column:volume_avg_20d"`
+		Tags        *string
 		PricePosition20d *float64
 		High20d     *float64
 		Low20d      *float64
+		Amplitude   *float64
+		AvgAmplitude20d *float64 `gorm:"column:avg_amplitude_20d"`
 		Status      string
 		ErrorReason *string
 	}
 
 	var rres result
 	err := r.db.WithContext(ctx).Table("analysis_results ar").
-		Select("s.trading_pair, s.market_type, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_20, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.status, ar.error_reason").
+		Select("s.trading_pair, s.market_type, ar.timeframe, ar.trade_date, ar.analysis_version, ar.close_price, ar.change, ar.change_percent, ar.return_5d, ar.return_20d, ar.return_60d, ar.volume, ar.volume_ratio, ar.score, ar.ma_5, ar.ma_10, ar.ma_20, ar.ma_60, ar.volume_avg_5d, ar.volume_avg_20d, ar.price_position_20d, ar.high_20d, ar.low_20d, ar.amplitude, ar.avg_amplitude_20d, ar.tags, ar.status, ar.error_reason").
 		Joins("JOIN stocks s ON ar.stock_id = s.id").
 		Where("s.trading_pair = ? AND ar.trade_date = ? AND ar.timeframe = ?", symbol, date, timeframe).
+		Where(activeVersionClause, analysis.DefaultVersion).
 		First(&rres).Error
 
 	if err != nil {
@@ -513,10 +670,18 @@ func (r *Repo) Get(ctx context.Context, symbol string, date time.Time, timeframe
 		Volume:         rres.Volume,
 		VolumeMultiple: rres.VolumeRatio,
 		Score:          rres.Score,
+		MA5:            rres.Ma5,
+		MA10:           rres.Ma10,
 		MA20:           rres.Ma20,
+		MA60:           rres.Ma60,
+		AvgVolume5:     rres.VolumeAvg5d,
+		AvgVolume20:    rres.VolumeAvg20d,
+		Tags:           parseTags(rres.Tags),
 		RangePos20:     rres.PricePosition20d,
 		High20:         rres.High20d,
 		Low20:          rres.Low20d,
+		Amplitude:      rres.Amplitude,
+		AvgAmplitude20: rres.AvgAmplitude20d,
 		Success:        rres.Status == "success",
 	}
 	if rres.ErrorReason != nil {
@@ -530,6 +695,24 @@ func (r *Repo) Get(ctx context.Context, symbol string, date time.Time, timeframe
 	return res, nil
 }
 
+// tagsJSON 將標籤編碼為 JSONB，無標籤時寫入 NULL。
+func tagsJSON(tags []analysisDomain.Tag) json.RawMessage {
+	if len(tags) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(tags)
+	return b
+}
+
+func parseTags(raw *string) []analysisDomain.Tag {
+	if raw == nil || *raw == "" {
+		return nil
+	}
+	var tags []analysisDomain.Tag
+	_ = json.Unmarshal([]byte(*raw), &tags)
+	return tags
+}
+
 func statusValue(success bool) string {
 	if success {
 		return "success"
@@ -43,9 +43,12 @@ func TestRepo_InsertAnalysisResult(t *testing.T) {
 		Success:   true,
 		Score:     75.5,
 	}
+	amp := 0.03
+	res.Amplitude = &amp
 
+	// 振幅與 20 根平均振幅是評分模型與策略的特徵，必須一併寫入
 	mock.ExpectBegin()
-	mock.ExpectQuery("INSERT INTO (.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ar-1"))
+	mock.ExpectQuery(`INSERT INTO "analysis_results" .*"amplitude","avg_amplitude_20d".* ON CONFLICT .*"amplitude"="excluded"."amplitude","avg_amplitude_20d"="excluded"."avg_amplitude_20d"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ar-1"))
 	mock.ExpectCommit()
 
 	err := repo.InsertAnalysisResult(ctx, "stock-123", res)
@@ -191,8 +194,8 @@ func TestRepo_FindHistory(t *testing.T) {
 	repo := NewRepo(gormDB)
 	ctx := context.Background()
 
-	rows := sqlmock.NewRows([]string{"trading_pair", "market_type", "timeframe", "trade_date", "analysis_version", "close_price", "change", "change_percent", "return_5d", "return_20d", "return_60d", "volume", "volume_ratio", "score", "ma_20", "price_position_20d", "high_20d", "low_20d", "status", "error_reason"}).
-		AddRow("BTCUSDT", "crypto", "1d", time.Now(), "v1", 50000.0, 1000.0, 0.02, 0.05, 0.1, 0.2, 1000, 1.5, 80.0, 48000.0, 0.8, 51000.0, 45000.0, "success", nil)
+	rows := sqlmock.NewRows([]string{"trading_pair", "market_type", "timeframe", "trade_date", "analysis_version", "close_price", "change", "change_percent", "return_5d", "return_20d", "return_60d", "volume", "volume_ratio", "score", "ma_20", "price_position_20d", "high_20d", "low_20d", "amplitude", "avg_amplitude_20d", "status", "error_reason"}).
+		AddRow("BTCUSDT", "crypto", "1d", time.Now(), "v1", 50000.0, 1000.0, 0.02, 0.05, 0.1, 0.2, 1000, 1.5, 80.0, 48000.0, 0.8, 51000.0, 45000.0, 0.03, 0.02, "success", nil)
 
 	mock.ExpectQuery("SELECT (.+) FROM (.+)").WillReturnRows(rows)
 
@@ -201,7 +204,10 @@ func TestRepo_FindHistory(t *testing.T) {
 		t.Fatalf("FindHistory failed: %v", err)
 	}
 	if len(results) != 1 {
-		t.Errorf("expected 1 result, got %d", len(results))
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if r := results[0]; r.Amplitude == nil || *r.Amplitude != 0.03 || r.AvgAmplitude20 == nil || *r.AvgAmplitude20 != 0.02 {
+		t.Errorf("expected stored amplitudes, got %v %v", r.Amplitude, r.AvgAmplitude20)
 	}
 }
 
@@ -243,3 +249,65 @@ func TestRepo_LatestAnalysisDate(t *testing.T) {
 		t.Error("expected valid date")
 	}
 }
+
+func TestRepo_BarTimes(t *testing.T) {
+	gormDB, mock, db := setupRepoMock(t)
+	defer db.Close()
+
+	repo := NewRepo(gormDB)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"trade_date"}).AddRow(from).AddRow(from.Add(time.Hour))
+	mock.ExpectQuery(`SELECT "daily_prices"."trade_date" FROM "daily_prices" JOIN stocks`).
+		WithArgs("BTCUSDT", "1h", from, from.Add(24*time.Hour)).
+		WillReturnRows(rows)
+
+	times, err := repo.BarTimes(context.Background(), "BTCUSDT", "1h", from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 2 || !times[1].Equal(from.Add(time.Hour)) {
+		t.Errorf("unexpected bar times %v", times)
+	}
+}
+
+func TestRepo_PriceRange(t *testing.T) {
+	gormDB, mock, db := setupRepoMock(t)
+	defer db.Close()
+
+	repo := NewRepo(gormDB)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"trading_pair", "market_type", "timeframe", "trade_date", "open_price", "high_price", "low_price", "close_price", "volume"}).
+		AddRow("BTCUSDT", "CRYPTO", "1h", from, 1.0, 2.0, 0.5, 1.5, 10)
+	mock.ExpectQuery(`SELECT .* FROM "daily_prices" JOIN stocks .* daily_prices.trade_date >= \$3 AND daily_prices.trade_date < \$4 ORDER BY daily_prices.trade_date LIMIT \$5`).
+		WithArgs("BTCUSDT", "1h", from, from.Add(24*time.Hour), 500).
+		WillReturnRows(rows)
+
+	prices, err := repo.PriceRange(context.Background(), "BTCUSDT", "1h", from, from.Add(24*time.Hour), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Close != 1.5 || prices[0].Timeframe != "1h" {
+		t.Errorf("unexpected prices %+v", prices)
+	}
+}
+
+func TestRepo_AnalysisRange(t *testing.T) {
+	gormDB, mock, db := setupRepoMock(t)
+	defer db.Close()
+
+	repo := NewRepo(gormDB)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"trading_pair", "timeframe", "trade_date", "close_price", "status"}).
+		AddRow("BTCUSDT", "1d", from, 100.0, "success")
+	mock.ExpectQuery(`SELECT .* FROM analysis_results ar JOIN stocks s .* ar.analysis_version = COALESCE\(\(SELECT name FROM analysis_versions WHERE is_active\), \$2\).* ar.trade_date >= \$4 AND ar.trade_date < \$5\) ORDER BY ar.trade_date LIMIT \$6`).
+		WithArgs("BTCUSDT", "v1-mvp", "1d", from, from.AddDate(0, 1, 0), 100).
+		WillReturnRows(rows)
+
+	results, err := repo.AnalysisRange(context.Background(), "BTCUSDT", "1d", from, from.AddDate(0, 1, 0), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Close != 100 || !results[0].Success {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"ai-auto-trade/internal/application/trading"
@@ -312,8 +313,12 @@ func (r *TradingRepo) ListTrades(ctx context.Context, filter tradingDomain.Trade
 		query = query.Where("entry_date <= ?", *filter.EndDate)
 	}
 
+	query = query.Order("entry_date DESC")
+	if !filter.All {
+		query = query.Limit(200)
+	}
 	var models []StrategyTrade
-	err := query.Order("entry_date DESC").Limit(200).Find(&models).Error
+	err := query.Find(&models).Error
 	if err != nil {
 		return nil, err
 	}
@@ -346,6 +351,31 @@ func (r *TradingRepo) ListTrades(ctx context.Context, filter tradingDomain.Trade
 	return out, nil
 }
 
+// SumRealizedPnL 以 exit_date 篩選並在資料庫加總已實現損益，不受 ListTrades 的筆數上限影響。
+func (r *TradingRepo) SumRealizedPnL(ctx context.Context, env tradingDomain.Environment, since time.Time) (map[string]float64, error) {
+	var rows []struct {
+		StrategyID *string
+		Total      float64
+	}
+	err := r.db.WithContext(ctx).Model(&StrategyTrade{}).
+		Select("strategy_id, SUM(pnl_usdt) AS total").
+		Where("env = ? AND exit_date >= ? AND pnl_usdt IS NOT NULL", string(env), since).
+		Group("strategy_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		id := "manual"
+		if row.StrategyID != nil {
+			id = *row.StrategyID
+		}
+		out[id] += row.Total
+	}
+	return out, nil
+}
+
 // GetOpenPosition 取得當前持倉。
 func (r *TradingRepo) GetOpenPosition(ctx context.Context, strategyID string, env tradingDomain.Environment) (*tradingDomain.Position, error) {
 	query := r.db.WithContext(ctx).Model(&StrategyPosition{}).Where("env = ? AND status = 'open'", string(env))
@@ -382,6 +412,9 @@ func (r *TradingRepo) GetOpenPosition(ctx context.Context, strategyID string, en
 	} else {
 		p.StrategyID = "manual"
 	}
+	if m.UserID != nil {
+		p.UserID = *m.UserID
+	}
 	return p, nil
 }
 
@@ -412,6 +445,9 @@ func (r *TradingRepo) ListOpenPositions(ctx context.Context) ([]tradingDomain.Po
 		} else {
 			p.StrategyID = "manual"
 		}
+		if m.UserID != nil {
+			p.UserID = *m.UserID
+		}
 		out[i] = p
 	}
 	return out, nil
@@ -441,6 +477,9 @@ func (r *TradingRepo) GetPosition(ctx context.Context, id string) (*tradingDomai
 	} else {
 		p.StrategyID = "manual"
 	}
+	if m.UserID != nil {
+		p.UserID = *m.UserID
+	}
 	return p, nil
 }
 
@@ -451,10 +490,15 @@ func (r *TradingRepo) UpsertPosition(ctx context.Context, p tradingDomain.Positi
 		s := p.StrategyID
 		sid = &s
 	}
+	var uid *string
+	if p.UserID != "" {
+		uid = &p.UserID
+	}
 
 	m := StrategyPosition{
 		ID:         p.ID,
 		StrategyID: sid,
+		UserID:     uid,
 		Env:        string(p.Env),
 		Symbol:     p.Symbol,
 		EntryDate:  p.EntryDate,
@@ -481,6 +525,176 @@ func (r *TradingRepo) ClosePosition(ctx context.Context, id string, exitDate tim
 	}).Error
 }
 
+// LoadPaperBalances 讀取模擬帳戶所有資產餘額。
+func (r *TradingRepo) LoadPaperBalances(ctx context.Context, accountID string) (map[string]float64, error) {
+	var rows []PaperBalance
+	if err := r.db.WithContext(ctx).Where("account_id = ?", accountID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		out[row.Asset] = row.Amount
+	}
+	return out, nil
+}
+
+// OpenPaperAccount 寫入模擬帳戶初始餘額；已存在的資產不變，多個實例同時開戶只會生效一次。
+func (r *TradingRepo) OpenPaperAccount(ctx context.Context, accountID string, initial map[string]float64) error {
+	if len(initial) == 0 {
+		return nil
+	}
+	rows := paperBalanceRows(accountID, initial)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// ApplyPaperDeltas 在同一筆交易中鎖定帳戶既有餘額並加減 deltas；任一資產會變為負數時整筆回滾。
+// 增加以 amount = amount + delta 寫入，尚無資料列的資產由並行的開戶或入帳累加而不互相覆蓋。
+func (r *TradingRepo) ApplyPaperDeltas(ctx context.Context, accountID string, deltas map[string]float64) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	assets := make([]string, 0, len(deltas))
+	for asset := range deltas {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked []PaperBalance
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("account_id = ? AND asset IN ?", accountID, assets).
+			Order("asset").Find(&locked).Error; err != nil {
+			return err
+		}
+		current := make(map[string]float64, len(locked))
+		for _, row := range locked {
+			current[row.Asset] = row.Amount
+		}
+		for _, asset := range assets {
+			if current[asset]+deltas[asset] < -1e-9 {
+				return fmt.Errorf("%w: need %.8f %s, available %.8f", trading.ErrInsufficientFunds, -deltas[asset], asset, current[asset])
+			}
+		}
+		rows := paperBalanceRows(accountID, deltas)
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "account_id"}, {Name: "asset"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "amount"}, Value: gorm.Expr("GREATEST(paper_balances.amount + excluded.amount, 0)")},
+				{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+			},
+		}).Create(&rows).Error
+	})
+}
+
+// paperBalanceRows 依資產排序產生資料列，讓並行交易以相同順序上鎖。
+func paperBalanceRows(accountID string, amounts map[string]float64) []PaperBalance {
+	now := time.Now()
+	rows := make([]PaperBalance, 0, len(amounts))
+	for asset, amount := range amounts {
+		rows = append(rows, PaperBalance{AccountID: accountID, Asset: asset, Amount: amount, UpdatedAt: now})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Asset < rows[j].Asset })
+	return rows
+}
+
+// LoadHaltStates 讀取所有 kill switch 與熔斷狀態。
+func (r *TradingRepo) LoadHaltStates(ctx context.Context) ([]trading.HaltState, error) {
+	var rows []TradingHalt
+	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]trading.HaltState, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, trading.HaltState{
+			Scope:             row.Scope,
+			Halted:            row.Halted,
+			Reason:            row.Reason,
+			ConsecutiveLosses: row.ConsecutiveLosses,
+			ConsecutiveErrors: row.ConsecutiveErrors,
+			Equity:            row.Equity,
+			PeakEquity:        row.PeakEquity,
+			HaltedAt:          row.HaltedAt,
+			UpdatedAt:         row.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+// SaveHaltState 新增或更新單一 kill switch / 熔斷狀態。
+func (r *TradingRepo) SaveHaltState(ctx context.Context, st trading.HaltState) error {
+	m := TradingHalt{
+		Scope:             st.Scope,
+		Halted:            st.Halted,
+		Reason:            st.Reason,
+		ConsecutiveLosses: st.ConsecutiveLosses,
+		ConsecutiveErrors: st.ConsecutiveErrors,
+		Equity:            st.Equity,
+		PeakEquity:        st.PeakEquity,
+		HaltedAt:          st.HaltedAt,
+		UpdatedAt:         st.UpdatedAt,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}},
+		DoUpdates: clause.AssignmentColumns([]string{"halted", "reason", "consecutive_losses", "consecutive_errors", "equity", "peak_equity", "halted_at", "updated_at"}),
+	}).Create(&m).Error
+}
+
+// LoadOpenGridOrders 讀取策略在指定環境仍掛著與待送出的網格單。
+func (r *TradingRepo) LoadOpenGridOrders(ctx context.Context, strategyID string, env tradingDomain.Environment) ([]trading.GridOrder, error) {
+	var rows []GridOrder
+	err := r.db.WithContext(ctx).
+		Where("strategy_id = ? AND env = ? AND status IN ?", strategyID, string(env), []string{trading.OrderStatusNew, trading.GridOrderPending}).
+		Order("level ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]trading.GridOrder, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, trading.GridOrder{
+			ID:         row.ID,
+			StrategyID: row.StrategyID,
+			Env:        tradingDomain.Environment(row.Env),
+			Level:      row.Level,
+			Side:       row.Side,
+			Price:      row.Price,
+			Qty:        row.Qty,
+			OrderID:    row.OrderID,
+			Status:     row.Status,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+// SaveGridOrder 新增網格單，帶 ID 時更新訂單編號與狀態（待送出的網格單送出後補上編號）。
+func (r *TradingRepo) SaveGridOrder(ctx context.Context, o trading.GridOrder) (string, error) {
+	if o.ID != "" {
+		err := r.db.WithContext(ctx).Model(&GridOrder{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+			"order_id":   o.OrderID,
+			"status":     o.Status,
+			"updated_at": o.UpdatedAt,
+		}).Error
+		return o.ID, err
+	}
+	m := GridOrder{
+		StrategyID: o.StrategyID,
+		Env:        string(o.Env),
+		Level:      o.Level,
+		Side:       o.Side,
+		Price:      o.Price,
+		Qty:        o.Qty,
+		OrderID:    o.OrderID,
+		Status:     o.Status,
+		CreatedAt:  o.CreatedAt,
+		UpdatedAt:  o.UpdatedAt,
+	}
+	if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
+		return "", err
+	}
+	return m.ID, nil
+}
+
 // SaveLog 寫入日誌。
 func (r *TradingRepo) SaveLog(ctx context.Context, log tradingDomain.LogEntry) error {
 	payload, _ := json.Marshal(log.Payload)
@@ -3,7 +3,9 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"ai-auto-trade/internal/application/trading"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
@@ -118,11 +120,11 @@ func TestGetOpenPosition(t *testing.T) {
 	defer db.Close()
 	repo := NewTradingRepo(gormDB)
 
-	mock.ExpectQuery("SELECT (.+) FROM \"strategy_positions\" WHERE (.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("p1"))
+	mock.ExpectQuery("SELECT (.+) FROM \"strategy_positions\" WHERE (.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("p1", "u1"))
 
 	p, err := repo.GetOpenPosition(context.Background(), "s1", "paper")
-	if err != nil || p.ID != "p1" {
-		t.Fatalf("failed: %v", err)
+	if err != nil || p.ID != "p1" || p.UserID != "u1" {
+		t.Fatalf("failed: %+v %v", p, err)
 	}
 }
 
@@ -132,10 +134,12 @@ func TestUpsertPosition(t *testing.T) {
 	repo := NewTradingRepo(gormDB)
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("INSERT INTO \"strategy_positions\"").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("p1"))
+	mock.ExpectQuery("INSERT INTO \"strategy_positions\" \\(\"strategy_id\",\"user_id\"").
+		WithArgs(nil, "u1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("p1"))
 	mock.ExpectCommit()
 
-	err := repo.UpsertPosition(context.Background(), tradingDomain.Position{ID: "p1"})
+	err := repo.UpsertPosition(context.Background(), tradingDomain.Position{ID: "p1", StrategyID: "manual", UserID: "u1"})
 	if err != nil {
 		t.Fatalf("failed: %v", err)
 	}
@@ -146,12 +150,45 @@ func TestListTrades(t *testing.T) {
 	defer db.Close()
 	repo := NewTradingRepo(gormDB)
 
-	mock.ExpectQuery("SELECT (.+) FROM \"strategy_trades\"").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("t1"))
+	mock.ExpectQuery("SELECT (.+) FROM \"strategy_trades\" ORDER BY entry_date DESC LIMIT \\$1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("t1"))
 
 	res, err := repo.ListTrades(context.Background(), tradingDomain.TradeFilter{})
 	if err != nil || len(res) != 1 {
 		t.Fatalf("failed: %v", err)
 	}
+
+	// 報表與比對讀取完整區間，不套用筆數上限
+	mock.ExpectQuery("SELECT (.+) FROM \"strategy_trades\" WHERE env = \\$1 ORDER BY entry_date DESC$").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("t1").AddRow("t2"))
+	res, err = repo.ListTrades(context.Background(), tradingDomain.TradeFilter{Env: tradingDomain.EnvPaper, All: true})
+	if err != nil || len(res) != 2 {
+		t.Fatalf("failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSumRealizedPnL(t *testing.T) {
+	gormDB, mock, db := setupTradingMock(t)
+	defer db.Close()
+	repo := NewTradingRepo(gormDB)
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	// 以出場時間篩選並在資料庫加總，不套用 ListTrades 的筆數上限
+	mock.ExpectQuery(`SELECT strategy_id, SUM\(pnl_usdt\) AS total FROM "strategy_trades" WHERE env = \$1 AND exit_date >= \$2 AND pnl_usdt IS NOT NULL GROUP BY "strategy_id"$`).
+		WithArgs("paper", since).
+		WillReturnRows(sqlmock.NewRows([]string{"strategy_id", "total"}).AddRow("s1", -60.0).AddRow(nil, 5.0))
+
+	pnl, err := repo.SumRealizedPnL(context.Background(), tradingDomain.EnvPaper, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pnl["s1"] != -60 || pnl["manual"] != 5 {
+		t.Fatalf("unexpected pnl %+v", pnl)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
 }
 
 func TestScoringStrategyMethods(t *testing.T) {
@@ -175,3 +212,123 @@ func TestScoringStrategyMethods(t *testing.T) {
 		t.Fatalf("failed: %v", err)
 	}
 }
+
+func TestPaperBalances(t *testing.T) {
+	gormDB, mock, db := setupTradingMock(t)
+	defer db.Close()
+	repo := NewTradingRepo(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO \"paper_balances\".*ON CONFLICT DO NOTHING").
+		WithArgs("u1", "BTC", 0.5, sqlmock.AnyArg(), "u1", "USDT", 1000.0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectCommit()
+	if err := repo.OpenPaperAccount(context.Background(), "u1", map[string]float64{"USDT": 1000, "BTC": 0.5}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	// 加減以 amount + delta 寫入，並先鎖定既有資料列檢查餘額
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM \"paper_balances\" WHERE account_id = \\$1 AND asset IN \\(\\$2,\\$3\\) ORDER BY asset FOR UPDATE").
+		WithArgs("u1", "BTC", "USDT").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "asset", "amount"}).AddRow("u1", "USDT", 1000.0))
+	mock.ExpectExec("INSERT INTO \"paper_balances\".*ON CONFLICT \\(\"account_id\",\"asset\"\\) DO UPDATE SET \"amount\"=GREATEST\\(paper_balances.amount \\+ excluded.amount, 0\\)").
+		WithArgs("u1", "BTC", 0.001, sqlmock.AnyArg(), "u1", "USDT", -49.5, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectCommit()
+	if err := repo.ApplyPaperDeltas(context.Background(), "u1", map[string]float64{"USDT": -49.5, "BTC": 0.001}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	// 另一實例已花掉餘額：鎖定後發現不足，整筆回滾
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM \"paper_balances\"").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "asset", "amount"}).AddRow("u1", "USDT", 10.0))
+	mock.ExpectRollback()
+	err := repo.ApplyPaperDeltas(context.Background(), "u1", map[string]float64{"USDT": -49.5, "BTC": 0.001})
+	if !errors.Is(err, trading.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM \"paper_balances\"").
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "asset", "amount"}).AddRow("u1", "USDT", 950.5).AddRow("u1", "BTC", 0.001))
+	bal, err := repo.LoadPaperBalances(context.Background(), "u1")
+	if err != nil || bal["USDT"] != 950.5 || bal["BTC"] != 0.001 {
+		t.Fatalf("unexpected balances %+v err=%v", bal, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHaltStates(t *testing.T) {
+	gormDB, mock, db := setupTradingMock(t)
+	defer db.Close()
+	repo := NewTradingRepo(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO \"trading_halts\".*ON CONFLICT").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := repo.SaveHaltState(context.Background(), trading.HaltState{Scope: "global", Halted: true, Reason: "incident"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM \"trading_halts\"").
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "halted", "reason", "consecutive_losses"}).
+			AddRow("global", true, "incident", 0).
+			AddRow("strategy:s1:paper", true, "3 consecutive losses", 3))
+	states, err := repo.LoadHaltStates(context.Background())
+	if err != nil || len(states) != 2 || !states[0].Halted || states[1].ConsecutiveLosses != 3 {
+		t.Fatalf("unexpected states %+v err=%v", states, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	lock := NewAdvisoryLock(db, 42)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+	if ok, err := lock.TryAcquire(ctx); ok || err != nil {
+		t.Fatalf("expected lock held elsewhere, ok=%v err=%v", ok, err)
+	}
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	if ok, err := lock.TryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected to acquire, ok=%v err=%v", ok, err)
+	}
+
+	mock.ExpectQuery("SELECT pg_advisory_unlock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+	if err := lock.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Check(ctx); err == nil {
+		t.Fatal("expected check to fail after release")
+	}
+
+	// unlock 失敗時連線必須被關閉，不能帶著鎖回到連線池
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectQuery("SELECT pg_advisory_unlock").WithArgs(42).WillReturnError(errors.New("connection reset"))
+	mock.ExpectClose()
+	if ok, err := lock.TryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected to acquire, ok=%v err=%v", ok, err)
+	}
+	if err := lock.Release(ctx); err == nil {
+		t.Fatal("expected the unlock error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
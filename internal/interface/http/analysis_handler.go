@@ -25,7 +25,7 @@ func (s *Server) handleAnalysisDaily(c *gin.Context) {
 		return
 	}
 
-	summary, err := s.runAnalysisForDate(c.Request.Context(), tradeDate)
+	summary, err := s.runAnalysis(c.Request.Context(), tradeDate)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "error_code": errCodeInternal})
 		return
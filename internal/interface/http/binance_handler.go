@@ -14,20 +14,26 @@ func (s *Server) handleBinanceAccount(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "binance client not initialized", "error_code": errCodeInternal})
 		return
 	}
-	info, err := s.binanceClient.GetAccountInfo()
+	info, err := s.binanceClient.GetAccountInfo(c.Request.Context())
 	if err != nil {
 		// If we are in Paper mode, don't return an error even if key is invalid.
-		// Return a mock balance instead.
+		// Return the caller's simulated paper balances instead.
 		if s.defaultEnv == tradingDomain.EnvPaper {
+			bal, perr := s.tradingSvc.PaperAccount(currentUserID(c)).Balances(c.Request.Context())
+			if perr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": perr.Error(), "error_code": errCodeInternal})
+				return
+			}
+			balances := make([]gin.H, 0, len(bal))
+			for asset, amount := range bal {
+				balances = append(balances, gin.H{"asset": asset, "free": fmt.Sprintf("%.8f", amount), "locked": "0.00"})
+			}
 			c.JSON(http.StatusOK, gin.H{
 				"success": true,
 				"is_mock": true,
 				"account": gin.H{
 					"accountType": "SPOT",
-					"balances": []gin.H{
-						{"asset": "USDT", "free": "0.00", "locked": "0.00"},
-						{"asset": "BTC", "free": "0.000000", "locked": "0.000000"},
-					},
+					"balances":    balances,
 				},
 			})
 			return
@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"net/http"
+
+	tradingDomain "ai-auto-trade/internal/domain/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+type killSwitchRequest struct {
+	Engaged bool   `json:"engaged"`
+	Flatten bool   `json:"flatten"`
+	Reason  string `json:"reason"`
+}
+
+func (s *Server) handleGetKillSwitch(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "status": s.tradingSvc.HaltStatus()})
+}
+
+// handleSetKillSwitch 啟動或解除 kill switch；flatten 時同步平掉所有未平倉部位。
+func (s *Server) handleSetKillSwitch(c *gin.Context) {
+	var req killSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	ctx := c.Request.Context()
+	if !req.Engaged {
+		if err := s.tradingSvc.ReleaseKillSwitch(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "error_code": errCodeInternal})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "status": s.tradingSvc.HaltStatus()})
+		return
+	}
+
+	closed, err := s.tradingSvc.EngageKillSwitch(ctx, req.Reason, req.Flatten, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success":          false,
+			"error":            err.Error(),
+			"error_code":       errCodeInternal,
+			"closed_positions": closed,
+			"status":           s.tradingSvc.HaltStatus(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "closed_positions": closed, "status": s.tradingSvc.HaltStatus()})
+}
+
+// handleResetBreaker 解除策略在指定環境的熔斷。
+func (s *Server) handleResetBreaker(c *gin.Context, strategyID string) {
+	env := s.defaultEnv
+	if qenv := c.Query("env"); qenv != "" {
+		env = tradingDomain.Environment(qenv)
+	}
+	if err := s.tradingSvc.ResetCircuitBreaker(c.Request.Context(), strategyID, env); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "error_code": errCodeInternal})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -32,10 +32,15 @@ func (s *Server) handleHealth(c *gin.Context) {
 		dbStatus = "using_memory"
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"success": true,
 		"health":  "ok",
 		"db":      dbStatus,
 		"time":    time.Now().Format(time.RFC3339),
-	})
+	}
+	if s.tradingSvc != nil {
+		// kill switch 與熔斷中策略，讓監控能直接看到交易是否被暫停
+		resp["trading"] = s.tradingSvc.HaltStatus()
+	}
+	c.JSON(http.StatusOK, resp)
 }
@@ -47,5 +47,9 @@ func TestHealthHandler(t *testing.T) {
 		if resp["db"] != "using_memory" {
 			t.Errorf("expected using_memory, got %v", resp["db"])
 		}
+		trading, ok := resp["trading"].(map[string]interface{})
+		if !ok || trading["kill_switch"] == nil || trading["tripped_strategies"] == nil {
+			t.Errorf("expected trading halt status, got %v", resp["trading"])
+		}
 	})
 }
@@ -1,12 +1,17 @@
 package httpapi
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"ai-auto-trade/internal/application/analysis"
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/jobs"
 	"ai-auto-trade/internal/application/trading"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
 
@@ -186,45 +191,259 @@ func formatOptionalTimes(v *float64) string {
 	return fmt.Sprintf("%.2fx", *v)
 }
 
-func (s *Server) recordJob(j jobRun) {
-	s.jobMu.Lock()
-	defer s.jobMu.Unlock()
-	if j.DataSource == "" {
-		j.DataSource = s.dataSource
+// newJobRun 建立一次執行紀錄；TargetStart/TargetEnd 為處理的交易日區間，分析版本為目前啟用中的版本。
+func (s *Server) newJobRun(ctx context.Context, kind, triggeredBy string, targetStart, targetEnd time.Time, analysisOn bool) *jobs.Run {
+	return &jobs.Run{
+		Kind:            kind,
+		TriggeredBy:     triggeredBy,
+		DataSource:      s.dataSource,
+		TargetStart:     targetStart,
+		TargetEnd:       targetEnd,
+		Start:           time.Now(),
+		AnalysisOn:      analysisOn,
+		AnalysisVersion: s.activeAnalysisVersion(ctx),
 	}
-	s.jobHistory = append(s.jobHistory, j)
-	if len(s.jobHistory) > 50 {
-		s.jobHistory = s.jobHistory[len(s.jobHistory)-50:]
+}
+
+// activeAnalysisVersion 回傳啟用中的分析版本；讀取註冊表失敗時視為 DefaultVersion。
+func (s *Server) activeAnalysisVersion(ctx context.Context) string {
+	if s.versions == nil {
+		return analysis.DefaultVersion
+	}
+	v, err := analysis.ActiveVersion(ctx, s.versions)
+	if err != nil {
+		log.Printf("[Analysis] resolve active version failed: %v", err)
+		return analysis.DefaultVersion
+	}
+	return v
+}
+
+// recordJob 補上結束時間後寫入 job store；寫入失敗只記錄 log，不影響執行結果。
+func (s *Server) recordJob(ctx context.Context, run *jobs.Run) {
+	if run.End.IsZero() {
+		run.End = time.Now()
+	}
+	if err := s.jobStore.SaveRun(ctx, run); err != nil {
+		log.Printf("[Jobs] save %s run failed: %v", run.Kind, err)
 	}
-	if j.Kind == "auto" {
-		s.lastAutoRun = j.End
+}
+
+// addIngestion 將單一交易日的 ingestion 結果併入 run；整日失敗且沒有明細時補一筆日期層級的明細。
+func addIngestion(run *jobs.Run, tradeDate time.Time, sum ingestRunSummary, err error) {
+	stage := &run.Ingestion
+	stage.Success += sum.success
+	stage.Failure += sum.failure
+	stage.Total = stage.Success + stage.Failure
+	for _, it := range sum.items {
+		reason := it.Reason
+		if reason == "" && it.Quarantined > 0 {
+			reason = fmt.Sprintf("%d bars quarantined", it.Quarantined)
+		}
+		stage.Items = append(stage.Items, jobs.Item{
+			Symbol:      it.Symbol,
+			Timeframe:   it.Timeframe,
+			TradeDate:   tradeDate,
+			Status:      jobs.StatusOf(it.Stored, it.Failed+it.Quarantined, ""),
+			ErrorReason: reason,
+		})
+	}
+	if err != nil {
+		addStageError(run, stage, tradeDate, err, len(sum.items) == 0)
 	}
 }
 
-func jobRunToMap(j jobRun, loc *time.Location) map[string]interface{} {
+// addAnalysis 將單一交易日的分析結果併入 run。
+func addAnalysis(run *jobs.Run, tradeDate time.Time, sum analysisRunSummary, err error) {
+	stage := &run.Analysis
+	stage.Success += sum.success
+	stage.Failure += sum.failure
+	stage.Total += sum.total
+	for _, it := range sum.items {
+		stage.Items = append(stage.Items, jobs.Item{
+			Symbol:      it.Symbol,
+			Timeframe:   it.Timeframe,
+			TradeDate:   tradeDate,
+			Status:      jobs.StatusOf(it.Success, it.Failed, ""),
+			ErrorReason: it.Reason,
+			Duration:    it.Duration,
+		})
+	}
+	if err != nil {
+		addStageError(run, stage, tradeDate, err, len(sum.items) == 0)
+	}
+}
+
+// addStageError 累計錯誤摘要；跨多日的執行以交易日標示。
+func addStageError(run *jobs.Run, stage *jobs.Stage, tradeDate time.Time, err error, dateItem bool) {
+	msg := err.Error()
+	if !sameDay(run.TargetStart, run.TargetEnd) {
+		msg = tradeDate.Format("2006-01-02") + ": " + msg
+	}
+	if stage.Error != "" {
+		stage.Error += "; "
+	}
+	stage.Error += msg
+	if dateItem {
+		stage.Items = append(stage.Items, jobs.Item{TradeDate: tradeDate, Status: jobs.StatusFailed, ErrorReason: err.Error()})
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func jobRunToMap(j jobs.Run, loc *time.Location) map[string]interface{} {
 	start := j.Start.In(loc)
 	end := j.End.In(loc)
 	duration := int(end.Sub(start).Seconds())
 	return map[string]interface{}{
+		"id":               j.ID,
 		"kind":             j.Kind,
+		"status":           j.Status(),
 		"triggered_by":     optionalString(j.TriggeredBy),
+		"target_start":     formatOptionalDate(j.TargetStart),
+		"target_end":       formatOptionalDate(j.TargetEnd),
 		"start":            start.Format(time.RFC3339),
 		"end":              end.Format(time.RFC3339),
 		"duration_seconds": duration,
 		"data_source":      optionalString(j.DataSource),
 		"ingestion": map[string]interface{}{
-			"success": j.IngestionOK,
-			"error":   optionalString(j.IngestionErr),
+			"success":       j.Ingestion.Status() != jobs.StatusFailed,
+			"status":        j.Ingestion.Status(),
+			"success_count": j.Ingestion.Success,
+			"failure_count": j.Ingestion.Failure,
+			"error":         optionalString(j.Ingestion.Error),
 		},
 		"analysis": map[string]interface{}{
 			"enabled":       j.AnalysisOn,
-			"success":       j.AnalysisOK,
-			"total":         j.AnalysisTotal,
-			"success_count": j.AnalysisSucc,
-			"failure_count": j.AnalysisFail,
-			"error":         optionalString(j.AnalysisErr),
+			"version":       optionalString(j.AnalysisVersion),
+			"success":       j.AnalysisOn && j.Analysis.Status() != jobs.StatusFailed,
+			"status":        optionalString(analysisStatus(j)),
+			"total":         j.Analysis.Total,
+			"success_count": j.Analysis.Success,
+			"failure_count": j.Analysis.Failure,
+			"error":         optionalString(j.Analysis.Error),
+		},
+		"failures": jobFailures(j),
+	}
+}
+
+// jobRunDetailToMap 在摘要之外附上每個交易對／日期的明細。
+func jobRunDetailToMap(j jobs.Run, loc *time.Location) map[string]interface{} {
+	out := jobRunToMap(j, loc)
+	out["items"] = map[string]interface{}{
+		"ingestion": jobItemsToMaps(j.Ingestion.Items),
+		"analysis":  jobItemsToMaps(j.Analysis.Items),
+	}
+	return out
+}
+
+func analysisStatus(j jobs.Run) string {
+	if !j.AnalysisOn {
+		return ""
+	}
+	return j.Analysis.Status()
+}
+
+// jobFailures 列出未成功的明細；清單查詢不含明細時為空陣列。
+func jobFailures(j jobs.Run) []jobFailure {
+	out := []jobFailure{}
+	collect := func(stage string, items []jobs.Item) {
+		for _, it := range items {
+			if it.Status == jobs.StatusSuccess {
+				continue
+			}
+			out = append(out, jobFailure{
+				TradeDate: formatOptionalDate(it.TradeDate),
+				Stage:     stage,
+				Symbol:    it.Symbol,
+				Timeframe: it.Timeframe,
+				Reason:    it.ErrorReason,
+			})
+		}
+	}
+	collect("ingestion", j.Ingestion.Items)
+	collect("analysis", j.Analysis.Items)
+	return out
+}
+
+func jobItemsToMaps(items []jobs.Item) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(items))
+	for _, it := range items {
+		out = append(out, map[string]interface{}{
+			"symbol":       optionalString(it.Symbol),
+			"timeframe":    optionalString(it.Timeframe),
+			"trade_date":   formatOptionalDate(it.TradeDate),
+			"status":       it.Status,
+			"error_reason": optionalString(it.ErrorReason),
+			"duration_ms":  it.Duration.Milliseconds(),
+		})
+	}
+	return out
+}
+
+func formatOptionalDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// backfillToMap 將回補工作轉為 API 格式，tasks 附上尚未抓取的缺口以便追蹤。
+func backfillToMap(job dataingestion.BackfillJob, loc *time.Location) map[string]interface{} {
+	missing, processed, stored := job.Totals()
+	tasks := make([]map[string]interface{}, len(job.Tasks))
+	for i, t := range job.Tasks {
+		gaps := make([]map[string]string, len(t.Gaps))
+		for k, g := range t.Gaps {
+			gaps[k] = map[string]string{"from": g.From.In(loc).Format(time.RFC3339), "to": g.To.In(loc).Format(time.RFC3339)}
+		}
+		tasks[i] = map[string]interface{}{
+			"symbol":      t.Symbol,
+			"timeframe":   t.Timeframe,
+			"detected":    t.Detected,
+			"missing":     t.Missing,
+			"processed":   t.Processed,
+			"stored":      t.Stored,
+			"quarantined": t.Quarantined,
+			"gaps":        gaps,
+			"error":       optionalString(t.Error),
+		}
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.In(loc).Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"id":           job.ID,
+		"status":       job.Status,
+		"phase":        job.Phase,
+		"triggered_by": optionalString(job.TriggeredBy),
+		"symbols":      job.Symbols,
+		"timeframes":   job.Timeframes,
+		"start":        formatTime(job.Start),
+		"end":          formatTime(job.End),
+		"run_analysis": job.RunAnalysis,
+		"progress": map[string]interface{}{
+			"percent":          job.Percent(),
+			"missing_bars":     missing,
+			"processed_bars":   processed,
+			"stored_bars":      stored,
+			"analyzed_days":    job.AnalyzedDays,
+			"analysis_pending": len(job.PendingAnalysis),
+		},
+		"analysis": map[string]interface{}{
+			"success_count": job.AnalysisSuccess,
+			"failure_count": job.AnalysisFailure,
+			"errors":        job.AnalysisErrors,
 		},
-		"failures": j.Failures,
+		"tasks":       tasks,
+		"error":       optionalString(job.Error),
+		"created_at":  formatTime(job.CreatedAt),
+		"started_at":  formatTime(job.StartedAt),
+		"finished_at": formatTime(job.FinishedAt),
 	}
 }
 
@@ -0,0 +1,158 @@
+package httpapi
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleIngestionImport 匯入外部來源的歷史 K 線檔案（multipart 欄位 file），
+// 經驗證與品質檢查後寫入；欄位對應、時區與週期由表單欄位指定。
+func (s *Server) handleIngestionImport(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "file is required", "error_code": errCodeBadRequest})
+		return
+	}
+	defer file.Close()
+
+	opts, err := importOptionsFromForm(c, header.Filename)
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+
+	uc := dataingestion.NewImportUseCase(priceStore{repo: s.dataRepo})
+	uc.SetQualityGate(s.quality)
+	res, err := uc.Import(c.Request.Context(), file, opts)
+	if err != nil {
+		log.Printf("[Import] %s failed after %d rows: %v", header.Filename, res.Rows, err)
+		status, code := http.StatusInternalServerError, errCodeInternal
+		if errors.Is(err, dataingestion.ErrUnsupportedFormat) || res.Rows == 0 {
+			status, code = http.StatusBadRequest, errCodeBadRequest
+		}
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "error_code": code, "data": importResultToMap(res)})
+		return
+	}
+	log.Printf("[Import] %s by %s: rows=%d stored=%d failed=%d quarantined=%d",
+		header.Filename, currentUserID(c), res.Rows, res.Stored, res.Failed, res.Quarantined)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": importResultToMap(res)})
+}
+
+func importOptionsFromForm(c *gin.Context, filename string) (dataingestion.ImportOptions, error) {
+	opts := dataingestion.ImportOptions{
+		Format:     strings.ToLower(c.DefaultPostForm("format", dataingestion.FormatFromName(filename))),
+		Symbol:     strings.ToUpper(strings.TrimSpace(c.PostForm("symbol"))),
+		Market:     dataDomain.Market(strings.ToUpper(c.DefaultPostForm("market", string(dataDomain.MarketCrypto)))),
+		Timeframe:  strings.ToLower(c.PostForm("timeframe")),
+		TimeLayout: c.PostForm("time_layout"),
+	}
+	loc, err := time.LoadLocation(c.DefaultPostForm("tz", "UTC"))
+	if err != nil {
+		return opts, errors.New("invalid tz")
+	}
+	opts.Location = loc
+	opts.Columns, err = dataingestion.ParseColumnMapping(c.PostForm("columns"))
+	return opts, err
+}
+
+func importResultToMap(res dataingestion.ImportResult) map[string]interface{} {
+	failures := make([]map[string]interface{}, len(res.Failures))
+	for i, f := range res.Failures {
+		failures[i] = map[string]interface{}{"line": f.Line, "reason": f.Reason}
+	}
+	out := map[string]interface{}{
+		"rows":        res.Rows,
+		"stored":      res.Stored,
+		"failed":      res.Failed,
+		"quarantined": res.Quarantined,
+		"failures":    failures,
+		"from":        nil,
+		"to":          nil,
+	}
+	if res.Stored > 0 {
+		out["from"] = res.From.Format(time.RFC3339)
+		out["to"] = res.To.Format(time.RFC3339)
+	}
+	return out
+}
+
+// handleIngestionExport 以 CSV 串流匯出單一交易對 × 週期在 [from, to) 內的 K 線或分析結果。
+func (s *Server) handleIngestionExport(c *gin.Context) {
+	req := dataingestion.ExportRequest{
+		Dataset:   c.DefaultQuery("dataset", dataingestion.DatasetPrices),
+		Format:    strings.ToLower(c.DefaultQuery("format", dataingestion.FormatCSV)),
+		Symbol:    strings.ToUpper(strings.TrimSpace(c.Query("symbol"))),
+		Timeframe: strings.ToLower(c.DefaultQuery("timeframe", "1d")),
+	}
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid tz", "error_code": errCodeBadRequest})
+		return
+	}
+	req.Location = loc
+	from, err := parseJobTime(c.Query("from"), false)
+	if err != nil || from == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid from", "error_code": errCodeBadRequest})
+		return
+	}
+	to, err := parseJobTime(c.Query("to"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid to", "error_code": errCodeBadRequest})
+		return
+	}
+	req.From = *from
+	req.To = time.Now()
+	if to != nil {
+		req.To = *to
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+
+	w := &exportWriter{c: c, filename: req.Symbol + "_" + req.Timeframe + "_" + req.Dataset + ".csv"}
+	rows, err := dataingestion.NewExporter(s.dataRepo).Export(c.Request.Context(), w, req)
+	if err != nil {
+		log.Printf("[Export] %s %s %s failed after %d rows: %v", req.Dataset, req.Symbol, req.Timeframe, rows, err)
+		if !w.started {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "export failed", "error_code": errCodeInternal})
+			return
+		}
+		// 已開始輸出時無法改變狀態碼，改以 trailer 告知用戶端檔案不完整
+		c.Writer.Header().Set(exportErrorTrailer, "export failed")
+	}
+}
+
+// exportErrorTrailer 為串流中途失敗時設定的 HTTP trailer。
+const exportErrorTrailer = "X-Export-Error"
+
+// exportWriter 在第一次寫入時才送出 CSV 標頭，讓輸出前的錯誤仍能回傳 JSON。
+type exportWriter struct {
+	c        *gin.Context
+	filename string
+	started  bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "text/csv; charset=utf-8")
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.c.Header("Trailer", exportErrorTrailer)
+		w.c.Status(http.StatusOK)
+	}
+	n, err := w.c.Writer.Write(p)
+	w.c.Writer.Flush()
+	return n, err
+}
@@ -1,19 +1,27 @@
 package httpapi
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/jobs"
+
 	"github.com/gin-gonic/gin"
 )
 
+// handleIngestionBackfill 建立背景回補工作並立即回傳 202；以 GET /backfill/:id 查詢進度。
+// symbols/timeframes 未指定時使用擷取組態的觀察清單。
 func (s *Server) handleIngestionBackfill(c *gin.Context) {
 	var body struct {
-		StartDate   string `json:"start_date"`
-		EndDate     string `json:"end_date"`
-		RunAnalysis bool   `json:"run_analysis"`
+		StartDate   string   `json:"start_date"`
+		EndDate     string   `json:"end_date"`
+		RunAnalysis bool     `json:"run_analysis"`
+		Symbols     []string `json:"symbols"`
+		Timeframes  []string `json:"timeframes"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid body", "error_code": errCodeBadRequest})
@@ -26,73 +34,87 @@ func (s *Server) handleIngestionBackfill(c *gin.Context) {
 		return
 	}
 	end, err := time.Parse("2006-01-02", body.EndDate)
-	if err != nil {
+	if err != nil || end.Before(start) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid end_date", "error_code": errCodeBadRequest})
 		return
 	}
+	if len(body.Symbols) == 0 {
+		body.Symbols = s.ingestCfg.Symbols
+	}
+	if len(body.Timeframes) == 0 {
+		body.Timeframes = s.ingestCfg.Timeframes
+	}
 
 	triggeredBy := currentUserID(c)
-	job := jobRun{
-		Kind:        "backfill",
+	job, err := s.backfills.Submit(c.Request.Context(), dataingestion.BackfillJob{
 		TriggeredBy: triggeredBy,
-		Start:       time.Now(),
-		AnalysisOn:  body.RunAnalysis,
-	}
-	
-	log.Printf("[Backfill] Starting sync from %s to %s, triggered by %s", body.StartDate, body.EndDate, triggeredBy)
-	
-	total := 0
-	succ := 0
-	fail := 0
-	var failures []backfillFailure
-
-	curr := start
-	for !curr.After(end) {
-		// Ingestion
-		if err := s.generateDailyPricesStrict(c.Request.Context(), curr); err != nil {
-			log.Printf("[Backfill] Ingestion failed for %s: %v", curr.Format("2006-01-02"), err)
-			failures = append(failures, backfillFailure{
-				TradeDate: curr.Format("2006-01-02"),
-				Stage:     "ingestion",
-				Reason:    err.Error(),
-			})
-		} else {
-			// Analysis
-			if body.RunAnalysis {
-				summary, err := s.runAnalysisForDate(c.Request.Context(), curr)
-				if err != nil {
-					log.Printf("[Backfill] Analysis failed for %s: %v", curr.Format("2006-01-02"), err)
-					failures = append(failures, backfillFailure{
-						TradeDate: curr.Format("2006-01-02"),
-						Stage:     "analysis",
-						Reason:    err.Error(),
-					})
-				} else {
-					total += summary.total
-					succ += summary.success
-					fail += summary.failure
-				}
-			}
-		}
-		curr = curr.AddDate(0, 0, 1)
-	}
-
-	job.End = time.Now()
-	job.AnalysisTotal = total
-	job.AnalysisSucc = succ
-	job.AnalysisFail = fail
-	job.AnalysisOK = (fail == 0)
-	job.IngestionOK = (len(failures) == 0)
-	job.Failures = failures
-	s.recordJob(job)
-	
-	c.JSON(http.StatusOK, gin.H{
+		Symbols:     body.Symbols,
+		Timeframes:  body.Timeframes,
+		Start:       start,
+		End:         end.AddDate(0, 0, 1), // 結束日含當天
+		RunAnalysis: body.RunAnalysis,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	log.Printf("[Backfill] Job %s queued for %s to %s, triggered by %s", job.ID, body.StartDate, body.EndDate, triggeredBy)
+
+	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
-		"message": fmt.Sprintf("Backfill from %s to %s completed", body.StartDate, body.EndDate),
-		"summary": job,
+		"data":    backfillToMap(*job, taipeiLocation()),
 	})
 }
 
+// handleBackfillList 回傳最近的回補工作。
+func (s *Server) handleBackfillList(c *gin.Context) {
+	list, err := s.backfills.List(c.Request.Context())
+	if err != nil {
+		log.Printf("[Backfill] list jobs failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
+	loc := taipeiLocation()
+	data := make([]map[string]interface{}, len(list))
+	for i, job := range list {
+		data[i] = backfillToMap(job, loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// handleBackfillStatus 回傳單一回補工作的進度與各交易對剩餘缺口。
+func (s *Server) handleBackfillStatus(c *gin.Context) {
+	job, err := s.backfills.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, dataingestion.ErrBackfillNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "backfill job not found", "error_code": errCodeNotFound})
+		return
+	}
+	if err != nil {
+		log.Printf("[Backfill] load job %s failed: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": backfillToMap(*job, taipeiLocation())})
+}
+
+// handleBackfillCancel 取消尚未結束的回補工作；已寫入的 K 線保留。
+func (s *Server) handleBackfillCancel(c *gin.Context) {
+	job, err := s.backfills.Cancel(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, dataingestion.ErrBackfillNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "backfill job not found", "error_code": errCodeNotFound})
+		return
+	case errors.Is(err, dataingestion.ErrBackfillNotActive):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "backfill job already finished", "error_code": errCodeConflict})
+		return
+	case err != nil:
+		log.Printf("[Backfill] cancel job %s failed: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "cancel failed", "error_code": errCodeInternal})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": backfillToMap(*job, taipeiLocation())})
+}
+
 func (s *Server) handleIngestionDaily(c *gin.Context) {
 	var body struct {
 		TradeDate   string `json:"trade_date"`
@@ -113,38 +135,20 @@ func (s *Server) handleIngestionDaily(c *gin.Context) {
 		}
 	}
 
-	triggeredBy := currentUserID(c)
-	job := jobRun{
-		Kind:        "daily_manual",
-		TriggeredBy: triggeredBy,
-		Start:       time.Now(),
-		AnalysisOn:  body.RunAnalysis,
-	}
-	
-	if err := s.generateDailyPrices(c.Request.Context(), tradeDate); err != nil {
-		job.IngestionOK = false
-		job.IngestionErr = err.Error()
-	} else {
-		job.IngestionOK = true
-		if body.RunAnalysis {
-			summary, err := s.runAnalysisForDate(c.Request.Context(), tradeDate)
-			if err != nil {
-				job.AnalysisOK = false
-				job.AnalysisErr = err.Error()
-			} else {
-				job.AnalysisOK = true
-				job.AnalysisTotal = summary.total
-				job.AnalysisSucc = summary.success
-				job.AnalysisFail = summary.failure
-			}
-		}
+	run := s.newJobRun(c.Request.Context(), "daily_manual", currentUserID(c), tradeDate, tradeDate, body.RunAnalysis)
+
+	ctx := c.Request.Context()
+	ingested, err := s.generateDailyPrices(ctx, tradeDate)
+	addIngestion(run, tradeDate, ingested, err)
+	if err == nil && body.RunAnalysis {
+		summary, err := s.runAnalysis(ctx, tradeDate)
+		addAnalysis(run, tradeDate, summary, err)
 	}
-	
-	job.End = time.Now()
-	s.recordJob(job)
+
+	s.recordJob(context.WithoutCancel(ctx), run)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": job.IngestionOK && (!body.RunAnalysis || job.AnalysisOK),
-		"summary": job,
+		"success": run.Status() != jobs.StatusFailed,
+		"summary": jobRunToMap(*run, taipeiLocation()),
 	})
 }
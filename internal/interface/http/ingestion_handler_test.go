@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"ai-auto-trade/internal/domain/auth"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
 	"ai-auto-trade/internal/infrastructure/config"
 )
 
@@ -40,22 +43,103 @@ func TestIngestionHandler_Memory(t *testing.T) {
 		}
 	})
 
-	t.Run("Backfill_Success", func(t *testing.T) {
-		today := time.Now().Format("2006-01-02")
-		body := map[string]interface{}{
-			"start_date":   today,
-			"end_date":     today,
-			"run_analysis": false,
-		}
-		jsonBody, _ := json.Marshal(body)
+	t.Run("ImportAndExport", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("symbol", "solusdt")
+		_ = mw.WriteField("timeframe", "1d")
+		_ = mw.WriteField("columns", "time=Date")
+		fw, _ := mw.CreateFormFile("file", "sol.csv")
+		_, _ = fw.Write([]byte("Date,open,high,low,close,volume\n2023-01-02,10,12,9,11,100\n2023-01-03,11,13,10,12,120\n2023-01-04,x,1,1,1,1\n"))
+		_ = mw.Close()
+
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/api/admin/ingestion/backfill", bytes.NewBuffer(jsonBody))
+		req, _ := http.NewRequest("POST", "/api/admin/ingestion/import", &body)
 		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", mw.FormDataContentType())
 		server.Handler().ServeHTTP(w, req)
-
 		if w.Code != http.StatusOK {
-			t.Errorf("expected 200, got %d. body: %s", w.Code, w.Body.String())
+			t.Fatalf("expected 200, got %d. body: %s", w.Code, w.Body.String())
+		}
+		var imported struct {
+			Data struct {
+				Stored int `json:"stored"`
+				Failed int `json:"failed"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &imported)
+		if imported.Data.Stored != 2 || imported.Data.Failed != 1 {
+			t.Fatalf("unexpected import result: %s", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/admin/ingestion/export?symbol=SOLUSDT&timeframe=1d&from=2023-01-01T00:00:00Z&to=2023-02-01T00:00:00Z", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("expected csv, got %d %s", w.Code, w.Body.String())
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 3 || lines[1] != "SOLUSDT,CRYPTO,1d,2023-01-02T00:00:00Z,10,12,9,11,100" {
+			t.Fatalf("unexpected export: %q", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/admin/ingestion/export?symbol=SOLUSDT&from=2023-01-01&format=parquet", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for parquet export, got %d", w.Code)
+		}
+	})
+
+	t.Run("Backfill_ProgressAndCancel", func(t *testing.T) {
+		// 回補每次抓取時才向註冊表取得 K 線來源
+		server.exchanges.Register("binance", nil, blockingKlines{})
+
+		do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+			var buf bytes.Buffer
+			if body != nil {
+				_ = json.NewEncoder(&buf).Encode(body)
+			}
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, path, &buf)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			server.Handler().ServeHTTP(w, req)
+			return w
+		}
+
+		w := do("POST", "/api/admin/ingestion/backfill", map[string]interface{}{
+			"start_date": "2024-01-01",
+			"end_date":   "2024-01-31",
+			"symbols":    []string{"BTCUSDT"},
+		})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d. body: %s", w.Code, w.Body.String())
+		}
+		var created struct {
+			Data struct {
+				ID     string `json:"id"`
+				Status string `json:"status"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &created)
+		if created.Data.ID == "" || created.Data.Status != "queued" {
+			t.Fatalf("unexpected job: %s", w.Body.String())
+		}
+
+		if w := do("GET", "/api/admin/ingestion/backfill/"+created.Data.ID, nil); w.Code != http.StatusOK {
+			t.Errorf("expected 200 for progress, got %d", w.Code)
+		}
+		if w := do("POST", "/api/admin/ingestion/backfill/"+created.Data.ID+"/cancel", nil); w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"status":"canceled"`)) {
+			t.Errorf("expected canceled job, got %d %s", w.Code, w.Body.String())
+		}
+		if w := do("POST", "/api/admin/ingestion/backfill/"+created.Data.ID+"/cancel", nil); w.Code != http.StatusConflict {
+			t.Errorf("expected 409 for finished job, got %d", w.Code)
+		}
+		if w := do("GET", "/api/admin/ingestion/backfill/missing", nil); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
 		}
 	})
 
@@ -75,3 +159,11 @@ func TestIngestionHandler_Memory(t *testing.T) {
 		}
 	})
 }
+
+// blockingKlines 直到工作被取消才回傳，讓回補停在抓取階段。
+type blockingKlines struct{}
+
+func (blockingKlines) FetchKlines(ctx context.Context, _, _ string, _, _ time.Time) ([]dataDomain.DailyPrice, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
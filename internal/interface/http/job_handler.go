@@ -1,52 +1,128 @@
 package httpapi
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
+	"ai-auto-trade/internal/application/jobs"
+
 	"github.com/gin-gonic/gin"
 )
 
 func (s *Server) handleJobsStatus(c *gin.Context) {
-	s.jobMu.Lock()
-	history := make([]jobRun, len(s.jobHistory))
-	copy(history, s.jobHistory)
-	lastAuto := s.lastAutoRun
-	s.jobMu.Unlock()
-
+	ctx := c.Request.Context()
 	loc := taipeiLocation()
-	var lastAutoStr string
-	if !lastAuto.IsZero() {
-		lastAutoStr = lastAuto.In(loc).Format(time.RFC3339)
-	}
 
 	var latest map[string]interface{}
-	if len(history) > 0 {
-		latest = jobRunToMap(history[len(history)-1], loc)
+	if runs, _, err := s.jobStore.ListRuns(ctx, jobs.Filter{Limit: 1}); err != nil {
+		log.Printf("[Jobs] load latest run failed: %v", err)
+	} else if len(runs) > 0 {
+		latest = jobRunToMap(runs[0], loc)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	var lastAutoStr string
+	if runs, _, err := s.jobStore.ListRuns(ctx, jobs.Filter{Kind: "auto", Limit: 1}); err == nil && len(runs) > 0 {
+		lastAutoStr = runs[0].End.In(loc).Format(time.RFC3339)
+	}
+
+	resp := gin.H{
 		"success":       true,
 		"last_auto_run": latest,
 		"last_auto_end": lastAutoStr,
-	})
+	}
+	if s.scheduler != nil {
+		resp["schedule"] = s.scheduler.Status(ctx)
+	}
+	if s.elector != nil {
+		resp["leader"] = s.elector.Status()
+	}
+	if s.tradingSvc != nil {
+		resp["executor"] = s.tradingSvc.ExecutorStats()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
+// handleJobsHistory 依 kind/status/from/to 篩選執行紀錄，以 limit/offset 分頁，由新到舊排列。
 func (s *Server) handleJobsHistory(c *gin.Context) {
-	s.jobMu.Lock()
-	history := make([]jobRun, len(s.jobHistory))
-	copy(history, s.jobHistory)
-	s.jobMu.Unlock()
+	filter := jobs.Filter{
+		Kind:   c.Query("kind"),
+		Status: c.Query("status"),
+		Limit:  parseIntDefault(c.Query("limit"), 20),
+		Offset: parseIntDefault(c.Query("offset"), 0),
+	}
+	switch filter.Status {
+	case "", jobs.StatusSuccess, jobs.StatusPartial, jobs.StatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid status", "error_code": errCodeBadRequest})
+		return
+	}
+	var err error
+	if filter.From, err = parseJobTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid from", "error_code": errCodeBadRequest})
+		return
+	}
+	if filter.To, err = parseJobTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid to", "error_code": errCodeBadRequest})
+		return
+	}
+	filter = filter.Normalize()
+
+	runs, total, err := s.jobStore.ListRuns(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("[Jobs] list history failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
 
 	loc := taipeiLocation()
-	data := make([]map[string]interface{}, len(history))
-	for i, j := range history {
-		// Reverse order
-		data[len(history)-1-i] = jobRunToMap(j, loc)
+	data := make([]map[string]interface{}, len(runs))
+	for i, j := range runs {
+		data[i] = jobRunToMap(j, loc)
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"total_count": total,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+		"data":        data,
+	})
+}
+
+// handleJobDetail 回傳單次執行與每個交易對／日期的明細。
+func (s *Server) handleJobDetail(c *gin.Context) {
+	run, err := s.jobStore.GetRun(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "job not found", "error_code": errCodeNotFound})
+		return
+	}
+	if err != nil {
+		log.Printf("[Jobs] load job %s failed: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    data,
+		"data":    jobRunDetailToMap(*run, taipeiLocation()),
 	})
 }
+
+// parseJobTime 接受 RFC3339 或 YYYY-MM-DD（台北時間）；endOfDay 時日期涵蓋整天。
+func parseJobTime(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, taipeiLocation())
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
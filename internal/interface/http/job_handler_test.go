@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"ai-auto-trade/internal/application/jobs"
 	"ai-auto-trade/internal/domain/auth"
 	"ai-auto-trade/internal/infrastructure/config"
 )
@@ -22,15 +23,21 @@ func TestJobHandler_Memory(t *testing.T) {
 	token := pair.AccessToken
 
 	// Add mock history
-	server.jobMu.Lock()
-	server.jobHistory = append(server.jobHistory, jobRun{
+	ctx := context.Background()
+	_ = server.jobStore.SaveRun(ctx, &jobs.Run{
 		Kind:        "daily_job",
 		TriggeredBy: "test",
-		Start:       time.Now(),
+		Start:       time.Now().Add(-time.Hour),
 		AnalysisOn:  true,
-		AnalysisOK:  true,
+		Ingestion:   jobs.Stage{Success: 1},
+		Analysis:    jobs.Stage{Success: 1, Total: 1},
 	})
-	server.jobMu.Unlock()
+	failed := &jobs.Run{
+		Kind:      "backfill",
+		Start:     time.Now(),
+		Ingestion: jobs.Stage{Error: "no kline data", Items: []jobs.Item{{Symbol: "ETHUSDT", Timeframe: "1h", Status: jobs.StatusFailed, ErrorReason: "timeout"}}},
+	}
+	_ = server.jobStore.SaveRun(ctx, failed)
 
 	t.Run("Status", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -47,6 +54,9 @@ func TestJobHandler_Memory(t *testing.T) {
 		if resp["success"] != true {
 			t.Error("expected success true")
 		}
+		if l, ok := resp["leader"].(map[string]interface{}); !ok || l["backend"] != "memory" || l["instance_id"] == "" {
+			t.Errorf("expected leader status, got %v", resp["leader"])
+		}
 	})
 
 	t.Run("History", func(t *testing.T) {
@@ -62,8 +72,64 @@ func TestJobHandler_Memory(t *testing.T) {
 		var resp map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &resp)
 		data := resp["data"].([]interface{})
-		if len(data) == 0 {
-			t.Error("expected history data")
+		if len(data) != 2 || resp["total_count"] != float64(2) {
+			t.Fatalf("expected 2 runs, got %v", resp)
+		}
+		if first := data[0].(map[string]interface{}); first["kind"] != "backfill" || first["status"] != jobs.StatusFailed {
+			t.Errorf("expected newest failed backfill first, got %v", first)
+		}
+	})
+
+	t.Run("HistoryFilterAndPage", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/admin/jobs/history?status=success&limit=1&offset=0", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		data := resp["data"].([]interface{})
+		if len(data) != 1 || data[0].(map[string]interface{})["kind"] != "daily_job" || resp["limit"] != float64(1) {
+			t.Errorf("expected the successful daily job, got %v", resp)
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/admin/jobs/history?status=unknown", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for invalid status, got %d", w.Code)
+		}
+	})
+
+	t.Run("Detail", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/admin/jobs/history/"+failed.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+
+		var resp struct {
+			Data struct {
+				Failures []jobFailure `json:"failures"`
+				Items    struct {
+					Ingestion []map[string]interface{} `json:"ingestion"`
+				} `json:"items"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Data.Items.Ingestion) != 1 || len(resp.Data.Failures) != 1 || resp.Data.Failures[0].Reason != "timeout" {
+			t.Errorf("expected item detail, got %+v", resp.Data)
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/admin/jobs/history/missing", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		server.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
 		}
 	})
 }
@@ -2,384 +2,346 @@ package httpapi
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
-	analysisDomain "ai-auto-trade/internal/domain/analysis"
+	"ai-auto-trade/internal/application/analysis"
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/jobs"
+	"ai-auto-trade/internal/application/scheduler"
+	"ai-auto-trade/internal/application/trading"
 	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+	"ai-auto-trade/internal/infrastructure/config"
 )
 
-func (s *Server) runAnalysisForDate(ctx context.Context, tradeDate time.Time) (analysisRunSummary, error) {
-	prices, err := s.dataRepo.PricesByDate(ctx, tradeDate)
-	if err != nil {
-		return analysisRunSummary{}, err
-	}
-	if len(prices) == 0 {
-		return analysisRunSummary{}, errNoPrices
-	}
+// runAnalysis 以 AnalyzeUseCase 分析觀察清單在各擷取週期的 K 線；完全沒有可分析的 K 線時回傳 errNoPrices。
+func (s *Server) runAnalysis(ctx context.Context, tradeDate time.Time) (analysisRunSummary, error) {
+	return s.runAnalysisUntil(ctx, tradeDate, time.Time{})
+}
 
-	summary := analysisRunSummary{total: len(prices)}
-	for _, p := range prices {
-		res := s.calculateAnalysis(ctx, p)
-		if err := s.dataRepo.InsertAnalysisResult(ctx, p.Symbol, res); err != nil {
-			summary.failure++
-		} else {
-			summary.success++
+// runAnalysisUntil 同 runAnalysis，until 非零時一併分析到 until（不含）為止的交易日，每個交易對只讀取一次歷史。
+func (s *Server) runAnalysisUntil(ctx context.Context, tradeDate, until time.Time) (analysisRunSummary, error) {
+	var summary analysisRunSummary
+	for _, tf := range s.ingestCfg.Timeframes {
+		res, err := s.analyzeUC.Execute(ctx, analysis.AnalyzeInput{
+			TradeDate: tradeDate,
+			Until:     until,
+			Timeframe: tf,
+			Symbols:   s.ingestCfg.Symbols,
+		})
+		if err != nil {
+			return summary, fmt.Errorf("analyze %s: %w", tf, err)
+		}
+		for _, f := range res.Failures {
+			log.Printf("[Analysis] %s %s %s failed: %s", tradeDate.Format("2006-01-02"), tf, f.Symbol, f.Reason)
 		}
+		summary.success += res.SuccessCount
+		summary.failure += res.FailedCount
+		summary.items = append(summary.items, res.Items...)
+	}
+	summary.total = summary.success + summary.failure
+	if summary.success == 0 {
+		return summary, errNoPrices
 	}
 	return summary, nil
 }
 
-// startAutoPipeline 每隔 autoInterval 自動跑當日 ingestion + analysis。
-func (s *Server) startAutoPipeline() {
+// startAutoPipeline 每隔 autoInterval 自動跑當日 ingestion + analysis，直到 ctx 結束。
+func (s *Server) startAutoPipeline(ctx context.Context) {
 	ticker := time.NewTicker(s.autoInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		s.runPipelineOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.runPipelineOnce(ctx)
+		}
+	}
+}
+
+// newScheduler 建立以 K 線收盤對齊的排程器，取代固定週期的 pipeline 與 worker：
+// 每次觸發先跑 ingestion → analysis，成功後才評估到期的策略。
+func (s *Server) newScheduler(cfg config.SchedulerConfig) (*scheduler.Scheduler, error) {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone %q: %w", cfg.Timezone, err)
+	}
+	pipelineSched, err := scheduler.ParseCron(cfg.PipelineCron, loc)
+	if err != nil {
+		return nil, err
+	}
+	window, err := scheduler.ParseTradingWindow(cfg.TradingWindow, loc)
+	if err != nil {
+		return nil, err
 	}
+	return scheduler.New(scheduler.Config{
+		Location:    loc,
+		SettleDelay: cfg.SettleDelay,
+		Pipeline: &scheduler.Task{
+			Name:     "pipeline",
+			Schedule: pipelineSched,
+			Run: func(ctx context.Context, _ time.Time) error {
+				return s.runPipelineOnce(ctx)
+			},
+		},
+	}, trading.StrategyTasks(s.tradingSvc, loc, window)), nil
 }
 
-// startConfigBackfill 依組態設定的起始日，啟動一次性補資料與分析（僅補尚未分析的日期）。
-func (s *Server) startConfigBackfill() {
+// startConfigBackfill 先接續中斷的回補工作；沒有進行中的工作且組態設定了起始日時，
+// 提交一個從起始日到現在的回補，只補尚未儲存的 K 線。
+func (s *Server) startConfigBackfill(ctx context.Context) {
+	if err := s.backfills.Resume(ctx); err != nil {
+		log.Printf("[Backfill] resume failed: %v", err)
+		return
+	}
+	if s.backfillStart == "" {
+		return
+	}
 	start, err := time.Parse("2006-01-02", s.backfillStart)
 	if err != nil {
 		log.Printf("[Backfill] Invalid backfill start date: %s", s.backfillStart)
 		return
 	}
-	end := time.Now()
+	if active, err := s.backfills.HasActive(ctx); err != nil || active {
+		return
+	}
+	job, err := s.backfills.Submit(ctx, dataingestion.BackfillJob{
+		TriggeredBy: "system",
+		Symbols:     s.ingestCfg.Symbols,
+		Timeframes:  s.ingestCfg.Timeframes,
+		Start:       start,
+		End:         time.Now(),
+		RunAnalysis: true,
+	})
+	if err != nil {
+		log.Printf("[Backfill] submit failed: %v", err)
+		return
+	}
+	log.Printf("[Backfill] Scanning from %s to now as job %s", s.backfillStart, job.ID)
+}
 
-	log.Printf("[Backfill] Scanning from %s to %s", s.backfillStart, end.Format("2006-01-02"))
+// analyzeBackfillDays 供回補工作分析新補的連續交易日 [from, to)。
+func (s *Server) analyzeBackfillDays(ctx context.Context, from, to time.Time) (int, int, error) {
+	summary, err := s.runAnalysisUntil(ctx, from, to)
+	return summary.success, summary.failure, err
+}
 
-	curr := start
-	for !curr.After(end) {
-		exists, _ := s.dataRepo.HasAnalysisForDate(context.Background(), curr)
-		if !exists {
-			log.Printf("[Backfill] Auto-filling for %s", curr.Format("2006-01-02"))
-			// Use strict version for backfill to ensure historical data integrity
-			if err := s.generateDailyPricesStrict(context.Background(), curr); err == nil {
-				_, _ = s.runAnalysisForDate(context.Background(), curr)
-			}
+// recordBackfill 在回補工作結束時寫入執行紀錄；系統觸發且沒有缺漏時不留紀錄，避免每次啟動都產生空的 job。
+func (s *Server) recordBackfill(ctx context.Context, job dataingestion.BackfillJob) {
+	missing, _, _ := job.Totals()
+	if job.TriggeredBy == "system" && missing == 0 && job.Status == dataingestion.BackfillCompleted {
+		return
+	}
+	run := s.newJobRun(ctx, "backfill", job.TriggeredBy, job.Start, job.End, job.RunAnalysis)
+	run.Start = job.CreatedAt
+	run.End = job.FinishedAt
+	run.Ingestion = backfillStage(job)
+	if job.RunAnalysis {
+		run.Analysis = jobs.Stage{
+			Total:   job.AnalysisSuccess + job.AnalysisFailure,
+			Success: job.AnalysisSuccess,
+			Failure: job.AnalysisFailure,
+			Error:   strings.Join(job.AnalysisErrors, "; "),
 		}
-		curr = curr.AddDate(0, 0, 1)
 	}
+	s.recordJob(context.WithoutCancel(ctx), run)
 }
 
-func (s *Server) runPipelineOnce() {
-	now := time.Now()
-	job := jobRun{
-		Kind:        "auto",
-		TriggeredBy: "system",
-		Start:       now,
+// backfillStage 將每個交易對 × 週期的回補結果轉為 ingestion 明細；未抓完的缺口計為失敗。
+func backfillStage(job dataingestion.BackfillJob) jobs.Stage {
+	stage := jobs.Stage{Error: job.Error}
+	if job.Status == dataingestion.BackfillCanceled && stage.Error == "" {
+		stage.Error = "canceled"
 	}
-
-	if err := s.generateDailyPrices(context.Background(), now); err != nil {
-		job.IngestionOK = false
-		job.IngestionErr = err.Error()
-	} else {
-		job.IngestionOK = true
-		summary, err := s.runAnalysisForDate(context.Background(), now)
-		if err != nil {
-			job.AnalysisOK = false
-			job.AnalysisErr = err.Error()
+	for _, t := range job.Tasks {
+		remaining := t.Missing - t.Processed
+		status := jobs.StatusOf(t.Stored, remaining, t.Error)
+		if status == jobs.StatusFailed {
+			stage.Failure++
 		} else {
-			job.AnalysisOK = true
-			job.AnalysisTotal = summary.total
-			job.AnalysisSucc = summary.success
-			job.AnalysisFail = summary.failure
+			stage.Success++
 		}
+		stage.Items = append(stage.Items, jobs.Item{
+			Symbol:      t.Symbol,
+			Timeframe:   t.Timeframe,
+			Status:      status,
+			ErrorReason: t.Error,
+		})
 	}
-
-	job.End = time.Now()
-	s.recordJob(job)
+	stage.Total = stage.Success + stage.Failure
+	return stage
 }
 
-// --- Calculation Internal Helpers ---
+// runPipelineOnce 依序執行當日 ingestion 與 analysis 並記錄 job；任一階段失敗即回傳錯誤。
+func (s *Server) runPipelineOnce(ctx context.Context) error {
+	now := time.Now()
+	run := s.newJobRun(ctx, "auto", "system", now, now, true)
 
-func (s *Server) generateDailyPrices(ctx context.Context, tradeDate time.Time) error {
-	if s.useSynthetic {
-		return s.generateSyntheticBTC(ctx, tradeDate)
-	}
-	series, err := s.fetchBTCSeries(ctx, tradeDate)
+	var runErr error
+	ingested, err := s.generateDailyPrices(ctx, now)
+	addIngestion(run, now, ingested, err)
 	if err != nil {
-		return err
+		runErr = fmt.Errorf("ingestion: %w", err)
+	} else {
+		summary, err := s.runAnalysis(ctx, now)
+		addAnalysis(run, now, summary, err)
+		if err != nil {
+			runErr = fmt.Errorf("analysis: %w", err)
+		}
 	}
-	return s.storeBTCSeries(ctx, series)
+
+	s.recordJob(ctx, run)
+	return runErr
 }
 
-func (s *Server) generateDailyPricesStrict(ctx context.Context, tradeDate time.Time) error {
-	series, err := s.fetchBTCSeries(ctx, tradeDate)
-	if err != nil {
-		return err
-	}
-	return s.storeBTCSeries(ctx, series)
+// --- Ingestion Helpers ---
+
+// generateDailyPrices 擷取當日觀察清單的 K 線；use_synthetic 時改寫入固定的假資料。
+func (s *Server) generateDailyPrices(ctx context.Context, tradeDate time.Time) (ingestRunSummary, error) {
+	return s.ingestPrices(ctx, tradeDate, dataingestion.IngestModeDaily, s.useSynthetic)
 }
 
-func (s *Server) storeBTCSeries(ctx context.Context, series []dataDomain.DailyPrice) error {
-	for _, p := range series {
-		stockID, err := s.dataRepo.UpsertTradingPair(ctx, p.Symbol, "Bitcoin", dataDomain.MarketCrypto, "Crypto")
+// ingestPrices 透過 IngestUseCase 擷取並寫入 K 線；僅部分交易對失敗時記錄後視為成功。
+func (s *Server) ingestPrices(ctx context.Context, tradeDate time.Time, mode dataingestion.IngestMode, synthetic bool) (ingestRunSummary, error) {
+	var summary ingestRunSummary
+	uc := dataingestion.NewIngestUseCase(syntheticPriceSource{}, priceStore{repo: s.dataRepo})
+	// 合成資料為固定的測試資料，只檢查交易所資料
+	if !synthetic {
+		klines, err := s.exchanges.KlineSource(s.ingestExchange)
 		if err != nil {
-			return err
+			return summary, err
 		}
-		if err := s.dataRepo.InsertDailyPrice(ctx, stockID, p); err != nil {
-			return err
+		src := dataingestion.NewKlinePriceSource(klines, s.ingestCfg)
+		src.SetStoredBars(s.dataRepo)
+		uc = dataingestion.NewIngestUseCase(src, priceStore{repo: s.dataRepo})
+		uc.SetQualityGate(s.quality)
+	}
+	res, err := uc.Execute(ctx, dataingestion.IngestInput{
+		Date:    tradeDate,
+		Mode:    mode,
+		Replace: true,
+	})
+	if err != nil {
+		return summary, err
+	}
+	summary = ingestRunSummary{success: res.SuccessCount, failure: res.FailedCount, items: res.Items}
+	for _, f := range res.Failures {
+		log.Printf("[Ingestion] %s %s %s failed: %s", tradeDate.Format("2006-01-02"), f.Symbol, f.Timeframe, f.Reason)
+	}
+	if res.QuarantinedCount > 0 {
+		log.Printf("[Ingestion] %s quarantined %d suspicious bars", tradeDate.Format("2006-01-02"), res.QuarantinedCount)
+	}
+	if res.SuccessCount == 0 {
+		if res.FailedCount > 0 {
+			return summary, fmt.Errorf("no kline data: %d failures, first: %s %s", res.FailedCount, res.Failures[0].Symbol, res.Failures[0].Reason)
 		}
+		return summary, errors.New("no kline data")
 	}
-	return nil
+	return summary, nil
 }
 
-// generateSyntheticBTC 為無法取數時的預設資料（含近 5 日）。
-func (s *Server) generateSyntheticBTC(ctx context.Context, tradeDate time.Time) error {
-	code := "BTCUSDT"
-	market := dataDomain.MarketCrypto
-	open, high, low, close := 50000.0, 51000.0, 49000.0, 50500.0
-	volume := int64(1000)
+// exchangeKlines 每次請求時才向註冊表取得擷取用交易所的 K 線來源，交易所設定可於執行期間變更。
+type exchangeKlines struct {
+	s *Server
+}
 
-	// history (5 days)
-	for i := 4; i >= 0; i-- {
-		d := tradeDate.AddDate(0, 0, -(i + 1))
-		stockID, err := s.dataRepo.UpsertTradingPair(ctx, code, code, market, "")
-		if err == nil {
-			price := dataDomain.DailyPrice{
-				Symbol:    code,
-				Market:    market,
-				TradeDate: d,
-				Open:      open - float64(5+i),
-				High:      high - float64(5+i),
-				Low:       low - float64(5+i),
-				Close:     close - float64(5+i),
-				Volume:    volume / 2,
-			}
-			if err := s.dataRepo.InsertDailyPrice(ctx, stockID, price); err != nil {
-				return err
-			}
-		}
-	}
-	// today
-	stockID, err := s.dataRepo.UpsertTradingPair(ctx, code, code, market, "")
+func (e exchangeKlines) FetchKlines(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]dataDomain.DailyPrice, error) {
+	klines, err := e.s.exchanges.KlineSource(e.s.ingestExchange)
 	if err != nil {
-		return err
-	}
-	price := dataDomain.DailyPrice{
-		Symbol:    code,
-		Market:    market,
-		TradeDate: tradeDate,
-		Open:      open,
-		High:      high,
-		Low:       low,
-		Close:     close,
-		Volume:    volume,
+		return nil, err
 	}
-	if err := s.dataRepo.InsertDailyPrice(ctx, stockID, price); err != nil {
-		return err
-	}
-	return nil
+	return klines.FetchKlines(ctx, symbol, timeframe, start, end)
 }
 
-func (s *Server) calculateAnalysis(ctx context.Context, p dataDomain.DailyPrice) analysisDomain.DailyAnalysisResult {
-	history, _ := s.dataRepo.PricesByPair(ctx, p.Symbol, p.Timeframe)
-	var ret5, ret20, ret60 *float64
-	var volumeRatio *float64
-	var changeRate float64
-	var ma20, rangePos, h20, l20 *float64
-	var deviation20 *float64
+// priceStore 讓 DataRepository 相容 dataingestion.PriceRepository：先確保交易對存在再寫入 K 線。
+// InsertDailyPrice 以 (交易對, timeframe, 時間) upsert，因此 replace 不影響結果。
+type priceStore struct {
+	repo DataRepository
+}
 
-	idx := -1
-	for i := len(history) - 1; i >= 0; i-- {
-		if history[i].TradeDate.Equal(p.TradeDate) {
-			idx = i
-			break
-		}
+func (p priceStore) UpsertDailyPrice(ctx context.Context, price dataDomain.DailyPrice, _ bool) error {
+	stockID, err := p.repo.UpsertTradingPair(ctx, price.Symbol, price.Symbol, price.Market, "Crypto")
+	if err != nil {
+		return err
 	}
+	return p.repo.InsertDailyPrice(ctx, stockID, price)
+}
 
-	if idx > 0 {
-		prev := history[idx-1]
-		if prev.Close > 0 {
-			changeRate = (p.Close - prev.Close) / prev.Close
-		}
-	}
+// closedBarRecorder 在行情串流收到收盤 K 線時，先經資料品質檢查寫入 K 線，
+// 再以策略使用的分析版本分析該交易對，讓收盤評估讀到這根 K 線的結果。
+type closedBarRecorder struct {
+	s *Server
+}
 
-	// Helper for pct return
-	pctRet := func(days int) *float64 {
-		if idx < days { return nil }
-		earlier := history[idx-days]
-		if earlier.Close <= 0 { return nil }
-		val := (p.Close / earlier.Close) - 1
-		return &val
+func (r closedBarRecorder) RecordClosedBar(ctx context.Context, c trading.Candle, versions []string) error {
+	price := dataDomain.DailyPrice{
+		Symbol:    c.Symbol,
+		Market:    dataDomain.MarketCrypto,
+		Timeframe: c.Timeframe,
+		TradeDate: c.OpenTime,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    int64(c.Volume),
 	}
-	ret5 = pctRet(5)
-	ret20 = pctRet(20)
-	ret60 = pctRet(60)
-
-	// Volume Ratio (20d avg)
-	if idx >= 19 {
-		var sumVol float64
-		for i := idx - 19; i <= idx; i++ {
-			sumVol += float64(history[i].Volume)
+	if r.s.quality != nil {
+		clean, quarantined, err := r.s.quality.Screen(ctx, []dataDomain.DailyPrice{price})
+		if err != nil {
+			return err
 		}
-		avg := sumVol / 20
-		if avg > 0 {
-			vr := float64(p.Volume) / avg
-			volumeRatio = &vr
+		if len(quarantined) > 0 || len(clean) == 0 {
+			return fmt.Errorf("bar %s quarantined by quality checks", c.OpenTime.Format(time.RFC3339))
 		}
 	}
-
-	// MA20 and Deviation
-	if idx >= 19 {
-		var sum float64
-		for i := idx - 19; i <= idx; i++ {
-			sum += history[i].Close
-		}
-		avg := sum / 20
-		ma20 = &avg
-		if avg > 0 {
-			dev := (p.Close - avg) / avg
-			deviation20 = &dev
-		}
+	if err := (priceStore{repo: r.s.dataRepo}).UpsertDailyPrice(ctx, price, true); err != nil {
+		return fmt.Errorf("store bar: %w", err)
 	}
-
-	// High/Low Range 20d
-	if idx >= 0 {
-		start := idx - 19
-		if start < 0 { start = 0 }
-		high, low := history[idx].High, history[idx].Low
-		for i := start; i <= idx; i++ {
-			if history[i].High > high { high = history[i].High }
-			if history[i].Low < low { low = history[i].Low }
+	for _, v := range versions {
+		res, err := r.s.analyzeUC.Execute(ctx, analysis.AnalyzeInput{
+			TradeDate: c.OpenTime,
+			Timeframe: c.Timeframe,
+			Symbols:   []string{c.Symbol},
+			Version:   v,
+		})
+		if err != nil {
+			return fmt.Errorf("analyze %s: %w", c.Symbol, err)
 		}
-		h20, l20 = &high, &low
-		if high != low {
-			val := (p.Close - low) / (high - low)
-			rangePos = &val
-		} else {
-			zero := 0.0
-			rangePos = &zero
+		for _, f := range res.Failures {
+			log.Printf("[MarketFeed] analyse %s %s (%s) failed: %s", f.Symbol, c.Timeframe, v, f.Reason)
 		}
 	}
-
-	res := analysisDomain.DailyAnalysisResult{
-		Symbol:         p.Symbol,
-		Market:         p.Market,
-		Timeframe:      p.Timeframe,
-		Industry:       "",
-		TradeDate:      p.TradeDate,
-		Version:        "v1-mvp",
-		Close:          p.Close,
-		ChangeRate:     changeRate,
-		Return5:        ret5,
-		Return20:       ret20,
-		Return60:       ret60,
-		Volume:         p.Volume,
-		VolumeMultiple: volumeRatio,
-		MA20:           ma20,
-		Deviation20:    deviation20,
-		High20:         h20,
-		Low20:          l20,
-		RangePos20:     rangePos,
-		Success:        true,
-	}
-	res.Score = simpleScore(res)
-	return res
-}
-
-func simpleScore(res analysisDomain.DailyAnalysisResult) float64 {
-	score := 50.0
-
-	if res.Return5 != nil {
-		score += clamp(*res.Return5*100, -20, 20) * 0.5
-	}
-	if res.Return20 != nil {
-		score += clamp(*res.Return20*100, -40, 40) * 0.4
-	}
-	if res.VolumeMultiple != nil {
-		score += clamp((*res.VolumeMultiple-1)*10, -10, 15)
-	}
-	if res.RangePos20 != nil {
-		score += (*res.RangePos20 - 0.5) * 10
-	}
-
-	if score < 0 { score = 0 }
-	if score > 100 { score = 100 }
-	return score
-}
-
-func clamp(v, min, max float64) float64 {
-	if v < min { return min }
-	if v > max { return max }
-	return v
+	return nil
 }
 
-// fetchBTCSeries 從 Binance 抓取 BTCUSDT 1d K 線，包含指定日期與前 5 日。
-func (s *Server) fetchBTCSeries(ctx context.Context, tradeDate time.Time) ([]dataDomain.DailyPrice, error) {
-	start := tradeDate.AddDate(0, 0, -5)
-	end := tradeDate.AddDate(0, 0, 1)
-	url := "https://api.binance.com/api/v3/klines?symbol=BTCUSDT&interval=1d&startTime=" +
-		strconv.FormatInt(start.UnixMilli(), 10) + "&endTime=" + strconv.FormatInt(end.UnixMilli(), 10)
+// syntheticPriceSource 為無法取數時的預設資料：BTCUSDT 指定日期與前 5 日的日 K。
+type syntheticPriceSource struct{}
 
-	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Do(req)
-
-		if err != nil {
-			lastErr = err
-		} else {
-			defer resp.Body.Close()
-			if resp.StatusCode != http.StatusOK {
-				body, _ := io.ReadAll(resp.Body)
-				lastErr = fmt.Errorf("binance response not ok: status %d, body: %s", resp.StatusCode, string(body))
-			} else {
-				var raw [][]interface{}
-				if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-					lastErr = err
-				} else {
-					var out []dataDomain.DailyPrice
-					for _, row := range raw {
-						if len(row) < 6 {
-							continue
-						}
-						openTime, ok := row[0].(float64)
-						if !ok {
-							continue
-						}
-						open, _ := strconv.ParseFloat(row[1].(string), 64)
-						high, _ := strconv.ParseFloat(row[2].(string), 64)
-						low, _ := strconv.ParseFloat(row[3].(string), 64)
-						closeP, _ := strconv.ParseFloat(row[4].(string), 64)
-						vol, _ := strconv.ParseFloat(row[5].(string), 64)
-
-						day := time.UnixMilli(int64(openTime)).UTC()
-						out = append(out, dataDomain.DailyPrice{
-							Symbol:    "BTCUSDT",
-							Market:    dataDomain.MarketCrypto,
-							Timeframe: "1d",
-							TradeDate: day,
-							Open:      open,
-							High:      high,
-							Low:       low,
-							Close:     closeP,
-							Volume:    int64(vol),
-						})
-					}
-					if len(out) == 0 {
-						lastErr = errors.New("no kline data")
-					} else {
-						return out, nil
-					}
-				}
-			}
+func (syntheticPriceSource) FetchDaily(_ context.Context, tradeDate time.Time, _ []string, _ *dataDomain.Market) ([]dataDomain.DailyPrice, error) {
+	open, high, low, close := 50000.0, 51000.0, 49000.0, 50500.0
+	volume := int64(1000)
+	bar := func(d time.Time, offset float64, vol int64) dataDomain.DailyPrice {
+		return dataDomain.DailyPrice{
+			Symbol:    "BTCUSDT",
+			Market:    dataDomain.MarketCrypto,
+			Timeframe: "1d",
+			TradeDate: d,
+			Open:      open - offset,
+			High:      high - offset,
+			Low:       low - offset,
+			Close:     close - offset,
+			Volume:    vol,
 		}
+	}
 
-		if attempt < 3 {
-			time.Sleep(time.Duration(attempt) * time.Second)
-		}
+	out := make([]dataDomain.DailyPrice, 0, 6)
+	for i := 4; i >= 0; i-- {
+		out = append(out, bar(tradeDate.AddDate(0, 0, -(i+1)), float64(5+i), volume/2))
 	}
-	return nil, lastErr
+	return append(out, bar(tradeDate, 0, volume)), nil
 }
@@ -19,7 +19,7 @@ func (s *Server) handleListPositions(c *gin.Context) {
 }
 
 func (s *Server) handlePositionClose(c *gin.Context, id string) {
-	if err := s.tradingSvc.ClosePositionManually(c.Request.Context(), id); err != nil {
+	if err := s.tradingSvc.ClosePositionManually(c.Request.Context(), id, currentUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "error_code": errCodeInternal})
 		return
 	}
@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-auto-trade/internal/application/dataingestion"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleQualityStatus 回傳各交易對 × 週期最新 K 線的品質狀態；未通過者策略不會執行。
+func (s *Server) handleQualityStatus(c *gin.Context) {
+	list, err := s.quality.Statuses(c.Request.Context())
+	if err != nil {
+		log.Printf("[Quality] list status failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
+	loc := taipeiLocation()
+	data := make([]map[string]interface{}, len(list))
+	for i, st := range list {
+		data[i] = map[string]interface{}{
+			"symbol":     st.Symbol,
+			"timeframe":  st.Timeframe,
+			"last_bar":   st.LastBar.In(loc).Format(time.RFC3339),
+			"passed":     st.Passed,
+			"issues":     st.Issues,
+			"checked_at": st.CheckedAt.In(loc).Format(time.RFC3339),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// handleQuarantineList 依 symbol/timeframe/status 篩選隔離的 K 線，預設只列待審。
+func (s *Server) handleQuarantineList(c *gin.Context) {
+	filter := dataingestion.QuarantineFilter{
+		Symbol:    strings.ToUpper(strings.TrimSpace(c.Query("symbol"))),
+		Timeframe: c.Query("timeframe"),
+		Status:    c.DefaultQuery("status", dataingestion.QuarantinePending),
+		Limit:     parseIntDefault(c.Query("limit"), 100),
+	}
+	switch filter.Status {
+	case "all":
+		filter.Status = ""
+	case dataingestion.QuarantinePending, dataingestion.QuarantineReleased, dataingestion.QuarantineRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid status", "error_code": errCodeBadRequest})
+		return
+	}
+	bars, err := s.quality.Quarantined(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("[Quality] list quarantine failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
+	loc := taipeiLocation()
+	data := make([]map[string]interface{}, len(bars))
+	for i, b := range bars {
+		data[i] = quarantinedBarToMap(b, loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// handleQuarantineResolve 審核隔離的 K 線：release 寫入資料庫，reject 標記為壞資料。
+func (s *Server) handleQuarantineResolve(release bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var (
+			bar *dataingestion.QuarantinedBar
+			err error
+		)
+		if release {
+			bar, err = s.quality.Release(ctx, c.Param("id"), currentUserID(c), priceStore{repo: s.dataRepo})
+		} else {
+			bar, err = s.quality.Reject(ctx, c.Param("id"), currentUserID(c))
+		}
+		switch {
+		case errors.Is(err, dataingestion.ErrQuarantineNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "quarantined bar not found", "error_code": errCodeNotFound})
+			return
+		case errors.Is(err, dataingestion.ErrQuarantineResolved):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "quarantined bar already resolved", "error_code": errCodeConflict})
+			return
+		case err != nil:
+			log.Printf("[Quality] resolve %s failed: %v", c.Param("id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "resolve failed", "error_code": errCodeInternal})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": quarantinedBarToMap(*bar, taipeiLocation())})
+	}
+}
+
+func quarantinedBarToMap(b dataingestion.QuarantinedBar, loc *time.Location) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":          b.ID,
+		"symbol":      b.Price.Symbol,
+		"timeframe":   b.Price.Timeframe,
+		"bar_time":    b.Price.TradeDate.In(loc).Format(time.RFC3339),
+		"open":        b.Price.Open,
+		"high":        b.Price.High,
+		"low":         b.Price.Low,
+		"close":       b.Price.Close,
+		"volume":      b.Price.Volume,
+		"issues":      b.Issues,
+		"status":      b.Status,
+		"created_at":  b.CreatedAt.In(loc).Format(time.RFC3339),
+		"resolved_by": optionalString(b.ResolvedBy),
+		"resolved_at": nil,
+	}
+	if !b.ResolvedAt.IsZero() {
+		out["resolved_at"] = b.ResolvedAt.In(loc).Format(time.RFC3339)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-auto-trade/internal/application/analysis"
+	"ai-auto-trade/internal/application/dataingestion"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
+)
+
+// resampleSources 為可用來產生較粗週期的已儲存週期，由粗到細嘗試：來源越粗需要讀取的 K 線越少。
+var resampleSources = []string{"4h", "1h", "15m", "5m", "1m"}
+
+// fillWarmupBars 為回測區間前額外產生的根數，與 AnalyzeUseCase 預設回看根數一致，讓區間開頭的指標完整。
+const fillWarmupBars = 120
+
+// historyFiller 讓回測在策略週期沒有分析資料時，由已儲存的較細週期 K 線重新取樣、寫入並分析。
+type historyFiller struct {
+	s *Server
+}
+
+func (h historyFiller) FillHistory(ctx context.Context, symbol, timeframe, version string, from, to time.Time) (bool, error) {
+	step := dataDomain.TimeframeDuration(timeframe)
+	if step == 0 {
+		return false, nil
+	}
+	warmup := from.Add(-fillWarmupBars * step)
+	for _, src := range resampleSources {
+		if !dataDomain.CanResample(src, timeframe) {
+			continue
+		}
+		probe, err := h.s.dataRepo.PriceRange(ctx, symbol, src, warmup, to, 1)
+		if err != nil {
+			return false, err
+		}
+		if len(probe) == 0 {
+			continue
+		}
+		stored, err := dataingestion.NewResampler(h.s.dataRepo, priceStore{repo: h.s.dataRepo}).Run(ctx, dataingestion.ResampleInput{
+			Symbol:    symbol,
+			Source:    src,
+			Timeframe: timeframe,
+			From:      warmup,
+			To:        to,
+		})
+		if err != nil {
+			return false, err
+		}
+		log.Printf("[Resample] %s %s derived %d bars from %s", symbol, timeframe, stored, src)
+		if stored == 0 {
+			return false, nil
+		}
+		return true, h.analyze(ctx, symbol, timeframe, version, from, to)
+	}
+	return false, nil
+}
+
+// analyze 以指定版本一次分析 [from, to) 內新產生的 K 線。
+func (h historyFiller) analyze(ctx context.Context, symbol, timeframe, version string, from, to time.Time) error {
+	_, err := h.s.analyzeUC.Execute(ctx, analysis.AnalyzeInput{
+		TradeDate: from,
+		Until:     to,
+		Timeframe: timeframe,
+		Symbols:   []string{symbol},
+		Version:   version,
+	})
+	if err != nil {
+		return fmt.Errorf("analyze %s %s: %w", symbol, timeframe, err)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package httpapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -9,14 +12,21 @@ import (
 
 	"ai-auto-trade/internal/application/analysis"
 	"ai-auto-trade/internal/application/auth"
+	"ai-auto-trade/internal/application/dataingestion"
+	"ai-auto-trade/internal/application/jobs"
+	"ai-auto-trade/internal/application/leader"
+	"ai-auto-trade/internal/application/scheduler"
 	appStrategy "ai-auto-trade/internal/application/strategy"
 	"ai-auto-trade/internal/application/trading"
 	authDomain "ai-auto-trade/internal/domain/auth"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
 	"ai-auto-trade/internal/infra/memory"
 	authinfra "ai-auto-trade/internal/infrastructure/auth"
 	"ai-auto-trade/internal/infrastructure/config"
 	"ai-auto-trade/internal/infrastructure/external/binance"
+	"ai-auto-trade/internal/infrastructure/external/bybit"
+	"ai-auto-trade/internal/infrastructure/external/exchange"
 	"ai-auto-trade/internal/infrastructure/notify"
 	"ai-auto-trade/internal/infrastructure/persistence/postgres"
 
@@ -53,17 +63,30 @@ type Server struct {
 	backfillStart string
 	tradingSvc    *trading.Service
 	tradingRepo   trading.Repository
-	jobMu         sync.Mutex
-	jobHistory    []jobRun
-	lastAutoRun   time.Time
+	jobStore      jobs.Store
+	backfills     *dataingestion.BackfillRunner
+	quality       *dataingestion.QualityGate
 	dataSource    string
 	presetStore   backtestPresetStore
 	scoringBtUC   *appStrategy.BacktestUseCase
 	saveScoringBtUC *appStrategy.SaveScoringStrategyUseCase
 	optimizeUC    *appStrategy.OptimizeScoringStrategyUseCase
+	divergenceUC  *appStrategy.DivergenceUseCase
 	analyzeUC     *analysis.AnalyzeUseCase
+	versions      analysis.VersionStore
+	versionUC     *analysis.VersionUseCase
 	binanceClient   *binance.Client
+	exchanges       *exchange.Registry
+	ingestExchange  string // K 線來源交易所
+	ingestCfg       dataingestion.KlinePriceSourceConfig
 	defaultEnv      tradingDomain.Environment
+	orderTracker    *trading.OrderTracker
+	marketFeed      *trading.MarketFeed
+	scheduler       *scheduler.Scheduler
+	elector         *leader.Elector
+	bgCtx           context.Context // 背景工作的根 context，Shutdown 時取消
+	bgCancel        context.CancelFunc
+	bgDone          chan struct{}
 
 
 	configMu       sync.Mutex
@@ -80,6 +103,10 @@ func NewServer(cfg config.Config, db *gorm.DB) *Server {
 	var sessionStore authDomain.SessionStore
 	var tradingRepo trading.Repository
 	var presetStore backtestPresetStore
+	var jobStore jobs.Store
+	var backfillStore dataingestion.BackfillStore
+	var qualityStore dataingestion.QualityStore
+	var versionStore analysis.VersionStore
 	if db != nil {
 		dataRepo = postgres.NewRepo(db)
 		repo := postgres.NewAuthRepo(db)
@@ -87,12 +114,20 @@ func NewServer(cfg config.Config, db *gorm.DB) *Server {
 		sessionStore = repo
 		tradingRepo = postgres.NewTradingRepo(db)
 		presetStore = postgres.NewBacktestPresetStore(db)
+		jobStore = postgres.NewJobStore(db)
+		backfillStore = postgres.NewBackfillStore(db)
+		qualityStore = postgres.NewQualityStore(db)
+		versionStore = postgres.NewVersionStore(db)
 	} else {
 		dataRepo = memoryRepoAdapter{store: store}
 		authRepo = store
 		sessionStore = store
 		tradingRepo = memory.NewTradingRepo()
 		presetStore = store
+		jobStore = store
+		backfillStore = store
+		qualityStore = store
+		versionStore = store
 	}
 
 	ttl := cfg.Auth.TokenTTL
@@ -126,6 +161,50 @@ func NewServer(cfg config.Config, db *gorm.DB) *Server {
 
 	tradingSvc := trading.NewService(tradingRepo, dataRepo, binanceAdapter, tgNotifier)
 
+	// 公開行情一律使用主網；bybit 僅在設定金鑰時提供下單。
+	exchanges := exchange.NewRegistry("binance")
+	exchanges.Register("binance", binanceAdapter, binance.NewKlineSource(binance.NewClient("", "", false)))
+	var bybitAdapter trading.Exchange
+	if cfg.Bybit.APIKey != "" {
+		bybitAdapter = bybit.NewExchangeAdapter(bybit.NewClient(cfg.Bybit.APIKey, cfg.Bybit.APISecret, cfg.Bybit.UseTestnet))
+	}
+	exchanges.Register("bybit", bybitAdapter, bybit.NewKlineSource(bybit.NewClient("", "", false)))
+	tradingSvc.SetExchangeResolver(exchanges)
+	tradingSvc.SetRiskLimits(trading.RiskLimits{
+		MaxOrderNotional:     cfg.Risk.MaxOrderNotional,
+		MaxAssetExposure:     cfg.Risk.MaxAssetExposure,
+		MaxDailyLoss:         cfg.Risk.MaxDailyLoss,
+		MaxOpenPositions:     cfg.Risk.MaxOpenPositions,
+		MaxPriceDeviationPct: cfg.Risk.MaxPriceDeviationPct,
+	})
+	haltStore, _ := tradingRepo.(trading.HaltStore)
+	breakers := trading.NewCircuitBreakers(haltStore, trading.BreakerConfig{
+		MaxConsecutiveLosses: cfg.Breaker.MaxConsecutiveLosses,
+		MaxDrawdownPct:       cfg.Breaker.MaxDrawdownPct,
+		MaxConsecutiveErrors: cfg.Breaker.MaxConsecutiveErrors,
+	})
+	if err := breakers.Load(context.Background()); err != nil {
+		println("warning: load trading halts failed:", err.Error())
+	}
+	tradingSvc.SetCircuitBreakers(breakers)
+	quality := dataingestion.NewQualityGate(dataRepo, qualityStore, dataDomain.QualityRules{
+		OutlierSigma:     cfg.Ingestion.Quality.OutlierSigma,
+		VolatilityWindow: cfg.Ingestion.Quality.VolatilityWindow,
+	})
+	tradingSvc.SetDataQualityGuard(quality)
+	tradingSvc.SetExecutorConfig(trading.ExecutorConfig{
+		Workers:    cfg.AutoTrade.Workers,
+		RunTimeout: cfg.AutoTrade.RunTimeout,
+	})
+	paperLedger, _ := tradingRepo.(trading.PaperLedger)
+	tradingSvc.SetPaperExchange(trading.NewPaperExchange(binanceAdapter, paperLedger, trading.PaperConfig{
+		QuoteAsset:     "USDT",
+		InitialBalance: cfg.Paper.InitialBalance,
+		FeeRate:        cfg.Paper.FeeRate,
+		SlippageBps:    cfg.Paper.SlippageBps,
+		ImpactBps:      cfg.Paper.ImpactBps,
+	}))
+
 	source := "binance"
 	if cfg.Ingestion.UseSynthetic {
 		source = "synthetic"
@@ -157,13 +236,35 @@ func NewServer(cfg config.Config, db *gorm.DB) *Server {
 		tradingRepo:   tradingRepo,
 		dataSource:    source,
 		presetStore:   presetStore,
+		jobStore:      jobStore,
 	}
 
 	s.scoringBtUC = appStrategy.NewBacktestUseCase(db, dataRepo)
+	s.scoringBtUC.SetHistoryFiller(historyFiller{s: s})
 	s.saveScoringBtUC = appStrategy.NewSaveScoringStrategyUseCase(db)
 	s.optimizeUC = appStrategy.NewOptimizeScoringStrategyUseCase(s.scoringBtUC, s.saveScoringBtUC)
+	s.divergenceUC = appStrategy.NewDivergenceUseCase(s.scoringBtUC, tradingSvc, cfg.Paper.FeeRate)
 	s.analyzeUC = analysis.NewAnalyzeUseCase(dataRepo, dataRepo, dataRepo)
+	s.analyzeUC.SetVersions(versionStore)
+	s.analyzeUC.SetScoreModels(analysis.NewFileModelLoader(cfg.Analysis.ModelDir))
+	s.versions = versionStore
+	s.versionUC = analysis.NewVersionUseCase(versionStore, s.analyzeUC, dataRepo)
 	s.binanceClient = binanceClient
+	s.exchanges = exchanges
+	s.ingestExchange = cfg.Ingestion.Exchange
+	s.ingestCfg = dataingestion.KlinePriceSourceConfig{
+		Symbols:    cfg.Ingestion.Symbols,
+		Timeframes: cfg.Ingestion.Timeframes,
+		Lookback:   cfg.Ingestion.LookbackBars,
+		Base:       cfg.Ingestion.ResampleFrom,
+	}
+	s.quality = quality
+	s.backfills = dataingestion.NewBackfillRunner(backfillStore, exchangeKlines{s: s}, dataRepo, priceStore{repo: dataRepo}, dataingestion.BackfillConfig{
+		Analyze:  s.analyzeBackfillDays,
+		Quality:  quality,
+		Base:     cfg.Ingestion.ResampleFrom,
+		OnFinish: s.recordBackfill,
+	})
 	s.defaultEnv = tradingDomain.EnvTest
 	if !cfg.Binance.UseTestnet {
 		s.defaultEnv = tradingDomain.EnvProd
@@ -183,19 +284,113 @@ func NewServer(cfg config.Config, db *gorm.DB) *Server {
 	if s.tgClient != nil && s.tgConfig.Enabled {
 	// go s.startTelegramJob() // 移除每小時摘要報告，只保留進出場通知
 	}
-	if s.autoInterval > 0 {
-		go s.startAutoPipeline()
+	if cfg.Scheduler.Enabled {
+		sched, err := s.newScheduler(cfg.Scheduler)
+		if err != nil {
+			println("warning: scheduler disabled:", err.Error())
+		}
+		s.scheduler = sched
 	}
-	if s.backfillStart != "" {
-		go s.startConfigBackfill()
+	if cfg.Binance.UserStream && cfg.Binance.APIKey != "" {
+		s.orderTracker = trading.NewOrderTracker(tradingRepo, liveEnvs(s.defaultEnv)...)
+		s.orderTracker.SetOrderLookup(binanceAdapter)
 	}
-	if cfg.AutoTrade.Interval > 0 {
-		worker := trading.NewBackgroundWorker(tradingSvc, cfg.AutoTrade.Interval)
-		worker.Start()
+	if cfg.Binance.MarketStream {
+		s.marketFeed = trading.NewMarketFeed(tradingSvc)
+		tradingSvc.SetBarRecorder(closedBarRecorder{s: s})
 	}
+	s.elector = s.newElector(cfg.Leader, func(ctx context.Context) { s.runLeaderJobs(ctx, cfg) })
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	s.bgCtx, s.bgCancel, s.bgDone = bgCtx, bgCancel, make(chan struct{})
+	go func() {
+		defer close(s.bgDone)
+		s.elector.Run(bgCtx)
+	}()
 	return s
 }
 
+// Shutdown 取消所有背景工作（排程、資料管線、串流與 worker）並釋放 leader 鎖，
+// 再等待進行中的下單寫完；ctx 為整體等待期限。
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.bgCancel()
+	// 回補工作保留檢查點，下次啟動時接續
+	stopped := make(chan struct{})
+	go func() {
+		s.backfills.Stop()
+		<-s.bgDone
+		close(stopped)
+	}()
+	var errs []error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("wait for background jobs: %w", ctx.Err()))
+	}
+	if err := s.tradingSvc.Drain(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// newElector 有資料庫時以 Postgres advisory lock 選舉，否則使用程序內的鎖（單實例即為 leader）。
+func (s *Server) newElector(cfg config.LeaderConfig, onElected func(ctx context.Context)) *leader.Elector {
+	if s.db != nil {
+		if sqlDB, err := s.db.DB(); err == nil {
+			return leader.NewElector(postgres.NewAdvisoryLock(sqlDB, cfg.LockKey), "postgres", cfg.Interval, onElected)
+		}
+	}
+	return leader.NewElector(leader.NewMemoryLock(), "memory", cfg.Interval, onElected)
+}
+
+// runLeaderJobs 啟動只能由單一實例執行的背景工作（排程、資料管線、交易 worker、串流），
+// 並在 ctx 結束（失去領導權）時等待它們停止。
+func (s *Server) runLeaderJobs(ctx context.Context, cfg config.Config) {
+	var wg sync.WaitGroup
+	spawn := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	// 非 leader 期間其他實例可能啟動了 kill switch 或熔斷
+	if err := s.tradingSvc.ReloadHaltStates(ctx); err != nil {
+		log.Printf("[Leader] reload trading halts failed: %v", err)
+	}
+	if s.scheduler != nil {
+		spawn(func() { _ = s.scheduler.Run(ctx) })
+	} else if s.autoInterval > 0 {
+		spawn(func() { s.startAutoPipeline(ctx) })
+	}
+	spawn(func() { s.startConfigBackfill(ctx) })
+	if s.orderTracker != nil {
+		stream := binance.NewUserStream(s.binanceClient, s.orderTracker)
+		spawn(func() { _ = stream.Run(ctx) })
+	}
+	if s.marketFeed != nil {
+		stream := binance.NewMarketStream(s.binanceClient, s.marketFeed, s.marketFeed.Subscriptions)
+		spawn(func() { _ = stream.Run(ctx) })
+	}
+	if cfg.AutoTrade.Interval > 0 && s.scheduler == nil {
+		worker := trading.NewBackgroundWorker(s.tradingSvc, cfg.AutoTrade.Interval)
+		worker.Start()
+		spawn(func() {
+			<-ctx.Done()
+			worker.Stop()
+		})
+	}
+	wg.Wait()
+}
+
+// liveEnvs 回傳與目前 Binance 帳戶對應的持倉環境。
+func liveEnvs(defaultEnv tradingDomain.Environment) []tradingDomain.Environment {
+	if defaultEnv == tradingDomain.EnvTest {
+		return []tradingDomain.Environment{tradingDomain.EnvTest}
+	}
+	return []tradingDomain.Environment{tradingDomain.EnvProd, tradingDomain.EnvReal}
+}
+
 // Handler 回傳路由處理器，供 HTTP server 掛載。
 func (s *Server) Handler() http.Handler {
 	return s.engine
@@ -229,12 +424,24 @@ func (s *Server) registerRoutes() {
 			{
 				ingest.POST("/daily", s.handleIngestionDaily)
 				ingest.POST("/backfill", s.handleIngestionBackfill)
+				ingest.GET("/backfill", s.handleBackfillList)
+				ingest.GET("/backfill/:id", s.handleBackfillStatus)
+				ingest.POST("/backfill/:id/cancel", s.handleBackfillCancel)
+				ingest.GET("/quality", s.handleQualityStatus)
+				ingest.GET("/quarantine", s.handleQuarantineList)
+				ingest.POST("/quarantine/:id/release", s.handleQuarantineResolve(true))
+				ingest.POST("/quarantine/:id/reject", s.handleQuarantineResolve(false))
+				ingest.POST("/import", s.handleIngestionImport)
+				ingest.GET("/export", s.handleIngestionExport)
 			}
 
 			analysisG := admin.Group("/analysis")
 			analysisG.Use(s.requireAuth(auth.PermAnalysisTriggerDaily))
 			{
 				analysisG.POST("/daily", s.handleAnalysisDaily)
+				analysisG.POST("/versions", s.handleCreateAnalysisVersion)
+				analysisG.POST("/versions/:name/activate", s.handleActivateAnalysisVersion)
+				analysisG.POST("/versions/:name/recompute", s.handleRecomputeAnalysisVersion)
 			}
 
 			jobs := admin.Group("/jobs")
@@ -242,6 +449,7 @@ func (s *Server) registerRoutes() {
 			{
 				jobs.GET("/status", s.handleJobsStatus)
 				jobs.GET("/history", s.handleJobsHistory)
+				jobs.GET("/history/:id", s.handleJobDetail)
 			}
 
 			strategies := admin.Group("/strategies")
@@ -265,7 +473,10 @@ func (s *Server) registerRoutes() {
 					instance.GET("/reports", func(c *gin.Context) { s.handleListReports(c, c.Param("id")) })
 					instance.POST("/reports", func(c *gin.Context) { s.handleCreateReport(c, c.Param("id")) })
 					instance.POST("/report-generate", func(c *gin.Context) { s.handleGenerateReport(c, c.Param("id")) })
+					instance.GET("/shadow-compare", func(c *gin.Context) { s.handleShadowCompare(c, c.Param("id")) })
+					instance.GET("/divergence", func(c *gin.Context) { s.handleDivergenceReport(c, c.Param("id")) })
 					instance.GET("/logs", func(c *gin.Context) { s.handleListLogs(c, c.Param("id")) })
+					instance.POST("/breaker/reset", func(c *gin.Context) { s.handleResetBreaker(c, c.Param("id")) })
 				}
 			}
 
@@ -276,6 +487,13 @@ func (s *Server) registerRoutes() {
 				trades.POST("/manual-buy", s.handleManualBuy)
 			}
 
+			tradingG := admin.Group("/trading")
+			tradingG.Use(s.requireAuth(auth.PermStrategy))
+			{
+				tradingG.GET("/kill-switch", s.handleGetKillSwitch)
+				tradingG.POST("/kill-switch", s.handleSetKillSwitch)
+			}
+
 			pos := admin.Group("/positions")
 			pos.Use(s.requireAuth(auth.PermStrategy))
 			{
@@ -302,6 +520,8 @@ func (s *Server) registerRoutes() {
 			analysisQuery.GET("/strategies/get", s.handleGetStrategyByQuery)
 			analysisQuery.POST("/strategies/save-scoring", s.handleCreateStrategy)
 			analysisQuery.GET("/history", s.handleAnalysisHistory)
+			analysisQuery.GET("/versions", s.handleListAnalysisVersions)
+			analysisQuery.GET("/versions/diff", s.handleAnalysisVersionDiff)
 			analysisQuery.GET("/summary", s.handleAnalysisSummary)
 			analysisQuery.POST("/backtest", s.handleAnalysisBacktest)
 			analysisQuery.POST("/backtest/slug", s.handleSlugBacktest)
@@ -10,6 +10,7 @@ import (
 	analysisDomain "ai-auto-trade/internal/domain/analysis"
 	dataDomain "ai-auto-trade/internal/domain/dataingestion"
 	"ai-auto-trade/internal/infra/memory"
+	"math"
 	"sort"
 )
 
@@ -21,6 +22,7 @@ const (
 	errCodeAnalysisNotReady   = "ANALYSIS_NOT_READY"
 	errCodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
 	errCodeNotFound           = "NOT_FOUND"
+	errCodeConflict           = "CONFLICT"
 	errCodeInternal           = "INTERNAL_ERROR"
 	refreshCookieName         = "refresh_token"
 )
@@ -32,14 +34,15 @@ type DataRepository interface {
 	analysis.AnalysisQueryRepository
 	UpsertTradingPair(ctx context.Context, pair, name string, market dataDomain.Market, industry string) (string, error)
 	InsertDailyPrice(ctx context.Context, stockID string, price dataDomain.DailyPrice) error
-	PricesByDate(ctx context.Context, date time.Time) ([]dataDomain.DailyPrice, error)
 	PricesByPair(ctx context.Context, pair string, timeframe string) ([]dataDomain.DailyPrice, error)
 	FindHistory(ctx context.Context, symbol string, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error)
+	FindVersionHistory(ctx context.Context, version, symbol, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error)
 	Get(ctx context.Context, symbol string, date time.Time, timeframe string) (analysisDomain.DailyAnalysisResult, error)
-	InsertAnalysisResult(ctx context.Context, stockID string, res analysisDomain.DailyAnalysisResult) error
-	HasAnalysisForDate(ctx context.Context, date time.Time) (bool, error)
+	BarTimes(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]time.Time, error)
+	PriceRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]dataDomain.DailyPrice, error)
+	AnalysisRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]analysisDomain.DailyAnalysisResult, error)
 	LatestAnalysisDate(ctx context.Context) (time.Time, error)
-	GetHistory(ctx context.Context, symbol string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error)
+	GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error)
 	ListBasicInfo(ctx context.Context, symbols []string, date time.Time) ([]analysis.BasicInfo, error)
 	SaveDailyResult(ctx context.Context, result analysisDomain.DailyAnalysisResult) error
 }
@@ -53,26 +56,57 @@ func (m memoryRepoAdapter) UpsertTradingPair(ctx context.Context, pair, name str
 	return m.store.UpsertTradingPair(pair, name, market, industry), nil
 }
 
+// InsertDailyPrice 依交易對、週期與開盤時間寫入 K 線。
 func (m memoryRepoAdapter) InsertDailyPrice(ctx context.Context, stockID string, price dataDomain.DailyPrice) error {
 	m.store.InsertDailyPrice(price)
 	return nil
 }
 
-func (m memoryRepoAdapter) PricesByDate(ctx context.Context, date time.Time) ([]dataDomain.DailyPrice, error) {
-	return m.store.PricesByDate(date), nil
+func (m memoryRepoAdapter) PricesByPair(ctx context.Context, pair string, timeframe string) ([]dataDomain.DailyPrice, error) {
+	return m.store.BarsByPair(pair, timeframe), nil
 }
 
-func (m memoryRepoAdapter) PricesByPair(ctx context.Context, pair string, timeframe string) ([]dataDomain.DailyPrice, error) {
-	return m.store.PricesByPair(pair), nil
+func (m memoryRepoAdapter) BarTimes(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]time.Time, error) {
+	var out []time.Time
+	for _, p := range m.store.BarsByPair(symbol, timeframe) {
+		if !p.TradeDate.Before(from) && p.TradeDate.Before(to) {
+			out = append(out, p.TradeDate)
+		}
+	}
+	return out, nil
 }
 
-func (m memoryRepoAdapter) InsertAnalysisResult(ctx context.Context, stockID string, res analysisDomain.DailyAnalysisResult) error {
-	m.store.InsertAnalysisResult(res)
-	return nil
+func (m memoryRepoAdapter) PriceRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]dataDomain.DailyPrice, error) {
+	var out []dataDomain.DailyPrice
+	for _, p := range m.store.BarsByPair(symbol, timeframe) {
+		if p.TradeDate.Before(from) || !p.TradeDate.Before(to) {
+			continue
+		}
+		if p.Timeframe == "" {
+			p.Timeframe = "1d"
+		}
+		if out = append(out, p); len(out) == limit {
+			break
+		}
+	}
+	return out, nil
 }
 
-func (m memoryRepoAdapter) HasAnalysisForDate(ctx context.Context, date time.Time) (bool, error) {
-	return m.store.HasAnalysisForDate(date), nil
+func (m memoryRepoAdapter) AnalysisRange(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]analysisDomain.DailyAnalysisResult, error) {
+	all, err := m.store.FindHistory(ctx, symbol, &from, nil, math.MaxInt, false)
+	if err != nil {
+		return nil, err
+	}
+	var out []analysisDomain.DailyAnalysisResult
+	for _, r := range all {
+		if !r.TradeDate.Before(to) {
+			break
+		}
+		if out = append(out, r); len(out) == limit {
+			break
+		}
+	}
+	return out, nil
 }
 
 func (m memoryRepoAdapter) LatestAnalysisDate(ctx context.Context) (time.Time, error) {
@@ -91,13 +125,20 @@ func (m memoryRepoAdapter) FindHistory(ctx context.Context, symbol string, timef
 	return m.store.FindHistory(ctx, symbol, from, to, limit, onlySuccess)
 }
 
+func (m memoryRepoAdapter) FindVersionHistory(ctx context.Context, version, symbol, timeframe string, from, to *time.Time, limit int, onlySuccess bool) ([]analysisDomain.DailyAnalysisResult, error) {
+	return m.store.FindVersionHistory(ctx, version, symbol, from, to, limit, onlySuccess)
+}
+
+func (m memoryRepoAdapter) TopSymbolsByVolume(ctx context.Context, since time.Time, n int) ([]string, error) {
+	return m.store.TopSymbolsByVolume(since, n), nil
+}
+
 func (m memoryRepoAdapter) Get(ctx context.Context, symbol string, date time.Time, timeframe string) (analysisDomain.DailyAnalysisResult, error) {
 	return m.store.Get(ctx, symbol, date)
 }
 
-func (m memoryRepoAdapter) GetHistory(ctx context.Context, symbol string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error) {
-	// Simple implementation for memory adapter
-	all := m.store.PricesByPair(symbol)
+func (m memoryRepoAdapter) GetHistory(ctx context.Context, symbol, timeframe string, endDate time.Time, lookback int) ([]dataDomain.DailyPrice, error) {
+	all := m.store.BarsByPair(symbol, timeframe)
 	var out []dataDomain.DailyPrice
 	for _, p := range all {
 		if !p.TradeDate.After(endDate) {
@@ -114,17 +155,25 @@ func (m memoryRepoAdapter) GetHistory(ctx context.Context, symbol string, endDat
 	sort.Slice(out, func(i, j int) bool {
 		return out[i].TradeDate.Before(out[j].TradeDate)
 	})
+	for i := range out {
+		if out[i].Timeframe == "" {
+			out[i].Timeframe = "1d"
+		}
+	}
 	return out, nil
 }
 
+// ListBasicInfo 記憶體模式沒有交易對主檔，市場別取自已寫入的 K 線。
 func (m memoryRepoAdapter) ListBasicInfo(ctx context.Context, symbols []string, date time.Time) ([]analysis.BasicInfo, error) {
-	// Memory store doesn't have a clean ListBasicInfo, return dummy
 	if len(symbols) == 0 {
 		return nil, nil
 	}
 	out := make([]analysis.BasicInfo, len(symbols))
 	for i, s := range symbols {
 		out[i] = analysis.BasicInfo{Symbol: s}
+		if prices := m.store.PricesByPair(s); len(prices) > 0 {
+			out[i].Market = prices[len(prices)-1].Market
+		}
 	}
 	return out, nil
 }
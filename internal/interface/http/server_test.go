@@ -9,6 +9,7 @@ import (
 
 	appAnalysis "ai-auto-trade/internal/application/analysis"
 	"ai-auto-trade/internal/domain/analysis"
+	dataDomain "ai-auto-trade/internal/domain/dataingestion"
 	"ai-auto-trade/internal/infrastructure/config"
 
 	"github.com/gin-gonic/gin"
@@ -103,13 +104,17 @@ func TestMemoryRepoAdapter(t *testing.T) {
 	t.Run("DataOps", func(t *testing.T) {
 		now := time.Now()
 		
-		repo.InsertAnalysisResult(ctx, "BTCUSDT", analysis.DailyAnalysisResult{
+		repo.SaveDailyResult(ctx, analysis.DailyAnalysisResult{
 			Symbol: "BTCUSDT", TradeDate: now, Close: 50000,
 		})
 		
-		has, _ := repo.HasAnalysisForDate(ctx, now)
-		if !has {
-			t.Error("HasAnalysisForDate failed")
+		day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		_ = repo.InsertDailyPrice(ctx, "BTCUSDT", dataDomain.DailyPrice{Symbol: "BTCUSDT", TradeDate: day, Timeframe: "1d", Close: 1})
+		if times, _ := repo.BarTimes(ctx, "BTCUSDT", "1d", day, day.AddDate(0, 0, 1)); len(times) != 1 {
+			t.Errorf("BarTimes failed: %v", times)
+		}
+		if times, _ := repo.BarTimes(ctx, "BTCUSDT", "1h", day, day.AddDate(0, 0, 1)); len(times) != 0 {
+			t.Errorf("expected no intraday bars in memory mode, got %v", times)
 		}
 		
 		_, err := repo.LatestAnalysisDate(ctx)
@@ -128,3 +133,29 @@ func TestMemoryRepoAdapter(t *testing.T) {
 		}
 	})
 }
+
+func TestServer_Shutdown(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Auth.Secret = "test-secret"
+	server := NewServer(cfg, nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !server.elector.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("single instance should become leader")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if server.elector.IsLeader() {
+		t.Fatal("leadership must be released on shutdown")
+	}
+	if _, _, err := server.tradingSvc.ExecuteManualBacktestBuy(context.Background(), "BTCUSDT", 10); err == nil {
+		t.Fatal("orders must be refused after shutdown")
+	}
+}
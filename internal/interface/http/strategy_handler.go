@@ -297,6 +297,61 @@ func (s *Server) handleGenerateReport(c *gin.Context, strategyID string) {
 	})
 }
 
+// handleShadowCompare 並排比較 shadow 環境的候選策略與 baseline 策略（預設同一策略的目前環境）。
+func (s *Server) handleShadowCompare(c *gin.Context, strategyID string) {
+	baselineID := c.DefaultQuery("baseline", strategyID)
+	baselineEnv := s.defaultEnv
+	if qenv := c.Query("baseline_env"); qenv != "" {
+		baselineEnv = tradingDomain.Environment(qenv)
+	}
+	start, end, err := s.parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	cmp, err := s.tradingSvc.CompareShadow(c.Request.Context(), strategyID, baselineID, baselineEnv, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "comparison": cmp})
+}
+
+// handleDivergenceReport 在策略上線期間重跑回測，逐筆比對實際成交的漏單、多單、滑價、手續費與延遲。
+func (s *Server) handleDivergenceReport(c *gin.Context, strategyID string) {
+	st, err := s.tradingSvc.GetStrategy(c.Request.Context(), strategyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "strategy not found", "error_code": errCodeNotFound})
+		return
+	}
+	strat, err := s.tradingSvc.LoadScoringStrategyByID(c.Request.Context(), strategyID)
+	if err != nil || strat == nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "scoring strategy not found", "error_code": errCodeNotFound})
+		return
+	}
+
+	env := s.defaultEnv
+	if qenv := c.Query("env"); qenv != "" {
+		env = tradingDomain.Environment(qenv)
+	}
+	start, end, err := s.parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	// 與報告相同：未指定開始時間時，從上次啟動時間開始
+	if c.Query("start_date") == "" && st.LastActivatedAt != nil {
+		start = *st.LastActivatedAt
+	}
+
+	rep, err := s.divergenceUC.Execute(c.Request.Context(), strat, env, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "error_code": errCodeInternal})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "report": rep})
+}
+
 func (s *Server) handleOptimizeStrategy(c *gin.Context) {
 	var body strategy.OptimizeRequest
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -1,8 +1,8 @@
 package httpapi
 
 import (
-	"time"
-
+	"ai-auto-trade/internal/application/analysis"
+	"ai-auto-trade/internal/application/dataingestion"
 	tradingDomain "ai-auto-trade/internal/domain/trading"
 )
 
@@ -10,31 +10,23 @@ type analysisRunSummary struct {
 	total   int
 	success int
 	failure int
+	items   []analysis.Item
 }
 
-type backfillFailure struct {
+type ingestRunSummary struct {
+	success int
+	failure int
+	items   []dataingestion.Item
+}
+
+type jobFailure struct {
 	TradeDate string `json:"trade_date"`
 	Stage     string `json:"stage"`
+	Symbol    string `json:"symbol,omitempty"`
+	Timeframe string `json:"timeframe,omitempty"`
 	Reason    string `json:"reason"`
 }
 
-type jobRun struct {
-	Kind          string
-	TriggeredBy   string
-	Start         time.Time
-	End           time.Time
-	IngestionOK   bool
-	IngestionErr  string
-	AnalysisOn    bool
-	AnalysisOK    bool
-	AnalysisTotal int
-	AnalysisSucc  int
-	AnalysisFail  int
-	AnalysisErr   string
-	Failures      []backfillFailure
-	DataSource    string
-}
-
 type strategyBacktestRequest struct {
 	StartDate       string                  `json:"start_date"`
 	EndDate         string                  `json:"end_date"`
@@ -0,0 +1,216 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"ai-auto-trade/internal/application/analysis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleListAnalysisVersions 回傳註冊的分析版本與啟用中的版本。
+func (s *Server) handleListAnalysisVersions(c *gin.Context) {
+	list, err := s.versionUC.List(c.Request.Context())
+	if err != nil {
+		log.Printf("[Analysis] list versions failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "query failed", "error_code": errCodeInternal})
+		return
+	}
+	data := make([]map[string]interface{}, len(list))
+	loc := taipeiLocation()
+	for i, v := range list {
+		data[i] = versionToMap(v, loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// handleCreateAnalysisVersion 註冊新的分析版本（不啟用），score_model 為模型目錄下的檔名。
+func (s *Server) handleCreateAnalysisVersion(c *gin.Context) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ScoreModel  string `json:"score_model"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid body", "error_code": errCodeBadRequest})
+		return
+	}
+	v, err := s.versionUC.Create(c.Request.Context(), analysis.Version{Name: body.Name, Description: body.Description, ScoreModel: body.ScoreModel})
+	if errors.Is(err, analysis.ErrVersionExists) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error(), "error_code": errCodeConflict})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": versionToMap(v, taipeiLocation())})
+}
+
+// handleActivateAnalysisVersion 切換啟用中的版本：之後的排程分析寫入此版本，未釘選版本的策略與查詢改讀此版本。
+func (s *Server) handleActivateAnalysisVersion(c *gin.Context) {
+	name := c.Param("name")
+	err := s.versionUC.Activate(c.Request.Context(), name)
+	if errors.Is(err, analysis.ErrVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "analysis version not found", "error_code": errCodeNotFound})
+		return
+	}
+	if errors.Is(err, analysis.ErrRecomputeRunning) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error(), "error_code": errCodeConflict})
+		return
+	}
+	if err != nil {
+		log.Printf("[Analysis] activate version %s failed: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "activate failed", "error_code": errCodeInternal})
+		return
+	}
+	log.Printf("[Analysis] Version %s activated by %s", name, currentUserID(c))
+	c.JSON(http.StatusOK, gin.H{"success": true, "version": name})
+}
+
+// handleRecomputeAnalysisVersion 於背景以指定版本重算 [start_date, end_date] 的分析結果，
+// 完成後寫入 kind=recompute 的 job 紀錄；其他版本的結果不受影響。
+func (s *Server) handleRecomputeAnalysisVersion(c *gin.Context) {
+	var body struct {
+		StartDate  string   `json:"start_date"`
+		EndDate    string   `json:"end_date"`
+		Symbols    []string `json:"symbols"`
+		Timeframes []string `json:"timeframes"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid body", "error_code": errCodeBadRequest})
+		return
+	}
+	start, err := time.Parse("2006-01-02", body.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid start_date", "error_code": errCodeBadRequest})
+		return
+	}
+	end, err := time.Parse("2006-01-02", body.EndDate)
+	if err != nil || end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid end_date", "error_code": errCodeBadRequest})
+		return
+	}
+	if len(body.Symbols) == 0 {
+		body.Symbols = s.ingestCfg.Symbols
+	}
+	if len(body.Timeframes) == 0 {
+		body.Timeframes = s.ingestCfg.Timeframes
+	}
+
+	// 重算不隨請求結束，但在伺服器關閉時取消
+	ctx := s.bgCtx
+	run := s.newJobRun(ctx, "recompute", currentUserID(c), start, end, true)
+	input := analysis.RecomputeInput{
+		Version:    c.Param("name"),
+		From:       start,
+		To:         end.AddDate(0, 0, 1), // 結束日含當天
+		Timeframes: body.Timeframes,
+		Symbols:    body.Symbols,
+	}
+	err = s.versionUC.StartRecompute(ctx, input, func(in analysis.RecomputeInput, res analysis.AnalyzeResult, err error) {
+		run.AnalysisVersion = in.Version
+		addAnalysis(run, in.From, analysisRunSummary{
+			total:   res.SuccessCount + res.FailedCount,
+			success: res.SuccessCount,
+			failure: res.FailedCount,
+			items:   res.Items,
+		}, err)
+		// 關閉時被取消的重算仍要留下紀錄
+		s.recordJob(context.WithoutCancel(ctx), run)
+		log.Printf("[Analysis] Recompute %s finished: %d success, %d failure", in.Version, res.SuccessCount, res.FailedCount)
+	})
+	switch {
+	case errors.Is(err, analysis.ErrVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "analysis version not found", "error_code": errCodeNotFound})
+		return
+	case errors.Is(err, analysis.ErrRecomputeRunning):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error(), "error_code": errCodeConflict})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	log.Printf("[Analysis] Recompute %s queued for %s to %s", input.Version, body.StartDate, body.EndDate)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success":    true,
+		"version":    input.Version,
+		"start_date": body.StartDate,
+		"end_date":   body.EndDate,
+		"symbols":    body.Symbols,
+		"timeframes": body.Timeframes,
+	})
+}
+
+// handleAnalysisVersionDiff 比較兩個版本在同一交易對與期間的分數。
+func (s *Server) handleAnalysisVersionDiff(c *gin.Context) {
+	base, target := c.Query("base"), c.Query("target")
+	if base == "" || target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "base and target required", "error_code": errCodeBadRequest})
+		return
+	}
+	start, end, err := s.parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+	in := analysis.VersionDiffInput{
+		Base:      base,
+		Target:    target,
+		Symbol:    s.getSymbol(c),
+		Timeframe: s.getTimeframe(c, "1d"),
+		From:      start,
+		To:        end,
+	}
+	diff, err := s.versionUC.Diff(c.Request.Context(), in)
+	if errors.Is(err, analysis.ErrVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error(), "error_code": errCodeNotFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "error_code": errCodeBadRequest})
+		return
+	}
+
+	loc := taipeiLocation()
+	changes := make([]map[string]interface{}, len(diff.Changes))
+	for i, ch := range diff.Changes {
+		changes[i] = map[string]interface{}{
+			"trade_date":   ch.TradeDate.In(loc).Format(time.RFC3339),
+			"base_score":   ch.BaseScore,
+			"target_score": ch.TargetScore,
+			"delta":        ch.Delta(),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"base":      in.Base,
+		"target":    in.Target,
+		"symbol":    in.Symbol,
+		"timeframe": in.Timeframe,
+		"summary": gin.H{
+			"compared":       diff.Compared,
+			"changed":        diff.Changed,
+			"mean_abs_delta": diff.MeanAbsDelta,
+			"max_abs_delta":  diff.MaxAbsDelta,
+			"missing_base":   diff.MissingBase,
+			"missing_target": diff.MissingTarget,
+		},
+		"changes": changes,
+	})
+}
+
+func versionToMap(v analysis.Version, loc *time.Location) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        v.Name,
+		"description": v.Description,
+		"score_model": v.ScoreModel,
+		"active":      v.Active,
+		"created_at":  v.CreatedAt.In(loc).Format(time.RFC3339),
+	}
+}
@@ -130,8 +130,8 @@ async function updateDashboard() {
         let latencyCount = 0;
 
         history.forEach(job => {
-          totalSucc += (job.analysis_succ || 0);
-          totalFail += (job.analysis_fail || 0);
+          totalSucc += (job.analysis?.success_count || 0);
+          totalFail += (job.analysis?.failure_count || 0);
           if (job.end && job.start) {
             const lat = new Date(job.end) - new Date(job.start);
             totalLatency += lat;
@@ -169,7 +169,7 @@ function renderJobHistory(jobs) {
 
   container.innerHTML = jobs.map(job => {
     const time = job.start ? new Date(job.start).toLocaleTimeString('zh-TW', { hour12: false }) : '--:--';
-    const isSuccess = job.analysis_ok && job.ingestion_ok;
+    const isSuccess = job.status === 'success';
     const statusClass = isSuccess ? 'bg-success/10 text-success border-success/20' : 'bg-danger/10 text-danger border-danger/20';
     const statusText = isSuccess ? 'Success' : 'Failed';
 